@@ -0,0 +1,155 @@
+// Command kubectl-vault_unseal is a kubectl plugin for inspecting VaultUnsealConfigs.
+//
+// Install the binary anywhere on PATH and run it as "kubectl vault-unseal".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/plugin"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const usage = `Usage: kubectl vault-unseal <command> [flags]
+
+Commands:
+  status                 List vault instances of every VaultUnsealConfig
+  describe <name>        Show the spec and status of a VaultUnsealConfig
+  force-reconcile <name> Ask the operator to reconcile a VaultUnsealConfig now
+
+Flags:
+`
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(vaultv1.AddToScheme(scheme))
+}
+
+// globalFlags are accepted by every command.
+type globalFlags struct {
+	Kubeconfig    string
+	Context       string
+	Namespace     string
+	AllNamespaces bool
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("kubectl vault-unseal", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	var flags globalFlags
+	fs.StringVar(&flags.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file.")
+	fs.StringVar(&flags.Context, "context", "", "The kubeconfig context to use.")
+	fs.StringVar(&flags.Namespace, "namespace", "", "The namespace to use (defaults to the kubeconfig namespace).")
+	fs.StringVar(&flags.Namespace, "n", "", "Shorthand for --namespace.")
+	fs.BoolVar(&flags.AllNamespaces, "all-namespaces", false, "List VaultUnsealConfigs in all namespaces.")
+	fs.BoolVar(&flags.AllNamespaces, "A", false, "Shorthand for --all-namespaces.")
+
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	c, namespace, err := newClient(&flags)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	p := plugin.New(c, stdout)
+
+	command, names := positional[0], positional[1:]
+	switch command {
+	case "status":
+		if flags.AllNamespaces {
+			namespace = ""
+		}
+		err = p.Status(ctx, namespace)
+	case "describe", "force-reconcile":
+		if len(names) != 1 {
+			_, _ = fmt.Fprintf(stderr, "error: %s requires exactly one VaultUnsealConfig name\n", command)
+			return 2
+		}
+		key := types.NamespacedName{Namespace: namespace, Name: names[0]}
+		if command == "describe" {
+			err = p.Describe(ctx, key)
+		} else {
+			err = p.ForceReconcile(ctx, key)
+		}
+	default:
+		_, _ = fmt.Fprintf(stderr, "error: unknown command %q\n", command)
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed parses flags that appear before, between or after positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newClient builds a typed client and resolves the namespace the same way kubectl does.
+func newClient(flags *globalFlags) (client.Client, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = flags.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: flags.Context}
+	overrides.Context.Namespace = flags.Namespace
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve namespace: %w", err)
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create client: %w", err)
+	}
+	return c, namespace, nil
+}
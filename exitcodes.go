@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	"github.com/panteparak/vault-autounseal-operator/pkg/features"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// Exit codes of the operator, telling wrappers and health tooling why it
+// stopped. ExitUsage (2) is returned for invalid flags and environment.
+const (
+	// ExitFailure is returned for failures without a code of their own.
+	ExitFailure = 1
+	// ExitNoKubeconfig is returned when neither an in-cluster config nor a
+	// kubeconfig is found.
+	ExitNoKubeconfig = 3
+	// ExitCRDNotInstalled is returned when a CRD the operator watches is missing.
+	ExitCRDNotInstalled = 4
+	// ExitManagerStartFailed is returned when the controller manager, its
+	// controllers or its servers cannot be set up or started.
+	ExitManagerStartFailed = 5
+	// ExitLeaderElectionLost is returned when the leader lease is lost; another
+	// replica takes over, so a restart is expected.
+	ExitLeaderElectionLost = 6
+)
+
+// errLeaderElectionLost is the message controller-runtime stops the manager
+// with when the lease is lost. It is not exported as an error value.
+const errLeaderElectionLost = "leader election lost"
+
+// exitError carries the exit code of the error it wraps.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so the process exits with code.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code of err: 0 for nil, the code of a wrapped
+// exitError, otherwise ExitFailure.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitFailure
+}
+
+// managerStartError classifies an error returned by the manager's Start.
+func managerStartError(err error) error {
+	if err != nil && strings.Contains(err.Error(), errLeaderElectionLost) {
+		return withExitCode(ExitLeaderElectionLost, err)
+	}
+	return withExitCode(ExitManagerStartFailed, fmt.Errorf("manager start failed: %w", err))
+}
+
+// requiredKinds returns the kinds watched by the controllers config enables.
+func requiredKinds(config *OperatorConfig, scope controller.NamespaceScope) []string {
+	kinds := []string{"VaultUnsealConfig", "VaultClusterDefaults", "VaultHealthCheck"}
+	if scope.ClusterWide() {
+		kinds = append(kinds, "VaultFleetStatus")
+	}
+	if config.FeatureGates.Enabled(features.Backups) || config.ManageNetworkPolicy {
+		kinds = append(kinds, "VaultBackupSchedule", "VaultRestore")
+	}
+	if config.AuditTTL > 0 {
+		kinds = append(kinds, "VaultUnsealAudit")
+	}
+	return kinds
+}
+
+// checkCRDs fails with ExitCRDNotInstalled when the API server does not serve
+// one of kinds, which would otherwise only surface as a cache sync timeout.
+func checkCRDs(mapper meta.RESTMapper, kinds []string) error {
+	var missing []string
+	for _, kind := range kinds {
+		gvk := vaultv1.GroupVersion.WithKind(kind)
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if !meta.IsNoMatchError(err) {
+				return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to discover %s: %w", kind, err))
+			}
+			missing = append(missing, kind)
+		}
+	}
+	if len(missing) > 0 {
+		return withExitCode(ExitCRDNotInstalled, fmt.Errorf(
+			"CRDs not installed for %s - apply manifests/crd.yaml or `manager manifests --components crd`",
+			strings.Join(missing, ", ")))
+	}
+	return nil
+}
@@ -2,26 +2,56 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/panteparak/vault-autounseal-operator/pkg/admin"
 	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/certs"
 	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	"github.com/panteparak/vault-autounseal-operator/pkg/features"
+	"github.com/panteparak/vault-autounseal-operator/pkg/flagenv"
+	"github.com/panteparak/vault-autounseal-operator/pkg/logging"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/tracing"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/webhook"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 const (
 	// SignalBufferSize is the buffer size for signal channel.
 	SignalBufferSize = 2
+
+	// EnvPrefix prefixes the environment variables setting the operator flags,
+	// e.g. OPERATOR_METRICS_BIND_ADDRESS for --metrics-bind-address.
+	EnvPrefix = "OPERATOR_"
 )
 
 var (
@@ -37,11 +67,67 @@ var (
 // OperatorConfig holds the configuration for the operator.
 type OperatorConfig struct {
 	MetricsAddr          string
+	MetricsSecure        bool
+	MetricsCertDir       string
 	ProbeAddr            string
 	EnableLeaderElection bool
 	ShowVersion          bool
 	HealthCheck          bool
 	Development          bool
+	AdminAddr            string
+	AdminTokenFile       string
+	ManageNetworkPolicy  bool
+	NetworkPolicyName    string
+	OperatorNamespace    string
+	OperatorPodLabels    string
+	ExportStateConfigMap bool
+	StateConfigMapName   string
+
+	MinConcurrentReconciles int
+	MaxConcurrentReconciles int
+	MaxConcurrentUnseals    int
+
+	LogSampleFirst     int
+	LogSampleEvery     int
+	LogSummaryInterval time.Duration
+
+	// LogLevel is the level of the operator log, changed at runtime by SIGHUP
+	// and the log level ConfigMap
+	LogLevel             uberzap.AtomicLevel
+	LogLevelConfigMap    string
+	LogLevelPollInterval time.Duration
+
+	AuditTTL          time.Duration
+	AuditMaxPerConfig int
+
+	ServiceMesh         string
+	SidecarReadyURL     string
+	SidecarReadyTimeout time.Duration
+
+	TracingEndpoint    string
+	TracingSampleRatio float64
+
+	KeyExecCommands  string
+	AgeIdentityFiles string
+	PKCS11Modules    string
+	PKCS11Tool       string
+	TransitAudience  string
+
+	InstanceMetricLabels string
+
+	SelfTest bool
+
+	FeatureGates *features.Gate
+
+	WatchNamespaces string
+
+	EnableWebhooks bool
+	WebhookPort    int
+	WebhookCertDir string
+
+	CertReloadInterval time.Duration
+
+	EventOptions *controller.EventOptions
 }
 
 // NewOperatorConfig creates a new operator configuration with defaults.
@@ -49,8 +135,39 @@ func NewOperatorConfig() *OperatorConfig {
 	return &OperatorConfig{
 		MetricsAddr:          ":8080",
 		ProbeAddr:            ":8081",
+		AdminAddr:            "0",
+		NetworkPolicyName:    controller.DefaultNetworkPolicyName,
+		StateConfigMapName:   controller.DefaultStateConfigMapName,
+		OperatorNamespace:    os.Getenv("POD_NAMESPACE"),
+		OperatorPodLabels:    "app.kubernetes.io/name=vault-autounseal-operator",
 		EnableLeaderElection: false,
 		Development:          true,
+
+		MinConcurrentReconciles: controller.DefaultMinConcurrentReconciles,
+		MaxConcurrentReconciles: controller.DefaultMaxConcurrentReconciles,
+
+		LogSampleFirst:     controller.DefaultLogSampleFirst,
+		LogSampleEvery:     controller.DefaultLogSampleEvery,
+		LogSummaryInterval: controller.DefaultLogSummaryInterval,
+
+		LogLevelPollInterval: logging.DefaultLevelPollInterval,
+
+		AuditMaxPerConfig: controller.DefaultAuditMaxPerConfig,
+
+		SidecarReadyTimeout: 2 * time.Minute,
+
+		TracingSampleRatio: tracing.DefaultSampleRatio,
+
+		PKCS11Tool:      controller.DefaultPKCS11Tool,
+		TransitAudience: controller.DefaultTransitAudience,
+
+		SelfTest: true,
+
+		FeatureGates: features.NewGate(),
+
+		WebhookPort: 9443,
+
+		EventOptions: controller.DefaultEventOptions(),
 	}
 }
 
@@ -60,6 +177,25 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == UnsealCommand {
+		ctx, cancel := setupSignalHandler()
+		code := runUnseal(ctx, os.Args[2:], os.Stdout, os.Stderr)
+		cancel()
+		os.Exit(code)
+	}
+	if len(os.Args) > 1 && os.Args[1] == UnsealOnceCommand {
+		ctx, cancel := setupSignalHandler()
+		code := runUnsealOnce(ctx, os.Args[2:], os.Stdout, os.Stderr)
+		cancel()
+		os.Exit(code)
+	}
+	if len(os.Args) > 1 && os.Args[1] == ValidateCommand {
+		os.Exit(runValidate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == ManifestsCommand {
+		os.Exit(runManifests(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	config := NewOperatorConfig()
 	parseFlags(config)
 
@@ -83,16 +219,19 @@ func runMain(config *OperatorConfig) int {
 
 	err := run(ctx, config)
 	if err != nil {
-		setupLog.Error(err, "operator failed")
-		return 1
+		setupLog.Error(err, "operator failed", "exitCode", exitCode(err))
 	}
-	return 0
+	return exitCode(err)
 }
 
 // parseFlags configures the operator config from command line flags.
 func parseFlags(config *OperatorConfig) {
 	flag.StringVar(&config.MetricsAddr, "metrics-bind-address", config.MetricsAddr,
 		"The address the metric endpoint binds to.")
+	flag.BoolVar(&config.MetricsSecure, "metrics-secure", config.MetricsSecure,
+		"Serve metrics over HTTPS, with a self-signed certificate unless --metrics-cert-dir is set.")
+	flag.StringVar(&config.MetricsCertDir, "metrics-cert-dir", config.MetricsCertDir,
+		"Directory holding tls.crt and tls.key of the metrics server, reloaded when they change.")
 	flag.StringVar(&config.ProbeAddr, "health-probe-bind-address", config.ProbeAddr,
 		"The address the probe endpoint binds to.")
 	flag.BoolVar(&config.EnableLeaderElection, "leader-elect", config.EnableLeaderElection,
@@ -101,13 +240,121 @@ func parseFlags(config *OperatorConfig) {
 	flag.BoolVar(&config.ShowVersion, "version", config.ShowVersion, "Show version information and exit.")
 	flag.BoolVar(&config.HealthCheck, "health-check", config.HealthCheck, "Perform health check and exit.")
 	flag.BoolVar(&config.Development, "development", config.Development, "Enable development mode for logging.")
+	flag.StringVar(&config.AdminAddr, "admin-bind-address", config.AdminAddr,
+		"The address the admin API binds to. Set to 0 to disable the admin API.")
+	flag.StringVar(&config.AdminTokenFile, "admin-token-file", config.AdminTokenFile,
+		"File holding the bearer token required by the admin API.")
+	flag.BoolVar(&config.ManageNetworkPolicy, "manage-network-policy", config.ManageNetworkPolicy,
+		"Maintain a NetworkPolicy restricting operator egress to DNS, the API server and the destinations of vault.io resources.")
+	flag.StringVar(&config.NetworkPolicyName, "network-policy-name", config.NetworkPolicyName,
+		"Name of the managed NetworkPolicy.")
+	flag.StringVar(&config.OperatorNamespace, "operator-namespace", config.OperatorNamespace,
+		"Namespace the operator runs in (defaults to $POD_NAMESPACE).")
+	flag.StringVar(&config.OperatorPodLabels, "operator-pod-labels", config.OperatorPodLabels,
+		"Comma-separated key=value labels selecting the operator pods in the managed NetworkPolicy.")
+	flag.BoolVar(&config.ExportStateConfigMap, "export-state-configmap", config.ExportStateConfigMap,
+		"Maintain a ConfigMap per namespace summarizing the seal state of its VaultUnsealConfigs.")
+	flag.StringVar(&config.StateConfigMapName, "state-configmap-name", config.StateConfigMapName,
+		"Name of the exported unseal state ConfigMap.")
+	flag.IntVar(&config.MinConcurrentReconciles, "min-concurrent-reconciles", config.MinConcurrentReconciles,
+		"Number of VaultUnsealConfigs reconciled at once while the work queue is idle.")
+	flag.IntVar(&config.MaxConcurrentReconciles, "max-concurrent-reconciles", config.MaxConcurrentReconciles,
+		"Upper bound of concurrent VaultUnsealConfig reconciles as the work queue grows. "+
+			"Set equal to the minimum for a fixed number of workers.")
+	flag.IntVar(&config.MaxConcurrentUnseals, "max-concurrent-unseals", config.MaxConcurrentUnseals,
+		"Unseal submissions in flight across all VaultUnsealConfigs; 0 does not limit them.")
+	flag.IntVar(&config.LogSampleFirst, "log-sample-first", config.LogSampleFirst,
+		"Occurrences of an error repeated for the same instance that are logged before sampling starts.")
+	flag.IntVar(&config.LogSampleEvery, "log-sample-every", config.LogSampleEvery,
+		"Log one in this many occurrences of a repeated error after the first ones; 0 logs every error.")
+	flag.DurationVar(&config.LogSummaryInterval, "log-summary-interval", config.LogSummaryInterval,
+		"Longest time a sampled error goes unlogged; its next log line reports the suppressed occurrences.")
+	flag.StringVar(&config.LogLevelConfigMap, "log-level-configmap", config.LogLevelConfigMap,
+		"ConfigMap in the operator namespace whose logLevel key sets the log level at runtime (empty disables).")
+	flag.DurationVar(&config.LogLevelPollInterval, "log-level-poll-interval", config.LogLevelPollInterval,
+		"How often the log level ConfigMap is read.")
+	flag.DurationVar(&config.AuditTTL, "unseal-audit-ttl", config.AuditTTL,
+		"Record each unseal attempt as a VaultUnsealAudit kept for this long; 0 does not record attempts.")
+	flag.IntVar(&config.AuditMaxPerConfig, "unseal-audit-max", config.AuditMaxPerConfig,
+		"VaultUnsealAudits kept per VaultUnsealConfig, the oldest deleted first; 0 keeps all within the TTL.")
+	flag.StringVar(&config.ServiceMesh, "service-mesh", config.ServiceMesh,
+		"Service mesh injecting a sidecar into the operator pod (istio or linkerd). "+
+			"The operator waits for the sidecar to be ready before it starts.")
+	flag.StringVar(&config.SidecarReadyURL, "sidecar-ready-url", config.SidecarReadyURL,
+		"Readiness endpoint of the sidecar to wait for, overriding the default of --service-mesh.")
+	flag.DurationVar(&config.SidecarReadyTimeout, "sidecar-ready-timeout", config.SidecarReadyTimeout,
+		"How long to wait for the service mesh sidecar before giving up.")
+	flag.StringVar(&config.TracingEndpoint, "tracing-endpoint", config.TracingEndpoint,
+		"URL of an OTLP/HTTP collector the traces of reconciles and Vault requests are exported to, "+
+			"e.g. http://otel-collector:4318. Tracing is disabled when empty.")
+	flag.Float64Var(&config.TracingSampleRatio, "tracing-sample-ratio", config.TracingSampleRatio,
+		"Share of reconciles traced, between 0 and 1.")
+	flag.StringVar(&config.KeyExecCommands, "key-exec-commands", config.KeyExecCommands,
+		"Comma-separated commands that exec key sources may run. Exec key sources are disabled when empty.")
+	flag.StringVar(&config.AgeIdentityFiles, "age-identity-files", config.AgeIdentityFiles,
+		"Comma-separated files of age identities decrypting the keys of instances with ageDecryption "+
+			"but no identitySecretRef.")
+	flag.StringVar(&config.PKCS11Modules, "pkcs11-modules", config.PKCS11Modules,
+		"Comma-separated PKCS#11 module paths that PKCS#11 key sources and decryption may load. "+
+			"PKCS#11 is disabled when empty.")
+	flag.StringVar(&config.PKCS11Tool, "pkcs11-tool", config.PKCS11Tool,
+		"OpenSC pkcs11-tool command driving the PKCS#11 modules.")
+	flag.StringVar(&config.TransitAudience, "transit-audience", config.TransitAudience,
+		"Audience of the service account tokens transitUnwrap logs in to management Vaults with, "+
+			"which their roles must expect. It must not be an audience the Kubernetes API server accepts.")
+	flag.StringVar(&config.InstanceMetricLabels, "instance-metric-labels", config.InstanceMetricLabels,
+		"Comma-separated instance label keys exported as labels of the instance metrics, e.g. environment,team.")
+	flag.StringVar(&config.WatchNamespaces, "watch-namespaces", config.WatchNamespaces,
+		"Comma-separated namespaces this instance reconciles, caches and reads Secrets from, "+
+			"so several instances can each serve their own tenants. Empty watches the whole cluster.")
+	flag.Var(config.FeatureGates, "feature-gates",
+		"Comma-separated Feature=true|false pairs enabling gated subsystems. Known gates:\n"+config.FeatureGates.Usage())
+	flag.BoolVar(&config.SelfTest, "self-test", config.SelfTest,
+		"Check on startup that keys are redacted from errors and that notification sinks and key sources "+
+			"are usable; readiness fails until they are.")
+	flag.BoolVar(&config.EnableWebhooks, "enable-webhooks", config.EnableWebhooks,
+		"Serve the validating webhook rejecting invalid VaultUnsealConfigs, e.g. dependency cycles.")
+	flag.IntVar(&config.WebhookPort, "webhook-port", config.WebhookPort,
+		"Port the webhook server listens on.")
+	flag.StringVar(&config.WebhookCertDir, "webhook-cert-dir", config.WebhookCertDir,
+		"Directory holding tls.crt and tls.key of the webhook server (defaults to the controller-runtime location).")
+	flag.DurationVar(&config.CertReloadInterval, "cert-reload-interval", config.CertReloadInterval,
+		"How often serving certificates are re-read besides watching their files (default 10s).")
+	flag.IntVar(&config.EventOptions.Burst, "event-burst", config.EventOptions.Burst,
+		"Number of events an object may emit before further events are throttled.")
+	flag.Float64Var(&config.EventOptions.QPS, "event-qps", config.EventOptions.QPS,
+		"Rate per second at which the event budget of an object refills.")
+	flag.IntVar(&config.EventOptions.AggregationMaxEvents, "event-aggregation-max-events",
+		config.EventOptions.AggregationMaxEvents,
+		"Number of similar events with different messages after which they are combined into one.")
+	flag.DurationVar(&config.EventOptions.AggregationInterval, "event-aggregation-interval",
+		config.EventOptions.AggregationInterval,
+		"Time after the last similar event before a new one starts a fresh aggregation.")
 
 	opts := zap.Options{
 		Development: config.Development,
 	}
 	opts.BindFlags(flag.CommandLine)
+	// Flags on the command line override the environment
+	if err := flagenv.Apply(flag.CommandLine, EnvPrefix); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitUsage)
+	}
 	flag.Parse()
 
+	// --zap-log-level sets an atomic level; otherwise use the default of zap.New
+	// as an atomic level, so it can be changed at runtime
+	level, ok := opts.Level.(uberzap.AtomicLevel)
+	if !ok {
+		initial := zapcore.InfoLevel
+		if opts.Development {
+			initial = zapcore.DebugLevel
+		}
+		level = uberzap.NewAtomicLevelAt(initial)
+		opts.Level = level
+	}
+	config.LogLevel = level
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 }
 
@@ -145,47 +392,227 @@ func run(ctx context.Context, config *OperatorConfig) error {
 		"metrics-addr", config.MetricsAddr,
 		"probe-addr", config.ProbeAddr,
 		"leader-election", config.EnableLeaderElection,
+		"admin-addr", config.AdminAddr,
+		"feature-gates", config.FeatureGates.String(),
 	)
 
+	if err := waitForSidecar(ctx, config); err != nil {
+		return err
+	}
+
+	if config.TracingEndpoint != "" {
+		shutdown, err := tracing.Setup(ctx, config.TracingEndpoint, config.TracingSampleRatio, version)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		defer func() {
+			// Flush the last spans; ctx is already done at shutdown
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(flushCtx); err != nil {
+				setupLog.Error(err, "failed to flush traces")
+			}
+		}()
+	}
+
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
-		return fmt.Errorf(
+		return withExitCode(ExitNoKubeconfig, fmt.Errorf(
 			"unable to get kubernetes config - ensure operator is running in cluster or has valid kubeconfig: %w",
-			err)
+			err))
+	}
+
+	certReloaders, err := setupCertReloaders(config)
+	if err != nil {
+		return withExitCode(ExitManagerStartFailed, err)
+	}
+
+	scope := controller.NewNamespaceScope(splitList(config.WatchNamespaces))
+	if config.ManageNetworkPolicy && !scope.Contains(config.OperatorNamespace) {
+		return withExitCode(ExitUsage, fmt.Errorf(
+			"--manage-network-policy needs the operator namespace %q in --watch-namespaces", config.OperatorNamespace))
 	}
 
 	mgr, err := ctrl.NewManager(kubeConfig, ctrl.Options{
-		Scheme:                 scheme,
-		Metrics:                server.Options{BindAddress: config.MetricsAddr},
-		HealthProbeBindAddress: config.ProbeAddr,
+		Scheme: scheme,
+		Metrics: server.Options{
+			BindAddress:   config.MetricsAddr,
+			SecureServing: config.MetricsSecure,
+			TLSOpts:       certReloaders.tlsOpts(certs.ServerMetrics),
+			// Latency exemplars are only exposed in the OpenMetrics format
+			ExtraHandlers: map[string]http.Handler{
+				metrics.OpenMetricsPath: metrics.OpenMetricsHandler(ctrlmetrics.Registry),
+			},
+		},
+		// The probes are served by the operator's own server, which adds /healthz/details
+		HealthProbeBindAddress: "0",
 		LeaderElection:         config.EnableLeaderElection,
-		LeaderElectionID:       "vault-autounseal-operator-leader",
+		// Instances of different scopes in one namespace each hold their own lease
+		LeaderElectionID: scope.LeaderElectionID("vault-autounseal-operator-leader"),
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    config.WebhookPort,
+			CertDir: config.WebhookCertDir,
+			TLSOpts: certReloaders.tlsOpts(certs.ServerWebhook),
+		}),
+		// Secrets are read with direct GETs and only watched as metadata, so
+		// key material is never cached in operator memory. ConfigMaps are
+		// handled the same way to avoid caching every ConfigMap of the cluster.
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}}},
+		},
+		Cache: cache.Options{DefaultNamespaces: scope.CacheNamespaces()},
+		// The uncached reads above bypass the cache, so the client itself refuses other namespaces
+		NewClient: func(kubeConfig *rest.Config, options client.Options) (client.Client, error) {
+			c, err := client.New(kubeConfig, options)
+			if err != nil {
+				return nil, err
+			}
+			return controller.NewScopedClient(c, scope), nil
+		},
+		// The manager lives as long as the process, so the broadcaster cannot leak
+		EventBroadcaster: controller.NewEventBroadcaster(config.EventOptions), //nolint:staticcheck
 	})
 	if err != nil {
-		return fmt.Errorf("unable to start manager: %w", err)
+		return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to start manager: %w", err))
+	}
+
+	if err := checkCRDs(mgr.GetRESTMapper(), requiredKinds(config, scope)); err != nil {
+		return err
+	}
+
+	for _, reloader := range certReloaders {
+		if err := mgr.Add(reloader); err != nil {
+			return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to add certificate reloader: %w", err))
+		}
 	}
 
-	if err := setupControllers(mgr); err != nil {
-		return fmt.Errorf("unable to setup controllers: %w", err)
+	probes := controller.NewProbeServer(mgr.GetClient())
+	if err := setupControllers(mgr, config, scope, probes); err != nil {
+		return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to setup controllers: %w", err))
 	}
 
-	if err := setupHealthChecks(mgr); err != nil {
-		return fmt.Errorf("unable to setup health checks: %w", err)
+	if err := setupHealthChecks(mgr, config, probes); err != nil {
+		return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to setup health checks: %w", err))
+	}
+
+	if err := setupAdminAPI(mgr, config); err != nil {
+		return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to setup admin API: %w", err))
+	}
+
+	if err := setupLogLevel(mgr, config); err != nil {
+		return withExitCode(ExitManagerStartFailed, fmt.Errorf("unable to setup runtime log level: %w", err))
 	}
 
 	setupLog.Info("starting vault auto-unseal operator manager")
 
 	if err := mgr.Start(ctx); err != nil {
-		return fmt.Errorf("manager start failed: %w", err)
+		return managerStartError(err)
 	}
 
 	return nil
 }
 
+// certReloaders holds the certificate reloader of each HTTPS server serving
+// certificates from mounted files.
+type certReloaders map[string]*certs.Reloader
+
+// setupCertReloaders loads the serving certificates of the webhook and metrics
+// servers, which are then reloaded whenever their files change.
+func setupCertReloaders(config *OperatorConfig) (certReloaders, error) {
+	reloaders := certReloaders{}
+	certMetrics := metrics.NewCertificateMetrics(ctrlmetrics.Registry)
+	logger := ctrl.Log.WithName("certs")
+
+	if config.EnableWebhooks {
+		dir := config.WebhookCertDir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+		}
+		reloader, err := certs.NewReloader(certs.ServerWebhook, dir, config.CertReloadInterval, certMetrics, logger)
+		if err != nil {
+			return nil, err
+		}
+		reloaders[certs.ServerWebhook] = reloader
+	}
+	if config.MetricsSecure && config.MetricsCertDir != "" {
+		reloader, err := certs.NewReloader(certs.ServerMetrics, config.MetricsCertDir, config.CertReloadInterval,
+			certMetrics, logger)
+		if err != nil {
+			return nil, err
+		}
+		reloaders[certs.ServerMetrics] = reloader
+	}
+	return reloaders, nil
+}
+
+// tlsOpts returns the TLS options serving the certificate of server, or none
+// to leave the server to its defaults.
+func (r certReloaders) tlsOpts(server string) []func(*tls.Config) {
+	reloader, ok := r[server]
+	if !ok {
+		return nil
+	}
+	return []func(*tls.Config){reloader.ConfigureTLS}
+}
+
+// waitForSidecar waits for the service mesh sidecar of the operator pod, whose
+// proxy carries the requests to the API server and Vault.
+func waitForSidecar(ctx context.Context, config *OperatorConfig) error {
+	url := config.SidecarReadyURL
+	if url == "" {
+		var err error
+		if url, err = controller.SidecarReadyURL(config.ServiceMesh); err != nil {
+			return fmt.Errorf("invalid --service-mesh: %w", err)
+		}
+	}
+	if url == "" {
+		return nil
+	}
+	return controller.WaitForSidecar(ctx, setupLog, url, config.SidecarReadyTimeout)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // setupControllers configures all controllers.
-func setupControllers(mgr ctrl.Manager) error {
-	clientRepository := controller.NewDefaultVaultClientRepository(nil)
+func setupControllers(
+	mgr ctrl.Manager,
+	config *OperatorConfig,
+	scope controller.NamespaceScope,
+	probes *controller.ProbeServer,
+) error {
+	requestMetrics := metrics.NewRequestMetrics(ctrlmetrics.Registry)
+	clientRepository := controller.NewDefaultVaultClientRepository(&vault.DefaultClientFactory{Metrics: requestMetrics})
+	clientRepository.SetClientMetrics(requestMetrics)
+	clientRepository.SetNamespaceScope(scope)
+	podExecutor, err := controller.NewPodExecutor(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create pod executor: %w", err)
+	}
+	clientRepository.SetPodExecutor(podExecutor)
+	podDialer, err := controller.NewPodDialer(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create pod dialer: %w", err)
+	}
+	clientRepository.SetPodDialer(podDialer)
 	reconcilerOptions := controller.DefaultReconcilerOptions()
+	reconcilerOptions.MinConcurrentReconciles = config.MinConcurrentReconciles
+	reconcilerOptions.MaxConcurrentReconciles = max(config.MinConcurrentReconciles, config.MaxConcurrentReconciles)
+	reconcilerOptions.MaxConcurrentUnseals = config.MaxConcurrentUnseals
+	reconcilerOptions.LogSampleFirst = config.LogSampleFirst
+	reconcilerOptions.LogSampleEvery = config.LogSampleEvery
+	reconcilerOptions.LogSummaryInterval = config.LogSummaryInterval
+	reconcilerOptions.AuditTTL = config.AuditTTL
+	reconcilerOptions.AuditMaxPerConfig = config.AuditMaxPerConfig
+	reconcilerOptions.Features = config.FeatureGates
 
 	reconciler := controller.NewVaultUnsealConfigReconciler(
 		mgr.GetClient(),
@@ -195,22 +622,182 @@ func setupControllers(mgr ctrl.Manager) error {
 		reconcilerOptions,
 	)
 
+	reconciler.Recorder = mgr.GetEventRecorderFor(controller.EventRecorderName)
+	reconciler.Resolver = net.DefaultResolver
+	reconciler.KeyCommands = splitList(config.KeyExecCommands)
+	reconciler.AgeIdentityFiles = splitList(config.AgeIdentityFiles)
+	reconciler.PKCS11Modules = splitList(config.PKCS11Modules)
+	reconciler.PKCS11Tool = config.PKCS11Tool
+	reconciler.TransitAudience = config.TransitAudience
+	instanceMetrics, err := metrics.NewInstanceMetrics(ctrlmetrics.Registry, splitList(config.InstanceMetricLabels))
+	if err != nil {
+		return fmt.Errorf("invalid --instance-metric-labels: %w", err)
+	}
+	reconciler.Metrics = instanceMetrics
+	reconciler.KeySourceMetrics = metrics.NewKeySourceMetrics(ctrlmetrics.Registry)
+
 	if err := reconciler.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("failed to setup reconciler: %w", err)
 	}
+	if config.SelfTest {
+		selfTest := controller.NewSelfTest(reconciler, ctrl.Log.WithName("self-test"), controller.DefaultSelfTestInterval)
+		if err := mgr.Add(selfTest); err != nil {
+			return fmt.Errorf("failed to add self-test: %w", err)
+		}
+		if err := probes.AddReadyzCheck("self-test", selfTest.Check); err != nil {
+			return fmt.Errorf("unable to set up self-test ready check: %w", err)
+		}
+	}
+
+	if config.FeatureGates.Enabled(features.Backups) {
+		backupReconciler := controller.NewVaultBackupScheduleReconciler(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("VaultBackupSchedule"),
+			mgr.GetScheme(),
+			controller.DefaultBackupReconcilerOptions(),
+		)
+
+		if err := backupReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup backup reconciler: %w", err)
+		}
+
+		restoreReconciler := controller.NewVaultRestoreReconciler(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("VaultRestore"),
+			mgr.GetScheme(),
+			controller.DefaultRestoreReconcilerOptions(),
+		)
+
+		if err := restoreReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup restore reconciler: %w", err)
+		}
+	}
+
+	healthCheckReconciler := controller.NewVaultHealthCheckReconciler(
+		mgr.GetClient(),
+		ctrl.Log.WithName("controllers").WithName("VaultHealthCheck"),
+		mgr.GetScheme(),
+		metrics.NewHealthCheckMetrics(ctrlmetrics.Registry),
+	)
+
+	if err := healthCheckReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to setup health check reconciler: %w", err)
+	}
+
+	// The fleet status is a cluster-wide singleton that scoped instances would overwrite in turn
+	if scope.ClusterWide() {
+		fleetStatusReconciler := controller.NewVaultFleetStatusReconciler(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("VaultFleetStatus"),
+			mgr.GetScheme(),
+		)
+
+		if err := fleetStatusReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup fleet status reconciler: %w", err)
+		}
+	}
+
+	if config.ManageNetworkPolicy {
+		podSelector, err := labels.ConvertSelectorToLabelsMap(config.OperatorPodLabels)
+		if err != nil {
+			return fmt.Errorf("invalid --operator-pod-labels: %w", err)
+		}
+
+		networkPolicyReconciler := controller.NewNetworkPolicyReconciler(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("NetworkPolicy"),
+			mgr.GetScheme(),
+			controller.NetworkPolicyOptions{
+				Namespace:   config.OperatorNamespace,
+				Name:        config.NetworkPolicyName,
+				PodSelector: podSelector,
+				Endpoints:   splitList(config.TracingEndpoint),
+			},
+		)
+
+		if err := networkPolicyReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup network policy reconciler: %w", err)
+		}
+	}
+
+	if config.ExportStateConfigMap {
+		stateConfigMapReconciler := controller.NewStateConfigMapReconciler(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("StateConfigMap"),
+			mgr.GetScheme(),
+			config.StateConfigMapName,
+		)
+
+		if err := stateConfigMapReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup state ConfigMap reconciler: %w", err)
+		}
+	}
+
+	if config.EnableWebhooks {
+		if err := (&webhook.VaultUnsealConfigValidator{}).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup VaultUnsealConfig webhook: %w", err)
+		}
+	}
 
 	return nil
 }
 
-// setupHealthChecks configures health and readiness checks.
-func setupHealthChecks(mgr ctrl.Manager) error {
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+// setupHealthChecks configures health and readiness checks and adds the
+// probe server to the manager unless it is disabled.
+func setupHealthChecks(mgr ctrl.Manager, config *OperatorConfig, probes *controller.ProbeServer) error {
+	if err := probes.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to set up health check: %w", err)
 	}
 
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := probes.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to set up ready check: %w", err)
 	}
 
-	return nil
+	if config.ProbeAddr == "" || config.ProbeAddr == "0" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", config.ProbeAddr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s for health probes: %w", config.ProbeAddr, err)
+	}
+	return mgr.Add(&manager.Server{
+		Name:     "health probe",
+		Server:   &http.Server{Handler: probes.Handler(), ReadHeaderTimeout: 10 * time.Second},
+		Listener: listener,
+	})
+}
+
+// setupLogLevel lets SIGHUP and the log level ConfigMap change the log level at runtime.
+func setupLogLevel(mgr ctrl.Manager, config *OperatorConfig) error {
+	configMap := types.NamespacedName{Namespace: config.OperatorNamespace, Name: config.LogLevelConfigMap}
+	if configMap.Name != "" && configMap.Namespace == "" {
+		return fmt.Errorf("--log-level-configmap requires --operator-namespace or $POD_NAMESPACE")
+	}
+	// ConfigMaps are not cached, so the client reads the ConfigMap directly
+	// The operator namespace may be outside --watch-namespaces
+	return mgr.Add(logging.NewRuntimeLevel(config.LogLevel, mgr.GetAPIReader(), configMap,
+		config.LogLevelPollInterval, ctrl.Log.WithName("log-level")))
+}
+
+// setupAdminAPI adds the admin API server to the manager unless it is disabled.
+func setupAdminAPI(mgr ctrl.Manager, config *OperatorConfig) error {
+	if config.AdminAddr == "" || config.AdminAddr == "0" {
+		return nil
+	}
+	if config.AdminTokenFile == "" {
+		return fmt.Errorf("--admin-token-file is required when the admin API is enabled")
+	}
+
+	token, err := os.ReadFile(config.AdminTokenFile)
+	if err != nil {
+		return fmt.Errorf("unable to read admin token: %w", err)
+	}
+
+	server, err := admin.NewServer(config.AdminAddr, strings.TrimSpace(string(token)), mgr.GetClient(),
+		ctrl.Log.WithName("admin"))
+	if err != nil {
+		return err
+	}
+
+	return mgr.Add(server)
 }
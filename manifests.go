@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/panteparak/vault-autounseal-operator/manifests"
+)
+
+// ManifestsCommand prints the manifests embedded in the operator binary, for
+// installations without Helm.
+const ManifestsCommand = "manifests"
+
+// runManifests writes the selected embedded manifests to stdout and returns
+// an exit code.
+func runManifests(args []string, stdout, stderr io.Writer) int {
+	components := strings.Join(manifests.DefaultComponents, ",")
+	namespace := manifests.DefaultNamespace
+	image := ""
+	if version != "dev" {
+		image = manifests.ImageRepository + ":" + version
+	}
+
+	fs := flag.NewFlagSet(ManifestsCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&components, "components", components, fmt.Sprintf(
+		"Comma-separated manifests to print, of %s, or 'all'.", strings.Join(manifests.Components, ", ")))
+	fs.StringVar(&namespace, "namespace", namespace, "Namespace to install the operator into.")
+	fs.StringVar(&image, "image", image,
+		"Operator image of the Deployment (defaults to the image of this binary's version).")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s %s [flags]\n\n", os.Args[0], ManifestsCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	selected := manifests.Components
+	if components != "all" {
+		selected = strings.Split(components, ",")
+		for i := range selected {
+			selected[i] = strings.TrimSpace(selected[i])
+		}
+	}
+	out, err := manifests.Render(selected, manifests.Options{Namespace: namespace, Image: image})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%s: %v\n", ManifestsCommand, err)
+		return ExitUsage
+	}
+	if _, err := stdout.Write(out); err != nil {
+		_, _ = fmt.Fprintf(stderr, "%s: %v\n", ManifestsCommand, err)
+		return 1
+	}
+	return 0
+}
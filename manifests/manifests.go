@@ -0,0 +1,99 @@
+// Package manifests embeds the plain Kubernetes manifests of this directory,
+// so the operator binary prints the CRDs, RBAC and webhook configuration
+// matching the API types it was built with.
+package manifests
+
+import (
+	"embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:embed crd.yaml rbac.yaml webhook.yaml deployment.yaml
+var files embed.FS
+
+const (
+	// CRDs holds the CustomResourceDefinitions of the vault.io API group.
+	CRDs = "crd"
+	// RBAC holds the Namespace, ServiceAccount and roles of the operator.
+	RBAC = "rbac"
+	// Webhook holds the Service and ValidatingWebhookConfiguration of the webhook.
+	Webhook = "webhook"
+	// Deployment holds the operator Deployment.
+	Deployment = "deployment"
+
+	// DefaultNamespace is the namespace the embedded manifests install into.
+	DefaultNamespace = "vault-operator"
+
+	// ImageRepository is the image of the embedded Deployment.
+	ImageRepository = "ghcr.io/panteparak/vault-autounseal-operator"
+)
+
+// Components lists the manifests in the order they are applied.
+var Components = []string{CRDs, RBAC, Webhook, Deployment}
+
+// DefaultComponents are the manifests printed without a selection. The
+// Deployment is left out, as its flags are usually tailored.
+var DefaultComponents = []string{CRDs, RBAC, Webhook}
+
+var (
+	namespacePattern = regexp.MustCompile(`(?m)^(\s*namespace: )` + DefaultNamespace + `$`)
+	// The Namespace object itself and the cert-manager CA injection annotation
+	namespaceNamePattern = regexp.MustCompile(`(?m)^(kind: Namespace\nmetadata:\n  name: )` + DefaultNamespace + `$`)
+	injectCAPattern      = regexp.MustCompile(`(inject-ca-from: )` + DefaultNamespace + `/`)
+	imagePattern         = regexp.MustCompile(`(?m)^(\s*image: )` + regexp.QuoteMeta(ImageRepository) + `:\S+$`)
+)
+
+// Options adjust the embedded manifests to an installation.
+type Options struct {
+	// Namespace replaces DefaultNamespace; empty keeps it.
+	Namespace string
+	// Image replaces the Deployment image; empty keeps it.
+	Image string
+}
+
+// Render returns the manifests of components as one multi-document YAML
+// stream, in the order of Components.
+func Render(components []string, options Options) ([]byte, error) {
+	selected := make(map[string]bool, len(components))
+	for _, component := range components {
+		if !slices.Contains(Components, component) {
+			return nil, fmt.Errorf("unknown component %q, expected one of %s", component, strings.Join(Components, ", "))
+		}
+		selected[component] = true
+	}
+
+	var out strings.Builder
+	for _, component := range Components {
+		if !selected[component] {
+			continue
+		}
+		data, err := files.ReadFile(component + ".yaml")
+		if err != nil {
+			return nil, err
+		}
+		if out.Len() > 0 {
+			out.WriteString("---\n")
+		}
+		out.WriteString(rewrite(string(data), options))
+	}
+	return []byte(out.String()), nil
+}
+
+// rewrite applies options to one manifest file.
+func rewrite(manifest string, options Options) string {
+	if options.Namespace != "" && options.Namespace != DefaultNamespace {
+		manifest = namespacePattern.ReplaceAllString(manifest, "${1}"+options.Namespace)
+		manifest = namespaceNamePattern.ReplaceAllString(manifest, "${1}"+options.Namespace)
+		manifest = injectCAPattern.ReplaceAllString(manifest, "${1}"+options.Namespace+"/")
+	}
+	if options.Image != "" {
+		manifest = imagePattern.ReplaceAllString(manifest, "${1}"+options.Image)
+	}
+	if !strings.HasSuffix(manifest, "\n") {
+		manifest += "\n"
+	}
+	return manifest
+}
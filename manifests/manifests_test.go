@@ -0,0 +1,156 @@
+package manifests
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRender(t *testing.T) {
+	out, err := Render([]string{Deployment, CRDs}, Options{
+		Namespace: "ops",
+		Image:     ImageRepository + ":1.2.0",
+	})
+	require.NoError(t, err)
+
+	docs := strings.Split(string(out), "\n---\n")
+	assert.Contains(t, docs[0], "kind: CustomResourceDefinition")
+	assert.Contains(t, docs[len(docs)-1], "kind: Deployment")
+	assert.Contains(t, string(out), "  namespace: ops\n")
+	assert.NotContains(t, string(out), "namespace: "+DefaultNamespace)
+	assert.Contains(t, string(out), "image: "+ImageRepository+":1.2.0\n")
+
+	out, err = Render(DefaultComponents, Options{Namespace: "ops"})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "kind: Namespace\nmetadata:\n  name: ops\n")
+	assert.Contains(t, string(out), "inject-ca-from: ops/vault-autounseal-operator-webhook")
+	assert.NotContains(t, string(out), "kind: Deployment")
+
+	_, err = Render([]string{"crds"}, Options{})
+	assert.ErrorContains(t, err, `unknown component "crds"`)
+}
+
+// TestCRDsMatchTypes checks that every field of the API types is in the
+// schema of its embedded CRD, so the printed CRDs never prune fields the
+// binary sets.
+func TestCRDsMatchTypes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, vaultv1.AddToScheme(scheme))
+
+	data, err := files.ReadFile(CRDs + ".yaml")
+	require.NoError(t, err)
+	kinds := map[string]bool{}
+	for _, doc := range strings.Split(string(data), "\n---\n") {
+		var crd crdDocument
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &crd))
+		if crd.Spec.Names.Kind == "" {
+			continue
+		}
+		kinds[crd.Spec.Names.Kind] = true
+
+		obj, err := scheme.New(vaultv1.GroupVersion.WithKind(crd.Spec.Names.Kind))
+		require.NoError(t, err, crd.Metadata.Name)
+		require.Len(t, crd.Spec.Versions, 1, crd.Metadata.Name)
+		schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+		objType := reflect.TypeOf(obj).Elem()
+		for _, field := range []string{"Spec", "Status"} {
+			goField, ok := objType.FieldByName(field)
+			if !ok {
+				continue
+			}
+			name := jsonName(goField)
+			property, ok := schema.Properties[name]
+			if !assert.True(t, ok, "%s: .%s missing", crd.Metadata.Name, name) {
+				continue
+			}
+			assertSchemaCovers(t, crd.Metadata.Name+"."+name, goField.Type, property)
+		}
+	}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.GroupVersion() == vaultv1.GroupVersion && strings.HasPrefix(gvk.Kind, "Vault") &&
+			!strings.HasSuffix(gvk.Kind, "List") {
+			assert.True(t, kinds[gvk.Kind], "%s has no CRD", gvk.Kind)
+		}
+	}
+}
+
+// crdDocument holds the parts of a CustomResourceDefinition the test reads.
+type crdDocument struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Schema struct {
+				OpenAPIV3Schema schemaProps `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// schemaProps is the structure of an OpenAPI schema. additionalProperties is
+// either a schema or a boolean.
+type schemaProps struct {
+	Properties            map[string]schemaProps `json:"properties"`
+	Items                 *schemaProps           `json:"items"`
+	AdditionalProperties  json.RawMessage        `json:"additionalProperties"`
+	PreserveUnknownFields bool                   `json:"x-kubernetes-preserve-unknown-fields"`
+}
+
+// assertSchemaCovers walks the JSON fields of goType and asserts each one is
+// a property of schema.
+func assertSchemaCovers(t *testing.T, path string, goType reflect.Type, schema schemaProps) {
+	t.Helper()
+	for goType.Kind() == reflect.Pointer {
+		goType = goType.Elem()
+	}
+	if schema.PreserveUnknownFields {
+		return
+	}
+	switch goType.Kind() {
+	case reflect.Slice:
+		if goType.Elem().Kind() != reflect.Uint8 && schema.Items != nil {
+			assertSchemaCovers(t, path+"[]", goType.Elem(), *schema.Items)
+		}
+	case reflect.Map:
+		var values schemaProps
+		if json.Unmarshal(schema.AdditionalProperties, &values) == nil {
+			assertSchemaCovers(t, path+"{}", goType.Elem(), values)
+		}
+	case reflect.Struct:
+		// Types of other packages, like metav1.Time, are not objects in the schema
+		if goType.PkgPath() != reflect.TypeOf(vaultv1.VaultUnsealConfig{}).PkgPath() {
+			return
+		}
+		for i := range goType.NumField() {
+			field := goType.Field(i)
+			name := jsonName(field)
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" && field.Anonymous {
+				assertSchemaCovers(t, path, field.Type, schema)
+				continue
+			}
+			property, ok := schema.Properties[name]
+			if assert.True(t, ok, "%s.%s is not in the CRD schema", path, name) {
+				assertSchemaCovers(t, path+"."+name, field.Type, property)
+			}
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,313 @@
+// Package admin serves the operator's authenticated HTTP API for on-demand actions.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultShutdownTimeout bounds the graceful shutdown of the server.
+	DefaultShutdownTimeout = 10 * time.Second
+	// DefaultReadHeaderTimeout bounds reading the request headers.
+	DefaultReadHeaderTimeout = 10 * time.Second
+)
+
+// ConfigStatus is the aggregated state of a VaultUnsealConfig returned by the status endpoint.
+type ConfigStatus struct {
+	Namespace          string                        `json:"namespace"`
+	Name               string                        `json:"name"`
+	Generation         int64                         `json:"generation"`
+	Ready              bool                          `json:"ready"`
+	TotalInstances     int                           `json:"totalInstances"`
+	SealedInstances    int                           `json:"sealedInstances"`
+	ReconcileRequested string                        `json:"reconcileRequestedAt,omitempty"`
+	Instances          []vaultv1.VaultInstanceStatus `json:"instances"`
+	Conditions         []metav1.Condition            `json:"conditions"`
+}
+
+// InstanceSummary is the state of one managed vault instance returned by the instances endpoint.
+type InstanceSummary struct {
+	Namespace        string       `json:"namespace"`
+	UnsealConfig     string       `json:"unsealConfig"`
+	Name             string       `json:"name"`
+	Endpoint         string       `json:"endpoint,omitempty"`
+	Sealed           bool         `json:"sealed"`
+	Reason           string       `json:"reason,omitempty"`
+	LastError        string       `json:"lastError,omitempty"`
+	LastUnsealed     *metav1.Time `json:"lastUnsealed,omitempty"`
+	LastSealDetected *metav1.Time `json:"lastSealDetected,omitempty"`
+}
+
+// InstancesResponse lists the managed vault instances.
+type InstancesResponse struct {
+	Instances []InstanceSummary `json:"instances"`
+}
+
+// ReconcileResponse is returned when a reconcile was requested.
+type ReconcileResponse struct {
+	Namespace   string    `json:"namespace"`
+	Name        string    `json:"name"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// ErrorResponse is returned for failed requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the admin API. Every request must carry the configured bearer token.
+type Server struct {
+	Addr   string
+	Client client.Client
+	Log    logr.Logger
+
+	token string
+	now   func() time.Time
+}
+
+// NewServer creates an admin API server listening on addr.
+func NewServer(addr, token string, c client.Client, logger logr.Logger) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("admin API requires a bearer token")
+	}
+
+	return &Server{
+		Addr:   addr,
+		Client: c,
+		Log:    logger,
+		token:  token,
+		now:    time.Now,
+	}, nil
+}
+
+// Handler returns the HTTP handler of the admin API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/configs/{namespace}/{name}/reconcile", s.handleReconcile)
+	mux.HandleFunc("GET /api/v1/configs/{namespace}/{name}/status", s.handleStatus)
+	mux.HandleFunc("GET /api/v1/instances", s.handleInstances)
+	return s.authenticate(mux)
+}
+
+// Start implements manager.Runnable; it serves until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("starting admin API server", "addr", s.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("admin API server failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("admin API server shutdown failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every replica serves the API.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vault-autounseal-operator"`)
+			writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	key := requestKey(r)
+	requestedAt := s.now().UTC()
+
+	if err := controller.RequestReconcile(r.Context(), s.Client, key, requestedAt); err != nil {
+		s.writeError(w, key, err)
+		return
+	}
+
+	s.Log.Info("reconcile requested through admin API", "config", key.String())
+	writeJSON(w, http.StatusAccepted, ReconcileResponse{
+		Namespace:   key.Namespace,
+		Name:        key.Name,
+		RequestedAt: requestedAt,
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	key := requestKey(r)
+
+	var config vaultv1.VaultUnsealConfig
+	if err := s.Client.Get(r.Context(), key, &config); err != nil {
+		s.writeError(w, key, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newConfigStatus(&config))
+}
+
+// handleInstances lists the instances of every VaultUnsealConfig, or of those in
+// the namespace given by the namespace query parameter.
+func (s *Server) handleInstances(w http.ResponseWriter, r *http.Request) {
+	var opts []client.ListOption
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	var configs vaultv1.VaultUnsealConfigList
+	if err := s.Client.List(r.Context(), &configs, opts...); err != nil {
+		s.Log.Error(err, "admin API request failed", "path", r.URL.Path)
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	sort.Slice(configs.Items, func(i, j int) bool {
+		a, b := &configs.Items[i], &configs.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	response := InstancesResponse{Instances: []InstanceSummary{}}
+	for i := range configs.Items {
+		response.Instances = append(response.Instances, instanceSummaries(&configs.Items[i])...)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, key types.NamespacedName, err error) {
+	if apierrors.IsNotFound(err) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("VaultUnsealConfig %s not found", key)})
+		return
+	}
+
+	s.Log.Error(err, "admin API request failed", "config", key.String())
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
+
+func newConfigStatus(config *vaultv1.VaultUnsealConfig) ConfigStatus {
+	status := ConfigStatus{
+		Namespace:          config.Namespace,
+		Name:               config.Name,
+		Generation:         config.Generation,
+		TotalInstances:     len(config.Spec.VaultInstances),
+		ReconcileRequested: config.Annotations[vaultv1.ReconcileRequestedAtAnnotation],
+		Instances:          config.Status.VaultStatuses,
+		Conditions:         config.Status.Conditions,
+	}
+	if status.Instances == nil {
+		status.Instances = []vaultv1.VaultInstanceStatus{}
+	}
+	if status.Conditions == nil {
+		status.Conditions = []metav1.Condition{}
+	}
+
+	reported := make(map[string]bool, len(config.Status.VaultStatuses))
+	for _, instance := range config.Status.VaultStatuses {
+		reported[instance.Name] = true
+		if instance.Sealed {
+			status.SealedInstances++
+		}
+	}
+	// Instances without a reported status count as sealed until the operator checks them
+	for _, instance := range config.Spec.VaultInstances {
+		if !reported[instance.Name] {
+			status.SealedInstances++
+		}
+	}
+	status.Ready = status.TotalInstances > 0 && status.SealedInstances == 0
+
+	return status
+}
+
+// instanceSummaries returns the reported instances of config followed by the
+// spec instances the operator has not checked yet, which count as sealed.
+func instanceSummaries(config *vaultv1.VaultUnsealConfig) []InstanceSummary {
+	summaries := make([]InstanceSummary, 0, len(config.Status.VaultStatuses))
+	reported := make(map[string]bool, len(config.Status.VaultStatuses))
+	for _, status := range config.Status.VaultStatuses {
+		reported[status.Name] = true
+		summaries = append(summaries, InstanceSummary{
+			Namespace:        config.Namespace,
+			UnsealConfig:     config.Name,
+			Name:             status.Name,
+			Endpoint:         specEndpoint(config, status.Name),
+			Sealed:           status.Sealed,
+			Reason:           status.Reason,
+			LastError:        status.Error,
+			LastUnsealed:     status.LastUnsealed,
+			LastSealDetected: status.LastSealDetectedTime,
+		})
+	}
+	for _, instance := range config.Spec.VaultInstances {
+		if reported[instance.Name] || instance.Discovery != nil {
+			continue
+		}
+		summaries = append(summaries, InstanceSummary{
+			Namespace:    config.Namespace,
+			UnsealConfig: config.Name,
+			Name:         instance.Name,
+			Endpoint:     instance.Endpoint,
+			Sealed:       true,
+		})
+	}
+	return summaries
+}
+
+// specEndpoint returns the endpoint of the spec instance named name, or of the
+// discovery instance a discovered node named <instance>-<node> belongs to.
+func specEndpoint(config *vaultv1.VaultUnsealConfig, name string) string {
+	for _, instance := range config.Spec.VaultInstances {
+		if instance.Name == name {
+			return instance.Endpoint
+		}
+	}
+	for _, instance := range config.Spec.VaultInstances {
+		if instance.Discovery != nil && strings.HasPrefix(name, instance.Name+"-") {
+			return instance.Endpoint
+		}
+	}
+	return ""
+}
+
+func requestKey(r *http.Request) types.NamespacedName {
+	return types.NamespacedName{Namespace: r.PathValue("namespace"), Name: r.PathValue("name")}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
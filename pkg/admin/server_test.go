@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const testToken = "s3cret"
+
+func newTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	tc := testutil.NewTestContext(t)
+	config := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "primary", Namespace: "vault", Generation: 2},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{Name: "vault-0"}, {Name: "vault-1"}},
+		},
+		Status: vaultv1.VaultUnsealConfigStatus{
+			VaultStatuses: []vaultv1.VaultInstanceStatus{{Name: "vault-0"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(tc.Scheme).WithObjects(config).Build()
+
+	s, err := NewServer(":0", testToken, c, log.Log)
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return s, s.Handler()
+}
+
+func do(handler http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewServerRequiresToken(t *testing.T) {
+	_, err := NewServer(":0", "", nil, log.Log)
+	assert.Error(t, err)
+}
+
+func TestAuthentication(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	assert.Equal(t, http.StatusUnauthorized, do(handler, http.MethodGet, "/api/v1/configs/vault/primary/status", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(handler, http.MethodGet, "/api/v1/configs/vault/primary/status", "wrong").Code)
+	assert.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/api/v1/configs/vault/primary/status", testToken).Code)
+}
+
+func TestStatus(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := do(handler, http.MethodGet, "/api/v1/configs/vault/primary/status", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status ConfigStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, int64(2), status.Generation)
+	assert.Equal(t, 2, status.TotalInstances)
+	assert.Equal(t, 1, status.SealedInstances, "unreported instances count as sealed")
+	assert.False(t, status.Ready)
+
+	rec = do(handler, http.MethodGet, "/api/v1/configs/vault/missing/status", testToken)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestInstances(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := do(handler, http.MethodGet, "/api/v1/instances", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response InstancesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Instances, 2)
+	assert.Equal(t, InstanceSummary{Namespace: "vault", UnsealConfig: "primary", Name: "vault-0"}, response.Instances[0])
+	assert.Equal(t, "vault-1", response.Instances[1].Name)
+	assert.True(t, response.Instances[1].Sealed, "unreported instances count as sealed")
+
+	rec = do(handler, http.MethodGet, "/api/v1/instances?namespace=other", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"instances":[]}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, do(handler, http.MethodGet, "/api/v1/instances", "").Code)
+}
+
+func TestSpecEndpoint(t *testing.T) {
+	config := &vaultv1.VaultUnsealConfig{Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+		{Name: "transit", Endpoint: "https://transit:8200"},
+		{Name: "vault", Endpoint: "https://vault:8200", Discovery: &vaultv1.Discovery{DNSSrv: "_vault._tcp.vault.svc"}},
+	}}}
+
+	assert.Equal(t, "https://transit:8200", specEndpoint(config, "transit"))
+	assert.Equal(t, "https://vault:8200", specEndpoint(config, "vault-vault-0"), "discovered nodes report their discovery instance")
+	assert.Empty(t, specEndpoint(config, "transit-0"))
+}
+
+func TestReconcile(t *testing.T) {
+	s, handler := newTestServer(t)
+
+	assert.Equal(t, http.StatusMethodNotAllowed,
+		do(handler, http.MethodGet, "/api/v1/configs/vault/primary/reconcile", testToken).Code)
+
+	rec := do(handler, http.MethodPost, "/api/v1/configs/vault/primary/reconcile", testToken)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var config vaultv1.VaultUnsealConfig
+	key := types.NamespacedName{Namespace: "vault", Name: "primary"}
+	require.NoError(t, s.Client.Get(context.Background(), key, &config))
+	assert.Equal(t, "2025-01-01T00:00:00Z", config.Annotations[vaultv1.ReconcileRequestedAtAnnotation])
+
+	rec = do(handler, http.MethodPost, "/api/v1/configs/vault/missing/reconcile", testToken)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
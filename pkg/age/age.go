@@ -0,0 +1,165 @@
+// Package age decrypts files of the age encryption format
+// (age-encryption.org/v1) with X25519 identities, so unseal keys can be stored
+// encrypted to a recipient and decrypted in memory by the operator. Files are
+// binary, ASCII-armored as written by age -a, or base64 encoded to fit one line.
+// The format itself is implemented by filippo.io/age.
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const (
+	// IdentityPrefix starts the upper-case Bech32 encoding of an X25519 identity
+	IdentityPrefix = "AGE-SECRET-KEY-1"
+
+	headerVersion = "age-encryption.org/v1"
+)
+
+// ErrNoIdentityMatch is returned when none of the identities can decrypt a file.
+var ErrNoIdentityMatch = errors.New("no identity matched any of the recipients")
+
+// Identity is an X25519 age identity, the private key of a recipient.
+type Identity struct {
+	identity *age.X25519Identity
+}
+
+// GenerateIdentity returns a new random identity.
+func GenerateIdentity() (*Identity, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{identity: identity}, nil
+}
+
+// ParseIdentities reads identities, one AGE-SECRET-KEY-1 string per line, as
+// written by age-keygen. Blank lines and lines starting with # are skipped.
+// Errors name the line, never the key.
+func ParseIdentities(data []byte) ([]*Identity, error) {
+	var identities []*Identity
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		identity, err := ParseIdentity(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		identities = append(identities, identity)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("no identities found")
+	}
+	return identities, nil
+}
+
+// ParseIdentity parses one AGE-SECRET-KEY-1 string.
+func ParseIdentity(s string) (*Identity, error) {
+	if !strings.HasPrefix(s, IdentityPrefix) {
+		return nil, fmt.Errorf("unsupported identity, expected %s", IdentityPrefix)
+	}
+	identity, err := age.ParseX25519Identity(s)
+	if err != nil {
+		// Parse errors may quote characters of the key
+		return nil, errors.New("malformed identity")
+	}
+	return &Identity{identity: identity}, nil
+}
+
+// String returns the AGE-SECRET-KEY-1 encoding of the identity.
+func (i *Identity) String() string {
+	return i.identity.String()
+}
+
+// Recipient returns the age1 recipient files are encrypted to for the identity.
+func (i *Identity) Recipient() string {
+	return i.identity.Recipient().String()
+}
+
+// Decrypt returns the plaintext of an age file encrypted to one of identities.
+// Stanzas of other recipient types, like scrypt or ssh-ed25519, are skipped.
+func Decrypt(data []byte, identities []*Identity) ([]byte, error) {
+	src, err := dearmor(data)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]age.Identity, len(identities))
+	for i, identity := range identities {
+		ids[i] = identity.identity
+	}
+
+	r, err := age.Decrypt(src, ids...)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, ErrNoIdentityMatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// dearmor returns a reader of the binary file of data, which is binary,
+// ASCII-armored or base64 encoded.
+func dearmor(data []byte) (io.Reader, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte(headerVersion+"\n")):
+		return bytes.NewReader(data), nil
+	case bytes.HasPrefix(trimmed, []byte(armor.Header)):
+		return armor.NewReader(bytes.NewReader(trimmed)), nil
+	default:
+		decoded, err := base64.StdEncoding.DecodeString(string(trimmed))
+		if err != nil || !bytes.HasPrefix(decoded, []byte(headerVersion+"\n")) {
+			return nil, errors.New("not an age file")
+		}
+		return bytes.NewReader(decoded), nil
+	}
+}
+
+// Encrypt encrypts plaintext to the age1 recipients, returning a binary file.
+func Encrypt(plaintext []byte, recipients ...string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	parsed := make([]age.Recipient, len(recipients))
+	for i, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = r
+	}
+
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, parsed...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
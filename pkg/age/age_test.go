@@ -0,0 +1,111 @@
+package age
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	alice, err := GenerateIdentity()
+	require.NoError(t, err)
+	bob, err := GenerateIdentity()
+	require.NoError(t, err)
+	eve, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	plaintext := []byte("c2hhcmUtMQ==")
+	file, err := Encrypt(plaintext, alice.Recipient(), bob.Recipient())
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(file, []byte("age-encryption.org/v1\n-> X25519 ")))
+
+	armored := pem.EncodeToMemory(&pem.Block{Type: "AGE ENCRYPTED FILE", Bytes: file})
+	encodings := map[string][]byte{
+		"binary":  file,
+		"armored": append([]byte("\n"), armored...),
+		"base64":  []byte(base64.StdEncoding.EncodeToString(file) + "\n"),
+	}
+	for name, data := range encodings {
+		decrypted, err := Decrypt(data, []*Identity{eve, bob})
+		require.NoError(t, err, name)
+		assert.Equal(t, plaintext, decrypted, name)
+	}
+
+	_, err = Decrypt(file, []*Identity{eve})
+	assert.ErrorIs(t, err, ErrNoIdentityMatch)
+
+	tampered := bytes.Clone(file)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Decrypt(tampered, []*Identity{alice})
+	assert.ErrorContains(t, err, "failed to decrypt payload")
+
+	_, err = Decrypt([]byte("c2hhcmUtMQ=="), []*Identity{alice})
+	assert.ErrorContains(t, err, "not an age file")
+}
+
+func TestDecryptHeaderMAC(t *testing.T) {
+	identity, err := GenerateIdentity()
+	require.NoError(t, err)
+	file, err := Encrypt([]byte("key"), identity.Recipient())
+	require.NoError(t, err)
+
+	// A stanza added to the header invalidates its MAC
+	forged := bytes.Replace(file, []byte("\n---"), []byte("\n-> grease\n\n---"), 1)
+	_, err = Decrypt(forged, []*Identity{identity})
+	assert.ErrorContains(t, err, "bad header MAC")
+}
+
+func TestDecryptMultipleChunks(t *testing.T) {
+	// Payloads are encrypted in chunks of 64 KiB
+	const chunkSize = 64 * 1024
+	identity, err := GenerateIdentity()
+	require.NoError(t, err)
+	for _, size := range []int{0, chunkSize, chunkSize + 1, 2*chunkSize + 100} {
+		plaintext := bytes.Repeat([]byte{'k'}, size)
+		file, err := Encrypt(plaintext, identity.Recipient())
+		require.NoError(t, err)
+		decrypted, err := Decrypt(file, []*Identity{identity})
+		require.NoError(t, err, size)
+		assert.Equal(t, len(plaintext), len(decrypted), size)
+	}
+}
+
+func TestParseIdentities(t *testing.T) {
+	first, err := GenerateIdentity()
+	require.NoError(t, err)
+	second, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	data := "# created: 2026-01-01T00:00:00Z\n# public key: " + first.Recipient() + "\n" +
+		first.String() + "\n\n" + second.String() + "\n"
+	identities, err := ParseIdentities([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, first.Recipient(), identities[0].Recipient())
+	assert.Equal(t, second.String(), identities[1].String())
+	assert.True(t, strings.HasPrefix(first.String(), IdentityPrefix))
+	assert.True(t, strings.HasPrefix(first.Recipient(), "age1"))
+
+	_, err = ParseIdentities([]byte("# nothing\n"))
+	assert.ErrorContains(t, err, "no identities")
+
+	// Errors never echo the key material; any changed character breaks the checksum
+	key := first.String()
+	flipped := byte('Q')
+	if key[40] == flipped {
+		flipped = 'P'
+	}
+	corrupt := key[:40] + string(flipped) + key[41:]
+	_, err = ParseIdentities([]byte(corrupt))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+	assert.NotContains(t, err.Error(), corrupt[len(IdentityPrefix):])
+
+	_, err = ParseIdentities([]byte(first.Recipient()))
+	assert.ErrorContains(t, err, "unsupported identity")
+}
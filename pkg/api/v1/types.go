@@ -5,9 +5,48 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// ReconcileRequestedAtAnnotation is set on a VaultUnsealConfig to request an
+// immediate reconcile; its value is the RFC 3339 time of the request.
+const ReconcileRequestedAtAnnotation = "vault.io/reconcile-requested-at"
+
+// MaintenanceAnnotation holds off unsealing during planned maintenance. On a
+// VaultUnsealConfig its value lists the instances under maintenance, separated
+// by commas, or is "*" for all of them; on a Vault pod any value but "false"
+// puts the instance running in it under maintenance.
+const MaintenanceAnnotation = "vault.io/maintenance"
+
+// UnsealConfigPhase is the lifecycle phase of a VaultUnsealConfig, a summary of
+// its conditions and instance statuses for simple consumers.
+//
+// A new config starts in Pending until its instances are checked. It then moves
+// freely between Unsealing, Ready, Degraded and Error as its instances change.
+// Any phase may move to Suspended; a Suspended config only leaves it to Pending.
+type UnsealConfigPhase string
+
+const (
+	// UnsealConfigPhasePending means no instance has been checked yet
+	UnsealConfigPhasePending UnsealConfigPhase = "Pending"
+	// UnsealConfigPhaseUnsealing means some instances are sealed and none failed
+	UnsealConfigPhaseUnsealing UnsealConfigPhase = "Unsealing"
+	// UnsealConfigPhaseReady means all instances are unsealed
+	UnsealConfigPhaseReady UnsealConfigPhase = "Ready"
+	// UnsealConfigPhaseDegraded means some, but not all, instances failed
+	UnsealConfigPhaseDegraded UnsealConfigPhase = "Degraded"
+	// UnsealConfigPhaseError means every instance failed
+	UnsealConfigPhaseError UnsealConfigPhase = "Error"
+	// UnsealConfigPhaseSuspended means reconciliation of the config is paused,
+	// e.g. because every instance is disabled
+	UnsealConfigPhaseSuspended UnsealConfigPhase = "Suspended"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:object:generate=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Sealed Instances",type=integer,JSONPath=`.status.sealedInstances`
+// +kubebuilder:printcolumn:name="Last Unseal",type=date,JSONPath=`.status.lastUnsealTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // VaultUnsealConfig is the Schema for the vaultunsealconfigs API
 type VaultUnsealConfig struct {
@@ -49,26 +88,101 @@ func (v *VaultUnsealConfig) DeepCopyInto(out *VaultUnsealConfig) {
 type VaultUnsealConfigSpec struct {
 	// VaultInstances is a list of vault instances to manage
 	VaultInstances []VaultInstance `json:"vaultInstances"`
+
+	// Settings override the operator-wide VaultClusterDefaults for this config
+	// +optional
+	Settings *UnsealSettings `json:"settings,omitempty"`
+}
+
+// BankVaultsReference names a Vault resource of the bank-vaults operator
+type BankVaultsReference struct {
+	// Name of the Vault resource
+	Name string `json:"name"`
 }
 
 // VaultInstance represents a single Vault instance configuration
+// +kubebuilder:validation:XValidation:rule="has(self.unsealKeys) || has(self.keySource) || has(self.bankVaults)",message="either unsealKeys, keySource or bankVaults must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.endpoint) || has(self.bankVaults)",message="endpoint must be set unless bankVaults is"
+// +kubebuilder:validation:XValidation:rule="[has(self.transitUnwrap), has(self.ageDecryption), has(self.pkcs11Decryption), has(self.pgpDecryption)].filter(x, x).size() <= 1",message="at most one of transitUnwrap, ageDecryption, pkcs11Decryption or pgpDecryption may be set"
 type VaultInstance struct {
 	// Name is the unique identifier for this vault instance
 	Name string `json:"name"`
 
-	// Endpoint is the URL of the vault instance
-	Endpoint string `json:"endpoint"`
+	// Endpoint is the URL of the vault instance. It may only be omitted when
+	// BankVaults supplies it.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// BankVaults reads the endpoint and the unseal key Secret from a Vault
+	// resource of the bank-vaults operator in the namespace of the config, so
+	// they are not configured twice. Endpoint, UnsealKeys and KeySource set on
+	// the instance take precedence.
+	// +optional
+	BankVaults *BankVaultsReference `json:"bankVaults,omitempty"`
 
 	// UnsealKeys is a list of unseal keys for this instance
-	UnsealKeys []string `json:"unsealKeys"`
+	// +optional
+	UnsealKeys []string `json:"unsealKeys,omitempty"`
+
+	// KeySource reads the unseal keys from an external source instead of UnsealKeys
+	// +optional
+	KeySource *KeySource `json:"keySource,omitempty"`
+
+	// TransitUnwrap decrypts the unseal keys with the Transit engine of a
+	// management Vault before they are submitted. UnsealKeys or the key source
+	// then hold Transit ciphertexts, so the raw shares are stored nowhere.
+	// +optional
+	TransitUnwrap *TransitUnwrap `json:"transitUnwrap,omitempty"`
+
+	// AgeDecryption decrypts the unseal keys, stored as age files encrypted to
+	// the recipient of an identity the operator holds, in memory before they are
+	// submitted
+	// +optional
+	AgeDecryption *AgeDecryption `json:"ageDecryption,omitempty"`
+
+	// PKCS11Decryption decrypts the unseal keys, stored as ciphertexts of an RSA
+	// key held by an HSM or smart card, with the PKCS#11 module of the token
+	// before they are submitted
+	// +optional
+	PKCS11Decryption *PKCS11Decryption `json:"pkcs11Decryption,omitempty"`
+
+	// PGPDecryption decrypts the unseal keys, stored as OpenPGP messages as
+	// printed by vault operator init -pgp-keys, with the private keys of the
+	// share holders before they are submitted
+	// +optional
+	PGPDecryption *PGPDecryption `json:"pgpDecryption,omitempty"`
+
+	// KeyEncoding is the encoding of the unseal keys, base64 or hex as printed by
+	// vault operator init. By default keys that decode as hex are treated as hex,
+	// like Vault does; set base64 to disable the detection.
+	// +kubebuilder:validation:Enum=base64;hex
+	// +optional
+	KeyEncoding KeyEncoding `json:"keyEncoding,omitempty"`
 
-	// Threshold is the number of unseal keys required (default: 3)
+	// KeyIndices are the zero-based positions of the keys in UnsealKeys or the key
+	// source that the operator submits, in order; the other keys are never sent
+	// to Vault. By default every key is used.
+	// +kubebuilder:validation:items:Minimum=0
+	// +listType=set
+	// +optional
+	KeyIndices []int `json:"keyIndices,omitempty"`
+
+	// ShuffleKeys submits the available keys in a random order on every unseal,
+	// so each unseal uses a different subset when more keys than the threshold
+	// are available and share usage is spread evenly
+	// +optional
+	ShuffleKeys bool `json:"shuffleKeys,omitempty"`
+
+	// Threshold is the number of unseal keys required. When neither the instance,
+	// the config settings nor the cluster defaults set one, it is read from the
+	// seal status of Vault
 	// +optional
 	Threshold *int `json:"threshold,omitempty"`
 
-	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// TLSSkipVerify disables TLS certificate verification. When unset, the
+	// config settings or the cluster defaults decide (default: false)
 	// +optional
-	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+	TLSSkipVerify *bool `json:"tlsSkipVerify,omitempty"`
 
 	// HAEnabled indicates if this is a HA setup (default: false)
 	// +optional
@@ -78,13 +192,400 @@ type VaultInstance struct {
 	// +optional
 	PodSelector map[string]string `json:"podSelector,omitempty"`
 
-	// Namespace is the target namespace for pod monitoring
+	// Namespace is the target namespace for pod monitoring, the namespace of the
+	// VaultUnsealConfig if unset
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
+
+	// Raft enables reporting of Raft autopilot and peer health once the instance is unsealed
+	// +optional
+	Raft *RaftMonitoring `json:"raft,omitempty"`
+
+	// Discovery enumerates the Vault nodes of the instance instead of using its
+	// endpoint directly. Each node is checked and reported as its own instance;
+	// the endpoint supplies the scheme and the default port.
+	// +optional
+	Discovery *Discovery `json:"discovery,omitempty"`
+
+	// Access selects how the operator reaches the instance; by default it calls
+	// the endpoint directly
+	// +optional
+	Access *InstanceAccess `json:"access,omitempty"`
+
+	// Tunnel dials the endpoint through an SSH bastion or a SOCKS5 proxy, for
+	// Vaults in networks not routable from the cluster
+	// +optional
+	Tunnel *Tunnel `json:"tunnel,omitempty"`
+
+	// Distribution is the server distribution, vault or openbao. By default it is
+	// detected from the reported version.
+	// +kubebuilder:validation:Enum=vault;openbao
+	// +optional
+	Distribution string `json:"distribution,omitempty"`
+
+	// ExpectedVersion is a semver range the Vault server version must satisfy,
+	// e.g. ">=1.15.0 <1.17.0". Mismatches are reported in the VersionSupported condition.
+	// +optional
+	ExpectedVersion string `json:"expectedVersion,omitempty"`
+
+	// DependsOn names instances of this config that must be unsealed before this
+	// one is, e.g. the Vault providing its transit seal. Cycles are rejected.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Enabled excludes the instance from reconciliation when false, e.g. while
+	// it is decommissioned, without removing it from the spec (default: true)
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Labels are attached to the Events of this instance as annotations and to
+	// its metrics when the operator exports the key, e.g. environment or team.
+	// Keys are lowercase Prometheus label names and values at most 63 characters.
+	// +kubebuilder:validation:MaxProperties=10
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[a-z]([a-z0-9_]{0,61}[a-z0-9])?$'))",message="label keys must be lowercase letters, digits and underscores, starting with a letter"
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(self[k]) <= 63)",message="label values must be at most 63 characters"
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// KeyEncoding is how the unseal keys of an instance are encoded
+type KeyEncoding string
+
+const (
+	// KeyEncodingBase64 keys are base64 encoded, as the unseal_keys_b64 of vault operator init
+	KeyEncodingBase64 KeyEncoding = "base64"
+	// KeyEncodingHex keys are hex encoded, as the unseal_keys_hex of vault operator init
+	KeyEncodingHex KeyEncoding = "hex"
+)
+
+// AccessMode is how the operator reaches the Vault server of an instance
+type AccessMode string
+
+const (
+	// AccessModeDirect calls the endpoint from the operator pod
+	AccessModeDirect AccessMode = "Direct"
+	// AccessModeExec runs each request inside a container of the instance's pod,
+	// for Vaults only reachable on the pod's localhost through an agent or sidecar
+	AccessModeExec AccessMode = "Exec"
+	// AccessModePortForward connects to the endpoint port of the instance's pod
+	// through a Kubernetes port-forward, for pods without a Service
+	AccessModePortForward AccessMode = "PortForward"
+)
+
+// InstanceAccess selects how the operator reaches an instance
+type InstanceAccess struct {
+	// Mode is Direct, Exec or PortForward
+	// +kubebuilder:validation:Enum=Direct;Exec;PortForward
+	// +optional
+	Mode AccessMode `json:"mode,omitempty"`
+
+	// Pod is the pod the requests run in or are forwarded to, in the config's
+	// namespace. With Exec the endpoint is resolved from inside the pod; with
+	// PortForward only its port is used.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+
+	// Container runs the requests in Exec mode; defaults to the pod's default container
+	// +optional
+	Container string `json:"container,omitempty"`
+}
+
+// Tunnel selects the jump host connections to an instance are dialed through.
+// Credentials are read from Secrets in the namespace of the config.
+// +kubebuilder:validation:XValidation:rule="has(self.ssh) != has(self.socks5)",message="exactly one of ssh or socks5 must be set"
+type Tunnel struct {
+	// SSH forwards connections through an SSH bastion
+	// +optional
+	SSH *SSHTunnel `json:"ssh,omitempty"`
+
+	// SOCKS5 connects through a SOCKS5 proxy
+	// +optional
+	SOCKS5 *SOCKS5Tunnel `json:"socks5,omitempty"`
+}
+
+// SSHTunnel forwards connections to an instance through an SSH bastion
+// +kubebuilder:validation:XValidation:rule="has(self.privateKeySecretRef) || has(self.passwordSecretRef)",message="either privateKeySecretRef or passwordSecretRef must be set"
+type SSHTunnel struct {
+	// Address is the host:port of the bastion; the port defaults to 22
+	Address string `json:"address"`
+
+	// User is the user to log in to the bastion as
+	User string `json:"user"`
+
+	// HostKeys are the accepted public keys of the bastion, in authorized_keys
+	// format such as "ssh-ed25519 AAAAC3Nza..."
+	// +kubebuilder:validation:MinItems=1
+	HostKeys []string `json:"hostKeys"`
+
+	// PrivateKeySecretRef references an unencrypted private key in PEM or OpenSSH format
+	// +optional
+	PrivateKeySecretRef *SecretKeyRef `json:"privateKeySecretRef,omitempty"`
+
+	// PasswordSecretRef references the password of User
+	// +optional
+	PasswordSecretRef *SecretKeyRef `json:"passwordSecretRef,omitempty"`
+}
+
+// SOCKS5Tunnel connects to an instance through a SOCKS5 proxy. The endpoint
+// host is resolved by the proxy.
+type SOCKS5Tunnel struct {
+	// Address is the host:port of the proxy; the port defaults to 1080
+	Address string `json:"address"`
+
+	// Username authenticates to the proxy together with PasswordSecretRef
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// PasswordSecretRef references the password of Username
+	// +optional
+	PasswordSecretRef *SecretKeyRef `json:"passwordSecretRef,omitempty"`
+}
+
+// KeySource selects where the unseal keys of an instance are read from
+// +kubebuilder:validation:XValidation:rule="[has(self.secret), has(self.exec), has(self.pkcs11)].filter(x, x).size() == 1",message="exactly one of secret, exec or pkcs11 must be set"
+type KeySource struct {
+	// Secret reads the keys from a Secret in the namespace of the config
+	// +optional
+	Secret *SecretKeySource `json:"secret,omitempty"`
+
+	// Exec runs a command in the operator whose standard output supplies the keys
+	// +optional
+	Exec *ExecKeySource `json:"exec,omitempty"`
+
+	// PKCS11 reads the keys from data objects of an HSM or smart card token
+	// +optional
+	PKCS11 *PKCS11KeySource `json:"pkcs11,omitempty"`
+}
+
+// SecretKeySource reads unseal keys from the data of a Secret
+// +kubebuilder:validation:XValidation:rule="has(self.keys) != has(self.combinedKey)",message="exactly one of keys or combinedKey must be set"
+type SecretKeySource struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Keys are the data keys holding the unseal keys, in the order they are submitted
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+
+	// CombinedKey is a single data key holding all unseal keys, one per line or
+	// as JSON, e.g. the output of vault operator init -format=json
+	// +optional
+	CombinedKey string `json:"combinedKey,omitempty"`
+}
+
+// ExecKeySource reads unseal keys from the output of a command, in the manner of
+// client-go exec credential plugins. The command prints either a JSON object
+// {"keys": [...]} or one key per line, in the order the keys are submitted.
+type ExecKeySource struct {
+	// Command is the executable to run; it must be allowed by the operator's
+	// --key-exec-commands flag
+	Command string `json:"command"`
+
+	// Args are passed to the command
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Env sets additional environment variables of the command, which does not
+	// inherit the operator's environment beyond PATH, HOME, TMPDIR, TZ, LANG,
+	// proxy and CA variables. Loader and path variables like LD_PRELOAD or PATH
+	// are rejected
+	// +optional
+	Env []ExecEnvVar `json:"env,omitempty"`
+
+	// Timeout bounds a run of the command; defaults to 30s
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ExecEnvVar is an environment variable of an exec key source command
+type ExecEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PKCS11Token selects a token of a PKCS#11 module and the PIN to log in to it.
+// The operator drives the module with OpenSC's pkcs11-tool.
+type PKCS11Token struct {
+	// Module is the path of the PKCS#11 module library in the operator, e.g.
+	// /usr/lib/softhsm/libsofthsm2.so; it must be allowed by the operator's
+	// --pkcs11-modules flag
+	Module string `json:"module"`
+
+	// Slot is the ID of the slot holding the token. By default the token is
+	// selected by TokenLabel, or the first slot with a token is used.
+	// +optional
+	Slot *int64 `json:"slot,omitempty"`
+
+	// TokenLabel selects the token by its label
+	// +optional
+	TokenLabel string `json:"tokenLabel,omitempty"`
+
+	// PINSecretRef references the user PIN of the token in a Secret in the
+	// namespace of the config
+	PINSecretRef SecretKeyRef `json:"pinSecretRef"`
+
+	// Timeout bounds each operation on the token; defaults to 30s
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// PKCS11KeySource reads unseal keys stored as data objects on a token
+type PKCS11KeySource struct {
+	// Token is the token holding the keys
+	Token PKCS11Token `json:"token"`
+
+	// Objects are the labels of the data objects holding the keys, in the order
+	// they are submitted
+	// +kubebuilder:validation:MinItems=1
+	Objects []string `json:"objects"`
+}
+
+// PKCS11Decryption decrypts unseal keys with an RSA private key of a token.
+// Each key is the base64 encoded ciphertext of the key.
+// +kubebuilder:validation:XValidation:rule="has(self.keyLabel) || has(self.keyID)",message="keyLabel or keyID must be set"
+type PKCS11Decryption struct {
+	// Token is the token holding the private key
+	Token PKCS11Token `json:"token"`
+
+	// KeyLabel is the label of the private key
+	// +optional
+	KeyLabel string `json:"keyLabel,omitempty"`
+
+	// KeyID is the hex encoded ID of the private key
+	// +optional
+	KeyID string `json:"keyID,omitempty"`
+
+	// Mechanism is the decryption mechanism, RSA-PKCS-OAEP with SHA-256 or
+	// RSA-PKCS; defaults to RSA-PKCS-OAEP
+	// +kubebuilder:validation:Enum=RSA-PKCS-OAEP;RSA-PKCS
+	// +optional
+	Mechanism string `json:"mechanism,omitempty"`
+}
+
+// PGPDecryption decrypts unseal keys encrypted with OpenPGP, base64 encoded as
+// printed by vault operator init -pgp-keys or ASCII-armored. Each key is
+// decrypted with the private key it was encrypted to, so the keys may be listed
+// in any order.
+type PGPDecryption struct {
+	// PrivateKeys are the private keys of the share holders
+	// +kubebuilder:validation:MinItems=1
+	PrivateKeys []PGPPrivateKey `json:"privateKeys"`
+}
+
+// PGPPrivateKey references an OpenPGP private key in Secrets in the namespace
+// of the config.
+type PGPPrivateKey struct {
+	// SecretRef references the private key, ASCII-armored as written by
+	// gpg --armor --export-secret-keys, binary or base64 encoded
+	SecretRef SecretKeyRef `json:"secretRef"`
+
+	// PassphraseSecretRef references the passphrase protecting the private key
+	// +optional
+	PassphraseSecretRef *SecretKeyRef `json:"passphraseSecretRef,omitempty"`
+}
+
+// AgeDecryption decrypts unseal keys encrypted with age. Each key is an age
+// file, ASCII-armored as written by age -a or base64 encoded to fit one line.
+type AgeDecryption struct {
+	// IdentitySecretRef references the age identities, one AGE-SECRET-KEY-1 per
+	// line as written by age-keygen, in a Secret in the namespace of the config.
+	// By default the identities of the operator's --age-identity-files are used.
+	// +optional
+	IdentitySecretRef *SecretKeyRef `json:"identitySecretRef,omitempty"`
+}
+
+// TransitUnwrap decrypts Transit-wrapped unseal keys with a management Vault.
+// The operator logs in with Kubernetes auth on every unseal and keeps neither
+// the token nor the decrypted keys.
+type TransitUnwrap struct {
+	// Address is the URL of the management Vault
+	Address string `json:"address"`
+
+	// Mount is the path of the Transit engine; defaults to transit
+	// +optional
+	Mount string `json:"mount,omitempty"`
+
+	// Key is the name of the Transit key the unseal keys were encrypted with
+	Key string `json:"key"`
+
+	// AuthMount is the path of the Kubernetes auth method; defaults to kubernetes
+	// +optional
+	AuthMount string `json:"authMount,omitempty"`
+
+	// Role is the Kubernetes auth role, which must grant update on the decrypt
+	// endpoint of Key
+	Role string `json:"role"`
+
+	// ServiceAccountName is the service account in the namespace of the config
+	// whose token the operator requests to log in. The operator never sends its
+	// own token to the management Vault.
+	ServiceAccountName string `json:"serviceAccountName"`
+
+	// TLSSkipVerify disables TLS certificate verification of the management Vault
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+}
+
+// Discovery selects the provider that enumerates the Vault nodes of an instance
+// +kubebuilder:validation:XValidation:rule="[has(self.consul), has(self.dnsSrv), has(self.statefulSet)].filter(x, x).size() == 1",message="exactly one of consul, dnsSrv or statefulSet must be set"
+type Discovery struct {
+	// Consul lists the nodes registered for a service in a Consul catalog
+	// +optional
+	Consul *ConsulDiscovery `json:"consul,omitempty"`
+
+	// DNSSrv is an SRV record listing the nodes, e.g. _vault._tcp.example.com.
+	// Each target is checked on the port of its record.
+	// +optional
+	DNSSrv string `json:"dnsSrv,omitempty"`
+
+	// StatefulSet names the StatefulSet running Vault, in the instance namespace
+	// or else the config's namespace. Each replica is checked through its stable
+	// name under the governing Service, and changes of the StatefulSet, such as
+	// the progress of a rolling update, trigger a check.
+	// +optional
+	StatefulSet string `json:"statefulSet,omitempty"`
+}
+
+// ConsulDiscovery enumerates Vault nodes from a Consul catalog service
+type ConsulDiscovery struct {
+	// Address is the URL of the Consul HTTP API, e.g. https://consul.example.com:8501
+	Address string `json:"address"`
+
+	// Service is the catalog service Vault registers as (default: vault)
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Tag only lists nodes registered with this tag
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Datacenter queries another datacenter than the agent's own
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// TokenSecretRef references a Consul ACL token allowed to read the service
+	// +optional
+	TokenSecretRef *SecretKeyRef `json:"tokenSecretRef,omitempty"`
+}
+
+// RaftMonitoring configures Raft peer health reporting for an instance
+type RaftMonitoring struct {
+	// TokenSecretRef references a Vault token allowed to read sys/storage/raft/autopilot/state
+	TokenSecretRef SecretKeyRef `json:"tokenSecretRef"`
 }
 
 // VaultUnsealConfigStatus defines the observed state of VaultUnsealConfig
 type VaultUnsealConfigStatus struct {
+	// Phase summarizes the conditions and instance statuses
+	// +kubebuilder:validation:Enum=Pending;Unsealing;Ready;Degraded;Error;Suspended
+	// +optional
+	Phase UnsealConfigPhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed
+	// from. While it is behind metadata.generation, the status is stale.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Conditions represent the latest available observations
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
@@ -92,6 +593,18 @@ type VaultUnsealConfigStatus struct {
 	// VaultStatuses shows the status of each vault instance
 	// +optional
 	VaultStatuses []VaultInstanceStatus `json:"vaultStatuses,omitempty"`
+
+	// Ready is the number of unsealed instances out of all instances, e.g. 2/3
+	// +optional
+	Ready string `json:"ready,omitempty"`
+
+	// SealedInstances is the number of sealed or unreachable instances
+	// +optional
+	SealedInstances int32 `json:"sealedInstances"`
+
+	// LastUnsealTime is the last time the operator unsealed one of the instances
+	// +optional
+	LastUnsealTime *metav1.Time `json:"lastUnsealTime,omitempty"`
 }
 
 // VaultInstanceStatus represents the status of a single vault instance
@@ -109,6 +622,190 @@ type VaultInstanceStatus struct {
 	// Error contains any error message from the last operation
 	// +optional
 	Error string `json:"error,omitempty"`
+
+	// Reason is a machine-readable cause of the error or of the instance
+	// remaining sealed, e.g. EndpointUnreachable or InvalidKeys
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Raft reports the Raft cluster health seen from this instance
+	// +optional
+	Raft *RaftStatus `json:"raft,omitempty"`
+
+	// Version is the Vault server version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Distribution is vault or openbao, as configured or detected from the version
+	// +optional
+	Distribution string `json:"distribution,omitempty"`
+
+	// ClusterName is the name of the Vault cluster
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// ClusterID is the ID of the Vault cluster
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+
+	// Initialized reports whether the vault has been initialized
+	// +optional
+	Initialized *bool `json:"initialized,omitempty"`
+
+	// HAEnabled reports whether high availability is enabled, known once unsealed
+	// +optional
+	HAEnabled *bool `json:"haEnabled,omitempty"`
+
+	// Role is active, standby or performance-standby, known once unsealed
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// SealType is the seal mechanism, e.g. shamir or awskms
+	// +optional
+	SealType string `json:"sealType,omitempty"`
+
+	// RecoverySeal indicates the seal uses recovery keys, as with auto-unseal
+	// +optional
+	RecoverySeal bool `json:"recoverySeal,omitempty"`
+
+	// RecoverySealType is the type of the recovery seal, e.g. shamir
+	// +optional
+	RecoverySealType string `json:"recoverySealType,omitempty"`
+
+	// SealMigration indicates a seal migration is in progress
+	// +optional
+	SealMigration bool `json:"sealMigration,omitempty"`
+
+	// UnsealCount is the number of times the operator unsealed this instance
+	// +optional
+	UnsealCount int64 `json:"unsealCount,omitempty"`
+
+	// LastSealDetectedTime is when the instance was last found newly sealed
+	// +optional
+	LastSealDetectedTime *metav1.Time `json:"lastSealDetectedTime,omitempty"`
+
+	// ConsecutiveFailures is the number of checks in a row that failed with an error
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// NextRetryTime is when a failing instance is checked again; the retry delay
+	// doubles with each consecutive failure and survives operator restarts
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// RapidReseals is the number of times in a row the instance sealed again
+	// within the reseal detection window of being unsealed
+	// +optional
+	RapidReseals int32 `json:"rapidReseals,omitempty"`
+
+	// InvalidKeyFailures is the number of unseal attempts in a row that Vault
+	// rejected the keys of; once it reaches quarantineAfter the instance is quarantined
+	// +optional
+	InvalidKeyFailures int32 `json:"invalidKeyFailures,omitempty"`
+
+	// RejectedKeysHash identifies the keys Vault rejected, so a quarantine is
+	// lifted once the keys change. It is a truncated SHA-256 of the keys.
+	// +optional
+	RejectedKeysHash string `json:"rejectedKeysHash,omitempty"`
+
+	// Ready is whether the instance counts towards the Ready condition of the
+	// config. With hysteresis it only follows the seal state once enough
+	// consecutive checks agree.
+	// +optional
+	Ready *bool `json:"ready,omitempty"`
+
+	// ReadinessStreak is the number of consecutive checks that disagreed with Ready
+	// +optional
+	ReadinessStreak int32 `json:"readinessStreak,omitempty"`
+
+	// ResolvedAddresses are the addresses the endpoint host resolved to
+	// +optional
+	ResolvedAddresses []string `json:"resolvedAddresses,omitempty"`
+
+	// ResolutionError is why the endpoint host did not resolve, e.g. no such host
+	// +optional
+	ResolutionError string `json:"resolutionError,omitempty"`
+
+	// UnsealProgress reports the key shares accepted by the last unseal attempt
+	// +optional
+	UnsealProgress *UnsealProgress `json:"unsealProgress,omitempty"`
+
+	// EffectiveThreshold is the number of unseal keys the operator uses, either
+	// configured or detected from the seal status of Vault
+	// +optional
+	EffectiveThreshold int `json:"effectiveThreshold,omitempty"`
+
+	// Conditions report the state of the instance, e.g. Disabled
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// UnsealProgress is the state of a multi-key unseal attempt as reported by Vault
+type UnsealProgress struct {
+	// KeysProvided is the number of key shares Vault accepted
+	KeysProvided int `json:"keysProvided"`
+
+	// Threshold is the number of key shares Vault requires
+	Threshold int `json:"threshold"`
+
+	// NonceReset indicates a partially completed attempt was reset before submitting keys
+	// +optional
+	NonceReset bool `json:"nonceReset,omitempty"`
+}
+
+// RaftStatus is the autopilot view of a Raft cluster
+type RaftStatus struct {
+	// Healthy reports whether autopilot considers the cluster healthy
+	Healthy bool `json:"healthy"`
+
+	// FailureTolerance is the number of voters that can fail without losing quorum
+	FailureTolerance int `json:"failureTolerance"`
+
+	// Leader is the ID of the current leader
+	// +optional
+	Leader string `json:"leader,omitempty"`
+
+	// Voters is the number of voting peers
+	Voters int `json:"voters"`
+
+	// DeadServers lists the IDs of peers that left or failed
+	// +optional
+	DeadServers []string `json:"deadServers,omitempty"`
+
+	// Servers lists every Raft peer
+	// +optional
+	Servers []RaftServerStatus `json:"servers,omitempty"`
+
+	// Error contains the error of the last autopilot query
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// RaftServerStatus is the autopilot view of a single Raft peer
+type RaftServerStatus struct {
+	// ID is the Raft node ID
+	ID string `json:"id"`
+
+	// Address is the cluster address of the peer
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Status is the Raft role, e.g. leader, voter or non-voter
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// NodeStatus is the Serf status, e.g. alive, left or failed
+	// +optional
+	NodeStatus string `json:"nodeStatus,omitempty"`
+
+	// Healthy reports whether autopilot considers the peer healthy
+	Healthy bool `json:"healthy"`
+
+	// LastContact is the time since the leader last heard from the peer
+	// +optional
+	LastContact string `json:"lastContact,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -162,6 +859,9 @@ func (v *VaultUnsealConfigSpec) DeepCopyInto(out *VaultUnsealConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if v.Settings != nil {
+		out.Settings = v.Settings.DeepCopy()
+	}
 }
 
 // DeepCopy returns a deep copy of VaultUnsealConfigSpec
@@ -182,11 +882,21 @@ func (v *VaultInstance) DeepCopyInto(out *VaultInstance) {
 		*out = new(int)
 		**out = **in
 	}
+	if v.TLSSkipVerify != nil {
+		in, out := &v.TLSSkipVerify, &out.TLSSkipVerify
+		*out = new(bool)
+		**out = **in
+	}
 	if v.UnsealKeys != nil {
 		in, out := &v.UnsealKeys, &out.UnsealKeys
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if v.KeyIndices != nil {
+		in, out := &v.KeyIndices, &out.KeyIndices
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
 	if v.PodSelector != nil {
 		in, out := &v.PodSelector, &out.PodSelector
 		*out = make(map[string]string, len(*in))
@@ -194,6 +904,252 @@ func (v *VaultInstance) DeepCopyInto(out *VaultInstance) {
 			(*out)[key] = val
 		}
 	}
+	if v.DependsOn != nil {
+		in, out := &v.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if v.Enabled != nil {
+		in, out := &v.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if v.Labels != nil {
+		in, out := &v.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if v.KeySource != nil {
+		in, out := &v.KeySource, &out.KeySource
+		*out = new(KeySource)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.Raft != nil {
+		in, out := &v.Raft, &out.Raft
+		*out = new(RaftMonitoring)
+		**out = **in
+	}
+	if v.Access != nil {
+		in, out := &v.Access, &out.Access
+		*out = new(InstanceAccess)
+		**out = **in
+	}
+	if v.Tunnel != nil {
+		in, out := &v.Tunnel, &out.Tunnel
+		*out = new(Tunnel)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.Discovery != nil {
+		in, out := &v.Discovery, &out.Discovery
+		*out = new(Discovery)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.BankVaults != nil {
+		in, out := &v.BankVaults, &out.BankVaults
+		*out = new(BankVaultsReference)
+		**out = **in
+	}
+	if v.TransitUnwrap != nil {
+		in, out := &v.TransitUnwrap, &out.TransitUnwrap
+		*out = new(TransitUnwrap)
+		**out = **in
+	}
+	if v.AgeDecryption != nil {
+		in, out := &v.AgeDecryption, &out.AgeDecryption
+		*out = new(AgeDecryption)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.PKCS11Decryption != nil {
+		in, out := &v.PKCS11Decryption, &out.PKCS11Decryption
+		*out = new(PKCS11Decryption)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.PGPDecryption != nil {
+		in, out := &v.PGPDecryption, &out.PGPDecryption
+		*out = new(PGPDecryption)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (t *Tunnel) DeepCopyInto(out *Tunnel) {
+	*out = *t
+	if t.SSH != nil {
+		in, out := &t.SSH, &out.SSH
+		*out = new(SSHTunnel)
+		(*in).DeepCopyInto(*out)
+	}
+	if t.SOCKS5 != nil {
+		in, out := &t.SOCKS5, &out.SOCKS5
+		*out = new(SOCKS5Tunnel)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (s *SSHTunnel) DeepCopyInto(out *SSHTunnel) {
+	*out = *s
+	if s.HostKeys != nil {
+		in, out := &s.HostKeys, &out.HostKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if s.PrivateKeySecretRef != nil {
+		in, out := &s.PrivateKeySecretRef, &out.PrivateKeySecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if s.PasswordSecretRef != nil {
+		in, out := &s.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (s *SOCKS5Tunnel) DeepCopyInto(out *SOCKS5Tunnel) {
+	*out = *s
+	if s.PasswordSecretRef != nil {
+		in, out := &s.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (d *Discovery) DeepCopyInto(out *Discovery) {
+	*out = *d
+	if d.Consul != nil {
+		in, out := &d.Consul, &out.Consul
+		*out = new(ConsulDiscovery)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (c *ConsulDiscovery) DeepCopyInto(out *ConsulDiscovery) {
+	*out = *c
+	if c.TokenSecretRef != nil {
+		in, out := &c.TokenSecretRef, &out.TokenSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (a *AgeDecryption) DeepCopyInto(out *AgeDecryption) {
+	*out = *a
+	if a.IdentitySecretRef != nil {
+		in, out := &a.IdentitySecretRef, &out.IdentitySecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (k *KeySource) DeepCopyInto(out *KeySource) {
+	*out = *k
+	if k.Secret != nil {
+		in, out := &k.Secret, &out.Secret
+		*out = new(SecretKeySource)
+		(*in).DeepCopyInto(*out)
+	}
+	if k.Exec != nil {
+		in, out := &k.Exec, &out.Exec
+		*out = new(ExecKeySource)
+		(*in).DeepCopyInto(*out)
+	}
+	if k.PKCS11 != nil {
+		in, out := &k.PKCS11, &out.PKCS11
+		*out = new(PKCS11KeySource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (p *PKCS11Token) DeepCopyInto(out *PKCS11Token) {
+	*out = *p
+	if p.Slot != nil {
+		in, out := &p.Slot, &out.Slot
+		*out = new(int64)
+		**out = **in
+	}
+	if p.Timeout != nil {
+		in, out := &p.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (p *PKCS11KeySource) DeepCopyInto(out *PKCS11KeySource) {
+	*out = *p
+	p.Token.DeepCopyInto(&out.Token)
+	if p.Objects != nil {
+		in, out := &p.Objects, &out.Objects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (p *PKCS11Decryption) DeepCopyInto(out *PKCS11Decryption) {
+	*out = *p
+	p.Token.DeepCopyInto(&out.Token)
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (p *PGPDecryption) DeepCopyInto(out *PGPDecryption) {
+	*out = *p
+	if p.PrivateKeys != nil {
+		in, out := &p.PrivateKeys, &out.PrivateKeys
+		*out = make([]PGPPrivateKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (p *PGPPrivateKey) DeepCopyInto(out *PGPPrivateKey) {
+	*out = *p
+	if p.PassphraseSecretRef != nil {
+		in, out := &p.PassphraseSecretRef, &out.PassphraseSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (s *SecretKeySource) DeepCopyInto(out *SecretKeySource) {
+	*out = *s
+	if s.Keys != nil {
+		in, out := &s.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (e *ExecKeySource) DeepCopyInto(out *ExecKeySource) {
+	*out = *e
+	if e.Args != nil {
+		in, out := &e.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if e.Env != nil {
+		in, out := &e.Env, &out.Env
+		*out = make([]ExecEnvVar, len(*in))
+		copy(*out, *in)
+	}
+	if e.Timeout != nil {
+		in, out := &e.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy returns a deep copy of VaultInstance
@@ -223,6 +1179,10 @@ func (v *VaultUnsealConfigStatus) DeepCopyInto(out *VaultUnsealConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if v.LastUnsealTime != nil {
+		in, out := &v.LastUnsealTime, &out.LastUnsealTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy returns a deep copy of VaultUnsealConfigStatus
@@ -242,6 +1202,51 @@ func (v *VaultInstanceStatus) DeepCopyInto(out *VaultInstanceStatus) {
 		in, out := &v.LastUnsealed, &out.LastUnsealed
 		*out = (*in).DeepCopy()
 	}
+	if v.Raft != nil {
+		in, out := &v.Raft, &out.Raft
+		*out = new(RaftStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.LastSealDetectedTime != nil {
+		in, out := &v.LastSealDetectedTime, &out.LastSealDetectedTime
+		*out = (*in).DeepCopy()
+	}
+	if v.NextRetryTime != nil {
+		in, out := &v.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+	if v.Ready != nil {
+		in, out := &v.Ready, &out.Ready
+		*out = new(bool)
+		**out = **in
+	}
+	if v.Initialized != nil {
+		in, out := &v.Initialized, &out.Initialized
+		*out = new(bool)
+		**out = **in
+	}
+	if v.HAEnabled != nil {
+		in, out := &v.HAEnabled, &out.HAEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if v.ResolvedAddresses != nil {
+		in, out := &v.ResolvedAddresses, &out.ResolvedAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if v.UnsealProgress != nil {
+		in, out := &v.UnsealProgress, &out.UnsealProgress
+		*out = new(UnsealProgress)
+		**out = **in
+	}
+	if v.Conditions != nil {
+		in, out := &v.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy returns a deep copy of VaultInstanceStatus
@@ -253,3 +1258,18 @@ func (v *VaultInstanceStatus) DeepCopy() *VaultInstanceStatus {
 	v.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto copies all fields from this object into another
+func (v *RaftStatus) DeepCopyInto(out *RaftStatus) {
+	*out = *v
+	if v.DeadServers != nil {
+		in, out := &v.DeadServers, &out.DeadServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if v.Servers != nil {
+		in, out := &v.Servers, &out.Servers
+		*out = make([]RaftServerStatus, len(*in))
+		copy(*out, *in)
+	}
+}
@@ -0,0 +1,300 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SecretKeyRef references a single key of a Secret in the same namespace as the referencing resource
+type SecretKeyRef struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Key within the Secret's data
+	Key string `json:"key"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Interval",type=string,JSONPath=`.spec.interval`
+// +kubebuilder:printcolumn:name="Last Snapshot",type=date,JSONPath=`.status.lastSnapshotTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultBackupSchedule is the Schema for the vaultbackupschedules API
+type VaultBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultBackupScheduleSpec   `json:"spec,omitempty"`
+	Status VaultBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// VaultBackupScheduleSpec defines the desired state of VaultBackupSchedule
+type VaultBackupScheduleSpec struct {
+	// Endpoint is the URL of the vault instance to snapshot (should reach the active node)
+	Endpoint string `json:"endpoint"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+
+	// TokenSecretRef references a Vault token allowed to read sys/storage/raft/snapshot
+	TokenSecretRef SecretKeyRef `json:"tokenSecretRef"`
+
+	// Interval is the time between snapshots (e.g. "6h")
+	Interval metav1.Duration `json:"interval"`
+
+	// Destination is where snapshots are uploaded
+	Destination BackupDestination `json:"destination"`
+
+	// Retention controls how many snapshots are kept at the destination
+	// +optional
+	Retention BackupRetention `json:"retention,omitempty"`
+
+	// Suspend pauses scheduling of new snapshots (default: false)
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// BackupDestination selects exactly one object storage backend
+type BackupDestination struct {
+	// S3 uploads to an S3 (or S3-compatible) bucket
+	// +optional
+	S3 *S3Destination `json:"s3,omitempty"`
+
+	// GCS uploads to a Google Cloud Storage bucket using HMAC interoperability keys
+	// +optional
+	GCS *GCSDestination `json:"gcs,omitempty"`
+
+	// Azure uploads to an Azure Blob Storage container using a SAS token
+	// +optional
+	Azure *AzureDestination `json:"azure,omitempty"`
+}
+
+// S3Destination configures an S3 bucket
+type S3Destination struct {
+	// Bucket is the bucket name
+	Bucket string `json:"bucket"`
+
+	// Region is the bucket region (default: us-east-1)
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Endpoint overrides the S3 endpoint, e.g. for MinIO
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Prefix is prepended to every object key
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// ForcePathStyle uses path-style addressing instead of virtual-hosted buckets
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+
+	// CredentialsSecretRef names a Secret holding accessKeyId and secretAccessKey
+	CredentialsSecretRef string `json:"credentialsSecretRef"`
+}
+
+// GCSDestination configures a GCS bucket
+type GCSDestination struct {
+	// Bucket is the bucket name
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to every object key
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef names a Secret holding HMAC accessKeyId and secretAccessKey
+	CredentialsSecretRef string `json:"credentialsSecretRef"`
+}
+
+// AzureDestination configures an Azure Blob Storage container
+type AzureDestination struct {
+	// StorageAccount is the storage account name
+	StorageAccount string `json:"storageAccount"`
+
+	// Container is the blob container name
+	Container string `json:"container"`
+
+	// Prefix is prepended to every blob name
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef names a Secret holding a sasToken
+	CredentialsSecretRef string `json:"credentialsSecretRef"`
+}
+
+// BackupRetention defines snapshot retention
+type BackupRetention struct {
+	// MaxCount is the number of most recent snapshots to keep; 0 keeps all
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxCount int `json:"maxCount,omitempty"`
+}
+
+// VaultBackupScheduleStatus defines the observed state of VaultBackupSchedule
+type VaultBackupScheduleStatus struct {
+	// Conditions represent the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastSnapshotTime is when the last successful snapshot was uploaded
+	// +optional
+	LastSnapshotTime *metav1.Time `json:"lastSnapshotTime,omitempty"`
+
+	// Snapshots lists the retained snapshots, oldest first
+	// +optional
+	Snapshots []BackupSnapshot `json:"snapshots,omitempty"`
+}
+
+// BackupSnapshot describes one uploaded snapshot
+type BackupSnapshot struct {
+	// Key is the object key (or blob name) at the destination
+	Key string `json:"key"`
+
+	// Size is the snapshot size in bytes
+	Size int64 `json:"size"`
+
+	// Timestamp is when the snapshot was taken
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultBackupScheduleList contains a list of VaultBackupSchedule
+type VaultBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultBackupSchedule{}, &VaultBackupScheduleList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultBackupSchedule
+func (v *VaultBackupSchedule) DeepCopy() *VaultBackupSchedule {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultBackupSchedule)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultBackupSchedule) DeepCopyInto(out *VaultBackupSchedule) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Spec.DeepCopyInto(&out.Spec)
+	v.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultBackupScheduleList
+func (v *VaultBackupScheduleList) DeepCopy() *VaultBackupScheduleList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultBackupScheduleList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultBackupScheduleList) DeepCopyInto(out *VaultBackupScheduleList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultBackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultBackupScheduleSpec) DeepCopyInto(out *VaultBackupScheduleSpec) {
+	*out = *v
+	out.Interval = v.Interval
+	v.Destination.DeepCopyInto(&out.Destination)
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *BackupDestination) DeepCopyInto(out *BackupDestination) {
+	*out = *v
+	if v.S3 != nil {
+		in, out := &v.S3, &out.S3
+		*out = new(S3Destination)
+		**out = **in
+	}
+	if v.GCS != nil {
+		in, out := &v.GCS, &out.GCS
+		*out = new(GCSDestination)
+		**out = **in
+	}
+	if v.Azure != nil {
+		in, out := &v.Azure, &out.Azure
+		*out = new(AzureDestination)
+		**out = **in
+	}
+}
+
+// DeepCopy returns a deep copy of BackupDestination
+func (v *BackupDestination) DeepCopy() *BackupDestination {
+	if v == nil {
+		return nil
+	}
+	out := new(BackupDestination)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultBackupScheduleStatus) DeepCopyInto(out *VaultBackupScheduleStatus) {
+	*out = *v
+	if v.Conditions != nil {
+		in, out := &v.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if v.LastSnapshotTime != nil {
+		in, out := &v.LastSnapshotTime, &out.LastSnapshotTime
+		*out = (*in).DeepCopy()
+	}
+	if v.Snapshots != nil {
+		in, out := &v.Snapshots, &out.Snapshots
+		*out = make([]BackupSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *BackupSnapshot) DeepCopyInto(out *BackupSnapshot) {
+	*out = *v
+	v.Timestamp.DeepCopyInto(&out.Timestamp)
+}
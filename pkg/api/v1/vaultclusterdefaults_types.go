@@ -0,0 +1,413 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterDefaultsName is the name of the VaultClusterDefaults object the operator reads.
+const ClusterDefaultsName = "default"
+
+// UnsealSettings are settings shared by all vault instances of a VaultUnsealConfig.
+// Unset fields are inherited from VaultClusterDefaults, then from operator flags.
+type UnsealSettings struct {
+	// Timeout bounds a single reconciliation of a VaultUnsealConfig
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RequeueAfter is the interval between periodic seal checks
+	// +optional
+	RequeueAfter *metav1.Duration `json:"requeueAfter,omitempty"`
+
+	// Retry controls how soon a failed or still-sealed instance is retried
+	// +optional
+	Retry *RetrySettings `json:"retry,omitempty"`
+
+	// TLSSkipVerify disables TLS certificate verification for instances that do not set it themselves
+	// +optional
+	TLSSkipVerify *bool `json:"tlsSkipVerify,omitempty"`
+
+	// Threshold is the number of unseal keys required for instances that do not set one
+	// +optional
+	Threshold *int `json:"threshold,omitempty"`
+
+	// StrictThreshold fails unsealing when a configured threshold exceeds the one
+	// Vault reports. By default only the keys Vault requires are submitted.
+	// +optional
+	StrictThreshold *bool `json:"strictThreshold,omitempty"`
+
+	// MaxConcurrentUnseals bounds the instances of a config unsealed by one
+	// reconciliation; further sealed instances are deferred to the next retry,
+	// so a cluster recovering from a mass seal is unsealed in waves
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentUnseals *int32 `json:"maxConcurrentUnseals,omitempty"`
+
+	// Hysteresis requires consecutive checks to agree before an instance changes
+	// between ready and not ready, so transient blips do not flip the Ready condition
+	// +optional
+	Hysteresis *ReadyHysteresis `json:"hysteresis,omitempty"`
+
+	// ResealDetection flags instances that seal again soon after every unseal,
+	// such as a crash-looping Vault, and can slow down their unseals
+	// +optional
+	ResealDetection *ResealDetection `json:"resealDetection,omitempty"`
+
+	// QuarantineAfter is the number of failures in a row that Vault attributes
+	// to invalid keys before the instance is quarantined: the same keys are not
+	// submitted again until they or the spec change. 0 disables quarantine.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	QuarantineAfter *int32 `json:"quarantineAfter,omitempty"`
+
+	// Notifications are sinks that receive unseal events; an empty list disables inherited sinks
+	// +optional
+	Notifications []NotificationSink `json:"notifications,omitempty"`
+}
+
+// RetrySettings controls retries of failed unseal attempts
+type RetrySettings struct {
+	// Interval is the requeue delay after a reconciliation that left an instance sealed or failing
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ReadyHysteresis debounces the ready state of instances
+type ReadyHysteresis struct {
+	// ReadyThreshold is the number of consecutive unsealed checks before a
+	// not-ready instance counts as ready again (default: 1)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReadyThreshold *int32 `json:"readyThreshold,omitempty"`
+
+	// NotReadyThreshold is the number of consecutive sealed or failed checks
+	// before a ready instance counts as not ready (default: 1)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NotReadyThreshold *int32 `json:"notReadyThreshold,omitempty"`
+}
+
+// ResealDetection configures the detection of instances that keep sealing again
+type ResealDetection struct {
+	// Window is how soon after an unseal a new seal counts as a rapid reseal (default: 10m)
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// Threshold is the number of rapid reseals in a row that raise FrequentReseal (default: 3)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Threshold *int32 `json:"threshold,omitempty"`
+
+	// UnsealDelay holds off unsealing an instance with FrequentReseal until this
+	// long after its last unseal (default: unseal at once)
+	// +optional
+	UnsealDelay *metav1.Duration `json:"unsealDelay,omitempty"`
+}
+
+// NotificationSink receives unseal events. Exactly one sink type must be set.
+type NotificationSink struct {
+	// Name identifies the sink in logs
+	Name string `json:"name"`
+
+	// Webhook posts events as JSON to an HTTP endpoint
+	// +optional
+	Webhook *WebhookSink `json:"webhook,omitempty"`
+
+	// CloudEvents posts events as CloudEvents in binary HTTP mode, e.g. to a Knative broker
+	// +optional
+	CloudEvents *CloudEventsSink `json:"cloudEvents,omitempty"`
+
+	// NATS publishes events to a NATS subject
+	// +optional
+	NATS *NATSSink `json:"nats,omitempty"`
+
+	// KafkaRESTProxy produces events to a Kafka topic through a Kafka REST proxy;
+	// the native Kafka protocol is not supported
+	// +optional
+	KafkaRESTProxy *KafkaRESTProxySink `json:"kafkaRestProxy,omitempty"`
+}
+
+// WebhookSink posts events as JSON to an HTTP endpoint
+type WebhookSink struct {
+	// URL receives a POST request per event
+	URL string `json:"url"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+}
+
+// CloudEventsSink posts events as CloudEvents using the HTTP protocol binding
+type CloudEventsSink struct {
+	// URL receives a POST request per event, e.g. a Knative broker ingress
+	URL string `json:"url"`
+
+	// Source overrides the ce-source attribute (default: the path of the VaultUnsealConfig)
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+}
+
+// NATSSink publishes events as JSON messages to a NATS subject
+type NATSSink struct {
+	// ConnectionSecret names a Secret in the namespace of the VaultUnsealConfig
+	// holding the server url (nats:// or tls://) and optionally a token or a
+	// username and password
+	ConnectionSecret string `json:"connectionSecret"`
+
+	// Subject receives a message per event
+	Subject string `json:"subject"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+}
+
+// KafkaRESTProxySink produces events as JSON records to a Kafka topic through the
+// REST proxy API (v2), as served by the Confluent REST Proxy and the Strimzi Kafka Bridge
+type KafkaRESTProxySink struct {
+	// ConnectionSecret names a Secret in the namespace of the VaultUnsealConfig
+	// holding the proxy url and optionally a token or a username and password
+	ConnectionSecret string `json:"connectionSecret"`
+
+	// Topic receives a record per event, keyed by the vault instance
+	Topic string `json:"topic"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:resource:scope=Cluster
+
+// VaultClusterDefaults is the Schema for the vaultclusterdefaults API. The object
+// named "default" supplies settings inherited by every VaultUnsealConfig.
+type VaultClusterDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultClusterDefaultsSpec `json:"spec,omitempty"`
+}
+
+// VaultClusterDefaultsSpec defines the operator-wide default settings
+type VaultClusterDefaultsSpec struct {
+	UnsealSettings `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultClusterDefaultsList contains a list of VaultClusterDefaults
+type VaultClusterDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultClusterDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultClusterDefaults{}, &VaultClusterDefaultsList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultClusterDefaults) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultClusterDefaults
+func (v *VaultClusterDefaults) DeepCopy() *VaultClusterDefaults {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultClusterDefaults)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultClusterDefaults) DeepCopyInto(out *VaultClusterDefaults) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Spec.UnsealSettings.DeepCopyInto(&out.Spec.UnsealSettings)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultClusterDefaultsList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultClusterDefaultsList
+func (v *VaultClusterDefaultsList) DeepCopy() *VaultClusterDefaultsList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultClusterDefaultsList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultClusterDefaultsList) DeepCopyInto(out *VaultClusterDefaultsList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultClusterDefaults, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of UnsealSettings
+func (v *UnsealSettings) DeepCopy() *UnsealSettings {
+	if v == nil {
+		return nil
+	}
+	out := new(UnsealSettings)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *UnsealSettings) DeepCopyInto(out *UnsealSettings) {
+	*out = *v
+	if v.Timeout != nil {
+		in, out := &v.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if v.RequeueAfter != nil {
+		in, out := &v.RequeueAfter, &out.RequeueAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if v.Retry != nil {
+		in, out := &v.Retry, &out.Retry
+		*out = new(RetrySettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.TLSSkipVerify != nil {
+		in, out := &v.TLSSkipVerify, &out.TLSSkipVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if v.Threshold != nil {
+		in, out := &v.Threshold, &out.Threshold
+		*out = new(int)
+		**out = **in
+	}
+	if v.StrictThreshold != nil {
+		in, out := &v.StrictThreshold, &out.StrictThreshold
+		*out = new(bool)
+		**out = **in
+	}
+	if v.MaxConcurrentUnseals != nil {
+		in, out := &v.MaxConcurrentUnseals, &out.MaxConcurrentUnseals
+		*out = new(int32)
+		**out = **in
+	}
+	if v.Hysteresis != nil {
+		in, out := &v.Hysteresis, &out.Hysteresis
+		*out = new(ReadyHysteresis)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.ResealDetection != nil {
+		in, out := &v.ResealDetection, &out.ResealDetection
+		*out = new(ResealDetection)
+		(*in).DeepCopyInto(*out)
+	}
+	if v.QuarantineAfter != nil {
+		in, out := &v.QuarantineAfter, &out.QuarantineAfter
+		*out = new(int32)
+		**out = **in
+	}
+	if v.Notifications != nil {
+		in, out := &v.Notifications, &out.Notifications
+		*out = make([]NotificationSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *RetrySettings) DeepCopyInto(out *RetrySettings) {
+	*out = *v
+	if v.Interval != nil {
+		in, out := &v.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *ReadyHysteresis) DeepCopyInto(out *ReadyHysteresis) {
+	*out = *v
+	if v.ReadyThreshold != nil {
+		in, out := &v.ReadyThreshold, &out.ReadyThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if v.NotReadyThreshold != nil {
+		in, out := &v.NotReadyThreshold, &out.NotReadyThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *ResealDetection) DeepCopyInto(out *ResealDetection) {
+	*out = *v
+	if v.Window != nil {
+		in, out := &v.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if v.Threshold != nil {
+		in, out := &v.Threshold, &out.Threshold
+		*out = new(int32)
+		**out = **in
+	}
+	if v.UnsealDelay != nil {
+		in, out := &v.UnsealDelay, &out.UnsealDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *NotificationSink) DeepCopyInto(out *NotificationSink) {
+	*out = *v
+	if v.Webhook != nil {
+		in, out := &v.Webhook, &out.Webhook
+		*out = new(WebhookSink)
+		**out = **in
+	}
+	if v.CloudEvents != nil {
+		in, out := &v.CloudEvents, &out.CloudEvents
+		*out = new(CloudEventsSink)
+		**out = **in
+	}
+	if v.NATS != nil {
+		in, out := &v.NATS, &out.NATS
+		*out = new(NATSSink)
+		**out = **in
+	}
+	if v.KafkaRESTProxy != nil {
+		in, out := &v.KafkaRESTProxy, &out.KafkaRESTProxy
+		*out = new(KafkaRESTProxySink)
+		**out = **in
+	}
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FleetStatusName is the name of the VaultFleetStatus object maintained by the operator.
+const FleetStatusName = "default"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Configs",type=integer,JSONPath=`.status.totalConfigs`
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.status.totalInstances`
+// +kubebuilder:printcolumn:name="Sealed",type=integer,JSONPath=`.status.sealedInstances`
+// +kubebuilder:printcolumn:name="Failing",type=integer,JSONPath=`.status.failingInstances`
+// +kubebuilder:printcolumn:name="Last Transition",type=date,JSONPath=`.status.lastTransitionTime`
+
+// VaultFleetStatus is the Schema for the vaultfleetstatuses API. The operator keeps
+// a single object named "default" summarizing every VaultUnsealConfig in the cluster.
+type VaultFleetStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status VaultFleetStatusStatus `json:"status,omitempty"`
+}
+
+// VaultFleetStatusStatus defines the aggregated state of all VaultUnsealConfigs
+type VaultFleetStatusStatus struct {
+	// TotalConfigs is the number of VaultUnsealConfigs
+	TotalConfigs int `json:"totalConfigs"`
+
+	// ReadyConfigs is the number of VaultUnsealConfigs whose instances are all unsealed
+	ReadyConfigs int `json:"readyConfigs"`
+
+	// TotalInstances is the number of vault instances across all configs
+	TotalInstances int `json:"totalInstances"`
+
+	// SealedInstances is the number of vault instances reported as sealed
+	SealedInstances int `json:"sealedInstances"`
+
+	// FailingInstances is the number of vault instances whose last check or unseal failed
+	FailingInstances int `json:"failingInstances"`
+
+	// LastTransitionTime is the most recent Ready condition transition of any config
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// LastUpdated is the time the summary was last computed
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// Configs summarizes each VaultUnsealConfig
+	// +optional
+	Configs []FleetConfigSummary `json:"configs,omitempty"`
+}
+
+// FleetConfigSummary summarizes a single VaultUnsealConfig
+type FleetConfigSummary struct {
+	// Namespace of the VaultUnsealConfig
+	Namespace string `json:"namespace"`
+
+	// Name of the VaultUnsealConfig
+	Name string `json:"name"`
+
+	// Ready reports whether the Ready condition of the config is True
+	Ready bool `json:"ready"`
+
+	// Instances is the number of vault instances of the config
+	Instances int `json:"instances"`
+
+	// SealedInstances is the number of sealed instances of the config
+	SealedInstances int `json:"sealedInstances"`
+
+	// FailingInstances is the number of failing instances of the config
+	FailingInstances int `json:"failingInstances"`
+
+	// LastTransitionTime is the last transition of the config's Ready condition
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultFleetStatusList contains a list of VaultFleetStatus
+type VaultFleetStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultFleetStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultFleetStatus{}, &VaultFleetStatusList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultFleetStatus) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultFleetStatus
+func (v *VaultFleetStatus) DeepCopy() *VaultFleetStatus {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultFleetStatus)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultFleetStatus) DeepCopyInto(out *VaultFleetStatus) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultFleetStatusList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultFleetStatusList
+func (v *VaultFleetStatusList) DeepCopy() *VaultFleetStatusList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultFleetStatusList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultFleetStatusList) DeepCopyInto(out *VaultFleetStatusList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultFleetStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultFleetStatusStatus) DeepCopyInto(out *VaultFleetStatusStatus) {
+	*out = *v
+	if v.LastTransitionTime != nil {
+		out.LastTransitionTime = v.LastTransitionTime.DeepCopy()
+	}
+	if v.LastUpdated != nil {
+		out.LastUpdated = v.LastUpdated.DeepCopy()
+	}
+	if v.Configs != nil {
+		in, out := &v.Configs, &out.Configs
+		*out = make([]FleetConfigSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *FleetConfigSummary) DeepCopyInto(out *FleetConfigSummary) {
+	*out = *v
+	if v.LastTransitionTime != nil {
+		out.LastTransitionTime = v.LastTransitionTime.DeepCopy()
+	}
+}
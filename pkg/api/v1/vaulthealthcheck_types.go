@@ -0,0 +1,174 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.spec.endpoint`
+// +kubebuilder:printcolumn:name="Sealed",type=boolean,JSONPath=`.status.sealed`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Last Check",type=date,JSONPath=`.status.lastCheckTime`
+
+// VaultHealthCheck is the Schema for the vaulthealthchecks API. It observes a
+// Vault without holding unseal keys and never attempts to unseal it.
+type VaultHealthCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultHealthCheckSpec   `json:"spec,omitempty"`
+	Status VaultHealthCheckStatus `json:"status,omitempty"`
+}
+
+// VaultHealthCheckSpec defines the desired state of VaultHealthCheck
+type VaultHealthCheckSpec struct {
+	// Endpoint is the URL of the vault instance to observe
+	Endpoint string `json:"endpoint"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+
+	// Interval is the time between checks (default: 30s)
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// VaultHealthCheckStatus defines the observed state of VaultHealthCheck
+type VaultHealthCheckStatus struct {
+	// Reachable reports whether the last check got a response from Vault
+	// +optional
+	Reachable bool `json:"reachable"`
+
+	// Initialized reports whether Vault is initialized
+	// +optional
+	Initialized bool `json:"initialized"`
+
+	// Sealed reports whether Vault is sealed
+	// +optional
+	Sealed bool `json:"sealed"`
+
+	// Standby reports whether the node is a standby
+	// +optional
+	Standby bool `json:"standby"`
+
+	// Version is the reported Vault version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ClusterName is the reported cluster name
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// ClusterID is the reported cluster ID
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+
+	// LastCheckTime is when Vault was last checked
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// Conditions represent the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultHealthCheckList contains a list of VaultHealthCheck
+type VaultHealthCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultHealthCheck `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultHealthCheck{}, &VaultHealthCheckList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultHealthCheck) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultHealthCheck
+func (v *VaultHealthCheck) DeepCopy() *VaultHealthCheck {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultHealthCheck)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultHealthCheck) DeepCopyInto(out *VaultHealthCheck) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Spec.DeepCopyInto(&out.Spec)
+	v.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultHealthCheckList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultHealthCheckList
+func (v *VaultHealthCheckList) DeepCopy() *VaultHealthCheckList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultHealthCheckList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultHealthCheckList) DeepCopyInto(out *VaultHealthCheckList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultHealthCheckSpec) DeepCopyInto(out *VaultHealthCheckSpec) {
+	*out = *v
+	if v.Interval != nil {
+		in, out := &v.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultHealthCheckStatus) DeepCopyInto(out *VaultHealthCheckStatus) {
+	*out = *v
+	if v.LastCheckTime != nil {
+		out.LastCheckTime = v.LastCheckTime.DeepCopy()
+	}
+	if v.Conditions != nil {
+		in, out := &v.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
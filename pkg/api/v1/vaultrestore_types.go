@@ -0,0 +1,263 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RestorePhase is the lifecycle phase of a VaultRestore
+type RestorePhase string
+
+const (
+	// RestorePhasePending means the restore has not started yet
+	RestorePhasePending RestorePhase = "Pending"
+	// RestorePhaseRestoring means the snapshot is being streamed into Vault
+	RestorePhaseRestoring RestorePhase = "Restoring"
+	// RestorePhaseUnsealing means the operator is waiting for Vault to be unsealed again
+	RestorePhaseUnsealing RestorePhase = "Unsealing"
+	// RestorePhaseVerifying means the restored Vault is being checked
+	RestorePhaseVerifying RestorePhase = "Verifying"
+	// RestorePhaseCompleted means the restore finished and was verified
+	RestorePhaseCompleted RestorePhase = "Completed"
+	// RestorePhaseFailed means the restore failed; it is not retried
+	RestorePhaseFailed RestorePhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Snapshot",type=string,JSONPath=`.status.snapshotKey`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultRestore is the Schema for the vaultrestores API
+type VaultRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultRestoreSpec   `json:"spec,omitempty"`
+	Status VaultRestoreStatus `json:"status,omitempty"`
+}
+
+// VaultRestoreSpec defines the desired state of VaultRestore
+type VaultRestoreSpec struct {
+	// Endpoint is the URL of the vault instance to restore into
+	Endpoint string `json:"endpoint"`
+
+	// TLSSkipVerify disables TLS certificate verification (default: false)
+	// +optional
+	TLSSkipVerify bool `json:"tlsSkipVerify,omitempty"`
+
+	// TokenSecretRef references a Vault token allowed to update sys/storage/raft/snapshot-force
+	TokenSecretRef SecretKeyRef `json:"tokenSecretRef"`
+
+	// Source selects the snapshot to restore
+	Source RestoreSource `json:"source"`
+
+	// Force restores through snapshot-force, required when the snapshot comes
+	// from a different cluster (default: true)
+	// +optional
+	Force *bool `json:"force,omitempty"`
+
+	// UnsealConfigRef names a VaultUnsealConfig in the same namespace that is
+	// asked to re-unseal the Vault once the snapshot has been applied
+	// +optional
+	UnsealConfigRef string `json:"unsealConfigRef,omitempty"`
+
+	// UnsealTimeout bounds how long to wait for Vault to be unsealed after the restore (default: 5m)
+	// +optional
+	UnsealTimeout *metav1.Duration `json:"unsealTimeout,omitempty"`
+}
+
+// RestoreSource selects a stored snapshot. Either BackupScheduleRef or Destination must be set.
+type RestoreSource struct {
+	// BackupScheduleRef names a VaultBackupSchedule in the same namespace whose destination is used
+	// +optional
+	BackupScheduleRef string `json:"backupScheduleRef,omitempty"`
+
+	// Destination is the object storage holding the snapshot
+	// +optional
+	Destination *BackupDestination `json:"destination,omitempty"`
+
+	// Key is the object key to restore; defaults to the latest snapshot of BackupScheduleRef
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// VaultRestoreStatus defines the observed state of VaultRestore
+type VaultRestoreStatus struct {
+	// Phase is the current restore phase
+	// +optional
+	Phase RestorePhase `json:"phase,omitempty"`
+
+	// Message describes the current phase or failure
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// SnapshotKey is the object key being restored
+	// +optional
+	SnapshotKey string `json:"snapshotKey,omitempty"`
+
+	// BytesRestored is the number of snapshot bytes streamed into Vault
+	// +optional
+	BytesRestored int64 `json:"bytesRestored,omitempty"`
+
+	// StartTime is when the restore started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// RestoredTime is when the snapshot was accepted by Vault
+	// +optional
+	RestoredTime *metav1.Time `json:"restoredTime,omitempty"`
+
+	// CompletionTime is when the restore completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Verification holds the state observed after the restore
+	// +optional
+	Verification *RestoreVerification `json:"verification,omitempty"`
+
+	// Conditions represent the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RestoreVerification describes the Vault state observed after a restore
+type RestoreVerification struct {
+	// Initialized reports whether Vault is initialized
+	Initialized bool `json:"initialized"`
+
+	// Sealed reports whether Vault is sealed
+	Sealed bool `json:"sealed"`
+
+	// Version is the reported Vault version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ClusterID is the reported cluster ID
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultRestoreList contains a list of VaultRestore
+type VaultRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultRestore{}, &VaultRestoreList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultRestore) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultRestore
+func (v *VaultRestore) DeepCopy() *VaultRestore {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultRestore)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultRestore) DeepCopyInto(out *VaultRestore) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Spec.DeepCopyInto(&out.Spec)
+	v.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultRestoreList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultRestoreList
+func (v *VaultRestoreList) DeepCopy() *VaultRestoreList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultRestoreList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultRestoreList) DeepCopyInto(out *VaultRestoreList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultRestoreSpec) DeepCopyInto(out *VaultRestoreSpec) {
+	*out = *v
+	v.Source.DeepCopyInto(&out.Source)
+	if v.Force != nil {
+		in, out := &v.Force, &out.Force
+		*out = new(bool)
+		**out = **in
+	}
+	if v.UnsealTimeout != nil {
+		in, out := &v.UnsealTimeout, &out.UnsealTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *RestoreSource) DeepCopyInto(out *RestoreSource) {
+	*out = *v
+	if v.Destination != nil {
+		out.Destination = v.Destination.DeepCopy()
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultRestoreStatus) DeepCopyInto(out *VaultRestoreStatus) {
+	*out = *v
+	if v.StartTime != nil {
+		out.StartTime = v.StartTime.DeepCopy()
+	}
+	if v.RestoredTime != nil {
+		out.RestoredTime = v.RestoredTime.DeepCopy()
+	}
+	if v.CompletionTime != nil {
+		out.CompletionTime = v.CompletionTime.DeepCopy()
+	}
+	if v.Verification != nil {
+		in, out := &v.Verification, &out.Verification
+		*out = new(RestoreVerification)
+		**out = **in
+	}
+	if v.Conditions != nil {
+		in, out := &v.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Results of a recorded unseal attempt
+const (
+	// AuditResultUnsealed records an instance the operator unsealed
+	AuditResultUnsealed = "Unsealed"
+	// AuditResultFailed records a check or unseal of an instance that failed
+	AuditResultFailed = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// +kubebuilder:resource:shortName=vua
+// +kubebuilder:printcolumn:name="Config",type=string,JSONPath=`.spec.config`
+// +kubebuilder:printcolumn:name="Instance",type=string,JSONPath=`.spec.instance`
+// +kubebuilder:printcolumn:name="Result",type=string,JSONPath=`.spec.result`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+// +kubebuilder:printcolumn:name="Time",type=date,JSONPath=`.spec.timestamp`
+
+// VaultUnsealAudit is the Schema for the vaultunsealaudits API. The operator
+// creates one per unseal attempt of an instance when auditing is enabled, and
+// deletes it once it outlives the audit TTL or its config.
+type VaultUnsealAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultUnsealAuditSpec `json:"spec,omitempty"`
+}
+
+// VaultUnsealAuditSpec records a single unseal attempt
+type VaultUnsealAuditSpec struct {
+	// Config is the name of the VaultUnsealConfig of the instance
+	Config string `json:"config"`
+
+	// Instance is the name of the vault instance
+	Instance string `json:"instance"`
+
+	// Endpoint is the endpoint of the instance at the time of the attempt
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Timestamp is the time of the attempt
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// Result is Unsealed or Failed
+	// +kubebuilder:validation:Enum=Unsealed;Failed
+	Result string `json:"result"`
+
+	// Reason is the reason code of the outcome
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes the outcome
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ReconcileID identifies the reconcile that made the attempt in the
+	// operator logs and events
+	// +optional
+	ReconcileID string `json:"reconcileID,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultUnsealAuditList contains a list of VaultUnsealAudit
+type VaultUnsealAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultUnsealAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultUnsealAudit{}, &VaultUnsealAuditList{})
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultUnsealAudit) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultUnsealAudit
+func (v *VaultUnsealAudit) DeepCopy() *VaultUnsealAudit {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultUnsealAudit)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultUnsealAudit) DeepCopyInto(out *VaultUnsealAudit) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	v.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopyObject returns a deep copy of the object
+func (v *VaultUnsealAuditList) DeepCopyObject() runtime.Object {
+	if c := v.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy returns a deep copy of VaultUnsealAuditList
+func (v *VaultUnsealAuditList) DeepCopy() *VaultUnsealAuditList {
+	if v == nil {
+		return nil
+	}
+	out := new(VaultUnsealAuditList)
+	v.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultUnsealAuditList) DeepCopyInto(out *VaultUnsealAuditList) {
+	*out = *v
+	out.TypeMeta = v.TypeMeta
+	v.ListMeta.DeepCopyInto(&out.ListMeta)
+	if v.Items != nil {
+		in, out := &v.Items, &out.Items
+		*out = make([]VaultUnsealAudit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopyInto copies all fields from this object into another
+func (v *VaultUnsealAuditSpec) DeepCopyInto(out *VaultUnsealAuditSpec) {
+	*out = *v
+	v.Timestamp.DeepCopyInto(&out.Timestamp)
+}
@@ -0,0 +1,177 @@
+// Package backup uploads Vault Raft snapshots to object storage.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+const (
+	// AccessKeyIDKey is the credentials Secret key holding an S3/GCS access key ID.
+	AccessKeyIDKey = "accessKeyId"
+	// SecretAccessKeyKey is the credentials Secret key holding an S3/GCS secret access key.
+	SecretAccessKeyKey = "secretAccessKey"
+	// SASTokenKey is the credentials Secret key holding an Azure SAS token.
+	SASTokenKey = "sasToken"
+
+	// DefaultS3Region is used when an S3 destination does not specify a region.
+	DefaultS3Region = "us-east-1"
+	// DefaultHTTPTimeout bounds a single upload, download or delete request.
+	DefaultHTTPTimeout = 10 * time.Minute
+
+	// gcsEndpoint serves the XML API of Google Cloud Storage.
+	gcsEndpoint = "https://storage.googleapis.com"
+)
+
+// ObjectStore stores snapshot objects
+type ObjectStore interface {
+	// Put uploads size bytes read from body under key
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+
+	// Get opens the object stored under key; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Credentials holds the data of a destination's credentials Secret
+type Credentials map[string][]byte
+
+func (c Credentials) require(key string) (string, error) {
+	value, ok := c[key]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("credentials secret is missing key %q", key)
+	}
+	return string(value), nil
+}
+
+// CredentialsSecretName returns the name of the credentials Secret used by the destination.
+func CredentialsSecretName(dest *vaultv1.BackupDestination) (string, error) {
+	switch {
+	case dest.S3 != nil:
+		return dest.S3.CredentialsSecretRef, nil
+	case dest.GCS != nil:
+		return dest.GCS.CredentialsSecretRef, nil
+	case dest.Azure != nil:
+		return dest.Azure.CredentialsSecretRef, nil
+	default:
+		return "", fmt.Errorf("destination must set one of s3, gcs or azure")
+	}
+}
+
+// ObjectKey builds the object key for a snapshot taken at the given time.
+func ObjectKey(dest *vaultv1.BackupDestination, name string, at time.Time) string {
+	var prefix string
+	switch {
+	case dest.S3 != nil:
+		prefix = dest.S3.Prefix
+	case dest.GCS != nil:
+		prefix = dest.GCS.Prefix
+	case dest.Azure != nil:
+		prefix = dest.Azure.Prefix
+	}
+	return path.Join(prefix, fmt.Sprintf("%s-%s.snap", name, at.UTC().Format("20060102T150405Z")))
+}
+
+// Endpoint returns the URL of the object store a destination is written to,
+// empty for a destination setting none of s3, gcs or azure.
+func Endpoint(dest *vaultv1.BackupDestination) string {
+	switch {
+	case dest.S3 != nil && dest.S3.Endpoint != "":
+		return dest.S3.Endpoint
+	case dest.S3 != nil:
+		region := dest.S3.Region
+		if region == "" {
+			region = DefaultS3Region
+		}
+		if dest.S3.ForcePathStyle {
+			return fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", dest.S3.Bucket, region)
+	case dest.GCS != nil:
+		return gcsEndpoint
+	case dest.Azure != nil:
+		return fmt.Sprintf("https://%s.blob.core.windows.net", dest.Azure.StorageAccount)
+	default:
+		return ""
+	}
+}
+
+// NewObjectStore creates the ObjectStore matching the configured destination.
+func NewObjectStore(dest *vaultv1.BackupDestination, creds Credentials) (ObjectStore, error) {
+	httpClient := &http.Client{Timeout: DefaultHTTPTimeout}
+
+	switch {
+	case dest.S3 != nil:
+		accessKey, secretKey, err := hmacCredentials(creds)
+		if err != nil {
+			return nil, err
+		}
+		region := dest.S3.Region
+		if region == "" {
+			region = DefaultS3Region
+		}
+		return NewS3Store(S3Config{
+			Bucket:          dest.S3.Bucket,
+			Region:          region,
+			Endpoint:        dest.S3.Endpoint,
+			ForcePathStyle:  dest.S3.ForcePathStyle,
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+		}, httpClient)
+	case dest.GCS != nil:
+		accessKey, secretKey, err := hmacCredentials(creds)
+		if err != nil {
+			return nil, err
+		}
+		// GCS accepts SigV4-signed requests with HMAC keys through its XML API
+		return NewS3Store(S3Config{
+			Bucket:          dest.GCS.Bucket,
+			Region:          "auto",
+			Endpoint:        gcsEndpoint,
+			ForcePathStyle:  true,
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+		}, httpClient)
+	case dest.Azure != nil:
+		sas, err := creds.require(SASTokenKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureStore(AzureConfig{
+			StorageAccount: dest.Azure.StorageAccount,
+			Container:      dest.Azure.Container,
+			SASToken:       sas,
+		}, httpClient)
+	default:
+		return nil, fmt.Errorf("destination must set one of s3, gcs or azure")
+	}
+}
+
+func hmacCredentials(creds Credentials) (string, string, error) {
+	accessKey, err := creds.require(AccessKeyIDKey)
+	if err != nil {
+		return "", "", err
+	}
+	secretKey, err := creds.require(SecretAccessKeyKey)
+	if err != nil {
+		return "", "", err
+	}
+	return accessKey, secretKey, nil
+}
+
+// checkResponse converts non-2xx responses into errors, including a bounded part of the body.
+func checkResponse(op string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s failed with status %d: %s", op, resp.StatusCode, string(body))
+}
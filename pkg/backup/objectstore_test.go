@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   []byte
+}
+
+func newRecordingServer(t *testing.T, status int) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{
+			method: r.Method,
+			path:   r.URL.EscapedPath(),
+			query:  r.URL.RawQuery,
+			header: r.Header.Clone(),
+			body:   body,
+		})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestS3StorePutSignsRequest(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusOK)
+
+	store, err := NewS3Store(S3Config{
+		Bucket:          "backups",
+		Region:          "eu-west-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}, server.Client())
+	require.NoError(t, err)
+	store.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	payload := []byte("raft-snapshot-data")
+	err = store.Put(context.Background(), "vault/prod-20240102T030405Z.snap", bytes.NewReader(payload), int64(len(payload)))
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "/backups/vault/prod-20240102T030405Z.snap", req.path)
+	assert.Equal(t, payload, req.body)
+	assert.Equal(t, "20240102T030405Z", req.header.Get("X-Amz-Date"))
+	assert.Equal(t, sha256Hex(payload), req.header.Get("X-Amz-Content-Sha256"))
+	assert.True(t, strings.HasPrefix(req.header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240102/eu-west-1/s3/aws4_request, "+
+			"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="))
+}
+
+func TestS3StoreErrors(t *testing.T) {
+	server, _ := newRecordingServer(t, http.StatusForbidden)
+
+	store, err := NewS3Store(S3Config{Bucket: "b", Region: "us-east-1", Endpoint: server.URL}, server.Client())
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "k", bytes.NewReader([]byte("x")), 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+}
+
+func TestS3StoreDeleteIgnoresMissing(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusNotFound)
+
+	store, err := NewS3Store(S3Config{Bucket: "b", Region: "us-east-1", Endpoint: server.URL}, server.Client())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(context.Background(), "old.snap"))
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodDelete, (*requests)[0].method)
+	assert.Equal(t, "/b/old.snap", (*requests)[0].path)
+}
+
+func TestS3StoreGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/b/raft/a.snap" || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("snapshot"))
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Config{Bucket: "b", Region: "us-east-1", Endpoint: server.URL}, server.Client())
+	require.NoError(t, err)
+
+	body, err := store.Get(context.Background(), "raft/a.snap")
+	require.NoError(t, err)
+	defer func() { _ = body.Close() }()
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", string(data))
+
+	_, err = store.Get(context.Background(), "raft/missing.snap")
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestAzureStorePut(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusCreated)
+
+	store, err := NewAzureStore(AzureConfig{
+		StorageAccount: "acct",
+		Container:      "snapshots",
+		SASToken:       "?sv=2021-08-06&sig=abc",
+		Endpoint:       server.URL + "/acct",
+	}, server.Client())
+	require.NoError(t, err)
+
+	payload := []byte("snapshot")
+	require.NoError(t, store.Put(context.Background(), "daily/a.snap", bytes.NewReader(payload), int64(len(payload))))
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "/acct/snapshots/daily/a.snap", req.path)
+	assert.Equal(t, "sig=abc&sv=2021-08-06", req.query)
+	assert.Equal(t, "BlockBlob", req.header.Get("x-ms-blob-type"))
+	assert.Equal(t, payload, req.body)
+}
+
+func TestNewObjectStore(t *testing.T) {
+	creds := Credentials{AccessKeyIDKey: []byte("id"), SecretAccessKeyKey: []byte("secret")}
+
+	_, err := NewObjectStore(&vaultv1.BackupDestination{}, creds)
+	assert.Error(t, err, "empty destination must be rejected")
+
+	_, err = NewObjectStore(&vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Bucket: "b"}}, Credentials{})
+	assert.ErrorContains(t, err, AccessKeyIDKey)
+
+	store, err := NewObjectStore(&vaultv1.BackupDestination{GCS: &vaultv1.GCSDestination{Bucket: "b"}}, creds)
+	require.NoError(t, err)
+	s3Store, ok := store.(*S3Store)
+	require.True(t, ok)
+	assert.Equal(t, "https://storage.googleapis.com/b/k", s3Store.objectURL("k"))
+
+	_, err = NewObjectStore(&vaultv1.BackupDestination{Azure: &vaultv1.AzureDestination{
+		StorageAccount: "a", Container: "c",
+	}}, creds)
+	assert.ErrorContains(t, err, SASTokenKey)
+}
+
+func TestObjectKey(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	dest := &vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Prefix: "prod/raft"}}
+	assert.Equal(t, "prod/raft/vault-20240506T070809Z.snap", ObjectKey(dest, "vault", at))
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		dest     vaultv1.BackupDestination
+		endpoint string
+	}{
+		{vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Bucket: "b"}}, "https://b.s3.us-east-1.amazonaws.com"},
+		{vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Bucket: "b", Region: "eu-west-1", ForcePathStyle: true}},
+			"https://s3.eu-west-1.amazonaws.com"},
+		{vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Bucket: "b", Endpoint: "http://minio.minio.svc:9000"}},
+			"http://minio.minio.svc:9000"},
+		{vaultv1.BackupDestination{GCS: &vaultv1.GCSDestination{Bucket: "b"}}, "https://storage.googleapis.com"},
+		{vaultv1.BackupDestination{Azure: &vaultv1.AzureDestination{StorageAccount: "acct", Container: "c"}},
+			"https://acct.blob.core.windows.net"},
+		{vaultv1.BackupDestination{}, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.endpoint, Endpoint(&tt.dest))
+	}
+}
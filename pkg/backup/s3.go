@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat  = "20060102T150405Z"
+	amzDayFormat   = "20060102"
+)
+
+// S3Config configures an S3Store
+type S3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string
+	ForcePathStyle  bool
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store implements ObjectStore against the S3 REST API using SigV4 signing
+type S3Store struct {
+	config     S3Config
+	baseURL    *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Store creates an S3Store. Virtual-hosted addressing is used unless
+// ForcePathStyle is set or a custom endpoint is configured.
+func NewS3Store(config S3Config, httpClient *http.Client) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket must not be empty")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		if config.ForcePathStyle {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+		} else {
+			endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", config.Bucket, config.Region)
+		}
+	}
+
+	baseURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %w", endpoint, err)
+	}
+
+	if config.Endpoint != "" {
+		config.ForcePathStyle = true
+	}
+
+	return &S3Store{
+		config:     config,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// Put implements ObjectStore
+func (s *S3Store) Put(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	payloadHash, err := hashReadSeeker(body)
+	if err != nil {
+		return fmt.Errorf("failed to hash snapshot: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	s.sign(req, payloadHash)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 put %s: %w", key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return checkResponse("s3 put "+key, resp)
+}
+
+// Get implements ObjectStore
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 get %s: %w", key, err)
+	}
+	if err := checkResponse("s3 get "+key, resp); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete implements ObjectStore
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 delete %s: %w", key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Deleting a missing object is not an error for retention purposes
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse("s3 delete "+key, resp)
+}
+
+func (s *S3Store) objectURL(key string) string {
+	u := *s.baseURL
+	objectPath := "/" + strings.TrimPrefix(key, "/")
+	if s.config.ForcePathStyle {
+		objectPath = "/" + s.config.Bucket + objectPath
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = escapeURIPath(u.Path)
+	return u.String()
+}
+
+// sign adds SigV4 authentication headers to req
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	day := now.Format(amzDayFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host, payloadHash, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapeURIPath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{day, s.config.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), day)
+	signingKey = hmacSHA256(signingKey, s.config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+var emptyPayloadHash = sha256Hex(nil)
+
+func hashReadSeeker(body io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapeURIPath percent-encodes everything except RFC 3986 unreserved characters
+// and the path separator, as required for the SigV4 canonical URI
+func escapeURIPath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if isUnreserved(c) || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,112 @@
+// Package certs serves the TLS certificates of the operator's HTTPS servers
+// from mounted files and reloads them when the files change, e.g. when
+// cert-manager renews a certificate, without restarting the operator.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+)
+
+// Servers whose certificates are reloaded.
+const (
+	ServerWebhook = "webhook"
+	ServerMetrics = "metrics"
+)
+
+const (
+	// DefaultCertName and DefaultKeyName are the files of a certificate
+	// directory, as mounted from a kubernetes.io/tls Secret
+	DefaultCertName = "tls.crt"
+	DefaultKeyName  = "tls.key"
+)
+
+// Reloader serves the certificate of one server. Files are watched for
+// changes and also re-read periodically, as a Secret volume swaps its files
+// through a symlink that not every filesystem reports.
+type Reloader struct {
+	server  string
+	watcher *certwatcher.CertWatcher
+	metrics *metrics.CertificateMetrics
+	logger  logr.Logger
+
+	mu     sync.Mutex
+	loaded bool
+}
+
+// NewReloader loads the certificate and key of server from dir. It fails when
+// they cannot be read, so a missing mount is reported at startup. interval
+// overrides how often the files are re-read when positive; m may be nil.
+func NewReloader(
+	server, dir string,
+	interval time.Duration,
+	m *metrics.CertificateMetrics,
+	logger logr.Logger,
+) (*Reloader, error) {
+	watcher, err := certwatcher.New(filepath.Join(dir, DefaultCertName), filepath.Join(dir, DefaultKeyName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the %s serving certificate: %w", server, err)
+	}
+	if interval > 0 {
+		watcher = watcher.WithWatchInterval(interval)
+	}
+	r := &Reloader{server: server, watcher: watcher, metrics: m, logger: logger}
+	// The callback runs right away for the certificate already loaded
+	watcher.RegisterCallback(r.certificateChanged)
+	return r, nil
+}
+
+// ConfigureTLS makes config serve the current certificate. It is passed to
+// the TLSOpts of a controller-runtime server, which then does not watch the
+// files itself.
+func (r *Reloader) ConfigureTLS(config *tls.Config) {
+	config.GetCertificate = r.watcher.GetCertificate
+}
+
+// Start watches the files until ctx is done.
+func (r *Reloader) Start(ctx context.Context) error {
+	return r.watcher.Start(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica
+// serves webhooks and metrics.
+func (r *Reloader) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Reloader) certificateChanged(cert tls.Certificate) {
+	r.mu.Lock()
+	reload := r.loaded
+	r.loaded = true
+	r.mu.Unlock()
+
+	notAfter := expiry(cert)
+	if r.metrics != nil {
+		r.metrics.RecordCertificate(r.server, notAfter, reload)
+	}
+	if reload {
+		r.logger.Info("reloaded serving certificate", "server", r.server, "notAfter", notAfter)
+	}
+}
+
+// expiry returns the end of validity of the leaf certificate, or the zero
+// time when it cannot be parsed.
+func expiry(cert tls.Certificate) time.Time {
+	leaf := cert.Leaf
+	if leaf == nil && len(cert.Certificate) > 0 {
+		leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+	}
+	if leaf == nil {
+		return time.Time{}
+	}
+	return leaf.NotAfter
+}
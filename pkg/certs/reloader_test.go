@@ -0,0 +1,85 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate valid until notAfter to dir.
+func writeCertificate(t *testing.T, dir string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		Subject:      pkix.Name{CommonName: "vault-autounseal-operator-webhook"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DefaultKeyName),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DefaultCertName),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+}
+
+func TestReloaderServesRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	writeCertificate(t, dir, first)
+
+	certMetrics := metrics.NewCertificateMetrics(prometheus.NewRegistry())
+	reloader, err := NewReloader(ServerWebhook, dir, 50*time.Millisecond, certMetrics, logr.Discard())
+	require.NoError(t, err)
+	config := &tls.Config{}
+	reloader.ConfigureTLS(config)
+
+	served := func() time.Time {
+		cert, err := config.GetCertificate(nil)
+		require.NoError(t, err)
+		return expiry(*cert)
+	}
+	assert.True(t, first.Equal(served()))
+	assert.Equal(t, float64(first.Unix()), testutil.ToFloat64(certMetrics.Expiry.WithLabelValues(ServerWebhook)))
+	assert.Zero(t, testutil.ToFloat64(certMetrics.Reloads.WithLabelValues(ServerWebhook)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = reloader.Start(ctx) }()
+
+	renewed := first.Add(90 * 24 * time.Hour)
+	writeCertificate(t, dir, renewed)
+	assert.Eventually(t, func() bool {
+		return renewed.Equal(served()) &&
+			testutil.ToFloat64(certMetrics.Reloads.WithLabelValues(ServerWebhook)) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, float64(renewed.Unix()), testutil.ToFloat64(certMetrics.Expiry.WithLabelValues(ServerWebhook)))
+}
+
+func TestNewReloaderRequiresCertificate(t *testing.T) {
+	_, err := NewReloader(ServerMetrics, t.TempDir(), 0, nil, logr.Discard())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load the metrics serving certificate")
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+
+// PodExecutor runs a command in a container of a pod.
+type PodExecutor interface {
+	Exec(
+		ctx context.Context, namespace, pod, container string,
+		command []string, stdin io.Reader, stdout, stderr io.Writer,
+	) error
+}
+
+// spdyPodExecutor runs commands through the pods/exec subresource.
+type spdyPodExecutor struct {
+	config *rest.Config
+	client rest.Interface
+}
+
+// NewPodExecutor returns a PodExecutor using the API server of config.
+func NewPodExecutor(config *rest.Config) (PodExecutor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return &spdyPodExecutor{config: config, client: clientset.CoreV1().RESTClient()}, nil
+}
+
+// Exec implements PodExecutor.
+func (e *spdyPodExecutor) Exec(
+	ctx context.Context, namespace, pod, container string,
+	command []string, stdin io.Reader, stdout, stderr io.Writer,
+) error {
+	req := e.client.Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.config, http.MethodPost, req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// execTransport is an http.RoundTripper that sends each request from inside a
+// pod with the wget of the target container, as found in the Vault, OpenBao and
+// Vault Agent images. Vault treats POST like PUT, which wget cannot send.
+// HTTPS certificates are verified by wget against the CA bundle of the
+// container unless skipVerify is set.
+type execTransport struct {
+	executor                  PodExecutor
+	namespace, pod, container string
+	skipVerify                bool
+}
+
+// newExecTransport returns a transport running requests in the pod of access.
+func newExecTransport(
+	executor PodExecutor, namespace string, access *vaultv1.InstanceAccess, skipVerify bool,
+) *execTransport {
+	return &execTransport{
+		executor:   executor,
+		namespace:  namespace,
+		pod:        access.Pod,
+		container:  access.Container,
+		skipVerify: skipVerify,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *execTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		if len(body) == 0 {
+			body = []byte("{}")
+		}
+	default:
+		return nil, fmt.Errorf("method %s is not supported when running requests in pod %s/%s",
+			req.Method, t.namespace, t.pod)
+	}
+
+	command := []string{"wget", "-q", "-S", "-O", "-"}
+	if len(body) > 0 {
+		// wget reads the body from stdin, so key material never appears in a
+		// command line, neither of the exec request nor of a process in the pod
+		command = append(command, "--post-file=/dev/stdin")
+	}
+	if t.skipVerify && req.URL.Scheme == "https" {
+		command = append(command, "--no-check-certificate")
+	}
+	for name, values := range req.Header {
+		// Tokens stay out of the command line; exec access only serves unauthenticated endpoints
+		if strings.EqualFold(name, "X-Vault-Token") {
+			continue
+		}
+		for _, value := range values {
+			command = append(command, "--header", name+": "+value)
+		}
+	}
+	command = append(command, req.URL.String())
+
+	var stdin io.Reader
+	if len(body) > 0 {
+		stdin = bytes.NewReader(body)
+	}
+	var stdout, stderr bytes.Buffer
+	execErr := t.executor.Exec(req.Context(), t.namespace, t.pod, t.container, command, stdin, &stdout, &stderr)
+
+	resp, err := parseWgetResponse(stderr.Bytes(), stdout.Bytes())
+	if err != nil {
+		if execErr != nil {
+			return nil, fmt.Errorf("request in pod %s/%s failed: %w: %s",
+				t.namespace, t.pod, execErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// parseWgetResponse builds a response from the server headers wget -S printed
+// to stderr and the body it wrote to stdout. wget drops the body of error
+// responses, so those carry an empty body.
+func parseWgetResponse(stderr, stdout []byte) (*http.Response, error) {
+	var resp *http.Response
+	scanner := bufio.NewScanner(bytes.NewReader(stderr))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Error responses are reported as "wget: server returned error: HTTP/1.1 503 ..."
+		if i := strings.Index(line, "HTTP/"); i >= 0 {
+			fields := strings.SplitN(line[i:], " ", 3)
+			if len(fields) < 2 {
+				continue
+			}
+			code, err := strconv.Atoi(fields[1])
+			if err != nil {
+				continue
+			}
+			// Redirects print one status line per response; the last one wins
+			resp = &http.Response{
+				Status:     strings.Join(fields[1:], " "),
+				StatusCode: code,
+				Proto:      fields[0],
+				Header:     make(http.Header),
+			}
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && resp != nil {
+			resp.Header.Add(textproto.TrimString(name), textproto.TrimString(value))
+		}
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("no HTTP response in wget output: %s", strings.TrimSpace(string(stderr)))
+	}
+
+	if resp.StatusCode < http.StatusBadRequest {
+		resp.Body = io.NopCloser(bytes.NewReader(stdout))
+		resp.ContentLength = int64(len(stdout))
+	} else {
+		resp.Body = io.NopCloser(bytes.NewReader(nil))
+	}
+	return resp, nil
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// wgetExecutor emulates busybox wget in a pod by sending the request itself
+type wgetExecutor struct {
+	commands [][]string
+	pods     []string
+}
+
+func (e *wgetExecutor) Exec(
+	ctx context.Context, namespace, pod, container string,
+	command []string, stdin io.Reader, stdout, stderr io.Writer,
+) error {
+	e.commands = append(e.commands, command)
+	e.pods = append(e.pods, fmt.Sprintf("%s/%s/%s", namespace, pod, container))
+
+	method, body := http.MethodGet, io.Reader(nil)
+	if stdin != nil {
+		method, body = http.MethodPost, stdin
+	}
+	req, err := http.NewRequestWithContext(ctx, method, command[len(command)-1], body)
+	if err != nil {
+		return err
+	}
+	for i, arg := range command {
+		if arg == "--header" {
+			name, value, _ := strings.Cut(command[i+1], ": ")
+			req.Header.Add(name, value)
+		}
+	}
+	client := http.DefaultClient
+	if slices.Contains(command, "--no-check-certificate") {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		_, _ = fmt.Fprintf(stderr, "wget: server returned error: HTTP/1.1 %s\n", resp.Status)
+		return errors.New("command terminated with exit code 1")
+	}
+	_, _ = fmt.Fprintf(stderr, "  HTTP/1.1 %s\n  Content-Type: %s\n", resp.Status, resp.Header.Get("Content-Type"))
+	_, err = io.Copy(stdout, resp.Body)
+	return err
+}
+
+func TestExecAccessUnsealsThroughPod(t *testing.T) {
+	sealed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/sys/seal-status":
+			_, _ = fmt.Fprintf(w, `{"sealed":%t,"t":1,"n":1}`, sealed)
+		case "/v1/sys/unseal":
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), `"key"`) {
+				http.Error(w, "missing key", http.StatusBadRequest)
+				return
+			}
+			sealed = false
+			_, _ = fmt.Fprint(w, `{"sealed":false,"t":1,"n":1}`)
+		default:
+			http.Error(w, "unexpected path", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	executor := &wgetExecutor{}
+	repository := NewDefaultVaultClientRepository(nil)
+	repository.SetPodExecutor(executor)
+	defer func() { _ = repository.Close() }()
+
+	instance := &vaultv1.VaultInstance{
+		Name:      "vault-0",
+		Endpoint:  server.URL,
+		Namespace: "vault",
+		Access:    &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0", Container: "vault-agent"},
+	}
+	client, err := repository.GetClient(t.Context(), "vault/vault-0", instance)
+	require.NoError(t, err)
+
+	key := base64.StdEncoding.EncodeToString([]byte("unseal-key"))
+	status, err := client.Unseal(t.Context(), []string{key}, 1)
+	require.NoError(t, err)
+	assert.False(t, status.Sealed)
+
+	require.NotEmpty(t, executor.commands)
+	for i, command := range executor.commands {
+		assert.Equal(t, "vault/vault-0/vault-agent", executor.pods[i])
+		assert.Equal(t, "wget", command[0], "no shell expands the body into a command line")
+		assert.NotContains(t, strings.Join(command, " "), key, "keys are passed on stdin")
+		assert.NotContains(t, command, "--no-check-certificate")
+	}
+	assert.Contains(t, executor.commands[len(executor.commands)-1], "--post-file=/dev/stdin")
+
+	// Error responses keep their status code
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "", http.StatusForbidden)
+	})
+	_, err = client.GetSealStatus(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestExecAccessSkipsTLSVerification(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"sealed":false,"t":1,"n":1}`)
+	}))
+	defer server.Close()
+
+	executor := &wgetExecutor{}
+	repository := NewDefaultVaultClientRepository(nil)
+	repository.SetPodExecutor(executor)
+	defer func() { _ = repository.Close() }()
+
+	instance := &vaultv1.VaultInstance{
+		Name:          "vault-0",
+		Endpoint:      server.URL,
+		Namespace:     "vault",
+		TLSSkipVerify: testutil.BoolPtr(true),
+		Access:        &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+	}
+	client, err := repository.GetClient(t.Context(), "vault/vault-0", instance)
+	require.NoError(t, err)
+
+	_, err = client.GetSealStatus(t.Context())
+	require.NoError(t, err)
+	require.Len(t, executor.commands, 1)
+	assert.Contains(t, executor.commands[0], "--no-check-certificate")
+}
+
+func TestExecAccessRequiresExecutor(t *testing.T) {
+	repository := NewDefaultVaultClientRepository(nil)
+	instance := &vaultv1.VaultInstance{
+		Name:     "vault-0",
+		Endpoint: "http://127.0.0.1:8200",
+		Access:   &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+	}
+	_, err := repository.GetClient(t.Context(), "vault/vault-0", instance)
+	assert.Error(t, err)
+}
+
+func TestParseWgetResponse(t *testing.T) {
+	stderr := "Connecting to 127.0.0.1:8200 (127.0.0.1:8200)\n" +
+		"  HTTP/1.1 200 OK\n  Cache-Control: no-store\n  Content-Type: application/json\n"
+	resp, err := parseWgetResponse([]byte(stderr), []byte(`{"sealed":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"sealed":true}`, string(body))
+
+	resp, err = parseWgetResponse([]byte("wget: server returned error: HTTP/1.1 503 Service Unavailable\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	_, err = parseWgetResponse([]byte("sh: wget: not found\n"), nil)
+	assert.Error(t, err)
+}
+
+func TestExecAccessLimitedToScope(t *testing.T) {
+	executor := &wgetExecutor{}
+	repository := NewDefaultVaultClientRepository(nil)
+	repository.SetPodExecutor(executor)
+	repository.SetNamespaceScope(NewNamespaceScope([]string{"vault"}))
+	defer func() { _ = repository.Close() }()
+
+	instance := &vaultv1.VaultInstance{
+		Name:      "vault-0",
+		Endpoint:  "http://127.0.0.1:8200",
+		Namespace: "kube-system",
+		Access:    &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+	}
+	_, err := repository.GetClient(t.Context(), "kube-system/vault-0", instance)
+	assert.ErrorIs(t, err, ErrOutsideScope)
+
+	// The pod namespace is never left to the cluster-wide default
+	instance.Namespace = ""
+	_, err = repository.GetClient(t.Context(), "vault/vault-0", instance)
+	assert.Error(t, err)
+	assert.Empty(t, executor.commands)
+}
+
+func TestExecAccessLimitedToConfigNamespace(t *testing.T) {
+	executor := &wgetExecutor{}
+	repository := NewDefaultVaultClientRepository(nil)
+	repository.SetPodExecutor(executor)
+	defer func() { _ = repository.Close() }()
+	r := NewVaultUnsealConfigReconciler(fake.NewClientBuilder().Build(), log.Log, nil, repository, nil)
+
+	instance := &vaultv1.VaultInstance{
+		Name:       "vault-0",
+		Endpoint:   "http://127.0.0.1:8200",
+		Namespace:  "kube-system",
+		Access:     &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+		UnsealKeys: []string{"key"},
+	}
+	_, _, err := r.processVaultInstance(t.Context(), log.Log, instance, "tenant", unsealGate{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limited to pods in namespace tenant")
+	assert.Empty(t, executor.commands)
+}
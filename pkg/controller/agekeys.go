@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/panteparak/vault-autounseal-operator/pkg/age"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// keyDecryptionAge names age in key decryption errors.
+const keyDecryptionAge = "age"
+
+// errNoAgeIdentity is returned for age-encrypted keys without any identity.
+var errNoAgeIdentity = errors.New("no age identity: set identitySecretRef or the operator's --age-identity-files")
+
+// ageDecrypt decrypts age-encrypted keys in memory with the identities of
+// decryption.
+func (r *VaultUnsealConfigReconciler) ageDecrypt(
+	ctx context.Context,
+	namespace string,
+	decryption *vaultv1.AgeDecryption,
+	encrypted []string,
+) ([]string, error) {
+	identities, err := r.ageIdentities(ctx, namespace, decryption)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(encrypted))
+	for i, key := range encrypted {
+		plaintext, err := age.Decrypt([]byte(key), identities)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionAge, err: fmt.Errorf("key %d: %w", i, err)}
+		}
+		if keys[i] = strings.TrimSpace(string(plaintext)); keys[i] == "" {
+			return nil, &keyDecryptionError{method: keyDecryptionAge, err: fmt.Errorf("key %d is empty", i)}
+		}
+	}
+	return keys, nil
+}
+
+// ageIdentities returns the identities of decryption: those of its Secret, or
+// the operator's AgeIdentityFiles. Both are read on every decrypt, so rotated
+// identities are picked up without a restart.
+func (r *VaultUnsealConfigReconciler) ageIdentities(
+	ctx context.Context,
+	namespace string,
+	decryption *vaultv1.AgeDecryption,
+) ([]*age.Identity, error) {
+	if ref := decryption.IdentitySecretRef; ref != nil {
+		if r.Client == nil {
+			return nil, errors.New("age identity secret requires a Kubernetes client")
+		}
+		data, err := readSecretKey(ctx, r.Client, namespace, *ref)
+		if err != nil {
+			return nil, err
+		}
+		identities, err := age.ParseIdentities(data)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionAge,
+				err: fmt.Errorf("secret %s/%s key %q: %w", namespace, ref.Name, ref.Key, err)}
+		}
+		return identities, nil
+	}
+
+	if len(r.AgeIdentityFiles) == 0 {
+		return nil, &keyDecryptionError{method: keyDecryptionAge, err: errNoAgeIdentity}
+	}
+	var identities []*age.Identity
+	for _, path := range r.AgeIdentityFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionAge, err: err}
+		}
+		parsed, err := age.ParseIdentities(data)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionAge, err: fmt.Errorf("%s: %w", path, err)}
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
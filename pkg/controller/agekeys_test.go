@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/panteparak/vault-autounseal-operator/pkg/age"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// encryptKey returns key encrypted to identity, armored or base64 encoded.
+func encryptKey(t *testing.T, identity *age.Identity, key string, armored bool) string {
+	file, err := age.Encrypt([]byte(key), identity.Recipient())
+	require.NoError(t, err)
+	if armored {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "AGE ENCRYPTED FILE", Bytes: file}))
+	}
+	return base64.StdEncoding.EncodeToString(file)
+}
+
+func TestAgeDecryption(t *testing.T) {
+	operatorIdentity, err := age.GenerateIdentity()
+	require.NoError(t, err)
+	tenantIdentity, err := age.GenerateIdentity()
+	require.NoError(t, err)
+
+	identityFile := filepath.Join(t.TempDir(), "identities.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte("# operator\n"+operatorIdentity.String()+"\n"), 0o600))
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "age", Namespace: "vault"},
+		Data:       map[string][]byte{"identity": []byte(tenantIdentity.String())},
+	}).Build()
+	r := NewVaultUnsealConfigReconciler(c, log.Log, nil, nil, nil)
+	r.AgeIdentityFiles = []string{identityFile}
+
+	key1 := base64.StdEncoding.EncodeToString([]byte("share-1"))
+	key2 := base64.StdEncoding.EncodeToString([]byte("share-2"))
+	instance := &vaultv1.VaultInstance{
+		Name:          "vault",
+		UnsealKeys:    []string{encryptKey(t, operatorIdentity, key1, true), encryptKey(t, operatorIdentity, key2, false)},
+		AgeDecryption: &vaultv1.AgeDecryption{},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{key1, key2}, keys)
+	require.NoError(t, r.checkKeySource(t.Context(), "vault", instance))
+
+	// An identity Secret replaces the operator's identities
+	instance.AgeDecryption.IdentitySecretRef = &vaultv1.SecretKeyRef{Name: "age", Key: "identity"}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, age.ErrNoIdentityMatch)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+
+	instance.UnsealKeys = []string{encryptKey(t, tenantIdentity, key1, false)}
+	keys, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{key1}, keys)
+
+	instance.AgeDecryption.IdentitySecretRef.Name = "missing"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonSecretMissing, classifyError(err))
+}
+
+func TestAgeDecryptionFailures(t *testing.T) {
+	identity, err := age.GenerateIdentity()
+	require.NoError(t, err)
+	r := NewVaultUnsealConfigReconciler(nil, log.Log, nil, nil, nil)
+	instance := &vaultv1.VaultInstance{
+		Name:          "vault",
+		UnsealKeys:    []string{encryptKey(t, identity, "key", false)},
+		AgeDecryption: &vaultv1.AgeDecryption{},
+	}
+
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	assert.ErrorIs(t, err, errNoAgeIdentity)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+	assert.ErrorIs(t, r.checkKeySource(t.Context(), "vault", instance), errNoAgeIdentity)
+
+	identityFile := filepath.Join(t.TempDir(), "identities.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()), 0o600))
+	r.AgeIdentityFiles = []string{identityFile}
+
+	// Plain keys are not mistaken for encrypted ones
+	instance.UnsealKeys = []string{base64.StdEncoding.EncodeToString([]byte("share"))}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "age decryption: key 0: not an age file")
+
+	r.AgeIdentityFiles = []string{filepath.Join(t.TempDir(), "missing.txt")}
+	assert.Error(t, r.checkKeySource(t.Context(), "vault", instance))
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DefaultAuditMaxPerConfig is the number of VaultUnsealAudits kept per config
+const DefaultAuditMaxPerConfig = 100
+
+// auditsEnabled reports whether unseal attempts are recorded as VaultUnsealAudits.
+func (r *VaultUnsealConfigReconciler) auditsEnabled() bool {
+	return r.Options != nil && r.Options.AuditTTL > 0
+}
+
+// recordAudit creates a VaultUnsealAudit for an unseal attempt of instance.
+// The audit is owned by vaultConfig, so it goes away with it; failures to
+// record it are only logged.
+func (r *VaultUnsealConfigReconciler) recordAudit(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+	result, reason, message string,
+) {
+	if !r.auditsEnabled() {
+		return
+	}
+	audit := &vaultv1.VaultUnsealAudit{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: vaultConfig.Name + "-" + instance.Name + "-",
+			Namespace:    vaultConfig.Namespace,
+		},
+		Spec: vaultv1.VaultUnsealAuditSpec{
+			Config:      vaultConfig.Name,
+			Instance:    instance.Name,
+			Endpoint:    instance.Endpoint,
+			Timestamp:   metav1.Now(),
+			Result:      result,
+			Reason:      reason,
+			Message:     message,
+			ReconcileID: vault.RequestID(ctx),
+		},
+	}
+	if err := controllerutil.SetOwnerReference(vaultConfig, audit, r.Scheme); err != nil {
+		logger.Error(err, "failed to set owner of unseal audit")
+		return
+	}
+	if err := r.Create(ctx, audit); err != nil {
+		logger.Error(err, "failed to record unseal audit", "result", result)
+	}
+}
+
+// pruneAudits deletes the VaultUnsealAudits of vaultConfig older than the
+// audit TTL, and the oldest ones beyond the per-config limit.
+func (r *VaultUnsealConfigReconciler) pruneAudits(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	now time.Time,
+) {
+	if !r.auditsEnabled() {
+		return
+	}
+	var audits vaultv1.VaultUnsealAuditList
+	if err := r.List(ctx, &audits, client.InNamespace(vaultConfig.Namespace)); err != nil {
+		logger.Error(err, "failed to list unseal audits")
+		return
+	}
+	for _, audit := range expiredAudits(audits.Items, vaultConfig.Name, now, r.Options.AuditTTL, r.Options.AuditMaxPerConfig) {
+		if err := r.Delete(ctx, audit); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete expired unseal audit", "audit", audit.Name)
+		}
+	}
+}
+
+// expiredAudits returns the audits of the named config older than ttl, and
+// the oldest ones beyond maxAudits; maxAudits 0 keeps all within ttl.
+func expiredAudits(
+	audits []vaultv1.VaultUnsealAudit,
+	config string,
+	now time.Time,
+	ttl time.Duration,
+	maxAudits int,
+) []*vaultv1.VaultUnsealAudit {
+	var owned []*vaultv1.VaultUnsealAudit
+	for i := range audits {
+		if audits[i].Spec.Config == config {
+			owned = append(owned, &audits[i])
+		}
+	}
+	// Newest first, so everything past the limit is the oldest
+	sort.SliceStable(owned, func(i, j int) bool {
+		return owned[i].Spec.Timestamp.After(owned[j].Spec.Timestamp.Time)
+	})
+
+	var expired []*vaultv1.VaultUnsealAudit
+	for i, audit := range owned {
+		if now.Sub(audit.Spec.Timestamp.Time) > ttl || (maxAudits > 0 && i >= maxAudits) {
+			expired = append(expired, audit)
+		}
+	}
+	return expired
+}
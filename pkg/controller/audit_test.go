@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newAudit(name, config string, timestamp time.Time) vaultv1.VaultUnsealAudit {
+	return vaultv1.VaultUnsealAudit{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealAuditSpec{
+			Config:    config,
+			Instance:  "vault-0",
+			Timestamp: metav1.NewTime(timestamp),
+			Result:    vaultv1.AuditResultFailed,
+		},
+	}
+}
+
+func TestExpiredAudits(t *testing.T) {
+	now := time.Now()
+	audits := []vaultv1.VaultUnsealAudit{
+		newAudit("old", "vault", now.Add(-2*time.Hour)),
+		newAudit("newest", "vault", now.Add(-time.Minute)),
+		newAudit("older", "vault", now.Add(-30*time.Minute)),
+		newAudit("newer", "vault", now.Add(-10*time.Minute)),
+		newAudit("other", "other", now.Add(-3*time.Hour)),
+	}
+
+	names := func(expired []*vaultv1.VaultUnsealAudit) []string {
+		var out []string
+		for _, audit := range expired {
+			out = append(out, audit.Name)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"old"}, names(expiredAudits(audits, "vault", now, time.Hour, 0)),
+		"audits of other configs are left alone")
+	assert.Equal(t, []string{"older", "old"}, names(expiredAudits(audits, "vault", now, time.Hour, 2)),
+		"the oldest audits beyond the limit go first")
+	assert.Empty(t, expiredAudits(audits, "missing", now, time.Hour, 2))
+}
+
+func TestReconcileRecordsUnsealAudits(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", UID: "uid-1"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+		}},
+	}
+	expired := newAudit("expired", "vault", time.Now().Add(-2*time.Hour))
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig, &expired).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(nil, fmt.Errorf("boom"))
+
+	options := DefaultReconcilerOptions()
+	options.AuditTTL = time.Hour
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, options)
+	ctx := vault.WithRequestID(context.Background(), "reconcile-1")
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}})
+	require.NoError(t, err)
+
+	var audits vaultv1.VaultUnsealAuditList
+	require.NoError(t, k8sClient.List(context.Background(), &audits, client.InNamespace("vault")))
+	require.Len(t, audits.Items, 1, "the expired audit is deleted")
+	audit := audits.Items[0]
+	assert.Equal(t, "vault", audit.Spec.Config)
+	assert.Equal(t, "vault-0", audit.Spec.Instance)
+	assert.Equal(t, vaultv1.AuditResultFailed, audit.Spec.Result)
+	assert.Equal(t, ReasonVaultRequestFailed, audit.Spec.Reason)
+	assert.Equal(t, "failed to get vault client: boom", audit.Spec.Message)
+	assert.Equal(t, "reconcile-1", audit.Spec.ReconcileID)
+	require.Len(t, audit.OwnerReferences, 1)
+	assert.Equal(t, types.UID("uid-1"), audit.OwnerReferences[0].UID)
+}
+
+func TestReconcileWithoutAuditTTLRecordsNothing(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(nil, fmt.Errorf("boom"))
+
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}})
+	require.NoError(t, err)
+
+	var audits vaultv1.VaultUnsealAuditList
+	require.NoError(t, k8sClient.List(context.Background(), &audits))
+	assert.Empty(t, audits.Items)
+}
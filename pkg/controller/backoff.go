@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxRetryBackoff caps the retry delay of a failing instance, unless the
+	// configured retry interval is longer
+	maxRetryBackoff = 5 * time.Minute
+
+	// minRetryDelay keeps a retry that is already due from requeueing immediately
+	minRetryDelay = time.Second
+
+	// unresolvableBackoffFactor stretches the backoff of an instance whose
+	// endpoint does not resolve, which usually takes a configuration change
+	unresolvableBackoffFactor = 4
+
+	// maxUnresolvableBackoff caps the stretched backoff, unless the configured
+	// retry interval is longer
+	maxUnresolvableBackoff = 15 * time.Minute
+)
+
+// retryBackoff returns the delay before the next check of an instance that
+// failed failures times in a row: the retry interval, doubled per failure after
+// the first.
+func retryBackoff(retryInterval time.Duration, failures int32) time.Duration {
+	limit := max(maxRetryBackoff, retryInterval)
+	delay := retryInterval
+	for i := int32(1); i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// recordNextRetry schedules the next check of a failing instance from its
+// consecutive failures, and clears the schedule once a check succeeds.
+func recordNextRetry(status *vaultv1.VaultInstanceStatus, retryInterval time.Duration, now time.Time) {
+	if status.ConsecutiveFailures == 0 {
+		status.NextRetryTime = nil
+		return
+	}
+	next := metav1.NewTime(now.Add(instanceBackoff(status, retryInterval)))
+	status.NextRetryTime = &next
+}
+
+// instanceBackoff returns the delay before the next check of a failing
+// instance. Unresolvable endpoints back off unresolvableBackoffFactor times
+// longer than other failures.
+func instanceBackoff(status *vaultv1.VaultInstanceStatus, retryInterval time.Duration) time.Duration {
+	delay := retryBackoff(retryInterval, status.ConsecutiveFailures)
+	if status.Reason != ReasonUnresolvable {
+		return delay
+	}
+	return min(delay*unresolvableBackoffFactor, max(maxUnresolvableBackoff, retryInterval))
+}
+
+// backingOff reports whether the previous status of an instance schedules its
+// next check after now. A changed spec retries at once, since the change may be
+// the fix.
+func backingOff(vaultConfig *vaultv1.VaultUnsealConfig, previous *vaultv1.VaultInstanceStatus, now time.Time) bool {
+	if previous == nil || previous.NextRetryTime == nil || previous.Error == "" {
+		return false
+	}
+	if vaultConfig.Status.ObservedGeneration != vaultConfig.Generation {
+		return false
+	}
+	return now.Before(previous.NextRetryTime.Time)
+}
+
+// retryAfter returns the requeue delay of a config with sealed or failing
+// instances: the retry interval, or the time until the earliest scheduled retry
+// when every failing instance is backing off.
+func retryAfter(statuses []vaultv1.VaultInstanceStatus, retryInterval time.Duration, now time.Time) time.Duration {
+	var delay time.Duration
+	for i := range statuses {
+		status := &statuses[i]
+		if !status.Sealed && status.Error == "" {
+			continue
+		}
+		next := retryInterval
+		if status.Error != "" && status.NextRetryTime != nil {
+			next = max(status.NextRetryTime.Sub(now), minRetryDelay)
+		}
+		if delay == 0 || next < delay {
+			delay = next
+		}
+	}
+	if delay == 0 {
+		return retryInterval
+	}
+	return delay
+}
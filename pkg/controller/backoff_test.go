@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryBackoff(30*time.Second, 1))
+	assert.Equal(t, time.Minute, retryBackoff(30*time.Second, 2))
+	assert.Equal(t, 4*time.Minute, retryBackoff(30*time.Second, 4))
+	assert.Equal(t, maxRetryBackoff, retryBackoff(30*time.Second, 5))
+	assert.Equal(t, maxRetryBackoff, retryBackoff(30*time.Second, 1000))
+	assert.Equal(t, 10*time.Minute, retryBackoff(10*time.Minute, 3), "a longer retry interval is not capped")
+}
+
+func TestInstanceBackoff(t *testing.T) {
+	refused := &vaultv1.VaultInstanceStatus{Reason: ReasonEndpointUnreachable, ConsecutiveFailures: 2}
+	assert.Equal(t, time.Minute, instanceBackoff(refused, 30*time.Second))
+
+	unresolvable := &vaultv1.VaultInstanceStatus{Reason: ReasonUnresolvable, ConsecutiveFailures: 2}
+	assert.Equal(t, 4*time.Minute, instanceBackoff(unresolvable, 30*time.Second))
+	unresolvable.ConsecutiveFailures = 10
+	assert.Equal(t, maxUnresolvableBackoff, instanceBackoff(unresolvable, 30*time.Second))
+	assert.Equal(t, time.Hour, instanceBackoff(unresolvable, time.Hour), "a longer retry interval is not capped")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	inOneMinute := metav1.NewTime(now.Add(time.Minute))
+	overdue := metav1.NewTime(now.Add(-time.Minute))
+
+	failing := vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true, Error: "refused", NextRetryTime: &inOneMinute}
+	assert.Equal(t, time.Minute, retryAfter([]vaultv1.VaultInstanceStatus{failing, {Name: "vault-1"}}, 10*time.Second, now))
+
+	sealed := vaultv1.VaultInstanceStatus{Name: "vault-1", Sealed: true}
+	assert.Equal(t, 10*time.Second, retryAfter([]vaultv1.VaultInstanceStatus{failing, sealed}, 10*time.Second, now),
+		"sealed instances without errors are retried at the retry interval")
+
+	failing.NextRetryTime = &overdue
+	assert.Equal(t, minRetryDelay, retryAfter([]vaultv1.VaultInstanceStatus{failing}, 10*time.Second, now))
+}
+
+func TestVaultUnsealConfigReconciler_PersistsBackoff(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", Generation: 1},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{
+				Name: "vault-0", Endpoint: "http://127.0.0.1:8200", UnsealKeys: []string{"a2V5MQ=="},
+				// Without a pod executor, every check of the instance fails
+				Access: &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+			}},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	newReconciler := func() *VaultUnsealConfigReconciler {
+		repo := NewDefaultVaultClientRepository(nil)
+		t.Cleanup(func() { _ = repo.Close() })
+		return NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	}
+
+	result, err := newReconciler().Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(DefaultRequeueAfterSeconds*time.Second), float64(result.RequeueAfter), float64(time.Second))
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	status := updated.Status.VaultStatuses[0]
+	assert.Equal(t, int32(1), status.ConsecutiveFailures)
+	require.NotNil(t, status.NextRetryTime)
+	nextRetry := status.NextRetryTime.Time
+
+	// A restarted operator finds the retry scheduled and does not check the instance early
+	result, err = newReconciler().Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RequeueAfter, DefaultRequeueAfterSeconds*time.Second)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	status = updated.Status.VaultStatuses[0]
+	assert.Equal(t, int32(1), status.ConsecutiveFailures)
+	assert.True(t, nextRetry.Equal(status.NextRetryTime.Time))
+
+	// A spec change is checked at once, and the delay doubles with the next failure
+	updated.Generation = 2
+	require.NoError(t, k8sClient.Update(t.Context(), &updated))
+	result, err = newReconciler().Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(2*DefaultRequeueAfterSeconds*time.Second), float64(result.RequeueAfter), float64(time.Second))
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	assert.Equal(t, int32(2), updated.Status.VaultStatuses[0].ConsecutiveFailures)
+}
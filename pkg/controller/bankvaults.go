@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// bankVaultsGVK is the Vault resource of the bank-vaults operator. It is read
+// as unstructured, so the operator does not depend on its API package.
+var bankVaultsGVK = schema.GroupVersionKind{Group: "vault.banzaicloud.com", Version: "v1alpha1", Kind: "Vault"}
+
+const (
+	// bankVaultsAPIPort is the port of the Service bank-vaults creates for a Vault
+	bankVaultsAPIPort = 8200
+	// bankVaultsKeyPrefix prefixes the Secret data keys of the unseal keys
+	// bank-vaults stores, followed by their index
+	bankVaultsKeyPrefix = "vault-unseal-"
+	// bankVaultsDefaultShares is the number of key shares bank-vaults initializes Vault with by default
+	bankVaultsDefaultShares = 5
+)
+
+// bankVaultsUnsealBackends are the unseal key stores of bank-vaults other than
+// Kubernetes Secrets, which the operator cannot read.
+var bankVaultsUnsealBackends = []string{"google", "alibaba", "azure", "aws", "vault", "hsm"}
+
+// +kubebuilder:rbac:groups=vault.banzaicloud.com,resources=vaults,verbs=get;list;watch
+
+// applyBankVaults fills the endpoint and key source of instance from the
+// bank-vaults Vault resource it references in namespace. Settings of the
+// instance itself are kept.
+func (r *VaultUnsealConfigReconciler) applyBankVaults(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) error {
+	bankVaults := &unstructured.Unstructured{}
+	bankVaults.SetGroupVersionKind(bankVaultsGVK)
+	key := types.NamespacedName{Namespace: namespace, Name: instance.BankVaults.Name}
+	if err := r.Get(ctx, key, bankVaults); err != nil {
+		return fmt.Errorf("failed to read bank-vaults Vault %s: %w", key, err)
+	}
+
+	if instance.Endpoint == "" {
+		scheme := "https"
+		if bankVaultsTLSDisabled(bankVaults) {
+			scheme = "http"
+		}
+		instance.Endpoint = bankVaultsEndpoint(scheme, key.Namespace, key.Name)
+	}
+	if len(instance.UnsealKeys) > 0 || instance.KeySource != nil {
+		return nil
+	}
+
+	source, err := bankVaultsKeySource(bankVaults)
+	if err != nil {
+		return err
+	}
+	instance.KeySource = &vaultv1.KeySource{Secret: source}
+	return nil
+}
+
+// bankVaultsEndpoint returns the URL of the Service of the bank-vaults Vault
+// with the given name.
+func bankVaultsEndpoint(scheme, namespace, name string) string {
+	return fmt.Sprintf("%s://%s.%s.svc:%d", scheme, name, namespace, bankVaultsAPIPort)
+}
+
+// bankVaultsKeySource returns the Secret bank-vaults stores the unseal keys of
+// bankVaults in. Only Secrets in the namespace of the resource can be read.
+func bankVaultsKeySource(bankVaults *unstructured.Unstructured) (*vaultv1.SecretKeySource, error) {
+	unsealConfig, _, _ := unstructured.NestedMap(bankVaults.Object, "spec", "unsealConfig")
+	if _, ok := unsealConfig["kubernetes"]; !ok {
+		for _, backend := range bankVaultsUnsealBackends {
+			if _, ok := unsealConfig[backend]; ok {
+				return nil, fmt.Errorf("bank-vaults Vault %s stores its unseal keys in %s, not in a Kubernetes Secret",
+					bankVaults.GetName(), backend)
+			}
+		}
+	}
+
+	secretName, _, _ := unstructured.NestedString(unsealConfig, "kubernetes", "secretName")
+	if secretName == "" {
+		secretName = bankVaults.GetName() + "-unseal-keys"
+	}
+	secretNamespace, _, _ := unstructured.NestedString(unsealConfig, "kubernetes", "secretNamespace")
+	if secretNamespace != "" && secretNamespace != bankVaults.GetNamespace() {
+		return nil, fmt.Errorf("bank-vaults Vault %s stores its unseal keys in namespace %s; only Secrets in %s can be read",
+			bankVaults.GetName(), secretNamespace, bankVaults.GetNamespace())
+	}
+
+	shares, found, err := unstructured.NestedInt64(unsealConfig, "options", "secretShares")
+	if err != nil || !found || shares < 1 {
+		shares = bankVaultsDefaultShares
+	}
+	keys := make([]string, 0, shares)
+	for i := range shares {
+		keys = append(keys, bankVaultsKeyPrefix+strconv.FormatInt(i, 10))
+	}
+	return &vaultv1.SecretKeySource{Name: secretName, Keys: keys}, nil
+}
+
+// bankVaultsTLSDisabled reports whether the TCP listener of the Vault
+// configuration of bankVaults disables TLS. bank-vaults serves TLS by default.
+func bankVaultsTLSDisabled(bankVaults *unstructured.Unstructured) bool {
+	value, found, _ := unstructured.NestedFieldNoCopy(bankVaults.Object, "spec", "config", "listener", "tcp", "tls_disable")
+	if !found {
+		return false
+	}
+	switch disabled := value.(type) {
+	case bool:
+		return disabled
+	case string:
+		parsed, _ := strconv.ParseBool(disabled)
+		return parsed
+	case int64:
+		return disabled != 0
+	default:
+		return false
+	}
+}
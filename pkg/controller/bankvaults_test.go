@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTestBankVaults(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	bankVaults := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	bankVaults.SetGroupVersionKind(bankVaultsGVK)
+	bankVaults.SetName(name)
+	bankVaults.SetNamespace("vault")
+	return bankVaults
+}
+
+func TestApplyBankVaults(t *testing.T) {
+	defaults := newTestBankVaults("vault", map[string]interface{}{})
+	plain := newTestBankVaults("plain", map[string]interface{}{
+		"config": map[string]interface{}{
+			"listener": map[string]interface{}{"tcp": map[string]interface{}{"tls_disable": true}},
+		},
+		"unsealConfig": map[string]interface{}{
+			"options":    map[string]interface{}{"secretShares": int64(3)},
+			"kubernetes": map[string]interface{}{"secretName": "plain-keys", "secretNamespace": "vault"},
+		},
+	})
+	aws := newTestBankVaults("aws", map[string]interface{}{
+		"unsealConfig": map[string]interface{}{"aws": map[string]interface{}{"kmsKeyId": "alias/vault"}},
+	})
+	otherNamespace := newTestBankVaults("other", map[string]interface{}{
+		"unsealConfig": map[string]interface{}{"kubernetes": map[string]interface{}{"secretNamespace": "default"}},
+	})
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(defaults, plain, aws, otherNamespace).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	instance := &vaultv1.VaultInstance{Name: "vault", BankVaults: &vaultv1.BankVaultsReference{Name: "vault"}}
+	require.NoError(t, r.applyBankVaults(context.Background(), "vault", instance))
+	assert.Equal(t, "https://vault.vault.svc:8200", instance.Endpoint)
+	require.NotNil(t, instance.KeySource)
+	assert.Equal(t, &vaultv1.SecretKeySource{
+		Name: "vault-unseal-keys",
+		Keys: []string{"vault-unseal-0", "vault-unseal-1", "vault-unseal-2", "vault-unseal-3", "vault-unseal-4"},
+	}, instance.KeySource.Secret)
+
+	instance = &vaultv1.VaultInstance{Name: "plain", BankVaults: &vaultv1.BankVaultsReference{Name: "plain"}}
+	require.NoError(t, r.applyBankVaults(context.Background(), "vault", instance))
+	assert.Equal(t, "http://plain.vault.svc:8200", instance.Endpoint)
+	assert.Equal(t, &vaultv1.SecretKeySource{
+		Name: "plain-keys",
+		Keys: []string{"vault-unseal-0", "vault-unseal-1", "vault-unseal-2"},
+	}, instance.KeySource.Secret)
+
+	// Settings of the instance take precedence
+	instance = &vaultv1.VaultInstance{
+		Name: "vault", Endpoint: "https://vault.example.com", UnsealKeys: []string{"a2V5MQ=="},
+		BankVaults: &vaultv1.BankVaultsReference{Name: "aws"},
+	}
+	require.NoError(t, r.applyBankVaults(context.Background(), "vault", instance))
+	assert.Equal(t, "https://vault.example.com", instance.Endpoint)
+	assert.Nil(t, instance.KeySource)
+
+	instance = &vaultv1.VaultInstance{Name: "aws", BankVaults: &vaultv1.BankVaultsReference{Name: "aws"}}
+	err := r.applyBankVaults(context.Background(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stores its unseal keys in aws")
+
+	instance = &vaultv1.VaultInstance{Name: "other", BankVaults: &vaultv1.BankVaultsReference{Name: "other"}}
+	err = r.applyBankVaults(context.Background(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only Secrets in vault can be read")
+
+	instance = &vaultv1.VaultInstance{Name: "missing", BankVaults: &vaultv1.BankVaultsReference{Name: "missing"}}
+	require.Error(t, r.applyBankVaults(context.Background(), "vault", instance))
+}
+
+func TestVaultUnsealConfigReconciler_UnsealsWithBankVaultsKeys(t *testing.T) {
+	server := fakevault.New(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-unseal-keys", Namespace: "vault"},
+		Data:       map[string][]byte{"vault-root": []byte(server.RootToken())},
+	}
+	for i, key := range server.Keys() {
+		secret.Data[bankVaultsKeyPrefix+strconv.Itoa(i)] = []byte(key)
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault", Endpoint: server.URL(), BankVaults: &vaultv1.BankVaultsReference{Name: "vault"}},
+			{Name: "missing", BankVaults: &vaultv1.BankVaultsReference{Name: "missing"}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig, secret, newTestBankVaults("vault", map[string]interface{}{})).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	t.Cleanup(func() { _ = repo.Close() })
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.False(t, server.Sealed())
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	assert.Empty(t, updated.Status.VaultStatuses[0].Error)
+	assert.Equal(t, ReasonBankVaultsFailed, updated.Status.VaultStatuses[1].Reason)
+}
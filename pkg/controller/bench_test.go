@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// benchRepository hands out prebuilt clients by instance key, so benchmarks
+// measure the reconciler rather than mock bookkeeping
+type benchRepository struct {
+	clients map[string]*vault.MockVaultClient
+}
+
+func (r *benchRepository) GetClient(_ context.Context, key string, _ *vaultv1.VaultInstance) (vault.VaultClient, error) {
+	return r.clients[key], nil
+}
+
+func (r *benchRepository) Close() error { return nil }
+
+// benchUnsealKeys returns n base64 encoded 33 byte keys, the size of the
+// Shamir shares Vault hands out
+func benchUnsealKeys(b *testing.B, n int) []string {
+	b.Helper()
+	keys := make([]string, n)
+	for i := range keys {
+		share := make([]byte, 33)
+		if _, err := rand.Read(share); err != nil {
+			b.Fatal(err)
+		}
+		keys[i] = base64.StdEncoding.EncodeToString(share)
+	}
+	return keys
+}
+
+// newBenchReconciler returns a reconciler for a config with the given number of
+// instances, each with five keys and a threshold of three like a default Vault
+// init, and the mock clients of the instances
+func newBenchReconciler(
+	b *testing.B, instances int, sealed bool,
+) (*VaultUnsealConfigReconciler, ctrl.Request, []*vault.MockVaultClient) {
+	b.Helper()
+	scheme := runtime.NewScheme()
+	if err := vaultv1.AddToScheme(scheme); err != nil {
+		b.Fatal(err)
+	}
+
+	threshold := 3
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+	}
+	repo := &benchRepository{clients: make(map[string]*vault.MockVaultClient)}
+	clients := make([]*vault.MockVaultClient, 0, instances)
+	for i := 0; i < instances; i++ {
+		name := fmt.Sprintf("vault-%d", i)
+		vaultConfig.Spec.VaultInstances = append(vaultConfig.Spec.VaultInstances, vaultv1.VaultInstance{
+			Name:       name,
+			Endpoint:   fmt.Sprintf("https://%s.vault-internal.vault.svc:8200", name),
+			UnsealKeys: benchUnsealKeys(b, 5),
+			Threshold:  &threshold,
+		})
+		mockClient := vault.NewMockVaultClient()
+		mockClient.SetSealed(sealed)
+		repo.clients["vault/"+name] = mockClient
+		clients = append(clients, mockClient)
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	return reconciler, req, clients
+}
+
+// BenchmarkReconcile measures a reconcile of configs of growing size, both in
+// the steady state where every instance is unsealed and when every instance
+// has to be unsealed
+func BenchmarkReconcile(b *testing.B) {
+	for _, instances := range []int{1, 3, 10} {
+		b.Run(fmt.Sprintf("unsealed/instances=%d", instances), func(b *testing.B) {
+			reconciler, req, _ := newBenchReconciler(b, instances, false)
+			ctx := context.Background()
+			// The first reconcile records the status, later ones find it unchanged
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := reconciler.Reconcile(ctx, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("sealed/instances=%d", instances), func(b *testing.B) {
+			reconciler, req, clients := newBenchReconciler(b, instances, true)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, mockClient := range clients {
+					mockClient.SetSealed(true)
+				}
+				if _, err := reconciler.Reconcile(ctx, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkClientRepositoryGetClient measures cached client lookups, serially
+// and from concurrent reconciles of different endpoints
+func BenchmarkClientRepositoryGetClient(b *testing.B) {
+	repo := NewDefaultVaultClientRepository(nil)
+	instances := make([]*vaultv1.VaultInstance, 100)
+	for i := range instances {
+		instances[i] = &vaultv1.VaultInstance{
+			Name:     fmt.Sprintf("vault-%d", i),
+			Endpoint: fmt.Sprintf("https://vault-%d.vault-internal.vault.svc:8200", i),
+		}
+		if _, err := repo.GetClient(context.Background(), "vault/"+instances[i].Name, instances[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("serial", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			instance := instances[i%len(instances)]
+			if _, err := repo.GetClient(ctx, "vault/"+instance.Name, instance); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				instance := instances[i%len(instances)]
+				if _, err := repo.GetClient(ctx, "vault/"+instance.Name, instance); err != nil {
+					b.Error(err)
+					return
+				}
+				i++
+			}
+		})
+	})
+}
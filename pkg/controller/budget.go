@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// statusWriteReserve is kept out of the Vault budget of a reconciliation, so
+	// the status can still be written when every Vault call ran into its deadline
+	statusWriteReserve = 5 * time.Second
+
+	// discoveryBudgetShare is the share of the Vault budget node discovery may use
+	discoveryBudgetShare = 4
+
+	// minInstanceBudget is the least time an instance check is given, even when
+	// earlier instances left less than a fair share of the budget
+	minInstanceBudget = 2 * time.Second
+)
+
+// vaultBudget returns the context Vault calls, key source fetches and
+// discovery of a reconciliation run under: the reconcile timeout minus the
+// status write reserve, which shrinks to half of short timeouts.
+func vaultBudget(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	reserve := min(statusWriteReserve, timeout/2)
+	return context.WithTimeout(ctx, timeout-reserve)
+}
+
+// discoveryBudget returns the context node discovery runs under, so a hung
+// lookup leaves most of the budget to the instance checks.
+func discoveryBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/discoveryBudgetShare)
+}
+
+// instanceBudget returns the context one instance check runs under: a fair
+// share of the remaining budget among the instances still to check, so a single
+// hung call cannot starve the instances after it. Time left over by fast checks
+// carries over to later ones.
+func instanceBudget(ctx context.Context, remaining int) (context.Context, context.CancelFunc, time.Duration) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, 0
+	}
+	if remaining <= 1 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, time.Until(deadline)
+	}
+	share := max(time.Until(deadline)/time.Duration(remaining), minInstanceBudget)
+	ctx, cancel := context.WithTimeout(ctx, share)
+	return ctx, cancel, share
+}
+
+// budgetError explains a failure caused by an instance check running out of
+// its share of the budget rather than by the whole reconciliation timing out.
+func budgetError(err error, instanceCtx, parent context.Context, budget time.Duration) error {
+	if err == nil || parent.Err() != nil || !errors.Is(instanceCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("instance check exceeded its %s share of the reconcile timeout: %w", budget.Round(time.Millisecond), err)
+}
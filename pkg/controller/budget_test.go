@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVaultBudgetKeepsStatusWriteReserve(t *testing.T) {
+	ctx, cancel := vaultBudget(t.Context(), 30*time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.InDelta(t, 25*time.Second, time.Until(deadline), float64(time.Second))
+
+	// Short timeouts keep half for the status write
+	ctx, cancel = vaultBudget(t.Context(), 4*time.Second)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	assert.InDelta(t, 2*time.Second, time.Until(deadline), float64(time.Second))
+}
+
+func TestInstanceBudgetShares(t *testing.T) {
+	parent, cancel := context.WithTimeout(t.Context(), 20*time.Second)
+	defer cancel()
+
+	_, cancelShare, share := instanceBudget(parent, 4)
+	defer cancelShare()
+	assert.InDelta(t, 5*time.Second, share, float64(time.Second))
+
+	_, cancelShare, share = instanceBudget(parent, 100)
+	defer cancelShare()
+	assert.Equal(t, minInstanceBudget, share, "a share never drops below the minimum")
+
+	ctx, cancelShare, _ := instanceBudget(parent, 1)
+	defer cancelShare()
+	deadline, _ := ctx.Deadline()
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline, "the last instance gets what is left")
+
+	_, cancelShare, share = instanceBudget(t.Context(), 3)
+	defer cancelShare()
+	assert.Zero(t, share, "contexts without a deadline are not split")
+}
+
+func TestBudgetError(t *testing.T) {
+	parent := t.Context()
+	instanceCtx, cancel := context.WithTimeout(parent, time.Nanosecond)
+	defer cancel()
+	<-instanceCtx.Done()
+
+	err := budgetError(context.DeadlineExceeded, instanceCtx, parent, 2*time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded its 2s share")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	assert.NoError(t, budgetError(nil, instanceCtx, parent, time.Second))
+	plain := errors.New("connection refused")
+	assert.Equal(t, plain, budgetError(plain, parent, parent, time.Second), "errors within the budget are kept")
+}
+
+func TestProcessVaultInstances_HungInstanceKeepsBudgetForOthers(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hung.Close()
+	defer close(release)
+
+	server := fakevault.New(t)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "hung", Endpoint: hung.URL, UnsealKeys: []string{"key"}},
+				{
+					Name: "vault-0", Endpoint: server.URL(),
+					UnsealKeys: server.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().WithScheme(tc.Scheme).WithObjects(vaultConfig).Build()
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, tc.Logger, tc.Scheme, repo, nil)
+
+	settings := defaultUnsealSettings(r.Options)
+	settings.Timeout = 8 * time.Second
+	ctx, cancel := vaultBudget(t.Context(), settings.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	statuses, allReady := r.processVaultInstances(ctx, tc.Logger, vaultConfig, settings)
+	assert.Less(t, time.Since(started), settings.Timeout)
+
+	require.Len(t, statuses, 2)
+	assert.False(t, allReady)
+	assert.Contains(t, statuses[0].Error, "share of the reconcile timeout")
+	assert.Empty(t, statuses[1].Error, "the hung instance did not use up the budget of the next")
+	assert.False(t, statuses[1].Sealed)
+	assert.False(t, server.Sealed())
+}
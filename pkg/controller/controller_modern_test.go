@@ -1,18 +1,25 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/api"
 	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
 	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
 	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 // TODO: Fix status update issues in reconciler tests
@@ -244,7 +251,8 @@ func TestVaultUnsealConfigReconciler_processVaultInstances(t *testing.T) {
 		DefaultReconcilerOptions(),
 	)
 
-	statuses, allReady := reconciler.processVaultInstances(tc.Ctx, tc.Logger, vaultConfig)
+	statuses, allReady := reconciler.processVaultInstances(tc.Ctx, tc.Logger, vaultConfig,
+		defaultUnsealSettings(reconciler.Options))
 
 	// Assertions
 	assert.Len(t, statuses, 2)
@@ -273,7 +281,7 @@ func TestDefaultVaultClientRepository_GetClient(t *testing.T) {
 	instance := &vaultv1.VaultInstance{
 		Name:          "test-vault",
 		Endpoint:      "http://vault:8200",
-		TLSSkipVerify: true,
+		TLSSkipVerify: testutil.BoolPtr(true),
 	}
 
 	mockFactory.On("NewClient", "http://vault:8200", true, DefaultTimeoutSeconds*time.Second).Return(mockClient, nil)
@@ -301,32 +309,323 @@ func TestReconcilerOptions_Defaults(t *testing.T) {
 	assert.Equal(t, DefaultTimeoutSeconds*time.Second, opts.Timeout)
 }
 
-func TestGetThreshold(t *testing.T) {
+func TestUnsealThreshold(t *testing.T) {
 	tests := []struct {
-		name     string
-		instance *vaultv1.VaultInstance
-		expected int
+		name       string
+		instance   *vaultv1.VaultInstance
+		keyCount   int
+		sealStatus *api.SealStatusResponse
+		expected   int
+		reason     string
 	}{
 		{
-			name: "with threshold set",
-			instance: &vaultv1.VaultInstance{
-				Threshold: testutil.IntPtr(5),
-			},
+			name:     "with threshold set",
+			instance: &vaultv1.VaultInstance{Threshold: testutil.IntPtr(5)},
+			keyCount: 5,
 			expected: 5,
 		},
 		{
-			name: "with threshold nil",
-			instance: &vaultv1.VaultInstance{
-				Threshold: nil,
-			},
-			expected: 3,
+			name:       "with threshold nil",
+			instance:   &vaultv1.VaultInstance{},
+			keyCount:   3,
+			sealStatus: mocks.NewMockSealStatusResponse(true, 0, 2),
+			expected:   2,
+		},
+		{
+			name:     "with too few keys for the configured threshold",
+			instance: &vaultv1.VaultInstance{Threshold: testutil.IntPtr(3)},
+			keyCount: 2,
+			reason:   ReasonThresholdNotMet,
+		},
+		{
+			name:       "with too few keys for the detected threshold",
+			instance:   &vaultv1.VaultInstance{},
+			keyCount:   1,
+			sealStatus: mocks.NewMockSealStatusResponse(true, 0, 2),
+			reason:     ReasonThresholdNotMet,
+		},
+		{
+			name:       "with an uninitialized vault",
+			instance:   &vaultv1.VaultInstance{},
+			keyCount:   3,
+			sealStatus: &api.SealStatusResponse{Sealed: true},
+			reason:     ReasonVaultRequestFailed,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := getThreshold(tt.instance)
-			assert.Equal(t, tt.expected, result)
+			mockClient := &mocks.MockVaultClient{}
+			if tt.sealStatus != nil {
+				mockClient.On("GetSealStatus", mock.Anything).Return(tt.sealStatus, nil)
+			}
+
+			threshold, err := unsealThreshold(t.Context(), mockClient, tt.instance, tt.keyCount)
+			if tt.reason != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.reason, classifyError(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, threshold)
+			}
+			mockClient.AssertExpectations(t)
 		})
 	}
 }
+
+func TestDefaultVaultClientRepository_SharesClientsAcrossInstances(t *testing.T) {
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+
+	primary := &vaultv1.VaultInstance{Name: "vault", Endpoint: "http://vault.vault.svc:8200"}
+	other := &vaultv1.VaultInstance{Name: "vault-alias", Endpoint: "http://vault.vault.svc:8200"}
+	insecure := &vaultv1.VaultInstance{Name: "vault", Endpoint: "http://vault.vault.svc:8200", TLSSkipVerify: testutil.BoolPtr(true)}
+
+	client1, err := repo.GetClient(t.Context(), "team-a/vault", primary)
+	require.NoError(t, err)
+	client2, err := repo.GetClient(t.Context(), "team-b/vault-alias", other)
+	require.NoError(t, err)
+	client3, err := repo.GetClient(t.Context(), "team-c/vault", insecure)
+	require.NoError(t, err)
+
+	assert.Same(t, client1, client2, "identical connection settings share a client")
+	assert.NotSame(t, client1, client3, "TLS settings are part of the cache key")
+	cached := 0
+	for i := range repo.shards {
+		cached += len(repo.shards[i].clients)
+	}
+	assert.Equal(t, 2, cached)
+}
+
+func TestDefaultVaultClientRepository_ConcurrentGetClient(t *testing.T) {
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+
+	const reconciles, endpoints = 200, 20
+	clients := make([]vault.VaultClient, reconciles)
+	var wg sync.WaitGroup
+	for i := range reconciles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance := &vaultv1.VaultInstance{Endpoint: fmt.Sprintf("http://vault-%d.vault.svc:8200", i%endpoints)}
+			client, err := repo.GetClient(t.Context(), fmt.Sprintf("vault/config-%d", i), instance)
+			assert.NoError(t, err)
+			clients[i] = client
+		}()
+	}
+	wg.Wait()
+
+	for i := endpoints; i < reconciles; i++ {
+		assert.Same(t, clients[i%endpoints], clients[i], "each endpoint gets one client")
+	}
+}
+
+func TestVaultUnsealConfigReconciler_SkipsUnchangedStatus(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}, Threshold: testutil.IntPtr(1)},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockClient := &mocks.MockVaultClient{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(mockClient, nil)
+	mockClient.On("IsSealed", mock.Anything).Return(false, nil)
+
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, mockRepo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	var first vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &first))
+	require.Len(t, first.Status.VaultStatuses, 1)
+
+	// A second check that finds the same state does not write the status again
+	_, err = reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	var second vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &second))
+	assert.Equal(t, first.ResourceVersion, second.ResourceVersion)
+	assert.Equal(t, first.Status, second.Status)
+}
+
+// describedVaultClient is a mock client that also reports instance details
+type describedVaultClient struct {
+	*mocks.MockVaultClient
+	info *vault.InstanceInfo
+}
+
+func (c *describedVaultClient) InstanceInfo(_ context.Context) (*vault.InstanceInfo, error) {
+	return c.info, nil
+}
+
+func TestVaultUnsealConfigReconciler_ReportsInstanceDetails(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	haEnabled := true
+	mockClient := &describedVaultClient{MockVaultClient: &mocks.MockVaultClient{}, info: &vault.InstanceInfo{
+		Version: "1.15.0", Distribution: vault.DistributionVault, ClusterName: "vault-cluster-1", ClusterID: "c-1", Initialized: true,
+		SealType: "awskms", RecoverySeal: true, RecoverySealType: "shamir", Migration: true,
+		HAEnabled: &haEnabled, Role: vault.RoleActive,
+	}}
+	mockClient.On("IsSealed", mock.Anything).Return(false, nil)
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(mockClient, nil)
+
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, mockRepo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	status := updated.Status.VaultStatuses[0]
+	assert.Equal(t, "1.15.0", status.Version)
+	assert.Equal(t, vault.DistributionVault, status.Distribution)
+	assert.Equal(t, "vault-cluster-1", status.ClusterName)
+	assert.Equal(t, "c-1", status.ClusterID)
+	assert.Equal(t, "awskms", status.SealType)
+	assert.True(t, status.RecoverySeal)
+	assert.Equal(t, "shamir", status.RecoverySealType)
+	assert.True(t, status.SealMigration)
+	assert.Equal(t, vault.RoleActive, status.Role)
+	require.NotNil(t, status.Initialized)
+	assert.True(t, *status.Initialized)
+	require.NotNil(t, status.HAEnabled)
+	assert.True(t, *status.HAEnabled)
+}
+
+// progressVaultClient is a mock client that also reports unseal progress
+type progressVaultClient struct {
+	*mocks.MockVaultClient
+	progress []vault.UnsealProgress
+}
+
+func (c *progressVaultClient) UnsealWithProgress(
+	ctx context.Context, keys []string, threshold int, report func(vault.UnsealProgress),
+) (*api.SealStatusResponse, error) {
+	for _, p := range c.progress {
+		report(p)
+	}
+	return c.Unseal(ctx, keys, threshold)
+}
+
+func TestUnsealWithProgress(t *testing.T) {
+	keys := []string{"k1", "k2"}
+
+	// Clients without progress reporting only record an incomplete unseal
+	mockClient := &mocks.MockVaultClient{}
+	mockClient.On("Unseal", mock.Anything, keys, 2).
+		Return(&api.SealStatusResponse{Sealed: true, T: 3, Progress: 2}, nil).Once()
+	var status vaultv1.VaultInstanceStatus
+	_, err := unsealWithProgress(t.Context(), log.Log, mockClient, keys, 2, &status)
+	require.NoError(t, err)
+	assert.Equal(t, &vaultv1.UnsealProgress{KeysProvided: 2, Threshold: 3}, status.UnsealProgress)
+
+	// Reported progress is kept, including a reset of a stale attempt
+	progressClient := &progressVaultClient{MockVaultClient: &mocks.MockVaultClient{}, progress: []vault.UnsealProgress{
+		{Threshold: 2, NonceReset: true},
+		{KeysProvided: 1, Threshold: 2, NonceReset: true},
+		{KeysProvided: 2, Threshold: 2, NonceReset: true},
+	}}
+	progressClient.On("Unseal", mock.Anything, keys, 2).Return(&api.SealStatusResponse{T: 2}, nil).Once()
+	status = vaultv1.VaultInstanceStatus{}
+	sealStatus, err := unsealWithProgress(t.Context(), log.Log, progressClient, keys, 2, &status)
+	require.NoError(t, err)
+	assert.False(t, sealStatus.Sealed)
+	assert.Equal(t, &vaultv1.UnsealProgress{KeysProvided: 2, Threshold: 2, NonceReset: true}, status.UnsealProgress)
+}
+
+func TestRecordUnsealHistory(t *testing.T) {
+	// First check finds the instance sealed and unseals it
+	status := vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: &metav1.Time{Time: time.Now()}}
+	recordUnsealHistory(&status, nil, true, false)
+	assert.Equal(t, int64(1), status.UnsealCount)
+	require.NotNil(t, status.LastSealDetectedTime)
+	assert.True(t, sealDetected(&status, nil))
+	firstSeal := *status.LastSealDetectedTime
+
+	// Later checks that find it unsealed keep the history
+	previous := status
+	status = vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: &metav1.Time{Time: time.Now().Add(time.Hour)}}
+	recordUnsealHistory(&status, &previous, false, false)
+	assert.Equal(t, int64(1), status.UnsealCount)
+	assert.Equal(t, previous.LastUnsealed, status.LastUnsealed)
+	assert.Equal(t, firstSeal, *status.LastSealDetectedTime)
+	assert.False(t, sealDetected(&status, &previous))
+
+	// Failures are counted until a check succeeds
+	previous = status
+	status = vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true, Error: "connection refused"}
+	recordUnsealHistory(&status, &previous, false, true)
+	previous = status
+	status = vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true, Error: "connection refused"}
+	recordUnsealHistory(&status, &previous, false, true)
+	assert.Equal(t, int32(2), status.ConsecutiveFailures)
+	assert.Equal(t, int64(1), status.UnsealCount)
+	assert.NotNil(t, status.LastUnsealed, "failures keep the last unseal time")
+
+	// The restarted pod comes back sealed and is unsealed again
+	previous = status
+	status = vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: &metav1.Time{Time: time.Now()}}
+	recordUnsealHistory(&status, &previous, true, false)
+	assert.Equal(t, int64(2), status.UnsealCount)
+	assert.Zero(t, status.ConsecutiveFailures)
+	require.NotNil(t, status.LastSealDetectedTime)
+	assert.True(t, sealDetected(&status, &previous))
+}
+
+func TestInitCompleted(t *testing.T) {
+	initialized, uninitialized := true, false
+	status := vaultv1.VaultInstanceStatus{Name: "vault-0", Initialized: &initialized}
+
+	assert.True(t, initCompleted(&status, &vaultv1.VaultInstanceStatus{Initialized: &uninitialized}))
+	assert.False(t, initCompleted(&status, &vaultv1.VaultInstanceStatus{Initialized: &initialized}))
+	assert.False(t, initCompleted(&status, &vaultv1.VaultInstanceStatus{}), "an unknown state is not a transition")
+	assert.False(t, initCompleted(&status, nil))
+}
+
+func TestUpdateVaultConfigStatus_Summary(t *testing.T) {
+	unsealedAt := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	firstSeen := metav1.NewTime(time.Now().Truncate(time.Second))
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: make([]vaultv1.VaultInstance, 3)},
+	}
+	reconciler := &VaultUnsealConfigReconciler{}
+	reconciler.updateVaultConfigStatus(vaultConfig, []vaultv1.VaultInstanceStatus{
+		{Name: "vault-0", LastUnsealed: &unsealedAt, UnsealCount: 2},
+		{Name: "vault-1", LastUnsealed: &firstSeen},
+		{Name: "vault-2", Sealed: true},
+	}, false)
+
+	assert.Equal(t, "2/3", vaultConfig.Status.Ready)
+	assert.Equal(t, int32(1), vaultConfig.Status.SealedInstances)
+	require.NotNil(t, vaultConfig.Status.LastUnsealTime)
+	assert.True(t, unsealedAt.Equal(vaultConfig.Status.LastUnsealTime),
+		"instances the operator never unsealed do not count as unseals")
+}
@@ -101,7 +101,7 @@ func (suite *ControllerTestSuite) SkipTestReconcileBasicVaultConfig() {
 					Endpoint:      "http://vault.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3"},
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
@@ -158,14 +158,14 @@ func (suite *ControllerTestSuite) SkipTestReconcileMultipleVaultInstances() {
 					Endpoint:      "http://vault-primary.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3"},
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 				{
 					Name:          "vault-secondary",
 					Endpoint:      "http://vault-secondary.example.com:8200",
 					UnsealKeys:    []string{"key4", "key5", "key6"},
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: true,
+					TLSSkipVerify: func() *bool { b := true; return &b }(),
 				},
 			},
 		},
@@ -218,14 +218,14 @@ func (suite *ControllerTestSuite) SkipTestProcessVaultInstances() {
 					Endpoint:      "http://invalid-vault.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3"},
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
 	}
 
 	statuses, allReady := suite.reconciler.processVaultInstances(
-		suite.ctx, suite.logger, vaultConfig,
+		suite.ctx, suite.logger, vaultConfig, defaultUnsealSettings(suite.reconciler.Options),
 	)
 
 	// Should return statuses and allReady should be false due to errors
@@ -243,11 +243,11 @@ func (suite *ControllerTestSuite) SkipTestProcessVaultInstanceError() {
 		Endpoint:      "http://invalid-vault.example.com:8200",
 		UnsealKeys:    []string{"key1", "key2", "key3"},
 		Threshold:     func() *int { i := 3; return &i }(),
-		TLSSkipVerify: false,
+		TLSSkipVerify: func() *bool { b := false; return &b }(),
 	}
 
 	logger := suite.reconciler.Log.WithValues("test", "processVaultInstance")
-	status, err := suite.reconciler.processVaultInstance(suite.ctx, logger, instance, "default")
+	status, _, err := suite.reconciler.processVaultInstance(suite.ctx, logger, instance, "default", unsealGate{})
 
 	// Should return an error and empty status
 	assert.Error(suite.T(), err)
@@ -382,7 +382,7 @@ func (suite *ControllerTestSuite) TestVaultInstanceWithCustomThreshold() {
 					Endpoint:      "http://vault.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3", "key4", "key5"},
 					Threshold:     func() *int { i := 5; return &i }(),
-					TLSSkipVerify: true,
+					TLSSkipVerify: func() *bool { b := true; return &b }(),
 				},
 			},
 		},
@@ -403,7 +403,7 @@ func (suite *ControllerTestSuite) TestVaultInstanceWithCustomThreshold() {
 	assert.Equal(suite.T(), "custom-threshold-vault", instance.Name)
 	assert.NotNil(suite.T(), instance.Threshold)
 	assert.Equal(suite.T(), 5, *instance.Threshold)
-	assert.True(suite.T(), instance.TLSSkipVerify)
+	assert.True(suite.T(), *instance.TLSSkipVerify)
 	assert.Len(suite.T(), instance.UnsealKeys, 5)
 }
 
@@ -440,8 +440,8 @@ func (suite *ControllerTestSuite) TestVaultInstanceWithDefaultValues() {
 
 	instance := retrievedConfig.Spec.VaultInstances[0]
 	assert.Equal(suite.T(), "default-values-vault", instance.Name)
-	assert.Nil(suite.T(), instance.Threshold)       // Should be nil (uses default 3 in controller logic)
-	assert.False(suite.T(), instance.TLSSkipVerify) // Default should be false
+	assert.Nil(suite.T(), instance.Threshold)     // Should be nil (uses default 3 in controller logic)
+	assert.Nil(suite.T(), instance.TLSSkipVerify) // Should be nil (inherits the settings, false by default)
 }
 
 // TestControllerConcurrentReconciliation tests concurrent reconciliation handling
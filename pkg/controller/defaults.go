@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// unsealSettings are the effective settings of one VaultUnsealConfig after
+// applying its overrides on top of VaultClusterDefaults and operator options.
+type unsealSettings struct {
+	Timeout       time.Duration
+	RequeueAfter  time.Duration
+	RetryInterval time.Duration
+	TLSSkipVerify bool
+	// Threshold is 0 when instances read it from the seal status of Vault
+	Threshold       int
+	StrictThreshold bool
+	Notifications   []vaultv1.NotificationSink
+	// MaxConcurrentUnseals is 0 when the unseals of a config are not limited
+	MaxConcurrentUnseals int
+	// ReadyThreshold and NotReadyThreshold are the consecutive checks that must
+	// agree before an instance changes between ready and not ready
+	ReadyThreshold    int
+	NotReadyThreshold int
+	// ResealWindow, ResealThreshold and ResealUnsealDelay configure the
+	// detection of instances that keep sealing again
+	ResealWindow      time.Duration
+	ResealThreshold   int
+	ResealUnsealDelay time.Duration
+	// QuarantineAfter is the number of invalid-key rejections in a row that
+	// quarantine an instance; 0 disables quarantine
+	QuarantineAfter int
+}
+
+// defaultUnsealSettings returns the settings used when nothing is configured on the cluster.
+func defaultUnsealSettings(options *ReconcilerOptions) *unsealSettings {
+	return &unsealSettings{
+		Timeout:           options.Timeout,
+		RequeueAfter:      options.RequeueAfter,
+		RetryInterval:     options.RequeueAfter,
+		ReadyThreshold:    1,
+		NotReadyThreshold: 1,
+		ResealWindow:      defaultResealWindow,
+		ResealThreshold:   defaultResealThreshold,
+		QuarantineAfter:   defaultQuarantineAfter,
+	}
+}
+
+// apply overrides the settings with every field set in layer.
+func (s *unsealSettings) apply(layer *vaultv1.UnsealSettings) {
+	if layer == nil {
+		return
+	}
+	if layer.Timeout != nil && layer.Timeout.Duration > 0 {
+		s.Timeout = layer.Timeout.Duration
+	}
+	if layer.RequeueAfter != nil && layer.RequeueAfter.Duration > 0 {
+		s.RequeueAfter = layer.RequeueAfter.Duration
+		// Without an explicit retry interval, retries follow the periodic check
+		s.RetryInterval = s.RequeueAfter
+	}
+	if layer.Retry != nil && layer.Retry.Interval != nil && layer.Retry.Interval.Duration > 0 {
+		s.RetryInterval = layer.Retry.Interval.Duration
+	}
+	if layer.TLSSkipVerify != nil {
+		s.TLSSkipVerify = *layer.TLSSkipVerify
+	}
+	if layer.Threshold != nil {
+		s.Threshold = *layer.Threshold
+	}
+	if layer.StrictThreshold != nil {
+		s.StrictThreshold = *layer.StrictThreshold
+	}
+	if layer.Notifications != nil {
+		s.Notifications = layer.Notifications
+	}
+	if layer.MaxConcurrentUnseals != nil && *layer.MaxConcurrentUnseals > 0 {
+		s.MaxConcurrentUnseals = int(*layer.MaxConcurrentUnseals)
+	}
+	if layer.Hysteresis != nil {
+		if threshold := layer.Hysteresis.ReadyThreshold; threshold != nil && *threshold > 0 {
+			s.ReadyThreshold = int(*threshold)
+		}
+		if threshold := layer.Hysteresis.NotReadyThreshold; threshold != nil && *threshold > 0 {
+			s.NotReadyThreshold = int(*threshold)
+		}
+	}
+	if reseal := layer.ResealDetection; reseal != nil {
+		if reseal.Window != nil && reseal.Window.Duration > 0 {
+			s.ResealWindow = reseal.Window.Duration
+		}
+		if reseal.Threshold != nil && *reseal.Threshold > 0 {
+			s.ResealThreshold = int(*reseal.Threshold)
+		}
+		if reseal.UnsealDelay != nil {
+			s.ResealUnsealDelay = reseal.UnsealDelay.Duration
+		}
+	}
+	if layer.QuarantineAfter != nil && *layer.QuarantineAfter >= 0 {
+		s.QuarantineAfter = int(*layer.QuarantineAfter)
+	}
+}
+
+// instance returns a copy of instance with inherited settings filled in.
+func (s *unsealSettings) instance(instance *vaultv1.VaultInstance) *vaultv1.VaultInstance {
+	resolved := instance.DeepCopy()
+	if resolved.Threshold == nil && s.Threshold > 0 {
+		threshold := s.Threshold
+		resolved.Threshold = &threshold
+	}
+	if resolved.TLSSkipVerify == nil {
+		skipVerify := s.TLSSkipVerify
+		resolved.TLSSkipVerify = &skipVerify
+	}
+	return resolved
+}
+
+// +kubebuilder:rbac:groups=vault.io,resources=vaultclusterdefaults,verbs=get;list;watch
+
+// resolveSettings layers the config's overrides over the cluster defaults. Failing to
+// read the defaults never blocks unsealing; the operator options are used instead.
+func (r *VaultUnsealConfigReconciler) resolveSettings(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+) *unsealSettings {
+	settings := defaultUnsealSettings(r.Options)
+
+	var defaults vaultv1.VaultClusterDefaults
+	err := r.Get(ctx, types.NamespacedName{Name: vaultv1.ClusterDefaultsName}, &defaults)
+	switch {
+	case err == nil:
+		settings.apply(&defaults.Spec.UnsealSettings)
+	case !apierrors.IsNotFound(err):
+		logger.Error(err, "unable to read VaultClusterDefaults, using operator defaults")
+	}
+
+	settings.apply(vaultConfig.Spec.Settings)
+	return settings
+}
+
+// findVaultConfigsForDefaults requeues every VaultUnsealConfig when the cluster defaults change.
+func (r *VaultUnsealConfigReconciler) findVaultConfigsForDefaults(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	if obj.GetName() != vaultv1.ClusterDefaultsName {
+		return nil
+	}
+
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs); err != nil {
+		r.Log.Error(err, "failed to list VaultUnsealConfigs")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(configs.Items))
+	for _, config := range configs.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: config.Name, Namespace: config.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/notify"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUnsealSettingsLayering(t *testing.T) {
+	settings := defaultUnsealSettings(DefaultReconcilerOptions())
+	assert.Equal(t, DefaultRequeueAfterSeconds*time.Second, settings.RetryInterval)
+
+	settings.apply(&vaultv1.UnsealSettings{
+		RequeueAfter:  &metav1.Duration{Duration: time.Minute},
+		TLSSkipVerify: testutil.BoolPtr(true),
+		Threshold:     testutil.IntPtr(2),
+		Notifications: []vaultv1.NotificationSink{{Name: "ops"}},
+	})
+	settings.apply(&vaultv1.UnsealSettings{
+		Retry:         &vaultv1.RetrySettings{Interval: &metav1.Duration{Duration: 5 * time.Second}},
+		TLSSkipVerify: testutil.BoolPtr(false),
+		Notifications: []vaultv1.NotificationSink{},
+	})
+
+	assert.Equal(t, DefaultTimeoutSeconds*time.Second, settings.Timeout)
+	assert.Equal(t, time.Minute, settings.RequeueAfter)
+	assert.Equal(t, 5*time.Second, settings.RetryInterval)
+	assert.False(t, settings.TLSSkipVerify, "config override must win over cluster defaults")
+	assert.Empty(t, settings.Notifications, "an empty list disables inherited sinks")
+
+	resolved := settings.instance(&vaultv1.VaultInstance{Name: "v", TLSSkipVerify: testutil.BoolPtr(true)})
+	assert.Equal(t, 2, *resolved.Threshold)
+	assert.True(t, *resolved.TLSSkipVerify, "instances keep their own TLS setting")
+
+	// Instances that set tlsSkipVerify: false opt out of an inherited true
+	settings.TLSSkipVerify = true
+	verified := settings.instance(&vaultv1.VaultInstance{Name: "v", TLSSkipVerify: testutil.BoolPtr(false)})
+	assert.False(t, *verified.TLSSkipVerify)
+	inherited := settings.instance(&vaultv1.VaultInstance{Name: "v"})
+	assert.True(t, *inherited.TLSSkipVerify)
+
+	explicit := settings.instance(&vaultv1.VaultInstance{Name: "v", Threshold: testutil.IntPtr(4)})
+	assert.Equal(t, 4, *explicit.Threshold)
+}
+
+func TestReconcileInheritsClusterDefaults(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []notify.Event
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	defaults := &vaultv1.VaultClusterDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: vaultv1.ClusterDefaultsName},
+		Spec: vaultv1.VaultClusterDefaultsSpec{UnsealSettings: vaultv1.UnsealSettings{
+			Threshold: testutil.IntPtr(2),
+			Retry:     &vaultv1.RetrySettings{Interval: &metav1.Duration{Duration: 10 * time.Second}},
+			Notifications: []vaultv1.NotificationSink{
+				{Name: "ops", Webhook: &vaultv1.WebhookSink{URL: webhook.URL}},
+			},
+		}},
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1", "k2", "k3"}},
+			},
+		},
+	}
+
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(defaults, vaultConfig).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockClient := &mocks.MockVaultClient{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(mockClient, nil)
+	mockClient.On("IsSealed", mock.Anything).Return(true, nil)
+	mockClient.On("Unseal", mock.Anything, []string{"k1", "k2", "k3"}, 2).Return(
+		mocks.NewMockSealStatusResponse(true, 1, 2), nil).Once()
+	mockClient.On("Unseal", mock.Anything, []string{"k1", "k2", "k3"}, 2).Return(
+		mocks.NewMockSealStatusResponse(false, 2, 2), nil).Once()
+
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, mockRepo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	// Still sealed: retried at the inherited retry interval
+	result, err := reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, result.RequeueAfter)
+
+	// Unsealed: back to the periodic interval and a notification is delivered
+	// after the one reporting the seal
+	result, err = reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRequeueAfterSeconds*time.Second, result.RequeueAfter)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, notify.EventSealDetected, events[0].Type)
+	assert.Equal(t, notify.EventUnsealed, events[1].Type)
+	assert.Equal(t, "vault-0", events[1].Instance)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// DependencyError reports an instance whose dependsOn cannot be satisfied: a
+// dependency that is not an instance of the config, or a dependency cycle.
+type DependencyError struct {
+	// Instance is the instance with the invalid dependency
+	Instance string
+	// Cycle lists the instances of a cycle, starting and ending with Instance
+	Cycle []string
+	// Unknown is the dependency that names no instance of the config
+	Unknown string
+}
+
+func (e *DependencyError) Error() string {
+	if e.Unknown != "" {
+		return fmt.Sprintf("instance %s depends on unknown instance %s", e.Instance, e.Unknown)
+	}
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// DependencyOrder orders instances so that every instance comes after the
+// instances it depends on, keeping the spec order otherwise. It returns the
+// indices of the instances that can be ordered and the errors of those that
+// cannot; instances depending on an invalid instance are still ordered after it.
+func DependencyOrder(instances []vaultv1.VaultInstance) ([]int, map[int]*DependencyError) {
+	index := make(map[string]int, len(instances))
+	for i := range instances {
+		index[instances[i].Name] = i
+	}
+
+	invalid := make(map[int]*DependencyError)
+	for i := range instances {
+		for _, dependency := range instances[i].DependsOn {
+			if _, ok := index[dependency]; !ok {
+				invalid[i] = &DependencyError{Instance: instances[i].Name, Unknown: dependency}
+				break
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(instances))
+	var stack []int
+	var sorted []int
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, dependency := range instances[i].DependsOn {
+			j, ok := index[dependency]
+			if !ok {
+				continue
+			}
+			switch state[j] {
+			case unvisited:
+				visit(j)
+			case visiting:
+				markCycle(instances, stack, j, invalid)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		sorted = append(sorted, i)
+	}
+	for i := range instances {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+
+	order := make([]int, 0, len(sorted))
+	for _, i := range sorted {
+		if invalid[i] == nil {
+			order = append(order, i)
+		}
+	}
+	return order, invalid
+}
+
+// markCycle records the cycle closed by an edge from the top of stack back to
+// start on every instance in it.
+func markCycle(instances []vaultv1.VaultInstance, stack []int, start int, invalid map[int]*DependencyError) {
+	first := len(stack) - 1
+	for stack[first] != start {
+		first--
+	}
+	members := stack[first:]
+	for k, i := range members {
+		if invalid[i] != nil {
+			continue
+		}
+		cycle := make([]string, 0, len(members)+1)
+		for n := 0; n <= len(members); n++ {
+			cycle = append(cycle, instances[members[(k+n)%len(members)]].Name)
+		}
+		invalid[i] = &DependencyError{Instance: instances[i].Name, Cycle: cycle}
+	}
+}
+
+// ValidateDependencies returns the first dependency error of instances, in spec order.
+func ValidateDependencies(instances []vaultv1.VaultInstance) error {
+	_, invalid := DependencyOrder(instances)
+	for i := range instances {
+		if err := invalid[i]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unreadyDependencies returns the dependencies of instance that are not yet
+// unsealed according to the statuses checked so far. A dependency is ready once
+// it, or every one of its discovered nodes, is unsealed; disabled dependencies
+// never block.
+func unreadyDependencies(
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+	checked ...[]vaultv1.VaultInstanceStatus,
+) []string {
+	var unready []string
+	for _, name := range instance.DependsOn {
+		dependency := findSpecInstance(vaultConfig, name)
+		if dependency == nil || !instanceEnabled(dependency) {
+			continue
+		}
+		if !dependencyReady(dependency, checked) {
+			unready = append(unready, name)
+		}
+	}
+	return unready
+}
+
+func dependencyReady(dependency *vaultv1.VaultInstance, checked [][]vaultv1.VaultInstanceStatus) bool {
+	ready := false
+	for _, statuses := range checked {
+		for i := range statuses {
+			status := &statuses[i]
+			if status.Name != dependency.Name &&
+				(dependency.Discovery == nil || !strings.HasPrefix(status.Name, dependency.Name+"-")) {
+				continue
+			}
+			if status.Sealed || status.Error != "" {
+				return false
+			}
+			ready = true
+		}
+	}
+	return ready
+}
+
+// findSpecInstance returns the spec instance with the given name, if any.
+func findSpecInstance(vaultConfig *vaultv1.VaultUnsealConfig, name string) *vaultv1.VaultInstance {
+	for i := range vaultConfig.Spec.VaultInstances {
+		if vaultConfig.Spec.VaultInstances[i].Name == name {
+			return &vaultConfig.Spec.VaultInstances[i]
+		}
+	}
+	return nil
+}
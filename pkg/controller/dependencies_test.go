@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func dependentInstances(dependsOn map[string][]string, names ...string) []vaultv1.VaultInstance {
+	instances := make([]vaultv1.VaultInstance, len(names))
+	for i, name := range names {
+		instances[i] = vaultv1.VaultInstance{Name: name, DependsOn: dependsOn[name]}
+	}
+	return instances
+}
+
+func TestDependencyOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		dependsOn map[string][]string
+		order     []int
+		invalid   map[int]string
+	}{
+		{name: "spec order without dependencies", order: []int{0, 1, 2, 3}},
+		{
+			name:      "dependencies first",
+			dependsOn: map[string][]string{"a": {"c"}, "c": {"d"}},
+			order:     []int{3, 2, 0, 1},
+		},
+		{
+			name:      "unknown dependency",
+			dependsOn: map[string][]string{"b": {"transit"}},
+			order:     []int{0, 2, 3},
+			invalid:   map[int]string{1: "instance b depends on unknown instance transit"},
+		},
+		{
+			name:      "self dependency",
+			dependsOn: map[string][]string{"a": {"a"}},
+			order:     []int{1, 2, 3},
+			invalid:   map[int]string{0: "dependency cycle: a -> a"},
+		},
+		{
+			name:      "cycle with a dependent",
+			dependsOn: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "d": {"a"}},
+			order:     []int{3},
+			invalid: map[int]string{
+				0: "dependency cycle: a -> b -> c -> a",
+				1: "dependency cycle: b -> c -> a -> b",
+				2: "dependency cycle: c -> a -> b -> c",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := dependentInstances(tt.dependsOn, "a", "b", "c", "d")
+			order, invalid := DependencyOrder(instances)
+			assert.Equal(t, tt.order, order)
+			require.Len(t, invalid, len(tt.invalid))
+			for i, message := range tt.invalid {
+				require.NotNil(t, invalid[i])
+				assert.EqualError(t, invalid[i], message)
+			}
+			if len(tt.invalid) == 0 {
+				assert.NoError(t, ValidateDependencies(instances))
+			} else {
+				assert.Error(t, ValidateDependencies(instances))
+			}
+		})
+	}
+}
+
+func TestVaultUnsealConfigReconciler_UnsealsDependenciesFirst(t *testing.T) {
+	transit := fakevault.New(t)
+	dependent := fakevault.New(t)
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{
+					Name: "vault", Endpoint: dependent.URL(), DependsOn: []string{"transit"},
+					UnsealKeys: dependent.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+				{
+					Name: "transit", Endpoint: transit.URL(),
+					UnsealKeys: transit.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	reconcileStatuses := func() []vaultv1.VaultInstanceStatus {
+		t.Helper()
+		_, err := reconciler.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		var updated vaultv1.VaultUnsealConfig
+		require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+		require.Len(t, updated.Status.VaultStatuses, 2)
+		return updated.Status.VaultStatuses
+	}
+
+	// The dependency is checked first and unsealed in the same pass
+	statuses := reconcileStatuses()
+	assert.Equal(t, "transit", statuses[0].Name)
+	assert.Equal(t, "vault", statuses[1].Name)
+	assert.False(t, transit.Sealed())
+	assert.False(t, dependent.Sealed())
+
+	// A sealed dependency that cannot be unsealed holds back its dependents
+	transit.Seal()
+	dependent.Seal()
+	transit.Inject("sys/seal-status", fakevault.Fault{Status: http.StatusBadRequest})
+	statuses = reconcileStatuses()
+	assert.True(t, statuses[0].Sealed)
+	assert.NotEmpty(t, statuses[0].Error)
+	assert.True(t, statuses[1].Sealed)
+	assert.Empty(t, statuses[1].Error)
+	assert.Equal(t, ReasonDependencyNotReady, statuses[1].Reason)
+	assert.True(t, dependent.Sealed())
+	assert.Equal(t, fakevault.DefaultThreshold, dependent.Requests("sys/unseal"), "no keys are sent while waiting")
+}
+
+func TestVaultUnsealConfigReconciler_ReportsDependencyCycles(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "a", Endpoint: "http://a:8200", UnsealKeys: []string{"k1"}, DependsOn: []string{"b"}},
+				{Name: "b", Endpoint: "http://b:8200", UnsealKeys: []string{"k1"}, DependsOn: []string{"a"}},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, NewDefaultVaultClientRepository(nil), nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	for _, status := range updated.Status.VaultStatuses {
+		assert.Equal(t, ReasonInvalidDependencies, status.Reason)
+		assert.Contains(t, status.Error, "dependency cycle")
+	}
+	assert.Equal(t, vaultv1.UnsealConfigPhaseError, updated.Status.Phase)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"fmt"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionTypeDisabled is set on the status of an instance excluded from reconciliation
+const ConditionTypeDisabled = "Disabled"
+
+// ReasonInstanceDisabled means the instance is excluded with enabled: false
+const ReasonInstanceDisabled = "InstanceDisabled"
+
+// instanceEnabled reports whether instance is reconciled; instances are enabled by default.
+func instanceEnabled(instance *vaultv1.VaultInstance) bool {
+	return instance.Enabled == nil || *instance.Enabled
+}
+
+// instanceDisabled reports whether status belongs to a disabled instance.
+func instanceDisabled(status *vaultv1.VaultInstanceStatus) bool {
+	return meta.IsStatusConditionTrue(status.Conditions, ConditionTypeDisabled)
+}
+
+// disabledStatuses returns the statuses of the disabled instances of vaultConfig.
+func disabledStatuses(vaultConfig *vaultv1.VaultUnsealConfig) []vaultv1.VaultInstanceStatus {
+	var statuses []vaultv1.VaultInstanceStatus
+	for i := range vaultConfig.Spec.VaultInstances {
+		if instance := &vaultConfig.Spec.VaultInstances[i]; !instanceEnabled(instance) {
+			statuses = append(statuses, disabledStatus(vaultConfig, instance))
+		}
+	}
+	return statuses
+}
+
+// disabledStatus returns the status of a disabled instance. The vault is not
+// contacted, so only the unseal history is carried over from the previous status,
+// letting the counts continue once the instance is enabled again.
+func disabledStatus(vaultConfig *vaultv1.VaultUnsealConfig, instance *vaultv1.VaultInstance) vaultv1.VaultInstanceStatus {
+	status := vaultv1.VaultInstanceStatus{Name: instance.Name}
+	if previous := findInstanceStatus(vaultConfig, instance.Name); previous != nil {
+		status.UnsealCount = previous.UnsealCount
+		status.LastUnsealed = previous.LastUnsealed.DeepCopy()
+		status.LastSealDetectedTime = previous.LastSealDetectedTime.DeepCopy()
+		status.Conditions = append(status.Conditions, previous.Conditions...)
+	}
+	// Keeps the transition time while the instance stays disabled
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               ConditionTypeDisabled,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonInstanceDisabled,
+		Message:            fmt.Sprintf("Instance %s is excluded from reconciliation", instance.Name),
+		ObservedGeneration: vaultConfig.Generation,
+	})
+	return status
+}
+
+// countEnabled returns the number of statuses of enabled instances.
+func countEnabled(statuses []vaultv1.VaultInstanceStatus) int {
+	enabled := 0
+	for i := range statuses {
+		if !instanceDisabled(&statuses[i]) {
+			enabled++
+		}
+	}
+	return enabled
+}
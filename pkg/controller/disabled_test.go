@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestVaultUnsealConfigReconciler_SkipsDisabledInstances(t *testing.T) {
+	active := fakevault.New(t)
+	decommissioned := fakevault.New(t)
+
+	disabled := false
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", Generation: 3},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{
+					Name: "vault-0", Endpoint: active.URL(),
+					UnsealKeys: active.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+				{
+					Name: "vault-1", Endpoint: decommissioned.URL(), Enabled: &disabled,
+					UnsealKeys: decommissioned.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+			},
+		},
+		Status: vaultv1.VaultUnsealConfigStatus{VaultStatuses: []vaultv1.VaultInstanceStatus{
+			{Name: "vault-1", Sealed: true, Error: "connection refused", UnsealCount: 4},
+		}},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+
+	assert.False(t, active.Sealed())
+	assert.True(t, decommissioned.Sealed())
+	assert.Zero(t, decommissioned.Requests("sys/seal-status"), "a disabled instance is not contacted")
+
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	status := updated.Status.VaultStatuses[1]
+	assert.Equal(t, "vault-1", status.Name)
+	assert.False(t, status.Sealed)
+	assert.Empty(t, status.Error)
+	assert.Equal(t, int64(4), status.UnsealCount, "the unseal history is kept")
+	condition := meta.FindStatusCondition(status.Conditions, ConditionTypeDisabled)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, ReasonInstanceDisabled, condition.Reason)
+	assert.Equal(t, int64(3), condition.ObservedGeneration)
+
+	// Disabled instances do not count towards readiness
+	assert.Equal(t, "1/1", updated.Status.Ready)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseReady, updated.Status.Phase)
+
+	// Enabling the instance again reconciles it without the Disabled condition
+	updated.Spec.VaultInstances[1].Enabled = nil
+	require.NoError(t, k8sClient.Update(t.Context(), &updated))
+	_, err = reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	assert.False(t, decommissioned.Sealed())
+	assert.Equal(t, "2/2", updated.Status.Ready)
+	assert.Empty(t, updated.Status.VaultStatuses[1].Conditions)
+	assert.Equal(t, int64(5), updated.Status.VaultStatuses[1].UnsealCount)
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/features"
+)
+
+const (
+	// defaultConsulService is the catalog service Vault registers as by default
+	defaultConsulService = "vault"
+	// discoveryTimeout bounds a single discovery query
+	discoveryTimeout = 10 * time.Second
+)
+
+// discoveryHTTPClient queries discovery providers.
+var discoveryHTTPClient = &http.Client{Timeout: discoveryTimeout}
+
+// discoveredNode is a Vault server found by a discovery provider.
+type discoveredNode struct {
+	// Name identifies the node within its instance, e.g. the Consul node name
+	Name string
+	Host string
+	// Port is 0 when the provider does not know it
+	Port int
+}
+
+// expandInstances returns the instances to check in dependency order: spec
+// instances as is, and one instance per node of instances with discovery.
+// Instances referencing a bank-vaults Vault are completed from it first.
+// Instances whose discovery or bank-vaults Vault failed, or whose dependencies
+// are invalid, are returned as failed statuses instead.
+func (r *VaultUnsealConfigReconciler) expandInstances(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	settings *unsealSettings,
+) ([]*vaultv1.VaultInstance, []vaultv1.VaultInstanceStatus) {
+	instances := make([]*vaultv1.VaultInstance, 0, len(vaultConfig.Spec.VaultInstances))
+	var failed []vaultv1.VaultInstanceStatus
+
+	order, invalid := DependencyOrder(vaultConfig.Spec.VaultInstances)
+	for i := range vaultConfig.Spec.VaultInstances {
+		err := invalid[i]
+		if err == nil || !instanceEnabled(&vaultConfig.Spec.VaultInstances[i]) {
+			continue
+		}
+		logger.Error(err, "invalid instance dependencies", "instance", err.Instance)
+		status := vaultv1.VaultInstanceStatus{
+			Name:   err.Instance,
+			Sealed: true,
+			Error:  err.Error(),
+			Reason: ReasonInvalidDependencies,
+		}
+		recordUnsealHistory(&status, findInstanceStatus(vaultConfig, err.Instance), false, true)
+		failed = append(failed, status)
+	}
+
+	for _, i := range order {
+		if !instanceEnabled(&vaultConfig.Spec.VaultInstances[i]) {
+			continue
+		}
+		instance := settings.instance(&vaultConfig.Spec.VaultInstances[i])
+		if instance.BankVaults != nil {
+			if err := r.applyBankVaults(ctx, vaultConfig.Namespace, instance); err != nil {
+				logger.Error(err, "failed to read bank-vaults Vault", "instance", instance.Name)
+				status := vaultv1.VaultInstanceStatus{
+					Name:   instance.Name,
+					Sealed: true,
+					Error:  err.Error(),
+					Reason: ReasonBankVaultsFailed,
+				}
+				recordUnsealHistory(&status, findInstanceStatus(vaultConfig, instance.Name), false, true)
+				failed = append(failed, status)
+				continue
+			}
+		}
+		if instance.Discovery == nil {
+			instances = append(instances, instance)
+			continue
+		}
+
+		var nodes []discoveredNode
+		var err error
+		reason := ReasonDiscoveryFailed
+		if r.featureEnabled(features.Discovery) {
+			nodes, err = r.discoverNodes(ctx, vaultConfig.Namespace, instance)
+		} else {
+			err = fmt.Errorf("discovery is disabled by the %s feature gate", features.Discovery)
+			reason = ReasonFeatureDisabled
+		}
+		if err != nil {
+			logger.Error(err, "failed to discover vault nodes", "instance", instance.Name)
+			status := vaultv1.VaultInstanceStatus{
+				Name:   instance.Name,
+				Sealed: true,
+				Error:  err.Error(),
+				Reason: reason,
+			}
+			recordUnsealHistory(&status, findInstanceStatus(vaultConfig, instance.Name), false, true)
+			failed = append(failed, status)
+			continue
+		}
+		logger.V(1).Info("Discovered vault nodes", "instance", instance.Name, "nodes", len(nodes))
+
+		for _, node := range nodes {
+			nodeInstance, err := discoveredInstance(instance, node)
+			if err != nil {
+				logger.Error(err, "skipping discovered vault node", "instance", instance.Name, "node", node.Name)
+				continue
+			}
+			instances = append(instances, nodeInstance)
+		}
+	}
+	return instances, failed
+}
+
+// instanceStatuses returns the statuses recorded for a spec instance: its own,
+// or those of its discovered nodes.
+func instanceStatuses(
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+) []*vaultv1.VaultInstanceStatus {
+	var statuses []*vaultv1.VaultInstanceStatus
+	for i := range vaultConfig.Status.VaultStatuses {
+		status := &vaultConfig.Status.VaultStatuses[i]
+		if status.Name == instance.Name ||
+			(instance.Discovery != nil && strings.HasPrefix(status.Name, instance.Name+"-")) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// discoverNodes queries the discovery provider of instance.
+func (r *VaultUnsealConfigReconciler) discoverNodes(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) ([]discoveredNode, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	switch {
+	case instance.Discovery.Consul != nil:
+		consul := instance.Discovery.Consul
+		token := ""
+		if consul.TokenSecretRef != nil {
+			data, err := readSecretKey(ctx, r.Client, namespace, *consul.TokenSecretRef)
+			if err != nil {
+				return nil, err
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		return consulNodes(ctx, consul, token)
+	case instance.Discovery.DNSSrv != "":
+		return srvNodes(ctx, r.srvResolver(), instance.Discovery.DNSSrv)
+	case instance.Discovery.StatefulSet != "":
+		return r.statefulSetNodes(ctx, namespace, instance)
+	default:
+		return nil, fmt.Errorf("discovery of instance %s sets no provider", instance.Name)
+	}
+}
+
+// discoveredInstance returns a copy of instance addressing node. The node's
+// host and, when known, its port replace those of the instance endpoint.
+func discoveredInstance(instance *vaultv1.VaultInstance, node discoveredNode) (*vaultv1.VaultInstance, error) {
+	endpoint, err := url.Parse(instance.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", instance.Endpoint, err)
+	}
+	port := endpoint.Port()
+	if node.Port > 0 {
+		port = strconv.Itoa(node.Port)
+	}
+	if port == "" {
+		endpoint.Host = bracketIPv6(node.Host)
+	} else {
+		endpoint.Host = net.JoinHostPort(node.Host, port)
+	}
+
+	nodeInstance := instance.DeepCopy()
+	nodeInstance.Name = fmt.Sprintf("%s-%s", instance.Name, node.Name)
+	nodeInstance.Endpoint = endpoint.String()
+	nodeInstance.Discovery = nil
+	return nodeInstance, nil
+}
+
+// bracketIPv6 encloses IPv6 literals in brackets for use as a URL host.
+func bracketIPv6(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// consulCatalogEntry is the part of a Consul catalog service entry the operator uses.
+type consulCatalogEntry struct {
+	Node           string
+	Address        string
+	ServiceAddress string
+	ServicePort    int
+}
+
+// consulNodes lists the nodes registered for the service in the Consul catalog.
+// The catalog includes nodes failing their health checks, as sealed Vaults do.
+func consulNodes(ctx context.Context, consul *vaultv1.ConsulDiscovery, token string) ([]discoveredNode, error) {
+	service := consul.Service
+	if service == "" {
+		service = defaultConsulService
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(consul.Address, "/") + "/v1/catalog/service/" + url.PathEscape(service))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Consul address %q: %w", consul.Address, err)
+	}
+	query := endpoint.Query()
+	if consul.Tag != "" {
+		query.Set("tag", consul.Tag)
+	}
+	if consul.Datacenter != "" {
+		query.Set("dc", consul.Datacenter)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+	resp, err := discoveryHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul catalog: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul catalog returned %s for service %s", resp.Status, service)
+	}
+
+	var entries []consulCatalogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode Consul catalog: %w", err)
+	}
+
+	nodes := make([]discoveredNode, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.ServiceAddress
+		if host == "" {
+			host = entry.Address
+		}
+		nodes = append(nodes, discoveredNode{Name: entry.Node, Host: host, Port: entry.ServicePort})
+	}
+	// Keep the status order stable whatever order Consul returns
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+// srvNodes lists the targets of an SRV record. Each target is named by its host
+// name, so nodes keep their status when records are reordered or reweighted.
+func srvNodes(ctx context.Context, resolver SRVResolver, record string) ([]discoveredNode, error) {
+	_, records, err := resolver.LookupSRV(ctx, "", "", record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up SRV record %s: %w", record, err)
+	}
+
+	// Resolvers shuffle records by weight; sorting keeps names and status order stable
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Target != records[j].Target {
+			return records[i].Target < records[j].Target
+		}
+		return records[i].Port < records[j].Port
+	})
+
+	nodes := make([]discoveredNode, 0, len(records))
+	for i, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		name := host
+		// A target listed on several ports is named by port after the first
+		if i > 0 && records[i-1].Target == srv.Target {
+			name = fmt.Sprintf("%s-%d", host, srv.Port)
+		}
+		nodes = append(nodes, discoveredNode{Name: name, Host: host, Port: int(srv.Port)})
+	}
+	return nodes, nil
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDiscoveredInstance(t *testing.T) {
+	instance := &vaultv1.VaultInstance{
+		Name:      "vault",
+		Endpoint:  "https://vault.service.consul:8200",
+		Discovery: &vaultv1.Discovery{Consul: &vaultv1.ConsulDiscovery{Address: "http://consul:8500"}},
+	}
+
+	node, err := discoveredInstance(instance, discoveredNode{Name: "vm-1", Host: "10.0.1.5", Port: 8300})
+	require.NoError(t, err)
+	assert.Equal(t, "vault-vm-1", node.Name)
+	assert.Equal(t, "https://10.0.1.5:8300", node.Endpoint)
+	assert.Nil(t, node.Discovery)
+
+	// Without a catalog port the endpoint's port is kept
+	node, err = discoveredInstance(instance, discoveredNode{Name: "vm-2", Host: "fd00::2"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://[fd00::2]:8200", node.Endpoint)
+}
+
+func TestReconcileConsulDiscoveredNodes(t *testing.T) {
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "s3cr3t" {
+			http.Error(w, "ACL not found", http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "/v1/catalog/service/vault", r.URL.Path)
+		assert.Equal(t, "dc2", r.URL.Query().Get("dc"))
+		_, _ = fmt.Fprint(w, `[
+			{"Node":"vm-2","Address":"10.0.1.6","ServiceAddress":"","ServicePort":8200},
+			{"Node":"vm-1","Address":"10.0.0.1","ServiceAddress":"10.0.1.5","ServicePort":8200}
+		]`)
+	}))
+	defer consul.Close()
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vms", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:       "vault",
+			Endpoint:   "https://vault.service.consul:8200",
+			UnsealKeys: []string{"k1"},
+			Discovery: &vaultv1.Discovery{Consul: &vaultv1.ConsulDiscovery{
+				Address:        consul.URL,
+				Datacenter:     "dc2",
+				TokenSecretRef: &vaultv1.SecretKeyRef{Name: "consul", Key: "token"},
+			}},
+		}}},
+	}
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul", Namespace: "vault"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t\n")},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig, token).
+		Build()
+
+	mockClient := &mocks.MockVaultClient{}
+	mockClient.On("IsSealed", mock.Anything).Return(false, nil)
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-vm-1", mock.MatchedBy(func(i *vaultv1.VaultInstance) bool {
+		return i.Endpoint == "https://10.0.1.5:8200"
+	})).Return(mockClient, nil)
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-vm-2", mock.MatchedBy(func(i *vaultv1.VaultInstance) bool {
+		return i.Endpoint == "https://10.0.1.6:8200"
+	})).Return(mockClient, nil)
+
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vms", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	assert.Equal(t, "vault-vm-1", updated.Status.VaultStatuses[0].Name)
+	assert.Equal(t, "vault-vm-2", updated.Status.VaultStatuses[1].Name)
+	assert.Equal(t, "2/2", updated.Status.Ready)
+	mockRepo.AssertExpectations(t)
+
+	// A rejected token is reported on the instance
+	token.Data["token"] = []byte("wrong")
+	require.NoError(t, k8sClient.Update(t.Context(), token))
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	assert.Equal(t, ReasonDiscoveryFailed, updated.Status.VaultStatuses[0].Reason)
+	assert.Contains(t, updated.Status.VaultStatuses[0].Error, "403")
+}
+
+// srvTable resolves SRV records and hosts from fixed tables
+type srvTable struct {
+	staticResolver
+	records map[string][]*net.SRV
+}
+
+func (r *srvTable) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	records, ok := r.records[name]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	// Return a copy in reverse, as a resolver shuffling by weight might
+	out := make([]*net.SRV, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		srv := *records[i]
+		out = append(out, &srv)
+	}
+	return name, out, nil
+}
+
+func TestReconcileSRVDiscoveredNodes(t *testing.T) {
+	resolver := &srvTable{
+		staticResolver: staticResolver{},
+		records: map[string][]*net.SRV{"_vault._tcp.example.com": {
+			{Target: "vault-a.example.com.", Port: 8200},
+			{Target: "vault-b.example.com.", Port: 8200},
+		}},
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "srv", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:       "vault",
+			Endpoint:   "https://vault.example.com",
+			UnsealKeys: []string{"k1"},
+			Discovery:  &vaultv1.Discovery{DNSSrv: "_vault._tcp.example.com"},
+		}}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	mockClient := &mocks.MockVaultClient{}
+	mockClient.On("IsSealed", mock.Anything).Return(false, nil)
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, mock.Anything, mock.Anything).Return(mockClient, nil)
+
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	r.Resolver = resolver
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "srv", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	assert.Equal(t, "vault-vault-a.example.com", updated.Status.VaultStatuses[0].Name)
+	assert.Equal(t, "vault-vault-b.example.com", updated.Status.VaultStatuses[1].Name)
+	mockRepo.AssertCalled(t, "GetClient", mock.Anything, "vault/vault-vault-a.example.com",
+		mock.MatchedBy(func(i *vaultv1.VaultInstance) bool { return i.Endpoint == "https://vault-a.example.com:8200" }))
+
+	// Records are resolved again on the next reconcile
+	resolver.records["_vault._tcp.example.com"] = []*net.SRV{
+		{Target: "vault-b.example.com.", Port: 8200},
+		{Target: "vault-c.example.com.", Port: 8201},
+	}
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 2)
+	assert.Equal(t, "vault-vault-b.example.com", updated.Status.VaultStatuses[0].Name)
+	assert.Equal(t, "vault-vault-c.example.com", updated.Status.VaultStatuses[1].Name)
+
+	// A missing record is reported on the instance
+	delete(resolver.records, "_vault._tcp.example.com")
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	assert.Equal(t, ReasonDiscoveryFailed, updated.Status.VaultStatuses[0].Reason)
+}
+
+func TestReconcileWithDiscoveryFeatureDisabled(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "srv", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:       "vault",
+			Endpoint:   "https://vault.example.com",
+			UnsealKeys: []string{"k1"},
+			Discovery:  &vaultv1.Discovery{DNSSrv: "_vault._tcp.example.com"},
+		}}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	options := DefaultReconcilerOptions()
+	require.NoError(t, options.Features.Set("Discovery=false"))
+	mockRepo := &mocks.MockVaultClientRepository{}
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, options)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "srv", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	assert.Equal(t, ReasonFeatureDisabled, updated.Status.VaultStatuses[0].Reason)
+	assert.Equal(t, "discovery is disabled by the Discovery feature gate", updated.Status.VaultStatuses[0].Error)
+	mockRepo.AssertNotCalled(t, "GetClient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSRVNodesNamesRepeatedTargets(t *testing.T) {
+	resolver := &srvTable{records: map[string][]*net.SRV{"_vault._tcp.example.com": {
+		{Target: "vault.example.com.", Port: 8200},
+		{Target: "vault.example.com.", Port: 8300},
+	}}}
+	nodes, err := srvNodes(t.Context(), resolver, "_vault._tcp.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []discoveredNode{
+		{Name: "vault.example.com", Host: "vault.example.com", Port: 8200},
+		{Name: "vault.example.com-8300", Host: "vault.example.com", Port: 8300},
+	}, nodes)
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sort"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// dnsLookupTimeout bounds the resolution of an endpoint host.
+const dnsLookupTimeout = 5 * time.Second
+
+// HostResolver resolves host names; net.DefaultResolver implements it.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// SRVResolver looks up SRV records; net.DefaultResolver implements it. SRV
+// discovery uses the reconciler's Resolver when it implements SRVResolver.
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// srvResolver returns the resolver for SRV discovery, which is not optional
+// like endpoint resolution.
+func (r *VaultUnsealConfigReconciler) srvResolver() SRVResolver {
+	if resolver, ok := r.Resolver.(SRVResolver); ok {
+		return resolver
+	}
+	return net.DefaultResolver
+}
+
+// resolveEndpoint records the addresses the endpoint host resolves to, or why it
+// did not resolve, so a renamed Service is visible in status.
+func (r *VaultUnsealConfigReconciler) resolveEndpoint(
+	ctx context.Context,
+	instance *vaultv1.VaultInstance,
+	status *vaultv1.VaultInstanceStatus,
+) {
+	// Endpoints reached through a pod or a tunnel are not resolved by the operator
+	if r.Resolver == nil || instanceAccessKey(instance) != "" || instance.Tunnel != nil {
+		return
+	}
+
+	endpoint, err := url.Parse(instance.Endpoint)
+	if err != nil || endpoint.Hostname() == "" {
+		status.ResolutionError = fmt.Sprintf("endpoint %q has no host", instance.Endpoint)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+	addresses, err := r.Resolver.LookupHost(ctx, endpoint.Hostname())
+	if err != nil {
+		status.ResolutionError = err.Error()
+		return
+	}
+	sortAddresses(addresses)
+	status.ResolvedAddresses = addresses
+}
+
+// sortAddresses orders addresses numerically, IPv4 before IPv6, so the status of
+// a dual-stack Service is stable while resolvers rotate their answers.
+func sortAddresses(addresses []string) {
+	sort.SliceStable(addresses, func(i, j int) bool {
+		a, errA := netip.ParseAddr(addresses[i])
+		b, errB := netip.ParseAddr(addresses[j])
+		if errA != nil || errB != nil {
+			return addresses[i] < addresses[j]
+		}
+		return a.Compare(b) < 0
+	})
+}
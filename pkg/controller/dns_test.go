@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticResolver resolves hosts from a fixed table
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addresses, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return append([]string(nil), addresses...), nil
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{Resolver: staticResolver{
+		"vault.vault.svc": {"10.0.0.7", "10.0.0.5"},
+	}}
+
+	var status vaultv1.VaultInstanceStatus
+	r.resolveEndpoint(t.Context(), &vaultv1.VaultInstance{Endpoint: "https://vault.vault.svc:8200"}, &status)
+	assert.Equal(t, []string{"10.0.0.5", "10.0.0.7"}, status.ResolvedAddresses)
+	assert.Empty(t, status.ResolutionError)
+
+	// A renamed Service no longer resolves
+	status = vaultv1.VaultInstanceStatus{}
+	r.resolveEndpoint(t.Context(), &vaultv1.VaultInstance{Endpoint: "https://vault-old.vault.svc:8200"}, &status)
+	assert.Empty(t, status.ResolvedAddresses)
+	assert.Equal(t, "lookup vault-old.vault.svc: no such host", status.ResolutionError)
+
+	// Resolution is disabled without a resolver
+	status = vaultv1.VaultInstanceStatus{}
+	(&VaultUnsealConfigReconciler{}).resolveEndpoint(t.Context(),
+		&vaultv1.VaultInstance{Endpoint: "https://vault.vault.svc:8200"}, &status)
+	assert.Equal(t, vaultv1.VaultInstanceStatus{}, status)
+}
+
+func TestResolveDualStackEndpoint(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{Resolver: staticResolver{
+		"vault.vault.svc": {"fd00::10", "10.0.0.10", "fd00::9", "10.0.0.9"},
+		"fd00::5":         {"fd00::5"},
+	}}
+
+	var status vaultv1.VaultInstanceStatus
+	r.resolveEndpoint(t.Context(), &vaultv1.VaultInstance{Endpoint: "https://vault.vault.svc:8200"}, &status)
+	assert.Equal(t, []string{"10.0.0.9", "10.0.0.10", "fd00::9", "fd00::10"}, status.ResolvedAddresses)
+
+	// The brackets of an IPv6 endpoint are not part of the host
+	status = vaultv1.VaultInstanceStatus{}
+	r.resolveEndpoint(t.Context(), &vaultv1.VaultInstance{Endpoint: "https://[fd00::5]:8200"}, &status)
+	assert.Equal(t, []string{"fd00::5"}, status.ResolvedAddresses)
+	assert.Empty(t, status.ResolutionError)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// drainingNode returns the name of the node instance runs on when that node is
+// cordoned, as it is while drained, or "" otherwise or when the pod of the
+// instance is not known.
+func (r *VaultUnsealConfigReconciler) drainingNode(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) string {
+	pod := r.instancePod(ctx, namespace, instance)
+	if pod == nil || pod.Spec.NodeName == "" {
+		return ""
+	}
+	var node corev1.Node
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		return ""
+	}
+	if nodeCordoned(&node) {
+		return node.Name
+	}
+	return ""
+}
+
+// nodeCordoned reports whether node is marked unschedulable.
+func nodeCordoned(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// retryingInstance reports whether checking an instance again would retry an
+// unseal: its last check left it sealed or failed.
+func retryingInstance(previous *vaultv1.VaultInstanceStatus) bool {
+	return previous != nil && (previous.Sealed || previous.Error != "")
+}
+
+// drainingStatus returns the status of an instance whose retry is delayed
+// while its node is drained: the previous status, with the failure of the last
+// check replaced by ReasonNodeDraining so it is not reported again.
+func drainingStatus(previous *vaultv1.VaultInstanceStatus) vaultv1.VaultInstanceStatus {
+	status := *previous.DeepCopy()
+	status.Error = ""
+	status.Reason = ReasonNodeDraining
+	return status
+}
+
+// onlyDrainingPending reports whether every sealed or failing instance has its
+// retry delayed while its node is drained.
+func onlyDrainingPending(statuses []vaultv1.VaultInstanceStatus) bool {
+	for i := range statuses {
+		status := &statuses[i]
+		if (status.Sealed || status.Error != "") && status.Reason != ReasonNodeDraining {
+			return false
+		}
+	}
+	return true
+}
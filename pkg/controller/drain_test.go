@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestNodeCordoned(t *testing.T) {
+	assert.False(t, nodeCordoned(&corev1.Node{}))
+	assert.True(t, nodeCordoned(&corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}}))
+	assert.True(t, nodeCordoned(&corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{
+		Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule,
+	}}}}))
+}
+
+func TestOnlyDrainingPending(t *testing.T) {
+	assert.True(t, onlyDrainingPending([]vaultv1.VaultInstanceStatus{
+		{Name: "vault-0"},
+		{Name: "vault-1", Sealed: true, Reason: ReasonNodeDraining},
+	}))
+	assert.False(t, onlyDrainingPending([]vaultv1.VaultInstanceStatus{
+		{Name: "vault-1", Sealed: true, Reason: ReasonNodeDraining},
+		{Name: "vault-2", Error: "connection refused", Reason: ReasonEndpointUnreachable},
+	}))
+}
+
+func TestVaultUnsealConfigReconciler_DelaysRetriesOnDrainedNode(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{
+				Name: "vault-0", Endpoint: "http://127.0.0.1:8200", UnsealKeys: []string{"a2V5MQ=="},
+				// Without a pod executor, every check of the instance fails
+				Access: &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+			}},
+		},
+		Status: vaultv1.VaultUnsealConfigStatus{VaultStatuses: []vaultv1.VaultInstanceStatus{{
+			Name: "vault-0", Sealed: true, Error: "connection refused", Reason: ReasonEndpointUnreachable,
+			ConsecutiveFailures: 3,
+		}}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-0", Namespace: "vault"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig, pod, node).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	t.Cleanup(func() { _ = repo.Close() })
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	status := updated.Status.VaultStatuses[0]
+	assert.Equal(t, ReasonNodeDraining, status.Reason)
+	assert.Empty(t, status.Error)
+	assert.True(t, status.Sealed)
+	assert.Equal(t, int32(3), status.ConsecutiveFailures, "delayed retries are not counted as failures")
+
+	// Retries resume once the node is schedulable again
+	node.Spec.Unschedulable = false
+	require.NoError(t, k8sClient.Update(t.Context(), node))
+	_, err = reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	status = updated.Status.VaultStatuses[0]
+	assert.Contains(t, status.Error, "exec access is not available")
+	assert.Equal(t, int32(4), status.ConsecutiveFailures)
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// startUnsealManager runs the VaultUnsealConfig controller against env,
+// checking instances every requeueAfter
+func startUnsealManager(t *testing.T, env *testenv.Environment, requeueAfter time.Duration) {
+	t.Helper()
+	env.StartManager(t, func(mgr ctrl.Manager) error {
+		repo := NewDefaultVaultClientRepository(nil)
+		t.Cleanup(func() { _ = repo.Close() })
+		options := DefaultReconcilerOptions()
+		options.RequeueAfter = requeueAfter
+		reconciler := NewVaultUnsealConfigReconciler(mgr.GetClient(), log.Log, mgr.GetScheme(), repo, options)
+		reconciler.Recorder = mgr.GetEventRecorderFor(EventRecorderName)
+		return reconciler.SetupWithManager(mgr)
+	})
+}
+
+func TestEnvtest_VaultUnsealConfig(t *testing.T) {
+	env := testenv.Start(t)
+	startUnsealManager(t, env, 200*time.Millisecond)
+	ctx := t.Context()
+
+	sealed := fakevault.New(t)
+	unsealed := fakevault.New(t, fakevault.Unsealed())
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: env.Namespace(t)},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				testenv.VaultInstance("vault-0", sealed),
+				testenv.VaultInstance("vault-1", unsealed),
+			},
+		},
+	}
+	require.NoError(t, env.Client.Create(ctx, vaultConfig))
+	key := client.ObjectKeyFromObject(vaultConfig)
+
+	// readyAt waits for the Ready condition of the given generation
+	readyAt := func(generation int64, ready string) {
+		t.Helper()
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			var current vaultv1.VaultUnsealConfig
+			require.NoError(c, env.Client.Get(ctx, key, &current))
+			condition := meta.FindStatusCondition(current.Status.Conditions, ConditionTypeReady)
+			require.NotNil(c, condition)
+			assert.Equal(c, metav1.ConditionTrue, condition.Status)
+			assert.Equal(c, generation, condition.ObservedGeneration)
+			assert.Equal(c, ready, current.Status.Ready)
+		}, 30*time.Second, 100*time.Millisecond)
+	}
+
+	readyAt(vaultConfig.Generation, "2/2")
+	assert.False(t, sealed.Sealed())
+	assert.Zero(t, unsealed.Requests("sys/unseal"))
+
+	// The API server keeps status behind the subresource, so a spec update
+	// cannot overwrite what the controller reported
+	require.NoError(t, env.Client.Get(ctx, key, vaultConfig))
+	added := fakevault.New(t)
+	vaultConfig.Spec.VaultInstances = append(vaultConfig.Spec.VaultInstances, testenv.VaultInstance("vault-2", added))
+	vaultConfig.Status.Ready = "0/0"
+	require.NoError(t, env.Client.Update(ctx, vaultConfig))
+	assert.NotEqual(t, "0/0", vaultConfig.Status.Ready)
+
+	readyAt(vaultConfig.Generation, "3/3")
+	assert.False(t, added.Sealed())
+
+	// An instance that seals again is unsealed on a later check
+	sealed.Seal()
+	require.Eventually(t, func() bool { return !sealed.Sealed() }, 30*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+)
+
+// errorClass maps a failed check to the coarse class of its error metric. The
+// reason decides most classes; err, if known, separates timeouts and server
+// errors from other unreachable or failed requests.
+func errorClass(err error, reason string) metrics.ErrorClass {
+	var responseErr *api.ResponseError
+	var netErr net.Error
+
+	switch reason {
+	case ReasonUnresolvable:
+		return metrics.ErrorClassDNS
+	case ReasonTLSVerificationFailed:
+		return metrics.ErrorClassTLS
+	case ReasonInvalidKeys:
+		return metrics.ErrorClassInvalidKey
+	case ReasonPermissionDenied:
+		return metrics.ErrorClassPermission
+	case ReasonSecretMissing, ReasonSecretKeyMissing, ReasonExternalSecretFetchFailed, ReasonKeyCommandFailed,
+		ReasonTransitUnwrapFailed, ReasonKeyDecryptionFailed, ReasonPKCS11Failed:
+		return metrics.ErrorClassKeySource
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return metrics.ErrorClassTimeout
+	case errors.As(err, &responseErr) && responseErr.StatusCode >= http.StatusInternalServerError:
+		return metrics.ErrorClassHTTP5xx
+	case reason == ReasonEndpointUnreachable:
+		return metrics.ErrorClassConnection
+	default:
+		return metrics.ErrorClassOther
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+	dialErr := &url.Error{Op: "Get", URL: "https://vault:8200", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+	resolverTimeout := &url.Error{Op: "Get", URL: "https://vault-0:8200", Err: &net.OpError{
+		Op: "dial", Err: &net.DNSError{Err: "i/o timeout", Name: "vault-0", IsTimeout: true},
+	}}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected metrics.ErrorClass
+	}{
+		{"connection refused", dialErr, metrics.ErrorClassConnection},
+		{"deadline", fmt.Errorf("failed to unseal vault: %w", context.DeadlineExceeded), metrics.ErrorClassTimeout},
+		{"resolver timeout", resolverTimeout, metrics.ErrorClassTimeout},
+		{"server error", &api.ResponseError{StatusCode: 503, Errors: []string{"Vault is sealed"}}, metrics.ErrorClassHTTP5xx},
+		{"client error", &api.ResponseError{StatusCode: 400, Errors: []string{"bad request"}}, metrics.ErrorClassOther},
+		{
+			"rejected key",
+			&api.ResponseError{StatusCode: 400, Errors: []string{"cipher: message authentication failed"}},
+			metrics.ErrorClassInvalidKey,
+		},
+		{"permission", &api.ResponseError{StatusCode: 403}, metrics.ErrorClassPermission},
+		{"key source", &missingSecretKeyError{namespace: "vault", name: "vault-keys", key: "key2"}, metrics.ErrorClassKeySource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorClass(tt.err, classifyError(tt.err)))
+		})
+	}
+
+	// Discovery failures only keep their reason
+	assert.Equal(t, metrics.ErrorClassDNS, errorClass(nil, ReasonUnresolvable))
+	assert.Equal(t, metrics.ErrorClassOther, errorClass(nil, ReasonDiscoveryFailed))
+}
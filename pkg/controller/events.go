@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventRecorderName is the component reported as the source of operator events.
+const EventRecorderName = "vault-autounseal-operator"
+
+// InstanceLabelAnnotationPrefix prefixes the instance labels attached to the
+// Events of an instance as annotations.
+const InstanceLabelAnnotationPrefix = "instance.vault.io/"
+
+// ReconcileIDAnnotation holds the ID of the reconcile that emitted an Event,
+// also found in its log lines and the X-Request-ID header of its Vault requests.
+const ReconcileIDAnnotation = "vault.io/reconcile-id"
+
+// EventOptions configures how repeated events are deduplicated and throttled.
+// Identical events are always folded into one Event with a count and first and
+// last timestamps; these options bound what gets through to the API server.
+type EventOptions struct {
+	// Burst is the number of events an object may emit before being throttled
+	Burst int
+	// QPS is the rate at which the event budget of an object refills
+	QPS float64
+	// AggregationMaxEvents is the number of similar events, differing only in
+	// message, after which they are combined into one
+	AggregationMaxEvents int
+	// AggregationInterval is how long after the last similar event a new one
+	// starts a fresh aggregation
+	AggregationInterval time.Duration
+}
+
+// DefaultEventOptions returns the client-go correlator defaults: a burst of 25
+// events per object refilled at one event every 5 minutes, and aggregation of
+// more than 10 similar events within 10 minutes.
+func DefaultEventOptions() *EventOptions {
+	return &EventOptions{
+		Burst:                25,
+		QPS:                  1.0 / 300,
+		AggregationMaxEvents: 10,
+		AggregationInterval:  10 * time.Minute,
+	}
+}
+
+// NewEventBroadcaster returns a broadcaster deduplicating and throttling events
+// according to options.
+func NewEventBroadcaster(options *EventOptions) record.EventBroadcaster {
+	if options == nil {
+		options = DefaultEventOptions()
+	}
+	return record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize:            options.Burst,
+		QPS:                  float32(options.QPS),
+		MaxEvents:            options.AggregationMaxEvents,
+		MaxIntervalInSeconds: int(options.AggregationInterval.Seconds()),
+	})
+}
+
+// event records an event on obj if the reconciler has a recorder.
+func (r *VaultUnsealConfigReconciler) event(
+	obj runtime.Object,
+	eventType, reason, messageFmt string,
+	args ...interface{},
+) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
+
+// instanceEvent records an event about instance on obj, annotated with the
+// labels of the instance and the ID of the reconcile in ctx.
+func (r *VaultUnsealConfigReconciler) instanceEvent(
+	ctx context.Context,
+	obj runtime.Object,
+	instance *vaultv1.VaultInstance,
+	eventType, reason, messageFmt string,
+	args ...interface{},
+) {
+	if r.Recorder == nil {
+		return
+	}
+	labels := metrics.ValidInstanceLabels(instance.Labels)
+	annotations := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		annotations[InstanceLabelAnnotationPrefix+key] = value
+	}
+	if id := vault.RequestID(ctx); id != "" {
+		annotations[ReconcileIDAnnotation] = id
+	}
+	if len(annotations) == 0 {
+		r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+		return
+	}
+	r.Recorder.AnnotatedEventf(obj, annotations, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestEventBroadcasterFoldsRepeatedFailures(t *testing.T) {
+	clientset := kubefake.NewClientset()
+	broadcaster := NewEventBroadcaster(DefaultEventOptions())
+	defer broadcaster.Shutdown()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(newBackupTestScheme(t), corev1.EventSource{Component: EventRecorderName})
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", UID: "uid-1"}}
+	for range 5 {
+		recorder.Eventf(vaultConfig, corev1.EventTypeWarning, ReasonEndpointUnreachable,
+			"Instance vault-0 failed: connection refused")
+	}
+
+	require.Eventually(t, func() bool {
+		events, err := clientset.CoreV1().Events("vault").List(context.Background(), metav1.ListOptions{})
+		return err == nil && len(events.Items) == 1 && events.Items[0].Count == 5
+	}, 5*time.Second, 10*time.Millisecond, "one reconcile loop of failures is a single event with a count")
+}
+
+func TestReconcileRecordsFailureEvents(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(nil, fmt.Errorf("boom"))
+
+	recorder := record.NewFakeRecorder(10)
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	r.Recorder = recorder
+	ctx := vault.WithRequestID(context.Background(), "reconcile-1")
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning VaultRequestFailed Instance vault-0 failed: failed to get vault client: boom "+
+		"map[vault.io/reconcile-id:reconcile-1]", <-recorder.Events)
+}
+
+func TestReconcileLabelsInstanceEventsAndMetrics(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{
+				Name:       "vault-0",
+				Endpoint:   "http://vault-0:8200",
+				UnsealKeys: []string{"k1"},
+				Labels:     map[string]string{"environment": "production", "team": "platform", "Bad-Key": "x"},
+			},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(nil, fmt.Errorf("boom"))
+
+	instanceMetrics, err := metrics.NewInstanceMetrics(prometheus.NewRegistry(), []string{"environment"})
+	require.NoError(t, err)
+	recorder := record.NewFakeRecorder(10)
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	r.Recorder = recorder
+	r.Metrics = instanceMetrics
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err = r.Reconcile(vault.WithRequestID(context.Background(), "reconcile-1"), req)
+	require.NoError(t, err)
+
+	// Invalid labels are dropped rather than failing the event
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning VaultRequestFailed Instance vault-0 failed: failed to get vault client: boom "+
+		"map[instance.vault.io/environment:production instance.vault.io/team:platform "+
+		"vault.io/reconcile-id:reconcile-1]", <-recorder.Events)
+
+	// Only the exported label keys become metric labels
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		instanceMetrics.Sealed.WithLabelValues("vault", "vault", "vault-0", "production")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		instanceMetrics.Failures.WithLabelValues("vault", "vault", "vault-0", "production", ReasonVaultRequestFailed)))
+
+	// Deleting the config removes its series
+	require.NoError(t, k8sClient.Delete(context.Background(), vaultConfig))
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, testutil.CollectAndCount(instanceMetrics.Sealed))
+	assert.Equal(t, 0, testutil.CollectAndCount(instanceMetrics.Failures))
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestVaultUnsealConfigReconciler_UnsealsFakeVault(t *testing.T) {
+	sealed := fakevault.New(t)
+	unsealed := fakevault.New(t, fakevault.Unsealed())
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{
+					Name: "vault-0", Endpoint: sealed.URL(),
+					UnsealKeys: sealed.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+				{
+					Name: "vault-1", Endpoint: unsealed.URL(),
+					UnsealKeys: unsealed.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+				},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	reconcileStatuses := func() []vaultv1.VaultInstanceStatus {
+		t.Helper()
+		_, err := reconciler.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		var updated vaultv1.VaultUnsealConfig
+		require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+		require.Len(t, updated.Status.VaultStatuses, 2)
+		return updated.Status.VaultStatuses
+	}
+
+	statuses := reconcileStatuses()
+	assert.False(t, sealed.Sealed())
+	assert.False(t, statuses[0].Sealed)
+	assert.Empty(t, statuses[0].Error)
+	assert.NotNil(t, statuses[0].LastUnsealed)
+	assert.Equal(t, fakevault.DefaultVersion, statuses[0].Version)
+	assert.False(t, statuses[1].Sealed)
+	assert.Zero(t, unsealed.Requests("sys/unseal"), "an unsealed instance is left alone")
+
+	// An instance that seals again is unsealed on the next check
+	sealed.Seal()
+	statuses = reconcileStatuses()
+	assert.False(t, sealed.Sealed())
+	assert.False(t, statuses[0].Sealed)
+	assert.Equal(t, 2*fakevault.DefaultThreshold, sealed.Requests("sys/unseal"))
+}
+
+func TestVaultUnsealConfigReconciler_DetectsThreshold(t *testing.T) {
+	sealed := fakevault.New(t, fakevault.WithShares(4, 2))
+	short := fakevault.New(t, fakevault.WithShares(5, 4))
+	unsealed := fakevault.New(t, fakevault.WithShares(3, 2), fakevault.Unsealed())
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: sealed.URL(), UnsealKeys: sealed.Keys()},
+				{Name: "vault-1", Endpoint: short.URL(), UnsealKeys: short.Keys()[:3]},
+				{Name: "vault-2", Endpoint: unsealed.URL(), UnsealKeys: unsealed.Keys()},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	statuses := updated.Status.VaultStatuses
+	require.Len(t, statuses, 3)
+
+	// Only the threshold reported by Vault is submitted, not every key
+	assert.False(t, sealed.Sealed())
+	assert.Equal(t, 2, sealed.Requests("sys/unseal"))
+	assert.Equal(t, 2, statuses[0].EffectiveThreshold)
+
+	// Too few keys for the detected threshold are not submitted at all
+	assert.True(t, short.Sealed())
+	assert.Zero(t, short.Requests("sys/unseal"))
+	assert.Equal(t, ReasonThresholdNotMet, statuses[1].Reason)
+	assert.Contains(t, statuses[1].Error, "threshold (4) exceeds number of available keys (3)")
+
+	assert.False(t, statuses[2].Sealed)
+	assert.Equal(t, 2, statuses[2].EffectiveThreshold)
+}
+
+func TestVaultUnsealConfigReconciler_UnsealsWithHexKeys(t *testing.T) {
+	detected := fakevault.New(t)
+	explicit := fakevault.New(t)
+	mismatched := fakevault.New(t)
+
+	hexKeys := func(server *fakevault.Server) []string {
+		t.Helper()
+		var keys []string
+		for _, key := range server.Keys() {
+			decoded, err := base64.StdEncoding.DecodeString(key)
+			require.NoError(t, err)
+			keys = append(keys, hex.EncodeToString(decoded))
+		}
+		return keys
+	}
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: detected.URL(), UnsealKeys: hexKeys(detected)},
+				{
+					Name: "vault-1", Endpoint: explicit.URL(), UnsealKeys: hexKeys(explicit),
+					KeyEncoding: vaultv1.KeyEncodingHex,
+				},
+				{
+					Name: "vault-2", Endpoint: mismatched.URL(), UnsealKeys: mismatched.Keys(),
+					KeyEncoding: vaultv1.KeyEncodingHex,
+				},
+			},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	statuses := updated.Status.VaultStatuses
+	require.Len(t, statuses, 3)
+
+	assert.False(t, detected.Sealed())
+	assert.Empty(t, statuses[0].Error)
+	assert.False(t, explicit.Sealed())
+	assert.Empty(t, statuses[1].Error)
+
+	// Base64 keys declared as hex are rejected before reaching Vault
+	assert.True(t, mismatched.Sealed())
+	assert.Zero(t, mismatched.Requests("sys/unseal"))
+	assert.Equal(t, ReasonInvalidKeys, statuses[2].Reason)
+	assert.NotContains(t, statuses[2].Error, mismatched.Keys()[0])
+}
+
+func TestVaultUnsealConfigReconciler_NeverExceedsVaultThreshold(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		server := fakevault.New(t, fakevault.WithShares(5, 2))
+		vaultConfig := &vaultv1.VaultUnsealConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+			Spec: vaultv1.VaultUnsealConfigSpec{
+				VaultInstances: []vaultv1.VaultInstance{{
+					Name: "vault-0", Endpoint: server.URL(), UnsealKeys: server.Keys(), Threshold: testutil.IntPtr(3),
+				}},
+				Settings: &vaultv1.UnsealSettings{StrictThreshold: &strict},
+			},
+		}
+		tc := testutil.NewTestContext(t)
+		k8sClient := fake.NewClientBuilder().
+			WithScheme(tc.Scheme).
+			WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+			WithObjects(vaultConfig).
+			Build()
+
+		repo := NewDefaultVaultClientRepository(nil)
+		reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+		_, err := reconciler.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		_ = repo.Close()
+
+		var updated vaultv1.VaultUnsealConfig
+		require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+		require.Len(t, updated.Status.VaultStatuses, 1)
+		status := updated.Status.VaultStatuses[0]
+		if strict {
+			assert.True(t, server.Sealed())
+			assert.Zero(t, server.Requests("sys/unseal"))
+			assert.Equal(t, ReasonThresholdMismatch, status.Reason)
+		} else {
+			assert.False(t, server.Sealed())
+			assert.Equal(t, 2, server.Requests("sys/unseal"))
+			assert.Empty(t, status.Error)
+		}
+	}
+}
+
+func TestVaultUnsealConfigReconciler_HoldsOffDuringMaintenance(t *testing.T) {
+	server := fakevault.New(t)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "vault", Namespace: "vault",
+			Annotations: map[string]string{vaultv1.MaintenanceAnnotation: "vault-1, vault-0"},
+		},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{Name: "vault-0", Endpoint: server.URL(), UnsealKeys: server.Keys()}},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	t.Cleanup(func() { _ = repo.Close() })
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	assert.Equal(t, ReasonMaintenance, updated.Status.VaultStatuses[0].Reason)
+	assert.True(t, server.Sealed())
+	assert.Zero(t, server.Requests("sys/unseal"))
+
+	// Unsealing resumes once the signal clears
+	delete(updated.Annotations, vaultv1.MaintenanceAnnotation)
+	require.NoError(t, k8sClient.Update(t.Context(), &updated))
+	_, err = reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.False(t, server.Sealed())
+}
+
+func TestVaultUnsealConfigReconciler_ReportsObservedGeneration(t *testing.T) {
+	server := fakevault.New(t)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", Generation: 3},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{Name: "vault-0", Endpoint: server.URL(), UnsealKeys: server.Keys()}},
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	t.Cleanup(func() { _ = repo.Close() })
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	// Health checks, e.g. of Argo CD, compare both against metadata.generation
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	assert.Equal(t, updated.Generation, updated.Status.ObservedGeneration)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseReady, updated.Status.Phase)
+	ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, updated.Generation, ready.ObservedGeneration)
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeReconciling))
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeStalled))
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// FuzzParseEndpoint checks that the parsers the controller runs on instance
+// endpoints, from watch mappers to network policies, never panic on malformed
+// input and only produce well-formed results
+func FuzzParseEndpoint(f *testing.F) {
+	for _, seed := range []string{
+		"https://vault-0.vault-internal.vault.svc:8200",
+		"https://vault.vault.svc.cluster.local.:8200",
+		"http://10.0.0.5:8200",
+		"https://[fd00::1]:8200",
+		"https://[fe80::1%25eth0]:8200",
+		"http://fd00::1:8200",
+		"https://vault.example.com",
+		"https://vault:99999",
+		"https://:8200",
+		"http://%zz",
+		"http://[::1",
+		"svc",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	r := &VaultUnsealConfigReconciler{Resolver: staticResolver{}}
+	f.Fuzz(func(t *testing.T, endpoint string) {
+		if value := endpointPodIndexValue(endpoint); value != "" &&
+			!strings.HasPrefix(value, "ip:") && !strings.HasPrefix(value, "pod:") {
+			t.Fatalf("unexpected pod index value %q", value)
+		}
+
+		rule, key, err := egressRule(endpoint)
+		if err == nil {
+			if key == "" || len(rule.Ports) != 1 {
+				t.Fatalf("incomplete egress rule %q: %+v", key, rule)
+			}
+			if port := rule.Ports[0].Port.IntValue(); port < 1 || port > 65535 {
+				t.Fatalf("egress rule allows port %d", port)
+			}
+		}
+
+		var status vaultv1.VaultInstanceStatus
+		r.resolveEndpoint(context.Background(), &vaultv1.VaultInstance{Endpoint: endpoint}, &status)
+		if status.ResolutionError == "" {
+			t.Fatalf("resolved %q with an empty resolver", endpoint)
+		}
+	})
+}
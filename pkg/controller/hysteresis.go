@@ -0,0 +1,43 @@
+package controller
+
+import (
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// debounceReadiness sets Ready on every enabled instance status from its seal
+// state. An instance only changes between ready and not ready once the
+// configured number of consecutive checks agree; until then it keeps its
+// previous state and counts the disagreeing checks in ReadinessStreak. It
+// reports whether every enabled instance is ready.
+func debounceReadiness(
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	statuses []vaultv1.VaultInstanceStatus,
+	settings *unsealSettings,
+) bool {
+	allReady := true
+	for i := range statuses {
+		status := &statuses[i]
+		status.ReadinessStreak = 0
+		if instanceDisabled(status) {
+			status.Ready = nil
+			continue
+		}
+
+		observed := !status.Sealed
+		ready := observed
+		previous := findInstanceStatus(vaultConfig, status.Name)
+		if previous != nil && previous.Ready != nil && *previous.Ready != observed {
+			threshold := settings.NotReadyThreshold
+			if observed {
+				threshold = settings.ReadyThreshold
+			}
+			if streak := previous.ReadinessStreak + 1; streak < int32(threshold) {
+				ready = *previous.Ready
+				status.ReadinessStreak = streak
+			}
+		}
+		status.Ready = &ready
+		allReady = allReady && ready
+	}
+	return allReady
+}
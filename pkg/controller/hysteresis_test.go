@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDebounceReadiness(t *testing.T) {
+	readyThreshold, notReadyThreshold := int32(3), int32(2)
+	settings := defaultUnsealSettings(DefaultReconcilerOptions())
+	settings.apply(&vaultv1.UnsealSettings{Hysteresis: &vaultv1.ReadyHysteresis{
+		ReadyThreshold: &readyThreshold, NotReadyThreshold: &notReadyThreshold,
+	}})
+	require.Equal(t, 3, settings.ReadyThreshold)
+	require.Equal(t, 2, settings.NotReadyThreshold)
+
+	config := &vaultv1.VaultUnsealConfig{}
+	check := func(sealed bool) (bool, vaultv1.VaultInstanceStatus) {
+		statuses := []vaultv1.VaultInstanceStatus{{Name: "vault-0", Sealed: sealed}}
+		ready := debounceReadiness(config, statuses, settings)
+		config.Status.VaultStatuses = statuses
+		return ready, statuses[0]
+	}
+
+	// The first check is taken as is
+	ready, status := check(false)
+	assert.True(t, ready)
+	require.NotNil(t, status.Ready)
+
+	// A single failed check is a blip
+	ready, status = check(true)
+	assert.True(t, ready)
+	assert.Equal(t, int32(1), status.ReadinessStreak)
+	ready, _ = check(false)
+	assert.True(t, ready, "the streak restarts after an agreeing check")
+	ready, _ = check(true)
+	assert.True(t, ready)
+	ready, status = check(true)
+	assert.False(t, ready, "notReadyThreshold failed checks in a row")
+	assert.Zero(t, status.ReadinessStreak)
+
+	// Recovery needs readyThreshold unsealed checks
+	for range 2 {
+		ready, _ = check(false)
+		assert.False(t, ready)
+	}
+	ready, status = check(false)
+	assert.True(t, ready)
+	assert.True(t, *status.Ready)
+}
+
+func TestReadyConditionWithHysteresis(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{}
+	config := &vaultv1.VaultUnsealConfig{}
+
+	r.updateVaultConfigStatus(config, []vaultv1.VaultInstanceStatus{{Name: "vault-0"}}, false)
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonStabilizing, condition.Reason)
+
+	r.updateVaultConfigStatus(config, []vaultv1.VaultInstanceStatus{{Name: "vault-0", Sealed: true}}, true)
+	condition = meta.FindStatusCondition(config.Status.Conditions, ConditionTypeReady)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "1 of 1 vault instances are sealed")
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+const (
+	// DefaultKeyExecTimeout bounds a run of an exec key source command without a timeout
+	DefaultKeyExecTimeout = 30 * time.Second
+	// KeyExecInfoEnv names the environment variable describing the instance
+	// whose keys an exec key source command is asked for, as JSON
+	KeyExecInfoEnv = "VAULT_AUTOUNSEAL_EXEC_INFO"
+	// keyExecWaitDelay bounds waiting for the output of a command after it exits
+	keyExecWaitDelay = time.Second
+)
+
+// keyExecPassEnv lists the variables of the operator's environment an exec key
+// source command inherits; everything else must be set in env.
+var keyExecPassEnv = []string{
+	"PATH", "HOME", "TMPDIR", "TZ", "LANG", "SSL_CERT_FILE", "SSL_CERT_DIR",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// keyExecDeniedEnv lists variables env may not set, as they change which code
+// the command loads or runs rather than what it does.
+var keyExecDeniedEnv = []string{
+	"PATH", "IFS", "ENV", "BASH_ENV", "SHELLOPTS", "GCONV_PATH", "LOCPATH", "NLSPATH",
+	"HOSTALIASES", "RESOLV_HOST_CONF", "PYTHONPATH", "PYTHONHOME", "PYTHONSTARTUP",
+	"PERL5LIB", "PERL5OPT", "PERLLIB", "RUBYLIB", "RUBYOPT", "NODE_OPTIONS", "NODE_PATH",
+	"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "GODEBUG", KeyExecInfoEnv,
+}
+
+// keyExecDeniedEnvPrefixes lists prefixes of dynamic loader variables env may not set.
+var keyExecDeniedEnvPrefixes = []string{"LD_", "DYLD_", "MALLOC_", "GLIBC_"}
+
+// keyExecInfo is passed to exec key source commands in KeyExecInfoEnv.
+type keyExecInfo struct {
+	Namespace string `json:"namespace"`
+	Instance  string `json:"instance"`
+	Endpoint  string `json:"endpoint"`
+}
+
+// keyCommandError is a failure to run an exec key source command or to read
+// keys from its output.
+type keyCommandError struct {
+	command string
+	err     error
+}
+
+func (e *keyCommandError) Error() string {
+	return fmt.Sprintf("key command %s: %v", e.command, e.err)
+}
+
+func (e *keyCommandError) Unwrap() error {
+	return e.err
+}
+
+// execKeys runs the exec key source command of instance and returns the keys it
+// prints. Only commands listed in KeyCommands are run.
+func (r *VaultUnsealConfigReconciler) execKeys(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) ([]string, error) {
+	source := instance.KeySource.Exec
+	if !slices.Contains(r.KeyCommands, source.Command) {
+		return nil, &keyCommandError{
+			command: source.Command,
+			err:     errors.New("command is not allowed by --key-exec-commands"),
+		}
+	}
+
+	timeout := DefaultKeyExecTimeout
+	if source.Timeout != nil && source.Timeout.Duration > 0 {
+		timeout = source.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	info, err := json.Marshal(keyExecInfo{Namespace: namespace, Instance: instance.Name, Endpoint: instance.Endpoint})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExecEnv(source.Env); err != nil {
+		return nil, &keyCommandError{command: source.Command, err: err}
+	}
+	cmd := exec.CommandContext(ctx, source.Command, source.Args...)
+	cmd.Env = keyExecEnv(source.Env, KeyExecInfoEnv+"="+string(info))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children left holding the output pipes must not stall the reconcile
+	cmd.WaitDelay = keyExecWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		// Report the first line of stderr only, as plugins may echo more than they should
+		if line, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); line != "" {
+			err = fmt.Errorf("%w: %s", err, line)
+		}
+		return nil, &keyCommandError{command: source.Command, err: err}
+	}
+
+	keys, err := parseKeyList(stdout.Bytes())
+	if errors.Is(err, errNoKeys) {
+		err = errors.New("command printed no keys")
+	}
+	if err != nil {
+		return nil, &keyCommandError{command: source.Command, err: err}
+	}
+	return keys, nil
+}
+
+// keyExecEnv returns the environment of an exec key source command: the
+// variables of keyExecPassEnv the operator has, then env and extra.
+func keyExecEnv(env []vaultv1.ExecEnvVar, extra ...string) []string {
+	var environ []string
+	for _, name := range keyExecPassEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			environ = append(environ, name+"="+value)
+		}
+	}
+	for _, variable := range env {
+		environ = append(environ, variable.Name+"="+variable.Value)
+	}
+	return append(environ, extra...)
+}
+
+// checkExecEnv rejects env variables that are malformed or change which code
+// an exec key source command loads, like LD_PRELOAD or PATH.
+func checkExecEnv(env []vaultv1.ExecEnvVar) error {
+	for _, variable := range env {
+		name := variable.Name
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("env name %q is invalid", name)
+		}
+		upper := strings.ToUpper(name)
+		denied := slices.Contains(keyExecDeniedEnv, upper) || slices.ContainsFunc(keyExecDeniedEnvPrefixes,
+			func(prefix string) bool { return strings.HasPrefix(upper, prefix) })
+		if denied {
+			return fmt.Errorf("env %s is not allowed", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// writeKeyCommand writes an executable shell script and returns its path.
+func writeKeyCommand(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "vault-keys")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700))
+	return path
+}
+
+func TestExecKeySource(t *testing.T) {
+	// The plugin looks the keys up by the instance it is asked about
+	command := writeKeyCommand(t, `
+case "$VAULT_AUTOUNSEAL_EXEC_INFO" in
+  *'"instance":"vault-0"'*) printf '{"keys": ["k1", "k2"]}' ;;
+  *) printf 'k3\n\n%s\n' "$1-$REGION" ;;
+esac
+`)
+	r := NewVaultUnsealConfigReconciler(nil, log.Log, nil, nil, nil)
+	r.KeyCommands = []string{command}
+
+	instance := &vaultv1.VaultInstance{
+		Name:      "vault-0",
+		Endpoint:  "http://vault-0:8200",
+		KeySource: &vaultv1.KeySource{Exec: &vaultv1.ExecKeySource{Command: command}},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k2"}, keys)
+
+	instance.Name = "vault-1"
+	instance.KeySource.Exec.Args = []string{"k4"}
+	instance.KeySource.Exec.Env = []vaultv1.ExecEnvVar{{Name: "REGION", Value: "eu"}}
+	keys, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k3", "k4-eu"}, keys)
+
+	// Commands must be allowed by the operator
+	r.KeyCommands = nil
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+	assert.Equal(t, ReasonKeyCommandFailed, classifyError(err))
+}
+
+func TestExecKeySourceFailures(t *testing.T) {
+	failing := writeKeyCommand(t, "echo 'token expired' >&2\necho 'more detail' >&2\nexit 3\n")
+	silent := writeKeyCommand(t, "exit 0\n")
+	slow := writeKeyCommand(t, "exec sleep 10\n")
+	r := NewVaultUnsealConfigReconciler(nil, log.Log, nil, nil, nil)
+	r.KeyCommands = []string{failing, silent, slow}
+
+	run := func(source *vaultv1.ExecKeySource) error {
+		_, err := r.unsealKeys(t.Context(), "vault", &vaultv1.VaultInstance{
+			Name:      "vault-0",
+			KeySource: &vaultv1.KeySource{Exec: source},
+		})
+		require.Error(t, err)
+		assert.Equal(t, ReasonKeyCommandFailed, classifyError(err))
+		return err
+	}
+
+	err := run(&vaultv1.ExecKeySource{Command: failing})
+	assert.Contains(t, err.Error(), "exit status 3: token expired")
+	assert.NotContains(t, err.Error(), "more detail")
+
+	err = run(&vaultv1.ExecKeySource{Command: silent})
+	assert.Contains(t, err.Error(), "printed no keys")
+
+	start := time.Now()
+	err = run(&vaultv1.ExecKeySource{Command: slow, Timeout: &metav1.Duration{Duration: 100 * time.Millisecond}})
+	assert.Contains(t, err.Error(), "timed out after 100ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestExecKeySourceEnvironment(t *testing.T) {
+	t.Setenv("OPERATOR_TOKEN", "secret")
+	t.Setenv("TZ", "UTC")
+	// The plugin prints the variables it sees as its key
+	command := writeKeyCommand(t, "echo \"token=$OPERATOR_TOKEN tz=$TZ region=$REGION\"\n")
+	r := NewVaultUnsealConfigReconciler(nil, log.Log, nil, nil, nil)
+	r.KeyCommands = []string{command}
+
+	instance := &vaultv1.VaultInstance{
+		Name: "vault-0",
+		KeySource: &vaultv1.KeySource{Exec: &vaultv1.ExecKeySource{
+			Command: command,
+			Env:     []vaultv1.ExecEnvVar{{Name: "REGION", Value: "eu"}},
+		}},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token= tz=UTC region=eu"}, keys, "only allowed operator variables are inherited")
+
+	for _, name := range []string{"LD_PRELOAD", "ld_library_path", "DYLD_INSERT_LIBRARIES", "PATH", "PYTHONPATH",
+		"NODE_OPTIONS", KeyExecInfoEnv, "A=B", ""} {
+		instance.KeySource.Exec.Env = []vaultv1.ExecEnvVar{{Name: name, Value: "/tmp/evil"}}
+		_, err := r.unsealKeys(t.Context(), "vault", instance)
+		require.Error(t, err, name)
+		assert.Equal(t, ReasonKeyCommandFailed, classifyError(err))
+		assert.Error(t, r.checkKeySource(t.Context(), "vault", instance), name)
+	}
+}
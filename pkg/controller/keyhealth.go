@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionTypeKeysAvailable reports whether the key sources of a config
+// supplied their keys, apart from whether Vault could be reached.
+const ConditionTypeKeysAvailable = "KeysAvailable"
+
+// keySourceReason returns the KeysAvailable reason of an instance that failed
+// to read its keys, or "" for other outcomes.
+func keySourceReason(status *vaultv1.VaultInstanceStatus) string {
+	switch status.Reason {
+	case ReasonSecretMissing, ReasonSecretKeyMissing, ReasonExternalSecretFetchFailed:
+		return status.Reason
+	case ReasonKeyCommandFailed, ReasonTransitUnwrapFailed, ReasonKeyDecryptionFailed, ReasonPKCS11Failed:
+		return ReasonExternalSecretFetchFailed
+	default:
+		return ""
+	}
+}
+
+// updateKeysCondition summarizes the key source failures of the last checks.
+// Keys are only read to unseal, so an unsealed instance counts as available.
+// The condition is removed when no instance reads its keys from a key source.
+func (r *VaultUnsealConfigReconciler) updateKeysCondition(vaultConfig *vaultv1.VaultUnsealConfig) {
+	sourced := 0
+	reason := ""
+	var failures []string
+	for i := range vaultConfig.Spec.VaultInstances {
+		instance := &vaultConfig.Spec.VaultInstances[i]
+		if (instance.KeySource == nil && instance.BankVaults == nil && instance.TransitUnwrap == nil &&
+			instance.AgeDecryption == nil && instance.PKCS11Decryption == nil && instance.PGPDecryption == nil) ||
+			!instanceEnabled(instance) {
+			continue
+		}
+		sourced++
+
+		for _, status := range instanceStatuses(vaultConfig, instance) {
+			failed := keySourceReason(status)
+			if failed == "" {
+				continue
+			}
+			if reason == "" {
+				reason = failed
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", status.Name, status.Error))
+		}
+	}
+
+	if sourced == 0 {
+		meta.RemoveStatusCondition(&vaultConfig.Status.Conditions, ConditionTypeKeysAvailable)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionTypeKeysAvailable,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonKeysAvailable,
+		Message:            fmt.Sprintf("None of the %d key sources failed", sourced),
+		ObservedGeneration: vaultConfig.Generation,
+	}
+	if len(failures) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reason
+		condition.Message = strings.Join(failures, "; ")
+	}
+	meta.SetStatusCondition(&vaultConfig.Status.Conditions, condition)
+}
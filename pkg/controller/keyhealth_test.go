@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKeysCondition(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{}
+	secretSource := &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{Name: "vault-keys", Keys: []string{"key1"}}}
+	config := &vaultv1.VaultUnsealConfig{
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", KeySource: secretSource},
+			{Name: "vault-1", KeySource: secretSource},
+			{Name: "inline", UnsealKeys: []string{"k1"}},
+		}},
+		Status: vaultv1.VaultUnsealConfigStatus{VaultStatuses: []vaultv1.VaultInstanceStatus{
+			{Name: "vault-0"},
+			{Name: "vault-1", Sealed: true, Reason: ReasonEndpointUnreachable, Error: "connection refused"},
+			{Name: "inline", Sealed: true, Reason: ReasonSecretMissing, Error: "tunnel secret"},
+		}},
+	}
+	r.updateKeysCondition(config)
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeKeysAvailable)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status, "an unreachable Vault is not a key source failure")
+	assert.Equal(t, ReasonKeysAvailable, condition.Reason)
+
+	config.Status.VaultStatuses[1].Reason = ReasonSecretKeyMissing
+	config.Status.VaultStatuses[1].Error = `secret vault/vault-keys has no key "key1"`
+	r.updateKeysCondition(config)
+	condition = meta.FindStatusCondition(config.Status.Conditions, ConditionTypeKeysAvailable)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonSecretKeyMissing, condition.Reason)
+	assert.Equal(t, `vault-1: secret vault/vault-keys has no key "key1"`, condition.Message)
+
+	// Exec commands count as external fetches
+	config.Status.VaultStatuses[1].Reason = ReasonKeyCommandFailed
+	r.updateKeysCondition(config)
+	condition = meta.FindStatusCondition(config.Status.Conditions, ConditionTypeKeysAvailable)
+	assert.Equal(t, ReasonExternalSecretFetchFailed, condition.Reason)
+
+	// The condition disappears once no instance uses a key source
+	config.Spec.VaultInstances = config.Spec.VaultInstances[2:]
+	r.updateKeysCondition(config)
+	assert.Nil(t, meta.FindStatusCondition(config.Status.Conditions, ConditionTypeKeysAvailable))
+}
+
+func TestReconcileSeparatesKeySourceFailures(t *testing.T) {
+	server := fakevault.New(t)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:     "vault-0",
+			Endpoint: server.URL(),
+			KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+				Name: "vault-keys", Keys: []string{"key1"},
+			}},
+		}}},
+	}
+	// Reading the Secret times out, while Vault itself is reachable
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*corev1.Secret); ok {
+					return context.DeadlineExceeded
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	assert.Equal(t, ReasonExternalSecretFetchFailed, updated.Status.VaultStatuses[0].Reason)
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeKeysAvailable)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonExternalSecretFetchFailed, condition.Reason)
+	assert.True(t, server.Sealed())
+}
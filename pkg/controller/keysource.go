@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// missingSecretKeyError is returned when a key source Secret lacks a key.
+type missingSecretKeyError struct {
+	namespace, name, key string
+}
+
+func (e *missingSecretKeyError) Error() string {
+	return fmt.Sprintf("secret %s/%s has no key %q", e.namespace, e.name, e.key)
+}
+
+// keySourceError is returned when the keys of a key source cannot be read, so
+// the failure is not mistaken for one reaching Vault.
+type keySourceError struct {
+	err error
+}
+
+func (e *keySourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *keySourceError) Unwrap() error {
+	return e.err
+}
+
+// keyDecryptionError is returned when stored keys cannot be decrypted locally,
+// e.g. because no configured identity matches.
+type keyDecryptionError struct {
+	method string
+	err    error
+}
+
+func (e *keyDecryptionError) Error() string {
+	return fmt.Sprintf("%s decryption: %v", e.method, e.err)
+}
+
+func (e *keyDecryptionError) Unwrap() error {
+	return e.err
+}
+
+// unsealKeys returns the keys used to unseal instance: the inline UnsealKeys, or
+// the keys read from its key source, restricted to its KeyIndices, decrypted
+// when they are stored encrypted, shuffled with ShuffleKeys and base64 encoded
+// whatever their KeyEncoding.
+func (r *VaultUnsealConfigReconciler) unsealKeys(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) ([]string, error) {
+	start := time.Now()
+	keys, err := r.sourceKeys(ctx, namespace, instance)
+	if provider := keySourceProvider(instance); provider != "" && r.KeySourceMetrics != nil {
+		r.KeySourceMetrics.RecordFetch(provider, err == nil, time.Since(start))
+	}
+	if err != nil {
+		return nil, &keySourceError{err: err}
+	}
+	if keys, err = selectKeys(keys, instance.KeyIndices); err != nil {
+		return nil, err
+	}
+	// Only the selected keys are decrypted
+	if keys, err = r.decryptKeys(ctx, namespace, instance, keys); err != nil {
+		return nil, &keySourceError{err: err}
+	}
+	if instance.ShuffleKeys {
+		keys = slices.Clone(keys)
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	}
+
+	normalized := make([]string, len(keys))
+	for i, key := range keys {
+		if normalized[i], err = vault.NormalizeKey(key, string(instance.KeyEncoding)); err != nil {
+			return nil, fmt.Errorf("invalid unseal key %d: %w", i, err)
+		}
+	}
+	return normalized, nil
+}
+
+// decryptKeys decrypts keys with the TransitUnwrap, AgeDecryption,
+// PKCS11Decryption or PGPDecryption of instance, or returns them unchanged when
+// they are stored in plain.
+func (r *VaultUnsealConfigReconciler) decryptKeys(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+	keys []string,
+) ([]string, error) {
+	var provider string
+	var decrypt func() ([]string, error)
+	switch {
+	case instance.TransitUnwrap != nil:
+		provider = metrics.KeySourceTransit
+		decrypt = func() ([]string, error) { return r.transitUnwrap(ctx, namespace, instance.TransitUnwrap, keys) }
+	case instance.AgeDecryption != nil:
+		provider = metrics.KeySourceAge
+		decrypt = func() ([]string, error) { return r.ageDecrypt(ctx, namespace, instance.AgeDecryption, keys) }
+	case instance.PKCS11Decryption != nil:
+		provider = metrics.KeySourcePKCS11
+		decrypt = func() ([]string, error) { return r.pkcs11Decrypt(ctx, namespace, instance.PKCS11Decryption, keys) }
+	case instance.PGPDecryption != nil:
+		provider = metrics.KeySourcePGP
+		decrypt = func() ([]string, error) { return r.pgpDecrypt(ctx, namespace, instance.PGPDecryption, keys) }
+	default:
+		return keys, nil
+	}
+
+	start := time.Now()
+	decrypted, err := decrypt()
+	if r.KeySourceMetrics != nil {
+		r.KeySourceMetrics.RecordFetch(provider, err == nil, time.Since(start))
+	}
+	return decrypted, err
+}
+
+// selectKeys returns the keys at indices, or every key without indices.
+func selectKeys(keys []string, indices []int) ([]string, error) {
+	if len(indices) == 0 {
+		return keys, nil
+	}
+	selected := make([]string, 0, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(keys) {
+			return nil, vault.NewValidationError("keyIndices", i,
+				fmt.Sprintf("key index %d is out of range of the %d available keys", i, len(keys)))
+		}
+		selected = append(selected, keys[i])
+	}
+	return selected, nil
+}
+
+// keySourceProvider names the key source of instance in metrics, or returns
+// "" for keys inline in the spec. The Secrets of instances referencing a
+// bank-vaults Vault, whether located by applyBankVaults or not, are
+// reported as bankvaults.
+func keySourceProvider(instance *vaultv1.VaultInstance) string {
+	switch {
+	case instance.KeySource == nil && instance.BankVaults != nil && len(instance.UnsealKeys) == 0:
+		return metrics.KeySourceBankVaults
+	case instance.KeySource == nil:
+		return ""
+	case instance.KeySource.Exec != nil:
+		return metrics.KeySourceExec
+	case instance.KeySource.PKCS11 != nil:
+		return metrics.KeySourcePKCS11
+	case instance.KeySource.Secret != nil && instance.BankVaults != nil:
+		return metrics.KeySourceBankVaults
+	case instance.KeySource.Secret != nil:
+		return metrics.KeySourceSecret
+	}
+	return ""
+}
+
+// sourceKeys returns the keys of instance as stored in its spec or key source.
+func (r *VaultUnsealConfigReconciler) sourceKeys(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) ([]string, error) {
+	if instance.KeySource != nil && instance.KeySource.Exec != nil {
+		return r.execKeys(ctx, namespace, instance)
+	}
+	if instance.KeySource != nil && instance.KeySource.PKCS11 != nil {
+		return r.pkcs11Keys(ctx, namespace, instance.KeySource.PKCS11)
+	}
+	if instance.KeySource == nil || instance.KeySource.Secret == nil {
+		return instance.UnsealKeys, nil
+	}
+	if r.Client == nil {
+		return nil, fmt.Errorf("secret key source requires a Kubernetes client")
+	}
+
+	source := instance.KeySource.Secret
+	// Secrets are excluded from the manager cache, so this is a direct GET
+	data, err := readSecretData(ctx, r.Client, namespace, source.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.CombinedKey != "" {
+		value := data[source.CombinedKey]
+		if len(bytes.TrimSpace(value)) == 0 {
+			return nil, &missingSecretKeyError{namespace: namespace, name: source.Name, key: source.CombinedKey}
+		}
+		keys, err := parseKeyList(value)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s/%s key %q: %w", namespace, source.Name, source.CombinedKey,
+				vault.NewValidationError("keys", nil, err.Error()))
+		}
+		return keys, nil
+	}
+
+	keys := make([]string, 0, len(source.Keys))
+	for _, key := range source.Keys {
+		value := strings.TrimSpace(string(data[key]))
+		if value == "" {
+			return nil, &missingSecretKeyError{namespace: namespace, name: source.Name, key: key}
+		}
+		keys = append(keys, value)
+	}
+	return keys, nil
+}
+
+// errNoKeys is returned by parseKeyList for input without any key.
+var errNoKeys = errors.New("no keys found")
+
+// parseKeyList reads the keys of a combined key source: one key per non-empty
+// line, a JSON array, a JSON object with a keys list, or the JSON output of
+// vault operator init, whose base64 keys are preferred over its hex keys.
+func parseKeyList(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	var keys []string
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+	case bytes.HasPrefix(data, []byte("{")):
+		var parsed struct {
+			Keys          []string `json:"keys"`
+			UnsealKeysB64 []string `json:"unseal_keys_b64"`
+			UnsealKeysHex []string `json:"unseal_keys_hex"`
+		}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid JSON output: %w", err)
+		}
+		switch {
+		case len(parsed.Keys) > 0:
+			keys = parsed.Keys
+		case len(parsed.UnsealKeysB64) > 0:
+			keys = parsed.UnsealKeysB64
+		default:
+			keys = parsed.UnsealKeysHex
+		}
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if key := strings.TrimSpace(scanner.Text()); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read keys: %w", err)
+		}
+		if len(keys) == 0 {
+			return nil, errNoKeys
+		}
+		return keys, nil
+	}
+
+	for i, key := range keys {
+		keys[i] = strings.TrimSpace(key)
+		if keys[i] == "" {
+			return nil, fmt.Errorf("key %d is empty", i)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+	return keys, nil
+}
+
+// findVaultConfigsForSecret maps a Secret change to the configs reading keys from it.
+// Only Secret metadata is watched; the keys are fetched when the config reconciles.
+func (r *VaultUnsealConfigReconciler) findVaultConfigsForSecret(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list VaultUnsealConfigs", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, config := range configs.Items {
+		for _, instance := range config.Spec.VaultInstances {
+			if instance.KeySource != nil && instance.KeySource.Secret != nil &&
+				instance.KeySource.Secret.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&config)})
+				break
+			}
+		}
+	}
+	return requests
+}
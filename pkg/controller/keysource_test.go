@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUnsealWithSecretKeySource(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-keys", Namespace: "vault"},
+		Data:       map[string][]byte{"key1": []byte("k1\n"), "key2": []byte("k2")},
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:     "vault-0",
+			Endpoint: "http://vault-0:8200",
+			KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+				Name: "vault-keys", Keys: []string{"key1", "key2"},
+			}},
+		}}},
+	}
+	other := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "inline", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-1", Endpoint: "http://vault-1:8200", UnsealKeys: []string{"k1"}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(secret, vaultConfig, other).
+		Build()
+
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockClient := &mocks.MockVaultClient{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(mockClient, nil)
+	mockClient.On("IsSealed", mock.Anything).Return(true, nil)
+	mockClient.On("GetSealStatus", mock.Anything).Return(mocks.NewMockSealStatusResponse(true, 0, 2), nil)
+	mockClient.On("Unseal", mock.Anything, []string{"k1", "k2"}, 2).Return(
+		mocks.NewMockSealStatusResponse(false, 2, 2), nil)
+
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+
+	// Only configs reading from the changed Secret are reconciled
+	requests := r.findVaultConfigsForSecret(context.Background(), secret)
+	assert.Equal(t, []ctrl.Request{req}, requests)
+}
+
+func TestSecretKeySourceMissingKey(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-keys", Namespace: "vault"},
+		Data:       map[string][]byte{"key1": []byte("k1")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(secret).Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	instance := &vaultv1.VaultInstance{KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+		Name: "vault-keys", Keys: []string{"key1", "key2"},
+	}}}
+	_, err := r.unsealKeys(context.Background(), "vault", instance)
+	assert.EqualError(t, err, `secret vault/vault-keys has no key "key2"`)
+
+	// Inline keys are used as they are
+	keys, err := r.unsealKeys(context.Background(), "vault", &vaultv1.VaultInstance{UnsealKeys: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+}
+
+func TestKeySourceFetchMetrics(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-keys", Namespace: "vault"},
+		Data:       map[string][]byte{"key1": []byte("k1")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(secret).Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+	r.KeySourceMetrics = metrics.NewKeySourceMetrics(prometheus.NewRegistry())
+
+	source := func(keys ...string) *vaultv1.VaultInstance {
+		return &vaultv1.VaultInstance{KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+			Name: "vault-keys", Keys: keys,
+		}}}
+	}
+	_, err := r.unsealKeys(context.Background(), "vault", source("key1"))
+	require.NoError(t, err)
+	_, err = r.unsealKeys(context.Background(), "vault", source("key2"))
+	require.Error(t, err)
+	_, err = r.unsealKeys(context.Background(), "vault", &vaultv1.VaultInstance{UnsealKeys: []string{"a"}})
+	require.NoError(t, err)
+	// The Secret located from a bank-vaults Vault is recorded apart
+	bankVaults := source("key1")
+	bankVaults.BankVaults = &vaultv1.BankVaultsReference{Name: "vault"}
+	_, err = r.unsealKeys(context.Background(), "vault", bankVaults)
+	require.NoError(t, err)
+
+	// Inline keys are not fetched from anywhere
+	assert.Equal(t, 3, testutil.CollectAndCount(r.KeySourceMetrics.Duration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.KeySourceMetrics.Failures.WithLabelValues(metrics.KeySourceSecret)))
+	assert.Equal(t, "bankvaults", keySourceProvider(&vaultv1.VaultInstance{
+		BankVaults: &vaultv1.BankVaultsReference{Name: "vault"},
+	}))
+}
+
+func TestUnsealWithCombinedSecretKey(t *testing.T) {
+	server := fakevault.New(t)
+	initOutput, err := json.Marshal(map[string]any{
+		"unseal_keys_b64":  server.Keys(),
+		"unseal_shares":    fakevault.DefaultShares,
+		"unseal_threshold": fakevault.DefaultThreshold,
+		"root_token":       server.RootToken(),
+	})
+	require.NoError(t, err)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-init", Namespace: "vault"},
+		Data: map[string][]byte{
+			"init.json": initOutput,
+			"keys.txt":  []byte(strings.Join(server.Keys(), "\n") + "\n"),
+		},
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:     "vault-0",
+			Endpoint: server.URL(),
+			KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+				Name: "vault-init", CombinedKey: "init.json",
+			}},
+		}}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(secret, vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.False(t, server.Sealed())
+
+	combined := func(key string) ([]string, error) {
+		instance := &vaultv1.VaultInstance{KeySource: &vaultv1.KeySource{Secret: &vaultv1.SecretKeySource{
+			Name: "vault-init", CombinedKey: key,
+		}}}
+		return r.unsealKeys(t.Context(), "vault", instance)
+	}
+	keys, err := combined("keys.txt")
+	require.NoError(t, err)
+	assert.Equal(t, server.Keys(), keys)
+
+	_, err = combined("missing")
+	assert.Equal(t, ReasonSecretKeyMissing, classifyError(err))
+
+	require.NoError(t, k8sClient.Update(t.Context(), &corev1.Secret{
+		ObjectMeta: secret.ObjectMeta,
+		Data:       map[string][]byte{"init.json": []byte(`{"unseal_keys_b64": [`)},
+	}))
+	_, err = combined("init.json")
+	assert.Equal(t, ReasonInvalidKeys, classifyError(err))
+	assert.Contains(t, err.Error(), `secret vault/vault-init key "init.json"`)
+}
+
+func TestUnsealWithKeyIndices(t *testing.T) {
+	server := fakevault.New(t)
+	outOfRange := fakevault.New(t)
+
+	// Keys kept offline are placeholders that Vault would reject
+	keys := server.Keys()
+	keys[1], keys[3] = "offline", "offline"
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: server.URL(), UnsealKeys: keys, KeyIndices: []int{4, 0, 2}},
+			{Name: "vault-1", Endpoint: outOfRange.URL(), UnsealKeys: outOfRange.Keys(), KeyIndices: []int{0, 5}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	statuses := updated.Status.VaultStatuses
+	require.Len(t, statuses, 2)
+
+	assert.False(t, server.Sealed())
+	assert.Empty(t, statuses[0].Error)
+	assert.Equal(t, 3, server.Requests("sys/unseal"))
+
+	assert.True(t, outOfRange.Sealed())
+	assert.Zero(t, outOfRange.Requests("sys/unseal"))
+	assert.Equal(t, ReasonInvalidKeys, statuses[1].Reason)
+	assert.Contains(t, statuses[1].Error, "key index 5 is out of range of the 5 available keys")
+}
+
+func TestUnsealKeysShuffle(t *testing.T) {
+	r := NewVaultUnsealConfigReconciler(nil, log.Log, nil, nil, nil)
+	stored := []string{"k0", "k1", "k2", "k3", "k4"}
+	instance := &vaultv1.VaultInstance{UnsealKeys: slices.Clone(stored), KeyIndices: []int{0, 1, 2, 3}, ShuffleKeys: true}
+
+	firstKeys := make(map[string]bool)
+	for range 100 {
+		keys, err := r.unsealKeys(t.Context(), "vault", instance)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, stored[:4], keys, "only the selected keys are shuffled")
+		firstKeys[keys[0]] = true
+	}
+	assert.Len(t, firstKeys, 4, "every selected key is submitted first at times")
+	assert.Equal(t, stored, instance.UnsealKeys, "the spec is left untouched")
+}
+
+func TestParseKeyList(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		keys    []string
+		wantErr bool
+	}{
+		{name: "json", output: `{"keys":["a","b"]}`, keys: []string{"a", "b"}},
+		{name: "json with padding", output: "\n {\"keys\":[\" a \"]}\n", keys: []string{"a"}},
+		{name: "lines", output: "a\r\n\nb\n", keys: []string{"a", "b"}},
+		{name: "json array", output: `[" a ", "b"]`, keys: []string{"a", "b"}},
+		{
+			name:   "vault operator init",
+			output: `{"unseal_keys_b64":["YQ==","Yg=="],"unseal_keys_hex":["61","62"],"unseal_threshold":2}`,
+			keys:   []string{"YQ==", "Yg=="},
+		},
+		{name: "vault operator init hex", output: `{"unseal_keys_hex":["61","62"]}`, keys: []string{"61", "62"}},
+		{name: "empty json array", output: `[]`, wantErr: true},
+		{name: "invalid json array", output: `["a",`, wantErr: true},
+		{name: "invalid json", output: `{"keys":`, wantErr: true},
+		{name: "empty json key", output: `{"keys":["a",""]}`, wantErr: true},
+		{name: "no keys", output: `{"keys":[]}`, wantErr: true},
+		{name: "empty", output: "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseKeyList([]byte(tt.output))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types of the kstatus conventions that Flux health checks read
+// alongside Ready. Both are abnormal-true: they are only present while true.
+const (
+	// ConditionTypeReconciling reports that instances of a config are still being unsealed
+	ConditionTypeReconciling = "Reconciling"
+	// ConditionTypeStalled reports that instances of a config failed with an error
+	ConditionTypeStalled = "Stalled"
+)
+
+// updateProgressConditions derives the Reconciling and Stalled conditions from
+// the phase: a Pending or Unsealing config is reconciling, a Degraded or Error
+// config is stalled. They carry the reason and message of the Ready condition.
+func updateProgressConditions(vaultConfig *vaultv1.VaultUnsealConfig) {
+	reconciling, stalled := false, false
+	switch vaultConfig.Status.Phase {
+	case vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseUnsealing:
+		reconciling = true
+	case vaultv1.UnsealConfigPhaseDegraded, vaultv1.UnsealConfigPhaseError:
+		stalled = true
+	}
+
+	reason, message := string(vaultConfig.Status.Phase), ""
+	if ready := meta.FindStatusCondition(vaultConfig.Status.Conditions, ConditionTypeReady); ready != nil {
+		reason, message = ready.Reason, ready.Message
+	}
+	setAbnormalCondition(vaultConfig, ConditionTypeReconciling, reconciling, reason, message)
+	setAbnormalCondition(vaultConfig, ConditionTypeStalled, stalled, reason, message)
+}
+
+// setAbnormalCondition sets the condition of type conditionType while active
+// and removes it otherwise.
+func setAbnormalCondition(vaultConfig *vaultv1.VaultUnsealConfig, conditionType string, active bool, reason, message string) {
+	if !active {
+		meta.RemoveStatusCondition(&vaultConfig.Status.Conditions, conditionType)
+		return
+	}
+	meta.SetStatusCondition(&vaultConfig.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: vaultConfig.Generation,
+	})
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateProgressConditions(t *testing.T) {
+	tests := []struct {
+		phase       vaultv1.UnsealConfigPhase
+		reconciling bool
+		stalled     bool
+	}{
+		{phase: vaultv1.UnsealConfigPhasePending, reconciling: true},
+		{phase: vaultv1.UnsealConfigPhaseUnsealing, reconciling: true},
+		{phase: vaultv1.UnsealConfigPhaseReady},
+		{phase: vaultv1.UnsealConfigPhaseDegraded, stalled: true},
+		{phase: vaultv1.UnsealConfigPhaseError, stalled: true},
+		{phase: vaultv1.UnsealConfigPhaseSuspended},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			vaultConfig := &vaultv1.VaultUnsealConfig{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: vaultv1.VaultUnsealConfigStatus{
+					Phase: tt.phase,
+					Conditions: []metav1.Condition{
+						{
+							Type: ConditionTypeReady, Status: metav1.ConditionFalse,
+							Reason: ReasonEndpointUnreachable, Message: "1 of 2 vault instances are sealed",
+						},
+						// Left over from an earlier phase
+						{Type: ConditionTypeReconciling, Status: metav1.ConditionTrue, Reason: ReasonSomeInstancesSealed},
+						{Type: ConditionTypeStalled, Status: metav1.ConditionTrue, Reason: ReasonEndpointUnreachable},
+					},
+				},
+			}
+			updateProgressConditions(vaultConfig)
+
+			for conditionType, active := range map[string]bool{
+				ConditionTypeReconciling: tt.reconciling,
+				ConditionTypeStalled:     tt.stalled,
+			} {
+				condition := meta.FindStatusCondition(vaultConfig.Status.Conditions, conditionType)
+				if !active {
+					assert.Nil(t, condition, "abnormal-true conditions are removed once false")
+					continue
+				}
+				require.NotNil(t, condition)
+				assert.Equal(t, metav1.ConditionTrue, condition.Status)
+				assert.Equal(t, ReasonEndpointUnreachable, condition.Reason)
+				assert.Equal(t, "1 of 2 vault instances are sealed", condition.Message)
+				assert.Equal(t, int64(2), condition.ObservedGeneration)
+			}
+		})
+	}
+}
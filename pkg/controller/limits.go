@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+)
+
+// unsealLimiter bounds the unseal submissions in flight across all configs. A
+// nil limiter does not limit.
+type unsealLimiter struct {
+	slots chan struct{}
+}
+
+// newUnsealLimiter returns a limiter admitting limit concurrent unseals, or nil
+// when limit is not positive.
+func newUnsealLimiter(limit int) *unsealLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &unsealLimiter{slots: make(chan struct{}, limit)}
+}
+
+// acquire waits for a free slot and returns the function releasing it.
+func (l *unsealLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for an unseal slot: %w", ctx.Err())
+	}
+}
+
+type unsealBudgetKey struct{}
+
+// unsealBudget counts the unseals one reconciliation of a config may still submit.
+type unsealBudget struct {
+	remaining int
+}
+
+// withUnsealBudget limits the unseals submitted with ctx to limit; a limit that is
+// not positive does not limit.
+func withUnsealBudget(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, unsealBudgetKey{}, &unsealBudget{remaining: limit})
+}
+
+// takeUnsealSlot reports whether an unseal may be submitted with ctx and
+// consumes its slot. Instances of a config are processed one at a time, so the
+// budget needs no locking.
+func takeUnsealSlot(ctx context.Context) bool {
+	budget, ok := ctx.Value(unsealBudgetKey{}).(*unsealBudget)
+	if !ok {
+		return true
+	}
+	if budget.remaining == 0 {
+		return false
+	}
+	budget.remaining--
+	return true
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUnsealLimiter(t *testing.T) {
+	var unlimited *unsealLimiter
+	release, err := unlimited.acquire(t.Context())
+	require.NoError(t, err)
+	release()
+	assert.Nil(t, newUnsealLimiter(0))
+
+	limiter := newUnsealLimiter(1)
+	release, err = limiter.acquire(t.Context())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for an unseal slot")
+
+	release()
+	release, err = limiter.acquire(t.Context())
+	require.NoError(t, err, "a released slot is free again")
+	release()
+}
+
+func TestUnsealBudget(t *testing.T) {
+	assert.True(t, takeUnsealSlot(t.Context()), "contexts without a budget are not limited")
+	assert.True(t, takeUnsealSlot(withUnsealBudget(t.Context(), 0)))
+
+	ctx := withUnsealBudget(t.Context(), 2)
+	assert.True(t, takeUnsealSlot(ctx))
+	assert.True(t, takeUnsealSlot(ctx))
+	assert.False(t, takeUnsealSlot(ctx))
+}
+
+func TestVaultUnsealConfigReconciler_UnsealsInWaves(t *testing.T) {
+	var instances []vaultv1.VaultInstance
+	var vaults []*fakevault.Server
+	for _, name := range []string{"vault-0", "vault-1", "vault-2"} {
+		server := fakevault.New(t)
+		vaults = append(vaults, server)
+		instances = append(instances, vaultv1.VaultInstance{
+			Name: name, Endpoint: server.URL(),
+			UnsealKeys: server.Keys(), Threshold: testutil.IntPtr(fakevault.DefaultThreshold),
+		})
+	}
+	limit := int32(2)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			Settings:       &vaultv1.UnsealSettings{MaxConcurrentUnseals: &limit},
+			VaultInstances: instances,
+		},
+	}
+	tc := testutil.NewTestContext(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(tc.Scheme).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	reconciler := NewVaultUnsealConfigReconciler(k8sClient, log.Log, tc.Scheme, repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+
+	_, err := reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	assert.False(t, vaults[0].Sealed())
+	assert.False(t, vaults[1].Sealed())
+	assert.True(t, vaults[2].Sealed())
+	assert.Zero(t, vaults[2].Requests("sys/unseal"))
+	assert.Equal(t, ReasonUnsealDeferred, updated.Status.VaultStatuses[2].Reason)
+	assert.Empty(t, updated.Status.VaultStatuses[2].Error, "a deferred unseal is not a failure")
+
+	_, err = reconciler.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.False(t, vaults[2].Sealed(), "the next wave unseals the rest")
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// DefaultLogSampleFirst is the number of occurrences of an error logged in full
+	DefaultLogSampleFirst = 3
+
+	// DefaultLogSampleEvery logs one in this many occurrences after the first ones
+	DefaultLogSampleEvery = 10
+
+	// DefaultLogSummaryInterval is the longest a repeated error goes unlogged
+	DefaultLogSummaryInterval = 5 * time.Minute
+
+	// sampledErrorExpiry forgets errors not seen for this long, so the next
+	// outage is logged in full again. It exceeds the longest retry backoff.
+	sampledErrorExpiry = 30 * time.Minute
+)
+
+// failureLogSampler thins out an error logged again and again for the same
+// instance during a long outage. The first occurrences are logged, then one in
+// every, and at least one per summary interval; logged repeats carry how many
+// occurrences there were and how many were suppressed since the last one.
+type failureLogSampler struct {
+	first           int
+	every           int
+	summaryInterval time.Duration
+
+	mu        sync.Mutex
+	errors    map[string]*sampledError
+	lastPrune time.Time
+}
+
+// sampledError counts the occurrences of one error.
+type sampledError struct {
+	occurrences int
+	suppressed  int
+	lastSeen    time.Time
+	lastLogged  time.Time
+}
+
+// newFailureLogSampler returns a sampler, or nil when every is 0 and errors
+// are not sampled.
+func newFailureLogSampler(first, every int, summaryInterval time.Duration) *failureLogSampler {
+	if every <= 0 {
+		return nil
+	}
+	return &failureLogSampler{
+		first:           max(first, 1),
+		every:           every,
+		summaryInterval: summaryInterval,
+		errors:          make(map[string]*sampledError),
+	}
+}
+
+// sample records an occurrence of the error identified by key and reports
+// whether it is logged, with the occurrences so far and the suppressed ones
+// since the error was last logged.
+func (s *failureLogSampler) sample(key string, now time.Time) (logged bool, occurrences, suppressed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	entry := s.errors[key]
+	if entry == nil || now.Sub(entry.lastSeen) > sampledErrorExpiry {
+		entry = &sampledError{}
+		s.errors[key] = entry
+	}
+	entry.occurrences++
+	entry.lastSeen = now
+
+	logged = entry.occurrences <= s.first ||
+		(entry.occurrences-s.first)%s.every == 0 ||
+		(s.summaryInterval > 0 && now.Sub(entry.lastLogged) >= s.summaryInterval)
+	if !logged {
+		entry.suppressed++
+		return false, entry.occurrences, entry.suppressed
+	}
+	suppressed = entry.suppressed
+	entry.suppressed = 0
+	entry.lastLogged = now
+	return true, entry.occurrences, suppressed
+}
+
+// prune drops the errors not seen within sampledErrorExpiry, at most once per expiry.
+func (s *failureLogSampler) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < sampledErrorExpiry {
+		return
+	}
+	s.lastPrune = now
+	for key, entry := range s.errors {
+		if now.Sub(entry.lastSeen) > sampledErrorExpiry {
+			delete(s.errors, key)
+		}
+	}
+}
+
+// wrap returns logger with its errors sampled. Errors are identical when they
+// are logged with the same message, error text and values added after scope,
+// which names the config. A nil sampler returns logger unchanged.
+func (s *failureLogSampler) wrap(logger logr.Logger, scope string) logr.Logger {
+	if s == nil || logger.GetSink() == nil {
+		return logger
+	}
+	sink := logger.GetSink()
+	// The wrapper adds a frame between the caller and the sink
+	if depthSink, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = depthSink.WithCallDepth(1)
+	}
+	return logr.New(&samplingSink{LogSink: sink, sampler: s, scope: scope})
+}
+
+// samplingSink passes everything but sampled errors through to its LogSink.
+type samplingSink struct {
+	logr.LogSink
+	sampler *failureLogSampler
+	scope   string
+}
+
+// Init does nothing: the wrapped sink was initialized by its own logger.
+func (s *samplingSink) Init(logr.RuntimeInfo) {}
+
+func (s *samplingSink) Error(err error, msg string, keysAndValues ...any) {
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	}
+	key := fmt.Sprintf("%s|%s|%s|%v", s.scope, msg, errText, keysAndValues)
+	logged, occurrences, suppressed := s.sampler.sample(key, time.Now())
+	if !logged {
+		return
+	}
+	if occurrences > s.sampler.first {
+		keysAndValues = append(keysAndValues, "occurrences", occurrences, "suppressed", suppressed)
+	}
+	s.LogSink.Error(err, msg, keysAndValues...)
+}
+
+func (s *samplingSink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &samplingSink{
+		LogSink: s.LogSink.WithValues(keysAndValues...),
+		sampler: s.sampler,
+		scope:   fmt.Sprintf("%s%v", s.scope, keysAndValues),
+	}
+}
+
+func (s *samplingSink) WithName(name string) logr.LogSink {
+	return &samplingSink{LogSink: s.LogSink.WithName(name), sampler: s.sampler, scope: s.scope + "/" + name}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureLogSamplerSample(t *testing.T) {
+	sampler := newFailureLogSampler(2, 3, 10*time.Minute)
+	now := time.Now()
+
+	var logged []int
+	for i := 1; i <= 8; i++ {
+		if ok, occurrences, _ := sampler.sample("vault-0", now); ok {
+			logged = append(logged, occurrences)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, logged)
+
+	// A quiet summary interval logs the next occurrence with the suppressed count
+	now = now.Add(10 * time.Minute)
+	ok, occurrences, suppressed := sampler.sample("vault-0", now)
+	assert.True(t, ok)
+	assert.Equal(t, 9, occurrences)
+	assert.Zero(t, suppressed)
+	ok, _, _ = sampler.sample("vault-0", now)
+	assert.False(t, ok)
+	_, _, suppressed = sampler.sample("vault-0", now)
+	assert.Equal(t, 1, suppressed)
+
+	// Other errors and errors not seen for a while start over
+	ok, occurrences, _ = sampler.sample("vault-1", now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, occurrences)
+	_, occurrences, _ = sampler.sample("vault-0", now.Add(sampledErrorExpiry+11*time.Minute))
+	assert.Equal(t, 1, occurrences)
+
+	assert.Nil(t, newFailureLogSampler(3, 0, time.Minute))
+}
+
+func TestFailureLogSamplerWrap(t *testing.T) {
+	var lines []string
+	base := funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{})
+	sampler := newFailureLogSampler(1, 2, time.Hour)
+
+	logger := sampler.wrap(base, "vault/cluster").WithValues("instance", "vault-0")
+	other := sampler.wrap(base, "vault/cluster").WithValues("instance", "vault-1")
+	err := errors.New("connection refused")
+	for range 3 {
+		logger.Error(err, "failed to process vault instance")
+	}
+	other.Error(err, "failed to process vault instance")
+	logger.Info("passed through")
+
+	require.Len(t, lines, 4)
+	assert.NotContains(t, lines[0], "occurrences")
+	assert.Contains(t, lines[1], `"occurrences"=3`)
+	assert.Contains(t, lines[1], `"suppressed"=1`)
+	assert.Contains(t, lines[2], `"vault-1"`)
+	assert.Contains(t, lines[3], "passed through")
+
+	var nilSampler *failureLogSampler
+	assert.Equal(t, logr.Discard(), nilSampler.wrap(logr.Discard(), "vault/cluster"))
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maintenanceSignal returns why instance is under planned maintenance, or ""
+// when it is not. An instance is under maintenance while the config's
+// maintenance annotation lists it, or while the pod it runs in carries the
+// annotation or is the target of a disruption, e.g. an eviction admitted by its
+// PodDisruptionBudget. Signals are re-read on every check, so unsealing resumes
+// as soon as they clear.
+func (r *VaultUnsealConfigReconciler) maintenanceSignal(
+	ctx context.Context,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+) string {
+	if configMaintenance(vaultConfig, instance.Name) {
+		return "config annotation " + vaultv1.MaintenanceAnnotation
+	}
+
+	pod := r.instancePod(ctx, vaultConfig.Namespace, instance)
+	if pod == nil {
+		return ""
+	}
+	if value, ok := pod.Annotations[vaultv1.MaintenanceAnnotation]; ok && value != "false" {
+		return "annotation " + vaultv1.MaintenanceAnnotation + " on pod " + pod.Name
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue {
+			return "pod " + pod.Name + " is a disruption target: " + condition.Reason
+		}
+	}
+	return ""
+}
+
+// configMaintenance reports whether the maintenance annotation of vaultConfig
+// lists the instance with the given name. Listing an instance with discovery
+// covers each of its nodes.
+func configMaintenance(vaultConfig *vaultv1.VaultUnsealConfig, name string) bool {
+	value, ok := vaultConfig.Annotations[vaultv1.MaintenanceAnnotation]
+	if !ok {
+		return false
+	}
+	for _, listed := range strings.Split(value, ",") {
+		listed = strings.TrimSpace(listed)
+		if listed == "*" || listed == name {
+			return true
+		}
+		if instance := findSpecInstance(vaultConfig, listed); instance != nil &&
+			instance.Discovery != nil && strings.HasPrefix(name, listed+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// instancePod returns the pod instance runs in, if it is known: the pod of
+// exec or port-forward access, or the pod its endpoint addresses by its stable
+// DNS name. It returns nil when there is no such pod or it cannot be read, as
+// always without a Kubernetes client in one-shot runs.
+func (r *VaultUnsealConfigReconciler) instancePod(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) *corev1.Pod {
+	if r.Client == nil {
+		return nil
+	}
+	var key types.NamespacedName
+	switch {
+	case instance.Access != nil && instance.Access.Pod != "":
+		key = types.NamespacedName{Namespace: namespace, Name: instance.Access.Pod}
+		if instance.Namespace != "" {
+			key.Namespace = instance.Namespace
+		}
+	default:
+		value, found := strings.CutPrefix(endpointPodIndexValue(instance.Endpoint), "pod:")
+		if !found {
+			return nil
+		}
+		key.Namespace, key.Name, _ = strings.Cut(value, "/")
+	}
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, key, &pod); err != nil {
+		return nil
+	}
+	return &pod
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestConfigMaintenance(t *testing.T) {
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault", Discovery: &vaultv1.Discovery{StatefulSet: "vault"}},
+			{Name: "transit"},
+			{Name: "transit-dr"},
+		}},
+	}
+	assert.False(t, configMaintenance(vaultConfig, "transit"), "no annotation")
+
+	vaultConfig.Annotations = map[string]string{vaultv1.MaintenanceAnnotation: "vault, transit"}
+	assert.True(t, configMaintenance(vaultConfig, "transit"))
+	assert.True(t, configMaintenance(vaultConfig, "vault-2"), "listing an instance covers its discovered nodes")
+	assert.False(t, configMaintenance(vaultConfig, "transit-dr"), "only instances with discovery have nodes")
+
+	vaultConfig.Annotations[vaultv1.MaintenanceAnnotation] = "*"
+	assert.True(t, configMaintenance(vaultConfig, "transit-dr"))
+}
+
+func TestMaintenanceSignalFromPod(t *testing.T) {
+	annotated := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "vault-0", Namespace: "vault",
+		Annotations: map[string]string{vaultv1.MaintenanceAnnotation: "true"},
+	}}
+	evicted := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-1", Namespace: "vault"},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+			Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "EvictionByEvictionAPI",
+		}}},
+	}
+	cleared := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "vault-2", Namespace: "vault",
+		Annotations: map[string]string{vaultv1.MaintenanceAnnotation: "false"},
+	}}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(annotated, evicted, cleared).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+	vaultConfig := &vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"}}
+
+	tests := []struct {
+		name     string
+		instance vaultv1.VaultInstance
+		want     string
+	}{
+		{
+			name:     "annotated pod addressed by DNS name",
+			instance: vaultv1.VaultInstance{Name: "vault-0", Endpoint: "https://vault-0.vault-internal.vault.svc:8200"},
+			want:     "annotation vault.io/maintenance on pod vault-0",
+		},
+		{
+			name: "evicted pod reached by exec",
+			instance: vaultv1.VaultInstance{
+				Name: "vault-1", Endpoint: "http://127.0.0.1:8200",
+				Access: &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-1"},
+			},
+			want: "pod vault-1 is a disruption target: EvictionByEvictionAPI",
+		},
+		{
+			name:     "annotation set to false",
+			instance: vaultv1.VaultInstance{Name: "vault-2", Endpoint: "https://vault-2.vault-internal.vault.svc:8200"},
+		},
+		{
+			name:     "missing pod",
+			instance: vaultv1.VaultInstance{Name: "vault-3", Endpoint: "https://vault-3.vault-internal.vault.svc:8200"},
+		},
+		{
+			name:     "endpoint addressing a service",
+			instance: vaultv1.VaultInstance{Name: "vault", Endpoint: "https://vault.vault.svc:8200"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.maintenanceSignal(context.Background(), vaultConfig, &tt.instance))
+		})
+	}
+}
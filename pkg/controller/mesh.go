@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Service meshes whose sidecar the operator can wait for.
+const (
+	ServiceMeshIstio   = "istio"
+	ServiceMeshLinkerd = "linkerd"
+)
+
+// sidecarPollInterval is the time between readiness checks of a mesh sidecar.
+const sidecarPollInterval = time.Second
+
+// SidecarReadyURL returns the local readiness endpoint of the sidecar proxy of
+// mesh, or an empty URL when no mesh is configured.
+func SidecarReadyURL(mesh string) (string, error) {
+	switch mesh {
+	case "", "none":
+		return "", nil
+	case ServiceMeshIstio:
+		return "http://localhost:15021/healthz/ready", nil
+	case ServiceMeshLinkerd:
+		return "http://localhost:4191/ready", nil
+	default:
+		return "", fmt.Errorf("unknown service mesh %q, expected %s or %s", mesh, ServiceMeshIstio, ServiceMeshLinkerd)
+	}
+}
+
+// WaitForSidecar blocks until the sidecar readiness endpoint at url answers
+// with a success status. Until then a meshed pod has no working network, so
+// requests to Vault and the API server would fail.
+func WaitForSidecar(ctx context.Context, logger logr.Logger, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: sidecarPollInterval}
+	ticker := time.NewTicker(sidecarPollInterval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		err := sidecarReady(ctx, client, url)
+		if err == nil {
+			logger.Info("service mesh sidecar is ready", "url", url, "attempts", attempt)
+			return nil
+		}
+		logger.V(1).Info("waiting for service mesh sidecar", "url", url, "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("service mesh sidecar at %s not ready after %s: %w", url, timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// sidecarReady checks the sidecar readiness endpoint once.
+func sidecarReady(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("sidecar returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestSidecarReadyURL(t *testing.T) {
+	url, err := SidecarReadyURL(ServiceMeshIstio)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:15021/healthz/ready", url)
+
+	url, err = SidecarReadyURL(ServiceMeshLinkerd)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4191/ready", url)
+
+	url, err = SidecarReadyURL("")
+	require.NoError(t, err)
+	assert.Empty(t, url)
+
+	_, err = SidecarReadyURL("consul-connect")
+	assert.Error(t, err)
+}
+
+func TestWaitForSidecar(t *testing.T) {
+	var checks atomic.Int32
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// The proxy reports unready until it has received its configuration
+		if checks.Add(1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer sidecar.Close()
+
+	require.NoError(t, WaitForSidecar(t.Context(), log.Log, sidecar.URL, 5*time.Second))
+	assert.Equal(t, int32(2), checks.Load())
+
+	// A sidecar that never becomes ready fails the wait
+	sidecar.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	err := WaitForSidecar(t.Context(), log.Log, sidecar.URL, 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
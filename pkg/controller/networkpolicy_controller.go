@@ -0,0 +1,436 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/backup"
+	"github.com/panteparak/vault-autounseal-operator/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultNetworkPolicyName is the name of the generated operator egress policy.
+	DefaultNetworkPolicyName = "vault-autounseal-operator-egress"
+
+	// namespaceNameLabel is set by Kubernetes on every namespace.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+
+	// networkPolicyResyncPeriod bounds how long destinations that change without
+	// a spec change, such as discovered nodes and sink connection Secrets, take
+	// to be allowed.
+	networkPolicyResyncPeriod = 5 * time.Minute
+)
+
+// DefaultAPIServerPorts are the ports the operator may use to reach the Kubernetes API server.
+var DefaultAPIServerPorts = []int32{443, 6443}
+
+// NetworkPolicyOptions configures the generated operator egress policy.
+type NetworkPolicyOptions struct {
+	// Namespace is the namespace the operator pods run in
+	Namespace string
+	// Name is the name of the generated NetworkPolicy
+	Name string
+	// PodSelector selects the operator pods
+	PodSelector map[string]string
+	// APIServerPorts are allowed to any destination so the operator keeps reaching the API server
+	APIServerPorts []int32
+	// Endpoints are further destinations of the operator itself, e.g. the trace collector
+	Endpoints []string
+}
+
+// NetworkPolicyReconciler keeps a NetworkPolicy that only allows the operator to
+// reach DNS, the API server and the destinations of all vault.io resources.
+type NetworkPolicyReconciler struct {
+	client.Client
+	Log     logr.Logger
+	Scheme  *runtime.Scheme
+	Options NetworkPolicyOptions
+	// discovery lists the nodes of instances with discovery like the unseal
+	// reconciler does
+	discovery *VaultUnsealConfigReconciler
+}
+
+// NewNetworkPolicyReconciler creates a new network policy reconciler with dependencies.
+func NewNetworkPolicyReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+	options NetworkPolicyOptions,
+) *NetworkPolicyReconciler {
+	if options.Name == "" {
+		options.Name = DefaultNetworkPolicyName
+	}
+	if options.APIServerPorts == nil {
+		options.APIServerPorts = DefaultAPIServerPorts
+	}
+
+	return &NetworkPolicyReconciler{
+		Client:    client,
+		Log:       logger,
+		Scheme:    scheme,
+		Options:   options,
+		discovery: &VaultUnsealConfigReconciler{Client: client, Log: logger},
+	}
+}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch
+
+func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "NetworkPolicy")
+
+	if req.NamespacedName != r.policyKey() {
+		return ctrl.Result{}, nil
+	}
+
+	endpoints, err := r.egressEndpoints(ctx, logger)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	egress := r.baseEgressRules()
+	seen := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		rule, key, err := egressRule(endpoint)
+		if err != nil {
+			logger.Error(err, "skipping destination in network policy", "endpoint", endpoint)
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			egress = append(egress, rule)
+		}
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: r.Options.Name, Namespace: r.Options.Namespace},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, policy, func() error {
+		if policy.Labels == nil {
+			policy.Labels = map[string]string{}
+		}
+		policy.Labels["app.kubernetes.io/managed-by"] = "vault-autounseal-operator"
+		policy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: r.Options.PodSelector},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		}
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to apply network policy: %w", err)
+	}
+
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Network policy synced", "operation", result, "destinations", len(seen))
+	}
+	return ctrl.Result{RequeueAfter: networkPolicyResyncPeriod}, nil
+}
+
+// egressEndpoints returns the sorted endpoints of every destination the operator
+// connects to for vault.io resources: Vault servers or their jump hosts,
+// management Vaults, Consul servers and discovered nodes, notification sinks
+// and backup object stores.
+func (r *NetworkPolicyReconciler) egressEndpoints(ctx context.Context, logger logr.Logger) ([]string, error) {
+	endpoints := slices.Clone(r.Options.Endpoints)
+
+	var defaults vaultv1.VaultClusterDefaults
+	err := r.Get(ctx, types.NamespacedName{Name: vaultv1.ClusterDefaultsName}, &defaults)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get VaultClusterDefaults: %w", err)
+	}
+
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs); err != nil {
+		return nil, fmt.Errorf("failed to list VaultUnsealConfigs: %w", err)
+	}
+	for _, config := range configs.Items {
+		for i := range config.Spec.VaultInstances {
+			endpoints = append(endpoints, r.instanceEndpoints(ctx, logger, config.Namespace, &config.Spec.VaultInstances[i])...)
+		}
+		// The sinks of a config replace those of the cluster defaults
+		sinks := defaults.Spec.Notifications
+		if config.Spec.Settings != nil && config.Spec.Settings.Notifications != nil {
+			sinks = config.Spec.Settings.Notifications
+		}
+		endpoints = append(endpoints, r.sinkEndpoints(ctx, logger, config.Namespace, sinks)...)
+	}
+
+	var schedules vaultv1.VaultBackupScheduleList
+	if err := r.List(ctx, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to list VaultBackupSchedules: %w", err)
+	}
+	for _, schedule := range schedules.Items {
+		endpoints = append(endpoints, schedule.Spec.Endpoint, backup.Endpoint(&schedule.Spec.Destination))
+	}
+
+	var restores vaultv1.VaultRestoreList
+	if err := r.List(ctx, &restores); err != nil {
+		return nil, fmt.Errorf("failed to list VaultRestores: %w", err)
+	}
+	for _, restore := range restores.Items {
+		endpoints = append(endpoints, restore.Spec.Endpoint)
+		// Restores from a schedule use the destination of the schedule
+		if restore.Spec.Source.Destination != nil {
+			endpoints = append(endpoints, backup.Endpoint(restore.Spec.Source.Destination))
+		}
+	}
+
+	var checks vaultv1.VaultHealthCheckList
+	if err := r.List(ctx, &checks); err != nil {
+		return nil, fmt.Errorf("failed to list VaultHealthChecks: %w", err)
+	}
+	for _, check := range checks.Items {
+		endpoints = append(endpoints, check.Spec.Endpoint)
+	}
+
+	sort.Strings(endpoints)
+	return endpoints, nil
+}
+
+// instanceEndpoints returns the endpoints an instance is reached through, and
+// those of its management Vault and discovery provider. Discovered nodes are
+// listed as the unseal reconciler finds them; a failing provider only drops
+// its nodes.
+func (r *NetworkPolicyReconciler) instanceEndpoints(
+	ctx context.Context,
+	logger logr.Logger,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) []string {
+	var endpoints []string
+	if instance.TransitUnwrap != nil {
+		endpoints = append(endpoints, instance.TransitUnwrap.Address)
+	}
+	if instance.Discovery != nil && instance.Discovery.Consul != nil {
+		endpoints = append(endpoints, instance.Discovery.Consul.Address)
+	}
+
+	// Tunneled instances are only reached through their jump host
+	if instance.Tunnel != nil {
+		return append(endpoints, "tcp://"+tunnelAddress(instance.Tunnel))
+	}
+	// Only the port matters to egress rules, so the scheme need not be read
+	if instance.Endpoint == "" && instance.BankVaults != nil {
+		return append(endpoints, bankVaultsEndpoint("https", namespace, instance.BankVaults.Name))
+	}
+	if instance.Discovery == nil {
+		return append(endpoints, instance.Endpoint)
+	}
+
+	nodes, err := r.discovery.discoverNodes(ctx, namespace, instance)
+	if err != nil {
+		logger.Error(err, "skipping discovered vault nodes in network policy", "instance", instance.Name)
+		return endpoints
+	}
+	for _, node := range nodes {
+		nodeInstance, err := discoveredInstance(instance, node)
+		if err != nil {
+			logger.Error(err, "skipping discovered vault node in network policy", "instance", instance.Name)
+			continue
+		}
+		endpoints = append(endpoints, nodeInstance.Endpoint)
+	}
+	return endpoints
+}
+
+// sinkEndpoints returns the urls of notification sinks. Message bus sinks are
+// read from their connection Secrets in namespace.
+func (r *NetworkPolicyReconciler) sinkEndpoints(
+	ctx context.Context,
+	logger logr.Logger,
+	namespace string,
+	sinks []vaultv1.NotificationSink,
+) []string {
+	var endpoints []string
+	for _, sink := range sinks {
+		var connectionSecret string
+		switch {
+		case sink.Webhook != nil:
+			endpoints = append(endpoints, sink.Webhook.URL)
+		case sink.CloudEvents != nil:
+			endpoints = append(endpoints, sink.CloudEvents.URL)
+		case sink.NATS != nil:
+			connectionSecret = sink.NATS.ConnectionSecret
+		case sink.KafkaRESTProxy != nil:
+			connectionSecret = sink.KafkaRESTProxy.ConnectionSecret
+		}
+		if connectionSecret == "" {
+			continue
+		}
+		data, err := readSecretData(ctx, r.Client, namespace, connectionSecret)
+		if err == nil {
+			var conn notify.Connection
+			if conn, err = notify.ConnectionFromSecret(namespace+"/"+connectionSecret, data); err == nil {
+				endpoints = append(endpoints, conn.URL)
+			}
+		}
+		if err != nil {
+			logger.Error(err, "skipping notification sink in network policy", "sink", sink.Name)
+		}
+	}
+	return endpoints
+}
+
+// baseEgressRules allows DNS and the API server, without which the operator cannot work.
+func (r *NetworkPolicyReconciler) baseEgressRules() []networkingv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dns := networkingv1.NetworkPolicyEgressRule{
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: ptrIntStr(53)},
+			{Protocol: &tcp, Port: ptrIntStr(53)},
+		},
+	}
+
+	apiServer := networkingv1.NetworkPolicyEgressRule{}
+	for _, port := range r.Options.APIServerPorts {
+		apiServer.Ports = append(apiServer.Ports, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: ptrIntStr(port)})
+	}
+
+	return []networkingv1.NetworkPolicyEgressRule{dns, apiServer}
+}
+
+// egressRule builds the egress rule reaching endpoint and a key identifying it.
+// In-cluster service names are narrowed to their namespace and IP addresses to
+// their host; other hostnames can only be restricted by port.
+func egressRule(endpoint string) (networkingv1.NetworkPolicyEgressRule, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return networkingv1.NetworkPolicyEgressRule{}, "", fmt.Errorf("invalid endpoint: %w", err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return networkingv1.NetworkPolicyEgressRule{}, "", fmt.Errorf("endpoint %q has no host", endpoint)
+	}
+
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "nats", "tls":
+			port = notify.DefaultNATSPort
+		default:
+			port = "443"
+		}
+	}
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err == nil && portNumber == 0 {
+		err = fmt.Errorf("port must be between 1 and 65535")
+	}
+	if err != nil {
+		return networkingv1.NetworkPolicyEgressRule{}, "", fmt.Errorf("invalid port in endpoint %q: %w", endpoint, err)
+	}
+
+	tcp := corev1.ProtocolTCP
+	rule := networkingv1.NetworkPolicyEgressRule{
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: ptrIntStr(int32(portNumber))}},
+	}
+
+	var peer string
+	switch ip := net.ParseIP(host); {
+	case ip != nil:
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		cidr := fmt.Sprintf("%s/%d", ip.String(), bits)
+		rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+		peer = "ip=" + cidr
+	case serviceNamespace(host) != "":
+		namespace := serviceNamespace(host)
+		rule.To = []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: namespace}},
+		}}
+		peer = "namespace=" + namespace
+	default:
+		peer = "any"
+	}
+
+	return rule, fmt.Sprintf("%s:%d", peer, portNumber), nil
+}
+
+// serviceNamespace returns the namespace of an in-cluster DNS name such as
+// vault.vault.svc or vault-0.vault-internal.vault.svc.cluster.local.
+func serviceNamespace(host string) string {
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	for i := 2; i < len(labels); i++ {
+		if labels[i] == "svc" {
+			return labels[i-1]
+		}
+	}
+	return ""
+}
+
+func ptrIntStr(port int32) *intstr.IntOrString {
+	value := intstr.FromInt32(port)
+	return &value
+}
+
+func (r *NetworkPolicyReconciler) policyKey() types.NamespacedName {
+	return types.NamespacedName{Namespace: r.Options.Namespace, Name: r.Options.Name}
+}
+
+// policyRequest maps any change of a Vault endpoint to the single managed policy.
+func (r *NetworkPolicyReconciler) policyRequest(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: r.policyKey()}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NetworkPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Options.Namespace == "" {
+		return fmt.Errorf("network policy management requires the operator namespace")
+	}
+
+	isManagedPolicy := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.Options.Namespace && obj.GetName() == r.Options.Name
+	})
+	// Only spec changes can move endpoints; status updates are ignored
+	endpointChanged := builder.WithPredicates(predicate.GenerationChangedPredicate{})
+	mapToPolicy := handler.EnqueueRequestsFromMapFunc(r.policyRequest)
+
+	// Apply the policy once at startup, even before any Vault resource exists
+	initialSync := manager.RunnableFunc(func(ctx context.Context) error {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: r.policyKey()}); err != nil {
+			r.Log.Error(err, "initial network policy sync failed")
+		}
+		return nil
+	})
+	if err := mgr.Add(initialSync); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("networkpolicy").
+		For(&networkingv1.NetworkPolicy{}, builder.WithPredicates(isManagedPolicy)).
+		Watches(&vaultv1.VaultUnsealConfig{}, mapToPolicy, endpointChanged).
+		Watches(&vaultv1.VaultBackupSchedule{}, mapToPolicy, endpointChanged).
+		Watches(&vaultv1.VaultRestore{}, mapToPolicy, endpointChanged).
+		Watches(&vaultv1.VaultHealthCheck{}, mapToPolicy, endpointChanged).
+		Watches(&vaultv1.VaultClusterDefaults{}, mapToPolicy, endpointChanged).
+		Complete(r)
+}
@@ -0,0 +1,345 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestEgressRule(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		key      string
+	}{
+		{"https://vault.vault.svc:8200", "namespace=vault:8200"},
+		{"http://vault-0.vault-internal.vault-prod.svc.cluster.local:8200", "namespace=vault-prod:8200"},
+		{"https://10.0.0.5:8200", "ip=10.0.0.5/32:8200"},
+		{"https://[fd00::5]:8200", "ip=fd00::5/128:8200"},
+		{"https://vault.example.com", "any:443"},
+		{"http://vault.example.com", "any:80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			rule, key, err := egressRule(tt.endpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, key)
+			require.Len(t, rule.Ports, 1)
+		})
+	}
+
+	for _, endpoint := range []string{"not a url", "https://vault:0", "https://vault:65536"} {
+		_, _, err := egressRule(endpoint)
+		assert.Error(t, err, endpoint)
+	}
+}
+
+func TestNetworkPolicyTracksVaultEndpoints(t *testing.T) {
+	config := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "https://vault-0.vault-internal.vault.svc:8200"},
+			{Name: "vault-1", Endpoint: "https://vault-1.vault-internal.vault.svc:8200"},
+		}},
+	}
+	check := &vaultv1.VaultHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "vault"},
+		Spec:       vaultv1.VaultHealthCheckSpec{Endpoint: "https://10.1.2.3:8200"},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(config, check).Build()
+
+	r := NewNetworkPolicyReconciler(k8sClient, log.Log, k8sClient.Scheme(), NetworkPolicyOptions{
+		Namespace:   "vault-operator",
+		PodSelector: map[string]string{"app.kubernetes.io/name": "vault-autounseal-operator"},
+		Endpoints:   []string{"http://10.9.8.7:4318"},
+	})
+	req := ctrl.Request{NamespacedName: r.policyKey()}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var policy networkingv1.NetworkPolicy
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &policy))
+	assert.Equal(t, "vault-autounseal-operator", policy.Spec.PodSelector.MatchLabels["app.kubernetes.io/name"])
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, policy.Spec.PolicyTypes)
+	// DNS, API server, the vault namespace (deduplicated), the health check IP
+	// and the trace collector of the operator
+	require.Len(t, policy.Spec.Egress, 5)
+	assert.Equal(t, "10.9.8.7/32", policy.Spec.Egress[2].To[0].IPBlock.CIDR)
+	assert.Equal(t, "10.1.2.3/32", policy.Spec.Egress[3].To[0].IPBlock.CIDR)
+	assert.Equal(t, "vault", policy.Spec.Egress[4].To[0].NamespaceSelector.MatchLabels[namespaceNameLabel])
+
+	// Removing the health check drops its destination
+	require.NoError(t, k8sClient.Delete(context.Background(), check))
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &policy))
+	assert.Len(t, policy.Spec.Egress, 4)
+}
+
+// egressFieldPattern matches the json names of spec fields naming a destination
+// the operator connects to, or the Secret holding one.
+var egressFieldPattern = regexp.MustCompile(`(?i)(endpoint|address|url|srv|server|servers|brokers|connectionSecret)$`)
+
+// egressUnions are the spec types whose every member is a destination, such as
+// an object store without an endpoint field.
+var egressUnions = map[reflect.Type]bool{
+	reflect.TypeOf(vaultv1.BackupDestination{}): true,
+	reflect.TypeOf(vaultv1.NotificationSink{}):  true,
+}
+
+// elemType returns the type held by pointers, slices and maps of t.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// egressFields lists the spec fields of t naming destinations, as json paths
+// prefixed with path.
+func egressFields(t reflect.Type, path string, union bool) []string {
+	t = elemType(t)
+	if t.Kind() != reflect.Struct || t.PkgPath() != reflect.TypeOf(vaultv1.VaultInstance{}).PkgPath() {
+		return nil
+	}
+	var fields []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fieldPath := path
+		if name != "" {
+			fieldPath += "." + name
+		}
+		// A union member is one destination, whatever its fields
+		if union && name != "name" {
+			fields = append(fields, fieldPath)
+			continue
+		}
+		if egressFieldPattern.MatchString(name) {
+			fields = append(fields, fieldPath)
+		}
+		fields = append(fields, egressFields(field.Type, fieldPath, egressUnions[elemType(field.Type)])...)
+	}
+	return fields
+}
+
+// TestNetworkPolicyCoversEgress fails for spec fields naming a destination the
+// managed policy does not allow; cover a new one in egressEndpoints and here.
+func TestNetworkPolicyCoversEgress(t *testing.T) {
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"Node":"node-1","Address":"10.0.4.1","ServicePort":8204}]`))
+	}))
+	defer consul.Close()
+
+	config := func(instance vaultv1.VaultInstance, settings *vaultv1.UnsealSettings) *vaultv1.VaultUnsealConfig {
+		instance.Name = "vault"
+		return &vaultv1.VaultUnsealConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+			Spec:       vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{instance}, Settings: settings},
+		}
+	}
+	connection := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bus", Namespace: "vault"},
+		Data:       map[string][]byte{notify.ConnectionURLKey: []byte("nats://10.0.5.1")},
+	}
+	sinks := func(sink vaultv1.NotificationSink) []vaultv1.NotificationSink {
+		sink.Name = "sink"
+		return []vaultv1.NotificationSink{sink}
+	}
+	schedule := func(dest vaultv1.BackupDestination) *vaultv1.VaultBackupSchedule {
+		return &vaultv1.VaultBackupSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "vault"},
+			Spec:       vaultv1.VaultBackupScheduleSpec{Endpoint: "https://10.0.6.1:8206", Destination: dest},
+		}
+	}
+	restore := func(dest vaultv1.BackupDestination) *vaultv1.VaultRestore {
+		return &vaultv1.VaultRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "vault"},
+			Spec: vaultv1.VaultRestoreSpec{
+				Endpoint: "https://10.0.7.1:8207", Source: vaultv1.RestoreSource{Destination: &dest, Key: "snap"},
+			},
+		}
+	}
+	s3 := vaultv1.BackupDestination{S3: &vaultv1.S3Destination{Bucket: "b", Endpoint: "http://10.0.8.1:9000"}}
+	gcs := vaultv1.BackupDestination{GCS: &vaultv1.GCSDestination{Bucket: "b"}}
+	azure := vaultv1.BackupDestination{Azure: &vaultv1.AzureDestination{StorageAccount: "a", Container: "c"}}
+	defaults := func(sink vaultv1.NotificationSink) *vaultv1.VaultClusterDefaults {
+		return &vaultv1.VaultClusterDefaults{
+			ObjectMeta: metav1.ObjectMeta{Name: vaultv1.ClusterDefaultsName},
+			Spec:       vaultv1.VaultClusterDefaultsSpec{UnsealSettings: vaultv1.UnsealSettings{Notifications: sinks(sink)}},
+		}
+	}
+
+	// Each destination field, with the objects setting it and the endpoints
+	// the policy must allow
+	tests := map[string]struct {
+		objects []client.Object
+		allowed []string
+	}{
+		"VaultUnsealConfig.vaultInstances.endpoint": {
+			[]client.Object{config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, nil)},
+			[]string{"https://10.0.1.1:8201"},
+		},
+		"VaultUnsealConfig.vaultInstances.transitUnwrap.address": {
+			[]client.Object{config(vaultv1.VaultInstance{
+				Endpoint:      "https://10.0.1.1:8201",
+				TransitUnwrap: &vaultv1.TransitUnwrap{Address: "https://10.0.1.2:8202"},
+			}, nil)},
+			[]string{"https://10.0.1.2:8202"},
+		},
+		"VaultUnsealConfig.vaultInstances.tunnel.ssh.address": {
+			[]client.Object{config(vaultv1.VaultInstance{
+				Endpoint: "https://vault.internal:8200",
+				Tunnel:   &vaultv1.Tunnel{SSH: &vaultv1.SSHTunnel{Address: "10.0.2.1:2222"}},
+			}, nil)},
+			[]string{"tcp://10.0.2.1:2222"},
+		},
+		"VaultUnsealConfig.vaultInstances.tunnel.socks5.address": {
+			[]client.Object{config(vaultv1.VaultInstance{
+				Endpoint: "https://vault.internal:8200",
+				Tunnel:   &vaultv1.Tunnel{SOCKS5: &vaultv1.SOCKS5Tunnel{Address: "10.0.2.2:1081"}},
+			}, nil)},
+			[]string{"tcp://10.0.2.2:1081"},
+		},
+		"VaultUnsealConfig.vaultInstances.discovery.consul.address": {
+			[]client.Object{config(vaultv1.VaultInstance{
+				Endpoint:  "https://vault.service.consul:8200",
+				Discovery: &vaultv1.Discovery{Consul: &vaultv1.ConsulDiscovery{Address: consul.URL}},
+			}, nil)},
+			[]string{consul.URL, "https://10.0.4.1:8204"},
+		},
+		"VaultUnsealConfig.vaultInstances.discovery.dnsSrv": {
+			[]client.Object{config(vaultv1.VaultInstance{
+				Endpoint:  "https://vault.example.com:8200",
+				Discovery: &vaultv1.Discovery{DNSSrv: "_vault._tcp.example.com"},
+			}, nil)},
+			[]string{"https://10.0.3.1:8203"},
+		},
+		"VaultUnsealConfig.settings.notifications.webhook": {
+			[]client.Object{config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, &vaultv1.UnsealSettings{
+				Notifications: sinks(vaultv1.NotificationSink{Webhook: &vaultv1.WebhookSink{URL: "https://10.0.5.2:9002"}}),
+			})},
+			[]string{"https://10.0.5.2:9002"},
+		},
+		"VaultUnsealConfig.settings.notifications.cloudEvents": {
+			[]client.Object{config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, &vaultv1.UnsealSettings{
+				Notifications: sinks(vaultv1.NotificationSink{CloudEvents: &vaultv1.CloudEventsSink{URL: "http://10.0.5.3:9003"}}),
+			})},
+			[]string{"http://10.0.5.3:9003"},
+		},
+		"VaultUnsealConfig.settings.notifications.nats": {
+			[]client.Object{connection, config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, &vaultv1.UnsealSettings{
+				Notifications: sinks(vaultv1.NotificationSink{NATS: &vaultv1.NATSSink{ConnectionSecret: "bus"}}),
+			})},
+			[]string{"nats://10.0.5.1:4222"},
+		},
+		"VaultUnsealConfig.settings.notifications.kafkaRestProxy": {
+			[]client.Object{connection, config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, &vaultv1.UnsealSettings{
+				Notifications: sinks(vaultv1.NotificationSink{KafkaRESTProxy: &vaultv1.KafkaRESTProxySink{ConnectionSecret: "bus"}}),
+			})},
+			[]string{"nats://10.0.5.1:4222"},
+		},
+		"VaultClusterDefaults.notifications.webhook": {
+			[]client.Object{
+				defaults(vaultv1.NotificationSink{Webhook: &vaultv1.WebhookSink{URL: "https://10.0.5.4:9004"}}),
+				config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, nil),
+			},
+			[]string{"https://10.0.5.4:9004"},
+		},
+		"VaultClusterDefaults.notifications.cloudEvents": {
+			[]client.Object{
+				defaults(vaultv1.NotificationSink{CloudEvents: &vaultv1.CloudEventsSink{URL: "https://10.0.5.5:9005"}}),
+				config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, nil),
+			},
+			[]string{"https://10.0.5.5:9005"},
+		},
+		"VaultClusterDefaults.notifications.nats": {
+			[]client.Object{
+				connection,
+				defaults(vaultv1.NotificationSink{NATS: &vaultv1.NATSSink{ConnectionSecret: "bus"}}),
+				config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, nil),
+			},
+			[]string{"nats://10.0.5.1:4222"},
+		},
+		"VaultClusterDefaults.notifications.kafkaRestProxy": {
+			[]client.Object{
+				connection,
+				defaults(vaultv1.NotificationSink{KafkaRESTProxy: &vaultv1.KafkaRESTProxySink{ConnectionSecret: "bus"}}),
+				config(vaultv1.VaultInstance{Endpoint: "https://10.0.1.1:8201"}, nil),
+			},
+			[]string{"nats://10.0.5.1:4222"},
+		},
+		"VaultBackupSchedule.endpoint":          {[]client.Object{schedule(s3)}, []string{"https://10.0.6.1:8206"}},
+		"VaultBackupSchedule.destination.s3":    {[]client.Object{schedule(s3)}, []string{"http://10.0.8.1:9000"}},
+		"VaultBackupSchedule.destination.gcs":   {[]client.Object{schedule(gcs)}, []string{"https://storage.googleapis.com"}},
+		"VaultBackupSchedule.destination.azure": {[]client.Object{schedule(azure)}, []string{"https://a.blob.core.windows.net"}},
+		"VaultRestore.endpoint":                 {[]client.Object{restore(s3)}, []string{"https://10.0.7.1:8207"}},
+		"VaultRestore.source.destination.s3":    {[]client.Object{restore(s3)}, []string{"http://10.0.8.1:9000"}},
+		"VaultRestore.source.destination.gcs":   {[]client.Object{restore(gcs)}, []string{"https://storage.googleapis.com"}},
+		"VaultRestore.source.destination.azure": {[]client.Object{restore(azure)}, []string{"https://a.blob.core.windows.net"}},
+		"VaultHealthCheck.endpoint": {
+			[]client.Object{&vaultv1.VaultHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "vault"},
+				Spec:       vaultv1.VaultHealthCheckSpec{Endpoint: "https://10.0.9.1:8209"},
+			}},
+			[]string{"https://10.0.9.1:8209"},
+		},
+	}
+
+	var fields []string
+	for _, spec := range []any{
+		vaultv1.VaultUnsealConfigSpec{}, vaultv1.VaultClusterDefaultsSpec{}, vaultv1.VaultBackupScheduleSpec{},
+		vaultv1.VaultRestoreSpec{}, vaultv1.VaultHealthCheckSpec{},
+	} {
+		specType := reflect.TypeOf(spec)
+		fields = append(fields, egressFields(specType, strings.TrimSuffix(specType.Name(), "Spec"), false)...)
+	}
+	var uncovered []string
+	for _, field := range fields {
+		if _, ok := tests[field]; !ok {
+			uncovered = append(uncovered, field)
+		}
+	}
+	assert.Empty(t, uncovered, "destinations not covered by the network policy")
+	assert.Len(t, tests, len(fields), "covered destinations not found in the spec types")
+
+	for field, tt := range tests {
+		t.Run(field, func(t *testing.T) {
+			k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(tt.objects...).Build()
+			r := NewNetworkPolicyReconciler(k8sClient, log.Log, k8sClient.Scheme(), NetworkPolicyOptions{
+				Namespace: "vault-operator",
+			})
+			r.discovery.Resolver = &srvTable{records: map[string][]*net.SRV{
+				"_vault._tcp.example.com": {{Target: "10.0.3.1.", Port: 8203}},
+			}}
+			req := ctrl.Request{NamespacedName: r.policyKey()}
+			_, err := r.Reconcile(t.Context(), req)
+			require.NoError(t, err)
+
+			var policy networkingv1.NetworkPolicy
+			require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &policy))
+			for _, endpoint := range tt.allowed {
+				rule, _, err := egressRule(endpoint)
+				require.NoError(t, err)
+				assert.Contains(t, policy.Spec.Egress, rule, "egress to %s is not allowed", endpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// VaultUnsealConfigKind is the kind of VaultUnsealConfig resources.
+const VaultUnsealConfigKind = "VaultUnsealConfig"
+
+// LoadVaultUnsealConfigs reads the VaultUnsealConfigs of a YAML or JSON stream.
+// Each document is either a VaultUnsealConfig resource or a bare spec holding
+// vaultInstances and settings; documents of other kinds are skipped.
+func LoadVaultUnsealConfigs(r io.Reader) ([]*vaultv1.VaultUnsealConfig, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	var configs []*vaultv1.VaultUnsealConfig
+	for doc := 1; ; doc++ {
+		var raw struct {
+			vaultv1.VaultUnsealConfig
+			vaultv1.VaultUnsealConfigSpec
+		}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+
+		config := raw.VaultUnsealConfig.DeepCopy()
+		switch config.Kind {
+		case VaultUnsealConfigKind:
+		case "":
+			if raw.VaultInstances == nil {
+				// Empty document, e.g. a trailing "---"
+				continue
+			}
+			config.Spec = *raw.VaultUnsealConfigSpec.DeepCopy()
+			config.Name = fmt.Sprintf("config-%d", doc)
+		default:
+			continue
+		}
+
+		if len(config.Spec.VaultInstances) == 0 {
+			return nil, fmt.Errorf("document %d: no vaultInstances configured", doc)
+		}
+		configs = append(configs, config)
+	}
+
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no VaultUnsealConfig found")
+	}
+	return configs, nil
+}
+
+// UnsealOnce checks and unseals every instance of vaultConfig a single time,
+// without a Kubernetes API server. Cluster defaults are not consulted; only the
+// config's own settings are layered over options. Notifications are delivered
+// as they are by the controller. Raft monitoring needs token Secrets and is skipped;
+// instances reading keys from a Secret fail.
+func UnsealOnce(
+	ctx context.Context,
+	logger logr.Logger,
+	repository VaultClientRepository,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	options *ReconcilerOptions,
+) ([]vaultv1.VaultInstanceStatus, bool) {
+	r := NewVaultUnsealConfigReconciler(nil, logger, nil, repository, options)
+
+	vaultConfig = vaultConfig.DeepCopy()
+	for i := range vaultConfig.Spec.VaultInstances {
+		vaultConfig.Spec.VaultInstances[i].Raft = nil
+	}
+
+	settings := defaultUnsealSettings(r.Options)
+	settings.apply(vaultConfig.Spec.Settings)
+
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
+
+	return r.processVaultInstances(ctx, logger, vaultConfig, settings)
+}
+
+// UnsealUntilReady calls UnsealOnce every interval until every instance of
+// vaultConfig is unsealed or ctx is done, for a Vault that may still be
+// starting. It returns the statuses of the last attempt.
+func UnsealUntilReady(
+	ctx context.Context,
+	logger logr.Logger,
+	repository VaultClientRepository,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	options *ReconcilerOptions,
+	interval time.Duration,
+) ([]vaultv1.VaultInstanceStatus, bool) {
+	for {
+		statuses, allReady := UnsealOnce(ctx, logger, repository, vaultConfig, options)
+		if allReady {
+			return statuses, true
+		}
+		for _, status := range statuses {
+			if status.Sealed {
+				logger.Info("vault instance not unsealed yet", "instance", status.Name,
+					"reason", status.Reason, "error", status.Error, "retryIn", interval)
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return statuses, false
+		case <-timer.C:
+		}
+	}
+}
+
+// ReadKeyFiles reads unseal keys from the files of dir, such as a mounted
+// Secret, in file name order. Each file holds one key or a key list in any
+// format of a combined Secret key. Hidden files, like the ..data link of a
+// Secret volume, and directories are skipped.
+func ReadKeyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		// Secret volume files are symlinks; stat follows them
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // dir is supplied by the operator
+		if err != nil {
+			return nil, err
+		}
+		fileKeys, err := parseKeyList(data)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no unseal keys found in %s", dir)
+	}
+	return keys, nil
+}
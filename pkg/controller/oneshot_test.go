@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestLoadVaultUnsealConfigs(t *testing.T) {
+	configs, err := LoadVaultUnsealConfigs(strings.NewReader(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ignored
+---
+apiVersion: vault.io/v1
+kind: VaultUnsealConfig
+metadata:
+  name: primary
+  namespace: vault
+spec:
+  vaultInstances:
+  - name: vault-0
+    endpoint: http://vault-0:8200
+    unsealKeys: [k1, k2]
+    threshold: 2
+---
+vaultInstances:
+- name: dr
+  endpoint: https://dr.example.com:8200
+  unsealKeys: [k1]
+settings:
+  threshold: 1
+---
+`))
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	assert.Equal(t, "primary", configs[0].Name)
+	assert.Equal(t, "vault", configs[0].Namespace)
+	assert.Equal(t, 2, *configs[0].Spec.VaultInstances[0].Threshold)
+
+	assert.Equal(t, "config-3", configs[1].Name)
+	assert.Equal(t, "dr", configs[1].Spec.VaultInstances[0].Name)
+	assert.Equal(t, 1, *configs[1].Spec.Settings.Threshold)
+
+	_, err = LoadVaultUnsealConfigs(strings.NewReader("kind: ConfigMap\n"))
+	assert.EqualError(t, err, "no VaultUnsealConfig found")
+
+	_, err = LoadVaultUnsealConfigs(strings.NewReader("kind: VaultUnsealConfig\nspec: {}\n"))
+	assert.EqualError(t, err, "document 1: no vaultInstances configured")
+}
+
+func TestUnsealOnce(t *testing.T) {
+	threshold := 1
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+				{Name: "vault-1", Endpoint: "http://vault-1:8200", UnsealKeys: []string{"k1"}},
+			},
+			Settings: &vaultv1.UnsealSettings{Threshold: &threshold},
+		},
+	}
+
+	repo := &mocks.MockVaultClientRepository{}
+	unsealed := &mocks.MockVaultClient{}
+	failing := &mocks.MockVaultClient{}
+	repo.On("GetClient", mock.Anything, "/vault-0", mock.Anything).Return(unsealed, nil)
+	repo.On("GetClient", mock.Anything, "/vault-1", mock.Anything).Return(failing, nil)
+	unsealed.On("IsSealed", mock.Anything).Return(true, nil)
+	unsealed.On("Unseal", mock.Anything, []string{"k1"}, 1).Return(mocks.NewMockSealStatusResponse(false, 1, 1), nil)
+	failing.On("IsSealed", mock.Anything).Return(false, assert.AnError)
+
+	statuses, allReady := UnsealOnce(context.Background(), log.Log, repo, vaultConfig, nil)
+
+	assert.False(t, allReady)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Sealed)
+	assert.NotNil(t, statuses[0].LastUnsealed)
+	assert.True(t, statuses[1].Sealed)
+	assert.Contains(t, statuses[1].Error, "failed to check seal status")
+	unsealed.AssertExpectations(t)
+}
+
+func TestUnsealOncePodEndpoint(t *testing.T) {
+	// Pods of endpoints addressing them by DNS name are looked up for maintenance
+	// signals and port probes, which one-shot runs have no Kubernetes client for
+	threshold := 1
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{
+				Name:       "vault-0",
+				Endpoint:   "http://vault-0.vault-internal.vault.svc:8200",
+				UnsealKeys: []string{"k1"},
+			}},
+			Settings: &vaultv1.UnsealSettings{Threshold: &threshold},
+		},
+	}
+
+	repo := &mocks.MockVaultClientRepository{}
+	client := &mocks.MockVaultClient{}
+	repo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(client, nil)
+	client.On("IsSealed", mock.Anything).Return(true, nil)
+	client.On("Unseal", mock.Anything, []string{"k1"}, 1).Return(mocks.NewMockSealStatusResponse(false, 1, 1), nil)
+
+	statuses, allReady := UnsealOnce(context.Background(), log.Log, repo, vaultConfig, nil)
+
+	assert.True(t, allReady)
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Sealed)
+	assert.Empty(t, statuses[0].Error)
+	assert.NotEqual(t, ReasonPodStarting, statuses[0].Reason, "the unresolvable endpoint is not probed")
+	client.AssertExpectations(t)
+}
+
+func TestUnsealUntilReady(t *testing.T) {
+	threshold := 1
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "local"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{
+					Name:       "vault-0",
+					Endpoint:   "http://127.0.0.1:8200",
+					UnsealKeys: []string{"k1"},
+					Threshold:  &threshold,
+				},
+			},
+		},
+	}
+
+	repo := &mocks.MockVaultClientRepository{}
+	client := &mocks.MockVaultClient{}
+	repo.On("GetClient", mock.Anything, "/vault-0", mock.Anything).Return(client, nil)
+	// Vault is still starting on the first attempt
+	client.On("IsSealed", mock.Anything).Return(false, assert.AnError).Once()
+	client.On("IsSealed", mock.Anything).Return(true, nil).Once()
+	client.On("Unseal", mock.Anything, []string{"k1"}, 1).Return(mocks.NewMockSealStatusResponse(false, 1, 1), nil)
+
+	statuses, allReady := UnsealUntilReady(context.Background(), log.Log, repo, vaultConfig, nil, time.Millisecond)
+
+	assert.True(t, allReady)
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Sealed)
+	client.AssertExpectations(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	down := &mocks.MockVaultClient{}
+	downRepo := &mocks.MockVaultClientRepository{}
+	downRepo.On("GetClient", mock.Anything, "/vault-0", mock.Anything).Return(down, nil)
+	down.On("IsSealed", mock.Anything).Return(false, assert.AnError)
+
+	statuses, allReady = UnsealUntilReady(ctx, log.Log, downRepo, vaultConfig, nil, 5*time.Millisecond)
+
+	assert.False(t, allReady)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Sealed)
+}
+
+func TestReadKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key2"), []byte("k2\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key1"), []byte("k1"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys"), []byte(`["k3","k4"]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0o700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o700))
+
+	keys, err := ReadKeyFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k2", "k3", "k4"}, keys)
+
+	_, err = ReadKeyFiles(t.TempDir())
+	assert.ErrorContains(t, err, "no unseal keys found")
+
+	_, err = ReadKeyFiles(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
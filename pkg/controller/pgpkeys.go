@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// keyDecryptionPGP names OpenPGP in key decryption errors.
+const keyDecryptionPGP = "pgp"
+
+var (
+	// errNoPGPKeyMatch is returned for keys encrypted to none of the private keys.
+	errNoPGPKeyMatch = errors.New("not encrypted to any of the private keys")
+	// errPGPPassphrase is returned for protected private keys without a passphrase.
+	errPGPPassphrase = errors.New("private key is protected: set passphraseSecretRef")
+)
+
+// pgpDecrypt decrypts OpenPGP-encrypted keys in memory with the private keys
+// of decryption. Each key is decrypted with the private key it was encrypted
+// to, as vault operator init -pgp-keys encrypts each share to another holder.
+func (r *VaultUnsealConfigReconciler) pgpDecrypt(
+	ctx context.Context,
+	namespace string,
+	decryption *vaultv1.PGPDecryption,
+	encrypted []string,
+) ([]string, error) {
+	keyring, err := r.pgpKeyring(ctx, namespace, decryption)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(encrypted))
+	for i, key := range encrypted {
+		plaintext, err := pgpDecryptMessage(key, keyring)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionPGP, err: fmt.Errorf("key %d: %w", i, err)}
+		}
+		if keys[i] = strings.TrimSpace(string(plaintext)); keys[i] == "" {
+			return nil, &keyDecryptionError{method: keyDecryptionPGP, err: fmt.Errorf("key %d is empty", i)}
+		}
+	}
+	return keys, nil
+}
+
+// pgpDecryptMessage decrypts an OpenPGP message, base64 encoded as printed by
+// Vault or ASCII-armored, with keyring.
+func pgpDecryptMessage(key string, keyring openpgp.EntityList) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	var message io.Reader
+	if strings.HasPrefix(key, "-----BEGIN PGP MESSAGE-----") {
+		block, err := armor.Decode(strings.NewReader(key))
+		if err != nil {
+			return nil, err
+		}
+		message = block.Body
+	} else {
+		data, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, errors.New("not a base64 or armored OpenPGP message")
+		}
+		message = bytes.NewReader(data)
+	}
+
+	details, err := openpgp.ReadMessage(message, keyring, nil, nil)
+	if errors.Is(err, pgperrors.ErrKeyIncorrect) {
+		return nil, errNoPGPKeyMatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsEncrypted {
+		return nil, errors.New("message is not encrypted")
+	}
+	// The integrity of the message is checked once its body is read to the end
+	return io.ReadAll(details.UnverifiedBody)
+}
+
+// pgpKeyring reads and unlocks the private keys of decryption. The Secrets are
+// read on every decrypt, so rotated keys are picked up without a restart.
+func (r *VaultUnsealConfigReconciler) pgpKeyring(
+	ctx context.Context,
+	namespace string,
+	decryption *vaultv1.PGPDecryption,
+) (openpgp.EntityList, error) {
+	if r.Client == nil {
+		return nil, errors.New("pgp private key secrets require a Kubernetes client")
+	}
+
+	var keyring openpgp.EntityList
+	for _, key := range decryption.PrivateKeys {
+		data, err := readSecretKey(ctx, r.Client, namespace, key.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		var passphrase []byte
+		if ref := key.PassphraseSecretRef; ref != nil {
+			if passphrase, err = readSecretKey(ctx, r.Client, namespace, *ref); err != nil {
+				return nil, err
+			}
+			passphrase = bytes.TrimRight(passphrase, "\r\n")
+		}
+
+		entities, err := parsePGPPrivateKeys(data, passphrase)
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionPGP,
+				err: fmt.Errorf("secret %s/%s key %q: %w", namespace, key.SecretRef.Name, key.SecretRef.Key, err)}
+		}
+		keyring = append(keyring, entities...)
+	}
+	return keyring, nil
+}
+
+// parsePGPPrivateKeys parses armored, binary or base64 encoded private keys and
+// unlocks them with passphrase.
+func parsePGPPrivateKeys(data, passphrase []byte) (openpgp.EntityList, error) {
+	// Binary keys may end in bytes that look like whitespace, so only text is trimmed
+	trimmed := bytes.TrimSpace(data)
+	var entities openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(trimmed, []byte("-----BEGIN PGP")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(trimmed))
+	} else {
+		if decoded, decodeErr := base64.StdEncoding.DecodeString(string(trimmed)); decodeErr == nil {
+			data = decoded
+		}
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			return nil, errors.New("not a private key")
+		}
+		keys := []*packet.PrivateKey{entity.PrivateKey}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil {
+				keys = append(keys, subkey.PrivateKey)
+			}
+		}
+		for _, key := range keys {
+			if !key.Encrypted {
+				continue
+			}
+			if passphrase == nil {
+				return nil, errPGPPassphrase
+			}
+			if err := key.Decrypt(passphrase); err != nil {
+				return nil, errors.New("wrong passphrase")
+			}
+		}
+	}
+	return entities, nil
+}
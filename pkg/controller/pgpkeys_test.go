@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newPGPEntity returns a new RSA key pair and its private key, armored or
+// base64 encoded.
+func newPGPEntity(t *testing.T, armored bool) (*openpgp.Entity, []byte) {
+	entity, err := openpgp.NewEntity("holder", "", "holder@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoRSA})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	if armored {
+		w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, entity.SerializePrivate(w, nil))
+		require.NoError(t, w.Close())
+		return entity, buf.Bytes()
+	}
+	require.NoError(t, entity.SerializePrivate(&buf, nil))
+	return entity, []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// encryptShare encrypts the hex encoded share to entity, base64 encoded like
+// vault operator init -pgp-keys.
+func encryptShare(t *testing.T, entity *openpgp.Entity, share []byte) string {
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(hex.EncodeToString(share)))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestPGPDecryption(t *testing.T) {
+	first, firstKey := newPGPEntity(t, true)
+	second, secondKey := newPGPEntity(t, false)
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgp", Namespace: "vault"},
+		Data:       map[string][]byte{"first.asc": firstKey, "second": secondKey},
+	}).Build()
+	r := NewVaultUnsealConfigReconciler(c, log.Log, nil, nil, nil)
+
+	share1, share2 := []byte("share-1"), []byte("share-2")
+	instance := &vaultv1.VaultInstance{
+		Name: "vault",
+		// Each share is encrypted to another holder, in any order
+		UnsealKeys: []string{encryptShare(t, second, share2), encryptShare(t, first, share1)},
+		PGPDecryption: &vaultv1.PGPDecryption{PrivateKeys: []vaultv1.PGPPrivateKey{
+			{SecretRef: vaultv1.SecretKeyRef{Name: "pgp", Key: "first.asc"}},
+			{SecretRef: vaultv1.SecretKeyRef{Name: "pgp", Key: "second"}},
+		}},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		base64.StdEncoding.EncodeToString(share2),
+		base64.StdEncoding.EncodeToString(share1),
+	}, keys)
+	require.NoError(t, r.checkKeySource(t.Context(), "vault", instance))
+
+	other, _ := newPGPEntity(t, true)
+	instance.UnsealKeys = []string{encryptShare(t, other, share1)}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	assert.ErrorIs(t, err, errNoPGPKeyMatch)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+
+	instance.UnsealKeys = []string{base64.StdEncoding.EncodeToString(share1)}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+
+	instance.PGPDecryption.PrivateKeys[1].SecretRef.Name = "missing"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonSecretMissing, classifyError(err))
+}
+
+func TestPGPDecryptionPassphrase(t *testing.T) {
+	// Exported with gpg --armor --export-secret-keys, protected by "correct horse"
+	privateKey, err := os.ReadFile("testdata/pgp-passphrase.asc")
+	require.NoError(t, err)
+	keyring, err := parsePGPPrivateKeys(privateKey, []byte("correct horse"))
+	require.NoError(t, err)
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgp", Namespace: "vault"},
+		Data: map[string][]byte{
+			"private.asc": privateKey,
+			"passphrase":  []byte("correct horse\n"),
+			"wrong":       []byte("wrong horse"),
+		},
+	}).Build()
+	r := NewVaultUnsealConfigReconciler(c, log.Log, nil, nil, nil)
+
+	var armored bytes.Buffer
+	aw, err := armor.Encode(&armored, "PGP MESSAGE", nil)
+	require.NoError(t, err)
+	w, err := openpgp.Encrypt(aw, keyring, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("0a0b0c"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, aw.Close())
+
+	key := vaultv1.PGPPrivateKey{SecretRef: vaultv1.SecretKeyRef{Name: "pgp", Key: "private.asc"}}
+	instance := &vaultv1.VaultInstance{
+		Name:          "vault",
+		UnsealKeys:    []string{armored.String()},
+		PGPDecryption: &vaultv1.PGPDecryption{PrivateKeys: []vaultv1.PGPPrivateKey{key}},
+	}
+	err = r.checkKeySource(t.Context(), "vault", instance)
+	assert.ErrorIs(t, err, errPGPPassphrase)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+
+	instance.PGPDecryption.PrivateKeys[0].PassphraseSecretRef = &vaultv1.SecretKeyRef{Name: "pgp", Key: "wrong"}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "wrong passphrase")
+
+	instance.PGPDecryption.PrivateKeys[0].PassphraseSecretRef.Key = "passphrase"
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString([]byte{0x0a, 0x0b, 0x0c})}, keys)
+}
+
+func TestPGPDecryptionCurve25519(t *testing.T) {
+	// Generated with gpg --quick-gen-key ed25519 and --quick-add-key cv25519, the
+	// default of current GnuPG; the share was encrypted with gpg --encrypt
+	privateKey, err := os.ReadFile("testdata/pgp-cv25519.asc")
+	require.NoError(t, err)
+	share, err := os.ReadFile("testdata/pgp-cv25519.msg")
+	require.NoError(t, err)
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgp", Namespace: "vault"},
+		Data:       map[string][]byte{"private.asc": privateKey},
+	}).Build()
+	r := NewVaultUnsealConfigReconciler(c, log.Log, nil, nil, nil)
+
+	instance := &vaultv1.VaultInstance{
+		Name:       "vault",
+		UnsealKeys: []string{string(share)},
+		PGPDecryption: &vaultv1.PGPDecryption{PrivateKeys: []vaultv1.PGPPrivateKey{
+			{SecretRef: vaultv1.SecretKeyRef{Name: "pgp", Key: "private.asc"}},
+		}},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString([]byte{0x0a, 0x0b, 0x0c})}, keys)
+
+	// Keys generated in Go on the same curves round-trip as well
+	entity, err := openpgp.NewEntity("holder", "", "holder@example.com",
+		&packet.Config{Algorithm: packet.PubKeyAlgoEdDSA, Curve: packet.Curve25519})
+	require.NoError(t, err)
+	var generated bytes.Buffer
+	require.NoError(t, entity.SerializePrivate(&generated, nil))
+	keyring, err := parsePGPPrivateKeys(generated.Bytes(), nil)
+	require.NoError(t, err)
+	plaintext, err := pgpDecryptMessage(encryptShare(t, entity, []byte("share")), keyring)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString([]byte("share")), string(plaintext))
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// phaseTransitions lists the phases each phase may move to. A Suspended config
+// restarts its lifecycle in Pending.
+var phaseTransitions = map[vaultv1.UnsealConfigPhase][]vaultv1.UnsealConfigPhase{
+	"": {
+		vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseUnsealing, vaultv1.UnsealConfigPhaseReady,
+		vaultv1.UnsealConfigPhaseDegraded, vaultv1.UnsealConfigPhaseError, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhasePending: {
+		vaultv1.UnsealConfigPhaseUnsealing, vaultv1.UnsealConfigPhaseReady, vaultv1.UnsealConfigPhaseDegraded,
+		vaultv1.UnsealConfigPhaseError, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhaseUnsealing: {
+		vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseReady, vaultv1.UnsealConfigPhaseDegraded,
+		vaultv1.UnsealConfigPhaseError, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhaseReady: {
+		vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseUnsealing, vaultv1.UnsealConfigPhaseDegraded,
+		vaultv1.UnsealConfigPhaseError, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhaseDegraded: {
+		vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseUnsealing, vaultv1.UnsealConfigPhaseReady,
+		vaultv1.UnsealConfigPhaseError, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhaseError: {
+		vaultv1.UnsealConfigPhasePending, vaultv1.UnsealConfigPhaseUnsealing, vaultv1.UnsealConfigPhaseReady,
+		vaultv1.UnsealConfigPhaseDegraded, vaultv1.UnsealConfigPhaseSuspended,
+	},
+	vaultv1.UnsealConfigPhaseSuspended: {
+		vaultv1.UnsealConfigPhasePending,
+	},
+}
+
+// derivePhase computes the phase of an active config from its Ready condition
+// and instance statuses.
+func derivePhase(status *vaultv1.VaultUnsealConfigStatus) vaultv1.UnsealConfigPhase {
+	if len(status.VaultStatuses) == 0 {
+		return vaultv1.UnsealConfigPhasePending
+	}
+	// Nothing is reconciled while every instance is disabled
+	enabled := countEnabled(status.VaultStatuses)
+	if enabled == 0 {
+		return vaultv1.UnsealConfigPhaseSuspended
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, ConditionTypeReady) {
+		return vaultv1.UnsealConfigPhaseReady
+	}
+
+	failed := 0
+	for i := range status.VaultStatuses {
+		if status.VaultStatuses[i].Error != "" {
+			failed++
+		}
+	}
+	switch {
+	case failed == enabled:
+		return vaultv1.UnsealConfigPhaseError
+	case failed > 0:
+		return vaultv1.UnsealConfigPhaseDegraded
+	default:
+		return vaultv1.UnsealConfigPhaseUnsealing
+	}
+}
+
+// setPhase moves status to the desired phase if the transition is allowed. A
+// Suspended config that is active again passes through Pending first.
+func setPhase(status *vaultv1.VaultUnsealConfigStatus, desired vaultv1.UnsealConfigPhase) {
+	current := status.Phase
+	if current == desired {
+		return
+	}
+	for _, allowed := range phaseTransitions[current] {
+		if allowed == desired {
+			status.Phase = desired
+			return
+		}
+	}
+	if current == vaultv1.UnsealConfigPhaseSuspended {
+		status.Phase = vaultv1.UnsealConfigPhasePending
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDerivePhase(t *testing.T) {
+	ready := []metav1.Condition{{Type: ConditionTypeReady, Status: metav1.ConditionTrue}}
+	notReady := []metav1.Condition{{Type: ConditionTypeReady, Status: metav1.ConditionFalse}}
+	disabled := []metav1.Condition{{Type: ConditionTypeDisabled, Status: metav1.ConditionTrue}}
+
+	tests := []struct {
+		name     string
+		status   vaultv1.VaultUnsealConfigStatus
+		expected vaultv1.UnsealConfigPhase
+	}{
+		{name: "no instances checked", expected: vaultv1.UnsealConfigPhasePending},
+		{
+			name: "all unsealed",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: ready, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0"},
+			}},
+			expected: vaultv1.UnsealConfigPhaseReady,
+		},
+		{
+			name: "sealed without errors",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: notReady, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0"}, {Name: "vault-1", Sealed: true},
+			}},
+			expected: vaultv1.UnsealConfigPhaseUnsealing,
+		},
+		{
+			name: "some failing",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: notReady, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0"}, {Name: "vault-1", Sealed: true, Error: "timeout"},
+			}},
+			expected: vaultv1.UnsealConfigPhaseDegraded,
+		},
+		{
+			name: "all failing",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: notReady, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0", Sealed: true, Error: "timeout"},
+			}},
+			expected: vaultv1.UnsealConfigPhaseError,
+		},
+		{
+			name: "all failing but a disabled instance",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: notReady, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0", Sealed: true, Error: "timeout"}, {Name: "vault-1", Conditions: disabled},
+			}},
+			expected: vaultv1.UnsealConfigPhaseError,
+		},
+		{
+			name: "all disabled",
+			status: vaultv1.VaultUnsealConfigStatus{Conditions: ready, VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0", Conditions: disabled},
+			}},
+			expected: vaultv1.UnsealConfigPhaseSuspended,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, derivePhase(&tt.status))
+		})
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	status := &vaultv1.VaultUnsealConfigStatus{}
+	setPhase(status, vaultv1.UnsealConfigPhaseUnsealing)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseUnsealing, status.Phase)
+
+	setPhase(status, vaultv1.UnsealConfigPhaseReady)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseReady, status.Phase)
+
+	setPhase(status, vaultv1.UnsealConfigPhaseSuspended)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseSuspended, status.Phase)
+
+	// Resuming restarts the lifecycle
+	setPhase(status, vaultv1.UnsealConfigPhaseReady)
+	assert.Equal(t, vaultv1.UnsealConfigPhasePending, status.Phase)
+	setPhase(status, vaultv1.UnsealConfigPhaseReady)
+	assert.Equal(t, vaultv1.UnsealConfigPhaseReady, status.Phase)
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+const (
+	// DefaultPKCS11Tool is the OpenSC command driving PKCS#11 modules
+	DefaultPKCS11Tool = "pkcs11-tool"
+	// PKCS11MechanismOAEP decrypts with RSA-OAEP using SHA-256 for the hash and MGF1
+	PKCS11MechanismOAEP = "RSA-PKCS-OAEP"
+	// PKCS11MechanismPKCS decrypts with RSA PKCS#1 v1.5 padding
+	PKCS11MechanismPKCS = "RSA-PKCS"
+	// pkcs11PINEnv passes the PIN to pkcs11-tool, so it never appears in its arguments
+	pkcs11PINEnv = "VAULT_AUTOUNSEAL_PKCS11_PIN"
+	// keyDecryptionPKCS11 names PKCS#11 in key decryption errors
+	keyDecryptionPKCS11 = "pkcs11"
+)
+
+// pkcs11Error is a failure to load a PKCS#11 module, log in to its token, or
+// read or decrypt keys with it.
+type pkcs11Error struct {
+	module string
+	err    error
+}
+
+func (e *pkcs11Error) Error() string {
+	return fmt.Sprintf("pkcs11 module %s: %v", e.module, e.err)
+}
+
+func (e *pkcs11Error) Unwrap() error {
+	return e.err
+}
+
+// pkcs11Keys reads the data objects of a PKCS#11 key source.
+func (r *VaultUnsealConfigReconciler) pkcs11Keys(
+	ctx context.Context,
+	namespace string,
+	source *vaultv1.PKCS11KeySource,
+) ([]string, error) {
+	keys := make([]string, 0, len(source.Objects))
+	for _, label := range source.Objects {
+		out, err := r.runPKCS11Tool(ctx, namespace, &source.Token, nil,
+			"--read-object", "--type", "data", "--label", label)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimSpace(string(out))
+		if key == "" {
+			return nil, &pkcs11Error{module: source.Token.Module, err: fmt.Errorf("data object %q is empty", label)}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// pkcs11Decrypt decrypts base64 encoded RSA ciphertexts with the private key of
+// decryption, one token operation per key.
+func (r *VaultUnsealConfigReconciler) pkcs11Decrypt(
+	ctx context.Context,
+	namespace string,
+	decryption *vaultv1.PKCS11Decryption,
+	encrypted []string,
+) ([]string, error) {
+	args := []string{"--decrypt"}
+	switch decryption.Mechanism {
+	case "", PKCS11MechanismOAEP:
+		args = append(args, "--mechanism", PKCS11MechanismOAEP, "--hash-algorithm", "SHA256", "--mgf", "MGF1-SHA256")
+	case PKCS11MechanismPKCS:
+		args = append(args, "--mechanism", PKCS11MechanismPKCS)
+	default:
+		return nil, &pkcs11Error{module: decryption.Token.Module,
+			err: fmt.Errorf("unsupported mechanism %q", decryption.Mechanism)}
+	}
+	if decryption.KeyLabel != "" {
+		args = append(args, "--label", decryption.KeyLabel)
+	}
+	if decryption.KeyID != "" {
+		args = append(args, "--id", decryption.KeyID)
+	}
+
+	keys := make([]string, len(encrypted))
+	for i, key := range encrypted {
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+		if err != nil {
+			return nil, &keyDecryptionError{method: keyDecryptionPKCS11, err: fmt.Errorf("key %d is not base64: %w", i, err)}
+		}
+		out, err := r.runPKCS11Tool(ctx, namespace, &decryption.Token, ciphertext, args...)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		if keys[i] = strings.TrimSpace(string(out)); keys[i] == "" {
+			return nil, &pkcs11Error{module: decryption.Token.Module, err: fmt.Errorf("key %d decrypted to nothing", i)}
+		}
+	}
+	return keys, nil
+}
+
+// runPKCS11Tool logs in to token and runs one pkcs11-tool operation, writing
+// stdin to the tool and returning its output. Only modules listed in
+// PKCS11Modules are loaded, as loading a module runs its code.
+func (r *VaultUnsealConfigReconciler) runPKCS11Tool(
+	ctx context.Context,
+	namespace string,
+	token *vaultv1.PKCS11Token,
+	stdin []byte,
+	args ...string,
+) ([]byte, error) {
+	pin, err := r.pkcs11PIN(ctx, namespace, token)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := DefaultKeyExecTimeout
+	if token.Timeout != nil && token.Timeout.Duration > 0 {
+		timeout = token.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	argv := []string{"--module", token.Module, "--login", "--pin", "env:" + pkcs11PINEnv}
+	if token.Slot != nil {
+		argv = append(argv, "--slot", strconv.FormatInt(*token.Slot, 10))
+	}
+	if token.TokenLabel != "" {
+		argv = append(argv, "--token-label", token.TokenLabel)
+	}
+	cmd := exec.CommandContext(ctx, r.pkcs11Tool(), append(argv, args...)...)
+	// Like exec key sources, the tool only sees keyExecPassEnv of the operator
+	cmd.Env = keyExecEnv(nil, pkcs11PINEnv+"="+pin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = keyExecWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		// pkcs11-tool reports the failing call last, after the slot it used
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if line := strings.TrimSpace(lines[len(lines)-1]); line != "" {
+			err = fmt.Errorf("%w: %s", err, line)
+		}
+		return nil, &pkcs11Error{module: token.Module, err: err}
+	}
+	return stdout.Bytes(), nil
+}
+
+// pkcs11PIN checks that the module of token is allowed and returns its PIN.
+func (r *VaultUnsealConfigReconciler) pkcs11PIN(
+	ctx context.Context,
+	namespace string,
+	token *vaultv1.PKCS11Token,
+) (string, error) {
+	if !slices.Contains(r.PKCS11Modules, token.Module) {
+		return "", &pkcs11Error{module: token.Module, err: errors.New("module is not allowed by --pkcs11-modules")}
+	}
+	if r.Client == nil {
+		return "", errors.New("pkcs11 PIN secret requires a Kubernetes client")
+	}
+	pin, err := readSecretKey(ctx, r.Client, namespace, token.PINSecretRef)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(pin)), nil
+}
+
+// pkcs11Tool returns the pkcs11-tool command to run.
+func (r *VaultUnsealConfigReconciler) pkcs11Tool() string {
+	if r.PKCS11Tool == "" {
+		return DefaultPKCS11Tool
+	}
+	return r.PKCS11Tool
+}
+
+// checkPKCS11Token checks that the module of token is allowed and installed,
+// pkcs11-tool is installed and the PIN can be read. The token is not accessed.
+func (r *VaultUnsealConfigReconciler) checkPKCS11Token(
+	ctx context.Context,
+	namespace string,
+	token *vaultv1.PKCS11Token,
+) error {
+	if _, err := r.pkcs11PIN(ctx, namespace, token); err != nil {
+		return err
+	}
+	if _, err := os.Stat(token.Module); err != nil {
+		return &pkcs11Error{module: token.Module, err: errors.New("module not found")}
+	}
+	if _, err := exec.LookPath(r.pkcs11Tool()); err != nil {
+		return &pkcs11Error{module: token.Module, err: fmt.Errorf("%s not found", r.pkcs11Tool())}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fakePKCS11Tool records its arguments next to itself, logs in with PIN 1234,
+// reads data objects as key-<label> and decrypts by prefixing plain-.
+const fakePKCS11Tool = `
+echo "$*" >"$0.args"
+env >"$0.env"
+if [ "$VAULT_AUTOUNSEAL_PKCS11_PIN" != 1234 ]; then
+  echo 'Using slot 0 with a present token (0x0)' >&2
+  echo 'error: PKCS11 function C_Login failed: rv = CKR_PIN_INCORRECT (0xa0)' >&2
+  exit 1
+fi
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --read-object) op=read ;;
+    --decrypt) op=decrypt ;;
+    --label) label=$2; shift ;;
+  esac
+  shift
+done
+case $op in
+  read) printf '%s\n' "key-$label" ;;
+  decrypt) printf 'plain-'; cat ;;
+esac
+`
+
+// newPKCS11Reconciler returns a reconciler running the fake pkcs11-tool with
+// an allowed module and the PIN Secret hsm/pin.
+func newPKCS11Reconciler(t *testing.T) (*VaultUnsealConfigReconciler, string) {
+	module := filepath.Join(t.TempDir(), "libsofthsm2.so")
+	require.NoError(t, os.WriteFile(module, nil, 0o600))
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hsm", Namespace: "vault"},
+		Data:       map[string][]byte{"pin": []byte("1234\n"), "wrong": []byte("0000")},
+	}).Build()
+	r := NewVaultUnsealConfigReconciler(c, log.Log, nil, nil, nil)
+	r.PKCS11Tool = writeKeyCommand(t, fakePKCS11Tool)
+	r.PKCS11Modules = []string{module}
+	return r, module
+}
+
+func TestPKCS11KeySource(t *testing.T) {
+	t.Setenv("OPERATOR_SECRET", "s3cr3t")
+	r, module := newPKCS11Reconciler(t)
+	slot := int64(2)
+	instance := &vaultv1.VaultInstance{
+		Name: "vault",
+		KeySource: &vaultv1.KeySource{PKCS11: &vaultv1.PKCS11KeySource{
+			Token: vaultv1.PKCS11Token{
+				Module:       module,
+				Slot:         &slot,
+				PINSecretRef: vaultv1.SecretKeyRef{Name: "hsm", Key: "pin"},
+			},
+			Objects: []string{"unseal-0", "unseal-1"},
+		}},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key-unseal-0", "key-unseal-1"}, keys)
+
+	// The PIN is passed in the environment, never in the arguments
+	args, err := os.ReadFile(r.PKCS11Tool + ".args")
+	require.NoError(t, err)
+	assert.Equal(t, "--module "+module+" --login --pin env:VAULT_AUTOUNSEAL_PKCS11_PIN --slot 2 "+
+		"--read-object --type data --label unseal-1\n", string(args))
+	// The tool gets the PIN but not the rest of the operator's environment
+	env, err := os.ReadFile(r.PKCS11Tool + ".env")
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "VAULT_AUTOUNSEAL_PKCS11_PIN=1234")
+	assert.NotContains(t, string(env), "OPERATOR_SECRET")
+	require.NoError(t, r.checkKeySource(t.Context(), "vault", instance))
+
+	instance.KeySource.PKCS11.Token.PINSecretRef.Key = "wrong"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 1: error: PKCS11 function C_Login failed")
+	assert.Equal(t, ReasonPKCS11Failed, classifyError(err))
+
+	// Modules must be allowed by the operator
+	r.PKCS11Modules = nil
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+	assert.Equal(t, ReasonPKCS11Failed, classifyError(err))
+	assert.Error(t, r.checkKeySource(t.Context(), "vault", instance))
+}
+
+func TestPKCS11Decryption(t *testing.T) {
+	r, module := newPKCS11Reconciler(t)
+	instance := &vaultv1.VaultInstance{
+		Name: "vault",
+		UnsealKeys: []string{
+			base64.StdEncoding.EncodeToString([]byte("share-1")),
+			base64.StdEncoding.EncodeToString([]byte("share-2")),
+		},
+		PKCS11Decryption: &vaultv1.PKCS11Decryption{
+			Token: vaultv1.PKCS11Token{
+				Module:       module,
+				TokenLabel:   "vault",
+				PINSecretRef: vaultv1.SecretKeyRef{Name: "hsm", Key: "pin"},
+			},
+			KeyLabel: "unseal",
+		},
+	}
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plain-share-1", "plain-share-2"}, keys)
+
+	args, err := os.ReadFile(r.PKCS11Tool + ".args")
+	require.NoError(t, err)
+	assert.Equal(t, "--module "+module+" --login --pin env:VAULT_AUTOUNSEAL_PKCS11_PIN --token-label vault "+
+		"--decrypt --mechanism RSA-PKCS-OAEP --hash-algorithm SHA256 --mgf MGF1-SHA256 --label unseal\n", string(args))
+	require.NoError(t, r.checkKeySource(t.Context(), "vault", instance))
+
+	instance.PKCS11Decryption.Mechanism = PKCS11MechanismPKCS
+	instance.PKCS11Decryption.KeyLabel = ""
+	instance.PKCS11Decryption.KeyID = "01"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	args, err = os.ReadFile(r.PKCS11Tool + ".args")
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--decrypt --mechanism RSA-PKCS --id 01\n")
+
+	instance.UnsealKeys = []string{"not base64!"}
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonKeyDecryptionFailed, classifyError(err))
+
+	// A missing module fails the self-test before the token is used
+	instance.PKCS11Decryption.Token.Module = filepath.Join(t.TempDir(), "missing.so")
+	r.PKCS11Modules = append(r.PKCS11Modules, instance.PKCS11Decryption.Token.Module)
+	err = r.checkKeySource(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "module not found")
+}
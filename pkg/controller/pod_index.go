@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// vaultConfigPodIndex indexes VaultUnsealConfigs by the pods their instances may
+// run in, so pod and StatefulSet events are mapped to configs without listing
+// every config.
+const vaultConfigPodIndex = "vault.io/pod"
+
+// indexVaultConfigPods returns the pod index values of a VaultUnsealConfig:
+// the pod IP or pod DNS name each endpoint addresses, the StatefulSet each
+// instance discovers its pods from, and the namespace each instance watches for
+// label-matched pods, the config's own unless the instance sets one.
+func indexVaultConfigPods(obj client.Object) []string {
+	config, ok := obj.(*vaultv1.VaultUnsealConfig)
+	if !ok {
+		return nil
+	}
+
+	values := sets.New[string]()
+	for _, instance := range config.Spec.VaultInstances {
+		if value := endpointPodIndexValue(instance.Endpoint); value != "" {
+			values.Insert(value)
+		}
+		if instance.Discovery != nil && instance.Discovery.StatefulSet != "" {
+			values.Insert(statefulSetIndexValue(instanceNamespace(config.Namespace, &instance), instance.Discovery.StatefulSet))
+		}
+		// bank-vaults runs a Vault resource as a StatefulSet of the same name
+		if instance.BankVaults != nil {
+			values.Insert(statefulSetIndexValue(config.Namespace, instance.BankVaults.Name))
+		}
+		values.Insert("namespace:" + instanceNamespace(config.Namespace, &instance))
+	}
+	return sets.List(values)
+}
+
+// endpointPodIndexValue identifies the pod an endpoint addresses directly, either
+// by IP or by its stable DNS name such as vault-0.vault-internal.vault.svc.
+func endpointPodIndexValue(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		return "ip:" + ip.String()
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	for i := 3; i < len(labels); i++ {
+		if labels[i] == "svc" {
+			return "pod:" + labels[i-1] + "/" + labels[0]
+		}
+	}
+	return ""
+}
+
+// statefulSetIndexValue identifies the pods of a StatefulSet.
+func statefulSetIndexValue(namespace, name string) string {
+	return "statefulset:" + namespace + "/" + name
+}
+
+// podIndexValues returns the index values under which configs interested in pod are found.
+func podIndexValues(pod *corev1.Pod) []string {
+	values := []string{
+		"pod:" + pod.Namespace + "/" + pod.Name,
+		"namespace:" + pod.Namespace,
+	}
+	if pod.Spec.Hostname != "" && pod.Spec.Hostname != pod.Name {
+		values = append(values, "pod:"+pod.Namespace+"/"+pod.Spec.Hostname)
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "StatefulSet" {
+		values = append(values, statefulSetIndexValue(pod.Namespace, owner.Name))
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		if ip := net.ParseIP(podIP.IP); ip != nil {
+			values = append(values, "ip:"+ip.String())
+		}
+	}
+	return values
+}
+
+// indexVaultConfigsForPods registers vaultConfigPodIndex with the manager cache.
+func indexVaultConfigsForPods(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &vaultv1.VaultUnsealConfig{}, vaultConfigPodIndex, indexVaultConfigPods)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestEndpointPodIndexValue(t *testing.T) {
+	assert.Equal(t, "pod:vault/vault-0", endpointPodIndexValue("https://vault-0.vault-internal.vault.svc:8200"))
+	assert.Equal(t, "pod:vault/vault-0",
+		endpointPodIndexValue("https://Vault-0.vault-internal.vault.svc.cluster.local:8200"))
+	assert.Equal(t, "ip:10.0.0.5", endpointPodIndexValue("http://10.0.0.5:8200"))
+	assert.Equal(t, "ip:fd00::5", endpointPodIndexValue("http://[fd00:0:0::5]:8200"))
+	assert.Empty(t, endpointPodIndexValue("https://vault.vault.svc:8200"), "services do not address a pod")
+	assert.Empty(t, endpointPodIndexValue("https://vault.example.com"))
+}
+
+func TestFindVaultConfigsForPodUsesIndex(t *testing.T) {
+	byDNS := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "by-dns", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "https://vault-0.vault-internal.vault.svc:8200", Namespace: "elsewhere"},
+		}},
+	}
+	byIP := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "by-ip", Namespace: "ops"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://10.1.0.7:8200", Namespace: "elsewhere"},
+		}},
+	}
+	byIPv6 := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "by-ipv6", Namespace: "ops"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://[fd00:10::7]:8200", Namespace: "elsewhere"},
+		}},
+	}
+	bySelector := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "by-selector", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name: "vault", Endpoint: "https://vault.vault.svc:8200", Namespace: "vault",
+			PodSelector: map[string]string{"app.kubernetes.io/name": "vault"},
+		}}},
+	}
+	// Instances without a namespace watch the namespace of their config
+	configNamespace := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-namespace", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault", Endpoint: "https://vault.vault.svc:8200"},
+		}},
+	}
+	otherConfigNamespace := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-config-namespace", Namespace: "dr"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault", Endpoint: "https://vault.dr.svc:8200"},
+		}},
+	}
+	assert.Equal(t, []string{"namespace:dr"}, indexVaultConfigPods(otherConfigNamespace))
+	otherNamespace := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "dr"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault", Endpoint: "https://vault.dr.svc:8200", Namespace: "dr"},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithIndex(&vaultv1.VaultUnsealConfig{}, vaultConfigPodIndex, indexVaultConfigPods).
+		WithObjects(byDNS, byIP, byIPv6, bySelector, configNamespace, otherConfigNamespace, otherNamespace).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "vault-0", Namespace: "vault",
+			Labels: map[string]string{"app.kubernetes.io/name": "vault"},
+		},
+		// Dual-stack pods list an address of each family
+		Status: corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.1.0.7"}, {IP: "fd00:10:0::7"}}},
+	}
+	requests := r.findVaultConfigsForPod(context.Background(), pod)
+
+	names := make([]string, 0, len(requests))
+	for _, request := range requests {
+		names = append(names, request.Namespace+"/"+request.Name)
+	}
+	require.Len(t, names, 5)
+	assert.ElementsMatch(t, []string{
+		"vault/by-dns", "ops/by-ip", "ops/by-ipv6", "vault/by-selector", "vault/config-namespace",
+	}, names)
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/portforward,verbs=create
+
+// PodDialer opens connections to a port of a pod.
+type PodDialer interface {
+	DialPod(ctx context.Context, namespace, pod string, port int) (net.Conn, error)
+}
+
+// spdyPodDialer forwards connections through the pods/portforward subresource.
+type spdyPodDialer struct {
+	config *rest.Config
+	client rest.Interface
+}
+
+// NewPodDialer returns a PodDialer using the API server of config.
+func NewPodDialer(config *rest.Config) (PodDialer, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return &spdyPodDialer{config: config, client: clientset.CoreV1().RESTClient()}, nil
+}
+
+// DialPod implements PodDialer. Every connection has its own port-forward,
+// which is torn down when the connection is closed.
+func (d *spdyPodDialer) DialPod(ctx context.Context, namespace, pod string, port int) (net.Conn, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(d.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+	req := d.client.Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	// The upgrade does not observe ctx; close the connection if ctx ends first
+	type result struct {
+		conn httpstream.Connection
+		err  error
+	}
+	dialed := make(chan result, 1)
+	go func() {
+		conn, protocol, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+		if err == nil && protocol != portforward.PortForwardProtocolV1Name {
+			_ = conn.Close()
+			err = fmt.Errorf("unable to negotiate port-forward protocol, server returned %q", protocol)
+		}
+		dialed <- result{conn, err}
+	}()
+	var streamConn httpstream.Connection
+	select {
+	case r := <-dialed:
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to port-forward to pod %s/%s: %w", namespace, pod, r.err)
+		}
+		streamConn = r.conn
+	case <-ctx.Done():
+		go func() {
+			if r := <-dialed; r.conn != nil {
+				_ = r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+
+	conn, err := newPortForwardConn(streamConn, fmt.Sprintf("%s/%s:%d", namespace, pod, port), port)
+	if err != nil {
+		_ = streamConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// portForwardConn is a net.Conn over the data stream of a port-forward. Errors
+// the kubelet reports on the error stream, such as nothing listening on the
+// port, are returned by Read once the data stream ends.
+type portForwardConn struct {
+	streamConn httpstream.Connection
+	data       httpstream.Stream
+	address    podAddr
+
+	closeOnce sync.Once
+	errDone   chan struct{}
+	err       error
+}
+
+// newPortForwardConn opens the error and data streams of port on streamConn,
+// as kubectl port-forward does for each connection.
+func newPortForwardConn(streamConn httpstream.Connection, address string, port int) (*portForwardConn, error) {
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error stream: %w", err)
+	}
+	// Nothing is written to the error stream
+	_ = errorStream.Close()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	data, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data stream: %w", err)
+	}
+
+	c := &portForwardConn{streamConn: streamConn, data: data, address: podAddr(address), errDone: make(chan struct{})}
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			c.err = fmt.Errorf("failed to read port-forward error stream: %w", err)
+		case len(message) > 0:
+			c.err = fmt.Errorf("port-forward to %s failed: %s", address, strings.TrimSpace(string(message)))
+		}
+		close(c.errDone)
+	}()
+	return c, nil
+}
+
+// Read implements net.Conn.
+func (c *portForwardConn) Read(b []byte) (int, error) {
+	n, err := c.data.Read(b)
+	if errors.Is(err, io.EOF) {
+		// The error stream closes shortly after a failed data stream
+		select {
+		case <-c.errDone:
+			if c.err != nil {
+				return n, c.err
+			}
+		case <-time.After(time.Second):
+		}
+	}
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *portForwardConn) Write(b []byte) (int, error) {
+	return c.data.Write(b)
+}
+
+// Close implements net.Conn by tearing down the port-forward.
+func (c *portForwardConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		_ = c.data.Close()
+		err = c.streamConn.Close()
+	})
+	return err
+}
+
+// LocalAddr implements net.Conn.
+func (c *portForwardConn) LocalAddr() net.Addr { return c.address }
+
+// RemoteAddr implements net.Conn.
+func (c *portForwardConn) RemoteAddr() net.Addr { return c.address }
+
+// SetDeadline implements net.Conn; streams have no deadlines, requests are
+// bounded by their context and the client timeout instead.
+func (c *portForwardConn) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn.
+func (c *portForwardConn) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn.
+func (c *portForwardConn) SetWriteDeadline(time.Time) error { return nil }
+
+// podAddr is the namespace/pod:port a port-forward connects to.
+type podAddr string
+
+// Network implements net.Addr.
+func (a podAddr) Network() string { return "portforward" }
+
+// String implements net.Addr.
+func (a podAddr) String() string { return string(a) }
+
+// portForwardDialer returns a dial function connecting every address to the
+// same port of pod. The endpoint host is still used for TLS verification.
+func portForwardDialer(
+	dialer PodDialer, namespace, pod string,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		_, portName, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %q: %w", addr, err)
+		}
+		return dialer.DialPod(ctx, namespace, pod, port)
+	}
+}
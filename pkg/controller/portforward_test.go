@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// localPodDialer forwards every pod port to a local listener
+type localPodDialer struct {
+	address string
+	dialed  []string
+}
+
+func (d *localPodDialer) DialPod(ctx context.Context, namespace, pod string, port int) (net.Conn, error) {
+	d.dialed = append(d.dialed, fmt.Sprintf("%s/%s:%d", namespace, pod, port))
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", d.address)
+}
+
+func TestPortForwardAccessReachesPod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"sealed":true,"t":3,"n":5}`)
+	}))
+	defer server.Close()
+
+	dialer := &localPodDialer{address: server.Listener.Addr().String()}
+	repository := NewDefaultVaultClientRepository(nil)
+	repository.SetPodDialer(dialer)
+	defer func() { _ = repository.Close() }()
+
+	// The pod has no Service, so its name does not resolve
+	instance := &vaultv1.VaultInstance{
+		Name:      "vault-0",
+		Endpoint:  "http://vault-0.invalid:8200",
+		Namespace: "vault",
+		Access:    &vaultv1.InstanceAccess{Mode: vaultv1.AccessModePortForward, Pod: "vault-0"},
+	}
+	client, err := repository.GetClient(t.Context(), "vault/vault-0", instance)
+	require.NoError(t, err)
+
+	sealed, err := client.IsSealed(t.Context())
+	require.NoError(t, err)
+	assert.True(t, sealed)
+	require.NotEmpty(t, dialer.dialed)
+	assert.Equal(t, "vault/vault-0:8200", dialer.dialed[0])
+
+	// Without a dialer the mode is unavailable
+	_, err = NewDefaultVaultClientRepository(nil).GetClient(t.Context(), "vault/vault-0", instance)
+	assert.Error(t, err)
+}
+
+// fakeStream is a port-forward stream reading from a fixed buffer
+type fakeStream struct {
+	io.Reader
+	headers http.Header
+	written bytes.Buffer
+	closed  bool
+}
+
+func (s *fakeStream) Write(b []byte) (int, error) { return s.written.Write(b) }
+func (s *fakeStream) Close() error                { s.closed = true; return nil }
+func (s *fakeStream) Reset() error                { return s.Close() }
+func (s *fakeStream) Headers() http.Header        { return s.headers }
+func (s *fakeStream) Identifier() uint32          { return 0 }
+
+// fakeStreamConnection hands out streams whose content depends on their type
+type fakeStreamConnection struct {
+	content map[string]string
+	streams map[string]*fakeStream
+	closed  bool
+}
+
+func (c *fakeStreamConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	streamType := headers.Get(corev1.StreamType)
+	stream := &fakeStream{Reader: strings.NewReader(c.content[streamType]), headers: headers.Clone()}
+	c.streams[streamType] = stream
+	return stream, nil
+}
+func (c *fakeStreamConnection) Close() error                       { c.closed = true; return nil }
+func (c *fakeStreamConnection) CloseChan() <-chan bool             { return nil }
+func (c *fakeStreamConnection) SetIdleTimeout(time.Duration)       {}
+func (c *fakeStreamConnection) RemoveStreams(...httpstream.Stream) {}
+
+func TestPortForwardConn(t *testing.T) {
+	streamConn := &fakeStreamConnection{
+		content: map[string]string{corev1.StreamTypeData: "HTTP/1.1 200 OK\r\n\r\n"},
+		streams: map[string]*fakeStream{},
+	}
+	conn, err := newPortForwardConn(streamConn, "vault/vault-0:8200", 8200)
+	require.NoError(t, err)
+	assert.Equal(t, "8200", streamConn.streams[corev1.StreamTypeData].headers.Get(corev1.PortHeader))
+	assert.True(t, streamConn.streams[corev1.StreamTypeError].closed, "nothing is sent on the error stream")
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "GET / HTTP/1.1\r\n\r\n", streamConn.streams[corev1.StreamTypeData].written.String())
+	body, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n\r\n", string(body))
+
+	// Closing the connection tears down the port-forward
+	require.NoError(t, conn.Close())
+	assert.True(t, streamConn.closed)
+
+	// Errors reported by the kubelet surface when the data stream ends
+	streamConn = &fakeStreamConnection{
+		content: map[string]string{corev1.StreamTypeError: "error forwarding port 8200: connection refused"},
+		streams: map[string]*fakeStream{},
+	}
+	conn, err = newPortForwardConn(streamConn, "vault/vault-0:8200", 8200)
+	require.NoError(t, err)
+	_, err = conn.Read(make([]byte, 16))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+}
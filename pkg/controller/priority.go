@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// SealedPriority is the queue priority of configs with sealed or failing
+// instances; periodic verification of healthy configs uses the default of 0.
+const SealedPriority = 100
+
+// sealedConfigs tracks which configs had sealed or failing instances when they
+// were last reconciled. The zero value is ready to use.
+type sealedConfigs struct {
+	mu      sync.RWMutex
+	configs map[types.NamespacedName]bool
+}
+
+// set records whether the config has sealed instances.
+func (s *sealedConfigs) set(key types.NamespacedName, sealed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !sealed {
+		delete(s.configs, key)
+		return
+	}
+	if s.configs == nil {
+		s.configs = make(map[types.NamespacedName]bool)
+	}
+	s.configs[key] = true
+}
+
+// has reports whether the config had sealed instances when last reconciled.
+func (s *sealedConfigs) has(key types.NamespacedName) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.configs[key]
+}
+
+// sealedPriorityQueue is a priority queue that moves configs known to be sealed
+// ahead of everything else, whatever the priority of the event that queued them.
+type sealedPriorityQueue struct {
+	priorityqueue.PriorityQueue[reconcile.Request]
+	sealed *sealedConfigs
+}
+
+// newSealedPriorityQueue returns a NewQueue function for controller.Options.
+func newSealedPriorityQueue(
+	sealed *sealedConfigs,
+	logger logr.Logger,
+) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(
+		name string,
+		rateLimiter workqueue.TypedRateLimiter[reconcile.Request],
+	) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		queue := priorityqueue.New(name, func(o *priorityqueue.Opts[reconcile.Request]) {
+			o.Log = logger.WithValues("controller", name)
+			o.RateLimiter = rateLimiter
+		})
+		return &sealedPriorityQueue{PriorityQueue: queue, sealed: sealed}
+	}
+}
+
+// AddWithOpts adds items, raising the priority of configs known to be sealed.
+func (q *sealedPriorityQueue) AddWithOpts(o priorityqueue.AddOpts, items ...reconcile.Request) {
+	for _, item := range items {
+		opts := o
+		if q.sealed.has(item.NamespacedName) && opts.Priority < SealedPriority {
+			opts.Priority = SealedPriority
+		}
+		q.PriorityQueue.AddWithOpts(opts, item)
+	}
+}
+
+// Add adds an item at its tracked priority.
+func (q *sealedPriorityQueue) Add(item reconcile.Request) {
+	q.AddWithOpts(priorityqueue.AddOpts{}, item)
+}
+
+// AddAfter adds an item at its tracked priority once the delay has passed.
+func (q *sealedPriorityQueue) AddAfter(item reconcile.Request, after time.Duration) {
+	q.AddWithOpts(priorityqueue.AddOpts{After: after}, item)
+}
+
+// AddRateLimited adds an item at its tracked priority after its rate limit delay.
+func (q *sealedPriorityQueue) AddRateLimited(item reconcile.Request) {
+	q.AddWithOpts(priorityqueue.AddOpts{RateLimited: true}, item)
+}
+
+// resumeInFlight marks configs that arrive with the initial list of a newly
+// started or elected operator as sealed when their status shows sealed, failing
+// or unchecked instances. The sealed set only lives in memory and is empty after
+// a failover; without this the initial list is queued at low priority and
+// in-flight configs wait behind every healthy one.
+func resumeInFlight(sealed *sealedConfigs, logger logr.Logger) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			vaultConfig, ok := e.Object.(*vaultv1.VaultUnsealConfig)
+			if e.IsInInitialList && ok && inFlight(vaultConfig) {
+				logger.V(1).Info("Resuming in-flight config", "config", client.ObjectKeyFromObject(vaultConfig))
+				sealed.set(client.ObjectKeyFromObject(vaultConfig), true)
+			}
+			return true
+		},
+	}
+}
+
+// inFlight reports whether the recorded status of vaultConfig has enabled
+// instances that are sealed or failing, or has not been written yet.
+func inFlight(vaultConfig *vaultv1.VaultUnsealConfig) bool {
+	if len(vaultConfig.Status.VaultStatuses) == 0 {
+		return len(vaultConfig.Spec.VaultInstances) > 0
+	}
+	for i := range vaultConfig.Status.VaultStatuses {
+		status := &vaultConfig.Status.VaultStatuses[i]
+		if (status.Sealed || status.Error != "") && !instanceDisabled(status) {
+			return true
+		}
+	}
+	return false
+}
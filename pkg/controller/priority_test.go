@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSealedConfigsAreQueuedFirst(t *testing.T) {
+	var sealed sealedConfigs
+	healthy := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "vault", Name: "healthy"}}
+	sealedReq := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "vault", Name: "sealed"}}
+	recovered := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "vault", Name: "recovered"}}
+	sealed.set(sealedReq.NamespacedName, true)
+	sealed.set(recovered.NamespacedName, true)
+	sealed.set(recovered.NamespacedName, false)
+
+	newQueue := newSealedPriorityQueue(&sealed, log.Log)
+	queue := newQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	queue.Add(healthy)
+	queue.Add(recovered)
+	queue.Add(sealedReq)
+
+	pq, ok := queue.(priorityqueue.PriorityQueue[reconcile.Request])
+	require.True(t, ok, "the controller must see a priority queue")
+
+	item, priority, _ := pq.GetWithPriority()
+	assert.Equal(t, sealedReq, item)
+	assert.Equal(t, SealedPriority, priority)
+	pq.Done(item)
+
+	item, priority, _ = pq.GetWithPriority()
+	assert.Equal(t, healthy, item, "other configs keep their queue order")
+	assert.Equal(t, 0, priority)
+	pq.Done(item)
+}
+
+func TestResumeInFlightAfterFailover(t *testing.T) {
+	newConfig := func(name string, statuses ...vaultv1.VaultInstanceStatus) *vaultv1.VaultUnsealConfig {
+		return &vaultv1.VaultUnsealConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "vault", Name: name},
+			Spec:       vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{Name: "vault-0"}}},
+			Status:     vaultv1.VaultUnsealConfigStatus{VaultStatuses: statuses},
+		}
+	}
+	healthy := newConfig("healthy", vaultv1.VaultInstanceStatus{Name: "vault-0"})
+	sealedConfig := newConfig("sealed", vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true})
+	failing := newConfig("failing", vaultv1.VaultInstanceStatus{Name: "vault-0", Error: "connection refused"})
+	unchecked := newConfig("unchecked")
+	disabled := newConfig("disabled", vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true,
+		Conditions: []metav1.Condition{{Type: ConditionTypeDisabled, Status: metav1.ConditionTrue}}})
+
+	var sealed sealedConfigs
+	resume := resumeInFlight(&sealed, log.Log)
+	for _, config := range []*vaultv1.VaultUnsealConfig{healthy, sealedConfig, failing, unchecked, disabled} {
+		assert.True(t, resume.Create(event.CreateEvent{Object: config, IsInInitialList: true}))
+	}
+	created := newConfig("created")
+	assert.True(t, resume.Create(event.CreateEvent{Object: created}))
+
+	key := func(config *vaultv1.VaultUnsealConfig) types.NamespacedName {
+		return types.NamespacedName{Namespace: config.Namespace, Name: config.Name}
+	}
+	assert.True(t, sealed.has(key(sealedConfig)))
+	assert.True(t, sealed.has(key(failing)))
+	assert.True(t, sealed.has(key(unchecked)))
+	assert.False(t, sealed.has(key(healthy)))
+	assert.False(t, sealed.has(key(disabled)), "disabled instances are not in flight")
+	assert.False(t, sealed.has(key(created)), "only the initial list is resumed")
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// HealthDetailsPath serves the JSON summary of the configs on the probe server.
+const HealthDetailsPath = "/healthz/details"
+
+// HealthDetails summarizes the configs for uptime checks and smoke tests.
+type HealthDetails struct {
+	// Configs is the number of VaultUnsealConfigs
+	Configs int `json:"configs"`
+	// ReadyConfigs is the number of configs whose instances are all unsealed
+	ReadyConfigs int `json:"readyConfigs"`
+	// DegradedConfigs is the number of configs in the Degraded or Error phase
+	DegradedConfigs int `json:"degradedConfigs"`
+	// Instances is the number of vault instances across all configs
+	Instances int `json:"instances"`
+	// SealedInstances is the number of vault instances reported as sealed
+	SealedInstances int `json:"sealedInstances"`
+	// FailingInstances is the number of vault instances whose last check failed
+	FailingInstances int `json:"failingInstances"`
+}
+
+// summarizeHealth returns the health details of configs.
+func summarizeHealth(configs []vaultv1.VaultUnsealConfig) HealthDetails {
+	fleet := summarizeFleet(configs)
+	details := HealthDetails{
+		Configs:          fleet.TotalConfigs,
+		ReadyConfigs:     fleet.ReadyConfigs,
+		Instances:        fleet.TotalInstances,
+		SealedInstances:  fleet.SealedInstances,
+		FailingInstances: fleet.FailingInstances,
+	}
+	for i := range configs {
+		switch configs[i].Status.Phase {
+		case vaultv1.UnsealConfigPhaseDegraded, vaultv1.UnsealConfigPhaseError:
+			details.DegradedConfigs++
+		}
+	}
+	return details
+}
+
+// ProbeServer serves the liveness and readiness checks of the operator at
+// /healthz and /readyz, as the manager's probe server does, and the summary
+// of the configs at HealthDetailsPath.
+type ProbeServer struct {
+	reader client.Reader
+
+	mu      sync.Mutex
+	healthz map[string]healthz.Checker
+	readyz  map[string]healthz.Checker
+}
+
+// NewProbeServer returns a probe server reading the configs with reader.
+func NewProbeServer(reader client.Reader) *ProbeServer {
+	return &ProbeServer{
+		reader:  reader,
+		healthz: make(map[string]healthz.Checker),
+		readyz:  make(map[string]healthz.Checker),
+	}
+}
+
+// AddHealthzCheck adds a liveness check. Checks must be added before Handler is called.
+func (s *ProbeServer) AddHealthzCheck(name string, check healthz.Checker) error {
+	return s.addCheck(s.healthz, name, check)
+}
+
+// AddReadyzCheck adds a readiness check. Checks must be added before Handler is called.
+func (s *ProbeServer) AddReadyzCheck(name string, check healthz.Checker) error {
+	return s.addCheck(s.readyz, name, check)
+}
+
+func (s *ProbeServer) addCheck(checks map[string]healthz.Checker, name string, check healthz.Checker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := checks[name]; found {
+		return fmt.Errorf("check %q already exists", name)
+	}
+	checks[name] = check
+	return nil
+}
+
+// Handler returns the handler of the probe endpoints.
+func (s *ProbeServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for path, checks := range map[string]map[string]healthz.Checker{"/healthz": s.healthz, "/readyz": s.readyz} {
+		handler := http.StripPrefix(path, &healthz.Handler{Checks: checks})
+		mux.Handle(path, handler)
+		// Individual checks are served at subpaths
+		mux.Handle(path+"/", handler)
+	}
+	mux.HandleFunc(HealthDetailsPath, s.serveDetails)
+	return mux
+}
+
+func (s *ProbeServer) serveDetails(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var configs vaultv1.VaultUnsealConfigList
+	if err := s.reader.List(req.Context(), &configs); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unable to list VaultUnsealConfigs"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(summarizeHealth(configs.Items))
+}
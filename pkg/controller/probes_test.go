@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+func TestProbeServerHealthDetails(t *testing.T) {
+	config := func(name string, phase vaultv1.UnsealConfigPhase, ready metav1.ConditionStatus,
+		statuses ...vaultv1.VaultInstanceStatus) *vaultv1.VaultUnsealConfig {
+		instances := make([]vaultv1.VaultInstance, len(statuses))
+		for i, status := range statuses {
+			instances[i] = vaultv1.VaultInstance{Name: status.Name}
+		}
+		return &vaultv1.VaultUnsealConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "vault"},
+			Spec:       vaultv1.VaultUnsealConfigSpec{VaultInstances: instances},
+			Status: vaultv1.VaultUnsealConfigStatus{
+				Phase:         phase,
+				VaultStatuses: statuses,
+				Conditions:    []metav1.Condition{{Type: ConditionTypeReady, Status: ready}},
+			},
+		}
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(
+		config("ready", vaultv1.UnsealConfigPhaseReady, metav1.ConditionTrue,
+			vaultv1.VaultInstanceStatus{Name: "vault-0"}, vaultv1.VaultInstanceStatus{Name: "vault-1"}),
+		config("degraded", vaultv1.UnsealConfigPhaseDegraded, metav1.ConditionFalse,
+			vaultv1.VaultInstanceStatus{Name: "vault-0"},
+			vaultv1.VaultInstanceStatus{Name: "vault-1", Sealed: true, Error: "connection refused"}),
+		config("unsealing", vaultv1.UnsealConfigPhaseUnsealing, metav1.ConditionFalse,
+			vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true}),
+	).Build()
+
+	server := httptest.NewServer(NewProbeServer(k8sClient).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + HealthDetailsPath)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var details HealthDetails
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+	assert.Equal(t, HealthDetails{
+		Configs:          3,
+		ReadyConfigs:     1,
+		DegradedConfigs:  1,
+		Instances:        5,
+		SealedInstances:  2,
+		FailingInstances: 1,
+	}, details)
+}
+
+func TestProbeServerChecks(t *testing.T) {
+	probes := NewProbeServer(nil)
+	require.NoError(t, probes.AddHealthzCheck("healthz", healthz.Ping))
+	require.NoError(t, probes.AddReadyzCheck("readyz", healthz.Ping))
+	require.NoError(t, probes.AddReadyzCheck("self-test", func(*http.Request) error { return errors.New("not yet") }))
+	assert.EqualError(t, probes.AddReadyzCheck("readyz", healthz.Ping), `check "readyz" already exists`)
+
+	server := httptest.NewServer(probes.Handler())
+	defer server.Close()
+
+	for path, want := range map[string]int{
+		"/healthz":         http.StatusOK,
+		"/healthz/healthz": http.StatusOK,
+		"/readyz":          http.StatusInternalServerError,
+		"/readyz/readyz":   http.StatusOK,
+		"/readyz/missing":  http.StatusNotFound,
+	} {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, want, resp.StatusCode, path)
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// ConditionTypeQuarantined reports that instances of a config are quarantined
+// after Vault rejected their keys repeatedly. It is abnormal-true: only present
+// while true.
+const ConditionTypeQuarantined = "Quarantined"
+
+// defaultQuarantineAfter is the number of rejections in a row that quarantine an instance
+const defaultQuarantineAfter = 3
+
+// rejectedKeysError is returned when Vault rejected the submitted keys as
+// invalid. It carries their fingerprint to recognize the same keys later.
+type rejectedKeysError struct {
+	fingerprint string
+	err         error
+}
+
+func (e *rejectedKeysError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rejectedKeysError) Unwrap() error {
+	return e.err
+}
+
+// keysFingerprint identifies a set of keys independent of their order, which
+// shuffleKeys changes between attempts. Only a truncated hash is kept in status.
+func keysFingerprint(keys []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(slices.Sorted(slices.Values(keys)), "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// quarantinedKeys returns the fingerprint of the keys an instance is
+// quarantined with, or "" when its keys may be submitted. A spec change lifts
+// the quarantine.
+func quarantinedKeys(
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	previous *vaultv1.VaultInstanceStatus,
+	settings *unsealSettings,
+) string {
+	if !quarantined(previous, settings) || vaultConfig.Status.ObservedGeneration != vaultConfig.Generation {
+		return ""
+	}
+	return previous.RejectedKeysHash
+}
+
+// quarantined reports whether Vault rejected the keys of an instance often
+// enough in a row to stop submitting them.
+func quarantined(status *vaultv1.VaultInstanceStatus, settings *unsealSettings) bool {
+	return settings.QuarantineAfter > 0 && status != nil && status.RejectedKeysHash != "" &&
+		status.InvalidKeyFailures >= int32(settings.QuarantineAfter)
+}
+
+// recordInvalidKeys counts the checks in a row in which Vault rejected the
+// same keys of an instance. Rejections of other keys start the count over, an
+// unsealed instance or a spec change clears it, and other outcomes keep it.
+func recordInvalidKeys(status, previous *vaultv1.VaultInstanceStatus, err error, specChanged bool) {
+	var rejected *rejectedKeysError
+	switch {
+	case errors.As(err, &rejected):
+		status.RejectedKeysHash = rejected.fingerprint
+		status.InvalidKeyFailures = 1
+		if previous != nil && !specChanged && previous.RejectedKeysHash == rejected.fingerprint {
+			status.InvalidKeyFailures = previous.InvalidKeyFailures + 1
+		}
+	case previous != nil && !specChanged && status.Sealed:
+		status.RejectedKeysHash = previous.RejectedKeysHash
+		status.InvalidKeyFailures = previous.InvalidKeyFailures
+	}
+}
+
+// updateQuarantineCondition lists the quarantined instances.
+func updateQuarantineCondition(vaultConfig *vaultv1.VaultUnsealConfig) {
+	var names []string
+	for i := range vaultConfig.Status.VaultStatuses {
+		if status := &vaultConfig.Status.VaultStatuses[i]; status.Reason == ReasonKeysQuarantined {
+			names = append(names, status.Name)
+		}
+	}
+	setAbnormalCondition(vaultConfig, ConditionTypeQuarantined, len(names) > 0, ReasonKeysQuarantined,
+		fmt.Sprintf("Vault rejected the unseal keys repeatedly, update the keys or the spec: %s",
+			strings.Join(names, ", ")))
+}
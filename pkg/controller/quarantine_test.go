@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKeysFingerprintIgnoresOrder(t *testing.T) {
+	assert.Equal(t, keysFingerprint([]string{"a", "b", "c"}), keysFingerprint([]string{"c", "a", "b"}))
+	assert.NotEqual(t, keysFingerprint([]string{"a", "b"}), keysFingerprint([]string{"a", "c"}))
+	assert.Len(t, keysFingerprint([]string{"a"}), 16)
+}
+
+func TestRecordInvalidKeys(t *testing.T) {
+	rejected := func(fingerprint string) error {
+		return &rejectedKeysError{fingerprint: fingerprint, err: errors.New("invalid key")}
+	}
+
+	status := &vaultv1.VaultInstanceStatus{Sealed: true}
+	recordInvalidKeys(status, nil, rejected("aa"), false)
+	assert.Equal(t, int32(1), status.InvalidKeyFailures)
+
+	// The same keys count up, other keys start over
+	previous := status
+	status = &vaultv1.VaultInstanceStatus{Sealed: true}
+	recordInvalidKeys(status, previous, rejected("aa"), false)
+	assert.Equal(t, int32(2), status.InvalidKeyFailures)
+	other := &vaultv1.VaultInstanceStatus{Sealed: true}
+	recordInvalidKeys(other, status, rejected("bb"), false)
+	assert.Equal(t, int32(1), other.InvalidKeyFailures)
+	assert.Equal(t, "bb", other.RejectedKeysHash)
+
+	// Unrelated failures keep the count, an unseal or a spec change clears it
+	previous = status
+	status = &vaultv1.VaultInstanceStatus{Sealed: true}
+	recordInvalidKeys(status, previous, fmt.Errorf("connection refused"), false)
+	assert.Equal(t, int32(2), status.InvalidKeyFailures)
+	assert.Equal(t, "aa", status.RejectedKeysHash)
+	status = &vaultv1.VaultInstanceStatus{}
+	recordInvalidKeys(status, previous, nil, false)
+	assert.Zero(t, status.InvalidKeyFailures)
+	status = &vaultv1.VaultInstanceStatus{Sealed: true}
+	recordInvalidKeys(status, previous, nil, true)
+	assert.Empty(t, status.RejectedKeysHash)
+}
+
+func TestClassifyRejectedKeys(t *testing.T) {
+	err := &api.ResponseError{StatusCode: 400, Errors: []string{"failed to unseal: cipher: message authentication failed"}}
+	assert.Equal(t, ReasonInvalidKeys, classifyError(err))
+	assert.False(t, vaultRejectedKeys(&api.ResponseError{StatusCode: 500, Errors: []string{"invalid key"}}))
+}
+
+func TestReconcileQuarantinesRejectedKeys(t *testing.T) {
+	server := fakevault.New(t, fakevault.WithShares(3, 2))
+	quarantineAfter := int32(2)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", Generation: 1},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{
+				Name: "vault-0", Endpoint: server.URL(), UnsealKeys: []string{"a2V5MQ==", "a2V5Mg=="},
+			}},
+			Settings: &vaultv1.UnsealSettings{
+				QuarantineAfter: &quarantineAfter,
+				Retry:           &vaultv1.RetrySettings{Interval: &metav1.Duration{Duration: time.Millisecond}},
+			},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	var updated vaultv1.VaultUnsealConfig
+	reconcile := func() vaultv1.VaultInstanceStatus {
+		// Let the backoff of the previous failure run out
+		time.Sleep(10 * time.Millisecond)
+		_, err := r.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+		return updated.Status.VaultStatuses[0]
+	}
+
+	status := reconcile()
+	assert.Equal(t, ReasonInvalidKeys, status.Reason)
+	assert.Equal(t, int32(1), status.InvalidKeyFailures)
+	status = reconcile()
+	assert.Equal(t, int32(2), status.InvalidKeyFailures)
+	submitted := server.Requests("sys/unseal")
+
+	// Quarantined keys are read but not submitted again
+	status = reconcile()
+	assert.Equal(t, submitted, server.Requests("sys/unseal"))
+	assert.Equal(t, ReasonKeysQuarantined, status.Reason)
+	assert.Empty(t, status.Error)
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeQuarantined)
+	require.NotNil(t, condition)
+	assert.Contains(t, condition.Message, "vault-0")
+
+	// New keys lift the quarantine
+	updated.Spec.VaultInstances[0].UnsealKeys = server.Keys()
+	require.NoError(t, k8sClient.Update(t.Context(), &updated))
+	status = reconcile()
+	assert.False(t, server.Sealed())
+	assert.Zero(t, status.InvalidKeyFailures)
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeQuarantined))
+}
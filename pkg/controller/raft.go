@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionTypeRaftHealthy reports whether the Raft clusters of monitored instances are healthy.
+const ConditionTypeRaftHealthy = "RaftHealthy"
+
+// raftNodeAlive is the autopilot node status of a reachable peer.
+const raftNodeAlive = "alive"
+
+// RaftClientFactory creates Vault clients able to read the Raft autopilot state.
+type RaftClientFactory func(
+	endpoint string, tlsSkipVerify bool, token string, timeout time.Duration,
+) (vault.RaftClient, error)
+
+// DefaultRaftClientFactory creates a token-authenticated vault.Client.
+func DefaultRaftClientFactory(
+	endpoint string, tlsSkipVerify bool, token string, timeout time.Duration,
+) (vault.RaftClient, error) {
+	return vault.NewClientWithOptions(endpoint,
+		vault.WithTLSSkipVerify(tlsSkipVerify),
+		vault.WithTimeout(timeout),
+		vault.WithToken(token),
+	)
+}
+
+// raftStatus queries the autopilot state through instance. Failures are reported
+// in the returned status and never fail the unseal of the instance.
+func (r *VaultUnsealConfigReconciler) raftStatus(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) *vaultv1.RaftStatus {
+	token, err := readSecretKey(ctx, r.Client, namespace, instance.Raft.TokenSecretRef)
+	if err != nil {
+		return &vaultv1.RaftStatus{Error: err.Error()}
+	}
+
+	raftClient, err := r.RaftClientFactory(instance.Endpoint, skipTLSVerify(instance),
+		string(token), DefaultTimeoutSeconds*time.Second)
+	if err != nil {
+		return &vaultv1.RaftStatus{Error: fmt.Sprintf("failed to create vault client: %v", err)}
+	}
+	defer func() { _ = raftClient.Close() }()
+
+	state, err := raftClient.RaftAutopilotState(ctx)
+	if err != nil {
+		return &vaultv1.RaftStatus{Error: err.Error()}
+	}
+	return newRaftStatus(state)
+}
+
+// newRaftStatus converts the autopilot state into the instance status.
+func newRaftStatus(state *api.AutopilotState) *vaultv1.RaftStatus {
+	status := &vaultv1.RaftStatus{
+		Healthy:          state.Healthy,
+		FailureTolerance: state.FailureTolerance,
+		Leader:           state.Leader,
+		Voters:           len(state.Voters),
+		Servers:          make([]vaultv1.RaftServerStatus, 0, len(state.Servers)),
+	}
+
+	for id, server := range state.Servers {
+		if server == nil {
+			continue
+		}
+		if server.ID != "" {
+			id = server.ID
+		}
+		status.Servers = append(status.Servers, vaultv1.RaftServerStatus{
+			ID:          id,
+			Address:     server.Address,
+			Status:      server.Status,
+			NodeStatus:  server.NodeStatus,
+			Healthy:     server.Healthy,
+			LastContact: server.LastContact,
+		})
+		if server.NodeStatus != raftNodeAlive {
+			status.DeadServers = append(status.DeadServers, id)
+		}
+	}
+
+	sort.Slice(status.Servers, func(i, j int) bool { return status.Servers[i].ID < status.Servers[j].ID })
+	sort.Strings(status.DeadServers)
+	return status
+}
+
+// updateRaftCondition summarizes the Raft health of all monitored instances. The
+// condition is removed when no instance has Raft monitoring enabled.
+func (r *VaultUnsealConfigReconciler) updateRaftCondition(vaultConfig *vaultv1.VaultUnsealConfig) {
+	monitored := 0
+	var degraded []string
+	for _, status := range vaultConfig.Status.VaultStatuses {
+		if status.Raft == nil {
+			continue
+		}
+		monitored++
+
+		switch {
+		case status.Raft.Error != "":
+			degraded = append(degraded, fmt.Sprintf("%s: %s", status.Name, status.Raft.Error))
+		case !status.Raft.Healthy || len(status.Raft.DeadServers) > 0:
+			degraded = append(degraded, fmt.Sprintf("%s: %d dead servers (%s), failure tolerance %d",
+				status.Name, len(status.Raft.DeadServers), strings.Join(status.Raft.DeadServers, ", "),
+				status.Raft.FailureTolerance))
+		}
+	}
+
+	if monitored == 0 {
+		meta.RemoveStatusCondition(&vaultConfig.Status.Conditions, ConditionTypeRaftHealthy)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionTypeRaftHealthy,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonQuorumHealthy,
+		Message:            fmt.Sprintf("Raft is healthy on all %d monitored instances", monitored),
+		ObservedGeneration: vaultConfig.Generation,
+	}
+	if len(degraded) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonRaftDegraded
+		condition.Message = strings.Join(degraded, "; ")
+	}
+	meta.SetStatusCondition(&vaultConfig.Status.Conditions, condition)
+}
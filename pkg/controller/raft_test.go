@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeRaftClient struct {
+	state *api.AutopilotState
+	err   error
+}
+
+func (f *fakeRaftClient) RaftAutopilotState(context.Context) (*api.AutopilotState, error) {
+	return f.state, f.err
+}
+
+func (f *fakeRaftClient) Close() error { return nil }
+
+func TestRaftStatusReportsDeadServers(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "raft-token", Namespace: "vault"},
+		Data:       map[string][]byte{"token": []byte("s.raft")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(secret).Build()
+
+	raftClient := &fakeRaftClient{state: &api.AutopilotState{
+		Healthy:          false,
+		FailureTolerance: 0,
+		Leader:           "vault-0",
+		Voters:           []string{"vault-0", "vault-1", "vault-2"},
+		Servers: map[string]*api.AutopilotServer{
+			"vault-2": {ID: "vault-2", Address: "vault-2:8201", NodeStatus: "left", Status: "voter"},
+			"vault-0": {ID: "vault-0", Address: "vault-0:8201", NodeStatus: raftNodeAlive, Status: "leader", Healthy: true},
+			"vault-1": {ID: "vault-1", Address: "vault-1:8201", NodeStatus: raftNodeAlive, Status: "voter", Healthy: true},
+		},
+	}}
+	var usedToken string
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+	r.RaftClientFactory = func(_ string, _ bool, token string, _ time.Duration) (vault.RaftClient, error) {
+		usedToken = token
+		return raftClient, nil
+	}
+
+	instance := &vaultv1.VaultInstance{
+		Name:     "vault-0",
+		Endpoint: "https://vault-0:8200",
+		Raft:     &vaultv1.RaftMonitoring{TokenSecretRef: vaultv1.SecretKeyRef{Name: "raft-token", Key: "token"}},
+	}
+	status := r.raftStatus(context.Background(), "vault", instance)
+	assert.Equal(t, "s.raft", usedToken)
+	assert.Empty(t, status.Error)
+	assert.Equal(t, 3, status.Voters)
+	assert.Equal(t, "vault-0", status.Leader)
+	assert.Equal(t, []string{"vault-2"}, status.DeadServers)
+	require.Len(t, status.Servers, 3)
+	assert.Equal(t, "vault-0", status.Servers[0].ID, "servers are sorted")
+
+	config := &vaultv1.VaultUnsealConfig{Status: vaultv1.VaultUnsealConfigStatus{
+		VaultStatuses: []vaultv1.VaultInstanceStatus{{Name: "vault-0", Raft: status}, {Name: "standalone"}},
+	}}
+	r.updateRaftCondition(config)
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeRaftHealthy)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "Degraded", condition.Reason)
+	assert.Contains(t, condition.Message, "vault-2")
+
+	// Query failures are reported on the instance rather than failing the unseal
+	raftClient.err = errors.New("permission denied")
+	status = r.raftStatus(context.Background(), "vault", instance)
+	assert.Equal(t, "permission denied", status.Error)
+
+	// The condition disappears once monitoring is disabled everywhere
+	config.Status.VaultStatuses[0].Raft = nil
+	r.updateRaftCondition(config)
+	assert.Nil(t, meta.FindStatusCondition(config.Status.Conditions, ConditionTypeRaftHealthy))
+}
+
+func TestRaftConditionHealthy(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{}
+	config := &vaultv1.VaultUnsealConfig{Status: vaultv1.VaultUnsealConfigStatus{
+		VaultStatuses: []vaultv1.VaultInstanceStatus{
+			{Name: "vault-0", Raft: &vaultv1.RaftStatus{Healthy: true, FailureTolerance: 1, Voters: 3}},
+		},
+	}}
+	r.updateRaftCondition(config)
+
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeRaftHealthy)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "QuorumHealthy", condition.Reason)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// podStartupWindow is how long after its containers start a pod counts as
+	// newly started, so its port is probed before Vault is called
+	podStartupWindow = 2 * time.Minute
+	// portProbeAttempts bounds the port probes of a check
+	portProbeAttempts = 4
+	// portProbeDelay is the delay before the second probe, doubling after each
+	portProbeDelay = 250 * time.Millisecond
+	// portProbeTimeout bounds a single probe
+	portProbeTimeout = time.Second
+)
+
+// podStarting reports whether pod is newly (re)started: a container is not
+// running yet or has been running for less than podStartupWindow.
+func podStarting(pod *corev1.Pod, now time.Time) bool {
+	if pod.DeletionTimestamp != nil ||
+		pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return true
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		running := container.State.Running
+		if running == nil || now.Sub(running.StartedAt.Time) < podStartupWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointAddress returns the host and port an endpoint is dialed at, with the
+// default port of its scheme when it has none.
+func endpointAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// waitForPort probes whether address accepts TCP connections, up to attempts
+// times with a delay doubling after each probe. It returns the error of the
+// last probe when the port never opened.
+func waitForPort(ctx context.Context, address string, attempts int, delay time.Duration) error {
+	dialer := net.Dialer{Timeout: portProbeTimeout}
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		var conn net.Conn
+		if conn, err = dialer.DialContext(ctx, "tcp", address); err == nil {
+			_ = conn.Close()
+			return nil
+		}
+	}
+	return err
+}
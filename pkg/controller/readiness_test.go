@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStarting(t *testing.T) {
+	now := time.Now()
+	running := func(startedAt time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)},
+		}}
+	}
+	deleting := metav1.NewTime(now)
+
+	tests := []struct {
+		name string
+		pod  corev1.Pod
+		want bool
+	}{
+		{name: "no container status yet", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}, want: true},
+		{
+			name: "container waiting",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			}}}},
+			want: true,
+		},
+		{
+			name: "container restarted recently",
+			pod: corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				running(now.Add(-time.Hour)), running(now.Add(-10 * time.Second)),
+			}}},
+			want: true,
+		},
+		{
+			name: "running for long",
+			pod:  corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{running(now.Add(-time.Hour))}}},
+		},
+		{name: "terminating", pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleting}}},
+		{name: "failed", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, podStarting(&tt.pod, now))
+		})
+	}
+}
+
+func TestEndpointAddress(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"https://vault-0.vault-internal.vault.svc:8200": "vault-0.vault-internal.vault.svc:8200",
+		"https://vault.example.com":                     "vault.example.com:443",
+		"http://[fd00::5]":                              "[fd00::5]:80",
+	} {
+		address, err := endpointAddress(endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, want, address)
+	}
+}
+
+func TestWaitForPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, waitForPort(context.Background(), address, 1, time.Millisecond))
+
+	// The port of a closed listener refuses every probe
+	require.NoError(t, listener.Close())
+	start := time.Now()
+	err = waitForPort(context.Background(), address, 3, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "probes back off")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, waitForPort(ctx, address, 3, time.Hour), context.Canceled)
+}
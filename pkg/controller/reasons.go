@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Condition and instance status reasons. They are part of the API: alerts may
+// key off them, so existing values must not change.
+const (
+	// ReasonAllInstancesUnsealed means every instance of a config is unsealed
+	ReasonAllInstancesUnsealed = "AllInstancesUnsealed"
+	// ReasonSomeInstancesSealed means instances are sealed without a more specific cause
+	ReasonSomeInstancesSealed = "SomeInstancesSealed"
+	// ReasonStabilizing means every instance is unsealed, but not yet for readyThreshold checks in a row
+	ReasonStabilizing = "Stabilizing"
+	// ReasonEndpointUnreachable means the Vault endpoint could not be reached in time
+	ReasonEndpointUnreachable = "EndpointUnreachable"
+	// ReasonUnresolvable means the host of the Vault endpoint does not resolve
+	ReasonUnresolvable = "Unresolvable"
+	// ReasonTLSVerificationFailed means the Vault server certificate was rejected
+	ReasonTLSVerificationFailed = "TLSVerificationFailed"
+	// ReasonInvalidKeys means the unseal keys were malformed or rejected by Vault
+	ReasonInvalidKeys = "InvalidKeys"
+	// ReasonThresholdNotMet means fewer keys than the threshold were available or accepted
+	ReasonThresholdNotMet = "ThresholdNotMet"
+	// ReasonThresholdMismatch means the configured threshold exceeds the one Vault requires and strictThreshold is set
+	ReasonThresholdMismatch = "ThresholdMismatch"
+	// ReasonSecretMissing means the Secret of a key source does not exist
+	ReasonSecretMissing = "SecretMissing"
+	// ReasonSecretKeyMissing means the Secret of a key source lacks one of its keys
+	ReasonSecretKeyMissing = "SecretKeyMissing"
+	// ReasonExternalSecretFetchFailed means the keys of a key source could not be fetched for another reason
+	ReasonExternalSecretFetchFailed = "ExternalSecretFetchFailed"
+	// ReasonPermissionDenied means Vault or the API server denied a request
+	ReasonPermissionDenied = "PermissionDenied"
+	// ReasonVaultRequestFailed means a Vault request failed for another reason
+	ReasonVaultRequestFailed = "VaultRequestFailed"
+	// ReasonDiscoveryFailed means the nodes of an instance could not be discovered
+	ReasonDiscoveryFailed = "DiscoveryFailed"
+	// ReasonFeatureDisabled means the instance needs a subsystem disabled by --feature-gates
+	ReasonFeatureDisabled = "FeatureDisabled"
+	// ReasonBankVaultsFailed means the bank-vaults Vault of an instance could not be read or its keys not located
+	ReasonBankVaultsFailed = "BankVaultsFailed"
+	// ReasonTunnelFailed means the SSH bastion or SOCKS5 proxy of an instance could not be reached or logged in to
+	ReasonTunnelFailed = "TunnelFailed"
+	// ReasonKeyCommandFailed means the command of an exec key source was not allowed, failed or printed no keys
+	ReasonKeyCommandFailed = "KeyCommandFailed"
+	// ReasonTransitUnwrapFailed means the management Vault of a Transit unwrap could not be logged in to or did not decrypt the keys
+	ReasonTransitUnwrapFailed = "TransitUnwrapFailed"
+	// ReasonKeyDecryptionFailed means encrypted keys could not be decrypted with the identities of the operator
+	ReasonKeyDecryptionFailed = "KeyDecryptionFailed"
+	// ReasonPKCS11Failed means a PKCS#11 module was not allowed, its token could not be logged in to, or it did not return or decrypt the keys
+	ReasonPKCS11Failed = "PKCS11Failed"
+	// ReasonInvalidDependencies means dependsOn names an unknown instance or forms a cycle
+	ReasonInvalidDependencies = "InvalidDependencies"
+	// ReasonDependencyNotReady means an instance is left sealed until its dependencies are unsealed
+	ReasonDependencyNotReady = "DependencyNotReady"
+	// ReasonMaintenance means an instance is left sealed while it is under planned maintenance
+	ReasonMaintenance = "Maintenance"
+	// ReasonNodeDraining means unseal retries of an instance are delayed while the node of its pod is cordoned
+	ReasonNodeDraining = "NodeDraining"
+	// ReasonPodStarting means the pod of an instance was newly started and Vault does not accept connections yet
+	ReasonPodStarting = "PodStarting"
+	// ReasonUninitialized means Vault reports it is not initialized, so it is not unsealed
+	ReasonUninitialized = "Uninitialized"
+	// ReasonFrequentReseal means a crash-looping instance is left sealed until its resealDetection unsealDelay has passed
+	ReasonFrequentReseal = "FrequentReseal"
+	// ReasonKeysQuarantined means Vault rejected the keys of an instance quarantineAfter times in a row, so they are not submitted again
+	ReasonKeysQuarantined = "KeysQuarantined"
+	// ReasonUnsealDeferred means a sealed instance waits for the next check because its config used up maxConcurrentUnseals
+	ReasonUnsealDeferred = "UnsealDeferred"
+
+	// ReasonUnsealed is the reason of the event recorded when the operator unseals an instance
+	ReasonUnsealed = "Unsealed"
+
+	// ReasonQuorumHealthy means autopilot reports every monitored Raft cluster healthy
+	ReasonQuorumHealthy = "QuorumHealthy"
+	// ReasonRaftDegraded means autopilot reports a monitored Raft cluster unhealthy
+	ReasonRaftDegraded = "Degraded"
+
+	// ReasonKeysAvailable means no key source of a config failed to supply its keys
+	ReasonKeysAvailable = "KeysAvailable"
+
+	// ReasonVersionsInRange means every constrained instance runs an expected version
+	ReasonVersionsInRange = "VersionsInRange"
+	// ReasonVersionOutOfRange means an instance runs a version outside its expectedVersion
+	ReasonVersionOutOfRange = "VersionOutOfRange"
+	// ReasonInvalidVersionConstraint means an expectedVersion or reported version could not be parsed
+	ReasonInvalidVersionConstraint = "InvalidVersionConstraint"
+)
+
+// classifyError maps an error from processing an instance to a reason.
+func classifyError(err error) string {
+	var missingKey *missingSecretKeyError
+	var validationErr *vault.ValidationError
+	var responseErr *api.ResponseError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var tunnelErr *tunnelError
+	var keyCommandErr *keyCommandError
+	var mismatchErr *vault.ThresholdMismatchError
+	var keySourceErr *keySourceError
+	var transitErr *transitUnwrapError
+	var decryptionErr *keyDecryptionError
+	var pkcs11Err *pkcs11Error
+
+	switch {
+	case errors.As(err, &keySourceErr):
+		return classifyKeySourceError(keySourceErr.err)
+	case errors.As(err, &missingKey):
+		return ReasonSecretKeyMissing
+	case errors.As(err, &keyCommandErr):
+		return ReasonKeyCommandFailed
+	case errors.As(err, &transitErr):
+		return ReasonTransitUnwrapFailed
+	case errors.As(err, &decryptionErr):
+		return ReasonKeyDecryptionFailed
+	case errors.As(err, &pkcs11Err):
+		return ReasonPKCS11Failed
+	case apierrors.IsNotFound(err):
+		return ReasonSecretMissing
+	case apierrors.IsForbidden(err):
+		return ReasonPermissionDenied
+	case errors.As(err, &mismatchErr):
+		return ReasonThresholdMismatch
+	case errors.As(err, &validationErr):
+		if validationErr.Field == "threshold" {
+			return ReasonThresholdNotMet
+		}
+		return ReasonInvalidKeys
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		return ReasonTLSVerificationFailed
+	case errors.As(err, &tunnelErr):
+		return ReasonTunnelFailed
+	case errors.As(err, &responseErr):
+		return classifyResponseError(responseErr)
+	case errors.As(err, &dnsErr) && !dnsErr.IsTimeout && !dnsErr.IsTemporary:
+		// A resolver that timed out says nothing about the name; it is unreachable for now
+		return ReasonUnresolvable
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EHOSTUNREACH), errors.As(err, &netErr):
+		return ReasonEndpointUnreachable
+	default:
+		return ReasonVaultRequestFailed
+	}
+}
+
+// classifyKeySourceError maps an error reading the keys of a key source to a
+// reason, keeping API server failures apart from Vault connectivity.
+func classifyKeySourceError(err error) string {
+	var missingKey *missingSecretKeyError
+	var keyCommandErr *keyCommandError
+	var transitErr *transitUnwrapError
+	var decryptionErr *keyDecryptionError
+	var pkcs11Err *pkcs11Error
+	var validationErr *vault.ValidationError
+
+	switch {
+	case errors.As(err, &missingKey):
+		return ReasonSecretKeyMissing
+	case errors.As(err, &keyCommandErr):
+		return ReasonKeyCommandFailed
+	case errors.As(err, &transitErr):
+		return ReasonTransitUnwrapFailed
+	case errors.As(err, &decryptionErr):
+		return ReasonKeyDecryptionFailed
+	case errors.As(err, &pkcs11Err):
+		return ReasonPKCS11Failed
+	case apierrors.IsNotFound(err):
+		return ReasonSecretMissing
+	case errors.As(err, &validationErr):
+		return ReasonInvalidKeys
+	default:
+		return ReasonExternalSecretFetchFailed
+	}
+}
+
+// classifyResponseError maps an error response from Vault to a reason.
+func classifyResponseError(err *api.ResponseError) string {
+	switch {
+	case err.StatusCode == http.StatusForbidden:
+		return ReasonPermissionDenied
+	case vaultRejectedKeys(err):
+		return ReasonInvalidKeys
+	default:
+		return ReasonVaultRequestFailed
+	}
+}
+
+// vaultRejectedKeys reports whether Vault rejected submitted unseal keys: as
+// malformed, or because the combined shares failed to decrypt the root key.
+func vaultRejectedKeys(err *api.ResponseError) bool {
+	if err.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	message := strings.Join(err.Errors, " ")
+	return strings.Contains(message, "invalid key") || strings.Contains(message, "message authentication failed")
+}
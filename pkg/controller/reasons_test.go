@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyError(t *testing.T) {
+	secrets := schema.GroupResource{Resource: "secrets"}
+	dialErr := &url.Error{Op: "Get", URL: "https://vault:8200", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"connection refused", fmt.Errorf("failed to check seal status: %w", dialErr), ReasonEndpointUnreachable},
+		{"timeout", fmt.Errorf("failed to unseal vault: %w", context.DeadlineExceeded), ReasonEndpointUnreachable},
+		{
+			"unresolvable host",
+			fmt.Errorf("failed to check seal status: %w", &url.Error{Op: "Get", URL: "https://vault-0:8200", Err: &net.OpError{
+				Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "vault-0", IsNotFound: true},
+			}}),
+			ReasonUnresolvable,
+		},
+		{
+			"resolver timeout",
+			&url.Error{Op: "Get", URL: "https://vault-0:8200", Err: &net.OpError{
+				Op: "dial", Err: &net.DNSError{Err: "i/o timeout", Name: "vault-0", IsTimeout: true},
+			}},
+			ReasonEndpointUnreachable,
+		},
+		{
+			"unknown certificate authority",
+			&url.Error{Op: "Get", URL: "https://vault:8200", Err: x509.UnknownAuthorityError{}},
+			ReasonTLSVerificationFailed,
+		},
+		{
+			"malformed key",
+			fmt.Errorf("validation failed: %w", vault.NewValidationError("key", "x", "invalid base64 encoding")),
+			ReasonInvalidKeys,
+		},
+		{
+			"key rejected by vault",
+			vault.NewVaultError("unseal-key-submit", "https://vault:8200",
+				&api.ResponseError{StatusCode: 400, Errors: []string{"Unseal failed, invalid key"}}, true),
+			ReasonInvalidKeys,
+		},
+		{
+			"too few keys",
+			vault.NewValidationError("threshold", 3, "threshold (3) exceeds number of available keys (2)"),
+			ReasonThresholdNotMet,
+		},
+		{
+			"threshold above vault's",
+			fmt.Errorf("failed to unseal vault: %w", &vault.ThresholdMismatchError{Configured: 3, Required: 2}),
+			ReasonThresholdMismatch,
+		},
+		{"secret not found", apierrors.NewNotFound(secrets, "vault-keys"), ReasonSecretMissing},
+		{"secret key missing", &missingSecretKeyError{namespace: "vault", name: "vault-keys", key: "key2"}, ReasonSecretKeyMissing},
+		{"secret forbidden", apierrors.NewForbidden(secrets, "vault-keys", fmt.Errorf("denied")), ReasonPermissionDenied},
+		{
+			"key source secret not found",
+			fmt.Errorf("failed to read unseal keys: %w", &keySourceError{err: apierrors.NewNotFound(secrets, "vault-keys")}),
+			ReasonSecretMissing,
+		},
+		{
+			"key source secret forbidden",
+			&keySourceError{err: apierrors.NewForbidden(secrets, "vault-keys", fmt.Errorf("denied"))},
+			ReasonExternalSecretFetchFailed,
+		},
+		{
+			"key source timeout",
+			&keySourceError{err: fmt.Errorf("failed to get secret vault/vault-keys: %w", context.DeadlineExceeded)},
+			ReasonExternalSecretFetchFailed,
+		},
+		{"other", fmt.Errorf("unexpected response"), ReasonVaultRequestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
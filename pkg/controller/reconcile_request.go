@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RequestReconcile stamps a VaultUnsealConfig with ReconcileRequestedAtAnnotation,
+// which makes the operator check and unseal its instances right away.
+func RequestReconcile(ctx context.Context, c client.Client, key types.NamespacedName, at time.Time) error {
+	var config vaultv1.VaultUnsealConfig
+	if err := c.Get(ctx, key, &config); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(config.DeepCopy())
+	if config.Annotations == nil {
+		config.Annotations = map[string]string{}
+	}
+	config.Annotations[vaultv1.ReconcileRequestedAtAnnotation] = at.UTC().Format(time.RFC3339Nano)
+	return c.Patch(ctx, &config, patch)
+}
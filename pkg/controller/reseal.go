@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// ConditionTypeFrequentReseal reports that instances of a config keep sealing
+// again soon after being unsealed. It is abnormal-true: only present while true.
+const ConditionTypeFrequentReseal = "FrequentReseal"
+
+const (
+	// defaultResealWindow is how soon after an unseal a new seal counts as rapid
+	defaultResealWindow = 10 * time.Minute
+
+	// defaultResealThreshold is the number of rapid reseals in a row that raise FrequentReseal
+	defaultResealThreshold = 3
+)
+
+// recordRapidReseals counts the seals detected within window of the previous
+// unseal of an instance. A seal after a longer time, or staying unsealed for
+// longer than window, starts the count over. It must be called after
+// recordUnsealHistory.
+func recordRapidReseals(status, previous *vaultv1.VaultInstanceStatus, window time.Duration, now time.Time) {
+	if previous == nil {
+		return
+	}
+	rapid := previous.RapidReseals
+	switch {
+	case sealDetected(status, previous):
+		if previous.LastUnsealed != nil && now.Sub(previous.LastUnsealed.Time) <= window {
+			rapid++
+		} else {
+			rapid = 0
+		}
+	case !status.Sealed && status.LastUnsealed != nil && now.Sub(status.LastUnsealed.Time) > window:
+		rapid = 0
+	}
+	status.RapidReseals = rapid
+}
+
+// frequentReseal reports whether an instance sealed again rapidly often enough
+// in a row to be flagged.
+func frequentReseal(status *vaultv1.VaultInstanceStatus, settings *unsealSettings) bool {
+	return status != nil && status.RapidReseals >= int32(settings.ResealThreshold)
+}
+
+// resealHold returns the time before which a flagged instance is not unsealed
+// again, or the zero time when its unseal is not delayed.
+func resealHold(previous *vaultv1.VaultInstanceStatus, settings *unsealSettings) time.Time {
+	if settings.ResealUnsealDelay <= 0 || !frequentReseal(previous, settings) || previous.LastUnsealed == nil {
+		return time.Time{}
+	}
+	return previous.LastUnsealed.Add(settings.ResealUnsealDelay)
+}
+
+// updateResealCondition lists the instances flagged for frequent reseals.
+func updateResealCondition(vaultConfig *vaultv1.VaultUnsealConfig, settings *unsealSettings) {
+	var flagged []string
+	for i := range vaultConfig.Status.VaultStatuses {
+		if status := &vaultConfig.Status.VaultStatuses[i]; frequentReseal(status, settings) {
+			flagged = append(flagged, fmt.Sprintf("%s (%d times)", status.Name, status.RapidReseals))
+		}
+	}
+	setAbnormalCondition(vaultConfig, ConditionTypeFrequentReseal, len(flagged) > 0, ReasonFrequentReseal,
+		fmt.Sprintf("Sealed again within %s of being unsealed: %s",
+			settings.ResealWindow, strings.Join(flagged, ", ")))
+}
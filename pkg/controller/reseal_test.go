@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRecordRapidReseals(t *testing.T) {
+	now := time.Now()
+	unsealedAt := func(ago time.Duration) *metav1.Time {
+		at := metav1.NewTime(now.Add(-ago))
+		return &at
+	}
+	detected := metav1.NewTime(now)
+
+	// A seal shortly after an unseal counts
+	previous := &vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: unsealedAt(time.Minute), RapidReseals: 2}
+	status := &vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true, LastSealDetectedTime: &detected}
+	recordRapidReseals(status, previous, 10*time.Minute, now)
+	assert.Equal(t, int32(3), status.RapidReseals)
+
+	// A seal long after the last unseal starts over
+	previous.LastUnsealed = unsealedAt(time.Hour)
+	recordRapidReseals(status, previous, 10*time.Minute, now)
+	assert.Zero(t, status.RapidReseals)
+
+	// Checks without a new seal carry the count until the window has passed unsealed
+	previous = &vaultv1.VaultInstanceStatus{Name: "vault-0", RapidReseals: 2}
+	status = &vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: unsealedAt(time.Minute)}
+	recordRapidReseals(status, previous, 10*time.Minute, now)
+	assert.Equal(t, int32(2), status.RapidReseals)
+	status.LastUnsealed = unsealedAt(time.Hour)
+	recordRapidReseals(status, previous, 10*time.Minute, now)
+	assert.Zero(t, status.RapidReseals)
+}
+
+func TestResealHoldAndCondition(t *testing.T) {
+	threshold := int32(2)
+	settings := defaultUnsealSettings(DefaultReconcilerOptions())
+	settings.apply(&vaultv1.UnsealSettings{ResealDetection: &vaultv1.ResealDetection{
+		Threshold:   &threshold,
+		UnsealDelay: &metav1.Duration{Duration: 5 * time.Minute},
+	}})
+	require.Equal(t, defaultResealWindow, settings.ResealWindow)
+	require.Equal(t, 2, settings.ResealThreshold)
+
+	lastUnsealed := metav1.NewTime(time.Now())
+	previous := &vaultv1.VaultInstanceStatus{Name: "vault-0", LastUnsealed: &lastUnsealed, RapidReseals: 1}
+	assert.True(t, resealHold(previous, settings).IsZero(), "below the threshold")
+	previous.RapidReseals = 2
+	assert.Equal(t, lastUnsealed.Add(5*time.Minute), resealHold(previous, settings))
+
+	config := &vaultv1.VaultUnsealConfig{}
+	config.Status.VaultStatuses = []vaultv1.VaultInstanceStatus{*previous, {Name: "vault-1"}}
+	updateResealCondition(config, settings)
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeFrequentReseal)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "vault-0 (2 times)")
+	assert.NotContains(t, condition.Message, "vault-1")
+
+	config.Status.VaultStatuses[0].RapidReseals = 0
+	updateResealCondition(config, settings)
+	assert.Nil(t, meta.FindStatusCondition(config.Status.Conditions, ConditionTypeFrequentReseal))
+}
+
+func TestReconcileDelaysFrequentlyResealingVault(t *testing.T) {
+	server := fakevault.New(t)
+	threshold := int32(1)
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{{
+				Name: "vault-0", Endpoint: server.URL(), UnsealKeys: server.Keys(),
+			}},
+			Settings: &vaultv1.UnsealSettings{ResealDetection: &vaultv1.ResealDetection{
+				Threshold:   &threshold,
+				UnsealDelay: &metav1.Duration{Duration: time.Hour},
+			}},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	reconcile := func() vaultv1.VaultUnsealConfig {
+		_, err := r.Reconcile(t.Context(), req)
+		require.NoError(t, err)
+		var updated vaultv1.VaultUnsealConfig
+		require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+		return updated
+	}
+
+	updated := reconcile()
+	require.False(t, server.Sealed())
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeFrequentReseal))
+
+	// Sealing again right after the unseal flags the instance, which is still unsealed this time
+	server.Seal()
+	updated = reconcile()
+	assert.False(t, server.Sealed())
+	assert.Equal(t, int32(1), updated.Status.VaultStatuses[0].RapidReseals)
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeFrequentReseal)
+	require.NotNil(t, condition)
+	assert.Contains(t, condition.Message, "vault-0")
+
+	// Once flagged, the next unseal waits for the delay
+	server.Seal()
+	updated = reconcile()
+	assert.True(t, server.Sealed())
+	assert.Equal(t, ReasonFrequentReseal, updated.Status.VaultStatuses[0].Reason)
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrOutsideScope is returned by a scoped client for objects in namespaces the
+// operator instance does not own.
+var ErrOutsideScope = errors.New("namespace is outside the scope of this operator instance")
+
+// NamespaceScope is the set of namespaces an operator instance acts in, so
+// several instances can share a cluster, each owning its own tenants. An
+// empty scope is the whole cluster.
+type NamespaceScope []string
+
+// NewNamespaceScope returns the sorted, deduplicated scope of namespaces.
+func NewNamespaceScope(namespaces []string) NamespaceScope {
+	var scope NamespaceScope
+	for _, namespace := range namespaces {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && !slices.Contains(scope, namespace) {
+			scope = append(scope, namespace)
+		}
+	}
+	slices.Sort(scope)
+	return scope
+}
+
+// ClusterWide reports whether the scope places no restriction.
+func (s NamespaceScope) ClusterWide() bool {
+	return len(s) == 0
+}
+
+// Contains reports whether namespace is in scope. Cluster-scoped objects, with
+// an empty namespace, always are.
+func (s NamespaceScope) Contains(namespace string) bool {
+	return s.ClusterWide() || namespace == "" || slices.Contains(s, namespace)
+}
+
+// CacheNamespaces returns the namespaces the manager cache is restricted to,
+// nil for the whole cluster.
+func (s NamespaceScope) CacheNamespaces() map[string]cache.Config {
+	if s.ClusterWide() {
+		return nil
+	}
+	namespaces := make(map[string]cache.Config, len(s))
+	for _, namespace := range s {
+		namespaces[namespace] = cache.Config{}
+	}
+	return namespaces
+}
+
+// LeaderElectionID returns base for the whole cluster and base suffixed with a
+// hash of the namespaces otherwise, so instances of different scopes sharing a
+// namespace do not compete for one lease.
+func (s NamespaceScope) LeaderElectionID(base string) string {
+	if s.ClusterWide() {
+		return base
+	}
+	sum := sha256.Sum256([]byte(strings.Join(s, ",")))
+	return base + "-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// check returns ErrOutsideScope for a namespace out of scope.
+func (s NamespaceScope) check(namespace string) error {
+	if s.Contains(namespace) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOutsideScope, namespace)
+}
+
+// NewScopedClient returns c refusing every read and write of objects outside
+// scope. Secrets and ConfigMaps bypass the restricted cache, and specs may
+// reference other namespaces, so the cache alone does not confine an instance.
+func NewScopedClient(c client.Client, scope NamespaceScope) client.Client {
+	if scope.ClusterWide() {
+		return c
+	}
+	return &scopedClient{Client: c, scope: scope}
+}
+
+// scopedClient guards a client.Client with a NamespaceScope.
+type scopedClient struct {
+	client.Client
+	scope NamespaceScope
+}
+
+func (c *scopedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.scope.check(key.Namespace); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// List refuses an explicit namespace out of scope. Lists across all namespaces
+// are served by the restricted cache.
+func (c *scopedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.scope.check((&client.ListOptions{}).ApplyOptions(opts).Namespace); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *scopedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *scopedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *scopedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *scopedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *scopedClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if err := c.scope.check((&client.DeleteAllOfOptions{}).ApplyOptions(opts).Namespace); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *scopedClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *scopedClient) SubResource(subResource string) client.SubResourceClient {
+	return &scopedSubResourceClient{SubResourceClient: c.Client.SubResource(subResource), scope: c.scope}
+}
+
+// scopedSubResourceClient guards the subresources, like status, of a scopedClient.
+type scopedSubResourceClient struct {
+	client.SubResourceClient
+	scope NamespaceScope
+}
+
+func (c *scopedSubResourceClient) Get(
+	ctx context.Context, obj, subResource client.Object, opts ...client.SubResourceGetOption,
+) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.SubResourceClient.Get(ctx, obj, subResource, opts...)
+}
+
+func (c *scopedSubResourceClient) Create(
+	ctx context.Context, obj, subResource client.Object, opts ...client.SubResourceCreateOption,
+) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.SubResourceClient.Create(ctx, obj, subResource, opts...)
+}
+
+func (c *scopedSubResourceClient) Update(
+	ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption,
+) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.SubResourceClient.Update(ctx, obj, opts...)
+}
+
+func (c *scopedSubResourceClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption,
+) error {
+	if err := c.scope.check(obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.SubResourceClient.Patch(ctx, obj, patch, opts...)
+}
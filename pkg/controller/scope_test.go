@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestNamespaceScope(t *testing.T) {
+	clusterWide := NewNamespaceScope([]string{" ", ""})
+	assert.True(t, clusterWide.ClusterWide())
+	assert.True(t, clusterWide.Contains("anything"))
+	assert.Nil(t, clusterWide.CacheNamespaces())
+	assert.Equal(t, "lease", clusterWide.LeaderElectionID("lease"))
+
+	scope := NewNamespaceScope([]string{"team-b", "team-a", "team-b"})
+	assert.Equal(t, NamespaceScope{"team-a", "team-b"}, scope)
+	assert.True(t, scope.Contains("team-a"))
+	assert.True(t, scope.Contains(""), "cluster-scoped objects are in scope")
+	assert.False(t, scope.Contains("team-c"))
+	assert.Len(t, scope.CacheNamespaces(), 2)
+
+	id := scope.LeaderElectionID("lease")
+	assert.Regexp(t, `^lease-[0-9a-f]{10}$`, id)
+	assert.Equal(t, id, NewNamespaceScope([]string{"team-a", "team-b"}).LeaderElectionID("lease"))
+	assert.NotEqual(t, id, NewNamespaceScope([]string{"team-a"}).LeaderElectionID("lease"))
+}
+
+func TestScopedClient(t *testing.T) {
+	ctx := context.Background()
+	inner := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "team-a"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "team-b"}},
+			&vaultv1.VaultClusterDefaults{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		).
+		Build()
+	c := NewScopedClient(inner, NewNamespaceScope([]string{"team-a"}))
+
+	var secret corev1.Secret
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "keys"}, &secret))
+	err := c.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: "keys"}, &secret)
+	assert.ErrorIs(t, err, ErrOutsideScope)
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "default"}, &vaultv1.VaultClusterDefaults{}))
+
+	var secrets corev1.SecretList
+	assert.ErrorIs(t, c.List(ctx, &secrets, client.InNamespace("team-b")), ErrOutsideScope)
+	require.NoError(t, c.List(ctx, &secrets, client.InNamespace("team-a")))
+	assert.Len(t, secrets.Items, 1)
+
+	foreign := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "state", Namespace: "team-b"}}
+	assert.ErrorIs(t, c.Create(ctx, foreign), ErrOutsideScope)
+	assert.ErrorIs(t, c.Delete(ctx, foreign), ErrOutsideScope)
+	assert.ErrorIs(t, c.Status().Update(ctx, foreign), ErrOutsideScope)
+	assert.ErrorIs(t, c.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace("team-b")), ErrOutsideScope)
+
+	assert.Same(t, inner, NewScopedClient(inner, nil))
+}
+
+func TestReconcileRefusesConfigOutsideScope(t *testing.T) {
+	inner := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(&vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "team-b"}}).
+		Build()
+	c := NewScopedClient(inner, NewNamespaceScope([]string{"team-a"}))
+	r := NewVaultUnsealConfigReconciler(c, log.Log, inner.Scheme(), nil, nil)
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "vault"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+}
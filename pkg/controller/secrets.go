@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// readSecretData returns the data of the named Secret.
+func readSecretData(ctx context.Context, c client.Reader, namespace, name string) (map[string][]byte, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", namespace, name, err)
+	}
+	return secret.Data, nil
+}
+
+// readSecretKey returns a single non-empty value from a Secret.
+func readSecretKey(ctx context.Context, c client.Reader, namespace string, ref vaultv1.SecretKeyRef) ([]byte, error) {
+	data, err := readSecretData(ctx, c, namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := data[ref.Key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", namespace, ref.Name, ref.Key)
+	}
+	return value, nil
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/notify"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+)
+
+// DefaultSelfTestInterval is how often a failed self-test is run again
+const DefaultSelfTestInterval = time.Minute
+
+// errSelfTestPending fails readiness until the self-test has run once.
+var errSelfTestPending = errors.New("startup self-test has not completed")
+
+// SelfTest checks on startup that the operator keeps unseal keys out of its
+// errors, and that the notification sinks and key sources of every config are
+// usable, without sending events, running commands or exposing key values.
+// Readiness fails with the problems it found; a failed self-test is run again
+// every interval until it passes.
+type SelfTest struct {
+	reconciler *VaultUnsealConfigReconciler
+	logger     logr.Logger
+	interval   time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewSelfTest returns a self-test of the configuration seen by reconciler.
+func NewSelfTest(reconciler *VaultUnsealConfigReconciler, logger logr.Logger, interval time.Duration) *SelfTest {
+	if interval <= 0 {
+		interval = DefaultSelfTestInterval
+	}
+	return &SelfTest{reconciler: reconciler, logger: logger, interval: interval, err: errSelfTestPending}
+}
+
+// Start implements manager.Runnable: it runs the self-test once the caches
+// are started, then again every interval while it fails.
+func (s *SelfTest) Start(ctx context.Context) error {
+	for {
+		if err := s.Run(ctx); err == nil {
+			s.logger.Info("Startup self-test passed")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every replica
+// checks its own readiness.
+func (s *SelfTest) NeedLeaderElection() bool {
+	return false
+}
+
+// Check implements healthz.Checker, failing with the problems of the last run.
+func (s *SelfTest) Check(*http.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Run runs the self-test, logs every problem found and returns them joined.
+func (s *SelfTest) Run(ctx context.Context) error {
+	problems := checkRedaction()
+	problems = append(problems, s.checkConfigs(ctx)...)
+	for _, problem := range problems {
+		s.logger.Error(problem, "Startup self-test failed")
+	}
+	err := errors.Join(problems...)
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	return err
+}
+
+// checkRedaction feeds a random canary key through the errors raised for
+// invalid keys and reports the ones that fail to hide it.
+func checkRedaction() []error {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return []error{fmt.Errorf("redaction self-test: %w", err)}
+	}
+	canary := "selftest-" + hex.EncodeToString(secret)
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"hex key decoding", func() error {
+			_, err := vault.NormalizeKey(canary, vault.KeyEncodingHex)
+			return err
+		}},
+		{"key validation", func() error {
+			return vault.NewDefaultKeyValidator().ValidateBase64Key(canary)
+		}},
+		{"combined key parsing", func() error {
+			_, err := parseKeyList([]byte(`{"keys": "` + canary + `"}`))
+			return err
+		}},
+	}
+
+	var problems []error
+	for _, check := range checks {
+		err := check.run()
+		switch {
+		case err == nil:
+			problems = append(problems, fmt.Errorf("redaction self-test: %s accepted an invalid key", check.name))
+		case strings.Contains(err.Error(), canary):
+			problems = append(problems, fmt.Errorf("redaction self-test: %s exposes the key in its error", check.name))
+		}
+	}
+	return problems
+}
+
+// checkConfigs reports the notification sinks and key sources of the configs
+// that cannot be used.
+func (s *SelfTest) checkConfigs(ctx context.Context) []error {
+	r := s.reconciler
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs); err != nil {
+		return []error{fmt.Errorf("self-test: failed to list VaultUnsealConfigs: %w", err)}
+	}
+
+	var problems []error
+	for i := range configs.Items {
+		vaultConfig := &configs.Items[i]
+		path := vaultConfig.Namespace + "/" + vaultConfig.Name
+
+		settings := r.resolveSettings(ctx, s.logger, vaultConfig)
+		connections := r.sinkConnections(vaultConfig.Namespace)
+		for _, sink := range settings.Notifications {
+			if err := notify.ValidateSink(ctx, sink, connections); err != nil {
+				problems = append(problems, fmt.Errorf("config %s: %w", path, err))
+			}
+		}
+
+		for j := range vaultConfig.Spec.VaultInstances {
+			instance := &vaultConfig.Spec.VaultInstances[j]
+			if !instanceEnabled(instance) {
+				continue
+			}
+			if err := r.checkKeySource(ctx, vaultConfig.Namespace, instance); err != nil {
+				problems = append(problems, fmt.Errorf("config %s instance %s: %w", path, instance.Name, err))
+			}
+		}
+	}
+	return problems
+}
+
+// checkKeySource checks that the keys of instance can be read: its Secret
+// holds them, or its command or PKCS#11 module is allowed and installed, and a
+// token to log in to its Transit management Vault, its age identities, its
+// PKCS#11 PIN or its OpenPGP private keys are available. Commands are not run,
+// tokens are not accessed and keys are not decrypted.
+func (r *VaultUnsealConfigReconciler) checkKeySource(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) error {
+	if unwrap := instance.TransitUnwrap; unwrap != nil {
+		if _, err := r.transitLoginToken(ctx, namespace, unwrap); err != nil {
+			return &transitUnwrapError{address: unwrap.Address, err: err}
+		}
+	}
+	if decryption := instance.AgeDecryption; decryption != nil {
+		if _, err := r.ageIdentities(ctx, namespace, decryption); err != nil {
+			return err
+		}
+	}
+	if decryption := instance.PKCS11Decryption; decryption != nil {
+		if err := r.checkPKCS11Token(ctx, namespace, &decryption.Token); err != nil {
+			return err
+		}
+	}
+	if decryption := instance.PGPDecryption; decryption != nil {
+		if _, err := r.pgpKeyring(ctx, namespace, decryption); err != nil {
+			return err
+		}
+	}
+	if instance.KeySource == nil {
+		return nil
+	}
+	if source := instance.KeySource.Exec; source != nil {
+		if !slices.Contains(r.KeyCommands, source.Command) {
+			return &keyCommandError{command: source.Command, err: errors.New("command is not allowed by --key-exec-commands")}
+		}
+		if _, err := exec.LookPath(source.Command); err != nil {
+			return &keyCommandError{command: source.Command, err: errors.New("command not found")}
+		}
+		if err := checkExecEnv(source.Env); err != nil {
+			return &keyCommandError{command: source.Command, err: err}
+		}
+		return nil
+	}
+	if source := instance.KeySource.PKCS11; source != nil {
+		return r.checkPKCS11Token(ctx, namespace, &source.Token)
+	}
+	_, err := r.sourceKeys(ctx, namespace, instance)
+	return err
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultStateConfigMapName is the name of the exported unseal state ConfigMap.
+	DefaultStateConfigMapName = "vault-unseal-state"
+
+	// StateConfigMapSummaryKey holds the JSON summary of all configs of the namespace.
+	StateConfigMapSummaryKey = "state.json"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "vault-autounseal-operator"
+)
+
+// stateSummary is the JSON summary of the VaultUnsealConfigs of a namespace.
+type stateSummary struct {
+	Configs []configState `json:"configs"`
+}
+
+// configState is the exported state of one VaultUnsealConfig.
+type configState struct {
+	Name      string          `json:"name"`
+	Phase     string          `json:"phase,omitempty"`
+	Instances []instanceState `json:"instances"`
+}
+
+// instanceState is the exported state of one vault instance.
+type instanceState struct {
+	Name             string     `json:"name"`
+	Sealed           bool       `json:"sealed"`
+	Reason           string     `json:"reason,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	LastUnsealed     *time.Time `json:"lastUnsealed,omitempty"`
+	LastSealDetected *time.Time `json:"lastSealDetected,omitempty"`
+}
+
+// StateConfigMapReconciler keeps a ConfigMap in every namespace with
+// VaultUnsealConfigs that summarizes their instance seal states, for consumers
+// that cannot read custom resources.
+type StateConfigMapReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Name is the name of the ConfigMap in each namespace
+	Name string
+}
+
+// NewStateConfigMapReconciler creates a new state ConfigMap reconciler with dependencies.
+func NewStateConfigMapReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+	name string,
+) *StateConfigMapReconciler {
+	if name == "" {
+		name = DefaultStateConfigMapName
+	}
+
+	return &StateConfigMapReconciler{
+		Client: client,
+		Log:    logger,
+		Scheme: scheme,
+		Name:   name,
+	}
+}
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+func (r *StateConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "StateConfigMap")
+
+	if req.Name != r.Name {
+		return ctrl.Result{}, nil
+	}
+
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list VaultUnsealConfigs: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace},
+	}
+	if len(configs.Items) == 0 {
+		return ctrl.Result{}, r.deleteConfigMap(ctx, logger, configMap)
+	}
+
+	data, err := stateConfigMapData(configs.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		// Never take over a ConfigMap someone else created under the same name
+		if configMap.ResourceVersion != "" && configMap.Labels[managedByLabel] != managedByValue {
+			return fmt.Errorf("ConfigMap %s/%s exists and is not managed by the operator", req.Namespace, req.Name)
+		}
+		if configMap.Labels == nil {
+			configMap.Labels = map[string]string{}
+		}
+		configMap.Labels[managedByLabel] = managedByValue
+		configMap.Data = data
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to apply state ConfigMap: %w", err)
+	}
+
+	if result != controllerutil.OperationResultNone {
+		logger.V(1).Info("State ConfigMap synced", "operation", result, "configs", len(configs.Items))
+	}
+	return ctrl.Result{}, nil
+}
+
+// deleteConfigMap removes the ConfigMap of a namespace without VaultUnsealConfigs.
+func (r *StateConfigMapReconciler) deleteConfigMap(ctx context.Context, logger logr.Logger, configMap *corev1.ConfigMap) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(configMap), configMap); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if configMap.Labels[managedByLabel] != managedByValue {
+		return nil
+	}
+	if err := r.Delete(ctx, configMap); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	logger.V(1).Info("State ConfigMap deleted")
+	return nil
+}
+
+// stateConfigMapData renders the state of configs as flat keys for shell
+// scripts, e.g. vault-cluster.vault-0.sealed, and as a JSON summary.
+func stateConfigMapData(configs []vaultv1.VaultUnsealConfig) (map[string]string, error) {
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+
+	data := make(map[string]string)
+	summary := stateSummary{Configs: make([]configState, 0, len(configs))}
+	for i := range configs {
+		config := &configs[i]
+		state := configState{
+			Name:      config.Name,
+			Phase:     string(config.Status.Phase),
+			Instances: make([]instanceState, 0, len(config.Status.VaultStatuses)),
+		}
+		if state.Phase != "" {
+			data[config.Name+".phase"] = state.Phase
+		}
+
+		for _, status := range config.Status.VaultStatuses {
+			instance := instanceState{
+				Name:             status.Name,
+				Sealed:           status.Sealed,
+				Reason:           status.Reason,
+				Error:            status.Error,
+				LastUnsealed:     timeOrNil(status.LastUnsealed),
+				LastSealDetected: timeOrNil(status.LastSealDetectedTime),
+			}
+			state.Instances = append(state.Instances, instance)
+
+			prefix := config.Name + "." + status.Name + "."
+			data[prefix+"sealed"] = fmt.Sprintf("%t", status.Sealed)
+			if instance.LastUnsealed != nil {
+				data[prefix+"lastUnsealed"] = instance.LastUnsealed.Format(time.RFC3339)
+			}
+			if instance.LastSealDetected != nil {
+				data[prefix+"lastSealDetected"] = instance.LastSealDetected.Format(time.RFC3339)
+			}
+		}
+		summary.Configs = append(summary.Configs, state)
+	}
+
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode state summary: %w", err)
+	}
+	data[StateConfigMapSummaryKey] = string(encoded)
+	return data, nil
+}
+
+func timeOrNil(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// configMapRequest maps a change of a VaultUnsealConfig to the ConfigMap of its namespace.
+func (r *StateConfigMapReconciler) configMapRequest(_ context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: r.Name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StateConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isStateConfigMap := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.Name
+	})
+
+	// Status changes are what the ConfigMap exports, so every update is watched.
+	// ConfigMaps are watched as metadata only, so their data is never cached.
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("stateconfigmap").
+		For(&corev1.ConfigMap{}, builder.OnlyMetadata, builder.WithPredicates(isStateConfigMap)).
+		Watches(&vaultv1.VaultUnsealConfig{}, handler.EnqueueRequestsFromMapFunc(r.configMapRequest)).
+		Complete(r)
+}
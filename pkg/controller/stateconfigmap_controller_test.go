@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestStateConfigMapExportsSealState(t *testing.T) {
+	unsealed := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	config := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-cluster", Namespace: "vault"},
+		Status: vaultv1.VaultUnsealConfigStatus{
+			Phase: vaultv1.UnsealConfigPhaseUnsealing,
+			VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0", LastUnsealed: &unsealed, LastSealDetectedTime: &unsealed},
+				{Name: "vault-1", Sealed: true, Reason: ReasonEndpointUnreachable, Error: "connection refused"},
+			},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(config).Build()
+	r := NewStateConfigMapReconciler(k8sClient, log.Log, k8sClient.Scheme(), "")
+	key := types.NamespacedName{Namespace: "vault", Name: DefaultStateConfigMapName}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	var configMap corev1.ConfigMap
+	require.NoError(t, k8sClient.Get(context.Background(), key, &configMap))
+	assert.Equal(t, "vault-autounseal-operator", configMap.Labels["app.kubernetes.io/managed-by"])
+	assert.Equal(t, "Unsealing", configMap.Data["vault-cluster.phase"])
+	assert.Equal(t, "false", configMap.Data["vault-cluster.vault-0.sealed"])
+	assert.Equal(t, "2024-01-01T12:00:00Z", configMap.Data["vault-cluster.vault-0.lastUnsealed"])
+	assert.Equal(t, "true", configMap.Data["vault-cluster.vault-1.sealed"])
+	assert.NotContains(t, configMap.Data, "vault-cluster.vault-1.lastUnsealed")
+
+	var summary stateSummary
+	require.NoError(t, json.Unmarshal([]byte(configMap.Data[StateConfigMapSummaryKey]), &summary))
+	require.Len(t, summary.Configs, 1)
+	require.Len(t, summary.Configs[0].Instances, 2)
+	assert.Equal(t, "connection refused", summary.Configs[0].Instances[1].Error)
+
+	// The ConfigMap is removed with the last config of the namespace
+	require.NoError(t, k8sClient.Delete(context.Background(), config))
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	err = k8sClient.Get(context.Background(), key, &configMap)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestStateConfigMapLeavesForeignConfigMap(t *testing.T) {
+	foreign := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultStateConfigMapName, Namespace: "vault"},
+		Data:       map[string]string{"owner": "someone-else"},
+	}
+	config := &vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault-cluster", Namespace: "vault"}}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(foreign, config).Build()
+	r := NewStateConfigMapReconciler(k8sClient, log.Log, k8sClient.Scheme(), "")
+	key := types.NamespacedName{Namespace: "vault", Name: DefaultStateConfigMapName}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not managed by the operator")
+
+	// Nor is it deleted once no config is left
+	require.NoError(t, k8sClient.Delete(context.Background(), config))
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	var configMap corev1.ConfigMap
+	require.NoError(t, k8sClient.Get(context.Background(), key, &configMap))
+	assert.Equal(t, "someone-else", configMap.Data["owner"])
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// rolloutRequeueAfter is how soon a config is checked again while a StatefulSet
+// it discovers nodes from is rolling out, so restarted pods are unsealed quickly
+// enough for the rollout to proceed without losing quorum.
+const rolloutRequeueAfter = 5 * time.Second
+
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
+
+// instanceNamespace returns the namespace of the pods an instance watches and
+// the StatefulSet it discovers its nodes from: the instance namespace, or else
+// the config's.
+func instanceNamespace(namespace string, instance *vaultv1.VaultInstance) string {
+	if instance.Namespace != "" {
+		return instance.Namespace
+	}
+	return namespace
+}
+
+// statefulSetNodes returns one node per replica of the StatefulSet, named by its
+// ordinal and addressed by its stable name under the governing Service. Replicas
+// are listed whether or not their pod currently exists, so a pod restarting
+// during a rollout is checked as soon as it is back.
+func (r *VaultUnsealConfigReconciler) statefulSetNodes(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) ([]discoveredNode, error) {
+	var statefulSet appsv1.StatefulSet
+	key := types.NamespacedName{Namespace: instanceNamespace(namespace, instance), Name: instance.Discovery.StatefulSet}
+	if err := r.Get(ctx, key, &statefulSet); err != nil {
+		return nil, fmt.Errorf("failed to read StatefulSet %s: %w", key, err)
+	}
+	if statefulSet.Spec.ServiceName == "" {
+		return nil, fmt.Errorf("StatefulSet %s has no serviceName to address its pods", key)
+	}
+
+	start := int32(0)
+	if statefulSet.Spec.Ordinals != nil {
+		start = statefulSet.Spec.Ordinals.Start
+	}
+	replicas := statefulSetReplicas(&statefulSet)
+	nodes := make([]discoveredNode, 0, replicas)
+	for ordinal := start; ordinal < start+replicas; ordinal++ {
+		nodes = append(nodes, discoveredNode{
+			Name: strconv.Itoa(int(ordinal)),
+			Host: fmt.Sprintf("%s-%d.%s.%s.svc", statefulSet.Name, ordinal, statefulSet.Spec.ServiceName, statefulSet.Namespace),
+		})
+	}
+	return nodes, nil
+}
+
+// statefulSetReplicas returns the desired replicas of statefulSet, which
+// default to one.
+func statefulSetReplicas(statefulSet *appsv1.StatefulSet) int32 {
+	if statefulSet.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulSet.Spec.Replicas
+}
+
+// statefulSetRollingOut reports whether the pods of statefulSet are being
+// replaced by a new revision, or the controller has yet to observe a change.
+func statefulSetRollingOut(statefulSet *appsv1.StatefulSet) bool {
+	status := statefulSet.Status
+	return status.ObservedGeneration < statefulSet.Generation ||
+		status.CurrentRevision != status.UpdateRevision ||
+		status.UpdatedReplicas < statefulSetReplicas(statefulSet)
+}
+
+// rolloutInProgress reports whether a StatefulSet the enabled instances of
+// vaultConfig discover nodes from is rolling out.
+func (r *VaultUnsealConfigReconciler) rolloutInProgress(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+) bool {
+	for i := range vaultConfig.Spec.VaultInstances {
+		instance := &vaultConfig.Spec.VaultInstances[i]
+		if !instanceEnabled(instance) || instance.Discovery == nil || instance.Discovery.StatefulSet == "" {
+			continue
+		}
+		var statefulSet appsv1.StatefulSet
+		key := types.NamespacedName{
+			Namespace: instanceNamespace(vaultConfig.Namespace, instance),
+			Name:      instance.Discovery.StatefulSet,
+		}
+		if err := r.Get(ctx, key, &statefulSet); err != nil {
+			continue
+		}
+		if statefulSetRollingOut(&statefulSet) {
+			logger.Info("StatefulSet rollout in progress", "instance", instance.Name, "statefulSet", key.String(),
+				"updatedReplicas", statefulSet.Status.UpdatedReplicas, "readyReplicas", statefulSet.Status.ReadyReplicas)
+			return true
+		}
+	}
+	return false
+}
+
+// findVaultConfigsForStatefulSet maps a StatefulSet change, e.g. the progress
+// of a rollout, to the configs discovering nodes from it.
+func (r *VaultUnsealConfigReconciler) findVaultConfigsForStatefulSet(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs, client.MatchingFields{
+		vaultConfigPodIndex: statefulSetIndexValue(obj.GetNamespace(), obj.GetName()),
+	}); err != nil {
+		r.Log.Error(err, "failed to list VaultUnsealConfigs")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(configs.Items))
+	for _, config := range configs.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: config.Name, Namespace: config.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTestStatefulSet(replicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault", Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, ServiceName: "vault-internal"},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			Replicas:           replicas,
+			ReadyReplicas:      replicas,
+			UpdatedReplicas:    replicas,
+			CurrentRevision:    "vault-1",
+			UpdateRevision:     "vault-1",
+		},
+	}
+}
+
+func TestStatefulSetNodes(t *testing.T) {
+	statefulSet := newTestStatefulSet(3)
+	statefulSet.Spec.Ordinals = &appsv1.StatefulSetOrdinals{Start: 1}
+	headless := newTestStatefulSet(1)
+	headless.Name = "headless"
+	headless.Spec.ServiceName = ""
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(statefulSet, headless).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	// The instance namespace takes precedence over the config namespace
+	instance := &vaultv1.VaultInstance{
+		Name: "vault", Endpoint: "https://vault.vault.svc:8200", Namespace: "vault",
+		Discovery: &vaultv1.Discovery{StatefulSet: "vault"},
+	}
+	nodes, err := r.discoverNodes(context.Background(), "ops", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []discoveredNode{
+		{Name: "1", Host: "vault-1.vault-internal.vault.svc"},
+		{Name: "2", Host: "vault-2.vault-internal.vault.svc"},
+		{Name: "3", Host: "vault-3.vault-internal.vault.svc"},
+	}, nodes)
+
+	nodeInstance, err := discoveredInstance(instance, nodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, "vault-1", nodeInstance.Name)
+	assert.Equal(t, "https://vault-1.vault-internal.vault.svc:8200", nodeInstance.Endpoint)
+
+	instance.Namespace = ""
+	_, err = r.discoverNodes(context.Background(), "ops", instance)
+	require.Error(t, err, "the StatefulSet is looked up in the config namespace")
+	assert.Contains(t, err.Error(), "ops/vault")
+
+	instance.Discovery.StatefulSet = "headless"
+	_, err = r.discoverNodes(context.Background(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no serviceName")
+}
+
+func TestStatefulSetRollingOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*appsv1.StatefulSet)
+		want   bool
+	}{
+		{name: "settled", mutate: func(*appsv1.StatefulSet) {}},
+		{
+			name:   "spec change not yet observed",
+			mutate: func(s *appsv1.StatefulSet) { s.Generation = 3 },
+			want:   true,
+		},
+		{
+			name: "pods being replaced",
+			mutate: func(s *appsv1.StatefulSet) {
+				s.Status.UpdateRevision = "vault-2"
+				s.Status.UpdatedReplicas = 1
+			},
+			want: true,
+		},
+		{
+			name:   "updated replicas pending",
+			mutate: func(s *appsv1.StatefulSet) { s.Status.UpdatedReplicas = 2 },
+			want:   true,
+		},
+		{
+			name: "replicas default to one",
+			mutate: func(s *appsv1.StatefulSet) {
+				s.Spec.Replicas = nil
+				s.Status.UpdatedReplicas = 1
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statefulSet := newTestStatefulSet(3)
+			tt.mutate(statefulSet)
+			assert.Equal(t, tt.want, statefulSetRollingOut(statefulSet))
+		})
+	}
+}
+
+func TestRolloutInProgress(t *testing.T) {
+	rollingOut := newTestStatefulSet(3)
+	rollingOut.Status.UpdateRevision = "vault-2"
+	settled := newTestStatefulSet(3)
+	settled.Name = "settled"
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithObjects(rollingOut, settled).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "static", Endpoint: "https://vault.vault.svc:8200"},
+			{
+				Name: "settled", Endpoint: "https://vault.vault.svc:8200",
+				Discovery: &vaultv1.Discovery{StatefulSet: "settled"},
+			},
+			{
+				Name: "missing", Endpoint: "https://vault.vault.svc:8200",
+				Discovery: &vaultv1.Discovery{StatefulSet: "missing"},
+			},
+		}},
+	}
+	assert.False(t, r.rolloutInProgress(context.Background(), log.Log, vaultConfig))
+
+	disabled := false
+	vaultConfig.Spec.VaultInstances = append(vaultConfig.Spec.VaultInstances, vaultv1.VaultInstance{
+		Name: "rolling", Endpoint: "https://vault.vault.svc:8200", Enabled: &disabled,
+		Discovery: &vaultv1.Discovery{StatefulSet: "vault"},
+	})
+	assert.False(t, r.rolloutInProgress(context.Background(), log.Log, vaultConfig), "disabled instances are ignored")
+
+	vaultConfig.Spec.VaultInstances[3].Enabled = nil
+	assert.True(t, r.rolloutInProgress(context.Background(), log.Log, vaultConfig))
+}
+
+func TestFindVaultConfigsForStatefulSet(t *testing.T) {
+	byStatefulSet := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "by-statefulset", Namespace: "ops"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name: "vault", Endpoint: "https://vault.vault.svc:8200", Namespace: "vault",
+			Discovery: &vaultv1.Discovery{StatefulSet: "vault"},
+		}}},
+	}
+	otherStatefulSet := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name: "vault", Endpoint: "https://vault.vault.svc:8200",
+			Discovery: &vaultv1.Discovery{StatefulSet: "vault-dr"},
+		}}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithIndex(&vaultv1.VaultUnsealConfig{}, vaultConfigPodIndex, indexVaultConfigPods).
+		WithObjects(byStatefulSet, otherStatefulSet).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	requests := r.findVaultConfigsForStatefulSet(context.Background(), newTestStatefulSet(3))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "ops/by-statefulset", requests[0].Namespace+"/"+requests[0].Name)
+
+	// Pods of the StatefulSet map to the config even without a pod selector
+	controller := true
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "vault-1", Namespace: "vault",
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "StatefulSet", Name: "vault", UID: "uid", Controller: &controller,
+		}},
+	}}
+	requests = r.findVaultConfigsForPod(context.Background(), pod)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "ops/by-statefulset", requests[0].Namespace+"/"+requests[0].Name)
+}
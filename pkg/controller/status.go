@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateStatus writes the status of obj. When the write conflicts with a
+// concurrent change, obj is re-read, merge re-applies the computed status to the
+// fresh copy and the write is retried, so the status is neither lost nor
+// reported as a reconcile error.
+func updateStatus(ctx context.Context, c client.Client, obj client.Object, merge func()) error {
+	err := c.Status().Update(ctx, obj)
+	if !apierrors.IsConflict(err) {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		merge()
+		return c.Status().Update(ctx, obj)
+	})
+}
+
+// patchStatus is updateStatus for status computed against original: only the
+// difference is sent, guarded by the resource version of original.
+func patchStatus(ctx context.Context, c client.Client, obj, original client.Object, merge func()) error {
+	optimisticLock := client.MergeFromWithOptimisticLock{}
+	err := c.Status().Patch(ctx, obj, client.MergeFromWithOptions(original, optimisticLock))
+	if !apierrors.IsConflict(err) {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		base := obj.DeepCopyObject().(client.Object)
+		merge()
+		return c.Status().Patch(ctx, obj, client.MergeFromWithOptions(base, optimisticLock))
+	})
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUpdateStatusRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	check := &vaultv1.VaultHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "vault"},
+		Spec:       vaultv1.VaultHealthCheckSpec{Endpoint: "https://vault.dr:8200"},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultHealthCheck{}).
+		WithObjects(check).
+		Build()
+
+	var stale vaultv1.VaultHealthCheck
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(check), &stale))
+
+	// Another writer changes the object after it was read
+	var current vaultv1.VaultHealthCheck
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(check), &current))
+	current.Labels = map[string]string{"team": "dr"}
+	require.NoError(t, k8sClient.Update(ctx, &current))
+
+	stale.Status.Version = "1.15.0"
+	computed := stale.DeepCopy()
+	require.NoError(t, updateStatus(ctx, k8sClient, &stale, func() { stale.Status = computed.Status }))
+
+	var result vaultv1.VaultHealthCheck
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(check), &result))
+	assert.Equal(t, "1.15.0", result.Status.Version)
+	assert.Equal(t, "dr", result.Labels["team"])
+}
+
+func TestUnsealConfigPatchStatusKeepsForeignConditions(t *testing.T) {
+	ctx := context.Background()
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	var stale vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &stale))
+	original := stale.DeepCopy()
+
+	// An external controller records its own condition concurrently
+	var current vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &current))
+	meta.SetStatusCondition(&current.Status.Conditions, metav1.Condition{
+		Type: "PolicyCompliant", Status: metav1.ConditionTrue, Reason: "Checked",
+	})
+	require.NoError(t, k8sClient.Status().Update(ctx, &current))
+
+	stale.Status.VaultStatuses = []vaultv1.VaultInstanceStatus{{Name: "vault-0"}}
+	meta.SetStatusCondition(&stale.Status.Conditions, metav1.Condition{
+		Type: ConditionTypeReady, Status: metav1.ConditionTrue, Reason: "AllInstancesUnsealed",
+	})
+	require.NoError(t, r.patchStatus(ctx, &stale, original))
+
+	var result vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &result))
+	require.Len(t, result.Status.VaultStatuses, 1)
+	assert.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, ConditionTypeReady))
+	assert.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, "PolicyCompliant"))
+}
+
+func TestUnsealConfigPatchStatusMergesOwnedConditions(t *testing.T) {
+	ctx := context.Background()
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil, nil)
+
+	var stale vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &stale))
+	original := stale.DeepCopy()
+
+	// A concurrent write leaves every owned condition outdated
+	var current vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &current))
+	for _, conditionType := range unsealConfigConditionTypes {
+		meta.SetStatusCondition(&current.Status.Conditions, metav1.Condition{
+			Type: conditionType, Status: metav1.ConditionFalse, Reason: "Outdated",
+		})
+	}
+	require.NoError(t, k8sClient.Status().Update(ctx, &current))
+
+	// The computed status sets all owned conditions but the last, which cleared
+	last := len(unsealConfigConditionTypes) - 1
+	owned, cleared := unsealConfigConditionTypes[:last], unsealConfigConditionTypes[last]
+	for _, conditionType := range owned {
+		meta.SetStatusCondition(&stale.Status.Conditions, metav1.Condition{
+			Type: conditionType, Status: metav1.ConditionTrue, Reason: "Computed",
+		})
+	}
+	require.NoError(t, r.patchStatus(ctx, &stale, original))
+
+	var result vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(vaultConfig), &result))
+	for _, conditionType := range owned {
+		assert.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, conditionType), conditionType)
+	}
+	assert.Nil(t, meta.FindStatusCondition(result.Status.Conditions, cleared))
+
+	// Conditions added since the merge was written are owned as well
+	for _, conditionType := range []string{
+		ConditionTypeKeysAvailable, ConditionTypeUninitialized, ConditionTypeFrequentReseal, ConditionTypeQuarantined,
+	} {
+		assert.Contains(t, unsealConfigConditionTypes, conditionType)
+	}
+}
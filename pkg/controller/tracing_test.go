@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/mocks"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileTracesInstances(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1"}},
+		}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+	mockRepo := &mocks.MockVaultClientRepository{}
+	mockRepo.On("GetClient", mock.Anything, "vault/vault-0", mock.Anything).Return(nil, errors.New("boom"))
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), mockRepo, nil)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := r.Reconcile(vault.WithRequestID(context.Background(), "reconcile-1"), req)
+	require.NoError(t, err)
+
+	// The instance check is a child of the reconcile span, so Vault requests link to both
+	names := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans.Ended() {
+		names[span.Name()] = span
+	}
+	reconcile, check := names["VaultUnsealConfig.Reconcile"], names["VaultInstance.Check"]
+	require.NotNil(t, reconcile)
+	require.NotNil(t, check)
+	assert.Contains(t, reconcile.Attributes(), attribute.String("vault.reconcile_id", "reconcile-1"))
+	assert.Equal(t, reconcile.SpanContext().SpanID(), check.Parent().SpanID())
+	assert.Contains(t, check.Attributes(), attribute.String("vault.instance", "vault-0"))
+	assert.Equal(t, codes.Error, check.Status().Code)
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultTransitMount is the path of the Transit engine of a management Vault
+	DefaultTransitMount = "transit"
+	// DefaultKubernetesAuthMount is the path of the Kubernetes auth method of a management Vault
+	DefaultKubernetesAuthMount = "kubernetes"
+	// transitTokenExpiration is the lifetime requested for the service account
+	// tokens used to log in; the API server may issue longer ones
+	transitTokenExpiration = 10 * time.Minute
+	// transitCiphertextPrefix starts every Transit ciphertext
+	transitCiphertextPrefix = "vault:v"
+	// DefaultTransitAudience is the audience of the service account tokens used
+	// to log in, which the Kubernetes API server does not accept
+	DefaultTransitAudience = "vault"
+)
+
+// transitUnwrapError is a failure to log in to a management Vault or to decrypt
+// the keys with its Transit engine.
+type transitUnwrapError struct {
+	address string
+	err     error
+}
+
+func (e *transitUnwrapError) Error() string {
+	return fmt.Sprintf("transit unwrap with %s: %v", e.address, e.err)
+}
+
+func (e *transitUnwrapError) Unwrap() error {
+	return e.err
+}
+
+// transitUnwrap decrypts Transit ciphertexts with the management Vault of
+// unwrap. The token of the Kubernetes auth login is revoked once the
+// keys are decrypted.
+func (r *VaultUnsealConfigReconciler) transitUnwrap(
+	ctx context.Context,
+	namespace string,
+	unwrap *vaultv1.TransitUnwrap,
+	ciphertexts []string,
+) ([]string, error) {
+	for i, ciphertext := range ciphertexts {
+		if !strings.HasPrefix(ciphertext, transitCiphertextPrefix) {
+			return nil, vault.NewValidationError("unsealKeys", i,
+				fmt.Sprintf("key %d is not a Transit ciphertext", i))
+		}
+	}
+
+	jwt, err := r.transitLoginToken(ctx, namespace, unwrap)
+	if err != nil {
+		return nil, &transitUnwrapError{address: unwrap.Address, err: err}
+	}
+	transitClient, err := newTransitClient(unwrap, r.timeout())
+	if err != nil {
+		return nil, &transitUnwrapError{address: unwrap.Address, err: err}
+	}
+
+	keys, err := transitDecrypt(ctx, transitClient, unwrap, jwt, ciphertexts)
+	if err != nil {
+		return nil, &transitUnwrapError{address: unwrap.Address, err: err}
+	}
+	return keys, nil
+}
+
+// transitLoginToken requests the short-lived token of ServiceAccountName the
+// operator logs in to the management Vault with. The address is chosen by the
+// config's author, so the operator's own token is never sent, and the token is
+// bound to the audience set by the operator, never by the config, so it cannot
+// be one the Kubernetes API server accepts.
+func (r *VaultUnsealConfigReconciler) transitLoginToken(
+	ctx context.Context,
+	namespace string,
+	unwrap *vaultv1.TransitUnwrap,
+) (string, error) {
+	if unwrap.ServiceAccountName == "" {
+		return "", errors.New("serviceAccountName is required")
+	}
+	if r.Client == nil {
+		return "", errors.New("transit unwrap with a service account requires a Kubernetes client")
+	}
+
+	expiration := int64(transitTokenExpiration.Seconds())
+	audience := r.TransitAudience
+	if audience == "" {
+		audience = DefaultTransitAudience
+	}
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{audience},
+			ExpirationSeconds: &expiration,
+		},
+	}
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: unwrap.ServiceAccountName, Namespace: namespace},
+	}
+	if err := r.SubResource("token").Create(ctx, serviceAccount, request); err != nil {
+		return "", fmt.Errorf("failed to request a token of service account %s/%s: %w",
+			namespace, unwrap.ServiceAccountName, err)
+	}
+	return request.Status.Token, nil
+}
+
+// timeout returns the timeout of Vault requests.
+func (r *VaultUnsealConfigReconciler) timeout() time.Duration {
+	if r.Options == nil || r.Options.Timeout <= 0 {
+		return DefaultTimeoutSeconds * time.Second
+	}
+	return r.Options.Timeout
+}
+
+// newTransitClient creates a client of the management Vault of unwrap. The
+// VAULT_ environment of the operator is ignored, so no token leaks into it.
+func newTransitClient(unwrap *vaultv1.TransitUnwrap, timeout time.Duration) (*api.Client, error) {
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return nil, config.Error
+	}
+	config.Address = unwrap.Address
+	config.Timeout = timeout
+	config.MaxRetries = 0
+	if unwrap.TLSSkipVerify {
+		if err := config.ConfigureTLS(&api.TLSConfig{Insecure: true}); err != nil {
+			return nil, err
+		}
+	}
+	transitClient, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	transitClient.ClearToken()
+	transitClient.ClearNamespace()
+	return transitClient, nil
+}
+
+// transitDecrypt logs in with jwt and decrypts ciphertexts in one batch,
+// returning the plaintexts in order.
+func transitDecrypt(
+	ctx context.Context,
+	transitClient *api.Client,
+	unwrap *vaultv1.TransitUnwrap,
+	jwt string,
+	ciphertexts []string,
+) ([]string, error) {
+	authMount := mountPath(unwrap.AuthMount, DefaultKubernetesAuthMount)
+	login, err := transitClient.Logical().WriteWithContext(ctx, "auth/"+authMount+"/login", map[string]any{
+		"role": unwrap.Role,
+		"jwt":  jwt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes auth login as role %s: %w", unwrap.Role, err)
+	}
+	if login == nil || login.Auth == nil || login.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("kubernetes auth login as role %s returned no token", unwrap.Role)
+	}
+	transitClient.SetToken(login.Auth.ClientToken)
+	defer func() {
+		// The token is only needed for this decrypt; revoke it rather than wait for its TTL
+		_ = transitClient.Auth().Token().RevokeSelfWithContext(context.WithoutCancel(ctx), "")
+	}()
+
+	batch := make([]map[string]any, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		batch[i] = map[string]any{"ciphertext": ciphertext}
+	}
+	path := mountPath(unwrap.Mount, DefaultTransitMount) + "/decrypt/" + unwrap.Key
+	secret, err := transitClient.Logical().WriteWithContext(ctx, path, map[string]any{"batch_input": batch})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt with key %s: %w", unwrap.Key, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("decrypt with key %s returned no data", unwrap.Key)
+	}
+
+	results, _ := secret.Data["batch_results"].([]any)
+	if len(results) != len(ciphertexts) {
+		return nil, fmt.Errorf("decrypt with key %s returned %d results for %d keys",
+			unwrap.Key, len(results), len(ciphertexts))
+	}
+	keys := make([]string, len(results))
+	for i, result := range results {
+		item, _ := result.(map[string]any)
+		if message, _ := item["error"].(string); message != "" {
+			return nil, fmt.Errorf("decrypt key %d: %s", i, message)
+		}
+		plaintext, _ := item["plaintext"].(string)
+		decoded, err := base64.StdEncoding.DecodeString(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %d: invalid plaintext: %w", i, err)
+		}
+		if keys[i] = strings.TrimSpace(string(decoded)); keys[i] == "" {
+			return nil, fmt.Errorf("decrypt key %d: empty plaintext", i)
+		}
+	}
+	return keys, nil
+}
+
+// mountPath returns mount without surrounding slashes, or fallback when empty.
+func mountPath(mount, fallback string) string {
+	if mount = strings.Trim(mount, "/"); mount != "" {
+		return mount
+	}
+	return fallback
+}
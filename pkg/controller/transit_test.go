@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fakeTransitVault serves the Kubernetes auth login, Transit decrypt and token
+// revocation endpoints of a management Vault. Ciphertexts are "vault:v1:" and
+// the base64 plaintext.
+type fakeTransitVault struct {
+	mu      sync.Mutex
+	jwts    []string
+	revoked int
+}
+
+func (f *fakeTransitVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	switch r.URL.Path {
+	case "/v1/auth/k8s/login":
+		if body["role"] != "unsealer" {
+			http.Error(w, `{"errors":["invalid role name"]}`, http.StatusBadRequest)
+			return
+		}
+		f.jwts = append(f.jwts, body["jwt"].(string))
+		_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": "s.transit"}})
+	case "/v1/transit/decrypt/unseal":
+		if r.Header.Get("X-Vault-Token") != "s.transit" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		var results []map[string]any
+		for _, input := range body["batch_input"].([]any) {
+			ciphertext := input.(map[string]any)["ciphertext"].(string)
+			plaintext, ok := strings.CutPrefix(ciphertext, "vault:v1:")
+			if !ok {
+				results = append(results, map[string]any{"error": "invalid ciphertext: no prefix"})
+				continue
+			}
+			results = append(results, map[string]any{"plaintext": plaintext})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"batch_results": results}})
+	case "/v1/auth/token/revoke-self":
+		f.revoked++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// wrapKey returns the fake Transit ciphertext of key.
+func wrapKey(key string) string {
+	return "vault:v1:" + base64.StdEncoding.EncodeToString([]byte(key))
+}
+
+// newTokenRequestClient returns a client with the service account vault/unsealer
+// that records the audiences of the tokens requested for it.
+func newTokenRequestClient(audiences *[][]string) client.Client {
+	return fake.NewClientBuilder().
+		WithObjects(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "unsealer", Namespace: "vault"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceCreate: func(ctx context.Context, c client.Client, subResource string, obj client.Object,
+				request client.Object, opts ...client.SubResourceCreateOption) error {
+				if tokenRequest, ok := request.(*authenticationv1.TokenRequest); ok {
+					*audiences = append(*audiences, tokenRequest.Spec.Audiences)
+				}
+				return c.SubResource(subResource).Create(ctx, obj, request, opts...)
+			},
+		}).
+		Build()
+}
+
+func TestTransitUnwrap(t *testing.T) {
+	management := &fakeTransitVault{}
+	server := httptest.NewServer(management)
+	defer server.Close()
+
+	var audiences [][]string
+	r := NewVaultUnsealConfigReconciler(newTokenRequestClient(&audiences), log.Log, nil, nil, nil)
+
+	key1 := base64.StdEncoding.EncodeToString([]byte("share-1"))
+	key2 := base64.StdEncoding.EncodeToString([]byte("share-2"))
+	instance := &vaultv1.VaultInstance{
+		Name:       "vault",
+		UnsealKeys: []string{wrapKey("unused"), wrapKey(key1), wrapKey(key2)},
+		KeyIndices: []int{2, 1},
+		TransitUnwrap: &vaultv1.TransitUnwrap{
+			Address:            server.URL,
+			AuthMount:          "/k8s/",
+			Key:                "unseal",
+			Role:               "unsealer",
+			ServiceAccountName: "unsealer",
+		},
+	}
+
+	// The service account of the config's namespace logs in with a token the
+	// Kubernetes API server does not accept
+	keys, err := r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{key2, key1}, keys)
+	assert.Equal(t, []string{"fake-token"}, management.jwts)
+	assert.Equal(t, [][]string{{DefaultTransitAudience}}, audiences)
+	assert.Equal(t, 1, management.revoked)
+	require.NoError(t, r.checkKeySource(t.Context(), "vault", instance))
+
+	r.TransitAudience = "https://vault-mgmt.example.com"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://vault-mgmt.example.com"}, audiences[len(audiences)-1])
+
+	// The operator never logs in with its own token
+	instance.TransitUnwrap.ServiceAccountName = ""
+	logins := len(management.jwts)
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "serviceAccountName is required")
+	assert.Len(t, management.jwts, logins)
+
+	instance.TransitUnwrap.ServiceAccountName = "missing"
+	_, err = r.unsealKeys(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonTransitUnwrapFailed, classifyError(err))
+	assert.Error(t, r.checkKeySource(t.Context(), "vault", instance))
+}
+
+func TestTransitUnwrapFailures(t *testing.T) {
+	management := &fakeTransitVault{}
+	server := httptest.NewServer(management)
+	defer server.Close()
+
+	var audiences [][]string
+	r := NewVaultUnsealConfigReconciler(newTokenRequestClient(&audiences), log.Log, nil, nil, nil)
+	unwrap := &vaultv1.TransitUnwrap{
+		Address: server.URL, AuthMount: "k8s", Key: "unseal", Role: "unsealer", ServiceAccountName: "unsealer",
+	}
+
+	// Keys that were never wrapped are refused before reaching the management Vault
+	_, err := r.transitUnwrap(t.Context(), "vault", unwrap, []string{"raw-share"})
+	require.Error(t, err)
+	assert.Equal(t, ReasonInvalidKeys, classifyError(&keySourceError{err: err}))
+	assert.Empty(t, management.jwts)
+
+	_, err = r.transitUnwrap(t.Context(), "vault", unwrap, []string{wrapKey("k1"), "vault:v2:other"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decrypt key 1: invalid ciphertext")
+	assert.Equal(t, 1, management.revoked, "the login token is revoked after a failed decrypt")
+
+	unwrap.Role = "other"
+	_, err = r.transitUnwrap(t.Context(), "vault", unwrap, []string{wrapKey("k1")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "login as role other")
+	assert.Equal(t, ReasonTransitUnwrapFailed, classifyError(&keySourceError{err: err}))
+}
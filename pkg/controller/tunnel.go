@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// tunnelDialTimeout bounds connecting and logging in to a jump host
+	tunnelDialTimeout = 10 * time.Second
+	// defaultSSHPort is the port of SSH bastions without one
+	defaultSSHPort = "22"
+	// defaultSOCKS5Port is the port of SOCKS5 proxies without one
+	defaultSOCKS5Port = "1080"
+)
+
+// tunnel dials the connections of an instance through a jump host.
+type tunnel struct {
+	// key identifies the jump host and credentials, for client caching
+	key  string
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// tunnelError is a failure to connect or log in to the jump host of a tunnel,
+// as opposed to the jump host failing to reach Vault.
+type tunnelError struct {
+	address string
+	err     error
+}
+
+func (e *tunnelError) Error() string {
+	return fmt.Sprintf("tunnel via %s: %v", e.address, e.err)
+}
+
+func (e *tunnelError) Unwrap() error {
+	return e.err
+}
+
+type tunnelKey struct{}
+
+// withTunnel returns a context carrying the tunnel GetClient dials through.
+func withTunnel(ctx context.Context, t *tunnel) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tunnelKey{}, t)
+}
+
+// tunnelFromContext returns the tunnel of ctx, or nil.
+func tunnelFromContext(ctx context.Context) *tunnel {
+	t, _ := ctx.Value(tunnelKey{}).(*tunnel)
+	return t
+}
+
+// instanceTunnel returns the tunnel of instance with its credentials read from
+// namespace, or nil when the instance is dialed directly. Jump hosts are only
+// connected to on first use, so building a tunnel that is not used is cheap.
+func (r *VaultUnsealConfigReconciler) instanceTunnel(
+	ctx context.Context,
+	namespace string,
+	instance *vaultv1.VaultInstance,
+) (*tunnel, error) {
+	if instance.Tunnel == nil {
+		return nil, nil
+	}
+	if instanceAccessKey(instance) != "" {
+		return nil, fmt.Errorf("tunnel cannot be combined with %s access", instance.Access.Mode)
+	}
+
+	readOptional := func(ref *vaultv1.SecretKeyRef) ([]byte, error) {
+		if ref == nil {
+			return nil, nil
+		}
+		return readSecretKey(ctx, r.Client, namespace, *ref)
+	}
+
+	switch {
+	case instance.Tunnel.SSH != nil:
+		spec := instance.Tunnel.SSH
+		privateKey, err := readOptional(spec.PrivateKeySecretRef)
+		if err != nil {
+			return nil, err
+		}
+		password, err := readOptional(spec.PasswordSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		return newSSHTunnel(spec, privateKey, password)
+	case instance.Tunnel.SOCKS5 != nil:
+		spec := instance.Tunnel.SOCKS5
+		password, err := readOptional(spec.PasswordSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		return newSOCKS5Tunnel(spec, password)
+	default:
+		return nil, fmt.Errorf("tunnel of instance %s sets neither ssh nor socks5", instance.Name)
+	}
+}
+
+// tunnelDigest hashes the settings and credentials of a tunnel, so clients are
+// rebuilt when credentials rotate without keeping them in the cache key.
+func tunnelDigest(parts ...[]byte) string {
+	sum := sha256.Sum256(bytes.Join(parts, []byte{0}))
+	return hex.EncodeToString(sum[:8])
+}
+
+// withDefaultPort adds port to address unless it has one.
+func withDefaultPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), port)
+}
+
+// tunnelAddress returns the host:port of the jump host of t.
+func tunnelAddress(t *vaultv1.Tunnel) string {
+	switch {
+	case t.SSH != nil:
+		return withDefaultPort(t.SSH.Address, defaultSSHPort)
+	case t.SOCKS5 != nil:
+		return withDefaultPort(t.SOCKS5.Address, defaultSOCKS5Port)
+	default:
+		return ""
+	}
+}
+
+// newSSHTunnel returns a tunnel forwarding connections through an SSH bastion.
+func newSSHTunnel(spec *vaultv1.SSHTunnel, privateKey, password []byte) (*tunnel, error) {
+	var hostKeys []ssh.PublicKey
+	for _, line := range spec.HostKeys {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host key %q: %w", line, err)
+		}
+		hostKeys = append(hostKeys, key)
+	}
+	if len(hostKeys) == 0 {
+		return nil, fmt.Errorf("ssh tunnel requires at least one host key")
+	}
+
+	var auth []ssh.AuthMethod
+	if len(privateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if len(password) > 0 {
+		auth = append(auth, ssh.Password(strings.TrimSpace(string(password))))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("ssh tunnel requires a private key or password")
+	}
+
+	address := withDefaultPort(spec.Address, defaultSSHPort)
+	dialer := &sshDialer{
+		address: address,
+		config: &ssh.ClientConfig{
+			User:            spec.User,
+			Auth:            auth,
+			HostKeyCallback: fixedHostKeys(hostKeys),
+			Timeout:         tunnelDialTimeout,
+		},
+	}
+	digest := tunnelDigest([]byte(spec.User), []byte(strings.Join(spec.HostKeys, "\n")), privateKey, password)
+	return &tunnel{key: fmt.Sprintf("ssh:%s@%s:%s", spec.User, address, digest), dial: dialer.DialContext}, nil
+}
+
+// fixedHostKeys accepts a bastion presenting any of keys.
+func fixedHostKeys(keys []ssh.PublicKey) ssh.HostKeyCallback {
+	return func(_ string, _ net.Addr, presented ssh.PublicKey) error {
+		for _, key := range keys {
+			if bytes.Equal(key.Marshal(), presented.Marshal()) {
+				return nil
+			}
+		}
+		return fmt.Errorf("host key %s %s is not trusted", presented.Type(), ssh.FingerprintSHA256(presented))
+	}
+}
+
+// sshDialer forwards connections over one SSH connection to a bastion, which
+// is opened on first use and again after it drops.
+type sshDialer struct {
+	address string
+	config  *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// DialContext opens a connection to addr from the bastion.
+func (d *sshDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		// The bastion refusing to forward leaves the connection usable
+		var openErr *ssh.OpenChannelError
+		if !errors.As(err, &openErr) {
+			d.drop(client)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connect returns the connection to the bastion, opening it if needed.
+func (d *sshDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	dialer := net.Dialer{Timeout: tunnelDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, &tunnelError{address: d.address, err: err}
+	}
+	// Bound the handshake, which does not observe ctx
+	_ = conn.SetDeadline(time.Now().Add(tunnelDialTimeout))
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, d.address, d.config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, &tunnelError{address: d.address, err: err}
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, channels, requests)
+	d.client = client
+	go func() {
+		_ = client.Wait()
+		d.drop(client)
+	}()
+	return client, nil
+}
+
+// drop forgets client, if it is still the current connection, and closes it.
+func (d *sshDialer) drop(client *ssh.Client) {
+	d.mu.Lock()
+	if d.client == client {
+		d.client = nil
+	}
+	d.mu.Unlock()
+	_ = client.Close()
+}
+
+// newSOCKS5Tunnel returns a tunnel connecting through a SOCKS5 proxy.
+func newSOCKS5Tunnel(spec *vaultv1.SOCKS5Tunnel, password []byte) (*tunnel, error) {
+	address := withDefaultPort(spec.Address, defaultSOCKS5Port)
+	var auth *proxy.Auth
+	if spec.Username != "" {
+		auth = &proxy.Auth{User: spec.Username, Password: strings.TrimSpace(string(password))}
+	}
+	dialer, err := proxy.SOCKS5("tcp", address, auth, proxyForward{address: address})
+	if err != nil {
+		return nil, fmt.Errorf("invalid socks5 tunnel: %w", err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("socks5 dialer does not support contexts")
+	}
+	digest := tunnelDigest([]byte(spec.Username), password)
+	return &tunnel{key: fmt.Sprintf("socks5:%s@%s:%s", spec.Username, address, digest), dial: contextDialer.DialContext}, nil
+}
+
+// proxyForward connects to a SOCKS5 proxy, reporting failures as tunnel errors.
+type proxyForward struct {
+	address string
+}
+
+// Dial implements proxy.Dialer.
+func (f proxyForward) Dial(network, addr string) (net.Conn, error) {
+	return f.DialContext(context.Background(), network, addr)
+}
+
+// DialContext implements proxy.ContextDialer.
+func (f proxyForward) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: tunnelDialTimeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, &tunnelError{address: f.address, err: err}
+	}
+	return conn, nil
+}
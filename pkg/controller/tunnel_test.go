@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// jumpHost stands in for a bastion or proxy in another network: it resolves
+// names the operator cannot and counts the connections it forwards.
+type jumpHost struct {
+	hosts     map[string]string
+	forwarded atomic.Int32
+}
+
+func (j *jumpHost) dial(host string, port int) (net.Conn, error) {
+	if ip, ok := j.hosts[host]; ok {
+		host = ip
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err == nil {
+		j.forwarded.Add(1)
+	}
+	return conn, err
+}
+
+// pipe copies between a and b until either side closes.
+func pipe(a, b io.ReadWriteCloser) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+// serve accepts connections on a new listener until the test ends.
+func serve(t *testing.T, handle func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startSSHBastion runs an SSH server forwarding direct-tcpip channels and
+// returns its address and host key.
+func startSSHBastion(t *testing.T, j *jumpHost, user, password string) (string, string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if meta.User() == user && string(given) == password {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(signer)
+
+	address := serve(t, func(conn net.Conn) {
+		_, channels, requests, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(requests)
+		for newChannel := range channels {
+			var target struct {
+				Host       string
+				Port       uint32
+				OriginHost string
+				OriginPort uint32
+			}
+			if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+				_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+				continue
+			}
+			upstream, err := j.dial(target.Host, int(target.Port))
+			if err != nil {
+				_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, channelRequests, err := newChannel.Accept()
+			if err != nil {
+				_ = upstream.Close()
+				continue
+			}
+			go ssh.DiscardRequests(channelRequests)
+			go pipe(channel, upstream)
+		}
+	})
+	return address, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+// startSOCKS5Proxy runs a SOCKS5 proxy requiring username and password.
+func startSOCKS5Proxy(t *testing.T, j *jumpHost, user, password string) string {
+	return serve(t, func(conn net.Conn) {
+		defer func() { _ = conn.Close() }()
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		methods := make([]byte, header[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return
+		}
+		// Username/password authentication (RFC 1929)
+		_, _ = conn.Write([]byte{5, 2})
+		readField := func() string {
+			length := make([]byte, 1)
+			_, _ = io.ReadFull(conn, length)
+			field := make([]byte, length[0])
+			_, _ = io.ReadFull(conn, field)
+			return string(field)
+		}
+		version := make([]byte, 1)
+		if _, err := io.ReadFull(conn, version); err != nil {
+			return
+		}
+		if readField() != user || readField() != password {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+
+		request := make([]byte, 4)
+		if _, err := io.ReadFull(conn, request); err != nil || request[3] != 3 {
+			return
+		}
+		host := readField()
+		port := make([]byte, 2)
+		if _, err := io.ReadFull(conn, port); err != nil {
+			return
+		}
+		upstream, err := j.dial(host, int(binary.BigEndian.Uint16(port)))
+		if err != nil {
+			_, _ = conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		pipe(conn, upstream)
+	})
+}
+
+// startUnroutableVault serves a sealed Vault under a name only the jump host resolves.
+func startUnroutableVault(t *testing.T) (*jumpHost, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"sealed":true,"t":3,"n":5}`)
+	}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	return &jumpHost{hosts: map[string]string{"vault.internal": "127.0.0.1"}}, "http://vault.internal:" + port
+}
+
+func newTunnelTestReconciler(t *testing.T, secretData map[string][]byte) *VaultUnsealConfigReconciler {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jump-host", Namespace: "vault"},
+		Data:       secretData,
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).WithObjects(secret).Build()
+	return NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), NewDefaultVaultClientRepository(nil), nil)
+}
+
+func TestSSHTunnelReachesUnroutableVault(t *testing.T) {
+	jump, endpoint := startUnroutableVault(t)
+	address, hostKey := startSSHBastion(t, jump, "ops", "hunter2")
+	r := newTunnelTestReconciler(t, map[string][]byte{"password": []byte("hunter2\n")})
+
+	instance := &vaultv1.VaultInstance{
+		Name:     "remote",
+		Endpoint: endpoint,
+		Tunnel: &vaultv1.Tunnel{SSH: &vaultv1.SSHTunnel{
+			Address:           address,
+			User:              "ops",
+			HostKeys:          []string{hostKey},
+			PasswordSecretRef: &vaultv1.SecretKeyRef{Name: "jump-host", Key: "password"},
+		}},
+	}
+	tunnel, err := r.instanceTunnel(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	client, err := r.ClientRepository.GetClient(withTunnel(t.Context(), tunnel), "vault/remote", instance)
+	require.NoError(t, err)
+
+	sealed, err := client.IsSealed(t.Context())
+	require.NoError(t, err)
+	assert.True(t, sealed)
+	assert.Positive(t, jump.forwarded.Load())
+
+	// An untrusted bastion is refused before logging in
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+	instance.Tunnel.SSH.HostKeys = []string{string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey()))}
+	untrusted, err := r.instanceTunnel(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	assert.NotEqual(t, tunnel.key, untrusted.key, "a new host key builds a new client")
+	_, err = untrusted.dial(t.Context(), "tcp", "vault.internal:8200")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not trusted")
+	assert.Equal(t, ReasonTunnelFailed, classifyError(err))
+}
+
+func TestSOCKS5TunnelReachesUnroutableVault(t *testing.T) {
+	jump, endpoint := startUnroutableVault(t)
+	address := startSOCKS5Proxy(t, jump, "ops", "hunter2")
+	r := newTunnelTestReconciler(t, map[string][]byte{"password": []byte("hunter2")})
+
+	instance := &vaultv1.VaultInstance{
+		Name:     "remote",
+		Endpoint: endpoint,
+		Tunnel: &vaultv1.Tunnel{SOCKS5: &vaultv1.SOCKS5Tunnel{
+			Address:           address,
+			Username:          "ops",
+			PasswordSecretRef: &vaultv1.SecretKeyRef{Name: "jump-host", Key: "password"},
+		}},
+	}
+	tunnel, err := r.instanceTunnel(t.Context(), "vault", instance)
+	require.NoError(t, err)
+	client, err := r.ClientRepository.GetClient(withTunnel(t.Context(), tunnel), "vault/remote", instance)
+	require.NoError(t, err)
+
+	sealed, err := client.IsSealed(t.Context())
+	require.NoError(t, err)
+	assert.True(t, sealed)
+	assert.Equal(t, int32(1), jump.forwarded.Load())
+
+	// The password Secret must exist
+	instance.Tunnel.SOCKS5.PasswordSecretRef.Name = "missing"
+	_, err = r.instanceTunnel(t.Context(), "vault", instance)
+	require.Error(t, err)
+	assert.Equal(t, ReasonSecretMissing, classifyError(err))
+}
+
+func TestInstanceTunnelRejectsExecAccess(t *testing.T) {
+	r := newTunnelTestReconciler(t, nil)
+	instance := &vaultv1.VaultInstance{
+		Name:     "vault-0",
+		Endpoint: "http://127.0.0.1:8200",
+		Access:   &vaultv1.InstanceAccess{Mode: vaultv1.AccessModeExec, Pod: "vault-0"},
+		Tunnel:   &vaultv1.Tunnel{SOCKS5: &vaultv1.SOCKS5Tunnel{Address: "proxy.example.com"}},
+	}
+	_, err := r.instanceTunnel(t.Context(), "vault", instance)
+	assert.Error(t, err)
+}
+
+func TestTunnelAddress(t *testing.T) {
+	assert.Equal(t, "bastion.example.com:22", tunnelAddress(&vaultv1.Tunnel{SSH: &vaultv1.SSHTunnel{Address: "bastion.example.com"}}))
+	assert.Equal(t, "10.0.0.1:2222", tunnelAddress(&vaultv1.Tunnel{SSH: &vaultv1.SSHTunnel{Address: "10.0.0.1:2222"}}))
+	assert.Equal(t, "[fd00::1]:1080", tunnelAddress(&vaultv1.Tunnel{SOCKS5: &vaultv1.SOCKS5Tunnel{Address: "fd00::1"}}))
+	assert.Equal(t, "[fd00::1]:1080", tunnelAddress(&vaultv1.Tunnel{SOCKS5: &vaultv1.SOCKS5Tunnel{Address: "[fd00::1]"}}))
+}
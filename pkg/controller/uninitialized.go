@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+)
+
+// ConditionTypeUninitialized reports that instances of a config are not
+// initialized. It is abnormal-true: only present while true.
+const ConditionTypeUninitialized = "Uninitialized"
+
+// sealState reports whether vaultClient is sealed and initialized. Clients
+// unable to report initialization are taken to be initialized.
+func sealState(ctx context.Context, vaultClient vault.VaultClient) (sealed, initialized bool, err error) {
+	if stateClient, ok := vaultClient.(vault.SealStateClient); ok {
+		state, err := stateClient.SealState(ctx)
+		return state.Sealed, state.Initialized, err
+	}
+	sealed, err = vaultClient.IsSealed(ctx)
+	return sealed, true, err
+}
+
+// updateUninitializedCondition lists the instances waiting to be initialized.
+// Keys cannot unseal them, so they are left alone until an operator runs
+// vault operator init.
+func updateUninitializedCondition(vaultConfig *vaultv1.VaultUnsealConfig) {
+	var names []string
+	for i := range vaultConfig.Status.VaultStatuses {
+		if status := &vaultConfig.Status.VaultStatuses[i]; status.Reason == ReasonUninitialized {
+			names = append(names, status.Name)
+		}
+	}
+	setAbnormalCondition(vaultConfig, ConditionTypeUninitialized, len(names) > 0, ReasonUninitialized,
+		fmt.Sprintf("Waiting for vault to be initialized: %s", strings.Join(names, ", ")))
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileWaitsForUninitializedVault(t *testing.T) {
+	server := fakevault.New(t, fakevault.Uninitialized())
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"},
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{{
+			Name:       "vault-0",
+			Endpoint:   server.URL(),
+			UnsealKeys: []string{"a2V5MQ==", "a2V5Mg==", "a2V5Mw=="},
+		}}},
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
+		WithObjects(vaultConfig).
+		Build()
+
+	repo := NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repo.Close() }()
+	r := NewVaultUnsealConfigReconciler(k8sClient, log.Log, k8sClient.Scheme(), repo, nil)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "vault", Namespace: "vault"}}
+	_, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+
+	// No keys are submitted to a vault that has none
+	assert.Zero(t, server.Requests("sys/unseal"))
+	var updated vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.VaultStatuses, 1)
+	status := updated.Status.VaultStatuses[0]
+	assert.True(t, status.Sealed)
+	assert.Equal(t, ReasonUninitialized, status.Reason)
+	assert.Empty(t, status.Error)
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeUninitialized)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "vault-0")
+	assert.Equal(t, ReasonUninitialized, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeReady).Reason)
+
+	// Once initialized, the vault is unsealed with its keys
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = server.URL()
+	apiClient, err := api.NewClient(apiConfig)
+	require.NoError(t, err)
+	_, err = apiClient.Sys().Init(&api.InitRequest{SecretShares: 3, SecretThreshold: 2})
+	require.NoError(t, err)
+	updated.Spec.VaultInstances[0].UnsealKeys = server.Keys()
+	require.NoError(t, k8sClient.Update(t.Context(), &updated))
+
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(t.Context(), req.NamespacedName, &updated))
+	assert.False(t, server.Sealed())
+	assert.Nil(t, meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeUninitialized))
+}
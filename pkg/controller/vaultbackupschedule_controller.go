@@ -0,0 +1,308 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/backup"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DefaultSnapshotTimeoutMinutes bounds taking and uploading a single snapshot.
+	DefaultSnapshotTimeoutMinutes = 10
+	// DefaultBackupRetryDelaySeconds is the delay before retrying a failed backup.
+	DefaultBackupRetryDelaySeconds = 60
+
+	// ConditionTypeBackupSucceeded reports the outcome of the most recent backup attempt.
+	ConditionTypeBackupSucceeded = "BackupSucceeded"
+)
+
+// SnapshotClientFactory creates Vault clients able to take Raft snapshots.
+type SnapshotClientFactory func(
+	endpoint string, tlsSkipVerify bool, token string, timeout time.Duration,
+) (vault.SnapshotClient, error)
+
+// ObjectStoreFactory creates the object store for a backup destination.
+type ObjectStoreFactory func(dest *vaultv1.BackupDestination, creds backup.Credentials) (backup.ObjectStore, error)
+
+// DefaultSnapshotClientFactory creates a token-authenticated vault.Client.
+func DefaultSnapshotClientFactory(
+	endpoint string, tlsSkipVerify bool, token string, timeout time.Duration,
+) (vault.SnapshotClient, error) {
+	return vault.NewClientWithOptions(endpoint,
+		vault.WithTLSSkipVerify(tlsSkipVerify),
+		vault.WithTimeout(timeout),
+		vault.WithToken(token),
+	)
+}
+
+// BackupReconcilerOptions holds configuration for the backup reconciler.
+type BackupReconcilerOptions struct {
+	SnapshotTimeout time.Duration
+	RetryDelay      time.Duration
+	// ScratchDir holds snapshots while they are uploaded; defaults to os.TempDir()
+	ScratchDir string
+}
+
+// DefaultBackupReconcilerOptions returns default backup reconciler options.
+func DefaultBackupReconcilerOptions() *BackupReconcilerOptions {
+	return &BackupReconcilerOptions{
+		SnapshotTimeout: DefaultSnapshotTimeoutMinutes * time.Minute,
+		RetryDelay:      DefaultBackupRetryDelaySeconds * time.Second,
+	}
+}
+
+// VaultBackupScheduleReconciler reconciles a VaultBackupSchedule object
+type VaultBackupScheduleReconciler struct {
+	client.Client
+	Log                   logr.Logger
+	Scheme                *runtime.Scheme
+	Options               *BackupReconcilerOptions
+	SnapshotClientFactory SnapshotClientFactory
+	ObjectStoreFactory    ObjectStoreFactory
+
+	now func() time.Time
+}
+
+// NewVaultBackupScheduleReconciler creates a new backup reconciler with dependencies.
+func NewVaultBackupScheduleReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+	options *BackupReconcilerOptions,
+) *VaultBackupScheduleReconciler {
+	if options == nil {
+		options = DefaultBackupReconcilerOptions()
+	}
+
+	return &VaultBackupScheduleReconciler{
+		Client:                client,
+		Log:                   logger,
+		Scheme:                scheme,
+		Options:               options,
+		SnapshotClientFactory: DefaultSnapshotClientFactory,
+		ObjectStoreFactory:    backup.NewObjectStore,
+		now:                   time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=vault.io,resources=vaultbackupschedules,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=vault.io,resources=vaultbackupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (r *VaultBackupScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "VaultBackupSchedule")
+
+	var schedule vaultv1.VaultBackupSchedule
+	if err := r.Get(ctx, req.NamespacedName, &schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if schedule.Spec.Suspend {
+		logger.V(1).Info("Backup schedule is suspended", "name", schedule.Name)
+		return ctrl.Result{}, nil
+	}
+
+	interval := schedule.Spec.Interval.Duration
+	if interval <= 0 {
+		r.setCondition(&schedule, metav1.ConditionFalse, "InvalidSpec", "spec.interval must be greater than zero")
+		return ctrl.Result{}, r.updateStatus(ctx, &schedule)
+	}
+
+	now := r.now()
+	if last := schedule.Status.LastSnapshotTime; last != nil {
+		if next := last.Add(interval); now.Before(next) {
+			return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	logger.Info("Taking scheduled Raft snapshot", "name", schedule.Name, "endpoint", schedule.Spec.Endpoint)
+
+	snapshot, err := r.backup(ctx, &schedule, now)
+	if err != nil {
+		logger.Error(err, "scheduled backup failed")
+		r.setCondition(&schedule, metav1.ConditionFalse, "BackupFailed", err.Error())
+		if updateErr := r.updateStatus(ctx, &schedule); updateErr != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", updateErr)
+		}
+		return ctrl.Result{RequeueAfter: minDuration(r.Options.RetryDelay, interval)}, nil
+	}
+
+	schedule.Status.Snapshots = append(schedule.Status.Snapshots, *snapshot)
+	schedule.Status.LastSnapshotTime = &snapshot.Timestamp
+	r.applyRetention(ctx, logger, &schedule)
+	r.setCondition(&schedule, metav1.ConditionTrue, "SnapshotUploaded",
+		fmt.Sprintf("Uploaded snapshot %s (%d bytes)", snapshot.Key, snapshot.Size))
+
+	if err := r.updateStatus(ctx, &schedule); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.Info("Raft snapshot uploaded", "key", snapshot.Key, "size", snapshot.Size)
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// backup takes a snapshot into a scratch file and uploads it to the destination.
+func (r *VaultBackupScheduleReconciler) backup(
+	ctx context.Context,
+	schedule *vaultv1.VaultBackupSchedule,
+	now time.Time,
+) (*vaultv1.BackupSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Options.SnapshotTimeout)
+	defer cancel()
+
+	store, err := newObjectStore(ctx, r.Client, r.ObjectStoreFactory, schedule.Namespace, &schedule.Spec.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := readSecretKey(ctx, r.Client, schedule.Namespace, schedule.Spec.TokenSecretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	snapClient, err := r.SnapshotClientFactory(schedule.Spec.Endpoint, schedule.Spec.TLSSkipVerify,
+		string(token), r.Options.SnapshotTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	defer func() { _ = snapClient.Close() }()
+
+	scratch, err := os.CreateTemp(r.Options.ScratchDir, "vault-snapshot-*.snap")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch file: %w", err)
+	}
+	defer func() {
+		_ = scratch.Close()
+		_ = os.Remove(scratch.Name())
+	}()
+
+	if err := snapClient.RaftSnapshot(ctx, scratch); err != nil {
+		return nil, fmt.Errorf("failed to take raft snapshot: %w", err)
+	}
+
+	size, err := scratch.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size snapshot: %w", err)
+	}
+	if _, err := scratch.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind snapshot: %w", err)
+	}
+
+	key := backup.ObjectKey(&schedule.Spec.Destination, schedule.Name, now)
+	if err := store.Put(ctx, key, scratch, size); err != nil {
+		return nil, fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+
+	return &vaultv1.BackupSnapshot{
+		Key:       key,
+		Size:      size,
+		Timestamp: metav1.NewTime(now),
+	}, nil
+}
+
+// newObjectStore builds the object store for a destination from its credentials Secret.
+func newObjectStore(
+	ctx context.Context,
+	c client.Reader,
+	factory ObjectStoreFactory,
+	namespace string,
+	dest *vaultv1.BackupDestination,
+) (backup.ObjectStore, error) {
+	secretName, err := backup.CredentialsSecretName(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := readSecretData(ctx, c, namespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	return factory(dest, creds)
+}
+
+// applyRetention deletes the oldest snapshots beyond spec.retention.maxCount.
+// Snapshots that fail to delete stay in status so deletion is retried next time.
+func (r *VaultBackupScheduleReconciler) applyRetention(
+	ctx context.Context,
+	logger logr.Logger,
+	schedule *vaultv1.VaultBackupSchedule,
+) {
+	maxCount := schedule.Spec.Retention.MaxCount
+	excess := len(schedule.Status.Snapshots) - maxCount
+	if maxCount <= 0 || excess <= 0 {
+		return
+	}
+
+	store, err := newObjectStore(ctx, r.Client, r.ObjectStoreFactory, schedule.Namespace, &schedule.Spec.Destination)
+	if err != nil {
+		logger.Error(err, "unable to apply snapshot retention")
+		return
+	}
+
+	kept := make([]vaultv1.BackupSnapshot, 0, len(schedule.Status.Snapshots))
+	for i, snapshot := range schedule.Status.Snapshots {
+		if i >= excess {
+			kept = append(kept, snapshot)
+			continue
+		}
+		if err := store.Delete(ctx, snapshot.Key); err != nil {
+			logger.Error(err, "failed to delete expired snapshot", "key", snapshot.Key)
+			kept = append(kept, snapshot)
+			continue
+		}
+		logger.V(1).Info("Deleted expired snapshot", "key", snapshot.Key)
+	}
+	schedule.Status.Snapshots = kept
+}
+
+// updateStatus writes the status, retrying on conflicts with the computed status.
+func (r *VaultBackupScheduleReconciler) updateStatus(ctx context.Context, schedule *vaultv1.VaultBackupSchedule) error {
+	computed := schedule.DeepCopy()
+	return updateStatus(ctx, r.Client, schedule, func() { schedule.Status = computed.Status })
+}
+
+func (r *VaultBackupScheduleReconciler) setCondition(
+	schedule *vaultv1.VaultBackupSchedule,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&schedule.Status.Conditions, metav1.Condition{
+		Type:               ConditionTypeBackupSucceeded,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: schedule.Generation,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VaultBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status writes must not retrigger a snapshot; the schedule drives requeues
+		For(&vaultv1.VaultBackupSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/backup"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeSnapshotClient struct {
+	data     string
+	token    string
+	restored string
+	forced   bool
+}
+
+func (f *fakeSnapshotClient) RaftSnapshot(_ context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, f.data)
+	return err
+}
+
+func (f *fakeSnapshotClient) RaftSnapshotRestore(_ context.Context, r io.Reader, force bool) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.restored = string(data)
+	f.forced = force
+	return nil
+}
+
+func (f *fakeSnapshotClient) Close() error { return nil }
+
+type memoryObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	deleted []string
+}
+
+func (m *memoryObjectStore) Put(_ context.Context, key string, body io.ReadSeeker, _ int64) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryObjectStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryObjectStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	m.deleted = append(m.deleted, key)
+	return nil
+}
+
+func newBackupTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, vaultv1.AddToScheme(scheme))
+	return scheme
+}
+
+func newBackupSchedule(maxCount int) *vaultv1.VaultBackupSchedule {
+	return &vaultv1.VaultBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "vault"},
+		Spec: vaultv1.VaultBackupScheduleSpec{
+			Endpoint:       "https://vault.vault.svc:8200",
+			TokenSecretRef: vaultv1.SecretKeyRef{Name: "vault-token", Key: "token"},
+			Interval:       metav1.Duration{Duration: time.Hour},
+			Destination: vaultv1.BackupDestination{
+				S3: &vaultv1.S3Destination{Bucket: "b", Prefix: "raft", CredentialsSecretRef: "s3-creds"},
+			},
+			Retention: vaultv1.BackupRetention{MaxCount: maxCount},
+		},
+	}
+}
+
+func newBackupTestReconciler(
+	t *testing.T,
+	schedule *vaultv1.VaultBackupSchedule,
+	store *memoryObjectStore,
+	now *time.Time,
+) (*VaultBackupScheduleReconciler, client.Client, *fakeSnapshotClient) {
+	t.Helper()
+	scheme := newBackupTestScheme(t)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&vaultv1.VaultBackupSchedule{}).
+		WithObjects(
+			schedule,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "vault"},
+				Data:       map[string][]byte{"token": []byte("s.token")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "s3-creds", Namespace: "vault"},
+				Data: map[string][]byte{
+					backup.AccessKeyIDKey:     []byte("id"),
+					backup.SecretAccessKeyKey: []byte("secret"),
+				},
+			},
+		).
+		Build()
+
+	snapClient := &fakeSnapshotClient{data: "snapshot-bytes"}
+	r := NewVaultBackupScheduleReconciler(k8sClient, log.Log, scheme, nil)
+	r.Options.ScratchDir = t.TempDir()
+	r.SnapshotClientFactory = func(_ string, _ bool, token string, _ time.Duration) (vault.SnapshotClient, error) {
+		snapClient.token = token
+		return snapClient, nil
+	}
+	r.ObjectStoreFactory = func(_ *vaultv1.BackupDestination, _ backup.Credentials) (backup.ObjectStore, error) {
+		return store, nil
+	}
+	r.now = func() time.Time { return *now }
+	return r, k8sClient, snapClient
+}
+
+func TestBackupScheduleTakesAndUploadsSnapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+	r, k8sClient, snapClient := newBackupTestReconciler(t, newBackupSchedule(0), store, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+	assert.Equal(t, "s.token", snapClient.token)
+	assert.Equal(t, []byte("snapshot-bytes"), store.objects["raft/nightly-20240101T000000Z.snap"])
+
+	var updated vaultv1.VaultBackupSchedule
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.Snapshots, 1)
+	assert.Equal(t, int64(len("snapshot-bytes")), updated.Status.Snapshots[0].Size)
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionTypeBackupSucceeded))
+
+	// Not yet due: no new snapshot, requeue for the remainder of the interval
+	now = now.Add(20 * time.Minute)
+	result, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 40*time.Minute, result.RequeueAfter)
+	assert.Len(t, store.objects, 1)
+}
+
+func TestBackupScheduleAppliesRetention(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+	r, k8sClient, _ := newBackupTestReconciler(t, newBackupSchedule(2), store, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "vault"}}
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+	}
+
+	var updated vaultv1.VaultBackupSchedule
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	require.Len(t, updated.Status.Snapshots, 2)
+	assert.Equal(t, []string{"raft/nightly-20240101T000000Z.snap"}, store.deleted)
+	assert.Len(t, store.objects, 2)
+}
+
+func TestBackupScheduleReportsMissingToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule := newBackupSchedule(0)
+	schedule.Spec.TokenSecretRef.Key = "missing"
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+	r, k8sClient, _ := newBackupTestReconciler(t, schedule, store, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBackupRetryDelaySeconds*time.Second, result.RequeueAfter)
+	assert.Empty(t, store.objects)
+
+	var updated vaultv1.VaultBackupSchedule
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	cond := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeBackupSucceeded)
+	require.NotNil(t, cond)
+	assert.Equal(t, "BackupFailed", cond.Reason)
+}
+
+func TestBackupScheduleSuspended(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule := newBackupSchedule(0)
+	schedule.Spec.Suspend = true
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+	r, _, _ := newBackupTestReconciler(t, schedule, store, &now)
+
+	result, err := r.Reconcile(context.Background(),
+		ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "vault"}})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Empty(t, store.objects)
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// VaultFleetStatusReconciler maintains the singleton VaultFleetStatus summarizing
+// every VaultUnsealConfig in the cluster.
+type VaultFleetStatusReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	now func() time.Time
+}
+
+// NewVaultFleetStatusReconciler creates a new fleet status reconciler with dependencies.
+func NewVaultFleetStatusReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+) *VaultFleetStatusReconciler {
+	return &VaultFleetStatusReconciler{
+		Client: client,
+		Log:    logger,
+		Scheme: scheme,
+		now:    time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=vault.io,resources=vaultfleetstatuses,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=vault.io,resources=vaultfleetstatuses/status,verbs=get;update;patch
+
+func (r *VaultFleetStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "VaultFleetStatus")
+
+	if req.Name != vaultv1.FleetStatusName {
+		return ctrl.Result{}, nil
+	}
+
+	var configs vaultv1.VaultUnsealConfigList
+	if err := r.List(ctx, &configs); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list VaultUnsealConfigs: %w", err)
+	}
+	summary := summarizeFleet(configs.Items)
+
+	var fleet vaultv1.VaultFleetStatus
+	err := r.Get(ctx, req.NamespacedName, &fleet)
+	switch {
+	case apierrors.IsNotFound(err):
+		fleet = vaultv1.VaultFleetStatus{ObjectMeta: metav1.ObjectMeta{Name: vaultv1.FleetStatusName}}
+		if err := r.Create(ctx, &fleet); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create VaultFleetStatus: %w", err)
+		}
+		logger.Info("Created VaultFleetStatus")
+	case err != nil:
+		return ctrl.Result{}, err
+	}
+
+	// Config status updates arrive on every periodic check; only write real changes
+	summary.LastUpdated = fleet.Status.LastUpdated
+	if summary.LastUpdated != nil && equality.Semantic.DeepEqual(fleet.Status, summary) {
+		return ctrl.Result{}, nil
+	}
+
+	now := metav1.NewTime(r.now())
+	summary.LastUpdated = &now
+	fleet.Status = summary
+	if err := updateStatus(ctx, r.Client, &fleet, func() { fleet.Status = summary }); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.V(1).Info("Updated VaultFleetStatus",
+		"configs", summary.TotalConfigs, "sealed", summary.SealedInstances, "failing", summary.FailingInstances)
+	return ctrl.Result{}, nil
+}
+
+// summarizeFleet aggregates the reported state of the given configs.
+func summarizeFleet(configs []vaultv1.VaultUnsealConfig) vaultv1.VaultFleetStatusStatus {
+	summary := vaultv1.VaultFleetStatusStatus{
+		TotalConfigs: len(configs),
+		Configs:      make([]vaultv1.FleetConfigSummary, 0, len(configs)),
+	}
+
+	for i := range configs {
+		config := &configs[i]
+		configSummary := vaultv1.FleetConfigSummary{
+			Namespace: config.Namespace,
+			Name:      config.Name,
+			Instances: len(config.Spec.VaultInstances),
+		}
+		for _, status := range config.Status.VaultStatuses {
+			if status.Sealed {
+				configSummary.SealedInstances++
+			}
+			if status.Error != "" {
+				configSummary.FailingInstances++
+			}
+		}
+		if ready := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeReady); ready != nil {
+			configSummary.Ready = ready.Status == metav1.ConditionTrue
+			transition := ready.LastTransitionTime
+			configSummary.LastTransitionTime = &transition
+			if summary.LastTransitionTime == nil || summary.LastTransitionTime.Before(&transition) {
+				summary.LastTransitionTime = configSummary.LastTransitionTime.DeepCopy()
+			}
+		}
+
+		if configSummary.Ready {
+			summary.ReadyConfigs++
+		}
+		summary.TotalInstances += configSummary.Instances
+		summary.SealedInstances += configSummary.SealedInstances
+		summary.FailingInstances += configSummary.FailingInstances
+		summary.Configs = append(summary.Configs, configSummary)
+	}
+
+	sort.Slice(summary.Configs, func(i, j int) bool {
+		a, b := summary.Configs[i], summary.Configs[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return summary
+}
+
+// fleetStatusRequest maps any VaultUnsealConfig change to the singleton fleet status.
+func fleetStatusRequest(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: vaultv1.FleetStatusName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VaultFleetStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Own status writes must not trigger another aggregation
+		For(&vaultv1.VaultFleetStatus{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&vaultv1.VaultUnsealConfig{}, handler.EnqueueRequestsFromMapFunc(fleetStatusRequest)).
+		Complete(r)
+}
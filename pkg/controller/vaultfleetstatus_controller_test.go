@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func fleetTestConfig(namespace, name string, ready bool, transition time.Time,
+	statuses ...vaultv1.VaultInstanceStatus) *vaultv1.VaultUnsealConfig {
+	condition := metav1.Condition{
+		Type:               ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             "SomeInstancesSealed",
+		LastTransitionTime: metav1.NewTime(transition),
+	}
+	if ready {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "AllInstancesUnsealed"
+	}
+
+	instances := make([]vaultv1.VaultInstance, 0, len(statuses))
+	for _, status := range statuses {
+		instances = append(instances, vaultv1.VaultInstance{Name: status.Name})
+	}
+	return &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       vaultv1.VaultUnsealConfigSpec{VaultInstances: instances},
+		Status: vaultv1.VaultUnsealConfigStatus{
+			Conditions:    []metav1.Condition{condition},
+			VaultStatuses: statuses,
+		},
+	}
+}
+
+func TestFleetStatusAggregatesConfigs(t *testing.T) {
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultFleetStatus{}).
+		WithObjects(
+			fleetTestConfig("vault", "primary", true, earlier,
+				vaultv1.VaultInstanceStatus{Name: "vault-0"}, vaultv1.VaultInstanceStatus{Name: "vault-1"}),
+			fleetTestConfig("dr", "secondary", false, later,
+				vaultv1.VaultInstanceStatus{Name: "vault-0", Sealed: true},
+				vaultv1.VaultInstanceStatus{Name: "vault-1", Sealed: true, Error: "connection refused"}),
+		).
+		Build()
+
+	r := NewVaultFleetStatusReconciler(k8sClient, log.Log, k8sClient.Scheme())
+	updatedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return updatedAt }
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: vaultv1.FleetStatusName}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var fleet vaultv1.VaultFleetStatus
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &fleet))
+	assert.Equal(t, 2, fleet.Status.TotalConfigs)
+	assert.Equal(t, 1, fleet.Status.ReadyConfigs)
+	assert.Equal(t, 4, fleet.Status.TotalInstances)
+	assert.Equal(t, 2, fleet.Status.SealedInstances)
+	assert.Equal(t, 1, fleet.Status.FailingInstances)
+	assert.True(t, fleet.Status.LastTransitionTime.Time.Equal(later))
+	require.Len(t, fleet.Status.Configs, 2)
+	assert.Equal(t, "dr", fleet.Status.Configs[0].Namespace, "configs are sorted")
+	assert.True(t, fleet.Status.Configs[1].Ready)
+
+	// An unchanged fleet is not rewritten
+	r.now = func() time.Time { return updatedAt.Add(time.Hour) }
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &fleet))
+	assert.True(t, fleet.Status.LastUpdated.Time.Equal(updatedAt))
+}
+
+func TestFleetStatusIgnoresOtherNames(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().WithScheme(newBackupTestScheme(t)).Build()
+	r := NewVaultFleetStatusReconciler(k8sClient, log.Log, k8sClient.Scheme())
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "other"}})
+	require.NoError(t, err)
+
+	var fleets vaultv1.VaultFleetStatusList
+	require.NoError(t, k8sClient.List(context.Background(), &fleets))
+	assert.Empty(t, fleets.Items)
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DefaultHealthCheckIntervalSeconds is the default time between health checks.
+	DefaultHealthCheckIntervalSeconds = 30
+
+	// ConditionTypeReachable reports whether the observed Vault answered the last check.
+	ConditionTypeReachable = "Reachable"
+	// ConditionTypeHealthy reports whether the observed Vault is initialized and unsealed.
+	ConditionTypeHealthy = "Healthy"
+)
+
+// VaultHealthCheckReconciler reconciles a VaultHealthCheck object. It only reads
+// health information and never submits unseal keys.
+type VaultHealthCheckReconciler struct {
+	client.Client
+	Log           logr.Logger
+	Scheme        *runtime.Scheme
+	ClientFactory vault.ClientFactory
+	// Metrics is optional; when nil no metrics are recorded
+	Metrics *metrics.HealthCheckMetrics
+
+	now func() time.Time
+}
+
+// NewVaultHealthCheckReconciler creates a new health check reconciler with dependencies.
+func NewVaultHealthCheckReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+	healthMetrics *metrics.HealthCheckMetrics,
+) *VaultHealthCheckReconciler {
+	return &VaultHealthCheckReconciler{
+		Client:        client,
+		Log:           logger,
+		Scheme:        scheme,
+		ClientFactory: &vault.DefaultClientFactory{},
+		Metrics:       healthMetrics,
+		now:           time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=vault.io,resources=vaulthealthchecks,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vault.io,resources=vaulthealthchecks/status,verbs=get;update;patch
+
+func (r *VaultHealthCheckReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "VaultHealthCheck")
+
+	var check vaultv1.VaultHealthCheck
+	if err := r.Get(ctx, req.NamespacedName, &check); err != nil {
+		if apierrors.IsNotFound(err) && r.Metrics != nil {
+			r.Metrics.Delete(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interval := DefaultHealthCheckIntervalSeconds * time.Second
+	if check.Spec.Interval != nil && check.Spec.Interval.Duration > 0 {
+		interval = check.Spec.Interval.Duration
+	}
+
+	state, err := r.check(ctx, &check)
+	if err != nil {
+		logger.V(1).Info("Vault health check failed", "endpoint", check.Spec.Endpoint, "error", err.Error())
+	}
+
+	r.applyState(&check, state, err)
+	if r.Metrics != nil {
+		r.Metrics.Observe(check.Namespace, check.Name, state)
+	}
+
+	computed := check.DeepCopy()
+	if err := updateStatus(ctx, r.Client, &check, func() { check.Status = computed.Status }); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// check queries the health endpoint of the observed Vault.
+func (r *VaultHealthCheckReconciler) check(
+	ctx context.Context,
+	check *vaultv1.VaultHealthCheck,
+) (metrics.HealthState, error) {
+	vaultClient, err := r.ClientFactory.NewClient(check.Spec.Endpoint, check.Spec.TLSSkipVerify,
+		DefaultTimeoutSeconds*time.Second)
+	if err != nil {
+		return metrics.HealthState{}, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	defer func() { _ = vaultClient.Close() }()
+
+	health, err := vaultClient.HealthCheck(ctx)
+	if err != nil {
+		return metrics.HealthState{}, err
+	}
+
+	return metrics.HealthState{
+		Reachable:   true,
+		Initialized: health.Initialized,
+		Sealed:      health.Sealed,
+		Standby:     health.Standby,
+		Version:     health.Version,
+		ClusterName: health.ClusterName,
+		ClusterID:   health.ClusterID,
+	}, nil
+}
+
+// applyState copies the observed state and conditions into the status.
+func (r *VaultHealthCheckReconciler) applyState(
+	check *vaultv1.VaultHealthCheck,
+	state metrics.HealthState,
+	checkErr error,
+) {
+	now := metav1.NewTime(r.now())
+	check.Status.LastCheckTime = &now
+	check.Status.Reachable = state.Reachable
+
+	if checkErr != nil {
+		r.setCondition(check, ConditionTypeReachable, metav1.ConditionFalse, "Unreachable", checkErr.Error())
+		r.setCondition(check, ConditionTypeHealthy, metav1.ConditionUnknown, "Unreachable",
+			"Vault did not respond to the health check")
+		return
+	}
+
+	check.Status.Initialized = state.Initialized
+	check.Status.Sealed = state.Sealed
+	check.Status.Standby = state.Standby
+	check.Status.Version = state.Version
+	check.Status.ClusterName = state.ClusterName
+	check.Status.ClusterID = state.ClusterID
+	r.setCondition(check, ConditionTypeReachable, metav1.ConditionTrue, "Responding", "Vault responded to the health check")
+
+	switch {
+	case !state.Initialized:
+		r.setCondition(check, ConditionTypeHealthy, metav1.ConditionFalse, "Uninitialized", "Vault is not initialized")
+	case state.Sealed:
+		r.setCondition(check, ConditionTypeHealthy, metav1.ConditionFalse, "Sealed", "Vault is sealed")
+	default:
+		r.setCondition(check, ConditionTypeHealthy, metav1.ConditionTrue, "Unsealed", "Vault is initialized and unsealed")
+	}
+}
+
+func (r *VaultHealthCheckReconciler) setCondition(
+	check *vaultv1.VaultHealthCheck,
+	conditionType string,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&check.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: check.Generation,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VaultHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Checks are driven by the interval; status writes must not trigger extra checks
+		For(&vaultv1.VaultHealthCheck{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
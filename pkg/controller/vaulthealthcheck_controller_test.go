@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newHealthCheckTestReconciler(
+	t *testing.T,
+	check *vaultv1.VaultHealthCheck,
+) (*VaultHealthCheckReconciler, client.Client, *vault.MockVaultClient) {
+	t.Helper()
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultHealthCheck{}).
+		WithObjects(check).
+		Build()
+
+	vaultClient := vault.NewMockVaultClient()
+	r := NewVaultHealthCheckReconciler(k8sClient, log.Log, k8sClient.Scheme(),
+		metrics.NewHealthCheckMetrics(prometheus.NewRegistry()))
+	r.ClientFactory = &staticClientFactory{client: vaultClient}
+	return r, k8sClient, vaultClient
+}
+
+func TestHealthCheckReportsSealedVault(t *testing.T) {
+	check := &vaultv1.VaultHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr-vault", Namespace: "vault"},
+		Spec: vaultv1.VaultHealthCheckSpec{
+			Endpoint: "https://dr-vault.vault.svc:8200",
+			Interval: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	r, k8sClient, vaultClient := newHealthCheckTestReconciler(t, check)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "dr-vault", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+	assert.Equal(t, 0, vaultClient.GetCallCount("Unseal"), "health checks must never unseal")
+
+	var updated vaultv1.VaultHealthCheck
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	assert.True(t, updated.Status.Reachable)
+	assert.True(t, updated.Status.Sealed)
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionTypeReachable))
+	cond := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeHealthy)
+	require.NotNil(t, cond)
+	assert.Equal(t, "Sealed", cond.Reason)
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.Metrics.Sealed.WithLabelValues("vault", "dr-vault")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(r.Metrics.Up.WithLabelValues("vault", "dr-vault")))
+
+	vaultClient.SetSealed(false)
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionTypeHealthy))
+	assert.Equal(t, 0.0, testutil.ToFloat64(r.Metrics.Sealed.WithLabelValues("vault", "dr-vault")))
+}
+
+func TestHealthCheckReportsUnreachableVault(t *testing.T) {
+	check := &vaultv1.VaultHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr-vault", Namespace: "vault"},
+		Spec:       vaultv1.VaultHealthCheckSpec{Endpoint: "https://dr-vault.vault.svc:8200"},
+	}
+	r, k8sClient, vaultClient := newHealthCheckTestReconciler(t, check)
+	vaultClient.SetFailHealthCheck(true)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "dr-vault", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHealthCheckIntervalSeconds*time.Second, result.RequeueAfter)
+
+	var updated vaultv1.VaultHealthCheck
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &updated))
+	assert.False(t, updated.Status.Reachable)
+	assert.False(t, meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionTypeReachable))
+	assert.Equal(t, 0.0, testutil.ToFloat64(r.Metrics.Up.WithLabelValues("vault", "dr-vault")))
+
+	// Deleting the resource removes its series
+	require.NoError(t, k8sClient.Delete(context.Background(), &updated))
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, testutil.CollectAndCount(r.Metrics.Up))
+}
@@ -0,0 +1,387 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/backup"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DefaultRestoreUnsealTimeoutMinutes bounds the wait for Vault to be unsealed after a restore.
+	DefaultRestoreUnsealTimeoutMinutes = 5
+	// DefaultRestorePollSeconds is the interval between seal status checks after a restore.
+	DefaultRestorePollSeconds = 5
+
+	// ConditionTypeRestoreSucceeded reports the outcome of a restore.
+	ConditionTypeRestoreSucceeded = "RestoreSucceeded"
+)
+
+// RestoreReconcilerOptions holds configuration for the restore reconciler.
+type RestoreReconcilerOptions struct {
+	// RestoreTimeout bounds downloading and applying a single snapshot
+	RestoreTimeout time.Duration
+	PollInterval   time.Duration
+}
+
+// DefaultRestoreReconcilerOptions returns default restore reconciler options.
+func DefaultRestoreReconcilerOptions() *RestoreReconcilerOptions {
+	return &RestoreReconcilerOptions{
+		RestoreTimeout: DefaultSnapshotTimeoutMinutes * time.Minute,
+		PollInterval:   DefaultRestorePollSeconds * time.Second,
+	}
+}
+
+// VaultRestoreReconciler reconciles a VaultRestore object
+type VaultRestoreReconciler struct {
+	client.Client
+	Log                   logr.Logger
+	Scheme                *runtime.Scheme
+	Options               *RestoreReconcilerOptions
+	SnapshotClientFactory SnapshotClientFactory
+	ObjectStoreFactory    ObjectStoreFactory
+	ClientFactory         vault.ClientFactory
+
+	now func() time.Time
+}
+
+// NewVaultRestoreReconciler creates a new restore reconciler with dependencies.
+func NewVaultRestoreReconciler(
+	client client.Client,
+	logger logr.Logger,
+	scheme *runtime.Scheme,
+	options *RestoreReconcilerOptions,
+) *VaultRestoreReconciler {
+	if options == nil {
+		options = DefaultRestoreReconcilerOptions()
+	}
+
+	return &VaultRestoreReconciler{
+		Client:                client,
+		Log:                   logger,
+		Scheme:                scheme,
+		Options:               options,
+		SnapshotClientFactory: DefaultSnapshotClientFactory,
+		ObjectStoreFactory:    backup.NewObjectStore,
+		ClientFactory:         &vault.DefaultClientFactory{},
+		now:                   time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=vault.io,resources=vaultrestores,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=vault.io,resources=vaultrestores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.io,resources=vaultbackupschedules,verbs=get
+// +kubebuilder:rbac:groups=vault.io,resources=vaultunsealconfigs,verbs=get;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (r *VaultRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("reconciler", "VaultRestore")
+
+	var restore vaultv1.VaultRestore
+	if err := r.Get(ctx, req.NamespacedName, &restore); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	switch restore.Status.Phase {
+	case "", vaultv1.RestorePhasePending:
+		return r.restore(ctx, logger, &restore)
+	case vaultv1.RestorePhaseRestoring:
+		// A restore is applied within a single reconcile; finding it in progress means
+		// the operator was interrupted. Replaying a snapshot is not safe to do blindly.
+		return ctrl.Result{}, r.fail(ctx, &restore, "restore was interrupted before Vault accepted the snapshot")
+	case vaultv1.RestorePhaseUnsealing:
+		return r.waitForUnseal(ctx, logger, &restore)
+	case vaultv1.RestorePhaseVerifying:
+		return ctrl.Result{}, r.verify(ctx, logger, &restore)
+	default:
+		// Completed and Failed are terminal
+		return ctrl.Result{}, nil
+	}
+}
+
+// restore streams the selected snapshot into Vault and moves on to unsealing.
+func (r *VaultRestoreReconciler) restore(
+	ctx context.Context,
+	logger logr.Logger,
+	restore *vaultv1.VaultRestore,
+) (ctrl.Result, error) {
+	dest, key, err := r.resolveSource(ctx, restore)
+	if err != nil {
+		return ctrl.Result{}, r.fail(ctx, restore, err.Error())
+	}
+
+	start := metav1.NewTime(r.now())
+	restore.Status.Phase = vaultv1.RestorePhaseRestoring
+	restore.Status.Message = "Streaming snapshot into Vault"
+	restore.Status.SnapshotKey = key
+	restore.Status.StartTime = &start
+	// Not retried: a conflict means another reconcile may already be restoring
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.Info("Restoring Raft snapshot", "name", restore.Name, "key", key, "endpoint", restore.Spec.Endpoint)
+
+	restored, err := r.apply(ctx, restore, dest, key)
+	restore.Status.BytesRestored = restored
+	if err != nil {
+		logger.Error(err, "restore failed", "key", key)
+		return ctrl.Result{}, r.fail(ctx, restore, err.Error())
+	}
+
+	restoredTime := metav1.NewTime(r.now())
+	restore.Status.RestoredTime = &restoredTime
+	restore.Status.Phase = vaultv1.RestorePhaseUnsealing
+	restore.Status.Message = fmt.Sprintf("Snapshot applied (%d bytes), waiting for Vault to be unsealed", restored)
+
+	if restore.Spec.UnsealConfigRef != "" {
+		if err := r.requestUnseal(ctx, restore); err != nil {
+			return ctrl.Result{}, r.fail(ctx, restore, err.Error())
+		}
+	}
+
+	if err := r.updateStatus(ctx, restore); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.Info("Raft snapshot restored", "key", key, "bytes", restored)
+
+	return ctrl.Result{RequeueAfter: r.Options.PollInterval}, nil
+}
+
+// resolveSource returns the destination and object key to restore from.
+func (r *VaultRestoreReconciler) resolveSource(
+	ctx context.Context,
+	restore *vaultv1.VaultRestore,
+) (*vaultv1.BackupDestination, string, error) {
+	source := restore.Spec.Source
+	if source.Destination != nil {
+		if source.Key == "" {
+			return nil, "", fmt.Errorf("spec.source.key is required with spec.source.destination")
+		}
+		return source.Destination, source.Key, nil
+	}
+
+	if source.BackupScheduleRef == "" {
+		return nil, "", fmt.Errorf("spec.source must set backupScheduleRef or destination")
+	}
+
+	var schedule vaultv1.VaultBackupSchedule
+	name := types.NamespacedName{Namespace: restore.Namespace, Name: source.BackupScheduleRef}
+	if err := r.Get(ctx, name, &schedule); err != nil {
+		return nil, "", fmt.Errorf("failed to get backup schedule %s: %w", source.BackupScheduleRef, err)
+	}
+
+	key := source.Key
+	if key == "" {
+		snapshots := schedule.Status.Snapshots
+		if len(snapshots) == 0 {
+			return nil, "", fmt.Errorf("backup schedule %s has no snapshots", source.BackupScheduleRef)
+		}
+		key = snapshots[len(snapshots)-1].Key
+	}
+
+	return &schedule.Spec.Destination, key, nil
+}
+
+// apply downloads the snapshot and streams it into Vault, returning the number of bytes sent.
+func (r *VaultRestoreReconciler) apply(
+	ctx context.Context,
+	restore *vaultv1.VaultRestore,
+	dest *vaultv1.BackupDestination,
+	key string,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Options.RestoreTimeout)
+	defer cancel()
+
+	store, err := newObjectStore(ctx, r.Client, r.ObjectStoreFactory, restore.Namespace, dest)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := readSecretKey(ctx, r.Client, restore.Namespace, restore.Spec.TokenSecretRef)
+	if err != nil {
+		return 0, err
+	}
+
+	snapClient, err := r.SnapshotClientFactory(restore.Spec.Endpoint, restore.Spec.TLSSkipVerify,
+		string(token), r.Options.RestoreTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	defer func() { _ = snapClient.Close() }()
+
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download snapshot: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	force := restore.Spec.Force == nil || *restore.Spec.Force
+	counter := &countingReader{r: body}
+	if err := snapClient.RaftSnapshotRestore(ctx, counter, force); err != nil {
+		return counter.n, fmt.Errorf("failed to restore raft snapshot: %w", err)
+	}
+
+	return counter.n, nil
+}
+
+// requestUnseal annotates the referenced VaultUnsealConfig so it reconciles immediately.
+func (r *VaultRestoreReconciler) requestUnseal(ctx context.Context, restore *vaultv1.VaultRestore) error {
+	name := types.NamespacedName{Namespace: restore.Namespace, Name: restore.Spec.UnsealConfigRef}
+	if err := RequestReconcile(ctx, r.Client, name, r.now()); err != nil {
+		return fmt.Errorf("failed to request unseal from %s: %w", restore.Spec.UnsealConfigRef, err)
+	}
+	return nil
+}
+
+// waitForUnseal polls the seal status until Vault is unsealed or the unseal timeout expires.
+func (r *VaultRestoreReconciler) waitForUnseal(
+	ctx context.Context,
+	logger logr.Logger,
+	restore *vaultv1.VaultRestore,
+) (ctrl.Result, error) {
+	vaultClient, err := r.ClientFactory.NewClient(restore.Spec.Endpoint, restore.Spec.TLSSkipVerify,
+		DefaultTimeoutSeconds*time.Second)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	defer func() { _ = vaultClient.Close() }()
+
+	sealed, err := vaultClient.IsSealed(ctx)
+	if err != nil {
+		logger.V(1).Info("Seal status unavailable after restore", "error", err.Error())
+		sealed = true
+	}
+
+	if sealed {
+		timeout := DefaultRestoreUnsealTimeoutMinutes * time.Minute
+		if restore.Spec.UnsealTimeout != nil {
+			timeout = restore.Spec.UnsealTimeout.Duration
+		}
+		if restore.Status.RestoredTime != nil && r.now().Sub(restore.Status.RestoredTime.Time) > timeout {
+			return ctrl.Result{}, r.fail(ctx, restore,
+				fmt.Sprintf("vault was not unsealed within %s of the restore", timeout))
+		}
+		return ctrl.Result{RequeueAfter: r.Options.PollInterval}, nil
+	}
+
+	restore.Status.Phase = vaultv1.RestorePhaseVerifying
+	restore.Status.Message = "Vault unsealed, verifying restored state"
+	if err := r.updateStatus(ctx, restore); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	return ctrl.Result{}, r.verify(ctx, logger, restore)
+}
+
+// verify records the post-restore Vault state and completes the restore.
+func (r *VaultRestoreReconciler) verify(
+	ctx context.Context,
+	logger logr.Logger,
+	restore *vaultv1.VaultRestore,
+) error {
+	vaultClient, err := r.ClientFactory.NewClient(restore.Spec.Endpoint, restore.Spec.TLSSkipVerify,
+		DefaultTimeoutSeconds*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to create vault client: %w", err)
+	}
+	defer func() { _ = vaultClient.Close() }()
+
+	health, err := vaultClient.HealthCheck(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify restored vault: %w", err)
+	}
+
+	restore.Status.Verification = &vaultv1.RestoreVerification{
+		Initialized: health.Initialized,
+		Sealed:      health.Sealed,
+		Version:     health.Version,
+		ClusterID:   health.ClusterID,
+	}
+
+	if !health.Initialized || health.Sealed {
+		return r.fail(ctx, restore, "restored vault is not initialized and unsealed")
+	}
+
+	completion := metav1.NewTime(r.now())
+	restore.Status.Phase = vaultv1.RestorePhaseCompleted
+	restore.Status.Message = "Snapshot restored and verified"
+	restore.Status.CompletionTime = &completion
+	r.setCondition(restore, metav1.ConditionTrue, "RestoreCompleted", restore.Status.Message)
+
+	if err := r.updateStatus(ctx, restore); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.Info("Vault restore completed", "name", restore.Name, "key", restore.Status.SnapshotKey)
+	return nil
+}
+
+// fail marks the restore as terminally failed.
+func (r *VaultRestoreReconciler) fail(ctx context.Context, restore *vaultv1.VaultRestore, message string) error {
+	completion := metav1.NewTime(r.now())
+	restore.Status.Phase = vaultv1.RestorePhaseFailed
+	restore.Status.Message = message
+	restore.Status.CompletionTime = &completion
+	r.setCondition(restore, metav1.ConditionFalse, "RestoreFailed", message)
+
+	if err := r.updateStatus(ctx, restore); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
+
+// updateStatus writes the status, retrying on conflicts with the computed status.
+func (r *VaultRestoreReconciler) updateStatus(ctx context.Context, restore *vaultv1.VaultRestore) error {
+	computed := restore.DeepCopy()
+	return updateStatus(ctx, r.Client, restore, func() { restore.Status = computed.Status })
+}
+
+func (r *VaultRestoreReconciler) setCondition(
+	restore *vaultv1.VaultRestore,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:               ConditionTypeRestoreSucceeded,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: restore.Generation,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VaultRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Phase transitions are driven by requeues, not by our own status writes
+		For(&vaultv1.VaultRestore{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
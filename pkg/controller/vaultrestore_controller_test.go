@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/backup"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// staticClientFactory always returns the same mock client.
+type staticClientFactory struct {
+	client *vault.MockVaultClient
+}
+
+func (f *staticClientFactory) NewClient(string, bool, time.Duration) (vault.VaultClient, error) {
+	return f.client, nil
+}
+
+func newRestoreTestReconciler(
+	t *testing.T,
+	restore *vaultv1.VaultRestore,
+	store *memoryObjectStore,
+	now *time.Time,
+) (*VaultRestoreReconciler, client.Client, *fakeSnapshotClient, *vault.MockVaultClient) {
+	t.Helper()
+	schedule := newBackupSchedule(0)
+	schedule.Status.Snapshots = []vaultv1.BackupSnapshot{
+		{Key: "raft/nightly-20240101T000000Z.snap"},
+		{Key: "raft/nightly-20240102T000000Z.snap"},
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newBackupTestScheme(t)).
+		WithStatusSubresource(&vaultv1.VaultRestore{}, &vaultv1.VaultBackupSchedule{}).
+		WithObjects(
+			restore,
+			schedule,
+			&vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"}},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "vault"},
+				Data:       map[string][]byte{"token": []byte("s.token")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "s3-creds", Namespace: "vault"},
+				Data: map[string][]byte{
+					backup.AccessKeyIDKey:     []byte("id"),
+					backup.SecretAccessKeyKey: []byte("secret"),
+				},
+			},
+		).
+		Build()
+
+	snapClient := &fakeSnapshotClient{}
+	vaultClient := vault.NewMockVaultClient()
+	r := NewVaultRestoreReconciler(k8sClient, log.Log, k8sClient.Scheme(), nil)
+	r.SnapshotClientFactory = func(_ string, _ bool, token string, _ time.Duration) (vault.SnapshotClient, error) {
+		snapClient.token = token
+		return snapClient, nil
+	}
+	r.ObjectStoreFactory = func(_ *vaultv1.BackupDestination, _ backup.Credentials) (backup.ObjectStore, error) {
+		return store, nil
+	}
+	r.ClientFactory = &staticClientFactory{client: vaultClient}
+	r.now = func() time.Time { return *now }
+	return r, k8sClient, snapClient, vaultClient
+}
+
+func newRestore() *vaultv1.VaultRestore {
+	return &vaultv1.VaultRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "rollback", Namespace: "vault"},
+		Spec: vaultv1.VaultRestoreSpec{
+			Endpoint:        "https://vault.vault.svc:8200",
+			TokenSecretRef:  vaultv1.SecretKeyRef{Name: "vault-token", Key: "token"},
+			Source:          vaultv1.RestoreSource{BackupScheduleRef: "nightly"},
+			UnsealConfigRef: "vault",
+		},
+	}
+}
+
+func TestRestoreStreamsLatestSnapshotAndCompletes(t *testing.T) {
+	now := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	store := &memoryObjectStore{objects: map[string][]byte{
+		"raft/nightly-20240101T000000Z.snap": []byte("old"),
+		"raft/nightly-20240102T000000Z.snap": []byte("latest-snapshot"),
+	}}
+	r, k8sClient, snapClient, vaultClient := newRestoreTestReconciler(t, newRestore(), store, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "rollback", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRestorePollSeconds*time.Second, result.RequeueAfter)
+	assert.Equal(t, "latest-snapshot", snapClient.restored)
+	assert.True(t, snapClient.forced, "force must default to true")
+	assert.Equal(t, "s.token", snapClient.token)
+
+	var restore vaultv1.VaultRestore
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &restore))
+	assert.Equal(t, vaultv1.RestorePhaseUnsealing, restore.Status.Phase)
+	assert.Equal(t, "raft/nightly-20240102T000000Z.snap", restore.Status.SnapshotKey)
+	assert.Equal(t, int64(len("latest-snapshot")), restore.Status.BytesRestored)
+
+	var config vaultv1.VaultUnsealConfig
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "vault", Namespace: "vault"}, &config))
+	assert.Equal(t, "2024-01-03T00:00:00Z", config.Annotations[vaultv1.ReconcileRequestedAtAnnotation])
+
+	// Still sealed: keep polling
+	result, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRestorePollSeconds*time.Second, result.RequeueAfter)
+
+	vaultClient.SetSealed(false)
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, &restore))
+	assert.Equal(t, vaultv1.RestorePhaseCompleted, restore.Status.Phase)
+	require.NotNil(t, restore.Status.Verification)
+	assert.True(t, restore.Status.Verification.Initialized)
+	assert.False(t, restore.Status.Verification.Sealed)
+	assert.True(t, meta.IsStatusConditionTrue(restore.Status.Conditions, ConditionTypeRestoreSucceeded))
+}
+
+func TestRestoreFailsWhenUnsealTimesOut(t *testing.T) {
+	now := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	store := &memoryObjectStore{objects: map[string][]byte{"raft/nightly-20240102T000000Z.snap": []byte("data")}}
+	restore := newRestore()
+	restore.Spec.UnsealTimeout = &metav1.Duration{Duration: time.Minute}
+	r, k8sClient, _, _ := newRestoreTestReconciler(t, restore, store, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "rollback", Namespace: "vault"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, restore))
+	assert.Equal(t, vaultv1.RestorePhaseFailed, restore.Status.Phase)
+	assert.Contains(t, restore.Status.Message, "not unsealed")
+}
+
+func TestRestoreFailsOnMissingSnapshot(t *testing.T) {
+	now := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	restore := newRestore()
+	restore.Spec.Source.Key = "raft/missing.snap"
+	force := false
+	restore.Spec.Force = &force
+	r, k8sClient, snapClient, _ := newRestoreTestReconciler(t, restore, &memoryObjectStore{objects: map[string][]byte{}}, &now)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "rollback", Namespace: "vault"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Empty(t, snapClient.restored)
+
+	require.NoError(t, k8sClient.Get(context.Background(), req.NamespacedName, restore))
+	assert.Equal(t, vaultv1.RestorePhaseFailed, restore.Status.Phase)
+	assert.Equal(t, "raft/missing.snap", restore.Status.SnapshotKey)
+	cond := meta.FindStatusCondition(restore.Status.Conditions, ConditionTypeRestoreSucceeded)
+	require.NotNil(t, cond)
+	assert.Equal(t, "RestoreFailed", cond.Reason)
+
+	// Failed restores are terminal
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, snapClient.restored)
+}
@@ -2,20 +2,39 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
 	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/features"
+	"github.com/panteparak/vault-autounseal-operator/pkg/metrics"
+	"github.com/panteparak/vault-autounseal-operator/pkg/notify"
+	"github.com/panteparak/vault-autounseal-operator/pkg/tracing"
 	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -26,11 +45,14 @@ const (
 	DefaultRequeueAfterSeconds = 30
 	// DefaultTimeoutSeconds is the default timeout in seconds.
 	DefaultTimeoutSeconds = 30
-	// DefaultThreshold is the default threshold for unsealing.
-	DefaultThreshold = 3
+
+	// ConditionTypeReady reports whether all vault instances of a config are unsealed.
+	ConditionTypeReady = "Ready"
 )
 
-// VaultClientRepository manages vault client instances.
+// VaultClientRepository manages vault client instances. The key passed to
+// GetClient identifies the requesting instance as namespace/name; implementations
+// may share one client between instances with identical connection settings.
 type VaultClientRepository interface {
 	GetClient(ctx context.Context, key string, instance *vaultv1.VaultInstance) (vault.VaultClient, error)
 	Close() error
@@ -40,6 +62,27 @@ type VaultClientRepository interface {
 type ReconcilerOptions struct {
 	RequeueAfter time.Duration
 	Timeout      time.Duration
+	// MinConcurrentReconciles and MaxConcurrentReconciles bound the number of
+	// configs reconciled at once; the limit follows the queue depth in between.
+	MinConcurrentReconciles int
+	MaxConcurrentReconciles int
+	// WorkerScaleInterval is how often the concurrency limit is recomputed
+	WorkerScaleInterval time.Duration
+	// MaxConcurrentUnseals bounds the unseal submissions in flight across all
+	// configs; 0 does not limit them
+	MaxConcurrentUnseals int
+	// LogSampleFirst, LogSampleEvery and LogSummaryInterval sample errors
+	// logged repeatedly for an instance; LogSampleEvery 0 logs every error
+	LogSampleFirst     int
+	LogSampleEvery     int
+	LogSummaryInterval time.Duration
+	// AuditTTL is how long the VaultUnsealAudit of an unseal attempt is kept;
+	// 0 does not record attempts. AuditMaxPerConfig bounds the audits kept
+	// per config, 0 keeping all within the TTL.
+	AuditTTL          time.Duration
+	AuditMaxPerConfig int
+	// Features enables gated subsystems; nil uses the feature defaults
+	Features *features.Gate
 }
 
 // DefaultReconcilerOptions returns default reconciler options.
@@ -47,7 +90,27 @@ func DefaultReconcilerOptions() *ReconcilerOptions {
 	return &ReconcilerOptions{
 		RequeueAfter: DefaultRequeueAfterSeconds * time.Second,
 		Timeout:      DefaultTimeoutSeconds * time.Second,
+
+		MinConcurrentReconciles: DefaultMinConcurrentReconciles,
+		MaxConcurrentReconciles: DefaultMaxConcurrentReconciles,
+		WorkerScaleInterval:     DefaultWorkerScaleInterval,
+
+		LogSampleFirst:     DefaultLogSampleFirst,
+		LogSampleEvery:     DefaultLogSampleEvery,
+		LogSummaryInterval: DefaultLogSummaryInterval,
+
+		AuditMaxPerConfig: DefaultAuditMaxPerConfig,
+
+		Features: features.NewGate(),
+	}
+}
+
+// featureEnabled reports whether a gated subsystem is enabled.
+func (r *VaultUnsealConfigReconciler) featureEnabled(feature features.Feature) bool {
+	if r.Options == nil || r.Options.Features == nil {
+		return features.NewGate().Enabled(feature)
 	}
+	return r.Options.Features.Enabled(feature)
 }
 
 // VaultUnsealConfigReconciler reconciles a VaultUnsealConfig object
@@ -57,6 +120,40 @@ type VaultUnsealConfigReconciler struct {
 	Scheme           *runtime.Scheme
 	ClientRepository VaultClientRepository
 	Options          *ReconcilerOptions
+	// RaftClientFactory creates the clients used for instances with Raft monitoring
+	RaftClientFactory RaftClientFactory
+	// Recorder emits Kubernetes events on unseals and failures; nil disables events
+	Recorder record.EventRecorder
+	// Resolver resolves endpoint hosts for status; nil disables resolution
+	Resolver HostResolver
+	// KeyCommands lists the commands exec key sources may run; empty disables exec key sources
+	KeyCommands []string
+	// AgeIdentityFiles hold the age identities decrypting keys of instances
+	// without an identity Secret
+	AgeIdentityFiles []string
+	// PKCS11Modules lists the PKCS#11 modules tokens may be accessed with; empty
+	// disables PKCS#11
+	PKCS11Modules []string
+	// PKCS11Tool is the pkcs11-tool command; empty uses DefaultPKCS11Tool
+	PKCS11Tool string
+	// TransitAudience is the audience of the service account tokens transit
+	// unwrap logs in with; empty uses DefaultTransitAudience
+	TransitAudience string
+	// Metrics exports the state of each instance with its labels; nil disables instance metrics
+	Metrics *metrics.InstanceMetrics
+	// KeySourceMetrics records the reads of key sources; nil disables them
+	KeySourceMetrics *metrics.KeySourceMetrics
+
+	// sealed tracks configs with sealed instances so they are queued first
+	sealed sealedConfigs
+	// workers scales concurrent reconciles; nil when the concurrency is fixed
+	workers *workerScaler
+	// unseals bounds the unseal submissions across configs; nil does not limit
+	unseals *unsealLimiter
+	// failureLogs samples repeated errors of an instance; nil logs every error
+	failureLogs *failureLogSampler
+	// sinks reuses the notification sinks across events; nil creates them per event
+	sinks *notify.Sinks
 }
 
 // NewVaultUnsealConfigReconciler creates a new reconciler with dependencies.
@@ -72,19 +169,42 @@ func NewVaultUnsealConfigReconciler(
 	}
 
 	return &VaultUnsealConfigReconciler{
-		Client:           client,
-		Log:              logger,
-		Scheme:           scheme,
-		ClientRepository: repository,
-		Options:          options,
+		Client:            client,
+		Log:               logger,
+		Scheme:            scheme,
+		ClientRepository:  repository,
+		Options:           options,
+		RaftClientFactory: DefaultRaftClientFactory,
+		unseals:           newUnsealLimiter(options.MaxConcurrentUnseals),
+		failureLogs:       newFailureLogSampler(options.LogSampleFirst, options.LogSampleEvery, options.LogSummaryInterval),
+		sinks:             notify.NewSinks(),
 	}
 }
 
-// DefaultVaultClientRepository implements VaultClientRepository.
+// clientShardCount is the number of independently locked client cache shards,
+// so concurrent reconciles of different endpoints do not contend on one lock.
+const clientShardCount = 32
+
+// DefaultVaultClientRepository implements VaultClientRepository. Clients are
+// cached by connection settings, so instances pointing at the same endpoint
+// share a client even when they belong to different configs.
 type DefaultVaultClientRepository struct {
-	clients   map[string]*vault.Client
-	clientsMu sync.RWMutex
-	factory   vault.ClientFactory
+	shards  [clientShardCount]clientShard
+	factory vault.ClientFactory
+	// executor runs the requests of instances using exec access; nil rejects them
+	executor PodExecutor
+	// podDialer connects instances using port-forward access; nil rejects them
+	podDialer PodDialer
+	// scope limits the namespaces of the pods exec and port-forward access reach
+	scope NamespaceScope
+	// metrics records the requests of clients not created by factory
+	metrics vault.ClientMetrics
+}
+
+// clientShard holds the cached clients whose keys hash to it.
+type clientShard struct {
+	mu      sync.RWMutex
+	clients map[string]*vault.Client
 }
 
 // NewDefaultVaultClientRepository creates a new vault client repository.
@@ -93,82 +213,247 @@ func NewDefaultVaultClientRepository(factory vault.ClientFactory) *DefaultVaultC
 		factory = &vault.DefaultClientFactory{}
 	}
 
-	return &DefaultVaultClientRepository{
-		clients: make(map[string]*vault.Client),
-		factory: factory,
+	r := &DefaultVaultClientRepository{factory: factory}
+	for i := range r.shards {
+		r.shards[i].clients = make(map[string]*vault.Client)
 	}
+	return r
+}
+
+// SetPodExecutor enables exec access to instances.
+func (r *DefaultVaultClientRepository) SetPodExecutor(executor PodExecutor) {
+	r.executor = executor
+}
+
+// SetPodDialer enables port-forward access to instances.
+func (r *DefaultVaultClientRepository) SetPodDialer(dialer PodDialer) {
+	r.podDialer = dialer
+}
+
+// SetNamespaceScope limits exec and port-forward access to pods in scope.
+func (r *DefaultVaultClientRepository) SetNamespaceScope(scope NamespaceScope) {
+	r.scope = scope
+}
+
+// SetClientMetrics records the requests of clients reached through a tunnel,
+// exec or port-forward access. Direct clients use the metrics of the factory.
+func (r *DefaultVaultClientRepository) SetClientMetrics(metrics vault.ClientMetrics) {
+	r.metrics = metrics
 }
 
 // +kubebuilder:rbac:groups=vault.io,resources=vaultunsealconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vault.io,resources=vaultunsealconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=vault.io,resources=vaultunsealconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=vault.io,resources=vaultunsealaudits,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
-// GetClient retrieves or creates a vault client for the given instance.
+// GetClient retrieves or creates a vault client for the given instance. A
+// tunnel attached to ctx with withTunnel is dialed through.
 func (r *DefaultVaultClientRepository) GetClient(
-	_ context.Context,
+	ctx context.Context,
 	key string,
 	instance *vaultv1.VaultInstance,
 ) (vault.VaultClient, error) {
-	r.clientsMu.RLock()
-	if client, exists := r.clients[key]; exists {
-		r.clientsMu.RUnlock()
+	timeout := DefaultTimeoutSeconds * time.Second
+	access := instanceAccessKey(instance)
+	tunnel := tunnelFromContext(ctx)
+	if tunnel != nil {
+		access = tunnel.key
+	}
+	cacheKey := clientCacheKey(instance.Endpoint, skipTLSVerify(instance), timeout, access)
+
+	shard := r.shard(cacheKey)
+
+	shard.mu.RLock()
+	if client, exists := shard.clients[cacheKey]; exists {
+		shard.mu.RUnlock()
 
 		return client, nil
 	}
-	r.clientsMu.RUnlock()
+	shard.mu.RUnlock()
 
-	r.clientsMu.Lock()
-	defer r.clientsMu.Unlock()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if client, exists := r.clients[key]; exists {
+	if client, exists := shard.clients[cacheKey]; exists {
 		return client, nil
 	}
 
-	timeout := DefaultTimeoutSeconds * time.Second
-	vaultClient, err := r.factory.NewClient(instance.Endpoint, instance.TLSSkipVerify, timeout)
+	vaultClient, err := r.newClient(instance, timeout, tunnel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create vault client for %s: %w", key, err)
 	}
 
 	if concreteClient, ok := vaultClient.(*vault.Client); ok {
-		r.clients[key] = concreteClient
+		shard.clients[cacheKey] = concreteClient
 	}
 
 	return vaultClient, nil
 }
 
+// newClient creates a client reaching the instance as its access mode requires.
+func (r *DefaultVaultClientRepository) newClient(
+	instance *vaultv1.VaultInstance,
+	timeout time.Duration,
+	tunnel *tunnel,
+) (vault.VaultClient, error) {
+	if tunnel != nil {
+		return vault.NewClientWithOptions(instance.Endpoint,
+			vault.WithTLSSkipVerify(skipTLSVerify(instance)),
+			vault.WithTimeout(timeout),
+			vault.WithDialer(tunnel.dial),
+			vault.WithMetrics(r.metrics),
+		)
+	}
+	if instance.Access == nil || instance.Access.Mode == "" || instance.Access.Mode == vaultv1.AccessModeDirect {
+		return r.factory.NewClient(instance.Endpoint, skipTLSVerify(instance), timeout)
+	}
+	if instance.Access.Pod == "" {
+		return nil, fmt.Errorf("%s access requires access.pod", instance.Access.Mode)
+	}
+	if instance.Namespace == "" {
+		return nil, fmt.Errorf("%s access requires the namespace of the pod", instance.Access.Mode)
+	}
+	if err := r.scope.check(instance.Namespace); err != nil {
+		return nil, fmt.Errorf("%s access to pod %s: %w", instance.Access.Mode, instance.Access.Pod, err)
+	}
+
+	options := []vault.ClientOption{
+		vault.WithTLSSkipVerify(skipTLSVerify(instance)),
+		vault.WithTimeout(timeout),
+		vault.WithMetrics(r.metrics),
+	}
+	switch instance.Access.Mode {
+	case vaultv1.AccessModeExec:
+		if r.executor == nil {
+			return nil, fmt.Errorf("exec access is not available")
+		}
+		options = append(options, vault.WithTransport(
+			newExecTransport(r.executor, instance.Namespace, instance.Access, skipTLSVerify(instance))))
+	case vaultv1.AccessModePortForward:
+		if r.podDialer == nil {
+			return nil, fmt.Errorf("port-forward access is not available")
+		}
+		options = append(options, vault.WithDialer(portForwardDialer(r.podDialer, instance.Namespace, instance.Access.Pod)))
+	default:
+		return nil, fmt.Errorf("unknown access mode %q", instance.Access.Mode)
+	}
+	return vault.NewClientWithOptions(instance.Endpoint, options...)
+}
+
+// skipTLSVerify reports whether the TLS certificate of instance goes
+// unverified. Instances resolved against the unseal settings always set it.
+func skipTLSVerify(instance *vaultv1.VaultInstance) bool {
+	return instance.TLSSkipVerify != nil && *instance.TLSSkipVerify
+}
+
+// instanceAccessKey identifies how an instance is reached, for client caching.
+// It is empty for instances reached directly.
+func instanceAccessKey(instance *vaultv1.VaultInstance) string {
+	if instance.Access == nil {
+		return ""
+	}
+	switch instance.Access.Mode {
+	case vaultv1.AccessModeExec:
+		return fmt.Sprintf("exec:%s/%s/%s", instance.Namespace, instance.Access.Pod, instance.Access.Container)
+	case vaultv1.AccessModePortForward:
+		return fmt.Sprintf("port-forward:%s/%s", instance.Namespace, instance.Access.Pod)
+	default:
+		return ""
+	}
+}
+
+// clientCacheKey hashes every setting that affects how a client connects.
+func clientCacheKey(endpoint string, tlsSkipVerify bool, timeout time.Duration, access string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|tlsSkipVerify=%t|timeout=%s|access=%s",
+		endpoint, tlsSkipVerify, timeout, access)))
+	return hex.EncodeToString(sum[:])
+}
+
+// shard returns the shard holding the client cached under cacheKey, a hex digest.
+func (r *DefaultVaultClientRepository) shard(cacheKey string) *clientShard {
+	index, err := strconv.ParseUint(cacheKey[:4], 16, 16)
+	if err != nil {
+		index = 0
+	}
+	return &r.shards[index%clientShardCount]
+}
+
 // Close closes all vault clients in the repository.
 func (r *DefaultVaultClientRepository) Close() error {
-	r.clientsMu.Lock()
-	defer r.clientsMu.Unlock()
-
 	var lastErr error
-	for key, client := range r.clients {
-		if err := client.Close(); err != nil {
-			lastErr = fmt.Errorf("failed to close client %s: %w", key, err)
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.Lock()
+		for _, client := range shard.clients {
+			if err := client.Close(); err != nil {
+				lastErr = fmt.Errorf("failed to close client %s: %w", client.URL(), err)
+			}
 		}
+		shard.clients = make(map[string]*vault.Client)
+		shard.mu.Unlock()
 	}
 
-	r.clients = make(map[string]*vault.Client)
-
 	return lastErr
 }
 
-func (r *VaultUnsealConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx).WithValues("reconciler", "VaultUnsealConfig")
-
-	// Create a timeout context for this reconciliation
-	ctx, cancel := context.WithTimeout(ctx, r.Options.Timeout)
-	defer cancel()
+func (r *VaultUnsealConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	// The reconcile ID ties the log lines, events and Vault requests of this run together
+	baseLogger := log.FromContext(ctx)
+	reconcileID := string(controller.ReconcileIDFromContext(ctx))
+	if reconcileID == "" {
+		reconcileID = vault.RequestID(ctx)
+	}
+	if reconcileID == "" {
+		reconcileID = string(uuid.NewUUID())
+		baseLogger = baseLogger.WithValues("reconcileID", reconcileID)
+	}
+	ctx = vault.WithRequestID(ctx, reconcileID)
+	// The span of the reconcile parents the Vault requests, whose latency exemplars link to it
+	ctx, span := tracing.Tracer().Start(ctx, "VaultUnsealConfig.Reconcile", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("vault.unseal_config", req.Name),
+		attribute.String("vault.reconcile_id", reconcileID),
+	))
+	defer func() { endSpan(span, err) }()
+
+	// Identical errors of an instance repeat every retry during an outage; log a sample of them
+	logger := r.failureLogs.wrap(baseLogger, req.String()).WithValues("reconciler", "VaultUnsealConfig")
+
+	failing := false
+	if r.workers != nil {
+		if err := r.workers.acquire(ctx, r.sealed.has(req.NamespacedName)); err != nil {
+			return ctrl.Result{}, err
+		}
+		defer func() { r.workers.release(failing || err != nil) }()
+	}
 
 	// Fetch the VaultUnsealConfig instance
 	var vaultConfig vaultv1.VaultUnsealConfig
 	if err := r.Get(ctx, req.NamespacedName, &vaultConfig); err != nil {
+		// A scoped instance refuses configs of other tenants instead of retrying them
+		if errors.Is(err, ErrOutsideScope) {
+			logger.Error(err, "Refusing to reconcile VaultUnsealConfig outside the operator scope")
+			return ctrl.Result{}, nil
+		}
+		if apierrors.IsNotFound(err) {
+			r.sealed.set(req.NamespacedName, false)
+			if r.Metrics != nil {
+				r.Metrics.Delete(req.Namespace, req.Name)
+			}
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	original := vaultConfig.DeepCopy()
+
+	settings := r.resolveSettings(ctx, logger, &vaultConfig)
+
+	// Create a timeout context for this reconciliation
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
 
 	logger.Info("Reconciling VaultUnsealConfig - Event-driven controller",
 		"name", vaultConfig.Name,
@@ -178,14 +463,31 @@ func (r *VaultUnsealConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		"note", "Triggered by VaultUnsealConfig or Pod events",
 	)
 
-	// Process each vault instance
-	vaultStatuses, allReady := r.processVaultInstances(ctx, logger, &vaultConfig)
-
-	// Update status
-	r.updateVaultConfigStatus(&vaultConfig, vaultStatuses, allReady)
-
-	// Update the status
-	if err := r.Status().Update(ctx, &vaultConfig); err != nil {
+	// Process each vault instance; the status write keeps a reserve of the timeout
+	vaultCtx, vaultCancel := vaultBudget(ctx, settings.Timeout)
+	vaultStatuses, allReady := r.processVaultInstances(vaultCtx, logger, &vaultConfig, settings)
+	vaultCancel()
+	r.pruneAudits(ctx, logger, &vaultConfig, time.Now())
+	r.sealed.set(req.NamespacedName, !allReady)
+	failing = hasInstanceErrors(vaultStatuses)
+
+	// Update status; the Ready condition follows the debounced instance readiness
+	ready := debounceReadiness(&vaultConfig, vaultStatuses, settings)
+	r.updateVaultConfigStatus(&vaultConfig, vaultStatuses, ready)
+	r.updateRaftCondition(&vaultConfig)
+	r.updateVersionCondition(&vaultConfig)
+	r.updateKeysCondition(&vaultConfig)
+	setPhase(&vaultConfig.Status, derivePhase(&vaultConfig.Status))
+	updateProgressConditions(&vaultConfig)
+	updateUninitializedCondition(&vaultConfig)
+	updateResealCondition(&vaultConfig, settings)
+	updateQuarantineCondition(&vaultConfig)
+	vaultConfig.Status.ObservedGeneration = vaultConfig.Generation
+
+	// Periodic checks mostly confirm the recorded state; only write real changes
+	if equality.Semantic.DeepEqual(original.Status, vaultConfig.Status) {
+		logger.V(1).Info("Status unchanged, skipping update")
+	} else if err := r.patchStatus(ctx, &vaultConfig, original); err != nil {
 		logger.Error(err, "unable to update VaultUnsealConfig status")
 
 		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
@@ -193,43 +495,338 @@ func (r *VaultUnsealConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 
 	logger.V(1).Info("Reconciliation completed", "allReady", allReady, "statuses", len(vaultStatuses))
 
-	// Requeue for periodic reconciliation
-	return ctrl.Result{RequeueAfter: r.Options.RequeueAfter}, nil
+	// Requeue for periodic reconciliation, sooner while an instance is sealed or
+	// failing unless only retries on drained nodes are pending, and soonest while
+	// the pods of an instance are being replaced. Failing instances back off.
+	requeueAfter := settings.RequeueAfter
+	if !allReady && !onlyDrainingPending(vaultStatuses) {
+		requeueAfter = retryAfter(vaultStatuses, settings.RetryInterval, time.Now())
+	}
+	if r.rolloutInProgress(ctx, logger, &vaultConfig) {
+		requeueAfter = min(requeueAfter, rolloutRequeueAfter)
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// unsealConfigConditionTypes are the conditions of a VaultUnsealConfig set by
+// this controller. Conditions of other types belong to other writers.
+var unsealConfigConditionTypes = []string{
+	ConditionTypeReady, ConditionTypeRaftHealthy, ConditionTypeVersionSupported, ConditionTypeKeysAvailable,
+	ConditionTypeReconciling, ConditionTypeStalled, ConditionTypeUninitialized, ConditionTypeFrequentReseal,
+	ConditionTypeQuarantined,
+}
+
+// patchStatus writes the computed status. On conflicts it is merged into the
+// latest object: instance statuses are replaced and only the conditions owned by
+// this controller are touched.
+func (r *VaultUnsealConfigReconciler) patchStatus(
+	ctx context.Context,
+	vaultConfig, original *vaultv1.VaultUnsealConfig,
+) error {
+	computed := vaultConfig.Status.DeepCopy()
+	return patchStatus(ctx, r.Client, vaultConfig, original, func() {
+		vaultConfig.Status.Phase = computed.Phase
+		vaultConfig.Status.ObservedGeneration = computed.ObservedGeneration
+		vaultConfig.Status.VaultStatuses = computed.VaultStatuses
+		vaultConfig.Status.Ready = computed.Ready
+		vaultConfig.Status.SealedInstances = computed.SealedInstances
+		vaultConfig.Status.LastUnsealTime = computed.LastUnsealTime
+		for _, conditionType := range unsealConfigConditionTypes {
+			if condition := meta.FindStatusCondition(computed.Conditions, conditionType); condition != nil {
+				meta.SetStatusCondition(&vaultConfig.Status.Conditions, *condition)
+			} else {
+				meta.RemoveStatusCondition(&vaultConfig.Status.Conditions, conditionType)
+			}
+		}
+	})
 }
 
 func (r *VaultUnsealConfigReconciler) processVaultInstances(
 	ctx context.Context,
 	logger logr.Logger,
 	vaultConfig *vaultv1.VaultUnsealConfig,
+	settings *unsealSettings,
 ) ([]vaultv1.VaultInstanceStatus, bool) {
-	vaultStatuses := make([]vaultv1.VaultInstanceStatus, 0, len(vaultConfig.Spec.VaultInstances))
-	allReady := true
+	discoveryCtx, discoveryCancel := discoveryBudget(ctx)
+	instances, discoveryFailures := r.expandInstances(discoveryCtx, logger, vaultConfig, settings)
+	discoveryCancel()
+	vaultStatuses := make([]vaultv1.VaultInstanceStatus, 0, len(instances)+len(discoveryFailures))
+	allReady := len(discoveryFailures) == 0
+	now := time.Now()
+	if settings.StrictThreshold {
+		ctx = vault.WithStrictThreshold(ctx)
+	}
+	ctx = withUnsealBudget(ctx, settings.MaxConcurrentUnseals)
 
-	for i := range vaultConfig.Spec.VaultInstances {
-		instance := &vaultConfig.Spec.VaultInstances[i]
+	for i, instance := range instances {
 		instanceLogger := logger.WithValues("instance", instance.Name, "endpoint", instance.Endpoint)
 
-		status, err := r.processVaultInstance(ctx, instanceLogger, instance, vaultConfig.Namespace)
+		// A pod on a drained node is about to be rescheduled; its events trigger the next check
+		previous := findInstanceStatus(vaultConfig, instance.Name)
+		if retryingInstance(previous) {
+			if node := r.drainingNode(ctx, vaultConfig.Namespace, instance); node != "" {
+				instanceLogger.Info("Delaying unseal retry while the node is drained", "node", node)
+				status := drainingStatus(previous)
+				if status.Sealed {
+					allReady = false
+				}
+				vaultStatuses = append(vaultStatuses, status)
+				continue
+			}
+		}
+
+		// The backoff of a failing instance is kept in its status, so restarts do not reset it
+		if backingOff(vaultConfig, previous, now) {
+			instanceLogger.V(1).Info("Backing off failing instance",
+				"nextRetry", previous.NextRetryTime.Time, "failures", previous.ConsecutiveFailures)
+			vaultStatuses = append(vaultStatuses, *previous.DeepCopy())
+			allReady = false
+			continue
+		}
+
+		// Each check gets a share of the remaining budget, so a hung call cannot starve the rest
+		instanceCtx, instanceCancel, budget := instanceBudget(ctx, len(instances)-i)
+		instanceCtx, span := tracing.Tracer().Start(instanceCtx, "VaultInstance.Check",
+			trace.WithAttributes(attribute.String("vault.instance", instance.Name)))
+		gate := unsealGate{
+			waitingFor:  unreadyDependencies(vaultConfig, instance, vaultStatuses, discoveryFailures),
+			maintenance: r.maintenanceSignal(instanceCtx, vaultConfig, instance),
+			unsealAfter: resealHold(previous, settings),
+			quarantined: quarantinedKeys(vaultConfig, previous, settings),
+		}
+		status, unsealed, err := r.processVaultInstance(instanceCtx, instanceLogger, instance, vaultConfig.Namespace, gate)
+		err = budgetError(err, instanceCtx, ctx, budget)
+		endSpan(span, err)
 		if err != nil {
 			instanceLogger.Error(err, "failed to process vault instance")
 			status = vaultv1.VaultInstanceStatus{
 				Name:   instance.Name,
 				Sealed: true,
 				Error:  err.Error(),
+				Reason: classifyError(err),
 			}
 			allReady = false
+			// Repeated failures are folded into one Event by the broadcaster
+			r.instanceEvent(ctx, vaultConfig, instance, corev1.EventTypeWarning, status.Reason,
+				"Instance %s failed: %s", instance.Name, err.Error())
+			r.recordAudit(ctx, instanceLogger, vaultConfig, instance, vaultv1.AuditResultFailed, status.Reason, err.Error())
+			if r.Metrics != nil {
+				r.Metrics.RecordFailure(vaultConfig.Namespace, vaultConfig.Name, instance.Name, instance.Labels, status.Reason)
+				r.Metrics.RecordError(vaultConfig.Namespace, vaultConfig.Name, errorClass(err, status.Reason))
+			}
+			// Only report the first failure of a streak, not every retry
+			if previous := findInstanceStatus(vaultConfig, instance.Name); previous == nil || previous.Error == "" {
+				r.notify(ctx, instanceLogger, settings, vaultConfig, instance, notify.EventUnsealFailed, err.Error())
+			}
+		} else {
+			if unsealed {
+				r.instanceEvent(ctx, vaultConfig, instance, corev1.EventTypeNormal, ReasonUnsealed,
+					"Instance %s was unsealed", instance.Name)
+				r.recordAudit(ctx, instanceLogger, vaultConfig, instance, vaultv1.AuditResultUnsealed, ReasonUnsealed,
+					"Instance "+instance.Name+" was unsealed")
+				if r.Metrics != nil {
+					r.Metrics.RecordUnseal(vaultConfig.Namespace, vaultConfig.Name, instance.Name, instance.Labels)
+				}
+			}
+			// Raft health can only be read from an unsealed node
+			if instance.Raft != nil && !status.Sealed {
+				status.Raft = r.raftStatus(instanceCtx, vaultConfig.Namespace, instance)
+			}
 		}
 
 		if status.Sealed {
 			allReady = false
 		}
+		r.resolveEndpoint(instanceCtx, instance, &status)
+		instanceCancel()
+		recordUnsealHistory(&status, previous, unsealed, err != nil)
+		recordRapidReseals(&status, previous, settings.ResealWindow, now)
+		recordInvalidKeys(&status, previous, err, vaultConfig.Status.ObservedGeneration != vaultConfig.Generation)
+		if quarantined(&status, settings) && !quarantined(previous, settings) {
+			instanceLogger.Info("Quarantining instance after its keys were rejected repeatedly",
+				"failures", status.InvalidKeyFailures)
+			r.instanceEvent(ctx, vaultConfig, instance, corev1.EventTypeWarning, ReasonKeysQuarantined,
+				"Vault rejected the keys of instance %s %d times in a row; they are not submitted again until they or the spec change",
+				instance.Name, status.InvalidKeyFailures)
+		}
+		recordNextRetry(&status, settings.RetryInterval, now)
+		if frequentReseal(&status, settings) && !frequentReseal(previous, settings) {
+			instanceLogger.Info("Vault keeps sealing again soon after being unsealed", "reseals", status.RapidReseals)
+			r.instanceEvent(ctx, vaultConfig, instance, corev1.EventTypeWarning, ReasonFrequentReseal,
+				"Instance %s sealed again within %s of being unsealed %d times in a row",
+				instance.Name, settings.ResealWindow, status.RapidReseals)
+		}
+		if err == nil {
+			r.notifyTransitions(ctx, instanceLogger, settings, vaultConfig, instance, &status, previous, unsealed)
+		}
 
 		vaultStatuses = append(vaultStatuses, status)
 	}
+	vaultStatuses = append(vaultStatuses, discoveryFailures...)
+	vaultStatuses = append(vaultStatuses, disabledStatuses(vaultConfig)...)
+	r.observeInstances(vaultConfig, instances, discoveryFailures, vaultStatuses)
 
 	return vaultStatuses, allReady
 }
 
+// observeInstances exports the state of the checked instances and counts the
+// discovery failures, which carry the labels of their spec instance.
+func (r *VaultUnsealConfigReconciler) observeInstances(
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instances []*vaultv1.VaultInstance,
+	discoveryFailures, statuses []vaultv1.VaultInstanceStatus,
+) {
+	if r.Metrics == nil {
+		return
+	}
+
+	labels := make(map[string]map[string]string, len(statuses))
+	for _, instance := range instances {
+		labels[instance.Name] = instance.Labels
+	}
+	for i := range vaultConfig.Spec.VaultInstances {
+		instance := &vaultConfig.Spec.VaultInstances[i]
+		if _, ok := labels[instance.Name]; !ok {
+			labels[instance.Name] = instance.Labels
+		}
+	}
+	for _, status := range discoveryFailures {
+		r.Metrics.RecordFailure(vaultConfig.Namespace, vaultConfig.Name, status.Name, labels[status.Name], status.Reason)
+		r.Metrics.RecordError(vaultConfig.Namespace, vaultConfig.Name, errorClass(nil, status.Reason))
+	}
+
+	states := make([]metrics.InstanceState, 0, len(statuses))
+	for _, status := range statuses {
+		if instanceDisabled(&status) {
+			continue
+		}
+		states = append(states, metrics.InstanceState{
+			Name:   status.Name,
+			Labels: labels[status.Name],
+			Sealed: status.Sealed,
+		})
+	}
+	r.Metrics.Observe(vaultConfig.Namespace, vaultConfig.Name, states)
+}
+
+// recordUnsealHistory carries the unseal history of an instance over from its
+// previous status and updates it with the outcome of this check.
+func recordUnsealHistory(status, previous *vaultv1.VaultInstanceStatus, unsealed, failed bool) {
+	if previous != nil {
+		status.UnsealCount = previous.UnsealCount
+		status.ConsecutiveFailures = previous.ConsecutiveFailures
+		status.LastSealDetectedTime = previous.LastSealDetectedTime.DeepCopy()
+		// An instance not unsealed by this check keeps the time it was last unsealed
+		if !unsealed && previous.LastUnsealed != nil {
+			status.LastUnsealed = previous.LastUnsealed.DeepCopy()
+		}
+	}
+
+	if failed {
+		status.ConsecutiveFailures++
+		return
+	}
+	status.ConsecutiveFailures = 0
+
+	// A seal is detected when an instance last seen unsealed, or not seen at all, is sealed
+	wasSealed := previous != nil && previous.Sealed && previous.Error == ""
+	if (unsealed || status.Sealed) && !wasSealed {
+		now := metav1.NewTime(time.Now())
+		status.LastSealDetectedTime = &now
+	}
+	if unsealed {
+		status.UnsealCount++
+	}
+}
+
+// findInstanceStatus returns the last recorded status of the named instance, if any.
+func findInstanceStatus(vaultConfig *vaultv1.VaultUnsealConfig, name string) *vaultv1.VaultInstanceStatus {
+	for i := range vaultConfig.Status.VaultStatuses {
+		if vaultConfig.Status.VaultStatuses[i].Name == name {
+			return &vaultConfig.Status.VaultStatuses[i]
+		}
+	}
+	return nil
+}
+
+// notify delivers an unseal event to the configured sinks; delivery failures are only logged.
+func (r *VaultUnsealConfigReconciler) notify(
+	ctx context.Context,
+	logger logr.Logger,
+	settings *unsealSettings,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+	eventType notify.EventType,
+	message string,
+) {
+	if len(settings.Notifications) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notify.DefaultTimeout)
+	defer cancel()
+
+	err := r.sinks.SendAll(ctx, settings.Notifications, r.sinkConnections(vaultConfig.Namespace), notify.Event{
+		Type:         eventType,
+		Namespace:    vaultConfig.Namespace,
+		UnsealConfig: vaultConfig.Name,
+		Instance:     instance.Name,
+		Endpoint:     instance.Endpoint,
+		Message:      message,
+		Time:         time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Error(err, "failed to deliver notification", "event", eventType)
+	}
+}
+
+// sinkConnections reads the connections of message bus sinks from Secrets in namespace.
+func (r *VaultUnsealConfigReconciler) sinkConnections(namespace string) notify.ConnectionResolver {
+	return func(ctx context.Context, secretName string) (notify.Connection, error) {
+		data, err := readSecretData(ctx, r.Client, namespace, secretName)
+		if err != nil {
+			return notify.Connection{}, err
+		}
+		return notify.ConnectionFromSecret(namespace+"/"+secretName, data)
+	}
+}
+
+// notifyTransitions delivers the lifecycle events of a successful check in the
+// order they happened: a new seal, the completed initialization, then the unseal.
+func (r *VaultUnsealConfigReconciler) notifyTransitions(
+	ctx context.Context,
+	logger logr.Logger,
+	settings *unsealSettings,
+	vaultConfig *vaultv1.VaultUnsealConfig,
+	instance *vaultv1.VaultInstance,
+	status, previous *vaultv1.VaultInstanceStatus,
+	unsealed bool,
+) {
+	if sealDetected(status, previous) {
+		r.notify(ctx, logger, settings, vaultConfig, instance, notify.EventSealDetected, "")
+	}
+	if initCompleted(status, previous) {
+		r.notify(ctx, logger, settings, vaultConfig, instance, notify.EventInitCompleted, "")
+	}
+	if unsealed {
+		r.notify(ctx, logger, settings, vaultConfig, instance, notify.EventUnsealed, "")
+	}
+}
+
+// sealDetected reports whether recordUnsealHistory recorded a new seal of the instance.
+func sealDetected(status, previous *vaultv1.VaultInstanceStatus) bool {
+	if status.LastSealDetectedTime == nil {
+		return false
+	}
+	return previous == nil || !status.LastSealDetectedTime.Equal(previous.LastSealDetectedTime)
+}
+
+// initCompleted reports whether an instance last seen uninitialized is now initialized.
+func initCompleted(status, previous *vaultv1.VaultInstanceStatus) bool {
+	return previous != nil && previous.Initialized != nil && !*previous.Initialized &&
+		status.Initialized != nil && *status.Initialized
+}
+
 func (r *VaultUnsealConfigReconciler) updateVaultConfigStatus(
 	vaultConfig *vaultv1.VaultUnsealConfig,
 	vaultStatuses []vaultv1.VaultInstanceStatus,
@@ -239,28 +836,61 @@ func (r *VaultUnsealConfigReconciler) updateVaultConfigStatus(
 
 	// Count sealed instances for better messaging
 	sealedCount := 0
+	sealedReason := ""
+	var lastUnseal *metav1.Time
 	for _, status := range vaultStatuses {
 		if status.Sealed {
 			sealedCount++
+			if sealedReason == "" {
+				sealedReason = status.Reason
+			}
+		}
+		// Instances never unsealed by the operator only record when they were first seen
+		if status.UnsealCount > 0 && status.LastUnsealed != nil &&
+			(lastUnseal == nil || lastUnseal.Before(status.LastUnsealed)) {
+			lastUnseal = status.LastUnsealed
 		}
 	}
+	// Disabled instances are neither sealed nor ready
+	enabled := countEnabled(vaultStatuses)
+	vaultConfig.Status.Ready = fmt.Sprintf("%d/%d", enabled-sealedCount, enabled)
+	vaultConfig.Status.SealedInstances = int32(sealedCount)
+	if lastUnseal != nil {
+		vaultConfig.Status.LastUnsealTime = lastUnseal.DeepCopy()
+	}
 
 	// Update conditions
 	condition := metav1.Condition{
-		Type:               "Ready",
+		Type:               ConditionTypeReady,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 		ObservedGeneration: vaultConfig.Generation,
 	}
 
-	if allReady {
+	switch {
+	case allReady && sealedCount > 0:
+		// Hysteresis holds the condition until the instances stay sealed long enough
 		condition.Status = metav1.ConditionTrue
-		condition.Reason = "AllInstancesUnsealed"
-		condition.Message = fmt.Sprintf("All %d vault instances are unsealed", len(vaultConfig.Spec.VaultInstances))
-	} else {
+		condition.Reason = ReasonAllInstancesUnsealed
+		condition.Message = fmt.Sprintf("%d of %d vault instances are sealed, not yet for notReadyThreshold checks in a row",
+			sealedCount, enabled)
+	case allReady:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonAllInstancesUnsealed
+		condition.Message = fmt.Sprintf("All %d vault instances are unsealed", enabled)
+	case sealedCount == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonStabilizing
+		condition.Message = fmt.Sprintf("All %d vault instances are unsealed, not yet for readyThreshold checks in a row",
+			enabled)
+	default:
 		condition.Status = metav1.ConditionFalse
-		condition.Reason = "SomeInstancesSealed"
+		// The first sealed instance with a known cause explains the condition
+		condition.Reason = ReasonSomeInstancesSealed
+		if sealedReason != "" {
+			condition.Reason = sealedReason
+		}
 		condition.Message = fmt.Sprintf("%d of %d vault instances are sealed",
-			sealedCount, len(vaultConfig.Spec.VaultInstances))
+			sealedCount, enabled)
 	}
 
 	// Update or append condition
@@ -271,64 +901,153 @@ func (r *VaultUnsealConfigReconciler) updateCondition(
 	vaultConfig *vaultv1.VaultUnsealConfig,
 	condition *metav1.Condition,
 ) {
-	updated := false
-	for i, existingCondition := range vaultConfig.Status.Conditions {
-		if existingCondition.Type == condition.Type {
-			vaultConfig.Status.Conditions[i] = *condition
-			updated = true
-			break
-		}
-	}
-	if !updated {
-		vaultConfig.Status.Conditions = append(vaultConfig.Status.Conditions, *condition)
-	}
+	// Keeps the transition time while the status is unchanged
+	meta.SetStatusCondition(&vaultConfig.Status.Conditions, *condition)
+}
+
+// unsealGate holds what keeps a sealed instance from being unsealed in a check.
+// The zero value lets the unseal proceed.
+type unsealGate struct {
+	// waitingFor lists dependencies that are not unsealed yet
+	waitingFor []string
+	// maintenance names the maintenance signal holding off unseals
+	maintenance string
+	// unsealAfter delays the unseal of a frequently resealing instance
+	unsealAfter time.Time
+	// quarantined is the fingerprint of keys Vault rejected repeatedly
+	quarantined string
 }
 
+// processVaultInstance checks and, if needed, unseals one instance. It reports
+// whether this call unsealed the instance. A sealed instance is left sealed
+// while gate holds it off, and keys with the quarantined fingerprint of gate
+// are not submitted.
 func (r *VaultUnsealConfigReconciler) processVaultInstance(
 	ctx context.Context,
 	logger logr.Logger,
 	instance *vaultv1.VaultInstance,
 	namespace string,
-) (vaultv1.VaultInstanceStatus, error) {
+	gate unsealGate,
+) (vaultv1.VaultInstanceStatus, bool, error) {
 	clientKey := fmt.Sprintf("%s/%s", namespace, instance.Name)
+	// Pods reached by exec or port-forward access must be in the config's
+	// namespace, so a config cannot exec into pods of other tenants
+	if instance.Access != nil && instance.Namespace == "" {
+		instance.Namespace = namespace
+	}
+	if instanceAccessKey(instance) != "" && instance.Namespace != namespace {
+		return vaultv1.VaultInstanceStatus{}, false, fmt.Errorf(
+			"%s access is limited to pods in namespace %s", instance.Access.Mode, namespace)
+	}
+
+	tunnel, err := r.instanceTunnel(ctx, namespace, instance)
+	if err != nil {
+		return vaultv1.VaultInstanceStatus{}, false, fmt.Errorf("failed to set up tunnel: %w", err)
+	}
+
+	// A newly started pod refuses connections until Vault listens; wait briefly
+	// for its port instead of failing the check with connection errors. One-shot
+	// runs have no client to read the pod with and call Vault directly
+	if tunnel == nil && instanceAccessKey(instance) == "" && r.Client != nil {
+		if pod := r.instancePod(ctx, namespace, instance); pod != nil && podStarting(pod, time.Now()) {
+			address, err := endpointAddress(instance.Endpoint)
+			if err != nil {
+				return vaultv1.VaultInstanceStatus{}, false, err
+			}
+			if err := waitForPort(ctx, address, portProbeAttempts, portProbeDelay); err != nil {
+				logger.V(1).Info("Waiting for vault to listen on newly started pod", "pod", pod.Name, "error", err.Error())
+				return vaultv1.VaultInstanceStatus{Name: instance.Name, Sealed: true, Reason: ReasonPodStarting}, false, nil
+			}
+		}
+	}
 
 	// Get or create vault client using the repository
-	vaultClient, err := r.ClientRepository.GetClient(ctx, clientKey, instance)
+	vaultClient, err := r.ClientRepository.GetClient(withTunnel(ctx, tunnel), clientKey, instance)
 	if err != nil {
-		return vaultv1.VaultInstanceStatus{}, fmt.Errorf("failed to get vault client: %w", err)
+		return vaultv1.VaultInstanceStatus{}, false, fmt.Errorf("failed to get vault client: %w", err)
 	}
 
 	// Check if vault is sealed
-	isSealed, err := vaultClient.IsSealed(ctx)
+	isSealed, initialized, err := sealState(ctx, vaultClient)
 	if err != nil {
-		return vaultv1.VaultInstanceStatus{}, fmt.Errorf("failed to check seal status: %w", err)
+		return vaultv1.VaultInstanceStatus{}, false, fmt.Errorf("failed to check seal status: %w", err)
 	}
 
-	logger.V(1).Info("Vault seal status checked", "sealed", isSealed)
+	logger.V(1).Info("Vault seal status checked", "sealed", isSealed, "initialized", initialized)
 
 	status := vaultv1.VaultInstanceStatus{
 		Name:   instance.Name,
 		Sealed: isSealed,
 	}
+	if instance.Threshold != nil {
+		status.EffectiveThreshold = *instance.Threshold
+	}
 
 	// If sealed, attempt to unseal
-	if isSealed {
-		threshold := getThreshold(instance)
-		logger.Info("Attempting to unseal vault", "threshold", threshold, "keyCount", len(instance.UnsealKeys))
-
-		sealStatus, err := vaultClient.Unseal(ctx, instance.UnsealKeys, threshold)
+	unsealed := false
+	if isSealed && !initialized {
+		// Keys of another cluster would only fail as invalid; wait for vault operator init
+		status.Reason = ReasonUninitialized
+		logger.Info("Waiting for vault to be initialized before unsealing")
+	} else if isSealed && gate.maintenance != "" {
+		status.Reason = ReasonMaintenance
+		logger.Info("Holding off unsealing vault during maintenance", "signal", gate.maintenance)
+	} else if isSealed && len(gate.waitingFor) > 0 {
+		status.Reason = ReasonDependencyNotReady
+		logger.Info("Waiting for dependencies before unsealing vault", "dependencies", gate.waitingFor)
+	} else if isSealed && time.Now().Before(gate.unsealAfter) {
+		status.Reason = ReasonFrequentReseal
+		logger.Info("Delaying unseal of frequently resealing vault", "unsealAfter", gate.unsealAfter)
+	} else if isSealed && !takeUnsealSlot(ctx) {
+		status.Reason = ReasonUnsealDeferred
+		logger.Info("Deferring unseal to the next check, maxConcurrentUnseals reached")
+	} else if isSealed {
+		keys, err := r.unsealKeys(ctx, namespace, instance)
 		if err != nil {
-			return vaultv1.VaultInstanceStatus{}, fmt.Errorf("failed to unseal vault: %w", err)
+			return vaultv1.VaultInstanceStatus{}, false, fmt.Errorf("failed to read unseal keys: %w", err)
 		}
 
-		status.Sealed = sealStatus.Sealed
-		if !sealStatus.Sealed {
-			now := metav1.NewTime(time.Now())
-			status.LastUnsealed = &now
-			logger.Info("Vault successfully unsealed")
+		// Keys Vault rejected repeatedly are not submitted again, as every attempt
+		// lands in its audit log
+		fingerprint := keysFingerprint(keys)
+		if fingerprint == gate.quarantined {
+			status.Reason = ReasonKeysQuarantined
+			status.RejectedKeysHash = fingerprint
+			logger.Info("Not submitting keys vault rejected repeatedly, waiting for new keys or a spec change")
 		} else {
-			logger.Info("Vault remains sealed after unseal attempt",
-				"progress", sealStatus.Progress, "required", sealStatus.T)
+			threshold, err := unsealThreshold(ctx, vaultClient, instance, len(keys))
+			if err != nil {
+				return vaultv1.VaultInstanceStatus{}, false, err
+			}
+			status.EffectiveThreshold = threshold
+			logger.Info("Attempting to unseal vault", "threshold", threshold, "keyCount", len(keys))
+
+			release, err := r.unseals.acquire(ctx)
+			if err != nil {
+				return vaultv1.VaultInstanceStatus{}, false, err
+			}
+			sealStatus, err := unsealWithProgress(ctx, logger, vaultClient, keys, threshold, &status)
+			release()
+			if err != nil {
+				err = fmt.Errorf("failed to unseal vault: %w", err)
+				var responseErr *api.ResponseError
+				if errors.As(err, &responseErr) && vaultRejectedKeys(responseErr) {
+					err = &rejectedKeysError{fingerprint: fingerprint, err: err}
+				}
+				return vaultv1.VaultInstanceStatus{}, false, err
+			}
+
+			status.Sealed = sealStatus.Sealed
+			if !sealStatus.Sealed {
+				now := metav1.NewTime(time.Now())
+				status.LastUnsealed = &now
+				unsealed = true
+				logger.Info("Vault successfully unsealed")
+			} else {
+				status.Reason = ReasonThresholdNotMet
+				logger.Info("Vault remains sealed after unseal attempt",
+					"progress", sealStatus.Progress, "required", sealStatus.T)
+			}
 		}
 	} else {
 		// Already unsealed - update last unsealed time
@@ -337,26 +1056,150 @@ func (r *VaultUnsealConfigReconciler) processVaultInstance(
 		logger.V(1).Info("Vault is already unsealed")
 	}
 
-	return status, nil
+	describeInstance(ctx, logger, vaultClient, &status)
+	if instance.Distribution != "" {
+		status.Distribution = instance.Distribution
+	}
+
+	return status, unsealed, nil
 }
 
-// getThreshold returns the threshold value, defaulting to 3 if not set.
-func getThreshold(instance *vaultv1.VaultInstance) int {
+// unsealThreshold returns the number of keys to unseal the instance with: its
+// configured threshold or, without one, the threshold Vault reports in its seal
+// status. Fewer than keyCount keys fail with a threshold validation error.
+func unsealThreshold(
+	ctx context.Context,
+	vaultClient vault.VaultClient,
+	instance *vaultv1.VaultInstance,
+	keyCount int,
+) (int, error) {
+	threshold := 0
 	if instance.Threshold != nil {
-		return *instance.Threshold
+		threshold = *instance.Threshold
+	} else {
+		sealStatus, err := vaultClient.GetSealStatus(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read unseal threshold: %w", err)
+		}
+		if sealStatus.T < 1 {
+			return 0, fmt.Errorf("vault reports no unseal threshold, is it initialized?")
+		}
+		threshold = sealStatus.T
+	}
+
+	if keyCount < threshold {
+		return 0, vault.NewValidationError("threshold", threshold,
+			fmt.Sprintf("threshold (%d) exceeds number of available keys (%d)", threshold, keyCount))
+	}
+	return threshold, nil
+}
+
+// unsealWithProgress unseals the vault and records in status how many key shares
+// Vault accepted against its threshold. Clients unable to report each key only
+// report the final seal status.
+func unsealWithProgress(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultClient vault.VaultClient,
+	keys []string,
+	threshold int,
+	status *vaultv1.VaultInstanceStatus,
+) (*api.SealStatusResponse, error) {
+	progressClient, ok := vaultClient.(vault.UnsealProgressClient)
+	if !ok {
+		sealStatus, err := vaultClient.Unseal(ctx, keys, threshold)
+		if err == nil && sealStatus.Sealed {
+			status.UnsealProgress = &vaultv1.UnsealProgress{
+				KeysProvided: sealStatus.Progress, Threshold: sealStatus.T,
+			}
+		}
+		return sealStatus, err
+	}
+
+	sealStatus, err := progressClient.UnsealWithProgress(ctx, keys, threshold, func(progress vault.UnsealProgress) {
+		if progress.NonceReset && progress.KeysProvided == 0 {
+			logger.Info("Reset partially completed unseal", "threshold", progress.Threshold)
+		} else {
+			logger.V(1).Info("Unseal key accepted",
+				"keysProvided", progress.KeysProvided, "threshold", progress.Threshold)
+		}
+		status.UnsealProgress = &vaultv1.UnsealProgress{
+			KeysProvided: progress.KeysProvided,
+			Threshold:    progress.Threshold,
+			NonceReset:   progress.NonceReset,
+		}
+	})
+	return sealStatus, err
+}
+
+// describeInstance fills the version, cluster, role, seal type, seal migration
+// state and, unless configured, the unseal threshold of status for clients
+// able to report them. Failures only leave the fields unset.
+func describeInstance(
+	ctx context.Context,
+	logger logr.Logger,
+	vaultClient vault.VaultClient,
+	status *vaultv1.VaultInstanceStatus,
+) {
+	infoClient, ok := vaultClient.(vault.InstanceInfoClient)
+	if !ok {
+		return
+	}
+
+	info, err := infoClient.InstanceInfo(ctx)
+	if err != nil {
+		logger.V(1).Info("Unable to describe vault instance", "error", err.Error())
+		return
 	}
 
-	return DefaultThreshold
+	status.Version = info.Version
+	status.Distribution = info.Distribution
+	status.ClusterName = info.ClusterName
+	status.ClusterID = info.ClusterID
+	status.Initialized = &info.Initialized
+	status.HAEnabled = info.HAEnabled
+	status.Role = info.Role
+	status.SealType = info.SealType
+	if status.EffectiveThreshold == 0 {
+		status.EffectiveThreshold = info.Threshold
+	}
+	status.RecoverySeal = info.RecoverySeal
+	status.RecoverySealType = info.RecoverySealType
+	status.SealMigration = info.Migration
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VaultUnsealConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := indexVaultConfigsForPods(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+
+	options, err := r.controllerOptions(mgr)
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&vaultv1.VaultUnsealConfig{}).
+		For(&vaultv1.VaultUnsealConfig{}, builder.WithPredicates(resumeInFlight(&r.sealed, r.Log))).
 		Watches(
 			&corev1.Pod{},
 			handler.EnqueueRequestsFromMapFunc(r.findVaultConfigsForPod),
 		).
+		Watches(
+			&appsv1.StatefulSet{},
+			handler.EnqueueRequestsFromMapFunc(r.findVaultConfigsForStatefulSet),
+		).
+		Watches(
+			&vaultv1.VaultClusterDefaults{},
+			handler.EnqueueRequestsFromMapFunc(r.findVaultConfigsForDefaults),
+		).
+		// Metadata only, so key material is never held in the informer cache
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findVaultConfigsForSecret),
+			builder.OnlyMetadata,
+		).
+		WithOptions(options).
 		Complete(r)
 }
 
@@ -367,33 +1210,36 @@ func (r *VaultUnsealConfigReconciler) findVaultConfigsForPod(ctx context.Context
 		return []reconcile.Request{}
 	}
 
-	// Check if this is a Vault pod by looking at labels
-	if !r.isVaultPod(pod) {
-		return []reconcile.Request{}
-	}
-
 	logger := r.Log.WithValues("pod", pod.Name, "namespace", pod.Namespace)
+	isVaultPod := r.isVaultPod(pod)
 
-	// List all VaultUnsealConfigs
-	var configs vaultv1.VaultUnsealConfigList
-	if err := r.List(ctx, &configs); err != nil {
-		logger.Error(err, "failed to list VaultUnsealConfigs")
-		return []reconcile.Request{}
-	}
-
+	// Look up candidate configs through the pod index instead of listing all configs
+	seen := make(map[types.NamespacedName]bool)
 	var requests []reconcile.Request
-	for _, config := range configs.Items {
-		if r.configMatchesPod(&config, pod) {
+	for _, value := range podIndexValues(pod) {
+		// Endpoints addressing this pod match directly; namespace candidates match by labels
+		direct := !strings.HasPrefix(value, "namespace:")
+		if !direct && !isVaultPod {
+			continue
+		}
+
+		var configs vaultv1.VaultUnsealConfigList
+		if err := r.List(ctx, &configs, client.MatchingFields{vaultConfigPodIndex: value}); err != nil {
+			logger.Error(err, "failed to list VaultUnsealConfigs")
+			return []reconcile.Request{}
+		}
+
+		for _, config := range configs.Items {
+			key := types.NamespacedName{Name: config.Name, Namespace: config.Namespace}
+			if seen[key] || (!direct && !r.configMatchesPod(&config, pod)) {
+				continue
+			}
+			seen[key] = true
+
 			logger.V(1).Info("Pod event triggers VaultUnsealConfig reconciliation",
 				"config", config.Name,
 				"podPhase", pod.Status.Phase)
-
-			requests = append(requests, reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Name:      config.Name,
-					Namespace: config.Namespace,
-				},
-			})
+			requests = append(requests, reconcile.Request{NamespacedName: key})
 		}
 	}
 
@@ -420,7 +1266,7 @@ func (r *VaultUnsealConfigReconciler) isVaultPod(pod *corev1.Pod) bool {
 func (r *VaultUnsealConfigReconciler) configMatchesPod(config *vaultv1.VaultUnsealConfig, pod *corev1.Pod) bool {
 	for _, instance := range config.Spec.VaultInstances {
 		// Check if pod matches the instance configuration
-		if r.podMatchesInstance(pod, &instance) {
+		if r.podMatchesInstance(pod, config.Namespace, &instance) {
 			return true
 		}
 	}
@@ -428,9 +1274,13 @@ func (r *VaultUnsealConfigReconciler) configMatchesPod(config *vaultv1.VaultUnse
 }
 
 // podMatchesInstance checks if a pod matches a specific vault instance configuration
-func (r *VaultUnsealConfigReconciler) podMatchesInstance(pod *corev1.Pod, instance *vaultv1.VaultInstance) bool {
-	// If instance specifies a namespace and it doesn't match, skip
-	if instance.Namespace != "" && instance.Namespace != pod.Namespace {
+func (r *VaultUnsealConfigReconciler) podMatchesInstance(
+	pod *corev1.Pod,
+	configNamespace string,
+	instance *vaultv1.VaultInstance,
+) bool {
+	// Pods outside the namespace of the instance, the config's by default, never match
+	if instanceNamespace(configNamespace, instance) != pod.Namespace {
 		return false
 	}
 
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionTypeVersionSupported reports whether instances run a Vault version
+// within their expectedVersion constraint.
+const ConditionTypeVersionSupported = "VersionSupported"
+
+// checkVersion reports whether version satisfies the semver range constraint.
+func checkVersion(version, constraint string) (bool, error) {
+	expected, err := semver.ParseRange(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid expectedVersion %q: %w", constraint, err)
+	}
+	// Enterprise builds report versions such as 1.15.2+ent
+	parsed, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse vault version %q: %w", version, err)
+	}
+	return expected(parsed), nil
+}
+
+// updateVersionCondition compares the reported version of every instance with an
+// expectedVersion. Instances whose version is not known yet are skipped. The
+// condition is removed when no instance sets expectedVersion.
+func (r *VaultUnsealConfigReconciler) updateVersionCondition(vaultConfig *vaultv1.VaultUnsealConfig) {
+	constrained := 0
+	reason := ReasonVersionsInRange
+	var mismatches []string
+	for i := range vaultConfig.Spec.VaultInstances {
+		instance := &vaultConfig.Spec.VaultInstances[i]
+		if instance.ExpectedVersion == "" || !instanceEnabled(instance) {
+			continue
+		}
+		constrained++
+
+		for _, status := range instanceStatuses(vaultConfig, instance) {
+			if status.Version == "" {
+				continue
+			}
+			ok, err := checkVersion(status.Version, instance.ExpectedVersion)
+			switch {
+			case err != nil:
+				reason = ReasonInvalidVersionConstraint
+				mismatches = append(mismatches, fmt.Sprintf("%s: %v", status.Name, err))
+			case !ok:
+				if reason == ReasonVersionsInRange {
+					reason = ReasonVersionOutOfRange
+				}
+				mismatches = append(mismatches, fmt.Sprintf("%s: version %s does not satisfy %q",
+					status.Name, status.Version, instance.ExpectedVersion))
+			}
+		}
+	}
+
+	if constrained == 0 {
+		meta.RemoveStatusCondition(&vaultConfig.Status.Conditions, ConditionTypeVersionSupported)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionTypeVersionSupported,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            fmt.Sprintf("All %d constrained instances run an expected version", constrained),
+		ObservedGeneration: vaultConfig.Generation,
+	}
+	if len(mismatches) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = strings.Join(mismatches, "; ")
+	}
+	meta.SetStatusCondition(&vaultConfig.Status.Conditions, condition)
+}
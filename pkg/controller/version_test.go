@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckVersion(t *testing.T) {
+	ok, err := checkVersion("1.15.2", ">=1.15.0 <1.17.0")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = checkVersion("1.15.2+ent", ">=1.16.0")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = checkVersion("1.15.2", "latest")
+	assert.Error(t, err)
+}
+
+func TestVersionCondition(t *testing.T) {
+	r := &VaultUnsealConfigReconciler{}
+	config := &vaultv1.VaultUnsealConfig{
+		Spec: vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{
+			{Name: "vault-0", ExpectedVersion: ">=1.15.0 <1.17.0"},
+			{Name: "vault-1", ExpectedVersion: ">=1.15.0 <1.17.0"},
+			{Name: "unconstrained"},
+		}},
+		Status: vaultv1.VaultUnsealConfigStatus{VaultStatuses: []vaultv1.VaultInstanceStatus{
+			{Name: "vault-0", Version: "1.16.1"},
+			{Name: "vault-1"},
+			{Name: "unconstrained", Version: "1.13.0"},
+		}},
+	}
+	r.updateVersionCondition(config)
+	condition := meta.FindStatusCondition(config.Status.Conditions, ConditionTypeVersionSupported)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status, "unknown versions are not mismatches")
+	assert.Equal(t, ReasonVersionsInRange, condition.Reason)
+
+	// An instance outside the range before a fleet upgrade
+	config.Status.VaultStatuses[1].Version = "1.14.8"
+	r.updateVersionCondition(config)
+	condition = meta.FindStatusCondition(config.Status.Conditions, ConditionTypeVersionSupported)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonVersionOutOfRange, condition.Reason)
+	assert.Contains(t, condition.Message, "vault-1")
+	assert.NotContains(t, condition.Message, "vault-0")
+
+	// The condition disappears once no instance is constrained
+	for i := range config.Spec.VaultInstances {
+		config.Spec.VaultInstances[i].ExpectedVersion = ""
+	}
+	r.updateVersionCondition(config)
+	assert.Nil(t, meta.FindStatusCondition(config.Status.Conditions, ConditionTypeVersionSupported))
+}
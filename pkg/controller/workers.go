@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultMinConcurrentReconciles is the number of reconciles allowed while the queue is idle.
+	DefaultMinConcurrentReconciles = 1
+	// DefaultMaxConcurrentReconciles bounds the reconciles run after mass sealing events.
+	DefaultMaxConcurrentReconciles = 8
+	// DefaultWorkerScaleInterval is how often the concurrency limit is recomputed.
+	DefaultWorkerScaleInterval = 10 * time.Second
+
+	// workerBackoffErrorRate is the share of failing reconciles above which the
+	// limit is halved rather than grown, so unreachable Vaults are not hammered.
+	workerBackoffErrorRate = 0.5
+)
+
+// workerScaler limits concurrent reconciles to a bound between min and max that
+// follows the demand: reconciles running or waiting here plus the queue depth.
+// The controller runs max workers; those above the current limit wait here.
+// Configs with sealed instances are admitted first.
+type workerScaler struct {
+	min, max int
+	logger   logr.Logger
+
+	mu            sync.Mutex
+	cond          *sync.Cond
+	queue         workqueue.TypedInterface[reconcile.Request]
+	limit         int
+	active        int
+	waiting       int
+	sealedWaiting int
+	completed     int
+	failed        int
+}
+
+// newWorkerScaler returns a scaler starting at the minimum limit.
+func newWorkerScaler(minWorkers, maxWorkers int, logger logr.Logger) *workerScaler {
+	minWorkers = max(1, minWorkers)
+	s := &workerScaler{min: minWorkers, max: maxWorkers, limit: minWorkers, logger: logger}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// setQueue sets the queue whose depth drives the limit.
+func (s *workerScaler) setQueue(queue workqueue.TypedInterface[reconcile.Request]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = queue
+}
+
+// acquire blocks until a reconcile may run or ctx is done.
+func (s *workerScaler) acquire(ctx context.Context, sealed bool) error {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.cond.Broadcast()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Waiting workers took their item off the queue, so they count as demand
+	s.waiting++
+	defer func() { s.waiting-- }()
+	if sealed {
+		s.sealedWaiting++
+		defer func() { s.sealedWaiting-- }()
+	}
+	for s.active >= s.limit || (!sealed && s.sealedWaiting > 0) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.cond.Wait()
+	}
+	s.active++
+	return nil
+}
+
+// release ends a reconcile admitted by acquire, recording whether it failed.
+func (s *workerScaler) release(failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active--
+	s.completed++
+	if failed {
+		s.failed++
+	}
+	s.cond.Broadcast()
+}
+
+// adjust recomputes the limit from the demand and the error rate since the
+// previous adjustment, and returns the new limit.
+func (s *workerScaler) adjust() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	desired := s.active + s.waiting
+	if s.queue != nil {
+		desired += s.queue.Len()
+	}
+	if s.completed > 0 && float64(s.failed)/float64(s.completed) > workerBackoffErrorRate {
+		desired = s.limit / 2
+	}
+	desired = max(s.min, min(s.max, desired))
+
+	if desired != s.limit {
+		s.logger.Info("Adjusting concurrent reconciles",
+			"from", s.limit, "to", desired, "completed", s.completed, "failed", s.failed)
+		s.limit = desired
+		s.cond.Broadcast()
+	}
+	s.completed, s.failed = 0, 0
+	return s.limit
+}
+
+// run adjusts the limit every interval until ctx is done.
+func (s *workerScaler) run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.adjust()
+		}
+	}
+}
+
+// hasInstanceErrors reports whether any instance failed to be processed.
+func hasInstanceErrors(statuses []vaultv1.VaultInstanceStatus) bool {
+	for i := range statuses {
+		if statuses[i].Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// controllerOptions returns the VaultUnsealConfig controller options. When the
+// concurrency bounds differ, a scaler is registered with the manager and the
+// controller runs the maximum number of workers.
+func (r *VaultUnsealConfigReconciler) controllerOptions(mgr ctrl.Manager) (controller.Options, error) {
+	newQueue := newSealedPriorityQueue(&r.sealed, r.Log)
+	options := controller.Options{
+		NewQueue:                newQueue,
+		MaxConcurrentReconciles: r.Options.MaxConcurrentReconciles,
+	}
+	if r.Options.MaxConcurrentReconciles <= r.Options.MinConcurrentReconciles {
+		return options, nil
+	}
+
+	r.workers = newWorkerScaler(r.Options.MinConcurrentReconciles, r.Options.MaxConcurrentReconciles, r.Log)
+	options.NewQueue = func(
+		name string,
+		rateLimiter workqueue.TypedRateLimiter[reconcile.Request],
+	) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		queue := newQueue(name, rateLimiter)
+		r.workers.setQueue(queue)
+		return queue
+	}
+
+	interval := r.Options.WorkerScaleInterval
+	if interval <= 0 {
+		interval = DefaultWorkerScaleInterval
+	}
+	err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		return r.workers.run(ctx, interval)
+	}))
+	return options, err
+}
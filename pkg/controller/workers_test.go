@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWorkerScalerFollowsQueueDepth(t *testing.T) {
+	queue := workqueue.NewTyped[reconcile.Request]()
+	defer queue.ShutDown()
+	scaler := newWorkerScaler(1, 4, log.Log)
+	scaler.setQueue(queue)
+
+	assert.Equal(t, 1, scaler.adjust(), "an idle queue keeps the minimum")
+
+	for i := range 10 {
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "vault", Name: fmt.Sprint(i)}})
+	}
+	assert.Equal(t, 4, scaler.adjust(), "a thundering herd is capped at the maximum")
+
+	// Mostly failing reconciles halve the limit even while the queue is deep
+	ctx := context.Background()
+	for range 3 {
+		require.NoError(t, scaler.acquire(ctx, false))
+		scaler.release(true)
+	}
+	require.NoError(t, scaler.acquire(ctx, false))
+	scaler.release(false)
+	assert.Equal(t, 2, scaler.adjust())
+
+	// The error rate is measured per interval
+	assert.Equal(t, 4, scaler.adjust())
+}
+
+func TestWorkerScalerCountsWaitingWorkers(t *testing.T) {
+	// The controller runs the maximum number of workers, which take their items
+	// off the queue before they wait for the limit
+	queue := workqueue.NewTyped[reconcile.Request]()
+	defer queue.ShutDown()
+	scaler := newWorkerScaler(1, 4, log.Log)
+	scaler.setQueue(queue)
+	for i := range 3 {
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "vault", Name: fmt.Sprint(i)}})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	var workers sync.WaitGroup
+	for range 4 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			item, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+			defer queue.Done(item)
+			if scaler.acquire(ctx, false) != nil {
+				return
+			}
+			<-release
+			scaler.release(false)
+		}()
+	}
+	require.Eventually(t, func() bool {
+		scaler.mu.Lock()
+		defer scaler.mu.Unlock()
+		return scaler.active == 1 && scaler.waiting == 2
+	}, time.Second, time.Millisecond)
+	assert.Zero(t, queue.Len())
+
+	assert.Equal(t, 3, scaler.adjust(), "reconciles blocked on the limit count as demand")
+	require.Eventually(t, func() bool {
+		scaler.mu.Lock()
+		defer scaler.mu.Unlock()
+		return scaler.active == 3 && scaler.waiting == 0
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	queue.ShutDown()
+	workers.Wait()
+	assert.Equal(t, 1, scaler.adjust(), "the limit falls back once the work is done")
+}
+
+func TestWorkerScalerAdmitsSealedConfigsFirst(t *testing.T) {
+	scaler := newWorkerScaler(1, 4, log.Log)
+	ctx := context.Background()
+	require.NoError(t, scaler.acquire(ctx, false))
+
+	admitted := make(chan string, 2)
+	go func() {
+		if scaler.acquire(ctx, false) == nil {
+			admitted <- "healthy"
+		}
+	}()
+	// Let the healthy reconcile start waiting before the sealed one
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		if scaler.acquire(ctx, true) == nil {
+			admitted <- "sealed"
+		}
+	}()
+	require.Eventually(t, func() bool {
+		scaler.mu.Lock()
+		defer scaler.mu.Unlock()
+		return scaler.sealedWaiting == 1
+	}, time.Second, time.Millisecond)
+
+	scaler.release(false)
+	assert.Equal(t, "sealed", <-admitted)
+	scaler.release(false)
+	assert.Equal(t, "healthy", <-admitted)
+
+	// Waiting ends with the reconcile context
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, scaler.acquire(cancelled, false), context.Canceled)
+}
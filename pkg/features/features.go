@@ -0,0 +1,141 @@
+// Package features is the registry of feature gates, which let experimental
+// subsystems of the operator ship disabled and be turned on per cluster with
+// --feature-gates.
+package features
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature names a gated subsystem.
+type Feature string
+
+// Stage is the maturity of a feature.
+type Stage string
+
+const (
+	// Alpha features are disabled by default and may change or go away.
+	Alpha Stage = "ALPHA"
+	// Beta features are enabled by default and can still be disabled.
+	Beta Stage = "BETA"
+	// GA features are always enabled; their gate is kept for compatibility.
+	GA Stage = "GA"
+)
+
+const (
+	// Discovery expands instances with Consul or DNS SRV discovery into their nodes.
+	Discovery Feature = "Discovery"
+	// Backups runs the VaultBackupSchedule and VaultRestore controllers.
+	Backups Feature = "Backups"
+)
+
+// Spec describes a registered feature.
+type Spec struct {
+	Default bool
+	Stage   Stage
+}
+
+// registry lists every feature gate the operator knows.
+var registry = map[Feature]Spec{
+	Discovery: {Default: true, Stage: Beta},
+	Backups:   {Default: true, Stage: Beta},
+}
+
+// Gate holds the state of the registered features. It implements flag.Value
+// for a comma-separated list of Feature=bool pairs.
+type Gate struct {
+	mu      sync.RWMutex
+	known   map[Feature]Spec
+	enabled map[Feature]bool
+}
+
+// NewGate returns a gate over the registered features at their defaults.
+func NewGate() *Gate {
+	return newGate(registry)
+}
+
+func newGate(known map[Feature]Spec) *Gate {
+	return &Gate{known: known, enabled: make(map[Feature]bool)}
+}
+
+// Enabled reports whether feature is enabled. Unknown features are disabled.
+func (g *Gate) Enabled(feature Feature) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if enabled, ok := g.enabled[feature]; ok {
+		return enabled
+	}
+	return g.known[feature].Default
+}
+
+// Set parses a list such as "Discovery=false,Backups=true". Unknown features,
+// invalid values and disabling a GA feature are rejected, leaving the gate
+// unchanged.
+func (g *Gate) Set(value string) error {
+	updates := make(map[Feature]bool)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("feature gate %q must have the form Feature=true|false", pair)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		spec, known := g.known[feature]
+		if !known {
+			return fmt.Errorf("unknown feature gate %q, known gates: %s", feature, strings.Join(g.names(), ", "))
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("feature gate %s has invalid value %q", feature, raw)
+		}
+		if spec.Stage == GA && !enabled {
+			return fmt.Errorf("feature gate %s is GA and cannot be disabled", feature)
+		}
+		updates[feature] = enabled
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for feature, enabled := range updates {
+		g.enabled[feature] = enabled
+	}
+	return nil
+}
+
+// String returns the state of every feature as Feature=bool pairs.
+func (g *Gate) String() string {
+	if g == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(g.known))
+	for _, name := range g.names() {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, g.Enabled(Feature(name))))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Usage describes the known features for the help text of --feature-gates.
+func (g *Gate) Usage() string {
+	lines := make([]string, 0, len(g.known))
+	for _, name := range g.names() {
+		spec := g.known[Feature(name)]
+		lines = append(lines, fmt.Sprintf("%s=true|false (%s - default=%t)", name, spec.Stage, spec.Default))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (g *Gate) names() []string {
+	names := make([]string, 0, len(g.known))
+	for feature := range g.known {
+		names = append(names, string(feature))
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,45 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateDefaults(t *testing.T) {
+	gate := NewGate()
+	assert.True(t, gate.Enabled(Discovery))
+	assert.True(t, gate.Enabled(Backups))
+	assert.False(t, gate.Enabled("Unknown"))
+	assert.Equal(t, "Backups=true,Discovery=true", gate.String())
+}
+
+func TestGateSet(t *testing.T) {
+	gate := newGate(map[Feature]Spec{
+		"AutoInit": {Default: false, Stage: Alpha},
+		Discovery:  {Default: true, Stage: Beta},
+		"Stable":   {Default: true, Stage: GA},
+	})
+
+	require.NoError(t, gate.Set("AutoInit=true, Discovery=false,"))
+	assert.True(t, gate.Enabled("AutoInit"))
+	assert.False(t, gate.Enabled(Discovery))
+	assert.Equal(t, "AutoInit=true,Discovery=false,Stable=true", gate.String())
+
+	for value, want := range map[string]string{
+		"Backups=true":            `unknown feature gate "Backups", known gates: AutoInit, Discovery, Stable`,
+		"Discovery":               `feature gate "Discovery" must have the form Feature=true|false`,
+		"Discovery=maybe":         `feature gate Discovery has invalid value "maybe"`,
+		"Stable=false":            "feature gate Stable is GA and cannot be disabled",
+		"Discovery=true,Other=no": `unknown feature gate "Other", known gates: AutoInit, Discovery, Stable`,
+	} {
+		assert.EqualError(t, gate.Set(value), want, value)
+	}
+	// A rejected list changes nothing
+	assert.False(t, gate.Enabled(Discovery))
+
+	assert.Equal(t, "AutoInit=true|false (ALPHA - default=false)\n"+
+		"Discovery=true|false (BETA - default=true)\n"+
+		"Stable=true|false (GA - default=true)", gate.Usage())
+}
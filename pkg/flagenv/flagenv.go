@@ -0,0 +1,42 @@
+// Package flagenv sets command line flags from environment variables, so
+// every flag can be configured the container-native way. A flag given on the
+// command line takes precedence over its environment variable, which takes
+// precedence over the flag's default.
+package flagenv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Name returns the environment variable of flag name: prefix followed by the
+// upper-cased name with dashes and dots replaced by underscores, e.g.
+// OPERATOR_METRICS_BIND_ADDRESS for metrics-bind-address.
+func Name(prefix, name string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Apply sets each flag of fs whose environment variable is set, and appends
+// the variable to the flag's usage. Call it after defining the flags and
+// before fs.Parse, so the command line overrides the environment.
+func Apply(fs *flag.FlagSet, prefix string) error {
+	return apply(fs, prefix, os.LookupEnv)
+}
+
+func apply(fs *flag.FlagSet, prefix string, lookup func(string) (string, bool)) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		env := Name(prefix, f.Name)
+		f.Usage += " [$" + env + "]"
+		value, ok := lookup(env)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for $%s: %w", value, env, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,58 @@
+package flagenv
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "OPERATOR_METRICS_BIND_ADDRESS", Name("OPERATOR_", "metrics-bind-address"))
+	assert.Equal(t, "OPERATOR_ZAP_LOG_LEVEL", Name("OPERATOR_", "zap-log-level"))
+	assert.Equal(t, "OPERATOR_A_B", Name("OPERATOR_", "a.b"))
+}
+
+func TestApply(t *testing.T) {
+	env := map[string]string{
+		"OPERATOR_ADDRESS":     ":9090",
+		"OPERATOR_LEADER":      "true",
+		"OPERATOR_INTERVAL":    "1m",
+		"OPERATOR_OVERRIDDEN":  "from-env",
+		"UNRELATED_ADDRESS":    "ignored",
+		"OPERATOR_NOT_A_FLAG_": "ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	address := fs.String("address", ":8080", "Bind address.")
+	leader := fs.Bool("leader", false, "Leader election.")
+	interval := fs.Duration("interval", time.Second, "Interval.")
+	overridden := fs.String("overridden", "default", "Overridden on the command line.")
+	unset := fs.String("unset", "default", "Not in the environment.")
+
+	require.NoError(t, apply(fs, "OPERATOR_", lookup))
+	require.NoError(t, fs.Parse([]string{"--overridden=from-flag"}))
+
+	assert.Equal(t, ":9090", *address)
+	assert.True(t, *leader)
+	assert.Equal(t, time.Minute, *interval)
+	assert.Equal(t, "from-flag", *overridden)
+	assert.Equal(t, "default", *unset)
+	assert.Equal(t, "Bind address. [$OPERATOR_ADDRESS]", fs.Lookup("address").Usage)
+}
+
+func TestApplyInvalid(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Bool("leader", false, "Leader election.")
+
+	err := apply(fs, "OPERATOR_", func(string) (string, bool) { return "maybe", true })
+	assert.ErrorContains(t, err, `invalid value "maybe" for $OPERATOR_LEADER`)
+}
@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LevelConfigMapKey is the key of the log level ConfigMap holding the level
+	LevelConfigMapKey = "logLevel"
+
+	// DefaultLevelPollInterval is how often the log level ConfigMap is read
+	DefaultLevelPollInterval = 15 * time.Second
+)
+
+// ParseLevel converts a level name, or a positive verbosity as accepted by
+// --zap-log-level, to a zapcore.Level.
+func ParseLevel(level string) (zapcore.Level, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if verbosity, err := strconv.Atoi(level); err == nil {
+		if verbosity <= 0 || verbosity > -math.MinInt8 {
+			return zapcore.InfoLevel, fmt.Errorf("log verbosity must be between 1 and %d: %d", -math.MinInt8, verbosity)
+		}
+		return zapcore.Level(-verbosity), nil
+	}
+	return parseLogLevel(level)
+}
+
+// RuntimeLevel changes the level of the operator log while it runs, so debug
+// logging can be enabled without a restart that would hand over leadership.
+// SIGHUP toggles between debug and the initial level. A ConfigMap, when
+// configured, sets the level whenever its logLevel value changes; removing the
+// value or the ConfigMap restores the initial level.
+type RuntimeLevel struct {
+	level     uberzap.AtomicLevel
+	initial   zapcore.Level
+	reader    client.Reader
+	configMap types.NamespacedName
+	interval  time.Duration
+	logger    logr.Logger
+
+	mu      sync.Mutex
+	applied string
+}
+
+// NewRuntimeLevel creates a RuntimeLevel changing level. An empty configMap
+// name leaves SIGHUP as the only way to change it.
+func NewRuntimeLevel(
+	level uberzap.AtomicLevel,
+	reader client.Reader,
+	configMap types.NamespacedName,
+	interval time.Duration,
+	logger logr.Logger,
+) *RuntimeLevel {
+	if interval <= 0 {
+		interval = DefaultLevelPollInterval
+	}
+	return &RuntimeLevel{
+		level:     level,
+		initial:   level.Level(),
+		reader:    reader,
+		configMap: configMap,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Start handles SIGHUP and polls the ConfigMap until ctx is done.
+func (l *RuntimeLevel) Start(ctx context.Context) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var poll <-chan time.Time
+	if l.configMap.Name != "" {
+		l.sync(ctx)
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			l.ToggleDebug()
+		case <-poll:
+			l.sync(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica
+// follows the configured level.
+func (l *RuntimeLevel) NeedLeaderElection() bool {
+	return false
+}
+
+// ToggleDebug switches to debug logging, or back to the initial level when
+// debug logging is already enabled, and returns the new level.
+func (l *RuntimeLevel) ToggleDebug() zapcore.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := zapcore.DebugLevel
+	if l.level.Level() <= zapcore.DebugLevel {
+		next = l.initial
+	}
+	l.set(next, "signal")
+	return next
+}
+
+// Sync applies the level of the ConfigMap if its value changed since the last call.
+func (l *RuntimeLevel) Sync(ctx context.Context) error {
+	var configMap corev1.ConfigMap
+	value := ""
+	err := l.reader.Get(ctx, l.configMap, &configMap)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to read log level ConfigMap %s: %w", l.configMap, err)
+	default:
+		value = strings.TrimSpace(configMap.Data[LevelConfigMapKey])
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if value == l.applied {
+		return nil
+	}
+	// An invalid value is reported once, not on every poll
+	l.applied = value
+	if value == "" {
+		l.set(l.initial, "configmap")
+		return nil
+	}
+	level, err := ParseLevel(value)
+	if err != nil {
+		return fmt.Errorf("log level ConfigMap %s: %w", l.configMap, err)
+	}
+	l.set(level, "configmap")
+	return nil
+}
+
+func (l *RuntimeLevel) sync(ctx context.Context) {
+	if err := l.Sync(ctx); err != nil {
+		l.logger.Error(err, "failed to apply log level")
+	}
+}
+
+// set changes the level, announcing the change while info messages are still logged.
+func (l *RuntimeLevel) set(level zapcore.Level, source string) {
+	current := l.level.Level()
+	if level == current {
+		return
+	}
+	if level > current {
+		l.logger.Info("log level changed", "level", level.String(), "source", source)
+		l.level.SetLevel(level)
+		return
+	}
+	l.level.SetLevel(level)
+	l.logger.Info("log level changed", "level", level.String(), "source", source)
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CertificateMetrics records the serving certificates of the operator's HTTPS
+// servers, so an expiring certificate or a renewal that was never picked up
+// can be alerted on.
+type CertificateMetrics struct {
+	Expiry  *prometheus.GaugeVec
+	Reloads *prometheus.CounterVec
+}
+
+// NewCertificateMetrics creates the certificate metrics and registers them with registerer.
+func NewCertificateMetrics(registerer prometheus.Registerer) *CertificateMetrics {
+	m := &CertificateMetrics{
+		Expiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "serving_certificate_expiry_timestamp_seconds",
+			Help:      "Expiry of the serving certificate in use by server as a Unix timestamp",
+		}, []string{"server"}),
+		Reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "serving_certificate_reloads_total",
+			Help:      "Serving certificates reloaded from disk after a change by server",
+		}, []string{"server"}),
+	}
+	registerer.MustRegister(m.Expiry, m.Reloads)
+	return m
+}
+
+// RecordCertificate records the certificate now served by server; reload is
+// false for the certificate loaded at startup.
+func (m *CertificateMetrics) RecordCertificate(server string, notAfter time.Time, reload bool) {
+	m.Expiry.WithLabelValues(server).Set(float64(notAfter.Unix()))
+	if reload {
+		m.Reloads.WithLabelValues(server).Inc()
+	}
+}
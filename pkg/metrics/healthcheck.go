@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HealthState is the Vault state observed by a VaultHealthCheck.
+type HealthState struct {
+	Reachable   bool
+	Initialized bool
+	Sealed      bool
+	Standby     bool
+	Version     string
+	ClusterName string
+	ClusterID   string
+}
+
+// HealthCheckMetrics exposes the state observed by VaultHealthCheck resources.
+type HealthCheckMetrics struct {
+	Up          *prometheus.GaugeVec
+	Initialized *prometheus.GaugeVec
+	Sealed      *prometheus.GaugeVec
+	Standby     *prometheus.GaugeVec
+	Info        *prometheus.GaugeVec
+}
+
+// NewHealthCheckMetrics creates the health check gauges and registers them with registerer.
+func NewHealthCheckMetrics(registerer prometheus.Registerer) *HealthCheckMetrics {
+	labels := []string{"namespace", "name"}
+	m := &HealthCheckMetrics{
+		Up: newHealthGaugeVec("healthcheck_up",
+			"Whether the observed vault responded to the last health check", labels),
+		Initialized: newHealthGaugeVec("healthcheck_initialized",
+			"Whether the observed vault is initialized", labels),
+		Sealed: newHealthGaugeVec("healthcheck_sealed",
+			"Whether the observed vault is sealed", labels),
+		Standby: newHealthGaugeVec("healthcheck_standby",
+			"Whether the observed vault is a standby node", labels),
+		Info: newHealthGaugeVec("healthcheck_info",
+			"Version and cluster of the observed vault", append(labels, "version", "cluster_id")),
+	}
+	registerer.MustRegister(m.Up, m.Initialized, m.Sealed, m.Standby, m.Info)
+	return m
+}
+
+func newHealthGaugeVec(name, help string, labels []string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+}
+
+// Observe records the state of a VaultHealthCheck.
+func (m *HealthCheckMetrics) Observe(namespace, name string, state HealthState) {
+	m.Up.WithLabelValues(namespace, name).Set(boolToFloat(state.Reachable))
+	m.Initialized.WithLabelValues(namespace, name).Set(boolToFloat(state.Initialized))
+	m.Sealed.WithLabelValues(namespace, name).Set(boolToFloat(state.Sealed))
+	m.Standby.WithLabelValues(namespace, name).Set(boolToFloat(state.Standby))
+
+	// Drop the previous version/cluster series so upgrades do not leave stale info behind
+	m.Info.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	if state.Reachable {
+		m.Info.WithLabelValues(namespace, name, state.Version, state.ClusterID).Set(1)
+	}
+}
+
+// Delete removes all series of a deleted VaultHealthCheck.
+func (m *HealthCheckMetrics) Delete(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	m.Up.DeletePartialMatch(labels)
+	m.Initialized.DeletePartialMatch(labels)
+	m.Sealed.DeletePartialMatch(labels)
+	m.Standby.DeletePartialMatch(labels)
+	m.Info.DeletePartialMatch(labels)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MaxInstanceLabels bounds the labels of an instance that are attached to its
+// Events and metrics.
+const MaxInstanceLabels = 10
+
+// maxInstanceLabelValue is the longest instance label value that is kept.
+const maxInstanceLabelValue = 63
+
+var instanceLabelKeyPattern = regexp.MustCompile(`^[a-z]([a-z0-9_]{0,61}[a-z0-9])?$`)
+
+// instanceBaseLabels identify the instance of a series and cannot be used as instance label keys.
+var instanceBaseLabels = []string{"namespace", "config", "instance"}
+
+// ValidInstanceLabels returns the labels that are valid Prometheus label names
+// with values of at most 63 characters, keeping the first MaxInstanceLabels keys
+// in sorted order. The API server enforces the same rules; this guards against
+// objects stored before they were.
+func ValidInstanceLabels(labels map[string]string) map[string]string {
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if instanceLabelKeyPattern.MatchString(key) && len(value) <= maxInstanceLabelValue {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	if len(keys) > MaxInstanceLabels {
+		keys = keys[:MaxInstanceLabels]
+	}
+	valid := make(map[string]string, len(keys))
+	for _, key := range keys {
+		valid[key] = labels[key]
+	}
+	return valid
+}
+
+// ErrorClass is the coarse kind of a failed check, for alerting and SLOs.
+type ErrorClass string
+
+// Error classes of failed checks. Each reason of a failure maps to one class.
+const (
+	ErrorClassDNS        ErrorClass = "dns"
+	ErrorClassTLS        ErrorClass = "tls"
+	ErrorClassTimeout    ErrorClass = "timeout"
+	ErrorClassConnection ErrorClass = "connection"
+	ErrorClassHTTP5xx    ErrorClass = "http_5xx"
+	ErrorClassInvalidKey ErrorClass = "invalid_key"
+	ErrorClassPermission ErrorClass = "permission"
+	ErrorClassKeySource  ErrorClass = "key_source"
+	ErrorClassOther      ErrorClass = "other"
+)
+
+// InstanceState is the state of a vault instance of a VaultUnsealConfig.
+type InstanceState struct {
+	Name   string
+	Labels map[string]string
+	Sealed bool
+}
+
+// InstanceMetrics exposes the state of the vault instances of VaultUnsealConfig
+// resources. Series carry the instance labels whose keys the operator exports,
+// so alerts can be sliced by e.g. environment or team; instances without such a
+// label report it empty.
+type InstanceMetrics struct {
+	Sealed   *prometheus.GaugeVec
+	Unseals  *prometheus.CounterVec
+	Failures *prometheus.CounterVec
+	Errors   *prometheus.CounterVec
+
+	labelKeys []string
+}
+
+// NewInstanceMetrics creates the instance metrics with labelKeys as extra labels
+// and registers them with registerer. Only a bounded set of valid keys is
+// accepted, so the label set of the series cannot grow with user input.
+func NewInstanceMetrics(registerer prometheus.Registerer, labelKeys []string) (*InstanceMetrics, error) {
+	if len(labelKeys) > MaxInstanceLabels {
+		return nil, fmt.Errorf("at most %d instance metric labels are allowed, got %d", MaxInstanceLabels, len(labelKeys))
+	}
+	seen := make(map[string]bool, len(labelKeys))
+	for _, key := range labelKeys {
+		if !instanceLabelKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid instance metric label %q: must be lowercase letters, digits and underscores, starting with a letter", key)
+		}
+		if seen[key] || key == "reason" || slices.Contains(instanceBaseLabels, key) {
+			return nil, fmt.Errorf("instance metric label %q is reserved or duplicated", key)
+		}
+		seen[key] = true
+	}
+
+	labels := append(append([]string{}, instanceBaseLabels...), labelKeys...)
+	m := &InstanceMetrics{
+		Sealed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "instance_sealed",
+			Help:      "Whether the vault instance was sealed at the last reconciliation",
+		}, labels),
+		Unseals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "instance_unseals_total",
+			Help:      "Number of times the operator unsealed the vault instance",
+		}, labels),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "instance_failures_total",
+			Help:      "Number of failed checks of the vault instance by reason",
+		}, append(labels, "reason")),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of failed checks of vault instances by error class",
+		}, []string{"namespace", "config", "class"}),
+		labelKeys: append([]string{}, labelKeys...),
+	}
+	registerer.MustRegister(m.Sealed, m.Unseals, m.Failures, m.Errors)
+	return m, nil
+}
+
+// Observe records the state of the instances of a VaultUnsealConfig, replacing
+// the series of instances that were removed or relabeled.
+func (m *InstanceMetrics) Observe(namespace, config string, instances []InstanceState) {
+	m.Sealed.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "config": config})
+	for _, instance := range instances {
+		m.Sealed.WithLabelValues(m.labelValues(namespace, config, instance.Name, instance.Labels)...).
+			Set(boolToFloat(instance.Sealed))
+	}
+}
+
+// RecordUnseal counts an unseal of an instance.
+func (m *InstanceMetrics) RecordUnseal(namespace, config, instance string, labels map[string]string) {
+	m.Unseals.WithLabelValues(m.labelValues(namespace, config, instance, labels)...).Inc()
+}
+
+// RecordFailure counts a failed check of an instance.
+func (m *InstanceMetrics) RecordFailure(namespace, config, instance string, labels map[string]string, reason string) {
+	m.Failures.WithLabelValues(append(m.labelValues(namespace, config, instance, labels), reason)...).Inc()
+}
+
+// RecordError counts a failed check of an instance of a config by its class.
+// Unlike the failure counter it carries no instance labels, so the series stay
+// few enough to alert and compute error budgets on directly.
+func (m *InstanceMetrics) RecordError(namespace, config string, class ErrorClass) {
+	m.Errors.WithLabelValues(namespace, config, string(class)).Inc()
+}
+
+// Delete removes all series of a deleted VaultUnsealConfig.
+func (m *InstanceMetrics) Delete(namespace, config string) {
+	labels := prometheus.Labels{"namespace": namespace, "config": config}
+	m.Sealed.DeletePartialMatch(labels)
+	m.Unseals.DeletePartialMatch(labels)
+	m.Failures.DeletePartialMatch(labels)
+	m.Errors.DeletePartialMatch(labels)
+}
+
+func (m *InstanceMetrics) labelValues(namespace, config, instance string, labels map[string]string) []string {
+	labels = ValidInstanceLabels(labels)
+	values := make([]string, 0, len(instanceBaseLabels)+len(m.labelKeys))
+	values = append(values, namespace, config, instance)
+	for _, key := range m.labelKeys {
+		values = append(values, labels[key])
+	}
+	return values
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInstanceMetricsValidatesLabelKeys(t *testing.T) {
+	for _, keys := range [][]string{
+		{"Environment"},
+		{"team-name"},
+		{"instance"},
+		{"reason"},
+		{"team", "team"},
+		{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+	} {
+		_, err := NewInstanceMetrics(prometheus.NewRegistry(), keys)
+		assert.Error(t, err, "%v", keys)
+	}
+
+	_, err := NewInstanceMetrics(prometheus.NewRegistry(), []string{"environment", "team"})
+	require.NoError(t, err)
+}
+
+func TestValidInstanceLabels(t *testing.T) {
+	labels := map[string]string{
+		"environment": "production",
+		"Team":        "platform",
+		"owner":       strings.Repeat("x", 64),
+	}
+	assert.Equal(t, map[string]string{"environment": "production"}, ValidInstanceLabels(labels))
+	assert.Nil(t, ValidInstanceLabels(nil))
+
+	many := map[string]string{}
+	for _, key := range strings.Split("a b c d e f g h i j k l", " ") {
+		many[key] = key
+	}
+	valid := ValidInstanceLabels(many)
+	assert.Len(t, valid, MaxInstanceLabels)
+	assert.NotContains(t, valid, "l")
+}
+
+func TestInstanceMetricsObserveReplacesSeries(t *testing.T) {
+	m, err := NewInstanceMetrics(prometheus.NewRegistry(), []string{"team"})
+	require.NoError(t, err)
+
+	m.Observe("vault", "cluster", []InstanceState{
+		{Name: "vault-0", Labels: map[string]string{"team": "platform"}, Sealed: true},
+		{Name: "vault-1"},
+	})
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Sealed.WithLabelValues("vault", "cluster", "vault-0", "platform")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.Sealed.WithLabelValues("vault", "cluster", "vault-1", "")))
+
+	// Relabeled and removed instances leave no stale series
+	m.Observe("vault", "cluster", []InstanceState{
+		{Name: "vault-0", Labels: map[string]string{"team": "storage"}},
+	})
+	assert.Equal(t, 1, testutil.CollectAndCount(m.Sealed))
+
+	m.RecordUnseal("vault", "cluster", "vault-0", map[string]string{"team": "storage"})
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Unseals.WithLabelValues("vault", "cluster", "vault-0", "storage")))
+
+	m.Delete("vault", "cluster")
+	assert.Equal(t, 0, testutil.CollectAndCount(m.Sealed))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.Unseals))
+}
+
+func TestInstanceMetricsRecordError(t *testing.T) {
+	m, err := NewInstanceMetrics(prometheus.NewRegistry(), []string{"team"})
+	require.NoError(t, err)
+
+	m.RecordError("vault", "cluster", ErrorClassTimeout)
+	m.RecordError("vault", "cluster", ErrorClassTimeout)
+	m.RecordError("vault", "other", ErrorClassDNS)
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Errors.WithLabelValues("vault", "cluster", "timeout")))
+
+	m.Delete("vault", "cluster")
+	assert.Equal(t, 1, testutil.CollectAndCount(m.Errors))
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Providers of the key sources whose fetches are recorded.
+const (
+	KeySourceSecret = "secret"
+	KeySourceExec   = "exec"
+	KeySourcePKCS11 = "pkcs11"
+	KeySourcePGP    = "pgp"
+	// KeySourceTransit records the decryption of Transit-wrapped keys
+	KeySourceTransit = "transit"
+	// KeySourceAge records the decryption of age-encrypted keys
+	KeySourceAge = "age"
+	// KeySourceBankVaults records the unseal key Secrets of bank-vaults Vaults
+	KeySourceBankVaults = "bankvaults"
+)
+
+// KeySourceMetrics records how long reading the unseal keys from each key
+// source provider takes and how often it fails, so slow or flaky secret
+// backends can be told apart from slow Vaults.
+type KeySourceMetrics struct {
+	Duration *prometheus.HistogramVec
+	Failures *prometheus.CounterVec
+}
+
+// NewKeySourceMetrics creates the key source metrics and registers them with registerer.
+func NewKeySourceMetrics(registerer prometheus.Registerer) *KeySourceMetrics {
+	m := &KeySourceMetrics{
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "key_source_fetch_duration_seconds",
+			Help:      "Latency of reading unseal keys from a key source by provider",
+			Buckets:   requestBuckets,
+		}, []string{"provider", "result"}),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "key_source_fetch_failures_total",
+			Help:      "Failed reads of unseal keys from a key source by provider",
+		}, []string{"provider"}),
+	}
+	registerer.MustRegister(m.Duration, m.Failures)
+	return m
+}
+
+// RecordFetch records a read of the keys of provider.
+func (m *KeySourceMetrics) RecordFetch(provider string, success bool, duration time.Duration) {
+	result := ResultSuccess
+	if !success {
+		result = ResultFailure
+		m.Failures.WithLabelValues(provider).Inc()
+	}
+	m.Duration.WithLabelValues(provider, string(result)).Observe(duration.Seconds())
+}
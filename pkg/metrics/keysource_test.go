@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySourceMetricsRecordFetch(t *testing.T) {
+	m := NewKeySourceMetrics(prometheus.NewRegistry())
+
+	m.RecordFetch(KeySourceSecret, true, 10*time.Millisecond)
+	m.RecordFetch(KeySourceSecret, false, time.Second)
+	m.RecordFetch(KeySourceExec, false, 5*time.Second)
+	m.RecordFetch(KeySourceExec, false, 5*time.Second)
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m.Duration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Failures.WithLabelValues(KeySourceSecret)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Failures.WithLabelValues(KeySourceExec)))
+}
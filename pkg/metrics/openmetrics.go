@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// OpenMetricsPath serves the metrics in the OpenMetrics format when the
+// scraper asks for it. Exemplars are only exposed in that format, which the
+// default /metrics endpoint does not offer.
+const OpenMetricsPath = "/metrics/openmetrics"
+
+// OpenMetricsHandler returns a handler exposing the metrics of gatherer with
+// their exemplars to scrapers negotiating OpenMetrics.
+func OpenMetricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		ErrorHandling:     promhttp.HTTPErrorOnError,
+		EnableOpenMetrics: true,
+	})
+}
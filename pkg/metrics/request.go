@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Operations of the Vault requests whose latency is recorded.
+const (
+	OperationSealStatus = "seal_status"
+	OperationHealth     = "health"
+	OperationUnseal     = "unseal"
+)
+
+// requestBuckets span a fast local Vault to one that is about to time out.
+var requestBuckets = prometheus.ExponentialBuckets(0.005, 2.5, 10)
+
+// RequestMetrics records the latency of the seal status, health and unseal
+// requests of the vault clients, labeled by the host of the endpoint, so slow
+// Vaults or network paths stand out. It implements vault.ExemplarMetrics.
+type RequestMetrics struct {
+	Duration *prometheus.HistogramVec
+
+	// exemplar is attached to the observations, when set
+	exemplar prometheus.Labels
+}
+
+var _ vault.ExemplarMetrics = (*RequestMetrics)(nil)
+
+// NewRequestMetrics creates the request latency histogram and registers it with registerer.
+func NewRequestMetrics(registerer prometheus.Registerer) *RequestMetrics {
+	m := &RequestMetrics{
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "vault_request_duration_seconds",
+			Help:      "Latency of Vault seal status, health and unseal requests by endpoint host",
+			Buckets:   requestBuckets,
+		}, []string{"operation", "host", "result"}),
+	}
+	registerer.MustRegister(m.Duration)
+	return m
+}
+
+// RecordUnsealAttempt records the duration of an unseal, from the seal status
+// read before it to the last key submitted.
+func (m *RequestMetrics) RecordUnsealAttempt(endpoint string, success bool, duration time.Duration) {
+	m.observe(OperationUnseal, endpoint, success, duration)
+}
+
+// RecordHealthCheck records the duration of a health request.
+func (m *RequestMetrics) RecordHealthCheck(endpoint string, success bool, duration time.Duration) {
+	m.observe(OperationHealth, endpoint, success, duration)
+}
+
+// RecordSealStatusCheck records the duration of a seal status request.
+func (m *RequestMetrics) RecordSealStatusCheck(endpoint string, success bool, duration time.Duration) {
+	m.observe(OperationSealStatus, endpoint, success, duration)
+}
+
+// WithExemplar returns metrics attaching exemplar to the observations, so a
+// latency spike links to the trace and reconcile of the request.
+func (m *RequestMetrics) WithExemplar(exemplar map[string]string) vault.ClientMetrics {
+	return &RequestMetrics{Duration: m.Duration, exemplar: exemplarLabels(exemplar)}
+}
+
+func (m *RequestMetrics) observe(operation, endpoint string, success bool, duration time.Duration) {
+	result := ResultSuccess
+	if !success {
+		result = ResultFailure
+	}
+	observer := m.Duration.WithLabelValues(operation, EndpointHost(endpoint), string(result))
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && m.exemplar != nil {
+		exemplarObserver.ObserveWithExemplar(duration.Seconds(), m.exemplar)
+		return
+	}
+	observer.Observe(duration.Seconds())
+}
+
+// exemplarLabels returns exemplar, leaving out the reconcile ID if it would make
+// the exemplar longer than Prometheus accepts; nil if nothing fits.
+func exemplarLabels(exemplar map[string]string) prometheus.Labels {
+	labels := prometheus.Labels{}
+	for _, name := range []string{vault.ExemplarTraceID, vault.ExemplarSpanID, vault.ExemplarReconcileID} {
+		value, ok := exemplar[name]
+		if !ok {
+			continue
+		}
+		labels[name] = value
+		if exemplarRunes(labels) > prometheus.ExemplarMaxRunes {
+			delete(labels, name)
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+func exemplarRunes(labels prometheus.Labels) int {
+	runes := 0
+	for name, value := range labels {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	return runes
+}
+
+// EndpointHost returns the host and port of a Vault endpoint URL. Paths and
+// credentials are dropped, so they never end up in a label.
+func EndpointHost(endpoint string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "unknown"
+	}
+	return parsed.Host
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CloudEventsTypePrefix prefixes the ce-type of every event, e.g.
+// io.vault.unseal.unsealed for EventUnsealed.
+const CloudEventsTypePrefix = "io.vault.unseal."
+
+// CloudEventsSink posts events as CloudEvents 1.0 in binary mode of the HTTP
+// protocol binding: the attributes travel as ce-* headers and the body is the
+// JSON event, so Knative triggers can filter on type and source.
+type CloudEventsSink struct {
+	url        string
+	source     string
+	httpClient *http.Client
+}
+
+// NewCloudEventsSink creates a CloudEvents sink posting to url. An empty source
+// defaults to the API path of the VaultUnsealConfig of each event.
+func NewCloudEventsSink(url, source string, tlsSkipVerify bool) (*CloudEventsSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("cloudEvents url cannot be empty")
+	}
+	return &CloudEventsSink{url: url, source: source, httpClient: newHTTPClient(tlsSkipVerify)}, nil
+}
+
+// Send implements Sink
+func (s *CloudEventsSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	id, err := eventID()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", id)
+	req.Header.Set("ce-type", CloudEventsType(event.Type))
+	req.Header.Set("ce-source", s.eventSource(event))
+	req.Header.Set("ce-subject", event.Instance)
+	if !event.Time.IsZero() {
+		req.Header.Set("ce-time", event.Time.UTC().Format(time.RFC3339Nano))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("cloudEvents sink returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
+
+func (s *CloudEventsSink) eventSource(event Event) string {
+	if s.source != "" {
+		return s.source
+	}
+	return fmt.Sprintf("/apis/vault.io/v1/namespaces/%s/vaultunsealconfigs/%s", event.Namespace, event.UnsealConfig)
+}
+
+// CloudEventsType returns the ce-type of an event type.
+func CloudEventsType(eventType EventType) string {
+	return CloudEventsTypePrefix + strings.ToLower(string(eventType))
+}
+
+// eventID returns a random ce-id; receivers deduplicate redeliveries by source and id.
+func eventID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
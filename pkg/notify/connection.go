@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// Keys of the Secret holding the connection of a message bus sink.
+const (
+	ConnectionURLKey      = "url"
+	ConnectionTokenKey    = "token"
+	ConnectionUsernameKey = "username"
+	ConnectionPasswordKey = "password"
+)
+
+// Connection locates a message bus and the credentials to publish to it.
+type Connection struct {
+	URL      string
+	Token    string
+	Username string
+	Password string
+}
+
+// ConnectionResolver returns the connection stored in the named Secret.
+type ConnectionResolver func(ctx context.Context, secretName string) (Connection, error)
+
+// ConnectionFromSecret reads a connection from the data of a Secret. The url
+// is required; a token takes precedence over a username and password.
+func ConnectionFromSecret(secretName string, data map[string][]byte) (Connection, error) {
+	conn := Connection{
+		URL:      string(data[ConnectionURLKey]),
+		Token:    string(data[ConnectionTokenKey]),
+		Username: string(data[ConnectionUsernameKey]),
+		Password: string(data[ConnectionPasswordKey]),
+	}
+	if conn.URL == "" {
+		return Connection{}, fmt.Errorf("secret %s has no key %q", secretName, ConnectionURLKey)
+	}
+	return conn, nil
+}
+
+func resolveConnection(
+	ctx context.Context,
+	spec vaultv1.NotificationSink,
+	secretName string,
+	connections ConnectionResolver,
+) (Connection, error) {
+	if secretName == "" {
+		return Connection{}, fmt.Errorf("notification sink %q has no connectionSecret", spec.Name)
+	}
+	if connections == nil {
+		return Connection{}, fmt.Errorf("notification sink %q cannot read its connection secret", spec.Name)
+	}
+	conn, err := connections(ctx, secretName)
+	if err != nil {
+		return Connection{}, fmt.Errorf("notification sink %q: %w", spec.Name, err)
+	}
+	return conn, nil
+}
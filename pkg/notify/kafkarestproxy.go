@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaContentType is the media type of JSON records in the Kafka REST proxy API v2.
+const KafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaRESTProxySink produces events as JSON records to a Kafka topic through
+// the Kafka REST proxy API v2, served by the Confluent REST Proxy and the
+// Strimzi Kafka Bridge; it does not speak the Kafka wire protocol. Records are
+// keyed by instance, so the events of one instance land on one partition in order.
+type KafkaRESTProxySink struct {
+	url        string
+	topic      string
+	connection Connection
+	httpClient *http.Client
+}
+
+// NewKafkaRESTProxySink creates a Kafka REST proxy sink producing to topic through the proxy of conn.
+func NewKafkaRESTProxySink(conn Connection, topic string, tlsSkipVerify bool) (*KafkaRESTProxySink, error) {
+	if conn.URL == "" {
+		return nil, fmt.Errorf("kafka proxy url cannot be empty")
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("kafka topic cannot be empty")
+	}
+	return &KafkaRESTProxySink{url: conn.URL, topic: topic, connection: conn, httpClient: newHTTPClient(tlsSkipVerify)}, nil
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaProduceResponse reports the outcome of every record; a record the
+// proxy could not produce has an error code.
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Send implements Sink
+func (s *KafkaRESTProxySink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{
+		Key:   event.Namespace + "/" + event.UnsealConfig + "/" + event.Instance,
+		Value: event,
+	}}})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(s.url, "/") + "/topics/" + url.PathEscape(s.topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", KafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json, application/json")
+	switch {
+	case s.connection.Token != "":
+		req.Header.Set("Authorization", "Bearer "+s.connection.Token)
+	case s.connection.Username != "":
+		req.SetBasicAuth(s.connection.Username, s.connection.Password)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka proxy returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&produced); err != nil {
+		return fmt.Errorf("kafka proxy returned an invalid response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka proxy failed to produce to topic %s: error %d: %s", s.topic, *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
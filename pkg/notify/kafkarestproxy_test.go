@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaRESTProxySinkProducesRecord(t *testing.T) {
+	var request kafkaProduceRequest
+	var path, contentType, user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		user, password, _ = r.BasicAuth()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":42,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	sink, err := NewKafkaRESTProxySink(Connection{URL: server.URL + "/", Username: "operator", Password: "s3cret"}, "vault-unseal", false)
+	require.NoError(t, err)
+	sent := Event{Type: EventSealDetected, Namespace: "vault", UnsealConfig: "vault-cluster", Instance: "vault-0"}
+	require.NoError(t, sink.Send(context.Background(), sent))
+
+	assert.Equal(t, "/topics/vault-unseal", path)
+	assert.Equal(t, KafkaContentType, contentType)
+	assert.Equal(t, "operator", user)
+	assert.Equal(t, "s3cret", password)
+	require.Len(t, request.Records, 1)
+	assert.Equal(t, "vault/vault-cluster/vault-0", request.Records[0].Key)
+	assert.Equal(t, sent, request.Records[0].Value)
+}
+
+func TestKafkaRESTProxySinkReportsFailedRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"Kafka error"}]}`))
+	}))
+	defer server.Close()
+
+	sink, err := NewKafkaRESTProxySink(Connection{URL: server.URL, Token: "s3cret"}, "vault-unseal", false)
+	require.NoError(t, err)
+	err = sink.Send(context.Background(), Event{Type: EventUnsealed, Instance: "vault-0"})
+	assert.EqualError(t, err, "kafka proxy failed to produce to topic vault-unseal: error 50003: Kafka error")
+
+	_, err = NewKafkaRESTProxySink(Connection{URL: server.URL}, "", false)
+	require.Error(t, err)
+}
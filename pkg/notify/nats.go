@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultNATSPort is dialed when the url of a NATS sink has no port
+	DefaultNATSPort = "4222"
+
+	// natsClientName identifies the operator in the connection list of the server
+	natsClientName = "vault-autounseal-operator"
+)
+
+// NATSSink publishes events as JSON messages to a NATS subject, speaking the
+// core NATS client protocol. It connects for every event: events are rare, and
+// a connection is never left broken for the next one.
+type NATSSink struct {
+	url           string
+	subject       string
+	connection    Connection
+	tlsSkipVerify bool
+}
+
+// NewNATSSink creates a NATS sink publishing to subject on the server of conn.
+// A tls:// url, or a server requiring TLS, upgrades the connection to TLS.
+func NewNATSSink(conn Connection, subject string, tlsSkipVerify bool) (*NATSSink, error) {
+	if conn.URL == "" {
+		return nil, fmt.Errorf("nats url cannot be empty")
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("nats subject %q is invalid", subject)
+	}
+	return &NATSSink{url: conn.URL, subject: subject, connection: conn, tlsSkipVerify: tlsSkipVerify}, nil
+}
+
+// natsInfo holds the fields of the server INFO the sink uses.
+type natsInfo struct {
+	TLSRequired bool  `json:"tls_required"`
+	MaxPayload  int64 `json:"max_payload"`
+}
+
+// natsConnect is the CONNECT message of the client.
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Protocol    int    `json:"protocol"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+}
+
+// Send implements Sink
+func (s *NATSSink) Send(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	parsed, err := url.Parse(s.url)
+	if err != nil {
+		return fmt.Errorf("nats sink has an invalid url")
+	}
+	address := parsed.Host
+	if parsed.Port() == "" {
+		address = net.JoinHostPort(parsed.Hostname(), DefaultNATSPort)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := readNATSLine(reader)
+	if err != nil {
+		return err
+	}
+	infoJSON, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("nats server did not send INFO")
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
+		return fmt.Errorf("nats server sent an invalid INFO: %w", err)
+	}
+	if info.MaxPayload > 0 && int64(len(payload)) > info.MaxPayload {
+		return fmt.Errorf("event of %d bytes exceeds the nats max_payload of %d", len(payload), info.MaxPayload)
+	}
+
+	useTLS := parsed.Scheme == "tls" || info.TLSRequired
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         parsed.Hostname(),
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: s.tlsSkipVerify, //nolint:gosec // opt-in per sink
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	connect := natsConnect{TLSRequired: useTLS, Name: natsClientName, Lang: "go", Protocol: 1}
+	if s.connection.Token != "" {
+		connect.AuthToken = s.connection.Token
+	} else {
+		connect.User, connect.Pass = s.connection.Username, s.connection.Password
+	}
+	connectJSON, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "CONNECT %s\r\nPUB %s %d\r\n", connectJSON, s.subject, len(payload))
+	msg.Write(payload)
+	msg.WriteString("\r\nPING\r\n")
+	if _, err := conn.Write(msg.Bytes()); err != nil {
+		return err
+	}
+
+	// The server answers the PING once it processed the PUB, and reports a
+	// rejected CONNECT or PUB with -ERR before that
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			return err
+		}
+		if line == "PONG" {
+			return nil
+		}
+		if reason, ok := strings.CutPrefix(line, "-ERR"); ok {
+			return fmt.Errorf("nats server rejected the event: %s", strings.TrimSpace(reason))
+		}
+	}
+}
+
+// readNATSLine reads a protocol line without its CRLF.
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read from nats server: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// natsMessage is what the fake NATS server received from one connection.
+type natsMessage struct {
+	connect natsConnect
+	pub     string
+	payload string
+}
+
+// fakeNATSServer accepts a single connection, reads a CONNECT, PUB and PING,
+// and answers with reply.
+func fakeNATSServer(t *testing.T, reply string) (string, <-chan natsMessage) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan natsMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"max_payload\":1048576}\r\n")
+
+		reader := bufio.NewReader(conn)
+		var msg natsMessage
+		lines := make([]string, 4)
+		for i := range lines {
+			line, err := readNATSLine(reader)
+			if err != nil {
+				return
+			}
+			lines[i] = line
+		}
+		_ = json.Unmarshal([]byte(strings.TrimPrefix(lines[0], "CONNECT ")), &msg.connect)
+		msg.pub, msg.payload = lines[1], lines[2]
+		if lines[3] == "PING" {
+			_, _ = fmt.Fprint(conn, reply)
+		}
+		received <- msg
+	}()
+	return "nats://" + listener.Addr().String(), received
+}
+
+func TestNATSSinkPublishesEvent(t *testing.T) {
+	serverURL, received := fakeNATSServer(t, "PONG\r\n")
+	sink, err := NewNATSSink(Connection{URL: serverURL, Token: "s3cret"}, "vault.unseal", false)
+	require.NoError(t, err)
+
+	sent := Event{Type: EventUnsealed, Namespace: "vault", UnsealConfig: "vault-cluster", Instance: "vault-0"}
+	require.NoError(t, sink.Send(context.Background(), sent))
+
+	msg := <-received
+	assert.Equal(t, "s3cret", msg.connect.AuthToken)
+	assert.Equal(t, natsClientName, msg.connect.Name)
+	assert.False(t, msg.connect.Verbose)
+	assert.Equal(t, fmt.Sprintf("PUB vault.unseal %d", len(msg.payload)), msg.pub)
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(msg.payload), &event))
+	assert.Equal(t, sent, event)
+}
+
+func TestNATSSinkReportsServerErrors(t *testing.T) {
+	serverURL, received := fakeNATSServer(t, "-ERR 'Authorization Violation'\r\n")
+	sink, err := NewNATSSink(Connection{URL: serverURL, Username: "operator", Password: "wrong"}, "vault.unseal", false)
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), Event{Type: EventUnsealFailed, Instance: "vault-0"})
+	assert.EqualError(t, err, "nats server rejected the event: 'Authorization Violation'")
+	msg := <-received
+	assert.Equal(t, "operator", msg.connect.User)
+	assert.Equal(t, "wrong", msg.connect.Pass)
+
+	_, err = NewNATSSink(Connection{}, "vault.unseal", false)
+	require.Error(t, err)
+}
@@ -0,0 +1,271 @@
+// Package notify delivers unseal events to the notification sinks configured
+// on VaultUnsealConfigs and VaultClusterDefaults.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+)
+
+// EventType identifies what happened to a vault instance.
+type EventType string
+
+const (
+	// EventUnsealed is sent when the operator unsealed a vault instance.
+	EventUnsealed EventType = "Unsealed"
+	// EventUnsealFailed is sent when unsealing a vault instance failed.
+	EventUnsealFailed EventType = "UnsealFailed"
+	// EventSealDetected is sent when a vault instance last seen unsealed is sealed.
+	EventSealDetected EventType = "SealDetected"
+	// EventInitCompleted is sent when a vault instance last seen uninitialized is initialized.
+	EventInitCompleted EventType = "InitCompleted"
+
+	// DefaultTimeout bounds the delivery of a single event to a sink.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Event describes an unseal event of a single vault instance.
+type Event struct {
+	Type         EventType `json:"type"`
+	Namespace    string    `json:"namespace"`
+	UnsealConfig string    `json:"unsealConfig"`
+	Instance     string    `json:"instance"`
+	Endpoint     string    `json:"endpoint"`
+	Message      string    `json:"message,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Sink delivers events to one destination.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// NewSink creates the sink described by spec. Message bus sinks read their
+// connection through connections, which may be nil when none are configured.
+func NewSink(ctx context.Context, spec vaultv1.NotificationSink, connections ConnectionResolver) (Sink, error) {
+	conn, err := sinkConnection(ctx, spec, connections)
+	if err != nil {
+		return nil, err
+	}
+	return newSink(spec, conn)
+}
+
+// sinkConnection reads the connection of a message bus sink; other sinks
+// have none.
+func sinkConnection(
+	ctx context.Context,
+	spec vaultv1.NotificationSink,
+	connections ConnectionResolver,
+) (Connection, error) {
+	switch {
+	case spec.NATS != nil:
+		return resolveConnection(ctx, spec, spec.NATS.ConnectionSecret, connections)
+	case spec.KafkaRESTProxy != nil:
+		return resolveConnection(ctx, spec, spec.KafkaRESTProxy.ConnectionSecret, connections)
+	}
+	return Connection{}, nil
+}
+
+// newSink creates the sink described by spec with its resolved connection.
+func newSink(spec vaultv1.NotificationSink, conn Connection) (Sink, error) {
+	switch {
+	case spec.Webhook != nil:
+		return NewWebhookSink(spec.Webhook.URL, spec.Webhook.TLSSkipVerify)
+	case spec.CloudEvents != nil:
+		return NewCloudEventsSink(spec.CloudEvents.URL, spec.CloudEvents.Source, spec.CloudEvents.TLSSkipVerify)
+	case spec.NATS != nil:
+		return NewNATSSink(conn, spec.NATS.Subject, spec.NATS.TLSSkipVerify)
+	case spec.KafkaRESTProxy != nil:
+		return NewKafkaRESTProxySink(conn, spec.KafkaRESTProxy.Topic, spec.KafkaRESTProxy.TLSSkipVerify)
+	default:
+		return nil, fmt.Errorf("notification sink %q has no sink type configured", spec.Name)
+	}
+}
+
+// ValidateSink checks that spec describes a sink events can be delivered to,
+// without sending anything. Errors never include the URL, which may carry
+// credentials.
+func ValidateSink(ctx context.Context, spec vaultv1.NotificationSink, connections ConnectionResolver) error {
+	sink, err := NewSink(ctx, spec, connections)
+	if err != nil {
+		return err
+	}
+	sinkURL := ""
+	schemes := []string{"http", "https"}
+	switch s := sink.(type) {
+	case *WebhookSink:
+		sinkURL = s.url
+	case *CloudEventsSink:
+		sinkURL = s.url
+	case *NATSSink:
+		sinkURL, schemes = s.url, []string{"nats", "tls"}
+	case *KafkaRESTProxySink:
+		sinkURL = s.url
+	}
+	parsed, err := url.Parse(sinkURL)
+	if err != nil {
+		return fmt.Errorf("notification sink %q has an invalid url", spec.Name)
+	}
+	if !slices.Contains(schemes, parsed.Scheme) {
+		return fmt.Errorf("notification sink %q url must use %s", spec.Name, strings.Join(schemes, " or "))
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("notification sink %q url has no host", spec.Name)
+	}
+	return nil
+}
+
+// sinkExpiry forgets sinks no event was sent to for this long, e.g. after
+// their spec or connection Secret changed.
+const sinkExpiry = time.Hour
+
+// Sinks reuses the sinks, and with them the HTTP clients and their idle
+// connections, of the notification specs events are sent to. A sink is
+// created again when its spec or the content of its connection Secret
+// changes.
+type Sinks struct {
+	mu        sync.Mutex
+	sinks     map[string]*cachedSink
+	lastPrune time.Time
+}
+
+// cachedSink is a sink with the last time an event was sent to it.
+type cachedSink struct {
+	sink     Sink
+	lastUsed time.Time
+}
+
+// NewSinks returns an empty set of sinks.
+func NewSinks() *Sinks {
+	return &Sinks{sinks: make(map[string]*cachedSink)}
+}
+
+// SendAll delivers event to every configured sink and joins delivery errors.
+// A nil Sinks creates the sinks for this event only.
+func (s *Sinks) SendAll(
+	ctx context.Context,
+	specs []vaultv1.NotificationSink,
+	connections ConnectionResolver,
+	event Event,
+) error {
+	var errs []error
+	for _, spec := range specs {
+		sink, err := s.sink(ctx, spec, connections)
+		if err == nil {
+			err = sink.Send(ctx, event)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", spec.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sink returns the sink of spec, created on first use. The connection of
+// message bus sinks is read every time, so rotated credentials take effect.
+func (s *Sinks) sink(ctx context.Context, spec vaultv1.NotificationSink, connections ConnectionResolver) (Sink, error) {
+	conn, err := sinkConnection(ctx, spec, connections)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return newSink(spec, conn)
+	}
+	key, err := json.Marshal(struct {
+		Spec       vaultv1.NotificationSink
+		Connection Connection
+	}{spec, conn})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.prune(now)
+	if cached := s.sinks[string(key)]; cached != nil {
+		cached.lastUsed = now
+		return cached.sink, nil
+	}
+	sink, err := newSink(spec, conn)
+	if err != nil {
+		return nil, err
+	}
+	s.sinks[string(key)] = &cachedSink{sink: sink, lastUsed: now}
+	return sink, nil
+}
+
+// prune drops the sinks not used within sinkExpiry, at most once per expiry.
+func (s *Sinks) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < sinkExpiry {
+		return
+	}
+	s.lastPrune = now
+	for key, cached := range s.sinks {
+		if now.Sub(cached.lastUsed) > sinkExpiry {
+			delete(s.sinks, key)
+		}
+	}
+}
+
+// WebhookSink posts events as JSON.
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates a webhook sink posting to url.
+func NewWebhookSink(url string, tlsSkipVerify bool) (*WebhookSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook url cannot be empty")
+	}
+
+	return &WebhookSink{url: url, httpClient: newHTTPClient(tlsSkipVerify)}, nil
+}
+
+func newHTTPClient(tlsSkipVerify bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // opt-in per sink
+	}
+	return &http.Client{Timeout: DefaultTimeout, Transport: transport}
+}
+
+// Send implements Sink
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
@@ -157,7 +157,8 @@ func (r *VaultUnsealConfigReconciler) processVaultInstance(
 	if !exists {
 		timeout := 30 * time.Second
 		var err error
-		vaultClient, err = vault.NewClient(instance.Endpoint, instance.TLSSkipVerify, timeout)
+		skipVerify := instance.TLSSkipVerify != nil && *instance.TLSSkipVerify
+		vaultClient, err = vault.NewClient(instance.Endpoint, skipVerify, timeout)
 		if err != nil {
 			return vaultv1.VaultInstanceStatus{}, fmt.Errorf("failed to create vault client: %w", err)
 		}
@@ -0,0 +1,214 @@
+// Package plugin implements the kubectl vault-unseal plugin commands.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// None is printed for values that are not set.
+	None = "<none>"
+	// Never is printed for instances that were never unsealed by the operator.
+	Never = "<never>"
+	// Unknown is printed for instances the operator has not reported on yet.
+	Unknown = "Unknown"
+)
+
+// Plugin runs the plugin commands against a cluster.
+type Plugin struct {
+	Client client.Client
+	Out    io.Writer
+
+	now func() time.Time
+}
+
+// New creates a Plugin writing its output to out.
+func New(c client.Client, out io.Writer) *Plugin {
+	return &Plugin{Client: c, Out: out, now: time.Now}
+}
+
+// Status prints one table row per vault instance of every VaultUnsealConfig in
+// namespace; an empty namespace lists all namespaces.
+func (p *Plugin) Status(ctx context.Context, namespace string) error {
+	var configs vaultv1.VaultUnsealConfigList
+	if err := p.Client.List(ctx, &configs, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list VaultUnsealConfigs: %w", err)
+	}
+	sort.Slice(configs.Items, func(i, j int) bool {
+		a, b := configs.Items[i], configs.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	w := tabwriter.NewWriter(p.Out, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAMESPACE\tNAME\tINSTANCE\tSEALED\tLAST UNSEALED\tCONDITIONS")
+	for i := range configs.Items {
+		config := &configs.Items[i]
+		conditions := formatConditions(config.Status.Conditions)
+		for _, instance := range config.Spec.VaultInstances {
+			status := findStatus(config, instance.Name)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				config.Namespace, config.Name, instance.Name,
+				p.sealed(status), p.lastUnsealed(status), conditions)
+		}
+	}
+	return w.Flush()
+}
+
+// Describe prints the spec and status of a single VaultUnsealConfig.
+func (p *Plugin) Describe(ctx context.Context, key types.NamespacedName) error {
+	var config vaultv1.VaultUnsealConfig
+	if err := p.Client.Get(ctx, key, &config); err != nil {
+		return fmt.Errorf("failed to get VaultUnsealConfig %s: %w", key, err)
+	}
+
+	w := tabwriter.NewWriter(p.Out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Name:\t%s\n", config.Name)
+	_, _ = fmt.Fprintf(w, "Namespace:\t%s\n", config.Namespace)
+	_, _ = fmt.Fprintf(w, "Generation:\t%d\n", config.Generation)
+	requested := config.Annotations[vaultv1.ReconcileRequestedAtAnnotation]
+	if requested == "" {
+		requested = None
+	}
+	_, _ = fmt.Fprintf(w, "Reconcile Requested:\t%s\n", requested)
+
+	_, _ = fmt.Fprintln(w, "Instances:")
+	for _, instance := range config.Spec.VaultInstances {
+		status := findStatus(&config, instance.Name)
+		threshold := "default"
+		if instance.Threshold != nil {
+			threshold = fmt.Sprintf("%d", *instance.Threshold)
+		}
+		_, _ = fmt.Fprintf(w, "  %s:\n", instance.Name)
+		if instance.Endpoint != "" || instance.BankVaults == nil {
+			_, _ = fmt.Fprintf(w, "    Endpoint:\t%s\n", instance.Endpoint)
+		}
+		if instance.BankVaults != nil {
+			_, _ = fmt.Fprintf(w, "    Bank-Vaults:\t%s\n", instance.BankVaults.Name)
+		}
+		keys := fmt.Sprintf("%d", len(instance.UnsealKeys))
+		if instance.KeySource != nil && instance.KeySource.Secret != nil {
+			keys = fmt.Sprintf("%d from secret %s", len(instance.KeySource.Secret.Keys), instance.KeySource.Secret.Name)
+			if instance.KeySource.Secret.CombinedKey != "" {
+				keys = fmt.Sprintf("from secret %s key %s", instance.KeySource.Secret.Name, instance.KeySource.Secret.CombinedKey)
+			}
+		}
+		if instance.KeySource != nil && instance.KeySource.Exec != nil {
+			keys = fmt.Sprintf("from command %s", instance.KeySource.Exec.Command)
+		}
+		if instance.KeySource != nil && instance.KeySource.PKCS11 != nil {
+			keys = fmt.Sprintf("from PKCS#11 module %s", instance.KeySource.PKCS11.Token.Module)
+		}
+		if instance.BankVaults != nil && len(instance.UnsealKeys) == 0 && instance.KeySource == nil {
+			keys = fmt.Sprintf("from bank-vaults Vault %s", instance.BankVaults.Name)
+		}
+		if len(instance.KeyIndices) > 0 {
+			keys = fmt.Sprintf("%s, indices %v", keys, instance.KeyIndices)
+		}
+		if instance.ShuffleKeys {
+			keys += ", shuffled"
+		}
+		if instance.TransitUnwrap != nil {
+			keys = fmt.Sprintf("%s, wrapped by transit key %s", keys, instance.TransitUnwrap.Key)
+		}
+		if instance.AgeDecryption != nil {
+			keys += ", age-encrypted"
+		}
+		if instance.PKCS11Decryption != nil {
+			keys += ", decrypted by PKCS#11 key"
+		}
+		if instance.PGPDecryption != nil {
+			keys = fmt.Sprintf("%s, PGP-encrypted to %d keys", keys, len(instance.PGPDecryption.PrivateKeys))
+		}
+		_, _ = fmt.Fprintf(w, "    Keys:\t%s (threshold %s)\n", keys, threshold)
+		_, _ = fmt.Fprintf(w, "    HA Enabled:\t%t\n", instance.HAEnabled)
+		if instance.Enabled != nil && !*instance.Enabled {
+			_, _ = fmt.Fprintln(w, "    Disabled:\ttrue")
+		}
+		_, _ = fmt.Fprintf(w, "    Sealed:\t%s\n", p.sealed(status))
+		_, _ = fmt.Fprintf(w, "    Last Unsealed:\t%s\n", p.lastUnsealed(status))
+		if status != nil && status.Error != "" {
+			_, _ = fmt.Fprintf(w, "    Error:\t%s\n", status.Error)
+		}
+	}
+
+	if len(config.Status.Conditions) == 0 {
+		_, _ = fmt.Fprintf(w, "Conditions:\t%s\n", None)
+		return w.Flush()
+	}
+	_, _ = fmt.Fprintln(w, "Conditions:")
+	_, _ = fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tAGE\tMESSAGE")
+	for _, condition := range config.Status.Conditions {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+			condition.Type, condition.Status, condition.Reason,
+			p.age(condition.LastTransitionTime), condition.Message)
+	}
+	return w.Flush()
+}
+
+// ForceReconcile asks the operator to reconcile a VaultUnsealConfig right away
+// by stamping it with the reconcile-requested-at annotation.
+func (p *Plugin) ForceReconcile(ctx context.Context, key types.NamespacedName) error {
+	if err := controller.RequestReconcile(ctx, p.Client, key, p.now()); err != nil {
+		return fmt.Errorf("failed to request reconcile of %s: %w", key, err)
+	}
+
+	_, _ = fmt.Fprintf(p.Out, "vaultunsealconfig/%s reconcile requested\n", key.Name)
+	return nil
+}
+
+func (p *Plugin) sealed(status *vaultv1.VaultInstanceStatus) string {
+	if status == nil {
+		return Unknown
+	}
+	return fmt.Sprintf("%t", status.Sealed)
+}
+
+func (p *Plugin) lastUnsealed(status *vaultv1.VaultInstanceStatus) string {
+	if status == nil || status.LastUnsealed == nil {
+		return Never
+	}
+	return p.age(*status.LastUnsealed) + " ago"
+}
+
+func (p *Plugin) age(t metav1.Time) string {
+	if t.IsZero() {
+		return None
+	}
+	return duration.HumanDuration(p.now().Sub(t.Time))
+}
+
+func findStatus(config *vaultv1.VaultUnsealConfig, name string) *vaultv1.VaultInstanceStatus {
+	for i := range config.Status.VaultStatuses {
+		if config.Status.VaultStatuses[i].Name == name {
+			return &config.Status.VaultStatuses[i]
+		}
+	}
+	return nil
+}
+
+func formatConditions(conditions []metav1.Condition) string {
+	if len(conditions) == 0 {
+		return None
+	}
+	parts := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		parts = append(parts, fmt.Sprintf("%s=%s", condition.Type, condition.Status))
+	}
+	return strings.Join(parts, ",")
+}
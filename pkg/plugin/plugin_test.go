@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestPlugin(t *testing.T, objs ...client.Object) (*Plugin, *bytes.Buffer) {
+	t.Helper()
+	tc := testutil.NewTestContext(t)
+	c := fake.NewClientBuilder().WithScheme(tc.Scheme).WithObjects(objs...).Build()
+
+	var out bytes.Buffer
+	p := New(c, &out)
+	p.now = func() time.Time { return testNow }
+	return p, &out
+}
+
+func testConfig(namespace, name string) *vaultv1.VaultUnsealConfig {
+	return &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: vaultv1.VaultUnsealConfigSpec{
+			VaultInstances: []vaultv1.VaultInstance{
+				{Name: "vault-0", Endpoint: "http://vault-0:8200", UnsealKeys: []string{"k1", "k2"}, Threshold: testutil.IntPtr(2)},
+				{Name: "vault-1", Endpoint: "http://vault-1:8200", UnsealKeys: []string{"k1", "k2"}},
+			},
+		},
+		Status: vaultv1.VaultUnsealConfigStatus{
+			VaultStatuses: []vaultv1.VaultInstanceStatus{
+				{Name: "vault-0", LastUnsealed: testutil.TimePtr(testNow.Add(-5 * time.Minute))},
+			},
+			Conditions: []metav1.Condition{{
+				Type:               "Ready",
+				Status:             metav1.ConditionFalse,
+				Reason:             "SomeInstancesSealed",
+				Message:            "1 of 2 vault instances are sealed",
+				LastTransitionTime: metav1.NewTime(testNow.Add(-time.Hour)),
+			}},
+		},
+	}
+}
+
+func TestStatus(t *testing.T) {
+	p, out := newTestPlugin(t, testConfig("vault", "primary"), testConfig("other", "secondary"))
+
+	require.NoError(t, p.Status(context.Background(), "vault"))
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^NAMESPACE\s+NAME\s+INSTANCE\s+SEALED\s+LAST UNSEALED\s+CONDITIONS$`, string(lines[0]))
+	assert.Regexp(t, `^vault\s+primary\s+vault-0\s+false\s+5m ago\s+Ready=False$`, string(lines[1]))
+	assert.Regexp(t, `^vault\s+primary\s+vault-1\s+Unknown\s+<never>\s+Ready=False$`, string(lines[2]))
+
+	out.Reset()
+	require.NoError(t, p.Status(context.Background(), ""))
+	assert.Contains(t, out.String(), "secondary")
+}
+
+func TestDescribe(t *testing.T) {
+	p, out := newTestPlugin(t, testConfig("vault", "primary"))
+
+	require.NoError(t, p.Describe(context.Background(), types.NamespacedName{Namespace: "vault", Name: "primary"}))
+
+	assert.Contains(t, out.String(), "Keys:           2 (threshold 2)")
+	assert.Contains(t, out.String(), "Keys:           2 (threshold default)")
+	assert.Regexp(t, `Ready\s+False\s+SomeInstancesSealed\s+60m\s+1 of 2 vault instances are sealed`, out.String())
+
+	err := p.Describe(context.Background(), types.NamespacedName{Namespace: "vault", Name: "missing"})
+	assert.Error(t, err)
+}
+
+func TestForceReconcile(t *testing.T) {
+	p, out := newTestPlugin(t, testConfig("vault", "primary"))
+	key := types.NamespacedName{Namespace: "vault", Name: "primary"}
+
+	require.NoError(t, p.ForceReconcile(context.Background(), key))
+	assert.Equal(t, "vaultunsealconfig/primary reconcile requested\n", out.String())
+
+	var config vaultv1.VaultUnsealConfig
+	require.NoError(t, p.Client.Get(context.Background(), key, &config))
+	assert.Equal(t, "2025-01-01T12:00:00Z", config.Annotations[vaultv1.ReconcileRequestedAtAnnotation])
+}
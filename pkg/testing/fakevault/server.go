@@ -0,0 +1,529 @@
+// Package fakevault provides an in-process fake of the Vault seal API for tests.
+// It serves sys/health, sys/seal-status, sys/unseal and sys/init over httptest,
+// keeping Shamir unseal state like a real server, so controller tests can
+// exercise real clients without containers.
+package fakevault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	// DefaultShares and DefaultThreshold match the defaults of vault operator init
+	DefaultShares    = 5
+	DefaultThreshold = 3
+	// DefaultVersion is the version reported by the server
+	DefaultVersion = "1.19.0"
+
+	// keyShareSize is the size of the key shares Vault hands out
+	keyShareSize = 33
+)
+
+// Fault changes the responses to requests for a path
+type Fault struct {
+	// Status, if set, replaces the response with an error of this status
+	Status int
+	// Delay holds the response back, or until the client gives up
+	Delay time.Duration
+	// Times is the number of requests affected; zero affects all requests
+	// until the faults are cleared
+	Times int
+}
+
+// Server is a fake Vault server. Its methods are safe for concurrent use.
+type Server struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	initialized bool
+	sealed      bool
+	standby     bool
+	threshold   int
+	keys        [][]byte
+	submitted   [][]byte
+	nonce       string
+	rootToken   string
+	version     string
+	clusterName string
+	clusterID   string
+	faults      map[string]*Fault
+	requests    map[string]int
+
+	// Options applied before the server starts
+	shares        int
+	tls           bool
+	unsealed      bool
+	uninitialized bool
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithShares initializes the server with the given number of key shares and
+// threshold instead of the defaults
+func WithShares(shares, threshold int) Option {
+	return func(s *Server) {
+		s.shares = shares
+		s.threshold = threshold
+	}
+}
+
+// WithVersion sets the reported version, e.g. 2.1.0 for OpenBao
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// Unsealed starts the server unsealed
+func Unsealed() Option {
+	return func(s *Server) {
+		s.unsealed = true
+	}
+}
+
+// Uninitialized starts the server uninitialized; it has no keys until it is
+// initialized through sys/init
+func Uninitialized() Option {
+	return func(s *Server) {
+		s.uninitialized = true
+	}
+}
+
+// Standby makes the server report itself as a standby node
+func Standby() Option {
+	return func(s *Server) {
+		s.standby = true
+	}
+}
+
+// WithTLS serves HTTPS with a self-signed certificate
+func WithTLS() Option {
+	return func(s *Server) {
+		s.tls = true
+	}
+}
+
+// New starts a sealed, initialized server with DefaultShares key shares. The
+// server is closed when the test finishes.
+func New(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	s := &Server{
+		shares:      DefaultShares,
+		threshold:   DefaultThreshold,
+		version:     DefaultVersion,
+		clusterName: "vault-cluster-fake",
+		clusterID:   "00000000-0000-0000-0000-000000000000",
+		faults:      make(map[string]*Fault),
+		requests:    make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Like Vault, an uninitialized server reports itself sealed
+	s.sealed = true
+	if !s.uninitialized {
+		if err := s.initialize(s.shares, s.threshold); err != nil {
+			tb.Fatalf("fakevault: %v", err)
+		}
+		s.sealed = !s.unsealed
+	}
+
+	handler := http.HandlerFunc(s.serveHTTP)
+	if s.tls {
+		s.server = httptest.NewTLSServer(handler)
+	} else {
+		s.server = httptest.NewServer(handler)
+	}
+	tb.Cleanup(s.server.Close)
+	return s
+}
+
+// URL returns the address of the server, for use as an instance endpoint
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Client returns an HTTP client that trusts the server certificate
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+// Close shuts the server down; later requests fail to connect
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Keys returns the base64 encoded key shares of the server
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, len(s.keys))
+	for i, key := range s.keys {
+		keys[i] = base64.StdEncoding.EncodeToString(key)
+	}
+	return keys
+}
+
+// Threshold returns the number of key shares needed to unseal the server
+func (s *Server) Threshold() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.threshold
+}
+
+// RootToken returns the root token created when the server was initialized
+func (s *Server) RootToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rootToken
+}
+
+// Seal seals the server and discards any unseal progress, as a restart would
+func (s *Server) Seal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.initialized {
+		s.sealed = true
+	}
+	s.resetProgress()
+}
+
+// Sealed reports whether the server is sealed
+func (s *Server) Sealed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sealed
+}
+
+// Initialized reports whether the server is initialized
+func (s *Server) Initialized() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initialized
+}
+
+// Progress returns the number of key shares submitted in the current attempt
+func (s *Server) Progress() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.submitted)
+}
+
+// Requests returns the number of requests received for a path such as
+// sys/unseal
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[normalizePath(path)]
+}
+
+// Inject applies fault to the requests for a path such as sys/unseal,
+// replacing any fault already set for it
+func (s *Server) Inject(path string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[normalizePath(path)] = &fault
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string]*Fault)
+}
+
+// initialize generates key shares and a root token. It must be called with the
+// lock held or before the server starts.
+func (s *Server) initialize(shares, threshold int) error {
+	switch {
+	case shares < 1:
+		return fmt.Errorf("secret shares must be at least one")
+	case threshold < 1 || threshold > shares:
+		return fmt.Errorf("invalid seal configuration: threshold must be between 1 and %d", shares)
+	case shares > 1 && threshold == 1:
+		return fmt.Errorf("invalid seal configuration: threshold must be greater than one for multiple shares")
+	}
+
+	s.keys = make([][]byte, shares)
+	for i := range s.keys {
+		s.keys[i] = randomBytes(keyShareSize)
+	}
+	s.threshold = threshold
+	s.rootToken = "hvs." + base64.RawURLEncoding.EncodeToString(randomBytes(18))
+	s.initialized = true
+	s.sealed = true
+	s.resetProgress()
+	return nil
+}
+
+// resetProgress discards the shares of the current unseal attempt
+func (s *Server) resetProgress() {
+	s.submitted = nil
+	s.nonce = ""
+}
+
+// serveHTTP routes a request after counting it and applying its fault
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Path)
+
+	s.mu.Lock()
+	s.requests[path]++
+	var fault Fault
+	if f, ok := s.faults[path]; ok {
+		fault = *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				delete(s.faults, path)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault.Status != 0 {
+		writeErrors(w, fault.Status, fmt.Sprintf("injected fault: %s", http.StatusText(fault.Status)))
+		return
+	}
+
+	switch {
+	case path == "sys/health" && r.Method == http.MethodGet:
+		s.health(w, r)
+	case path == "sys/seal-status" && r.Method == http.MethodGet:
+		s.sealStatus(w)
+	case path == "sys/unseal" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		s.unseal(w, r)
+	case path == "sys/init" && r.Method == http.MethodGet:
+		s.mu.Lock()
+		initialized := s.initialized
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]bool{"initialized": initialized})
+	case path == "sys/init" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		s.init(w, r)
+	default:
+		writeErrors(w, http.StatusNotFound)
+	}
+}
+
+// health answers sys/health with the status codes Vault uses, which clients
+// override with the *code query parameters
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, param := http.StatusOK, "activecode"
+	switch {
+	case !s.initialized:
+		status, param = http.StatusNotImplemented, "uninitcode"
+	case s.sealed:
+		status, param = http.StatusServiceUnavailable, "sealedcode"
+	case s.standby:
+		status, param = http.StatusTooManyRequests, "standbycode"
+		if ok, _ := strconv.ParseBool(r.URL.Query().Get("standbyok")); ok {
+			status = http.StatusOK
+		}
+	}
+	if code, err := strconv.Atoi(r.URL.Query().Get(param)); err == nil {
+		status = code
+	}
+
+	writeJSON(w, status, &api.HealthResponse{
+		Initialized:                s.initialized,
+		Sealed:                     s.sealed,
+		Standby:                    s.standby,
+		ReplicationPerformanceMode: "disabled",
+		ReplicationDRMode:          "disabled",
+		ServerTimeUTC:              time.Now().Unix(),
+		Version:                    s.version,
+		ClusterName:                s.clusterName,
+		ClusterID:                  s.clusterID,
+	})
+}
+
+// sealStatus answers sys/seal-status
+func (s *Server) sealStatus(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.sealStatusResponse())
+}
+
+// unseal accepts a key share. Shares are collected until the threshold is
+// reached; the attempt then succeeds only if every share belongs to the server.
+func (s *Server) unseal(w http.ResponseWriter, r *http.Request) {
+	var req api.UnsealOpts
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrors(w, http.StatusBadRequest, "failed to parse JSON input: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		writeErrors(w, http.StatusBadRequest, "Vault is not initialized")
+		return
+	}
+	if req.Reset {
+		s.resetProgress()
+		writeJSON(w, http.StatusOK, s.sealStatusResponse())
+		return
+	}
+	if req.Key == "" {
+		writeErrors(w, http.StatusBadRequest, "'key' must be specified in request body as JSON, or 'reset' set to true")
+		return
+	}
+	key, err := decodeKey(req.Key)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "'key' must be a valid hex or base64 string")
+		return
+	}
+	if !s.sealed {
+		writeJSON(w, http.StatusOK, s.sealStatusResponse())
+		return
+	}
+
+	// Vault ignores a share submitted twice in one attempt
+	for _, submitted := range s.submitted {
+		if string(submitted) == string(key) {
+			writeJSON(w, http.StatusOK, s.sealStatusResponse())
+			return
+		}
+	}
+	if len(s.submitted) == 0 {
+		s.nonce = hex.EncodeToString(randomBytes(16))
+	}
+	s.submitted = append(s.submitted, key)
+	if len(s.submitted) < s.threshold {
+		writeJSON(w, http.StatusOK, s.sealStatusResponse())
+		return
+	}
+
+	valid := true
+	for _, submitted := range s.submitted {
+		valid = valid && s.isKey(submitted)
+	}
+	s.resetProgress()
+	if !valid {
+		writeErrors(w, http.StatusBadRequest, "failed to unseal: cipher: message authentication failed")
+		return
+	}
+	s.sealed = false
+	writeJSON(w, http.StatusOK, s.sealStatusResponse())
+}
+
+// init initializes the server with the requested key shares
+func (s *Server) init(w http.ResponseWriter, r *http.Request) {
+	var req api.InitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrors(w, http.StatusBadRequest, "failed to parse JSON input: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized {
+		writeErrors(w, http.StatusBadRequest, "Vault is already initialized")
+		return
+	}
+	if err := s.initialize(req.SecretShares, req.SecretThreshold); err != nil {
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := &api.InitResponse{RootToken: s.rootToken}
+	for _, key := range s.keys {
+		resp.Keys = append(resp.Keys, hex.EncodeToString(key))
+		resp.KeysB64 = append(resp.KeysB64, base64.StdEncoding.EncodeToString(key))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// sealStatusResponse describes the seal state. It must be called with the lock held.
+func (s *Server) sealStatusResponse() *api.SealStatusResponse {
+	status := &api.SealStatusResponse{
+		Type:        "shamir",
+		Initialized: s.initialized,
+		Sealed:      s.sealed,
+		T:           s.threshold,
+		N:           len(s.keys),
+		Progress:    len(s.submitted),
+		Nonce:       s.nonce,
+		Version:     s.version,
+		StorageType: "raft",
+	}
+	if !s.sealed {
+		status.ClusterName = s.clusterName
+		status.ClusterID = s.clusterID
+	}
+	return status
+}
+
+// isKey reports whether key is one of the key shares. It must be called with
+// the lock held.
+func (s *Server) isKey(key []byte) bool {
+	for _, share := range s.keys {
+		if string(share) == string(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeKey decodes a key share given as hex or base64, as Vault accepts both
+func decodeKey(key string) ([]byte, error) {
+	if decoded, err := hex.DecodeString(key); err == nil {
+		return decoded, nil
+	}
+	return base64.StdEncoding.DecodeString(key)
+}
+
+// normalizePath strips the API prefix so /v1/sys/unseal and sys/unseal match
+func normalizePath(path string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(path, "/"), "v1/")
+}
+
+// randomBytes returns n random bytes
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("fakevault: failed to read random bytes: %v", err))
+	}
+	return b
+}
+
+// writeJSON writes body as a JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeErrors writes an error response the way Vault does
+func writeErrors(w http.ResponseWriter, status int, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+	writeJSON(w, status, map[string][]string{"errors": errs})
+}
@@ -0,0 +1,173 @@
+package fakevault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPIClient(t *testing.T, s *Server) *api.Client {
+	t.Helper()
+	config := api.DefaultConfig()
+	config.Address = s.URL()
+	config.HttpClient = s.Client()
+	config.MaxRetries = 0
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func TestUnsealWithVaultClient(t *testing.T) {
+	s := New(t)
+	client, err := vault.NewClient(s.URL(), false, 5*time.Second)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	sealed, err := client.IsSealed(t.Context())
+	require.NoError(t, err)
+	assert.True(t, sealed)
+
+	status, err := client.Unseal(t.Context(), s.Keys()[:DefaultThreshold], DefaultThreshold)
+	require.NoError(t, err)
+	assert.False(t, status.Sealed)
+	assert.False(t, s.Sealed())
+
+	info, err := client.InstanceInfo(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultVersion, info.Version)
+	assert.Equal(t, vault.RoleActive, info.Role)
+	assert.Equal(t, "vault-cluster-fake", info.ClusterName)
+}
+
+func TestUnsealProgress(t *testing.T) {
+	s := New(t, WithShares(3, 2))
+	sys := newAPIClient(t, s).Sys()
+	keys := s.Keys()
+
+	status, err := sys.Unseal(keys[0])
+	require.NoError(t, err)
+	assert.True(t, status.Sealed)
+	assert.Equal(t, 1, status.Progress)
+	assert.Equal(t, 2, status.T)
+	assert.Equal(t, 3, status.N)
+	assert.NotEmpty(t, status.Nonce)
+
+	// A share submitted twice does not count
+	status, err = sys.Unseal(keys[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Progress)
+
+	// Resetting discards the attempt
+	status, err = sys.ResetUnsealProcess()
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Progress)
+
+	// An unknown share fails the attempt once the threshold is reached
+	_, err = sys.Unseal(keys[0])
+	require.NoError(t, err)
+	_, err = sys.Unseal("bm90LWEtc2hhcmU=")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unseal")
+	assert.True(t, s.Sealed())
+	assert.Equal(t, 0, s.Progress())
+
+	// Invalid encodings are rejected without counting
+	_, err = sys.Unseal("not a key!")
+	require.Error(t, err)
+	assert.Equal(t, 0, s.Progress())
+
+	status, err = sys.Unseal(keys[2])
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Progress)
+	status, err = sys.Unseal(keys[1])
+	require.NoError(t, err)
+	assert.False(t, status.Sealed)
+
+	s.Seal()
+	assert.True(t, s.Sealed())
+}
+
+func TestHealthStatusCodes(t *testing.T) {
+	get := func(s *Server, query string) int {
+		resp, err := s.Client().Get(s.URL() + "/v1/sys/health" + query)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get(New(t), ""))
+	assert.Equal(t, 299, get(New(t), "?sealedcode=299"))
+	assert.Equal(t, http.StatusOK, get(New(t, Unsealed()), ""))
+	assert.Equal(t, http.StatusNotImplemented, get(New(t, Uninitialized()), ""))
+	assert.Equal(t, http.StatusTooManyRequests, get(New(t, Unsealed(), Standby()), ""))
+	assert.Equal(t, http.StatusOK, get(New(t, Unsealed(), Standby()), "?standbyok=true"))
+
+	health, err := newAPIClient(t, New(t, Unsealed(), Standby(), WithVersion("2.1.0"), WithTLS())).Sys().Health()
+	require.NoError(t, err)
+	assert.True(t, health.Standby)
+	assert.Equal(t, "2.1.0", health.Version)
+}
+
+func TestInit(t *testing.T) {
+	s := New(t, Uninitialized())
+	sys := newAPIClient(t, s).Sys()
+
+	initialized, err := sys.InitStatus()
+	require.NoError(t, err)
+	assert.False(t, initialized)
+	assert.True(t, s.Sealed(), "uninitialized vaults report sealed")
+
+	_, err = sys.Unseal("a2V5")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not initialized")
+
+	_, err = sys.Init(&api.InitRequest{SecretShares: 3, SecretThreshold: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "greater than one")
+
+	resp, err := sys.Init(&api.InitRequest{SecretShares: 1, SecretThreshold: 1})
+	require.NoError(t, err)
+	assert.Equal(t, s.Keys(), resp.KeysB64)
+	assert.Len(t, resp.Keys, 1)
+	assert.Equal(t, s.RootToken(), resp.RootToken)
+	assert.True(t, s.Initialized())
+	assert.True(t, s.Sealed())
+
+	_, err = sys.Init(&api.InitRequest{SecretShares: 1, SecretThreshold: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already initialized")
+
+	// Vault accepts hex encoded shares too
+	status, err := sys.Unseal(resp.Keys[0])
+	require.NoError(t, err)
+	assert.False(t, status.Sealed)
+}
+
+func TestFaults(t *testing.T) {
+	s := New(t)
+	sys := newAPIClient(t, s).Sys()
+
+	s.Inject("sys/seal-status", Fault{Status: http.StatusInternalServerError, Times: 1})
+	_, err := sys.SealStatus()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "injected fault")
+	_, err = sys.SealStatus()
+	require.NoError(t, err, "the fault applies to one request")
+
+	s.Inject("/v1/sys/seal-status", Fault{Delay: time.Second})
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = sys.SealStatusWithContext(ctx)
+	require.Error(t, err)
+
+	s.ClearFaults()
+	_, err = sys.SealStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.Requests("sys/seal-status"))
+}
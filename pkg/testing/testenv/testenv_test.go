@@ -0,0 +1,33 @@
+package testenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryRootHoldsCRDs(t *testing.T) {
+	_, err := os.Stat(filepath.Join(repositoryRoot(), "manifests", "crd.yaml"))
+	require.NoError(t, err)
+}
+
+func TestVaultInstance(t *testing.T) {
+	server := fakevault.New(t, fakevault.WithShares(3, 2))
+	instance := VaultInstance("vault-0", server)
+	assert.Equal(t, "vault-0", instance.Name)
+	assert.Equal(t, server.URL(), instance.Endpoint)
+	assert.Equal(t, server.Keys(), instance.UnsealKeys)
+	require.NotNil(t, instance.Threshold)
+	assert.Equal(t, 2, *instance.Threshold)
+}
+
+func TestStart(t *testing.T) {
+	env := Start(t)
+	namespace := env.Namespace(t)
+	assert.NotEmpty(t, namespace)
+	assert.NotEqual(t, namespace, env.Namespace(t))
+}
@@ -145,6 +145,11 @@ func IntPtr(i int) *int {
 	return &i
 }
 
+// BoolPtr returns a pointer to a bool value.
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
 // TimePtr returns a pointer to a metav1.Time value.
 func TimePtr(t time.Time) *metav1.Time {
 	mt := metav1.NewTime(t)
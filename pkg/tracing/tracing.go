@@ -0,0 +1,63 @@
+// Package tracing exports OpenTelemetry traces of reconciles and the Vault
+// requests they make over OTLP/HTTP, so the latency exemplars of a slow request
+// link to its trace.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName names the tracer of the operator's spans
+	TracerName = "github.com/panteparak/vault-autounseal-operator"
+	// ServiceName is the service.name of the exported spans
+	ServiceName = "vault-autounseal-operator"
+	// DefaultSampleRatio is the share of reconciles traced by default
+	DefaultSampleRatio = 1.0
+)
+
+// Tracer returns the tracer of the operator's spans. Until Setup installs a
+// provider, spans are not recorded and carry no trace ID.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Setup installs a global tracer provider exporting spans to endpoint, the URL
+// of an OTLP/HTTP collector such as http://otel-collector:4318, and sampling
+// ratio of the traces started by the operator. The trace context is propagated
+// to Vault in traceparent headers. It returns a function flushing and stopping
+// the exporter.
+func Setup(ctx context.Context, endpoint string, ratio float64, version string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return nil, errors.New("tracing endpoint cannot be empty")
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio must be between 0 and 1: %g", ratio)
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+			semconv.ServiceVersion(version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetupExportsSpans(t *testing.T) {
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	shutdown, err := Setup(t.Context(), collector.URL, 1, "test")
+	require.NoError(t, err)
+
+	_, span := Tracer().Start(context.Background(), "reconcile")
+	assert.True(t, span.SpanContext().IsSampled())
+	span.End()
+
+	require.NoError(t, shutdown(t.Context()))
+	assert.Equal(t, int32(1), exports.Load())
+}
+
+func TestSetupValidates(t *testing.T) {
+	_, err := Setup(t.Context(), "", 1, "test")
+	assert.EqualError(t, err, "tracing endpoint cannot be empty")
+	_, err = Setup(t.Context(), "http://otel-collector:4318", 1.5, "test")
+	assert.EqualError(t, err, "tracing sample ratio must be between 0 and 1: 1.5")
+}
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchUnsealKeys returns n base64 encoded 33 byte keys, the size of the
+// Shamir shares Vault hands out. The keys are the same on every run so
+// results compare across runs.
+func benchUnsealKeys(n int) []string {
+	source := rand.New(rand.NewSource(1))
+	keys := make([]string, n)
+	for i := range keys {
+		share := make([]byte, 33)
+		source.Read(share)
+		keys[i] = base64.StdEncoding.EncodeToString(share)
+	}
+	return keys
+}
+
+// BenchmarkValidateKeys measures validating the keys of an instance before they
+// are submitted, for the default five key share and for larger shares
+func BenchmarkValidateKeys(b *testing.B) {
+	validators := []struct {
+		name      string
+		validator KeyValidator
+	}{
+		{"default", NewDefaultKeyValidator()},
+		{"strict", NewStrictKeyValidator(33)},
+	}
+	for _, v := range validators {
+		for _, n := range []int{5, 10} {
+			keys := benchUnsealKeys(n)
+			threshold := n/2 + 1
+			if err := v.validator.ValidateKeys(keys, threshold); err != nil {
+				b.Fatalf("benchmark keys are invalid: %v", err)
+			}
+
+			b.Run(fmt.Sprintf("%s/keys=%d", v.name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := v.validator.ValidateKeys(keys, threshold); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+
+	// Rejections build errors, so they are measured separately
+	b.Run("default/duplicate", func(b *testing.B) {
+		validator := NewDefaultKeyValidator()
+		keys := benchUnsealKeys(5)
+		keys[4] = keys[0]
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := validator.ValidateKeys(keys, 3); err == nil {
+				b.Fatal("duplicate keys were accepted")
+			}
+		}
+	})
+}
@@ -1,15 +1,24 @@
 package vault
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -29,6 +38,9 @@ type Client struct {
 	metrics   ClientMetrics
 	mu        sync.RWMutex
 	closed    bool
+
+	// sealChecks collapses concurrent seal-status checks of this endpoint into one request
+	sealChecks singleflight.Group
 }
 
 // ClientConfig holds configuration for creating a vault client
@@ -41,6 +53,11 @@ type ClientConfig struct {
 	Metrics       ClientMetrics
 	MaxRetries    int
 	RetryDelay    time.Duration
+	Token         string
+	// Transport replaces the default HTTP transport, e.g. to reach Vault through a pod
+	Transport http.RoundTripper
+	// Dial opens the connections of the default HTTP transport, e.g. through a tunnel
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 // ClientOption is a functional option for configuring a vault client.
@@ -89,6 +106,27 @@ func WithRetryPolicy(maxRetries int, retryDelay time.Duration) ClientOption {
 	}
 }
 
+// WithTransport sets the HTTP transport used to reach Vault.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *ClientConfig) {
+		c.Transport = transport
+	}
+}
+
+// WithDialer sets the function the default HTTP transport opens connections with.
+func WithDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(c *ClientConfig) {
+		c.Dial = dial
+	}
+}
+
+// WithToken sets the Vault token used for authenticated endpoints such as Raft snapshots.
+func WithToken(token string) ClientOption {
+	return func(c *ClientConfig) {
+		c.Token = token
+	}
+}
+
 // NewClient creates a new Vault client with the given configuration
 func NewClient(url string, tlsSkipVerify bool, timeout time.Duration) (*Client, error) {
 	return NewClientWithOptions(url,
@@ -133,6 +171,10 @@ func validateClientConfig(config *ClientConfig) error {
 			"URL exceeds maximum length of 2048 characters")
 	}
 
+	if err := validateEndpointHost(config.URL); err != nil {
+		return err
+	}
+
 	// Reject extremely small timeouts
 	if config.Timeout < time.Millisecond {
 		return NewValidationError("timeout", config.Timeout,
@@ -147,6 +189,37 @@ func validateClientConfig(config *ClientConfig) error {
 	return nil
 }
 
+// validateEndpointHost checks the host and port of an endpoint URL. IPv6
+// literals must be bracketed: in http://fd00::1:8200 the port cannot be told
+// apart from the address.
+func validateEndpointHost(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return NewValidationError("url", endpoint, fmt.Sprintf("invalid URL: %v", err))
+	}
+	if u.Hostname() == "" {
+		return NewValidationError("url", endpoint, "URL must contain a host")
+	}
+
+	if strings.HasPrefix(u.Host, "[") {
+		// Zones such as [fe80::1%25eth0] select the interface of a link-local address
+		host, _, _ := strings.Cut(u.Hostname(), "%")
+		if addr, err := netip.ParseAddr(host); err != nil || !addr.Is6() {
+			return NewValidationError("url", endpoint, "brackets must enclose an IPv6 address")
+		}
+	} else if strings.Count(u.Host, ":") > 1 {
+		return NewValidationError("url", endpoint,
+			"IPv6 addresses must be enclosed in brackets, e.g. https://[fd00::1]:8200")
+	}
+
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return NewValidationError("url", endpoint, fmt.Sprintf("invalid port %q", port))
+		}
+	}
+	return nil
+}
+
 // NewClientWithConfig creates a new Vault client with advanced configuration
 func NewClientWithConfig(config *ClientConfig) (*Client, error) {
 	if err := validateClientConfig(config); err != nil {
@@ -174,6 +247,7 @@ func NewClientWithConfig(config *ClientConfig) (*Client, error) {
 	httpClient := &http.Client{
 		Timeout: config.Timeout,
 		Transport: &http.Transport{
+			DialContext:         config.Dial,
 			DisableKeepAlives:   false,
 			MaxIdleConns:        20,
 			MaxIdleConnsPerHost: 10,
@@ -181,6 +255,11 @@ func NewClientWithConfig(config *ClientConfig) (*Client, error) {
 			MaxConnsPerHost:     50,
 		},
 	}
+	if config.Transport != nil {
+		httpClient.Transport = config.Transport
+	}
+	// Requests are traced as children of the span of ctx, e.g. of the reconcile
+	httpClient.Transport = otelhttp.NewTransport(&requestIDTransport{base: httpClient.Transport})
 	vaultConfig.HttpClient = httpClient
 
 	apiClient, err := api.NewClient(vaultConfig)
@@ -188,12 +267,16 @@ func NewClientWithConfig(config *ClientConfig) (*Client, error) {
 		return nil, NewVaultError("client-creation", config.URL, err, false)
 	}
 
+	if config.Token != "" {
+		apiClient.SetToken(config.Token)
+	}
+
 	// Set security headers
 	apiClient.SetHeaders(map[string][]string{
 		"User-Agent":             {"vault-autounseal-operator/2.0"},
 		"X-Content-Type-Options": {"nosniff"},
 		"X-Frame-Options":        {"DENY"},
-		"X-Request-ID":           {fmt.Sprintf("vault-operator-%d", time.Now().UnixNano())},
+		RequestIDHeader:          {fmt.Sprintf("vault-operator-%d", time.Now().UnixNano())},
 	})
 
 	// Set default validator if not provided
@@ -232,24 +315,85 @@ func NewClientWithConfig(config *ClientConfig) (*Client, error) {
 
 // IsSealed checks if the vault is sealed
 func (c *Client) IsSealed(ctx context.Context) (bool, error) {
+	state, err := c.SealState(ctx)
+	if err != nil {
+		return true, err
+	}
+	return state.Sealed, nil
+}
+
+// SealState reads whether the vault is sealed and initialized
+func (c *Client) SealState(ctx context.Context) (SealState, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	if c.closed {
-		return true, NewVaultError("is-sealed", c.url, fmt.Errorf("client is closed"), false)
+		return SealState{Sealed: true}, NewVaultError("is-sealed", c.url, fmt.Errorf("client is closed"), false)
 	}
 
-	start := time.Now()
-	status, err := c.client.Sys().SealStatusWithContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return SealState{Sealed: true}, NewVaultError("seal-status", c.url, err, true)
+	}
 
-	if c.metrics != nil {
-		c.metrics.RecordSealStatusCheck(c.url, err == nil, time.Since(start))
+	// The shared request must not fail every waiting caller when the first one gives up
+	shared := context.WithoutCancel(ctx)
+	result := c.sealChecks.DoChan("seal-status", func() (any, error) {
+		start := time.Now()
+		state, err := c.readSealState(shared)
+
+		if c.metrics != nil {
+			metricsFor(shared, c.metrics).RecordSealStatusCheck(c.url, err == nil, time.Since(start))
+		}
+		return state, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return SealState{Sealed: true}, NewVaultError("seal-status", c.url, ctx.Err(), true)
+	case res := <-result:
+		if res.Err != nil {
+			return SealState{Sealed: true}, NewVaultError("seal-status", c.url, res.Err, true)
+		}
+		return res.Val.(SealState), nil
 	}
+}
+
+// sealStatusBuffers recycles the buffers seal-status bodies are read into, so
+// periodic checks of many instances do not allocate one per request.
+var sealStatusBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
 
+// readSealState reads sys/seal-status and decodes only the sealed and
+// initialized flags, avoiding the full SealStatusResponse decoded by the API client.
+func (c *Client) readSealState(ctx context.Context) (SealState, error) {
+	// Raw requests do not apply the client timeout themselves
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	resp, err := c.client.Logical().ReadRawWithContext(ctx, "sys/seal-status")
 	if err != nil {
-		return true, NewVaultError("seal-status", c.url, err, true)
+		return SealState{Sealed: true}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	buf := sealStatusBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		sealStatusBuffers.Put(buf)
+	}()
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return SealState{Sealed: true}, fmt.Errorf("failed to read seal status: %w", err)
+	}
+
+	var state SealState
+	if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+		return SealState{Sealed: true}, fmt.Errorf("failed to decode seal status: %w", err)
 	}
-	return status.Sealed, nil
+	return state, nil
 }
 
 // GetSealStatus returns the current seal status
@@ -265,7 +409,7 @@ func (c *Client) GetSealStatus(ctx context.Context) (*api.SealStatusResponse, er
 	status, err := c.client.Sys().SealStatusWithContext(ctx)
 
 	if c.metrics != nil {
-		c.metrics.RecordSealStatusCheck(c.url, err == nil, time.Since(start))
+		metricsFor(ctx, c.metrics).RecordSealStatusCheck(c.url, err == nil, time.Since(start))
 	}
 
 	if err != nil {
@@ -287,6 +431,22 @@ func (c *Client) Unseal(ctx context.Context, keys []string, threshold int) (*api
 	return c.strategy.Unseal(ctx, c, keys, threshold)
 }
 
+// UnsealWithProgress unseals the vault, calling report as Vault accepts each key
+func (c *Client) UnsealWithProgress(
+	ctx context.Context, keys []string, threshold int, report func(UnsealProgress),
+) (*api.SealStatusResponse, error) {
+	return c.Unseal(withUnsealProgress(ctx, report), keys, threshold)
+}
+
+// ResetUnseal discards the key shares submitted for the current unseal attempt
+func (c *Client) ResetUnseal(ctx context.Context) (*api.SealStatusResponse, error) {
+	status, err := c.client.Sys().ResetUnsealProcessWithContext(ctx)
+	if err != nil {
+		return nil, NewVaultError("unseal-reset", c.url, err, true)
+	}
+	return status, nil
+}
+
 // SubmitSingleKey submits a single unseal key (used by strategies)
 func (c *Client) SubmitSingleKey(
 	ctx context.Context, encodedKey string, keyIndex int,
@@ -337,7 +497,7 @@ func (c *Client) HealthCheck(ctx context.Context) (*api.HealthResponse, error) {
 	health, err := c.client.Sys().HealthWithContext(ctx)
 
 	if c.metrics != nil {
-		c.metrics.RecordHealthCheck(c.url, err == nil, time.Since(start))
+		metricsFor(ctx, c.metrics).RecordHealthCheck(c.url, err == nil, time.Since(start))
 	}
 
 	if err != nil {
@@ -346,6 +506,124 @@ func (c *Client) HealthCheck(ctx context.Context) (*api.HealthResponse, error) {
 	return health, nil
 }
 
+// InstanceInfo describes the server from its seal status and, once unsealed, its
+// health and leader responses. Errors of the latter leave their fields unset.
+func (c *Client) InstanceInfo(ctx context.Context) (*InstanceInfo, error) {
+	status, err := c.GetSealStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &InstanceInfo{
+		Version:      status.Version,
+		Distribution: DetectDistribution(status.Version),
+		ClusterName:  status.ClusterName,
+		ClusterID:    status.ClusterID,
+		Initialized:  status.Initialized,
+		Sealed:       status.Sealed,
+		SealType:     status.Type,
+		Threshold:    status.T,
+
+		RecoverySeal:     status.RecoverySeal,
+		RecoverySealType: status.RecoverySealType,
+		Migration:        status.Migration,
+	}
+	if status.Sealed {
+		return info, nil
+	}
+
+	if health, err := c.HealthCheck(ctx); err == nil {
+		switch {
+		case health.PerformanceStandby:
+			info.Role = RolePerformanceStandby
+		case health.Standby:
+			info.Role = RoleStandby
+		default:
+			info.Role = RoleActive
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return info, nil
+	}
+	if leader, err := c.client.Sys().LeaderWithContext(ctx); err == nil {
+		info.HAEnabled = &leader.HAEnabled
+	}
+	return info, nil
+}
+
+// DetectDistribution infers the distribution from a reported server version.
+// OpenBao releases start at 2.0.0 while Vault releases are 1.x, and Vault
+// Enterprise builds carry an +ent suffix. Unknown versions report "".
+func DetectDistribution(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	majorPart, _, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorPart)
+	switch {
+	case err != nil:
+		return ""
+	case major < 2 || strings.Contains(version, "+ent"):
+		return DistributionVault
+	default:
+		return DistributionOpenBao
+	}
+}
+
+// RaftSnapshot streams a Raft storage snapshot into w. The client must have been
+// created with a token allowed to read sys/storage/raft/snapshot.
+func (c *Client) RaftSnapshot(ctx context.Context, w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return NewVaultError("raft-snapshot", c.url, fmt.Errorf("client is closed"), false)
+	}
+
+	if err := c.client.Sys().RaftSnapshotWithContext(ctx, w); err != nil {
+		return NewVaultError("raft-snapshot", c.url, err, true)
+	}
+	return nil
+}
+
+// RaftSnapshotRestore streams a Raft snapshot from r into Vault. With force the
+// snapshot-force endpoint is used, which accepts snapshots from other clusters.
+func (c *Client) RaftSnapshotRestore(ctx context.Context, r io.Reader, force bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return NewVaultError("raft-snapshot-restore", c.url, fmt.Errorf("client is closed"), false)
+	}
+
+	// A partially consumed snapshot stream cannot be replayed, so never retry
+	if err := c.client.Sys().RaftSnapshotRestoreWithContext(ctx, r, force); err != nil {
+		return NewVaultError("raft-snapshot-restore", c.url, err, false)
+	}
+	return nil
+}
+
+// RaftAutopilotState returns the autopilot state of the Raft cluster. The client
+// must have been created with a token allowed to read sys/storage/raft/autopilot/state.
+func (c *Client) RaftAutopilotState(ctx context.Context) (*api.AutopilotState, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, NewVaultError("raft-autopilot-state", c.url, fmt.Errorf("client is closed"), false)
+	}
+
+	state, err := c.client.Sys().RaftAutopilotStateWithContext(ctx)
+	if err != nil {
+		return nil, NewVaultError("raft-autopilot-state", c.url, err, true)
+	}
+	if state == nil {
+		return nil, NewVaultError("raft-autopilot-state", c.url, fmt.Errorf("autopilot state is not available"), false)
+	}
+	return state, nil
+}
+
 // Close closes the client and cleans up resources
 func (c *Client) Close() error {
 	c.mu.Lock()
@@ -387,11 +665,18 @@ func (c *Client) IsClosed() bool {
 }
 
 // DefaultClientFactory implements the ClientFactory interface
-type DefaultClientFactory struct{}
+type DefaultClientFactory struct {
+	// Metrics, if set, records the requests of the created clients
+	Metrics ClientMetrics
+}
 
 // NewClient implements ClientFactory interface
 func (f *DefaultClientFactory) NewClient(
 	endpoint string, tlsSkipVerify bool, timeout time.Duration,
 ) (VaultClient, error) {
-	return NewClient(endpoint, tlsSkipVerify, timeout)
+	return NewClientWithOptions(endpoint,
+		WithTLSSkipVerify(tlsSkipVerify),
+		WithTimeout(timeout),
+		WithMetrics(f.Metrics),
+	)
 }
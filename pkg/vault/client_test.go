@@ -3,11 +3,18 @@ package vault
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/fakevault"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -67,6 +74,20 @@ func (suite *ClientTestSuite) TestNewClient() {
 			timeout:       30 * time.Second,
 			expectError:   true,
 		},
+		{
+			name:          "bracketed IPv6 URL",
+			url:           "https://[fd00::1]:8200",
+			tlsSkipVerify: false,
+			timeout:       30 * time.Second,
+			expectError:   false,
+		},
+		{
+			name:          "unbracketed IPv6 URL",
+			url:           "https://fd00::1:8200",
+			tlsSkipVerify: false,
+			timeout:       30 * time.Second,
+			expectError:   true,
+		},
 		{
 			name:          "extremely small timeout",
 			url:           "http://localhost:8200",
@@ -177,6 +198,282 @@ func (suite *ClientTestSuite) TestClientClose() {
 	assert.True(suite.T(), client.IsClosed())
 }
 
+// TestIsSealedDecodesSealStatus tests the seal-status polling path against a live endpoint
+func (suite *ClientTestSuite) TestIsSealedDecodesSealStatus() {
+	sealed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/seal-status" {
+			http.Error(w, "unexpected path", http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"type":"shamir","initialized":true,"sealed":%t,"t":3,"n":5,"version":"1.15.0"}`, sealed)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	// Repeated checks reuse pooled buffers and must not leak state between calls
+	for _, want := range []bool{true, false, true} {
+		sealed = want
+		got, err := client.IsSealed(suite.ctx)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), want, got)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	})
+	got, err := client.IsSealed(suite.ctx)
+	assert.Error(suite.T(), err)
+	assert.True(suite.T(), got, "errors report the instance as sealed")
+}
+
+// TestSealStateReportsInitialization tests that the polling path reads the initialized flag
+func (suite *ClientTestSuite) TestSealStateReportsInitialization() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"type":"shamir","initialized":false,"sealed":true,"t":0,"n":0}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	state, err := client.SealState(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), SealState{Sealed: true, Initialized: false}, state)
+}
+
+// TestInstanceInfo tests that seal-status, health and leader responses describe the server
+func (suite *ClientTestSuite) TestInstanceInfo() {
+	sealed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/seal-status":
+			_, _ = fmt.Fprintf(w, `{"type":"awskms","initialized":true,"sealed":%t,"version":"1.15.0",`+
+				`"cluster_name":"vault-cluster-1","cluster_id":"c-1","recovery_seal":true,`+
+				`"recovery_seal_type":"shamir","migration":%t}`, sealed, sealed)
+		case "/v1/sys/health":
+			_, _ = fmt.Fprint(w, `{"initialized":true,"sealed":false,"standby":true,"version":"1.15.0"}`)
+		case "/v1/sys/leader":
+			_, _ = fmt.Fprint(w, `{"ha_enabled":true,"is_self":false}`)
+		default:
+			http.Error(w, "unexpected path", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	info, err := client.InstanceInfo(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1.15.0", info.Version)
+	assert.Equal(suite.T(), DistributionVault, info.Distribution)
+	assert.Equal(suite.T(), "vault-cluster-1", info.ClusterName)
+	assert.Equal(suite.T(), "c-1", info.ClusterID)
+	assert.True(suite.T(), info.Initialized)
+	assert.Equal(suite.T(), "awskms", info.SealType)
+	assert.True(suite.T(), info.RecoverySeal)
+	assert.Equal(suite.T(), "shamir", info.RecoverySealType)
+	assert.False(suite.T(), info.Migration)
+	assert.Equal(suite.T(), RoleStandby, info.Role)
+	require.NotNil(suite.T(), info.HAEnabled)
+	assert.True(suite.T(), *info.HAEnabled)
+
+	// A sealed server only reports its seal status
+	sealed = true
+	info, err = client.InstanceInfo(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), info.Sealed)
+	assert.True(suite.T(), info.Migration)
+	assert.Empty(suite.T(), info.Role)
+	assert.Nil(suite.T(), info.HAEnabled)
+}
+
+// TestUnsealWithProgress tests that a stale unseal attempt is reset and each accepted key is reported
+func (suite *ClientTestSuite) TestUnsealWithProgress() {
+	var mu sync.Mutex
+	progress, nonce := 1, "stale"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var req api.UnsealOpts
+		if r.URL.Path == "/v1/sys/unseal" {
+			require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		}
+		switch {
+		case req.Reset:
+			progress, nonce = 0, "fresh"
+		case req.Key != "":
+			progress++
+		}
+		sealed := progress < 2
+		if !sealed {
+			progress, nonce = 0, ""
+		}
+		_, _ = fmt.Fprintf(w, `{"sealed":%t,"t":2,"n":3,"progress":%d,"nonce":%q}`, sealed, progress, nonce)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	var reported []UnsealProgress
+	keys := []string{
+		base64.StdEncoding.EncodeToString([]byte("key-one")),
+		base64.StdEncoding.EncodeToString([]byte("key-two")),
+	}
+	status, err := client.UnsealWithProgress(suite.ctx, keys, 2, func(p UnsealProgress) {
+		reported = append(reported, p)
+	})
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), status.Sealed)
+	assert.Equal(suite.T(), []UnsealProgress{
+		{KeysProvided: 0, Threshold: 2, Nonce: "fresh", NonceReset: true},
+		{KeysProvided: 1, Threshold: 2, Nonce: "fresh", NonceReset: true},
+		{KeysProvided: 2, Threshold: 2, NonceReset: true},
+	}, reported)
+}
+
+// TestUnsealSubmitsOnlyRequiredKeys tests that no more keys than Vault requires are submitted
+func (suite *ClientTestSuite) TestUnsealSubmitsOnlyRequiredKeys() {
+	var mu sync.Mutex
+	progress, submitted := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path == "/v1/sys/unseal" {
+			submitted++
+			progress++
+		}
+		sealed := progress < 2
+		_, _ = fmt.Fprintf(w, `{"sealed":%t,"t":2,"n":5,"progress":%d}`, sealed, progress%2)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	keys := []string{
+		base64.StdEncoding.EncodeToString([]byte("key-one")),
+		base64.StdEncoding.EncodeToString([]byte("key-two")),
+		base64.StdEncoding.EncodeToString([]byte("key-three")),
+	}
+
+	// A strict threshold rejects the mismatch before submitting any key
+	_, err = client.Unseal(WithStrictThreshold(suite.ctx), keys, 3)
+	var mismatch *ThresholdMismatchError
+	require.ErrorAs(suite.T(), err, &mismatch)
+	assert.Equal(suite.T(), ThresholdMismatchError{Configured: 3, Required: 2}, *mismatch)
+	assert.Zero(suite.T(), submitted)
+
+	status, err := client.Unseal(suite.ctx, keys, 3)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), status.Sealed)
+	assert.Equal(suite.T(), 2, submitted, "only the threshold Vault reports is submitted")
+}
+
+// TestOpenBaoCompatibility tests that an OpenBao server is detected and unsealed like Vault
+func (suite *ClientTestSuite) TestOpenBaoCompatibility() {
+	sealed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/seal-status":
+			_, _ = fmt.Fprintf(w, `{"type":"shamir","initialized":true,"sealed":%t,"t":1,"n":1,`+
+				`"version":"2.1.0","build_date":"2024-11-29T17:10:21Z","cluster_name":"openbao-cluster"}`, sealed)
+		case "/v1/sys/unseal":
+			sealed = false
+			_, _ = fmt.Fprint(w, `{"type":"shamir","initialized":true,"sealed":false,"t":1,"n":1,"version":"2.1.0"}`)
+		case "/v1/sys/health":
+			_, _ = fmt.Fprint(w, `{"initialized":true,"sealed":false,"standby":false,"version":"2.1.0"}`)
+		case "/v1/sys/leader":
+			_, _ = fmt.Fprint(w, `{"ha_enabled":false,"is_self":false}`)
+		default:
+			http.Error(w, "unexpected path", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	status, err := client.Unseal(suite.ctx, []string{base64.StdEncoding.EncodeToString([]byte("bao-key"))}, 1)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), status.Sealed)
+
+	info, err := client.InstanceInfo(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), DistributionOpenBao, info.Distribution)
+	assert.Equal(suite.T(), "openbao-cluster", info.ClusterName)
+	assert.Equal(suite.T(), RoleActive, info.Role)
+}
+
+// TestDetectDistribution tests distribution detection from reported versions
+func (suite *ClientTestSuite) TestDetectDistribution() {
+	assert.Equal(suite.T(), DistributionVault, DetectDistribution("1.15.2"))
+	assert.Equal(suite.T(), DistributionVault, DetectDistribution("1.16.1+ent"))
+	assert.Equal(suite.T(), DistributionOpenBao, DetectDistribution("2.0.0"))
+	assert.Equal(suite.T(), DistributionOpenBao, DetectDistribution("v2.1.0-beta20241114"))
+	assert.Empty(suite.T(), DetectDistribution(""))
+	assert.Empty(suite.T(), DetectDistribution("unknown"))
+}
+
+// TestIsSealedSharesConcurrentChecks tests that concurrent checks of one endpoint issue a single request
+func (suite *ClientTestSuite) TestIsSealedSharesConcurrentChecks() {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = fmt.Fprint(w, `{"sealed":true}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(suite.T(), err)
+	defer func() { _ = client.Close() }()
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make(chan bool, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sealed, err := client.IsSealed(suite.ctx)
+			assert.NoError(suite.T(), err)
+			results <- sealed
+		}()
+	}
+
+	// Let every caller join the in-flight request before Vault answers
+	require.Eventually(suite.T(), func() bool { return requests.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for sealed := range results {
+		assert.True(suite.T(), sealed)
+	}
+	assert.Equal(suite.T(), int32(1), requests.Load())
+
+	// A caller giving up does not wait for the shared request
+	ctx, cancel := context.WithCancel(suite.ctx)
+	cancel()
+	_, err = client.IsSealed(ctx)
+	assert.ErrorIs(suite.T(), err, context.Canceled)
+}
+
 // TestClientClosedOperations tests operations on a closed client
 func (suite *ClientTestSuite) TestClientClosedOperations() {
 	client, err := NewClient("http://localhost:8200", false, 30*time.Second)
@@ -316,6 +613,69 @@ func (suite *ClientTestSuite) TestValidateClientConfig() {
 			},
 			expectError: true,
 		},
+		{
+			name: "bracketed IPv6 address",
+			config: &ClientConfig{
+				URL:        "http://[::1]:8200",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: false,
+		},
+		{
+			name: "IPv6 address without port",
+			config: &ClientConfig{
+				URL:        "https://[2001:db8::10]",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: false,
+		},
+		{
+			name: "link-local IPv6 address with zone",
+			config: &ClientConfig{
+				URL:        "http://[fe80::1%25eth0]:8200",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: false,
+		},
+		{
+			name: "unbracketed IPv6 address",
+			config: &ClientConfig{
+				URL:        "http://fd00::1:8200",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: true,
+		},
+		{
+			name: "brackets around a hostname",
+			config: &ClientConfig{
+				URL:        "http://[vault.example.com]:8200",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: true,
+		},
+		{
+			name: "port out of range",
+			config: &ClientConfig{
+				URL:        "http://[::1]:70000",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: true,
+		},
+		{
+			name: "missing host",
+			config: &ClientConfig{
+				URL:        "http://:8200",
+				Timeout:    30 * time.Second,
+				MaxRetries: 3,
+			},
+			expectError: true,
+		},
 		{
 			name: "timeout too small",
 			config: &ClientConfig{
@@ -348,6 +708,33 @@ func (suite *ClientTestSuite) TestValidateClientConfig() {
 	}
 }
 
+// TestIPv6Endpoint tests requests to a server listening on an IPv6 address
+func (suite *ClientTestSuite) TestIPv6Endpoint() {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		suite.T().Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"sealed":true,"t":3,"n":5,"progress":1}`)
+	}))
+	_ = server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	suite.Require().Contains(server.URL, "[::1]")
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	suite.Require().NoError(err)
+	defer func() { _ = client.Close() }()
+
+	assert.Equal(suite.T(), server.URL, client.URL())
+	status, err := client.GetSealStatus(suite.ctx)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), status.Sealed)
+	assert.Equal(suite.T(), 1, status.Progress)
+}
+
 // TestClientThreadSafety tests thread safety of client operations
 func (suite *ClientTestSuite) TestClientThreadSafety() {
 	client, err := NewClient("http://localhost:8200", false, 30*time.Second)
@@ -501,3 +888,26 @@ func (m *MockUnsealStrategy) Unseal(ctx context.Context, client VaultClient, key
 func TestVaultClientTestSuite(t *testing.T) {
 	suite.Run(t, new(ClientTestSuite))
 }
+
+func TestClientMetricsRecordEndpoint(t *testing.T) {
+	server := fakevault.New(t)
+	metrics := NewMockClientMetrics()
+	client, err := (&DefaultClientFactory{Metrics: metrics}).NewClient(server.URL(), false, 5*time.Second)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.IsSealed(t.Context())
+	require.NoError(t, err)
+	_, err = client.Unseal(t.Context(), server.Keys(), server.Threshold())
+	require.NoError(t, err)
+	_, err = client.(*Client).HealthCheck(t.Context())
+	require.NoError(t, err)
+
+	require.NotEmpty(t, metrics.GetSealStatusChecks())
+	assert.Equal(t, server.URL(), metrics.GetSealStatusChecks()[0].Endpoint)
+	require.Len(t, metrics.GetUnsealAttempts(), 1)
+	assert.Equal(t, server.URL(), metrics.GetUnsealAttempts()[0].Endpoint)
+	assert.True(t, metrics.GetUnsealAttempts()[0].Success)
+	require.Len(t, metrics.GetHealthChecks(), 1)
+	assert.Equal(t, server.URL(), metrics.GetHealthChecks()[0].Endpoint)
+}
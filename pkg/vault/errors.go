@@ -99,6 +99,17 @@ func (ue *UnsealError) Unwrap() error {
 	return ue.Err
 }
 
+// ThresholdMismatchError reports a configured threshold above the one Vault
+// requires, which would submit more keys than needed
+type ThresholdMismatchError struct {
+	Configured int
+	Required   int
+}
+
+func (e *ThresholdMismatchError) Error() string {
+	return fmt.Sprintf("configured threshold (%d) exceeds the threshold vault requires (%d)", e.Configured, e.Required)
+}
+
 // SealStatusInfo provides structured seal status information
 type SealStatusInfo struct {
 	Sealed        bool
@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Labels of the exemplars attached to recorded Vault requests
+const (
+	ExemplarTraceID     = "trace_id"
+	ExemplarSpanID      = "span_id"
+	ExemplarReconcileID = "reconcile_id"
+)
+
+// ExemplarMetrics is implemented by ClientMetrics that can attach an exemplar
+// to what they record, linking a latency spike to the trace of the request.
+type ExemplarMetrics interface {
+	ClientMetrics
+	// WithExemplar returns metrics recording with exemplar
+	WithExemplar(exemplar map[string]string) ClientMetrics
+}
+
+// RequestExemplar returns the exemplar of a request made with ctx: the sampled
+// OpenTelemetry trace and span it belongs to, and the reconcile ID set with
+// WithRequestID. Traces that are not sampled are never exported, so they are
+// left out. It returns nil when ctx carries neither.
+func RequestExemplar(ctx context.Context) map[string]string {
+	exemplar := make(map[string]string, 3)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() && span.IsSampled() {
+		exemplar[ExemplarTraceID] = span.TraceID().String()
+		exemplar[ExemplarSpanID] = span.SpanID().String()
+	}
+	if id := RequestID(ctx); id != "" {
+		exemplar[ExemplarReconcileID] = id
+	}
+	if len(exemplar) == 0 {
+		return nil
+	}
+	return exemplar
+}
+
+// metricsFor returns metrics recording the exemplar of ctx, if they support it.
+func metricsFor(ctx context.Context, metrics ClientMetrics) ClientMetrics {
+	exemplarMetrics, ok := metrics.(ExemplarMetrics)
+	if !ok {
+		return metrics
+	}
+	if exemplar := RequestExemplar(ctx); exemplar != nil {
+		return exemplarMetrics.WithExemplar(exemplar)
+	}
+	return metrics
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// exemplarRecorder records the exemplars it is given.
+type exemplarRecorder struct {
+	*MockClientMetrics
+	exemplars []map[string]string
+}
+
+func (r *exemplarRecorder) WithExemplar(exemplar map[string]string) ClientMetrics {
+	r.exemplars = append(r.exemplars, exemplar)
+	return r
+}
+
+func TestRequestExemplar(t *testing.T) {
+	assert.Nil(t, RequestExemplar(context.Background()))
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := WithRequestID(trace.ContextWithSpanContext(context.Background(), span), "reconcile-1")
+	assert.Equal(t, map[string]string{
+		ExemplarTraceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+		ExemplarSpanID:      "00f067aa0ba902b7",
+		ExemplarReconcileID: "reconcile-1",
+	}, RequestExemplar(ctx))
+
+	unsampled := trace.ContextWithSpanContext(context.Background(), span.WithTraceFlags(0))
+	assert.Nil(t, RequestExemplar(unsampled), "traces that are not exported are not linked")
+}
+
+func TestMetricsForAttachesExemplar(t *testing.T) {
+	recorder := &exemplarRecorder{MockClientMetrics: NewMockClientMetrics()}
+	ctx := WithRequestID(context.Background(), "reconcile-1")
+
+	metricsFor(ctx, recorder).RecordHealthCheck("http://vault:8200", true, time.Millisecond)
+	metricsFor(context.Background(), recorder).RecordHealthCheck("http://vault:8200", true, time.Millisecond)
+	assert.Equal(t, []map[string]string{{ExemplarReconcileID: "reconcile-1"}}, recorder.exemplars,
+		"requests without a trace or reconcile carry no exemplar")
+	assert.Len(t, recorder.GetHealthChecks(), 2)
+
+	// Metrics without exemplar support are used as they are
+	plain := NewMockClientMetrics()
+	assert.Same(t, plain, metricsFor(ctx, plain))
+}
+
+func TestClientPropagatesTrace(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"shamir","initialized":true,"sealed":false,"t":1,"n":1,"progress":0}`))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	ctx, reconcile := otel.Tracer("test").Start(t.Context(), "reconcile")
+	_, err = client.IsSealed(ctx)
+	require.NoError(t, err)
+	reconcile.End()
+
+	// The request is a child span of the reconcile, and Vault receives its context
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	request := ended[0]
+	assert.Equal(t, reconcile.SpanContext().SpanID(), request.Parent().SpanID())
+	assert.Equal(t, "00-"+request.SpanContext().TraceID().String()+"-"+request.SpanContext().SpanID().String()+"-01",
+		traceparent)
+}
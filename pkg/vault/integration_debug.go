@@ -386,7 +386,11 @@ func (eitr *EnhancedIntegrationTestRunner) RunScenariosWithDebug(ctx context.Con
 	overallStart := time.Now()
 
 	for i, scenario := range scenarios {
-		if err := eitr.executeScenarioWithDebug(ctx, scenario, i); err != nil {
+		start := time.Now()
+		err := eitr.executeScenarioWithDebug(ctx, scenario, i)
+		eitr.recordScenario(scenario.Name, start, err, "")
+		if err != nil {
+			eitr.skipScenarios(scenarios[i+1:], scenario.Name)
 			return err
 		}
 	}
@@ -249,6 +249,8 @@ type IntegrationTestRunner struct {
 	healthChecker  *HealthChecker
 	circuitBreaker *CircuitBreaker
 	semaphore      chan struct{} // For limiting concurrency
+	scenarios      *TestSuiteResult
+	mu             sync.Mutex
 }
 
 // NewIntegrationTestRunner creates a new integration test runner
@@ -319,49 +321,130 @@ type TestScenario struct {
 	Timeout     time.Duration
 }
 
-// RunScenarios executes multiple test scenarios with fail-fast behavior
+// ScenarioSuiteName names the suite that scenario results are reported under
+const ScenarioSuiteName = "Integration Scenarios"
+
+// RunScenarios executes multiple test scenarios with fail-fast behavior. Every
+// scenario is recorded in ScenarioResults, those not run after a failure as skipped.
 func (itr *IntegrationTestRunner) RunScenarios(ctx context.Context, scenarios []TestScenario) error {
-	for _, scenario := range scenarios {
-		// Check if circuit breaker is open
-		if itr.circuitBreaker.GetState() == CircuitOpen {
-			return fmt.Errorf("circuit breaker is OPEN - failing fast on scenario: %s", scenario.Name)
+	for i, scenario := range scenarios {
+		start := time.Now()
+		err := itr.runScenario(ctx, scenario)
+		itr.recordScenario(scenario.Name, start, err, "")
+		if err != nil {
+			itr.skipScenarios(scenarios[i+1:], scenario.Name)
+			return err
 		}
+	}
 
-		scenarioCtx := ctx
-		if scenario.Timeout > 0 {
-			var cancel context.CancelFunc
-			scenarioCtx, cancel = context.WithTimeout(ctx, scenario.Timeout)
-			defer cancel()
-		}
+	return nil
+}
 
-		// Setup
-		if scenario.Setup != nil {
-			if err := scenario.Setup(scenarioCtx); err != nil {
-				return fmt.Errorf("scenario %s setup failed: %w", scenario.Name, err)
-			}
+// runScenario executes the setup, test and cleanup of a scenario
+func (itr *IntegrationTestRunner) runScenario(ctx context.Context, scenario TestScenario) error {
+	// Check if circuit breaker is open
+	if itr.circuitBreaker.GetState() == CircuitOpen {
+		return fmt.Errorf("circuit breaker is OPEN - failing fast on scenario: %s", scenario.Name)
+	}
+
+	scenarioCtx := ctx
+	if scenario.Timeout > 0 {
+		var cancel context.CancelFunc
+		scenarioCtx, cancel = context.WithTimeout(ctx, scenario.Timeout)
+		defer cancel()
+	}
+
+	// Setup
+	if scenario.Setup != nil {
+		if err := scenario.Setup(scenarioCtx); err != nil {
+			return fmt.Errorf("scenario %s setup failed: %w", scenario.Name, err)
 		}
+	}
 
-		// Execute main test
-		err := itr.RunTest(scenarioCtx, scenario.Name, scenario.Execute)
+	// Execute main test
+	err := itr.RunTest(scenarioCtx, scenario.Name, scenario.Execute)
 
-		// Cleanup (always run, even on failure)
-		if scenario.Cleanup != nil {
-			if cleanupErr := scenario.Cleanup(scenarioCtx); cleanupErr != nil {
-				if err == nil {
-					err = fmt.Errorf("scenario %s cleanup failed: %w", scenario.Name, cleanupErr)
-				}
-				// If both test and cleanup failed, combine errors
+	// Cleanup (always run, even on failure)
+	if scenario.Cleanup != nil {
+		if cleanupErr := scenario.Cleanup(scenarioCtx); cleanupErr != nil {
+			if err == nil {
+				err = fmt.Errorf("scenario %s cleanup failed: %w", scenario.Name, cleanupErr)
 			}
+			// If both test and cleanup failed, combine errors
 		}
+	}
+
+	// Check if error matches expectation
+	if scenario.ExpectError && err == nil {
+		return fmt.Errorf("scenario %s expected error but succeeded", scenario.Name)
+	}
+	if !scenario.ExpectError && err != nil {
+		return fmt.Errorf("scenario %s failed: %w", scenario.Name, err)
+	}
 
-		// Check if error matches expectation
-		if scenario.ExpectError && err == nil {
-			return fmt.Errorf("scenario %s expected error but succeeded", scenario.Name)
+	return nil
+}
+
+// recordScenario records the outcome of a scenario started at start
+func (itr *IntegrationTestRunner) recordScenario(name string, start time.Time, err error, skipReason string) {
+	itr.mu.Lock()
+	defer itr.mu.Unlock()
+
+	if itr.scenarios == nil {
+		itr.scenarios = &TestSuiteResult{
+			Name:      ScenarioSuiteName,
+			StartTime: start,
+			Results:   make(map[string]*TestResult),
 		}
-		if !scenario.ExpectError && err != nil {
-			return fmt.Errorf("scenario %s failed: %w", scenario.Name, err)
+	}
+
+	end := time.Now()
+	itr.scenarios.Results[name] = &TestResult{
+		Name:       name,
+		StartTime:  start,
+		EndTime:    end,
+		Duration:   end.Sub(start),
+		Error:      err,
+		Skipped:    skipReason != "",
+		SkipReason: skipReason,
+	}
+	itr.scenarios.EndTime = end
+	itr.scenarios.Duration = end.Sub(itr.scenarios.StartTime)
+
+	// Recount, as a scenario run again replaces its earlier result
+	itr.scenarios.Passed, itr.scenarios.Failures, itr.scenarios.Skipped = 0, 0, 0
+	for _, result := range itr.scenarios.Results {
+		switch {
+		case result.Error != nil:
+			itr.scenarios.Failures++
+		case result.Skipped:
+			itr.scenarios.Skipped++
+		default:
+			itr.scenarios.Passed++
 		}
 	}
+}
+
+// skipScenarios records scenarios left unrun by the failure of another
+func (itr *IntegrationTestRunner) skipScenarios(scenarios []TestScenario, failed string) {
+	for _, scenario := range scenarios {
+		itr.recordScenario(scenario.Name, time.Now(), nil, fmt.Sprintf("not run after scenario %s failed", failed))
+	}
+}
 
-	return nil
+// ScenarioResults returns the results of the scenarios run so far, or nil if
+// none ran, for reporting with TestReporter.WriteJUnitReport
+func (itr *IntegrationTestRunner) ScenarioResults() *TestSuiteResult {
+	itr.mu.Lock()
+	defer itr.mu.Unlock()
+
+	if itr.scenarios == nil {
+		return nil
+	}
+	result := *itr.scenarios
+	result.Results = make(map[string]*TestResult, len(itr.scenarios.Results))
+	for name, test := range itr.scenarios.Results {
+		result.Results[name] = test
+	}
+	return &result
 }
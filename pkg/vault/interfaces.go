@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/hashicorp/vault/api"
@@ -31,6 +32,102 @@ type VaultClient interface {
 	IsClosed() bool
 }
 
+// SnapshotClient takes and restores Raft storage snapshots
+type SnapshotClient interface {
+	// RaftSnapshot streams a Raft snapshot into w
+	RaftSnapshot(ctx context.Context, w io.Writer) error
+
+	// RaftSnapshotRestore streams a Raft snapshot from r into Vault
+	RaftSnapshotRestore(ctx context.Context, r io.Reader, force bool) error
+
+	// Close closes the client and cleans up resources
+	Close() error
+}
+
+// RaftClient reads the state of a Raft storage cluster
+type RaftClient interface {
+	// RaftAutopilotState returns the autopilot view of the Raft peers
+	RaftAutopilotState(ctx context.Context) (*api.AutopilotState, error)
+
+	// Close closes the client and cleans up resources
+	Close() error
+}
+
+// InstanceInfoClient describes a Vault server beyond its seal state
+type InstanceInfoClient interface {
+	// InstanceInfo returns the version, cluster, role and seal type of the server
+	InstanceInfo(ctx context.Context) (*InstanceInfo, error)
+}
+
+// InstanceInfo describes a Vault server
+type InstanceInfo struct {
+	Version string
+	// Distribution is DistributionVault or DistributionOpenBao, detected from the version
+	Distribution string
+	ClusterName  string
+	ClusterID    string
+	Initialized  bool
+	Sealed       bool
+	SealType     string
+	// Threshold is the number of key shares required to unseal, 0 before initialization
+	Threshold int
+	// RecoverySeal is set when the seal uses recovery keys, i.e. auto-unseal
+	RecoverySeal     bool
+	RecoverySealType string
+	// Migration is set while a seal migration is in progress
+	Migration bool
+	// HAEnabled and Role are only known while the server is unsealed
+	HAEnabled *bool
+	Role      string
+}
+
+// Roles reported in InstanceInfo
+const (
+	RoleActive             = "active"
+	RoleStandby            = "standby"
+	RolePerformanceStandby = "performance-standby"
+)
+
+// UnsealProgressClient unseals while reporting the progress of each key submission
+type UnsealProgressClient interface {
+	// UnsealWithProgress behaves like Unseal and calls report after a stale
+	// unseal attempt is reset and after every key is accepted. report may be nil.
+	UnsealWithProgress(
+		ctx context.Context, keys []string, threshold int, report func(UnsealProgress),
+	) (*api.SealStatusResponse, error)
+}
+
+// SealStateClient reads the seal and initialization state in one request
+type SealStateClient interface {
+	// SealState behaves like IsSealed and also reports whether Vault is initialized
+	SealState(ctx context.Context) (SealState, error)
+}
+
+// SealState is the part of sys/seal-status the polling path reads
+type SealState struct {
+	Sealed      bool `json:"sealed"`
+	Initialized bool `json:"initialized"`
+}
+
+// UnsealProgress is the state of a multi-key unseal as reported by Vault
+type UnsealProgress struct {
+	// KeysProvided is the number of key shares Vault holds for the current attempt
+	KeysProvided int
+	// Threshold is the number of key shares Vault requires
+	Threshold int
+	// Nonce identifies the current unseal attempt
+	Nonce string
+	// NonceReset is set when a partially completed attempt was discarded first
+	NonceReset bool
+}
+
+// Distributions of the server behind an endpoint. OpenBao is an API-compatible
+// fork of Vault; the operator talks to both the same way.
+const (
+	DistributionVault   = "vault"
+	DistributionOpenBao = "openbao"
+)
+
 // ClientFactory creates vault clients
 type ClientFactory interface {
 	NewClient(endpoint string, tlsSkipVerify bool, timeout time.Duration) (VaultClient, error)
@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Encodings of unseal keys accepted by NormalizeKey
+const (
+	KeyEncodingBase64 = "base64"
+	KeyEncodingHex    = "hex"
+)
+
+// NormalizeKey returns key base64 encoded, the form the key validator checks.
+// Hex keys, as printed first by vault operator init, are re-encoded. Without an
+// encoding, keys that decode as hex are treated as hex like Vault does; anything
+// else is passed through for the validator to reject.
+func NormalizeKey(key, encoding string) (string, error) {
+	switch encoding {
+	case KeyEncodingBase64:
+		return key, nil
+	case KeyEncodingHex:
+		decoded, err := hex.DecodeString(key)
+		if err != nil {
+			return "", NewValidationError("key", key, "invalid hex encoding")
+		}
+		if len(decoded) == 0 {
+			return "", NewValidationError("key", key, "decoded key cannot be empty")
+		}
+		return base64.StdEncoding.EncodeToString(decoded), nil
+	case "":
+		if decoded, err := hex.DecodeString(key); err == nil && len(decoded) > 0 {
+			return base64.StdEncoding.EncodeToString(decoded), nil
+		}
+		return key, nil
+	default:
+		return "", NewValidationError("keyEncoding", encoding, "must be base64 or hex")
+	}
+}
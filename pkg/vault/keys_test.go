@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	// A Shamir share is one byte longer than the 32 byte key, so its hex form
+	// is not valid base64
+	share := []byte("0123456789abcdefghijklmnopqrstuvw")
+	hexKey := hex.EncodeToString(share)
+	base64Key := base64.StdEncoding.EncodeToString(share)
+
+	tests := []struct {
+		name     string
+		key      string
+		encoding string
+		expected string
+		wantErr  bool
+	}{
+		{name: "detected hex", key: hexKey, expected: base64Key},
+		{name: "detected base64", key: base64Key, expected: base64Key},
+		{name: "explicit hex", key: hexKey, encoding: KeyEncodingHex, expected: base64Key},
+		{name: "explicit base64 is not decoded", key: "deadbeef", encoding: KeyEncodingBase64, expected: "deadbeef"},
+		{name: "invalid hex", key: base64Key, encoding: KeyEncodingHex, wantErr: true},
+		{name: "empty hex", key: "", encoding: KeyEncodingHex, wantErr: true},
+		{name: "unknown encoding", key: hexKey, encoding: "base32", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NormalizeKey(tt.key, tt.encoding)
+			if tt.wantErr {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				if tt.key != "" {
+					assert.NotContains(t, err.Error(), tt.key, "keys are redacted")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, key)
+			assert.NoError(t, NewDefaultKeyValidator().ValidateBase64Key(key))
+		})
+	}
+}
@@ -297,11 +297,14 @@ func (m *MockVaultClient) Reset() {
 	m.lastError = nil
 }
 
-// MockClientMetrics implements ClientMetrics for testing
+// MockClientMetrics implements ClientMetrics for testing. Each kind of metric
+// has its own lock so concurrent recorders of different kinds do not contend.
 type MockClientMetrics struct {
-	mu               sync.RWMutex
+	unsealMu         sync.RWMutex
 	unsealAttempts   []UnsealAttemptMetric
+	healthMu         sync.RWMutex
 	healthChecks     []HealthCheckMetric
+	sealStatusMu     sync.RWMutex
 	sealStatusChecks []SealStatusCheckMetric
 }
 
@@ -330,8 +333,8 @@ func NewMockClientMetrics() *MockClientMetrics {
 
 // RecordUnsealAttempt implements ClientMetrics
 func (m *MockClientMetrics) RecordUnsealAttempt(endpoint string, success bool, duration time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.unsealMu.Lock()
+	defer m.unsealMu.Unlock()
 	m.unsealAttempts = append(m.unsealAttempts, UnsealAttemptMetric{
 		Endpoint: endpoint,
 		Success:  success,
@@ -341,8 +344,8 @@ func (m *MockClientMetrics) RecordUnsealAttempt(endpoint string, success bool, d
 
 // RecordHealthCheck implements ClientMetrics
 func (m *MockClientMetrics) RecordHealthCheck(endpoint string, success bool, duration time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
 	m.healthChecks = append(m.healthChecks, HealthCheckMetric{
 		Endpoint: endpoint,
 		Success:  success,
@@ -352,8 +355,8 @@ func (m *MockClientMetrics) RecordHealthCheck(endpoint string, success bool, dur
 
 // RecordSealStatusCheck implements ClientMetrics
 func (m *MockClientMetrics) RecordSealStatusCheck(endpoint string, success bool, duration time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.sealStatusMu.Lock()
+	defer m.sealStatusMu.Unlock()
 	m.sealStatusChecks = append(m.sealStatusChecks, SealStatusCheckMetric{
 		Endpoint: endpoint,
 		Success:  success,
@@ -363,35 +366,41 @@ func (m *MockClientMetrics) RecordSealStatusCheck(endpoint string, success bool,
 
 // Test helper methods
 func (m *MockClientMetrics) GetUnsealAttempts() []UnsealAttemptMetric {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.unsealMu.RLock()
+	defer m.unsealMu.RUnlock()
 	attempts := make([]UnsealAttemptMetric, len(m.unsealAttempts))
 	copy(attempts, m.unsealAttempts)
 	return attempts
 }
 
 func (m *MockClientMetrics) GetHealthChecks() []HealthCheckMetric {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.healthMu.RLock()
+	defer m.healthMu.RUnlock()
 	checks := make([]HealthCheckMetric, len(m.healthChecks))
 	copy(checks, m.healthChecks)
 	return checks
 }
 
 func (m *MockClientMetrics) GetSealStatusChecks() []SealStatusCheckMetric {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.sealStatusMu.RLock()
+	defer m.sealStatusMu.RUnlock()
 	checks := make([]SealStatusCheckMetric, len(m.sealStatusChecks))
 	copy(checks, m.sealStatusChecks)
 	return checks
 }
 
 func (m *MockClientMetrics) Reset() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.unsealMu.Lock()
 	m.unsealAttempts = nil
+	m.unsealMu.Unlock()
+
+	m.healthMu.Lock()
 	m.healthChecks = nil
+	m.healthMu.Unlock()
+
+	m.sealStatusMu.Lock()
 	m.sealStatusChecks = nil
+	m.sealStatusMu.Unlock()
 }
 
 // MockClientFactory implements ClientFactory for testing
@@ -0,0 +1,41 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader carries the ID that correlates a Vault request with the
+// operator logs and events of the reconcile that sent it.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose Vault requests carry id in their
+// X-Request-ID header.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID attached to ctx with WithRequestID, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDTransport sets the X-Request-ID header of requests whose context
+// carries a request ID, replacing the per-client default.
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := RequestID(req.Context()); id != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return t.base.RoundTrip(req)
+}
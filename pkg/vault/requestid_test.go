@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	var mu sync.Mutex
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"shamir","initialized":true,"sealed":false,"t":1,"n":1,"progress":0}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, 5*time.Second)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.IsSealed(WithRequestID(t.Context(), "reconcile-1"))
+	require.NoError(t, err)
+	_, err = client.IsSealed(t.Context())
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, headers, 2)
+	assert.Equal(t, "reconcile-1", headers[0])
+	assert.NotEqual(t, "reconcile-1", headers[1], "requests without an ID keep the client default")
+}
+
+func TestRequestIDContext(t *testing.T) {
+	assert.Empty(t, RequestID(t.Context()))
+	assert.Equal(t, "id", RequestID(WithRequestID(t.Context(), "id")))
+	assert.Equal(t, t.Context(), WithRequestID(t.Context(), ""), "an empty ID leaves the context unchanged")
+}
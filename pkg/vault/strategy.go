@@ -44,31 +44,68 @@ func (s *DefaultUnsealStrategy) Unseal(
 
 	if !status.Sealed {
 		if s.metrics != nil {
-			s.metrics.RecordUnsealAttempt("unknown", true, time.Since(start))
+			metricsFor(ctx, s.metrics).RecordUnsealAttempt(clientEndpoint(client), true, time.Since(start))
 		}
 		return status, nil
 	}
 
-	// Submit keys up to threshold
+	// Key shares left by an interrupted attempt would be combined with ours
+	// under the old nonce, so start over
+	progress := UnsealProgress{KeysProvided: status.Progress, Threshold: status.T, Nonce: status.Nonce}
+	if status.Progress > 0 {
+		if resetter, ok := client.(unsealResetter); ok {
+			status, err = resetter.ResetUnseal(ctx)
+			if err != nil {
+				return nil, NewVaultError("reset-unseal", "unknown", err, true)
+			}
+			progress = UnsealProgress{
+				KeysProvided: status.Progress, Threshold: status.T, Nonce: status.Nonce, NonceReset: true,
+			}
+			reportUnsealProgress(ctx, progress)
+		}
+	}
+
+	// Never submit more keys than Vault still requires, even when a larger
+	// threshold is configured, to expose as few keys as possible
+	if status.T > 0 && threshold > status.T {
+		if strictThreshold(ctx) {
+			return nil, &ThresholdMismatchError{Configured: threshold, Required: status.T}
+		}
+		threshold = status.T
+	}
+	needed := threshold
+	if status.T > 0 {
+		needed = max(min(threshold, status.T-status.Progress), 1)
+	}
 	keysToSubmit := keys
-	if len(keys) > threshold {
-		keysToSubmit = keys[:threshold]
+	if len(keys) > needed {
+		keysToSubmit = keys[:needed]
 	}
 
-	lastStatus, err := s.submitKeys(ctx, client, keysToSubmit)
+	lastStatus, err := s.submitKeys(ctx, client, keysToSubmit, progress.NonceReset)
 
 	if s.metrics != nil {
-		s.metrics.RecordUnsealAttempt("unknown", err == nil && !lastStatus.Sealed, time.Since(start))
+		metricsFor(ctx, s.metrics).RecordUnsealAttempt(clientEndpoint(client), err == nil && !lastStatus.Sealed, time.Since(start))
 	}
 
 	return lastStatus, err
 }
 
+// clientEndpoint returns the URL of client for metrics, or "unknown" for
+// clients unable to report it.
+func clientEndpoint(client VaultClient) string {
+	if urlClient, ok := client.(interface{ URL() string }); ok {
+		return urlClient.URL()
+	}
+	return "unknown"
+}
+
 // submitKeys submits unseal keys one by one
 func (s *DefaultUnsealStrategy) submitKeys(
 	ctx context.Context,
 	client VaultClient,
 	keys []string,
+	nonceReset bool,
 ) (*api.SealStatusResponse, error) {
 	var lastStatus *api.SealStatusResponse
 
@@ -89,9 +126,10 @@ func (s *DefaultUnsealStrategy) submitKeys(
 		}
 
 		lastStatus = status
+		reportUnsealProgress(ctx, submittedProgress(status, i+1, nonceReset))
 
-		// Stop if unsealed
-		if !status.Sealed {
+		// Stop once unsealed or once Vault holds as many keys as it requires
+		if !status.Sealed || (status.T > 0 && status.Progress >= status.T) {
 			break
 		}
 
@@ -102,6 +140,58 @@ func (s *DefaultUnsealStrategy) submitKeys(
 	return lastStatus, nil
 }
 
+// submittedProgress returns the progress after submitted keys were accepted.
+// Vault clears the progress once unsealed, so the submitted count is used then.
+func submittedProgress(status *api.SealStatusResponse, submitted int, nonceReset bool) UnsealProgress {
+	progress := UnsealProgress{
+		KeysProvided: status.Progress,
+		Threshold:    status.T,
+		Nonce:        status.Nonce,
+		NonceReset:   nonceReset,
+	}
+	if !status.Sealed {
+		progress.KeysProvided = submitted
+	}
+	return progress
+}
+
+// unsealResetter discards the key shares of a partially completed unseal
+type unsealResetter interface {
+	ResetUnseal(ctx context.Context) (*api.SealStatusResponse, error)
+}
+
+type unsealProgressKey struct{}
+
+type strictThresholdKey struct{}
+
+// WithStrictThreshold returns a context in which unsealing fails with a
+// ThresholdMismatchError when the threshold passed to Unseal exceeds the one
+// Vault reports, instead of submitting only the keys Vault requires.
+func WithStrictThreshold(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictThresholdKey{}, true)
+}
+
+// strictThreshold reports whether ctx was returned by WithStrictThreshold
+func strictThreshold(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictThresholdKey{}).(bool)
+	return strict
+}
+
+// withUnsealProgress returns a context whose unseal progress is passed to report
+func withUnsealProgress(ctx context.Context, report func(UnsealProgress)) context.Context {
+	if report == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, unsealProgressKey{}, report)
+}
+
+// reportUnsealProgress passes progress to the reporter of ctx, if any
+func reportUnsealProgress(ctx context.Context, progress UnsealProgress) {
+	if report, ok := ctx.Value(unsealProgressKey{}).(func(UnsealProgress)); ok {
+		report(progress)
+	}
+}
+
 // submitSingleKey submits a single unseal key
 func (s *DefaultUnsealStrategy) submitSingleKey(
 	ctx context.Context,
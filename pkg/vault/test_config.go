@@ -3,6 +3,7 @@ package vault
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -60,6 +61,8 @@ type TestConfig struct {
 	ReportVerbose         bool
 	ReportMetrics         bool
 	ReportMemorySnapshots bool
+	ReportJUnit           bool
+	ReportHTML            bool
 }
 
 // DefaultTestConfig returns default test configuration
@@ -111,6 +114,8 @@ func DefaultTestConfig() *TestConfig {
 		ReportVerbose:         true,
 		ReportMetrics:         true,
 		ReportMemorySnapshots: true,
+		ReportJUnit:           true,
+		ReportHTML:            true,
 	}
 }
 
@@ -267,6 +272,12 @@ func (tc *TestConfig) loadReportingConfig() {
 	if val := os.Getenv("REPORT_MEMORY_SNAPSHOTS"); val != "" {
 		tc.ReportMemorySnapshots = val == trueString
 	}
+	if val := os.Getenv("REPORT_JUNIT"); val != "" {
+		tc.ReportJUnit = val == trueString
+	}
+	if val := os.Getenv("REPORT_HTML"); val != "" {
+		tc.ReportHTML = val == trueString
+	}
 }
 
 // GetLoadTestConfig returns configuration for load testing
@@ -561,6 +572,21 @@ func (tsr *TestSuiteResult) GetFailedTests() []string {
 	return failed
 }
 
+// OrderedResults returns the test results in the order the tests started
+func (tsr *TestSuiteResult) OrderedResults() []*TestResult {
+	results := make([]*TestResult, 0, len(tsr.Results))
+	for _, result := range tsr.Results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].StartTime.Equal(results[j].StartTime) {
+			return results[i].StartTime.Before(results[j].StartTime)
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
 // GetSkippedTests returns a list of skipped test names
 func (tsr *TestSuiteResult) GetSkippedTests() []string {
 	var skipped []string
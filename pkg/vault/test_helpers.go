@@ -18,17 +18,25 @@ const (
 	operationIsSealed = "IsSealed"
 )
 
-// TestMetrics provides detailed testing metrics collection
+// TestMetrics provides detailed testing metrics collection. Each operation is
+// recorded under its own lock, so load test workers running different operations
+// do not serialize on the collector.
 type TestMetrics struct {
-	mu                   sync.RWMutex
-	operationCounts      map[string]int64
-	operationDurations   map[string][]time.Duration
-	errorCounts          map[string]int64
+	operations           sync.Map // operation name -> *operationMetrics
+	mu                   sync.Mutex
 	memorySnapshots      []MemorySnapshot
 	concurrentOperations int64
 	testStartTime        time.Time
 }
 
+// operationMetrics holds the recorded calls of a single operation
+type operationMetrics struct {
+	mu        sync.Mutex
+	count     int64
+	errors    int64
+	durations []time.Duration
+}
+
 // MemorySnapshot captures memory usage at a point in time
 type MemorySnapshot struct {
 	Timestamp  time.Time
@@ -42,23 +50,26 @@ type MemorySnapshot struct {
 // NewTestMetrics creates a new TestMetrics instance
 func NewTestMetrics() *TestMetrics {
 	return &TestMetrics{
-		operationCounts:    make(map[string]int64),
-		operationDurations: make(map[string][]time.Duration),
-		errorCounts:        make(map[string]int64),
-		testStartTime:      time.Now(),
+		testStartTime: time.Now(),
 	}
 }
 
 // RecordOperation records an operation with its duration
 func (tm *TestMetrics) RecordOperation(operation string, duration time.Duration, err error) {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+	value, ok := tm.operations.Load(operation)
+	if !ok {
+		value, _ = tm.operations.LoadOrStore(operation, &operationMetrics{})
+	}
+	op := value.(*operationMetrics)
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
 
-	tm.operationCounts[operation]++
-	tm.operationDurations[operation] = append(tm.operationDurations[operation], duration)
+	op.count++
+	op.durations = append(op.durations, duration)
 
 	if err != nil {
-		tm.errorCounts[operation]++
+		op.errors++
 	}
 }
 
@@ -93,9 +104,6 @@ func (tm *TestMetrics) TakeMemorySnapshot() {
 
 // GetSummary returns a summary of collected metrics
 func (tm *TestMetrics) GetSummary() TestSummary {
-	tm.mu.RLock()
-	defer tm.mu.RUnlock()
-
 	summary := TestSummary{
 		TestDuration:         time.Since(tm.testStartTime),
 		TotalOperations:      0,
@@ -107,9 +115,14 @@ func (tm *TestMetrics) GetSummary() TestSummary {
 	}
 
 	// Calculate operation stats
-	for operation, count := range tm.operationCounts {
-		durations := tm.operationDurations[operation]
-		errors := tm.errorCounts[operation]
+	tm.operations.Range(func(key, value any) bool {
+		operation := key.(string)
+		op := value.(*operationMetrics)
+
+		op.mu.Lock()
+		count, errors := op.count, op.errors
+		durations := append([]time.Duration(nil), op.durations...)
+		op.mu.Unlock()
 
 		summary.TotalOperations += count
 		summary.TotalErrors += errors
@@ -137,9 +150,12 @@ func (tm *TestMetrics) GetSummary() TestSummary {
 		}
 
 		summary.OperationBreakdown[operation] = stats
-	}
+		return true
+	})
 
 	// Calculate memory stats
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	if len(tm.memorySnapshots) > 1 {
 		first := tm.memorySnapshots[0]
 		last := tm.memorySnapshots[len(tm.memorySnapshots)-1]
@@ -0,0 +1,191 @@
+package vault
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// htmlReportTemplate renders a ComprehensiveReport as a self-contained page;
+// timing charts are plain CSS bars so the report opens offline
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test Report {{.Generated}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { text-align: left; padding: 0.25em 0.75em; border-bottom: 1px solid #ddd; }
+.chart td.bar { width: 40em; }
+.bar div { height: 1em; background: #4a90d9; min-width: 1px; }
+.failed .bar div, .failed .status { background: #d9534f; color: #fff; }
+.skipped .bar div { background: #aaa; }
+.passed .status { color: #2e7d32; }
+pre { background: #f6f6f6; padding: 0.75em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Test Report</h1>
+<p>Generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Suites</th><td>{{.Summary.TotalSuites}}</td></tr>
+<tr><th>Tests</th><td>{{.Summary.TotalTests}}</td></tr>
+<tr><th>Passed</th><td>{{.Summary.TotalPassed}} ({{printf "%.1f" .Summary.OverallPassRate}}%)</td></tr>
+<tr><th>Failed</th><td>{{.Summary.TotalFailed}}</td></tr>
+<tr><th>Skipped</th><td>{{.Summary.TotalSkipped}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+</table>
+
+<h2>Suite Timing</h2>
+<table class="chart">
+{{- range .Suites}}
+<tr class="{{.Status}}"><td><a href="#{{.Anchor}}">{{.Name}}</a></td><td class="bar"><div style="width: {{printf "%.1f" .Width}}%"></div></td><td>{{.Duration}}</td></tr>
+{{- end}}
+</table>
+
+{{- range .Suites}}
+<h2 id="{{.Anchor}}">{{.Name}}</h2>
+<p>{{.Passed}} passed, {{.Failed}} failed, {{.Skipped}} skipped in {{.Duration}}</p>
+<table class="chart">
+<tr><th>Test</th><th>Category</th><th>Timing</th><th>Duration</th><th>Status</th></tr>
+{{- range .Tests}}
+<tr class="{{.Status}}"><td>{{.Name}}</td><td>{{.Category}}</td><td class="bar"><div style="width: {{printf "%.1f" .Width}}%"></div></td><td>{{.Duration}}</td><td class="status">{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- if .Failures}}
+<h3>Failures</h3>
+{{- range .Failures}}
+<h4>{{.Name}}</h4>
+<pre>{{.Log}}</pre>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlReport is the view of a ComprehensiveReport rendered by htmlReportTemplate
+type htmlReport struct {
+	Generated string
+	Duration  string
+	Summary   ReportSummary
+	Suites    []htmlSuite
+}
+
+// htmlSuite is the view of a suite result
+type htmlSuite struct {
+	Name     string
+	Anchor   string
+	Status   string
+	Duration string
+	Width    float64
+	Passed   int
+	Failed   int
+	Skipped  int
+	Tests    []htmlTest
+	Failures []htmlFailure
+}
+
+// htmlTest is the view of a test result, with its bar width relative to the
+// slowest test of the suite
+type htmlTest struct {
+	Name     string
+	Category string
+	Status   string
+	Duration string
+	Width    float64
+}
+
+// htmlFailure is the failure log of a test or suite phase
+type htmlFailure struct {
+	Name string
+	Log  string
+}
+
+// WriteHTMLReport writes report as a standalone HTML page with per-suite timing
+// charts and failure logs
+func (tr *TestReporter) WriteHTMLReport(w io.Writer, report *ComprehensiveReport) error {
+	if err := htmlReportTemplate.Execute(w, newHTMLReport(report)); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return nil
+}
+
+// newHTMLReport builds the view of report, with suites and tests in the order
+// they started
+func newHTMLReport(report *ComprehensiveReport) htmlReport {
+	view := htmlReport{
+		Generated: report.Timestamp.Format(time.RFC3339),
+		Duration:  htmlDuration(report.Summary.TotalDuration),
+		Summary:   report.Summary,
+	}
+
+	results := make([]*TestSuiteResult, 0, len(report.Suites))
+	for _, result := range report.Suites {
+		results = append(results, result)
+	}
+	for i, result := range orderedSuites(results) {
+		suite := htmlSuite{
+			Name:     result.Name,
+			Anchor:   fmt.Sprintf("suite-%d", i),
+			Status:   "passed",
+			Duration: htmlDuration(result.Duration),
+			Width:    htmlBarWidth(result.Duration, report.Summary.LongestSuite),
+			Passed:   result.Passed,
+			Failed:   result.Failures,
+			Skipped:  result.Skipped,
+		}
+		if result.SetupError != nil {
+			suite.Failures = append(suite.Failures, htmlFailure{Name: "setup", Log: result.SetupError.Error()})
+		}
+
+		tests := result.OrderedResults()
+		var slowest time.Duration
+		for _, test := range tests {
+			slowest = max(slowest, test.Duration)
+		}
+		for _, test := range tests {
+			view := htmlTest{
+				Name:     test.Name,
+				Category: test.Category,
+				Status:   "passed",
+				Duration: htmlDuration(test.Duration),
+				Width:    htmlBarWidth(test.Duration, slowest),
+			}
+			switch {
+			case test.Error != nil:
+				view.Status = "failed"
+				suite.Failures = append(suite.Failures, htmlFailure{Name: test.Name, Log: test.Error.Error()})
+			case test.Skipped:
+				view.Status = "skipped"
+			}
+			suite.Tests = append(suite.Tests, view)
+		}
+
+		if result.TeardownError != nil {
+			suite.Failures = append(suite.Failures, htmlFailure{Name: "teardown", Log: result.TeardownError.Error()})
+		}
+		if len(suite.Failures) > 0 {
+			suite.Status = "failed"
+		}
+		view.Suites = append(view.Suites, suite)
+	}
+	return view
+}
+
+// htmlBarWidth returns d as a percentage of longest
+func htmlBarWidth(d, longest time.Duration) float64 {
+	if longest <= 0 {
+		return 0
+	}
+	return float64(d) / float64(longest) * 100
+}
+
+// htmlDuration rounds a duration for display
+func htmlDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
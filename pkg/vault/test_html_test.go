@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReport(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := &ComprehensiveReport{
+		Timestamp: start,
+		Suites: map[string]*TestSuiteResult{
+			"Security Testing": {
+				Name: "Security Testing", StartTime: start.Add(time.Second), Duration: time.Second,
+				Results: map[string]*TestResult{
+					"InputSanitization": {
+						Name: "InputSanitization", Category: "Security", StartTime: start.Add(time.Second),
+						Duration: time.Second, Error: errors.New("leaked <script>alert(1)</script>"),
+					},
+				},
+				Failures: 1,
+			},
+			"Load Testing": {
+				Name: "Load Testing", StartTime: start, Duration: 2 * time.Second,
+				Results: map[string]*TestResult{
+					"Fast": {Name: "Fast", StartTime: start, Duration: 500 * time.Millisecond},
+					"Slow": {Name: "Slow", StartTime: start.Add(time.Millisecond), Duration: 1500 * time.Millisecond},
+				},
+				Passed: 2,
+			},
+		},
+		Summary: ReportSummary{TotalSuites: 2, TotalTests: 3, TotalPassed: 2, TotalFailed: 1, LongestSuite: 2 * time.Second},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewTestReporter(DefaultTestConfig()).WriteHTMLReport(&buf, report))
+	page := buf.String()
+
+	// Suites are listed in the order they ran, charted against the longest
+	assert.Less(t, strings.Index(page, `<h2 id="suite-0">Load Testing</h2>`), strings.Index(page, `<h2 id="suite-1">Security Testing</h2>`))
+	assert.Contains(t, page, `<a href="#suite-1">Security Testing</a></td><td class="bar"><div style="width: 50.0%">`)
+	assert.Contains(t, page, `<td>Fast</td><td></td><td class="bar"><div style="width: 33.3%">`)
+	assert.Contains(t, page, `<td>Slow</td><td></td><td class="bar"><div style="width: 100.0%">`)
+
+	// Failure logs are escaped
+	assert.Contains(t, page, "<h3>Failures</h3>")
+	assert.Contains(t, page, "leaked &lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, page, "<script>")
+}
+
+func TestGenerateReportWritesAllFormats(t *testing.T) {
+	t.Chdir(t.TempDir())
+	runner := NewTestRunner(DefaultTestConfig())
+	runner.results["Unit"] = &TestSuiteResult{
+		Name: "Unit", StartTime: time.Now(), Duration: time.Second, Passed: 1,
+		Results: map[string]*TestResult{"Works": {Name: "Works", Duration: time.Second}},
+	}
+	require.NoError(t, runner.reporter.GenerateReport(runner))
+
+	for _, name := range []string{"test_report.json", "test_report.txt", "test_report.xml", "test_report.html"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err, name)
+		assert.Positive(t, info.Size(), name)
+	}
+}
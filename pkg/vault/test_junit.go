@@ -0,0 +1,158 @@
+package vault
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite reports a test suite, or the scenarios of an integration run
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase reports a single test or scenario
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Error     *JUnitMessage `xml:"error,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+}
+
+// JUnitMessage is the failure, error or skip reason of a test case
+type JUnitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReport converts suite results into a JUnit report, with suites in the
+// order they started
+func NewJUnitReport(results ...*TestSuiteResult) *JUnitTestSuites {
+	report := &JUnitTestSuites{}
+	var total time.Duration
+	for _, result := range orderedSuites(results) {
+		suite := NewJUnitTestSuite(result)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		total += result.Duration
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junitSeconds(total)
+	return report
+}
+
+// NewJUnitTestSuite converts the result of a suite. Tests are listed in the order
+// they started; setup and teardown errors are reported as erroring test cases.
+func NewJUnitTestSuite(result *TestSuiteResult) JUnitTestSuite {
+	suite := JUnitTestSuite{
+		Name: result.Name,
+		Time: junitSeconds(result.Duration),
+	}
+	if !result.StartTime.IsZero() {
+		suite.Timestamp = result.StartTime.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	if result.SetupError != nil {
+		suite.TestCases = append(suite.TestCases, junitErrorCase(result.Name, "setup", result.SetupError))
+	}
+	for _, test := range result.OrderedResults() {
+		testCase := JUnitTestCase{
+			Name:      test.Name,
+			Classname: junitClassname(result.Name, test.Category),
+			Time:      junitSeconds(test.Duration),
+		}
+		switch {
+		case test.Error != nil:
+			testCase.Failure = &JUnitMessage{Message: test.Error.Error(), Text: test.Error.Error()}
+		case test.Skipped:
+			testCase.Skipped = &JUnitMessage{Message: test.SkipReason}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	if result.TeardownError != nil {
+		suite.TestCases = append(suite.TestCases, junitErrorCase(result.Name, "teardown", result.TeardownError))
+	}
+
+	for _, testCase := range suite.TestCases {
+		suite.Tests++
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// Write writes the report as an XML document
+func (r *JUnitTestSuites) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// orderedSuites returns suite results in the order the suites started
+func orderedSuites(results []*TestSuiteResult) []*TestSuiteResult {
+	ordered := append([]*TestSuiteResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].StartTime.Before(ordered[j].StartTime)
+	})
+	return ordered
+}
+
+// junitErrorCase reports a failed suite phase
+func junitErrorCase(suite, phase string, err error) JUnitTestCase {
+	return JUnitTestCase{
+		Name:      phase,
+		Classname: suite,
+		Time:      junitSeconds(0),
+		Error:     &JUnitMessage{Message: err.Error(), Text: err.Error()},
+	}
+}
+
+// junitClassname groups the tests of a suite by category
+func junitClassname(suite, category string) string {
+	if category == "" {
+		return suite
+	}
+	return suite + "." + category
+}
+
+// junitSeconds formats a duration the way JUnit consumers expect
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
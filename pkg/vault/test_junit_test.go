@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJUnitReport(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	suite := &TestSuiteResult{
+		Name:      "Load Testing",
+		StartTime: start,
+		Duration:  1500 * time.Millisecond,
+		Results: map[string]*TestResult{
+			"Second": {
+				Name: "Second", Category: "Performance", StartTime: start.Add(time.Second),
+				Duration: 250 * time.Millisecond, Error: errors.New("error rate <too> high"),
+			},
+			"First": {Name: "First", Category: "Performance", StartTime: start, Duration: time.Second},
+			"Third": {Name: "Third", StartTime: start.Add(2 * time.Second), Skipped: true, SkipReason: "no vault"},
+		},
+		TeardownError: errors.New("containers left running"),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewTestReporter(DefaultTestConfig()).WriteJUnitReport(&buf, suite))
+	assert.Contains(t, buf.String(), xml.Header)
+	assert.Contains(t, buf.String(), "error rate &lt;too&gt; high")
+
+	var report JUnitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, "1.500", report.Time)
+
+	require.Len(t, report.Suites, 1)
+	got := report.Suites[0]
+	assert.Equal(t, "Load Testing", got.Name)
+	assert.Equal(t, "2025-01-02T03:04:05", got.Timestamp)
+	require.Len(t, got.TestCases, 4)
+	assert.Equal(t, "First", got.TestCases[0].Name)
+	assert.Equal(t, "Load Testing.Performance", got.TestCases[0].Classname)
+	assert.Equal(t, "1.000", got.TestCases[0].Time)
+	assert.Nil(t, got.TestCases[0].Failure)
+	assert.Equal(t, "Second", got.TestCases[1].Name)
+	require.NotNil(t, got.TestCases[1].Failure)
+	assert.Equal(t, "error rate <too> high", got.TestCases[1].Failure.Message)
+	assert.Equal(t, "Third", got.TestCases[2].Name)
+	assert.Equal(t, "Load Testing", got.TestCases[2].Classname)
+	require.NotNil(t, got.TestCases[2].Skipped)
+	assert.Equal(t, "no vault", got.TestCases[2].Skipped.Message)
+	assert.Equal(t, "teardown", got.TestCases[3].Name)
+	require.NotNil(t, got.TestCases[3].Error)
+}
+
+func TestScenarioResults(t *testing.T) {
+	config := DefaultIntegrationConfig()
+	config.HealthCheckInterval = 10 * time.Millisecond
+	runner := NewIntegrationTestRunner(config)
+	runner.RegisterClient("vault", NewMockVaultClient())
+	assert.Nil(t, runner.ScenarioResults())
+
+	succeed := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("vault stayed sealed") }
+	err := runner.RunScenarios(t.Context(), []TestScenario{
+		{Name: "unseal", Execute: succeed},
+		{Name: "rejects bad keys", Execute: fail, ExpectError: true},
+		{Name: "reseal", Execute: fail},
+		{Name: "rotate keys", Execute: succeed},
+	})
+	require.Error(t, err)
+
+	results := runner.ScenarioResults()
+	require.NotNil(t, results)
+	assert.Equal(t, ScenarioSuiteName, results.Name)
+	assert.Equal(t, 2, results.Passed)
+	assert.Equal(t, 1, results.Failures)
+	assert.Equal(t, 1, results.Skipped)
+	assert.Equal(t, "not run after scenario reseal failed", results.Results["rotate keys"].SkipReason)
+
+	suite := NewJUnitTestSuite(results)
+	require.Len(t, suite.TestCases, 4)
+	assert.Equal(t, "unseal", suite.TestCases[0].Name)
+	assert.Equal(t, "reseal", suite.TestCases[2].Name)
+	require.NotNil(t, suite.TestCases[2].Failure)
+	assert.Contains(t, suite.TestCases[2].Failure.Message, "vault stayed sealed")
+}
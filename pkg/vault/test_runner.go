@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime/pprof"
@@ -289,6 +290,45 @@ func (tr *TestReporter) GenerateReport(runner *TestRunner) error {
 
 	tr.writeTextReport(textFile, &report)
 
+	// Write HTML report with timing charts and failure logs
+	if tr.config.ReportHTML {
+		htmlFile, err := os.Create("test_report.html")
+		if err != nil {
+			return fmt.Errorf("failed to create HTML report: %w", err)
+		}
+		defer func() { _ = htmlFile.Close() }()
+
+		if err := tr.WriteHTMLReport(htmlFile, &report); err != nil {
+			return err
+		}
+	}
+
+	// Write JUnit XML report for CI test-report tooling
+	if tr.config.ReportJUnit {
+		xmlFile, err := os.Create("test_report.xml")
+		if err != nil {
+			return fmt.Errorf("failed to create JUnit report: %w", err)
+		}
+		defer func() { _ = xmlFile.Close() }()
+
+		results := make([]*TestSuiteResult, 0, len(runner.results))
+		for _, result := range runner.results {
+			results = append(results, result)
+		}
+		if err := tr.WriteJUnitReport(xmlFile, results...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteJUnitReport writes suite results, such as the scenario results of an
+// IntegrationTestRunner, as JUnit XML
+func (tr *TestReporter) WriteJUnitReport(w io.Writer, results ...*TestSuiteResult) error {
+	if err := NewJUnitReport(results...).Write(w); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
 	return nil
 }
 
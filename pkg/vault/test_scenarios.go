@@ -0,0 +1,460 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultScenarioThreshold is the unseal threshold of scenario vaults without one
+	defaultScenarioThreshold = 3
+	// scenarioPollInterval is how often expect steps with a window re-check Vault
+	scenarioPollInterval = 100 * time.Millisecond
+)
+
+// ScenarioEnvironment provides the Vault nodes of declarative scenarios, e.g.
+// mock clients or containers
+type ScenarioEnvironment interface {
+	// StartVault starts a node in the state described by vault and returns its client
+	StartVault(ctx context.Context, vault ScenarioVault) (VaultClient, error)
+	// SealVault seals a started node
+	SealVault(ctx context.Context, name string) error
+	// StopVault stops a started node
+	StopVault(ctx context.Context, name string) error
+}
+
+// ScenarioFile is a YAML document of declarative test scenarios
+type ScenarioFile struct {
+	Scenarios []ScenarioSpec `json:"scenarios"`
+}
+
+// ScenarioSpec declares the Vault topology of a scenario and the steps run against it
+type ScenarioSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Timeout     *ScenarioDuration `json:"timeout,omitempty"`
+	ExpectError bool              `json:"expectError,omitempty"`
+	Vaults      []ScenarioVault   `json:"vaults"`
+	Steps       []ScenarioStep    `json:"steps"`
+}
+
+// ScenarioVault is a Vault node of a scenario topology
+type ScenarioVault struct {
+	Name string `json:"name"`
+	// Sealed starts the node sealed
+	Sealed bool `json:"sealed,omitempty"`
+	// Threshold is the number of keys needed to unseal; defaults to 3
+	Threshold int `json:"threshold,omitempty"`
+	// Keys are the unseal keys submitted by unseal steps without their own
+	Keys []string `json:"keys,omitempty"`
+}
+
+// ScenarioStep is one action or check of a scenario; exactly one field is set
+type ScenarioStep struct {
+	// Seal seals the named node
+	Seal string `json:"seal,omitempty"`
+	// Unseal submits unseal keys to a node
+	Unseal *UnsealStep `json:"unseal,omitempty"`
+	// Expect checks the state of a node
+	Expect *ExpectStep `json:"expect,omitempty"`
+	// Wait pauses the scenario
+	Wait *ScenarioDuration `json:"wait,omitempty"`
+}
+
+// UnsealStep submits keys to a node
+type UnsealStep struct {
+	Vault string `json:"vault"`
+	// Keys default to the keys of the node
+	Keys []string `json:"keys,omitempty"`
+	// Threshold defaults to the threshold of the node
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// ExpectStep checks the seal status of a node; unset fields are not checked
+type ExpectStep struct {
+	Vault       string `json:"vault"`
+	Sealed      *bool  `json:"sealed,omitempty"`
+	Initialized *bool  `json:"initialized,omitempty"`
+	Progress    *int   `json:"progress,omitempty"`
+	// Within keeps re-checking until the expectations hold or the window ends
+	Within *ScenarioDuration `json:"within,omitempty"`
+}
+
+// ScenarioDuration is a duration written as a Go duration string, e.g. "5s"
+type ScenarioDuration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string
+func (d *ScenarioDuration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON writes the duration string
+func (d ScenarioDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// LoadTestScenarios reads declarative scenarios from a YAML file, or from every
+// .yaml and .yml file of a directory in name order, and binds them to env
+func LoadTestScenarios(path string, env ScenarioEnvironment) ([]TestScenario, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenarios: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenarios: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	var scenarios []TestScenario
+	seen := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file) //nolint:gosec // Scenario files are chosen by the test author
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenarios: %w", err)
+		}
+		specs, err := ParseTestScenarios(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, spec := range specs {
+			if other, ok := seen[spec.Name]; ok {
+				return nil, fmt.Errorf("%s: scenario %q is also defined in %s", file, spec.Name, other)
+			}
+			seen[spec.Name] = file
+			scenarios = append(scenarios, spec.Build(env))
+		}
+	}
+	return scenarios, nil
+}
+
+// ParseTestScenarios parses and validates a YAML document of scenarios. Unknown
+// fields are rejected so typos do not silently skip checks.
+func ParseTestScenarios(data []byte) ([]ScenarioSpec, error) {
+	var file ScenarioFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid scenario file: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range file.Scenarios {
+		spec := &file.Scenarios[i]
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("scenario %d (%s): %w", i, spec.Name, err)
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("scenario %q is defined twice", spec.Name)
+		}
+		names[spec.Name] = true
+	}
+	return file.Scenarios, nil
+}
+
+// validate checks that the steps of a scenario are well-formed and only refer to its vaults
+func (s *ScenarioSpec) validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(s.Steps) == 0 {
+		return errors.New("at least one step is required")
+	}
+
+	vaults := make(map[string]bool)
+	for _, vault := range s.Vaults {
+		if vault.Name == "" {
+			return errors.New("vault name is required")
+		}
+		if vaults[vault.Name] {
+			return fmt.Errorf("vault %q is declared twice", vault.Name)
+		}
+		if vault.Threshold < 0 {
+			return fmt.Errorf("vault %q has a negative threshold", vault.Name)
+		}
+		vaults[vault.Name] = true
+	}
+
+	for i, step := range s.Steps {
+		var vault string
+		actions := 0
+		if step.Seal != "" {
+			actions++
+			vault = step.Seal
+		}
+		if step.Unseal != nil {
+			actions++
+			vault = step.Unseal.Vault
+		}
+		if step.Expect != nil {
+			actions++
+			vault = step.Expect.Vault
+		}
+		if step.Wait != nil {
+			actions++
+		}
+		if actions != 1 {
+			return fmt.Errorf("step %d must set exactly one of seal, unseal, expect or wait", i)
+		}
+		if step.Wait == nil && !vaults[vault] {
+			return fmt.Errorf("step %d refers to undeclared vault %q", i, vault)
+		}
+	}
+	return nil
+}
+
+// Build binds the scenario to env: setup starts its vaults, execute runs its
+// steps in order and cleanup stops the vaults again
+func (s ScenarioSpec) Build(env ScenarioEnvironment) TestScenario {
+	run := &scenarioRun{spec: s, env: env, clients: make(map[string]VaultClient)}
+	scenario := TestScenario{
+		Name:        s.Name,
+		Description: s.Description,
+		Setup:       run.setup,
+		Execute:     run.execute,
+		Cleanup:     run.cleanup,
+		ExpectError: s.ExpectError,
+	}
+	if s.Timeout != nil {
+		scenario.Timeout = s.Timeout.Duration
+	}
+	return scenario
+}
+
+// scenarioRun holds the clients of the vaults of a running scenario
+type scenarioRun struct {
+	spec ScenarioSpec
+	env  ScenarioEnvironment
+
+	mu      sync.Mutex
+	clients map[string]VaultClient
+}
+
+func (r *scenarioRun) setup(ctx context.Context) error {
+	for _, vault := range r.spec.Vaults {
+		if vault.Threshold == 0 {
+			vault.Threshold = defaultScenarioThreshold
+		}
+		client, err := r.env.StartVault(ctx, vault)
+		if err != nil {
+			return fmt.Errorf("failed to start vault %s: %w", vault.Name, err)
+		}
+		r.mu.Lock()
+		r.clients[vault.Name] = client
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *scenarioRun) cleanup(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for _, vault := range r.spec.Vaults {
+		client, ok := r.clients[vault.Name]
+		if !ok {
+			continue
+		}
+		_ = client.Close()
+		if err := r.env.StopVault(ctx, vault.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop vault %s: %w", vault.Name, err))
+		}
+		delete(r.clients, vault.Name)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *scenarioRun) execute(ctx context.Context) error {
+	for i, step := range r.spec.Steps {
+		if err := r.runStep(ctx, step); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i, step.describe(), err)
+		}
+	}
+	return nil
+}
+
+func (r *scenarioRun) runStep(ctx context.Context, step ScenarioStep) error {
+	switch {
+	case step.Seal != "":
+		return r.env.SealVault(ctx, step.Seal)
+	case step.Unseal != nil:
+		return r.unseal(ctx, step.Unseal)
+	case step.Expect != nil:
+		return r.expect(ctx, step.Expect)
+	default:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(step.Wait.Duration):
+			return nil
+		}
+	}
+}
+
+func (r *scenarioRun) unseal(ctx context.Context, step *UnsealStep) error {
+	vault := r.vault(step.Vault)
+	keys, threshold := step.Keys, step.Threshold
+	if len(keys) == 0 {
+		keys = vault.Keys
+	}
+	if threshold == 0 {
+		threshold = vault.Threshold
+	}
+	if threshold == 0 {
+		threshold = defaultScenarioThreshold
+	}
+	_, err := r.client(step.Vault).Unseal(ctx, keys, threshold)
+	return err
+}
+
+func (r *scenarioRun) expect(ctx context.Context, step *ExpectStep) error {
+	client := r.client(step.Vault)
+	var deadline time.Time
+	if step.Within != nil {
+		deadline = time.Now().Add(step.Within.Duration)
+	}
+
+	for {
+		err := checkExpectations(ctx, client, step)
+		if err == nil || !time.Now().Before(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last check: %v)", ctx.Err(), err)
+		case <-time.After(scenarioPollInterval):
+		}
+	}
+}
+
+// checkExpectations compares the seal status of a node with an expect step
+func checkExpectations(ctx context.Context, client VaultClient, step *ExpectStep) error {
+	status, err := client.GetSealStatus(ctx)
+	if err != nil {
+		return err
+	}
+	var mismatches []string
+	if step.Sealed != nil && status.Sealed != *step.Sealed {
+		mismatches = append(mismatches, fmt.Sprintf("sealed is %t, want %t", status.Sealed, *step.Sealed))
+	}
+	if step.Initialized != nil && status.Initialized != *step.Initialized {
+		mismatches = append(mismatches, fmt.Sprintf("initialized is %t, want %t", status.Initialized, *step.Initialized))
+	}
+	if step.Progress != nil && status.Progress != *step.Progress {
+		mismatches = append(mismatches, fmt.Sprintf("progress is %d, want %d", status.Progress, *step.Progress))
+	}
+	if len(mismatches) > 0 {
+		return errors.New(strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+func (r *scenarioRun) vault(name string) ScenarioVault {
+	for _, vault := range r.spec.Vaults {
+		if vault.Name == name {
+			return vault
+		}
+	}
+	return ScenarioVault{Name: name}
+}
+
+func (r *scenarioRun) client(name string) VaultClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clients[name]
+}
+
+// describe names the action of a step for error messages
+func (s ScenarioStep) describe() string {
+	switch {
+	case s.Seal != "":
+		return "seal " + s.Seal
+	case s.Unseal != nil:
+		return "unseal " + s.Unseal.Vault
+	case s.Expect != nil:
+		return "expect " + s.Expect.Vault
+	default:
+		return "wait " + s.Wait.String()
+	}
+}
+
+// MockScenarioEnvironment runs declarative scenarios against MockVaultClients
+type MockScenarioEnvironment struct {
+	mu      sync.Mutex
+	clients map[string]*MockVaultClient
+}
+
+// NewMockScenarioEnvironment creates an environment of mock Vault nodes
+func NewMockScenarioEnvironment() *MockScenarioEnvironment {
+	return &MockScenarioEnvironment{clients: make(map[string]*MockVaultClient)}
+}
+
+// StartVault implements ScenarioEnvironment
+func (e *MockScenarioEnvironment) StartVault(_ context.Context, vault ScenarioVault) (VaultClient, error) {
+	client := NewMockVaultClient()
+	client.unsealThreshold = vault.Threshold
+	client.sealStatusResp.T = vault.Threshold
+	client.sealStatusResp.Initialized = true
+	client.SetSealed(vault.Sealed)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clients[vault.Name] = client
+	return client, nil
+}
+
+// SealVault implements ScenarioEnvironment
+func (e *MockScenarioEnvironment) SealVault(_ context.Context, name string) error {
+	client := e.Client(name)
+	if client == nil {
+		return fmt.Errorf("vault %s is not running", name)
+	}
+	client.SetSealed(true)
+	return nil
+}
+
+// StopVault implements ScenarioEnvironment
+func (e *MockScenarioEnvironment) StopVault(_ context.Context, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.clients, name)
+	return nil
+}
+
+// Client returns the mock client of a running node, or nil
+func (e *MockScenarioEnvironment) Client(name string) *MockVaultClient {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.clients[name]
+}
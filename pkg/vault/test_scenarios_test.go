@@ -0,0 +1,132 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestScenarios(t *testing.T) {
+	env := NewMockScenarioEnvironment()
+	scenarios, err := LoadTestScenarios(filepath.Join("testdata", "scenarios"), env)
+	require.NoError(t, err)
+
+	// Files are read in name order
+	names := make([]string, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		names = append(names, scenario.Name)
+	}
+	assert.Equal(t, []string{
+		"ha-partial-seal", "too-few-keys-stays-sealed", "unseal-sealed-node", "reseal-and-recover",
+	}, names)
+	assert.Equal(t, 10*time.Second, scenarios[2].Timeout)
+	assert.True(t, scenarios[1].ExpectError)
+
+	config := DefaultIntegrationConfig()
+	config.HealthCheckInterval = 10 * time.Millisecond
+	runner := NewIntegrationTestRunner(config)
+	require.NoError(t, runner.RunScenarios(t.Context(), scenarios))
+
+	results := runner.ScenarioResults()
+	assert.Equal(t, 4, results.Passed)
+	assert.Nil(t, env.Client("vault-0"), "cleanup stops every vault")
+}
+
+func TestScenarioStepFailure(t *testing.T) {
+	specs, err := ParseTestScenarios([]byte(`
+scenarios:
+- name: wrong-progress
+  vaults:
+  - name: vault-0
+    sealed: true
+  steps:
+  - unseal: {vault: vault-0, keys: ["a2V5MQ=="]}
+  - expect: {vault: vault-0, sealed: false, progress: 2}
+`))
+	require.NoError(t, err)
+
+	env := NewMockScenarioEnvironment()
+	scenario := specs[0].Build(env)
+	require.NoError(t, scenario.Setup(t.Context()))
+	err = scenario.Execute(t.Context())
+	require.Error(t, err)
+	assert.EqualError(t, err, "step 1 (expect vault-0): sealed is true, want false, progress is 1, want 2")
+	assert.Equal(t, []string{"a2V5MQ=="}, env.Client("vault-0").GetSubmittedKeys())
+	require.NoError(t, scenario.Cleanup(t.Context()))
+
+	// Expectations with a window give up once it ends
+	specs[0].Steps[1].Expect.Within = &ScenarioDuration{Duration: 50 * time.Millisecond}
+	scenario = specs[0].Build(env)
+	require.NoError(t, scenario.Setup(t.Context()))
+	start := time.Now()
+	require.Error(t, scenario.Execute(t.Context()))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// A context ending stops waiting
+	specs[0].Steps[1].Expect.Within = &ScenarioDuration{Duration: time.Minute}
+	scenario = specs[0].Build(env)
+	require.NoError(t, scenario.Setup(t.Context()))
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(scenario.Execute(ctx), context.DeadlineExceeded))
+}
+
+func TestParseTestScenariosRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "unknown field",
+			yaml: "scenarios:\n- name: a\n  steps:\n  - wait: 1s\n    sael: vault-0\n",
+			err:  `unknown field "sael"`,
+		},
+		{
+			name: "undeclared vault",
+			yaml: "scenarios:\n- name: a\n  steps:\n  - seal: vault-0\n",
+			err:  `step 0 refers to undeclared vault "vault-0"`,
+		},
+		{
+			name: "two actions",
+			yaml: "scenarios:\n- name: a\n  vaults: [{name: v}]\n  steps:\n  - seal: v\n    wait: 1s\n",
+			err:  "exactly one of seal, unseal, expect or wait",
+		},
+		{
+			name: "no steps",
+			yaml: "scenarios:\n- name: a\n",
+			err:  "at least one step is required",
+		},
+		{
+			name: "invalid duration",
+			yaml: "scenarios:\n- name: a\n  steps:\n  - wait: soon\n",
+			err:  "invalid duration",
+		},
+		{
+			name: "duplicate scenario",
+			yaml: "scenarios:\n- name: a\n  steps: [{wait: 1s}]\n- name: a\n  steps: [{wait: 1s}]\n",
+			err:  `scenario "a" is defined twice`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTestScenarios([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	// Scenario names are unique across files
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b.yml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("scenarios:\n- name: a\n  steps: [{wait: 1s}]\n"), 0o600))
+	}
+	_, err := LoadTestScenarios(dir, NewMockScenarioEnvironment())
+	assert.ErrorContains(t, err, "is also defined in")
+}
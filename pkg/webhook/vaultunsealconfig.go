@@ -0,0 +1,80 @@
+// Package webhook implements the admission webhooks of the operator. They
+// reject VaultUnsealConfigs the API server schema cannot, such as dependency
+// cycles between instances.
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-vault-io-v1-vaultunsealconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=vault.io,resources=vaultunsealconfigs,verbs=create;update,versions=v1,name=vvaultunsealconfig.vault.io,admissionReviewVersions=v1
+
+// VaultUnsealConfigValidator validates VaultUnsealConfigs on create and update.
+type VaultUnsealConfigValidator struct{}
+
+var _ admission.CustomValidator = &VaultUnsealConfigValidator{}
+
+// SetupWithManager registers the validator with the webhook server of mgr.
+func (v *VaultUnsealConfigValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&vaultv1.VaultUnsealConfig{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates a new VaultUnsealConfig.
+func (v *VaultUnsealConfigValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, validateVaultUnsealConfig(obj)
+}
+
+// ValidateUpdate validates a changed VaultUnsealConfig.
+func (v *VaultUnsealConfigValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, validateVaultUnsealConfig(newObj)
+}
+
+// ValidateDelete allows every deletion.
+func (v *VaultUnsealConfigValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateVaultUnsealConfig(obj runtime.Object) error {
+	vaultConfig, ok := obj.(*vaultv1.VaultUnsealConfig)
+	if !ok {
+		return fmt.Errorf("expected a VaultUnsealConfig, got %T", obj)
+	}
+	return ValidateVaultUnsealConfig(vaultConfig)
+}
+
+// ValidateVaultUnsealConfig runs the checks of the webhook on vaultConfig and
+// returns an Invalid API error listing every problem, or nil. The validate
+// command runs it on local files.
+func ValidateVaultUnsealConfig(vaultConfig *vaultv1.VaultUnsealConfig) error {
+	instancesPath := field.NewPath("spec", "vaultInstances")
+	var errs field.ErrorList
+	_, invalid := controller.DependencyOrder(vaultConfig.Spec.VaultInstances)
+	for i := range vaultConfig.Spec.VaultInstances {
+		err := invalid[i]
+		if err == nil {
+			continue
+		}
+		path := instancesPath.Index(i).Child("dependsOn")
+		if err.Unknown != "" {
+			errs = append(errs, field.NotFound(path, err.Unknown))
+		} else {
+			errs = append(errs, field.Invalid(path, vaultConfig.Spec.VaultInstances[i].DependsOn, err.Error()))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(vaultv1.GroupVersion.WithKind("VaultUnsealConfig").GroupKind(), vaultConfig.Name, errs)
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/testing/testenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func configWithDependencies(dependsOn map[string][]string) *vaultv1.VaultUnsealConfig {
+	config := &vaultv1.VaultUnsealConfig{ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "vault"}}
+	for _, name := range []string{"transit", "vault-0", "vault-1"} {
+		config.Spec.VaultInstances = append(config.Spec.VaultInstances, vaultv1.VaultInstance{
+			Name:       name,
+			Endpoint:   "http://" + name + ":8200",
+			UnsealKeys: []string{"k1"},
+			DependsOn:  dependsOn[name],
+		})
+	}
+	return config
+}
+
+func TestVaultUnsealConfigValidator(t *testing.T) {
+	validator := &VaultUnsealConfigValidator{}
+	ctx := t.Context()
+
+	valid := configWithDependencies(map[string][]string{"vault-0": {"transit"}, "vault-1": {"transit"}})
+	_, err := validator.ValidateCreate(ctx, valid)
+	assert.NoError(t, err)
+
+	cyclic := configWithDependencies(map[string][]string{"transit": {"vault-1"}, "vault-1": {"transit"}})
+	_, err = validator.ValidateUpdate(ctx, valid, cyclic)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "spec.vaultInstances[0].dependsOn")
+	assert.Contains(t, err.Error(), "dependency cycle: transit -> vault-1 -> transit")
+	assert.Contains(t, err.Error(), "spec.vaultInstances[2].dependsOn")
+
+	unknown := configWithDependencies(map[string][]string{"vault-0": {"transit-2"}})
+	_, err = validator.ValidateCreate(ctx, unknown)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `spec.vaultInstances[1].dependsOn: Not found: "transit-2"`)
+
+	_, err = validator.ValidateDelete(ctx, cyclic)
+	assert.NoError(t, err)
+}
+
+func TestEnvtest_RejectsDependencyCycles(t *testing.T) {
+	env := testenv.Start(t, testenv.WithWebhooks("../../manifests/webhook.yaml"))
+	env.StartManager(t, func(mgr ctrl.Manager) error {
+		return (&VaultUnsealConfigValidator{}).SetupWithManager(mgr)
+	})
+	namespace := env.Namespace(t)
+
+	cyclic := configWithDependencies(map[string][]string{"transit": {"vault-1"}, "vault-1": {"transit"}})
+	cyclic.Namespace = namespace
+	// The webhook server accepts connections shortly after the manager starts
+	require.Eventually(t, func() bool {
+		err := env.Client.Create(t.Context(), cyclic.DeepCopy())
+		return apierrors.IsInvalid(err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	valid := configWithDependencies(map[string][]string{"vault-0": {"transit"}})
+	valid.Namespace = namespace
+	require.NoError(t, env.Client.Create(t.Context(), valid))
+}
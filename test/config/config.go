@@ -8,18 +8,26 @@ import (
 // Config holds test configuration for the vault-autounseal-operator tests
 type Config struct {
 	// K3s configuration
-	K3sImage                string
-	K3sVersion              string
-	StartupTimeout          time.Duration
-	ReadinessPollInterval   time.Duration
+	K3sImage              string
+	K3sVersion            string
+	StartupTimeout        time.Duration
+	ReadinessPollInterval time.Duration
+
+	// kind configuration, for the kind e2e cluster provider
+	KindNodeImage string
+	KindVersion   string
 
 	// Vault configuration
-	VaultImage              string
-	VaultVersion            string
+	VaultImage   string
+	VaultVersion string
+
+	// Toxiproxy configuration
+	ToxiproxyImage   string
+	ToxiproxyVersion string
 
 	// Test retry configuration
-	RetryBackoff            time.Duration
-	MaxBackoff              time.Duration
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
 }
 
 var globalConfig *Config
@@ -28,12 +36,14 @@ var globalConfig *Config
 func GetGlobalConfig() (*Config, error) {
 	if globalConfig == nil {
 		globalConfig = &Config{
-			K3sVersion:              "v1.30.8-k3s1",
-			StartupTimeout:          2 * time.Minute,
-			ReadinessPollInterval:   2 * time.Second,
-			VaultVersion:            "1.19.0",
-			RetryBackoff:            500 * time.Millisecond,
-			MaxBackoff:              30 * time.Second,
+			K3sVersion:            "v1.30.8-k3s1",
+			KindVersion:           "v1.30.8",
+			StartupTimeout:        2 * time.Minute,
+			ReadinessPollInterval: 2 * time.Second,
+			VaultVersion:          "1.19.0",
+			ToxiproxyVersion:      "2.12.0",
+			RetryBackoff:          500 * time.Millisecond,
+			MaxBackoff:            30 * time.Second,
 		}
 	}
 	return globalConfig, nil
@@ -47,6 +57,14 @@ func (c *Config) GetK3sImage() string {
 	return "rancher/k3s:" + c.K3sVersion
 }
 
+// GetKindNodeImage returns the kind node image, matching the K3s Kubernetes version
+func (c *Config) GetKindNodeImage() string {
+	if c.KindNodeImage != "" {
+		return c.KindNodeImage
+	}
+	return "kindest/node:" + c.KindVersion
+}
+
 // GetK3sImageForVersion returns the K3s container image for a specific version
 func (c *Config) GetK3sImageForVersion(version string) string {
 	return "rancher/k3s:" + version
@@ -65,6 +83,14 @@ func (c *Config) GetVaultImageForVersion(version string) string {
 	return "vault:" + version
 }
 
+// GetToxiproxyImage returns the Toxiproxy container image name
+func (c *Config) GetToxiproxyImage() string {
+	if c.ToxiproxyImage != "" {
+		return c.ToxiproxyImage
+	}
+	return "ghcr.io/shopify/toxiproxy:" + c.ToxiproxyVersion
+}
+
 // Validate validates the test configuration
 func (c *Config) Validate() error {
 	// Basic validation - all configurations are optional with defaults
@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/panteparak/vault-autounseal-operator/test/config"
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/modules/k3s"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// K3sProvider runs k3s in a privileged testcontainer
+type K3sProvider struct{}
+
+// Name returns the name of the provider
+func (K3sProvider) Name() string { return "k3s" }
+
+// Create starts a k3s container, exposing opts.NodePorts on random host ports
+func (K3sProvider) Create(ctx context.Context, opts Options) (Cluster, error) {
+	image := opts.Image
+	if image == "" {
+		cfg, err := config.GetGlobalConfig()
+		if err != nil {
+			return nil, err
+		}
+		image = cfg.GetK3sImage()
+	}
+
+	ports := make([]string, len(opts.NodePorts))
+	for i, port := range opts.NodePorts {
+		ports[i] = fmt.Sprintf("%d/tcp", port)
+	}
+	container, err := k3s.Run(ctx, image,
+		testcontainers.WithExposedPorts(ports...),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("k3s is up and running").
+				WithStartupTimeout(180*time.Second).
+				WithPollInterval(5*time.Second),
+		),
+	)
+	if err != nil {
+		if container != nil {
+			_ = container.Terminate(context.Background())
+		}
+		return nil, fmt.Errorf("failed to start k3s: %w", err)
+	}
+
+	cluster := &k3sCluster{container: container}
+	for _, manifest := range opts.Manifests {
+		if err := cluster.Apply(ctx, manifest); err != nil {
+			_ = cluster.Terminate(context.Background())
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+type k3sCluster struct {
+	container *k3s.K3sContainer
+}
+
+func (c *k3sCluster) KubeConfig(ctx context.Context) ([]byte, error) {
+	return c.container.GetKubeConfig(ctx)
+}
+
+func (c *k3sCluster) NodePortAddress(ctx context.Context, nodePort int) (string, error) {
+	host, err := c.container.Host(ctx)
+	if err != nil {
+		return "", err
+	}
+	port, err := c.container.MappedPort(ctx, nat.Port(strconv.Itoa(nodePort)+"/tcp"))
+	if err != nil {
+		return "", fmt.Errorf("node port %d is not exposed: %w", nodePort, err)
+	}
+	return fmt.Sprintf("%s:%s", host, port.Port()), nil
+}
+
+func (c *k3sCluster) Kubectl(ctx context.Context, args ...string) (string, error) {
+	return c.exec(ctx, append([]string{"kubectl"}, args...)...)
+}
+
+func (c *k3sCluster) Apply(ctx context.Context, manifest string) error {
+	// Exec has no stdin, so the manifest is copied into the container first
+	path := fmt.Sprintf("/tmp/manifest-%d.yaml", time.Now().UnixNano())
+	if err := c.container.CopyToContainer(ctx, []byte(manifest), path, 0o644); err != nil {
+		return fmt.Errorf("failed to copy manifest: %w", err)
+	}
+	if output, err := c.exec(ctx, "kubectl", "apply", "-f", path); err != nil {
+		return fmt.Errorf("failed to apply manifest: %w: %s", err, output)
+	}
+	return nil
+}
+
+func (c *k3sCluster) LoadImage(ctx context.Context, images ...string) error {
+	return c.container.LoadImages(ctx, images...)
+}
+
+func (c *k3sCluster) Terminate(ctx context.Context) error {
+	return c.container.Terminate(ctx)
+}
+
+// exec runs a command in the k3s container and fails on a non-zero exit code
+func (c *k3sCluster) exec(ctx context.Context, cmd ...string) (string, error) {
+	code, reader, err := c.container.Exec(ctx, cmd, tcexec.Multiplexed())
+	if err != nil {
+		return "", err
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return string(output), fmt.Errorf("%s exited with %d", strings.Join(cmd, " "), code)
+	}
+	return string(output), nil
+}
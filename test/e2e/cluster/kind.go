@@ -0,0 +1,151 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/panteparak/vault-autounseal-operator/test/config"
+)
+
+// DefaultKindClusterName names kind clusters created without Options.Name
+const DefaultKindClusterName = "vault-autounseal-e2e"
+
+// KindProvider creates kind clusters with the kind and kubectl CLIs, which must
+// be on the PATH. NodePorts are mapped to the same ports on 127.0.0.1, so they
+// must be free on the host.
+type KindProvider struct{}
+
+// Name returns the name of the provider
+func (KindProvider) Name() string { return "kind" }
+
+// Create creates a kind cluster with its own kubeconfig, leaving the user's
+// kubeconfig untouched
+func (KindProvider) Create(ctx context.Context, opts Options) (Cluster, error) {
+	for _, tool := range []string{"kind", "kubectl"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil, fmt.Errorf("kind provider needs %s on the PATH: %w", tool, err)
+		}
+	}
+
+	image := opts.Image
+	if image == "" {
+		cfg, err := config.GetGlobalConfig()
+		if err != nil {
+			return nil, err
+		}
+		image = cfg.GetKindNodeImage()
+	}
+	name := opts.Name
+	if name == "" {
+		name = DefaultKindClusterName
+	}
+
+	dir, err := os.MkdirTemp("", "kind-"+name+"-*")
+	if err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(dir, "kind.yaml")
+	if err := os.WriteFile(configPath, []byte(kindConfig(opts.NodePorts)), 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	cluster := &kindCluster{name: name, dir: dir, kubeconfig: filepath.Join(dir, "kubeconfig")}
+	if _, err := run(ctx, nil, "kind", "create", "cluster",
+		"--name", name,
+		"--image", image,
+		"--config", configPath,
+		"--kubeconfig", cluster.kubeconfig,
+		"--wait", "180s",
+	); err != nil {
+		// A failed create can leave node containers behind
+		_ = cluster.Terminate(context.Background())
+		return nil, fmt.Errorf("failed to create kind cluster: %w", err)
+	}
+
+	for _, manifest := range opts.Manifests {
+		if err := cluster.Apply(ctx, manifest); err != nil {
+			_ = cluster.Terminate(context.Background())
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+// kindConfig returns a kind cluster configuration with one node that maps the
+// given NodePorts to the host
+func kindConfig(nodePorts []int) string {
+	var b strings.Builder
+	b.WriteString("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n- role: control-plane\n")
+	if len(nodePorts) > 0 {
+		b.WriteString("  extraPortMappings:\n")
+		for _, port := range nodePorts {
+			fmt.Fprintf(&b, "  - containerPort: %d\n    hostPort: %d\n    listenAddress: \"127.0.0.1\"\n", port, port)
+		}
+	}
+	return b.String()
+}
+
+type kindCluster struct {
+	name       string
+	dir        string
+	kubeconfig string
+}
+
+func (c *kindCluster) KubeConfig(_ context.Context) ([]byte, error) {
+	return os.ReadFile(c.kubeconfig)
+}
+
+func (c *kindCluster) NodePortAddress(_ context.Context, nodePort int) (string, error) {
+	return fmt.Sprintf("127.0.0.1:%d", nodePort), nil
+}
+
+func (c *kindCluster) Kubectl(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, nil, "kubectl", append([]string{"--kubeconfig", c.kubeconfig}, args...)...)
+}
+
+func (c *kindCluster) Apply(ctx context.Context, manifest string) error {
+	if _, err := run(ctx, strings.NewReader(manifest),
+		"kubectl", "--kubeconfig", c.kubeconfig, "apply", "-f", "-"); err != nil {
+		return fmt.Errorf("failed to apply manifest: %w", err)
+	}
+	return nil
+}
+
+func (c *kindCluster) LoadImage(ctx context.Context, images ...string) error {
+	args := append([]string{"load", "docker-image", "--name", c.name}, images...)
+	if _, err := run(ctx, nil, "kind", args...); err != nil {
+		return fmt.Errorf("failed to load images: %w", err)
+	}
+	return nil
+}
+
+func (c *kindCluster) Terminate(ctx context.Context) error {
+	defer func() { _ = os.RemoveAll(c.dir) }()
+	if _, err := run(ctx, nil, "kind", "delete", "cluster", "--name", c.name, "--kubeconfig", c.kubeconfig); err != nil {
+		return fmt.Errorf("failed to delete kind cluster: %w", err)
+	}
+	return nil
+}
+
+// run runs a command and returns its combined output, which is included in
+// the error when the command fails
+func run(ctx context.Context, stdin *strings.Reader, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if err := cmd.Run(); err != nil {
+		return output.String(), fmt.Errorf("%s %s: %w: %s",
+			name, strings.Join(args, " "), err, strings.TrimSpace(output.String()))
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,100 @@
+// Package cluster provides the Kubernetes clusters the e2e tests run against.
+// A Provider creates a Cluster; k3s runs k3s in a testcontainer and kind runs
+// a kind cluster through the kind CLI, for environments where k3s in Docker
+// is unreliable. The provider is chosen with -e2e.provider or
+// E2E_CLUSTER_PROVIDER and defaults to k3s.
+package cluster
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProviderEnv selects the provider when -e2e.provider is not set
+const ProviderEnv = "E2E_CLUSTER_PROVIDER"
+
+// DefaultProvider is used when no provider is selected
+const DefaultProvider = "k3s"
+
+var providerFlag = flag.String("e2e.provider", "",
+	"cluster provider for e2e tests: "+strings.Join(Providers(), ", ")+" (default "+DefaultProvider+")")
+
+// Options configures the cluster a provider creates
+type Options struct {
+	// Name identifies the cluster; kind uses it as the cluster name
+	Name string
+	// Image overrides the node image of the provider
+	Image string
+	// Manifests are applied once the cluster is up, in order
+	Manifests []string
+	// NodePorts are the NodePort service ports tests reach from the host
+	NodePorts []int
+}
+
+// Provider creates clusters of one kind
+type Provider interface {
+	// Name returns the name the provider is selected by
+	Name() string
+	// Create starts a cluster and waits until it accepts requests
+	Create(ctx context.Context, opts Options) (Cluster, error)
+}
+
+// Cluster is a running cluster for e2e tests
+type Cluster interface {
+	// KubeConfig returns a kubeconfig for reaching the cluster from the host
+	KubeConfig(ctx context.Context) ([]byte, error)
+	// NodePortAddress returns the host:port at which a NodePort given in
+	// Options.NodePorts is reachable from the host
+	NodePortAddress(ctx context.Context, nodePort int) (string, error)
+	// Kubectl runs kubectl against the cluster and returns its combined output
+	Kubectl(ctx context.Context, args ...string) (string, error)
+	// Apply applies a manifest to the cluster
+	Apply(ctx context.Context, manifest string) error
+	// LoadImage makes images from the local Docker daemon available to the
+	// cluster nodes without a registry
+	LoadImage(ctx context.Context, images ...string) error
+	// Terminate deletes the cluster
+	Terminate(ctx context.Context) error
+}
+
+var providers = map[string]Provider{
+	"k3s":  K3sProvider{},
+	"kind": KindProvider{},
+}
+
+// Providers returns the names of the available providers
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider returns the provider with the given name
+func NewProvider(name string) (Provider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster provider %q, expected one of %s",
+			name, strings.Join(Providers(), ", "))
+	}
+	return provider, nil
+}
+
+// Selected returns the provider selected by -e2e.provider, E2E_CLUSTER_PROVIDER
+// or the default, in that order
+func Selected() (Provider, error) {
+	name := *providerFlag
+	if name == "" {
+		name = os.Getenv(ProviderEnv)
+	}
+	if name == "" {
+		name = DefaultProvider
+	}
+	return NewProvider(name)
+}
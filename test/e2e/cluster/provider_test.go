@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSelected(t *testing.T) {
+	t.Setenv(ProviderEnv, "")
+	provider, err := Selected()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProvider, provider.Name())
+
+	t.Setenv(ProviderEnv, "kind")
+	provider, err = Selected()
+	require.NoError(t, err)
+	assert.Equal(t, "kind", provider.Name())
+
+	// The flag takes precedence over the environment
+	*providerFlag = "k3s"
+	t.Cleanup(func() { *providerFlag = "" })
+	provider, err = Selected()
+	require.NoError(t, err)
+	assert.Equal(t, "k3s", provider.Name())
+
+	_, err = NewProvider("minikube")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "k3s, kind")
+}
+
+func TestKindConfig(t *testing.T) {
+	var config struct {
+		Kind  string `json:"kind"`
+		Nodes []struct {
+			Role              string `json:"role"`
+			ExtraPortMappings []struct {
+				ContainerPort int    `json:"containerPort"`
+				HostPort      int    `json:"hostPort"`
+				ListenAddress string `json:"listenAddress"`
+			} `json:"extraPortMappings"`
+		} `json:"nodes"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(kindConfig([]int{30200, 30201})), &config))
+	assert.Equal(t, "Cluster", config.Kind)
+	require.Len(t, config.Nodes, 1)
+	require.Len(t, config.Nodes[0].ExtraPortMappings, 2)
+	mapping := config.Nodes[0].ExtraPortMappings[1]
+	assert.Equal(t, 30201, mapping.ContainerPort)
+	assert.Equal(t, 30201, mapping.HostPort)
+	assert.Equal(t, "127.0.0.1", mapping.ListenAddress)
+
+	config.Nodes = nil
+	require.NoError(t, yaml.Unmarshal([]byte(kindConfig(nil)), &config))
+	require.Len(t, config.Nodes, 1)
+	assert.Empty(t, config.Nodes[0].ExtraPortMappings)
+}
@@ -14,13 +14,11 @@ import (
 	"github.com/hashicorp/vault/api"
 	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
 	vaultpkg "github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/panteparak/vault-autounseal-operator/test/e2e/cluster"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/k3s"
 	"github.com/testcontainers/testcontainers-go/modules/vault"
-	"github.com/testcontainers/testcontainers-go/wait"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,10 +30,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
+// vaultNodePort is the NodePort of the in-cluster Vault service
+const vaultNodePort = 30200
+
 // E2EWorkflowTestSuite provides end-to-end workflow testing
 type E2EWorkflowTestSuite struct {
 	suite.Suite
-	k3sContainer   *k3s.K3sContainer
+	cluster        cluster.Cluster
 	vaultContainer *vault.VaultContainer
 	vaultClient    *api.Client
 	vaultAddr      string
@@ -58,7 +59,7 @@ func (suite *E2EWorkflowTestSuite) SetupSuite() {
 	suite.setupCompleteEnvironment()
 }
 
-// setupCompleteEnvironment creates K3s cluster with CRDs, Vault, and operator
+// setupCompleteEnvironment creates a cluster with CRDs, Vault, and operator
 func (suite *E2EWorkflowTestSuite) setupCompleteEnvironment() {
 	// Build operator image first
 	suite.buildOperatorImage()
@@ -71,7 +72,7 @@ func (suite *E2EWorkflowTestSuite) setupCompleteEnvironment() {
 	crdBytes, err := ioutil.ReadFile(crdPath)
 	require.NoError(suite.T(), err, "Failed to read CRD file")
 
-	// Create K3s cluster with the official CRD and basic RBAC
+	// Create the cluster with the official CRD and basic RBAC
 	crdAndRBACManifest := string(crdBytes) + `
 ---
 apiVersion: v1
@@ -183,29 +184,19 @@ spec:
           initialDelaySeconds: 30
           periodSeconds: 30`
 
-	// Create temporary manifest file
-	manifestFile, err := ioutil.TempFile("", "vault-operator-*.yaml")
-	require.NoError(suite.T(), err, "Failed to create temporary manifest file")
-	defer os.Remove(manifestFile.Name())
-
-	_, err = manifestFile.WriteString(crdAndRBACManifest)
-	require.NoError(suite.T(), err, "Failed to write manifest content")
-	manifestFile.Close()
-
-	k3sContainer, err := k3s.Run(suite.ctx,
-		"rancher/k3s:v1.32.1-k3s1",
-		k3s.WithManifest(manifestFile.Name()),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("k3s is up and running").
-				WithStartupTimeout(180*time.Second).
-				WithPollInterval(5*time.Second),
-		),
-	)
-	require.NoError(suite.T(), err, "Failed to start K3s cluster")
-	suite.k3sContainer = k3sContainer
+	provider, err := cluster.Selected()
+	require.NoError(suite.T(), err, "Failed to select cluster provider")
+
+	suite.T().Logf("Creating %s cluster", provider.Name())
+	suite.cluster, err = provider.Create(suite.ctx, cluster.Options{
+		Name:      "vault-autounseal-e2e",
+		Manifests: []string{crdAndRBACManifest},
+		NodePorts: []int{vaultNodePort},
+	})
+	require.NoError(suite.T(), err, "Failed to create %s cluster", provider.Name())
 
 	// Set up Kubernetes client
-	kubeconfig, err := k3sContainer.GetKubeConfig(suite.ctx)
+	kubeconfig, err := suite.cluster.KubeConfig(suite.ctx)
 	require.NoError(suite.T(), err, "Failed to get kubeconfig")
 
 	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
@@ -238,8 +229,7 @@ spec:
 func (suite *E2EWorkflowTestSuite) waitForCRDsReady() {
 	require.Eventually(suite.T(), func() bool {
 		// First check if CRD exists via kubectl
-		debugCmd := []string{"sh", "-c", "kubectl get crd vaultunsealconfigs.vault.io -o yaml"}
-		_, _, err := suite.k3sContainer.Exec(suite.ctx, debugCmd)
+		_, err := suite.cluster.Kubectl(suite.ctx, "get", "crd", "vaultunsealconfigs.vault.io", "-o", "yaml")
 		if err != nil {
 			suite.T().Logf("CRD not yet available: %v", err)
 			return false
@@ -332,15 +322,11 @@ func (suite *E2EWorkflowTestSuite) waitForVaultDeployment() {
 
 // getVaultServiceEndpoint gets the external endpoint for Vault service
 func (suite *E2EWorkflowTestSuite) getVaultServiceEndpoint() string {
-	// For E2E tests, we'll use the K3s container's exposed port
-	// First get the container's host and port for the Vault service
-	hostIP, err := suite.k3sContainer.Host(suite.ctx)
-	require.NoError(suite.T(), err, "Failed to get K3s host")
-
-	// K3s exposes services on random ports, we need to find the mapped port
-	// For now, let's use a simpler approach and access via NodePort
-	// We'll update the Vault service to be NodePort type
-	return fmt.Sprintf("http://%s:30200", hostIP) // Use fixed NodePort
+	// The Vault service is a NodePort service, which the cluster provider
+	// makes reachable from the test runner
+	address, err := suite.cluster.NodePortAddress(suite.ctx, vaultNodePort)
+	require.NoError(suite.T(), err, "Failed to get Vault NodePort address")
+	return "http://" + address
 }
 
 // configureVault sets up Vault with test data
@@ -407,10 +393,10 @@ func (suite *E2EWorkflowTestSuite) buildImageFromSource() {
 	require.NoError(suite.T(), err, "Failed to build operator image")
 }
 
-// deployOperatorViaHelm deploys the operator using Helm inside K3s
+// deployOperatorViaHelm deploys the operator using Helm inside the cluster
 func (suite *E2EWorkflowTestSuite) deployOperatorViaHelm() {
-	// Import the operator image into K3s
-	suite.importImageIntoK3s()
+	// Load the operator image into the cluster
+	suite.loadOperatorImage()
 
 	// Deploy operator using Helm
 	suite.installOperatorHelm()
@@ -419,31 +405,13 @@ func (suite *E2EWorkflowTestSuite) deployOperatorViaHelm() {
 	suite.waitForOperatorReady()
 }
 
-// importImageIntoK3s imports the built image into K3s cluster
-func (suite *E2EWorkflowTestSuite) importImageIntoK3s() {
-	// Save image to tar file
-	tempDir, err := ioutil.TempDir("", "k3s-image-*")
-	require.NoError(suite.T(), err)
-	defer os.RemoveAll(tempDir)
-
-	imageTarPath := filepath.Join(tempDir, "operator-image.tar")
+// loadOperatorImage loads the built image into the cluster nodes
+func (suite *E2EWorkflowTestSuite) loadOperatorImage() {
+	suite.T().Logf("Loading image: %s", suite.operatorImageTag)
+	err := suite.cluster.LoadImage(suite.ctx, suite.operatorImageTag)
+	require.NoError(suite.T(), err, "Failed to load image into cluster")
 
-	// Export image to tar
-	exportCmd := exec.Command("docker", "save", "-o", imageTarPath, suite.operatorImageTag)
-	suite.T().Logf("Exporting image: %s to %s", suite.operatorImageTag, imageTarPath)
-	err = exportCmd.Run()
-	require.NoError(suite.T(), err, "Failed to export image")
-
-	// Copy tar file to K3s container
-	err = suite.k3sContainer.CopyFileToContainer(suite.ctx, imageTarPath, "/tmp/operator-image.tar", 0644)
-	require.NoError(suite.T(), err, "Failed to copy image to K3s")
-
-	// Import image into K3s
-	importCmd := []string{"ctr", "images", "import", "/tmp/operator-image.tar"}
-	_, _, err = suite.k3sContainer.Exec(suite.ctx, importCmd)
-	require.NoError(suite.T(), err, "Failed to import image into K3s")
-
-	suite.T().Logf("Successfully imported operator image into K3s")
+	suite.T().Logf("Successfully loaded operator image into cluster")
 }
 
 // installOperatorHelm deploys operator using kubectl (simplified approach)
@@ -451,36 +419,15 @@ func (suite *E2EWorkflowTestSuite) installOperatorHelm() {
 	// Create operator manifests directly
 	operatorManifests := suite.generateOperatorManifests()
 
-	// Write manifests to temp file
-	tempDir, err := ioutil.TempDir("", "operator-manifests-*")
-	require.NoError(suite.T(), err)
-	defer os.RemoveAll(tempDir)
-
-	manifestsPath := filepath.Join(tempDir, "operator.yaml")
-	err = ioutil.WriteFile(manifestsPath, []byte(operatorManifests), 0644)
-	require.NoError(suite.T(), err)
-
-	// Copy manifests to K3s container
-	err = suite.k3sContainer.CopyFileToContainer(suite.ctx, manifestsPath, "/tmp/operator.yaml", 0644)
-	require.NoError(suite.T(), err)
-
-	// Create namespace
-	createNamespaceCmd := []string{"kubectl", "create", "namespace", "vault-system", "--dry-run=client", "-o", "yaml"}
-	_, _, _ = suite.k3sContainer.Exec(suite.ctx, createNamespaceCmd)
-	applyNamespaceCmd := []string{"sh", "-c", "kubectl create namespace vault-system --dry-run=client -o yaml | kubectl apply -f -"}
-	_, _, _ = suite.k3sContainer.Exec(suite.ctx, applyNamespaceCmd)
-
-	// Apply operator manifests
+	// Apply operator manifests; the vault-system namespace was created with the test namespaces
 	suite.T().Logf("Deploying operator with image: %s", suite.operatorImageTag)
-	applyCmd := []string{"kubectl", "apply", "-f", "/tmp/operator.yaml"}
-	_, _, err = suite.k3sContainer.Exec(suite.ctx, applyCmd)
+	err := suite.cluster.Apply(suite.ctx, operatorManifests)
 
 	if err != nil {
 		suite.T().Logf("Kubectl apply failed: %v", err)
 		// Try to get more details about what failed
-		debugCmd := []string{"sh", "-c", "cat /tmp/operator.yaml && echo '---' && kubectl get all -n vault-system"}
-		debugStdout, _, _ := suite.k3sContainer.Exec(suite.ctx, debugCmd)
-		suite.T().Logf("Debug output: %v", debugStdout)
+		debugStdout, _ := suite.cluster.Kubectl(suite.ctx, "get", "all", "-n", "vault-system")
+		suite.T().Logf("Manifests:\n%s\nDebug output: %v", operatorManifests, debugStdout)
 	}
 	require.NoError(suite.T(), err, "Failed to apply operator manifests")
 
@@ -682,9 +629,7 @@ func (suite *E2EWorkflowTestSuite) waitForOperatorReady() {
 		if deployment.Status.Replicas == 1 && deployment.Status.ReadyReplicas == 0 {
 			// Add some timing to avoid spamming logs
 			if time.Now().Unix()%30 == 0 { // Log every 30 seconds roughly
-				debugCmd := []string{"sh", "-c", "echo '=== Pod Status ==='; kubectl get pods -n vault-system -o wide; echo '=== Pod Description ==='; kubectl describe pod -n vault-system --selector=app.kubernetes.io/instance=vault-operator; echo '=== Pod Logs ==='; kubectl logs -n vault-system --selector=app.kubernetes.io/instance=vault-operator --tail=50; echo '=== CRD Status ==='; kubectl get crd vaultunsealconfigs.vault.io; echo '=== ServiceAccount ==='; kubectl get sa -n vault-system vault-operator-vault-autounseal-operator"}
-				stdout, _, _ := suite.k3sContainer.Exec(suite.ctx, debugCmd)
-				suite.T().Logf("Pod debug output: %v", stdout)
+				suite.logOperatorDebugInfo()
 			}
 		}
 
@@ -694,6 +639,27 @@ func (suite *E2EWorkflowTestSuite) waitForOperatorReady() {
 	}, 300*time.Second, 5*time.Second, "Operator deployment should become ready")
 }
 
+// logOperatorDebugInfo logs the state of the operator pod and its dependencies
+func (suite *E2EWorkflowTestSuite) logOperatorDebugInfo() {
+	selector := "--selector=app.kubernetes.io/instance=vault-operator"
+	for _, step := range []struct {
+		title string
+		args  []string
+	}{
+		{"Pod Status", []string{"get", "pods", "-n", "vault-system", "-o", "wide"}},
+		{"Pod Description", []string{"describe", "pod", "-n", "vault-system", selector}},
+		{"Pod Logs", []string{"logs", "-n", "vault-system", selector, "--tail=50"}},
+		{"CRD Status", []string{"get", "crd", "vaultunsealconfigs.vault.io"}},
+		{"ServiceAccount", []string{"get", "sa", "-n", "vault-system", "vault-operator-vault-autounseal-operator"}},
+	} {
+		output, err := suite.cluster.Kubectl(suite.ctx, step.args...)
+		if err != nil {
+			output = err.Error()
+		}
+		suite.T().Logf("=== %s ===\n%s", step.title, output)
+	}
+}
+
 // TearDownSuite cleans up resources
 func (suite *E2EWorkflowTestSuite) TearDownSuite() {
 	if suite.ctxCancel != nil {
@@ -704,12 +670,12 @@ func (suite *E2EWorkflowTestSuite) TearDownSuite() {
 		suite.vaultContainer.Terminate(context.Background())
 	}
 
-	if suite.k3sContainer != nil {
-		suite.k3sContainer.Terminate(context.Background())
+	if suite.cluster != nil {
+		suite.cluster.Terminate(context.Background())
 	}
 }
 
-// TestCompleteOperatorWorkflow tests the full operator workflow with operator running in K3s
+// TestCompleteOperatorWorkflow tests the full operator workflow with operator running in the cluster
 func (suite *E2EWorkflowTestSuite) TestCompleteOperatorWorkflow() {
 	// Step 1: Create VaultUnsealConfig
 	config := &vaultv1.VaultUnsealConfig{
@@ -1183,7 +1149,7 @@ func (suite *E2EWorkflowTestSuite) TestLongRunningWorkflow() {
 
 // TestVaultConnectivityWorkflow tests vault connectivity scenarios
 func (suite *E2EWorkflowTestSuite) TestVaultConnectivityWorkflow() {
-	// Test direct vault connectivity outside of K3s cluster (from test runner)
+	// Test direct vault connectivity outside of the cluster (from test runner)
 	vaultClient, err := vaultpkg.NewClient(suite.vaultAddr, false, 30*time.Second)
 	require.NoError(suite.T(), err, "Should create vault client")
 	defer vaultClient.Close()
@@ -1198,7 +1164,7 @@ func (suite *E2EWorkflowTestSuite) TestVaultConnectivityWorkflow() {
 	require.NoError(suite.T(), err, "Should check seal status")
 	suite.T().Logf("Vault sealed status (external view): %v", isSealed)
 
-	// Create operator configuration for internal K3s connectivity
+	// Create operator configuration for in-cluster connectivity
 	config := &vaultv1.VaultUnsealConfig{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "connectivity-test",
@@ -1218,8 +1184,8 @@ func (suite *E2EWorkflowTestSuite) TestVaultConnectivityWorkflow() {
 	err = suite.k8sClient.Create(suite.ctx, config)
 	require.NoError(suite.T(), err, "Should create connectivity config")
 
-	// Wait for operator running inside K3s to process the configuration
-	suite.T().Log("Waiting for operator (inside K3s) to process connectivity test")
+	// Wait for operator running inside the cluster to process the configuration
+	suite.T().Log("Waiting for operator (inside the cluster) to process connectivity test")
 	require.Eventually(suite.T(), func() bool {
 		var operatorState vaultv1.VaultUnsealConfig
 		err := suite.k8sClient.Get(suite.ctx, client.ObjectKey{
@@ -10,11 +10,11 @@ import (
 	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
 	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
 	vaultpkg "github.com/panteparak/vault-autounseal-operator/pkg/vault"
+	"github.com/panteparak/vault-autounseal-operator/test/e2e/cluster"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/k3s"
 	"github.com/testcontainers/testcontainers-go/modules/vault"
 	"github.com/testcontainers/testcontainers-go/wait"
 	corev1 "k8s.io/api/core/v1"
@@ -28,10 +28,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
-// K8sIntegrationTestSuite provides end-to-end integration testing with K3s and Vault
+// K8sIntegrationTestSuite provides end-to-end integration testing with a Kubernetes cluster and Vault
 type K8sIntegrationTestSuite struct {
 	suite.Suite
-	k3sContainer      *k3s.K3sContainer
+	cluster           cluster.Cluster
 	vaultContainer    *vault.VaultContainer
 	vaultClient       *api.Client
 	vaultAddr         string
@@ -44,15 +44,15 @@ type K8sIntegrationTestSuite struct {
 	ctxCancel         context.CancelFunc
 }
 
-// SetupSuite initializes the test suite with TestContainers K3s and Vault instances
+// SetupSuite initializes the test suite with a cluster from the selected provider and a Vault container
 func (suite *K8sIntegrationTestSuite) SetupSuite() {
 	suite.ctx, suite.ctxCancel = context.WithTimeout(context.Background(), 10*time.Minute)
 
 	// Set up logging for tests
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
-	// Start K3s cluster first
-	suite.setupK3sCluster()
+	// Start the cluster first
+	suite.setupCluster()
 
 	// Start Vault container
 	suite.setupVaultContainer()
@@ -61,25 +61,21 @@ func (suite *K8sIntegrationTestSuite) SetupSuite() {
 	suite.setupControllerWithK8s()
 }
 
-// setupK3sCluster creates a K3s cluster using TestContainers
-func (suite *K8sIntegrationTestSuite) setupK3sCluster() {
-	k3sContainer, err := k3s.Run(suite.ctx,
-		"rancher/k3s:v1.32.1-k3s1", // Latest K3s version
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("k3s is up and running").
-				WithStartupTimeout(180*time.Second).
-				WithPollInterval(5*time.Second),
-		),
-	)
-	require.NoError(suite.T(), err, "Failed to start K3s container")
-	suite.k3sContainer = k3sContainer
+// setupCluster creates a cluster with the provider selected by -e2e.provider
+func (suite *K8sIntegrationTestSuite) setupCluster() {
+	provider, err := cluster.Selected()
+	require.NoError(suite.T(), err, "Failed to select cluster provider")
+
+	suite.T().Logf("Creating %s cluster", provider.Name())
+	suite.cluster, err = provider.Create(suite.ctx, cluster.Options{Name: "vault-autounseal-k8s-e2e"})
+	require.NoError(suite.T(), err, "Failed to create %s cluster", provider.Name())
 
 	// Get kubeconfig
-	kubeconfig, err := k3sContainer.GetKubeConfig(suite.ctx)
-	require.NoError(suite.T(), err, "Failed to get kubeconfig from K3s")
+	kubeconfig, err := suite.cluster.KubeConfig(suite.ctx)
+	require.NoError(suite.T(), err, "Failed to get kubeconfig")
 
-	// Verify K3s is ready
-	suite.T().Logf("K3s cluster started successfully")
+	// Verify the cluster is ready
+	suite.T().Logf("%s cluster started successfully", provider.Name())
 	suite.T().Logf("Kubeconfig length: %d bytes", len(kubeconfig))
 }
 
@@ -135,8 +131,8 @@ func (suite *K8sIntegrationTestSuite) setupVaultContainer() {
 
 // setupControllerWithK8s sets up the controller with real K8s client
 func (suite *K8sIntegrationTestSuite) setupControllerWithK8s() {
-	// Get kubeconfig from K3s
-	kubeconfig, err := suite.k3sContainer.GetKubeConfig(suite.ctx)
+	// Get kubeconfig from the cluster
+	kubeconfig, err := suite.cluster.KubeConfig(suite.ctx)
 	require.NoError(suite.T(), err, "Failed to get kubeconfig")
 
 	// Create rest config from kubeconfig
@@ -176,17 +172,17 @@ func (suite *K8sIntegrationTestSuite) TearDownSuite() {
 		}
 	}
 
-	if suite.k3sContainer != nil {
-		err := suite.k3sContainer.Terminate(context.Background())
+	if suite.cluster != nil {
+		err := suite.cluster.Terminate(context.Background())
 		if err != nil {
-			suite.T().Logf("Failed to terminate K3s container: %v", err)
+			suite.T().Logf("Failed to terminate cluster: %v", err)
 		}
 	}
 }
 
 // TestK8sVaultIntegration tests the full Kubernetes integration with Vault
 func (suite *K8sIntegrationTestSuite) TestK8sVaultIntegration() {
-	// Create CRD in K3s cluster first
+	// Create CRD in the cluster first
 	suite.createVaultUnsealCRD()
 
 	// Create a VaultUnsealConfig resource
@@ -202,22 +198,22 @@ func (suite *K8sIntegrationTestSuite) TestK8sVaultIntegration() {
 					Endpoint:      suite.vaultAddr,
 					UnsealKeys:    suite.unsealKeys,
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
 	}
 
-	// Create the resource in K3s
+	// Create the resource in the cluster
 	err := suite.k8sClient.Create(suite.ctx, vaultConfig)
-	require.NoError(suite.T(), err, "Failed to create VaultUnsealConfig in K3s")
+	require.NoError(suite.T(), err, "Failed to create VaultUnsealConfig in the cluster")
 
 	// Verify it was created
 	var createdConfig vaultv1.VaultUnsealConfig
 	err = suite.k8sClient.Get(suite.ctx, types.NamespacedName{
 		Name: "k8s-test-vault-config", Namespace: "default",
 	}, &createdConfig)
-	require.NoError(suite.T(), err, "Failed to get created VaultUnsealConfig from K3s")
+	require.NoError(suite.T(), err, "Failed to get created VaultUnsealConfig from the cluster")
 
 	assert.Equal(suite.T(), "k8s-test-vault-config", createdConfig.Name)
 	assert.Equal(suite.T(), "k8s-test-vault", createdConfig.Spec.VaultInstances[0].Name)
@@ -234,12 +230,12 @@ func (suite *K8sIntegrationTestSuite) TestK8sVaultIntegration() {
 	require.NoError(suite.T(), err, "Reconciliation should succeed with real Vault")
 	assert.NotZero(suite.T(), result.RequeueAfter, "Should requeue after some time")
 
-	// Verify the status was updated in K3s
+	// Verify the status was updated in the cluster
 	var updatedConfig vaultv1.VaultUnsealConfig
 	err = suite.k8sClient.Get(suite.ctx, types.NamespacedName{
 		Name: "k8s-test-vault-config", Namespace: "default",
 	}, &updatedConfig)
-	require.NoError(suite.T(), err, "Failed to get updated config from K3s")
+	require.NoError(suite.T(), err, "Failed to get updated config from the cluster")
 
 	// Verify status
 	assert.NotEmpty(suite.T(), updatedConfig.Status.VaultStatuses, "Should have vault statuses")
@@ -254,7 +250,7 @@ func (suite *K8sIntegrationTestSuite) TestK8sVaultIntegration() {
 	assert.Equal(suite.T(), metav1.ConditionTrue, readyCondition.Status, "Ready condition should be true")
 }
 
-// createVaultUnsealCRD creates the VaultUnsealConfig CRD in the K3s cluster
+// createVaultUnsealCRD creates the VaultUnsealConfig CRD in the cluster
 func (suite *K8sIntegrationTestSuite) createVaultUnsealCRD() {
 	// In a real scenario, this would be handled by the operator installation
 	// For this test, we'll ensure the CRD is registered by the scheme
@@ -264,7 +260,7 @@ func (suite *K8sIntegrationTestSuite) createVaultUnsealCRD() {
 	// In a complete E2E test, you would apply the CRD YAML here
 }
 
-// TestK8sClusterHealth tests basic K3s cluster health
+// TestK8sClusterHealth tests basic cluster health
 func (suite *K8sIntegrationTestSuite) TestK8sClusterHealth() {
 	// Test that we can query the cluster
 	nodeList := &corev1.NodeList{}
@@ -273,7 +269,7 @@ func (suite *K8sIntegrationTestSuite) TestK8sClusterHealth() {
 	assert.NotEmpty(suite.T(), nodeList.Items, "Should have at least one node")
 
 	// Log cluster info
-	suite.T().Logf("K3s cluster has %d nodes", len(nodeList.Items))
+	suite.T().Logf("Cluster has %d nodes", len(nodeList.Items))
 	for i, node := range nodeList.Items {
 		suite.T().Logf("Node %d: %s (%s)", i, node.Name, node.Status.NodeInfo.KubeletVersion)
 	}
@@ -301,7 +297,7 @@ func (suite *K8sIntegrationTestSuite) TestEndToEndWorkflow() {
 	// This test simulates the complete operator workflow:
 	// 1. Vault becomes sealed (simulated)
 	// 2. Operator detects it and unseals it
-	// 3. Status is updated in K3s
+	// 3. Status is updated in the cluster
 
 	suite.createVaultUnsealCRD()
 
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	"github.com/panteparak/vault-autounseal-operator/test/integration/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FaultInjectionIntegrationTestSuite runs the controller against a real Vault
+// through Toxiproxy and checks how it reports and recovers from network faults
+type FaultInjectionIntegrationTestSuite struct {
+	shared.FaultInjectionTestSuite
+}
+
+// reconcileThroughProxy creates a config pointing at the proxy of the default
+// Vault instance, reconciles it and returns its instance status
+func (suite *FaultInjectionIntegrationTestSuite) reconcileThroughProxy(name string) vaultv1.VaultInstanceStatus {
+	proxy, exists := suite.GetVaultProxy("default")
+	require.True(suite.T(), exists, "Default vault instance should be proxied")
+
+	key := types.NamespacedName{Name: name, Namespace: "default"}
+	var config vaultv1.VaultUnsealConfig
+	if err := suite.K8sClient().Get(suite.Context(), key, &config); err != nil {
+		config = vaultv1.VaultUnsealConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: vaultv1.VaultUnsealConfigSpec{
+				VaultInstances: []vaultv1.VaultInstance{
+					{
+						Name:       "vault",
+						Endpoint:   proxy.Address,
+						UnsealKeys: []string{"dGVzdC1rZXktMQ==", "dGVzdC1rZXktMg==", "dGVzdC1rZXktMw=="},
+						Threshold:  func() *int { i := 3; return &i }(),
+					},
+				},
+			},
+		}
+		require.NoError(suite.T(), suite.K8sClient().Create(suite.Context(), &config), "Failed to create VaultUnsealConfig")
+	}
+
+	_, err := suite.ReconcileVaultUnsealConfig(key)
+	require.NoError(suite.T(), err, "Reconciliation should report faults in status, not fail")
+
+	require.NoError(suite.T(), suite.K8sClient().Get(suite.Context(), key, &config))
+	require.Len(suite.T(), config.Status.VaultStatuses, 1)
+	return config.Status.VaultStatuses[0]
+}
+
+// TestLatency checks that slow responses still reconcile
+func (suite *FaultInjectionIntegrationTestSuite) TestLatency() {
+	proxy, _ := suite.GetVaultProxy("default")
+	require.NoError(suite.T(), proxy.AddLatency(500*time.Millisecond, 100*time.Millisecond))
+
+	start := time.Now()
+	status := suite.reconcileThroughProxy("latency")
+	assert.GreaterOrEqual(suite.T(), time.Since(start), 400*time.Millisecond, "Latency should slow down reconciliation")
+	assert.False(suite.T(), status.Sealed, "Vault should be reported unsealed")
+	assert.Empty(suite.T(), status.Error, "Latency alone should not cause an error")
+}
+
+// TestBandwidthLimit checks that a slow link still reconciles
+func (suite *FaultInjectionIntegrationTestSuite) TestBandwidthLimit() {
+	proxy, _ := suite.GetVaultProxy("default")
+	require.NoError(suite.T(), proxy.LimitBandwidth(1))
+
+	status := suite.reconcileThroughProxy("bandwidth")
+	assert.False(suite.T(), status.Sealed, "Vault should be reported unsealed")
+	assert.Empty(suite.T(), status.Error, "A slow link should not cause an error")
+}
+
+// TestConnectionReset checks that reset connections are reported and that the
+// controller recovers once they stop
+func (suite *FaultInjectionIntegrationTestSuite) TestConnectionReset() {
+	proxy, _ := suite.GetVaultProxy("default")
+	require.NoError(suite.T(), proxy.ResetPeer(0))
+
+	status := suite.reconcileThroughProxy("reset")
+	assert.NotEmpty(suite.T(), status.Error, "Reset connections should be reported")
+
+	require.NoError(suite.T(), proxy.RemoveToxic("reset_peer_upstream"))
+	status = suite.reconcileThroughProxy("reset")
+	assert.Empty(suite.T(), status.Error, "Controller should recover once connections succeed")
+	assert.False(suite.T(), status.Sealed, "Vault should be reported unsealed")
+}
+
+// TestVaultDown checks that refused connections are reported as unreachable
+func (suite *FaultInjectionIntegrationTestSuite) TestVaultDown() {
+	proxy, _ := suite.GetVaultProxy("default")
+	require.NoError(suite.T(), proxy.SetEnabled(false))
+
+	status := suite.reconcileThroughProxy("down")
+	assert.Equal(suite.T(), controller.ReasonEndpointUnreachable, status.Reason)
+
+	require.NoError(suite.T(), proxy.SetEnabled(true))
+	status = suite.reconcileThroughProxy("down")
+	assert.Empty(suite.T(), status.Error, "Controller should recover once Vault is back")
+}
+
+func TestFaultInjectionIntegrationTestSuite(t *testing.T) {
+	shared.RunFaultInjectionTests(t, new(FaultInjectionIntegrationTestSuite))
+}
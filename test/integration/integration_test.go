@@ -130,7 +130,7 @@ func (suite *VaultIntegrationTestSuite) setupKubernetesClient() {
 type basicVaultRepository struct{}
 
 func (r *basicVaultRepository) GetClient(ctx context.Context, key string, instance *vaultv1.VaultInstance) (vaultpkg.VaultClient, error) {
-	return vaultpkg.NewClient(instance.Endpoint, instance.TLSSkipVerify, 30*time.Second)
+	return vaultpkg.NewClient(instance.Endpoint, instance.TLSSkipVerify != nil && *instance.TLSSkipVerify, 30*time.Second)
 }
 
 func (r *basicVaultRepository) Close() error {
@@ -257,7 +257,7 @@ func (suite *VaultIntegrationTestSuite) TestControllerReconciliation() {
 					Endpoint:      suite.vaultAddr,
 					UnsealKeys:    suite.unsealKeys,
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
@@ -315,14 +315,14 @@ func (suite *VaultIntegrationTestSuite) TestControllerReconciliationWithMultiple
 					Endpoint:      suite.vaultAddr,
 					UnsealKeys:    suite.unsealKeys,
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 				{
 					Name:          "vault-secondary",
 					Endpoint:      suite.vaultAddr,
 					UnsealKeys:    suite.unsealKeys,
 					Threshold:     func() *int { i := 3; return &i }(),
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
@@ -40,47 +40,51 @@ type IntegrationTestSuite struct {
 	k3sManager   *K3sManager
 	crdGenerator *CRDGenerator
 
+	// Fault injection between the controller and Vault
+	toxiproxyManager *ToxiproxyManager
+
 	// Kubernetes components
-	scheme        *runtime.Scheme
-	k8sClient     client.Client
-	reconciler    *controller.VaultUnsealConfigReconciler
+	scheme     *runtime.Scheme
+	k8sClient  client.Client
+	reconciler *controller.VaultUnsealConfigReconciler
 
 	// Test configuration
-	testTimeout   time.Duration
-	setupOptions  *IntegrationSetupOptions
+	testTimeout  time.Duration
+	setupOptions *IntegrationSetupOptions
 }
 
 // IntegrationSetupOptions configures what components to set up for a test
 type IntegrationSetupOptions struct {
 	// Which components to initialize
-	RequiresVault          bool
-	RequiresK3s           bool
-	RequiresController    bool
-	RequiresCRDs          bool
+	RequiresVault      bool
+	RequiresK3s        bool
+	RequiresController bool
+	RequiresCRDs       bool
+	RequiresToxiproxy  bool // Route Vault traffic through Toxiproxy
 
 	// Vault configuration
-	VaultMode            VaultMode
-	VaultVersion         string
-	NumVaultInstances    int
-	VaultInstanceNames   []string
+	VaultMode          VaultMode
+	VaultVersion       string
+	NumVaultInstances  int
+	VaultInstanceNames []string
 
 	// K3s configuration
-	K3sVersion           string
-	K3sNamespace         string
+	K3sVersion   string
+	K3sNamespace string
 
 	// Controller configuration
-	UseRealK8sClient     bool  // Use real K3s client vs fake client
+	UseRealK8sClient     bool // Use real K3s client vs fake client
 	EnableLeaderElection bool
 
 	// Test behavior
-	SkipInShortMode      bool
-	CustomTimeout        time.Duration
+	SkipInShortMode bool
+	CustomTimeout   time.Duration
 }
 
 // DefaultIntegrationSetupOptions returns sensible defaults for integration tests
 func DefaultIntegrationSetupOptions() *IntegrationSetupOptions {
 	return &IntegrationSetupOptions{
-		RequiresVault:       true,
+		RequiresVault:      true,
 		RequiresK3s:        false,
 		RequiresController: true,
 		RequiresCRDs:       true,
@@ -89,10 +93,10 @@ func DefaultIntegrationSetupOptions() *IntegrationSetupOptions {
 		NumVaultInstances:  1,
 		VaultInstanceNames: []string{"default"},
 
-		K3sNamespace:       "default",
-		UseRealK8sClient:   false,
-		SkipInShortMode:    true,
-		CustomTimeout:      15 * time.Minute,
+		K3sNamespace:     "default",
+		UseRealK8sClient: false,
+		SkipInShortMode:  true,
+		CustomTimeout:    15 * time.Minute,
 	}
 }
 
@@ -158,6 +162,10 @@ func (suite *IntegrationTestSuite) SetupIntegrationSuite(options *IntegrationSet
 		suite.setupVaultInstances()
 	}
 
+	if options.RequiresToxiproxy {
+		suite.setupToxiproxy()
+	}
+
 	if options.RequiresK3s {
 		suite.setupK3sManager()
 		suite.setupK3sCluster()
@@ -227,6 +235,23 @@ func (suite *IntegrationTestSuite) setupVaultInstances() {
 	}
 }
 
+// setupToxiproxy starts Toxiproxy and puts a proxy, named after the instance,
+// in front of every Vault instance
+func (suite *IntegrationTestSuite) setupToxiproxy() {
+	require.NotNil(suite.T(), suite.vaultManager, "Toxiproxy requires Vault - ensure RequiresVault is true")
+
+	var err error
+	suite.toxiproxyManager, err = NewToxiproxyManager(suite.ctx)
+	require.NoError(suite.T(), err, "Failed to start Toxiproxy")
+
+	for name, instance := range suite.vaultManager.instances {
+		proxy, err := suite.toxiproxyManager.ProxyVault(name, instance)
+		require.NoError(suite.T(), err, "Failed to proxy vault instance %s", name)
+
+		suite.T().Logf("Proxying vault instance '%s' at %s", name, proxy.Address)
+	}
+}
+
 // setupK3sManager initializes the K3s manager
 func (suite *IntegrationTestSuite) setupK3sManager() {
 	suite.k3sManager = NewK3sManager(suite.ctx, suite.Suite)
@@ -279,20 +304,28 @@ func (suite *IntegrationTestSuite) setupController() {
 		// Use fake client if no real K8s client is set up
 		suite.k8sClient = fake.NewClientBuilder().
 			WithScheme(suite.scheme).
+			WithStatusSubresource(&vaultv1.VaultUnsealConfig{}).
 			Build()
 	}
 
-	// Create controller with mock repository
-	mockRepo := &mocks.MockVaultClientRepository{}
-	// Set up mock to return error when trying to connect (since we don't have real vault)
-	mockRepo.On("GetClient", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
-		Return(nil, errors.New("vault connection failed - expected in integration test")).Maybe()
+	var repo controller.VaultClientRepository
+	if suite.toxiproxyManager != nil {
+		// Faults are injected into real traffic, so the controller needs real clients
+		repo = controller.NewDefaultVaultClientRepository(nil)
+	} else {
+		// Create controller with mock repository
+		mockRepo := &mocks.MockVaultClientRepository{}
+		// Set up mock to return error when trying to connect (since we don't have real vault)
+		mockRepo.On("GetClient", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+			Return(nil, errors.New("vault connection failed - expected in integration test")).Maybe()
+		repo = mockRepo
+	}
 
 	suite.reconciler = controller.NewVaultUnsealConfigReconciler(
 		suite.k8sClient,
 		ctrl.Log.WithName("controllers").WithName("VaultUnsealConfig"),
 		suite.scheme,
-		mockRepo,
+		repo,
 		nil, // Use default options
 	)
 
@@ -301,6 +334,10 @@ func (suite *IntegrationTestSuite) setupController() {
 
 // TearDownIntegrationSuite cleans up all resources
 func (suite *IntegrationTestSuite) TearDownIntegrationSuite() {
+	if suite.toxiproxyManager != nil {
+		suite.toxiproxyManager.Cleanup()
+	}
+
 	if suite.vaultManager != nil {
 		suite.vaultManager.Cleanup()
 	}
@@ -348,6 +385,14 @@ func (suite *IntegrationTestSuite) GetDefaultVaultInstance() *VaultInstance {
 	return instance
 }
 
+// GetVaultProxy returns the Toxiproxy proxy in front of a Vault instance
+func (suite *IntegrationTestSuite) GetVaultProxy(name string) (*VaultProxy, bool) {
+	if suite.toxiproxyManager == nil {
+		return nil, false
+	}
+	return suite.toxiproxyManager.GetProxy(name)
+}
+
 // GetK3sInstance returns the K3s instance
 func (suite *IntegrationTestSuite) GetK3sInstance() (*K3sInstance, bool) {
 	if suite.k3sManager == nil {
@@ -438,6 +483,11 @@ func (suite *IntegrationTestSuite) K3sManager() *K3sManager {
 	return suite.k3sManager
 }
 
+// ToxiproxyManager returns the Toxiproxy manager
+func (suite *IntegrationTestSuite) ToxiproxyManager() *ToxiproxyManager {
+	return suite.toxiproxyManager
+}
+
 // CRDGenerator returns the CRD generator
 func (suite *IntegrationTestSuite) CRDGenerator() *CRDGenerator {
 	return suite.crdGenerator
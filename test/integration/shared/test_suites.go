@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -126,6 +127,33 @@ func (suite *CompatibilityTestSuite) TearDownSuite() {
 	suite.TearDownIntegrationSuite()
 }
 
+// FaultInjectionTestSuite is a specialized test suite for network fault injection
+// Use this to run the real controller against Vault through Toxiproxy, adding
+// latency, bandwidth limits and connection resets with GetVaultProxy()
+type FaultInjectionTestSuite struct {
+	IntegrationTestSuite
+}
+
+// SetupSuite initializes the fault injection test suite
+func (suite *FaultInjectionTestSuite) SetupSuite() {
+	options := DefaultIntegrationSetupOptions()
+	options.RequiresToxiproxy = true
+	options.RequiresK3s = false
+	options.RequiresController = true
+
+	suite.SetupIntegrationSuite(options)
+}
+
+// SetupTest restores normal traffic before each test
+func (suite *FaultInjectionTestSuite) SetupTest() {
+	require.NoError(suite.T(), suite.ToxiproxyManager().Reset(), "Failed to reset Toxiproxy")
+}
+
+// TearDownSuite cleans up resources
+func (suite *FaultInjectionTestSuite) TearDownSuite() {
+	suite.TearDownIntegrationSuite()
+}
+
 // Convenience functions for running test suites
 
 // RunVaultOnlyTests runs a test suite that only requires Vault containers
@@ -159,6 +187,14 @@ func RunMultiVaultTests(t *testing.T, testSuite suite.TestingSuite) {
 	suite.Run(t, testSuite)
 }
 
+// RunFaultInjectionTests runs tests that inject network faults with Toxiproxy
+func RunFaultInjectionTests(t *testing.T, testSuite suite.TestingSuite) {
+	if testing.Short() {
+		t.Skip("Skipping fault injection tests in short mode")
+	}
+	suite.Run(t, testSuite)
+}
+
 // RunCompatibilityTests runs compatibility tests (usually in CI only)
 func RunCompatibilityTests(t *testing.T, testSuite suite.TestingSuite) {
 	if testing.Short() {
@@ -0,0 +1,301 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/hashicorp/vault/api"
+	"github.com/panteparak/vault-autounseal-operator/test/config"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// toxiproxyAPIPort is the port of the Toxiproxy HTTP API
+	toxiproxyAPIPort = "8474/tcp"
+	// toxiproxyFirstProxyPort is the first port handed out to proxies; the
+	// container publishes toxiproxyProxyPorts consecutive ports from it
+	toxiproxyFirstProxyPort = 8666
+	toxiproxyProxyPorts     = 10
+	// vaultContainerPort is the port Vault listens on inside its container
+	vaultContainerPort = 8200
+)
+
+// ToxicStream is the direction of traffic a toxic applies to
+type ToxicStream string
+
+const (
+	// Upstream affects requests sent to Vault
+	Upstream ToxicStream = "upstream"
+	// Downstream affects responses returned by Vault
+	Downstream ToxicStream = "downstream"
+)
+
+// Toxic is a fault Toxiproxy injects into the traffic of a proxy. See
+// https://github.com/Shopify/toxiproxy#toxics for the types and attributes.
+type Toxic struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Stream     ToxicStream    `json:"stream"`
+	Toxicity   float32        `json:"toxicity"`
+	Attributes map[string]int `json:"attributes"`
+}
+
+// VaultProxy routes traffic to a Vault instance through Toxiproxy. Address and
+// Client go through the proxy, so faults added to it affect every request.
+type VaultProxy struct {
+	Name     string
+	Listen   string
+	Upstream string
+	Address  string
+	Client   *api.Client
+
+	manager *ToxiproxyManager
+}
+
+// ToxiproxyManager manages a Toxiproxy container used to inject network faults
+// between the controller and Vault containers
+type ToxiproxyManager struct {
+	ctx        context.Context
+	config     *config.Config
+	container  testcontainers.Container
+	apiURL     string
+	httpClient *http.Client
+	proxies    map[string]*VaultProxy
+}
+
+// NewToxiproxyManager starts a Toxiproxy container for tests
+func NewToxiproxyManager(ctx context.Context) (*ToxiproxyManager, error) {
+	cfg, err := config.GetGlobalConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	exposedPorts := []string{toxiproxyAPIPort}
+	for i := 0; i < toxiproxyProxyPorts; i++ {
+		exposedPorts = append(exposedPorts, fmt.Sprintf("%d/tcp", toxiproxyFirstProxyPort+i))
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        cfg.GetToxiproxyImage(),
+			ExposedPorts: exposedPorts,
+			WaitingFor: wait.ForHTTP("/version").
+				WithPort(toxiproxyAPIPort).
+				WithPollInterval(cfg.ReadinessPollInterval).
+				WithStartupTimeout(cfg.StartupTimeout),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start toxiproxy: %w", err)
+	}
+
+	apiURL, err := container.PortEndpoint(ctx, toxiproxyAPIPort, "http")
+	if err != nil {
+		_ = testcontainers.TerminateContainer(container)
+		return nil, fmt.Errorf("failed to get toxiproxy address: %w", err)
+	}
+
+	return &ToxiproxyManager{
+		ctx:        ctx,
+		config:     cfg,
+		container:  container,
+		apiURL:     apiURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		proxies:    make(map[string]*VaultProxy),
+	}, nil
+}
+
+// ProxyVault creates a proxy in front of a Vault container. The proxy reaches
+// Vault on the container network, so Vault's published port is bypassed.
+func (tm *ToxiproxyManager) ProxyVault(name string, instance *VaultInstance) (*VaultProxy, error) {
+	if instance.Container == nil {
+		return nil, fmt.Errorf("vault instance %s has no container", name)
+	}
+
+	ip, err := instance.Container.ContainerIP(tm.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address of vault %s: %w", name, err)
+	}
+
+	proxy, err := tm.CreateProxy(name, fmt.Sprintf("%s:%d", ip, vaultContainerPort))
+	if err != nil {
+		return nil, err
+	}
+	proxy.Client.SetToken(instance.RootToken)
+	return proxy, nil
+}
+
+// CreateProxy creates a proxy to upstream on the next free proxy port
+func (tm *ToxiproxyManager) CreateProxy(name, upstream string) (*VaultProxy, error) {
+	if _, exists := tm.proxies[name]; exists {
+		return nil, fmt.Errorf("proxy %s already exists", name)
+	}
+	if len(tm.proxies) >= toxiproxyProxyPorts {
+		return nil, fmt.Errorf("all %d toxiproxy ports are in use", toxiproxyProxyPorts)
+	}
+
+	port := toxiproxyFirstProxyPort + len(tm.proxies)
+	listen := fmt.Sprintf("0.0.0.0:%d", port)
+	body := map[string]any{"name": name, "listen": listen, "upstream": upstream, "enabled": true}
+	if err := tm.request(http.MethodPost, "/proxies", body); err != nil {
+		return nil, fmt.Errorf("failed to create proxy %s: %w", name, err)
+	}
+
+	address, err := tm.container.PortEndpoint(tm.ctx, nat.Port(fmt.Sprintf("%d/tcp", port)), "http")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address of proxy %s: %w", name, err)
+	}
+
+	client, err := api.NewClient(&api.Config{
+		Address: address,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client for proxy %s: %w", name, err)
+	}
+
+	proxy := &VaultProxy{
+		Name:     name,
+		Listen:   listen,
+		Upstream: upstream,
+		Address:  address,
+		Client:   client,
+		manager:  tm,
+	}
+	tm.proxies[name] = proxy
+	return proxy, nil
+}
+
+// GetProxy returns a proxy by name
+func (tm *ToxiproxyManager) GetProxy(name string) (*VaultProxy, bool) {
+	proxy, exists := tm.proxies[name]
+	return proxy, exists
+}
+
+// Reset enables every proxy and removes all toxics, restoring normal traffic
+func (tm *ToxiproxyManager) Reset() error {
+	if err := tm.request(http.MethodPost, "/reset", nil); err != nil {
+		return fmt.Errorf("failed to reset toxiproxy: %w", err)
+	}
+	return nil
+}
+
+// Cleanup terminates the Toxiproxy container
+func (tm *ToxiproxyManager) Cleanup() {
+	if tm.container != nil {
+		if err := testcontainers.TerminateContainer(tm.container); err != nil {
+			fmt.Printf("Failed to cleanup toxiproxy: %v\n", err)
+		}
+	}
+	tm.container = nil
+	tm.proxies = make(map[string]*VaultProxy)
+}
+
+// request calls the Toxiproxy API, encoding body as JSON when it is not nil
+func (tm *ToxiproxyManager) request(method, path string, body any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(tm.ctx, method, tm.apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tm.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// AddToxic adds a toxic to the proxy. A toxic without a name is named after its
+// type and stream; a zero toxicity applies it to every connection.
+func (p *VaultProxy) AddToxic(toxic Toxic) error {
+	if toxic.Stream == "" {
+		toxic.Stream = Downstream
+	}
+	if toxic.Name == "" {
+		toxic.Name = fmt.Sprintf("%s_%s", toxic.Type, toxic.Stream)
+	}
+	if toxic.Toxicity == 0 {
+		toxic.Toxicity = 1
+	}
+	if err := p.manager.request(http.MethodPost, "/proxies/"+p.Name+"/toxics", toxic); err != nil {
+		return fmt.Errorf("failed to add %s toxic to proxy %s: %w", toxic.Type, p.Name, err)
+	}
+	return nil
+}
+
+// RemoveToxic removes a toxic from the proxy
+func (p *VaultProxy) RemoveToxic(name string) error {
+	if err := p.manager.request(http.MethodDelete, "/proxies/"+p.Name+"/toxics/"+name, nil); err != nil {
+		return fmt.Errorf("failed to remove toxic %s from proxy %s: %w", name, p.Name, err)
+	}
+	return nil
+}
+
+// AddLatency delays responses by latency, varied by up to jitter
+func (p *VaultProxy) AddLatency(latency, jitter time.Duration) error {
+	return p.AddToxic(Toxic{
+		Type:       "latency",
+		Attributes: map[string]int{"latency": int(latency.Milliseconds()), "jitter": int(jitter.Milliseconds())},
+	})
+}
+
+// LimitBandwidth limits responses to rate KB/s
+func (p *VaultProxy) LimitBandwidth(rate int) error {
+	return p.AddToxic(Toxic{
+		Type:       "bandwidth",
+		Attributes: map[string]int{"rate": rate},
+	})
+}
+
+// ResetPeer resets connections with a TCP RST after timeout; zero resets them
+// as soon as data arrives
+func (p *VaultProxy) ResetPeer(timeout time.Duration) error {
+	return p.AddToxic(Toxic{
+		Type:       "reset_peer",
+		Stream:     Upstream,
+		Attributes: map[string]int{"timeout": int(timeout.Milliseconds())},
+	})
+}
+
+// Timeout stops all data from getting through and closes connections after
+// timeout; zero keeps them open until the toxic is removed
+func (p *VaultProxy) Timeout(timeout time.Duration) error {
+	return p.AddToxic(Toxic{
+		Type:       "timeout",
+		Stream:     Upstream,
+		Attributes: map[string]int{"timeout": int(timeout.Milliseconds())},
+	})
+}
+
+// SetEnabled opens or closes the proxy. A disabled proxy refuses connections,
+// as if Vault were down.
+func (p *VaultProxy) SetEnabled(enabled bool) error {
+	if err := p.manager.request(http.MethodPost, "/proxies/"+p.Name, map[string]any{"enabled": enabled}); err != nil {
+		return fmt.Errorf("failed to update proxy %s: %w", p.Name, err)
+	}
+	return nil
+}
@@ -118,7 +118,7 @@ func (suite *ControllerTestSuite) TestReconcileBasicVaultConfig() {
 					Endpoint:      "http://vault.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3"},
 					Threshold:     &threshold,
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
@@ -221,7 +221,7 @@ func (suite *ControllerTestSuite) TestReconcileSuccessfulUnseal() {
 					Endpoint:      "https://vault-1.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2", "key3"},
 					Threshold:     &threshold,
-					TLSSkipVerify: false,
+					TLSSkipVerify: func() *bool { b := false; return &b }(),
 				},
 			},
 		},
@@ -579,7 +579,7 @@ func (suite *ControllerTestSuite) TestReconcileWithTLSSkipVerify() {
 					Endpoint:      "https://vault-self-signed.example.com:8200",
 					UnsealKeys:    []string{"key1", "key2"},
 					Threshold:     &threshold,
-					TLSSkipVerify: true,
+					TLSSkipVerify: func() *bool { b := true; return &b }(),
 				},
 			},
 		},
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/panteparak/vault-autounseal-operator/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseLevel(t *testing.T) {
+	for value, want := range map[string]zapcore.Level{
+		"debug":   zapcore.DebugLevel,
+		" Info ":  zapcore.InfoLevel,
+		"warning": zapcore.WarnLevel,
+		"error":   zapcore.ErrorLevel,
+		"3":       zapcore.Level(-3),
+	} {
+		level, err := logging.ParseLevel(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, level, value)
+	}
+
+	for _, value := range []string{"verbose", "0", "200"} {
+		_, err := logging.ParseLevel(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRuntimeLevelToggleDebug(t *testing.T) {
+	level := uberzap.NewAtomicLevelAt(zapcore.WarnLevel)
+	runtimeLevel := logging.NewRuntimeLevel(level, nil, types.NamespacedName{}, 0, logr.Discard())
+
+	assert.Equal(t, zapcore.DebugLevel, runtimeLevel.ToggleDebug())
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+	assert.Equal(t, zapcore.WarnLevel, runtimeLevel.ToggleDebug())
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+}
+
+func TestRuntimeLevelFollowsConfigMap(t *testing.T) {
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "vault-system", Name: "operator-log-level"}
+	k8sClient := fake.NewClientBuilder().Build()
+	level := uberzap.NewAtomicLevelAt(zapcore.InfoLevel)
+	runtimeLevel := logging.NewRuntimeLevel(level, k8sClient, key, 0, logr.Discard())
+
+	// A missing ConfigMap keeps the initial level
+	require.NoError(t, runtimeLevel.Sync(ctx))
+	assert.Equal(t, zapcore.InfoLevel, level.Level())
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Data:       map[string]string{logging.LevelConfigMapKey: "debug"},
+	}
+	require.NoError(t, k8sClient.Create(ctx, configMap))
+	require.NoError(t, runtimeLevel.Sync(ctx))
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	// An invalid value is reported once and keeps the current level
+	configMap.Data[logging.LevelConfigMapKey] = "loud"
+	require.NoError(t, k8sClient.Update(ctx, configMap))
+	assert.Error(t, runtimeLevel.Sync(ctx))
+	assert.NoError(t, runtimeLevel.Sync(ctx))
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	// Deleting the ConfigMap restores the initial level
+	require.NoError(t, k8sClient.Delete(ctx, configMap))
+	require.NoError(t, runtimeLevel.Sync(ctx))
+	assert.Equal(t, zapcore.InfoLevel, level.Level())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// UnsealCommand runs the operator binary as a one-shot unseal CLI.
+	UnsealCommand = "unseal"
+
+	// ExitUnsealed is returned when every instance is unsealed.
+	ExitUnsealed = 0
+	// ExitSealed is returned when at least one instance is still sealed or failed.
+	ExitSealed = 1
+	// ExitUsage is returned for invalid flags or an unreadable config file.
+	ExitUsage = 2
+)
+
+// UnsealConfig holds the configuration of the one-shot unseal command.
+type UnsealConfig struct {
+	File        string
+	Timeout     time.Duration
+	Development bool
+}
+
+// runUnseal unseals the vaults listed in a config file once and returns an exit code.
+func runUnseal(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	config := &UnsealConfig{
+		Timeout:     controller.DefaultTimeoutSeconds * time.Second,
+		Development: true,
+	}
+
+	fs := flag.NewFlagSet(UnsealCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&config.File, "f", "", "VaultUnsealConfig YAML or bare spec file to unseal, '-' for stdin.")
+	fs.DurationVar(&config.Timeout, "timeout", config.Timeout, "Timeout for each VaultUnsealConfig.")
+	fs.BoolVar(&config.Development, "development", config.Development, "Enable development mode for logging.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s %s -f <file> [flags]\n\n", os.Args[0], UnsealCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitUnsealed
+		}
+		return ExitUsage
+	}
+	if config.File == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	logger := zap.New(zap.UseDevMode(config.Development), zap.WriteTo(stderr))
+
+	configs, err := loadUnsealConfigs(config.File)
+	if err != nil {
+		logger.Error(err, "unable to load unseal config", "file", config.File)
+		return ExitUsage
+	}
+
+	return unsealAll(ctx, logger, configs, config.Timeout, stdout)
+}
+
+func loadUnsealConfigs(path string) ([]*vaultv1.VaultUnsealConfig, error) {
+	if path == "-" {
+		return controller.LoadVaultUnsealConfigs(os.Stdin)
+	}
+
+	f, err := os.Open(path) //nolint:gosec // path is supplied by the operator
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return controller.LoadVaultUnsealConfigs(f)
+}
+
+// unsealAll unseals every config once and prints one result row per instance.
+func unsealAll(
+	ctx context.Context,
+	logger logr.Logger,
+	configs []*vaultv1.VaultUnsealConfig,
+	timeout time.Duration,
+	stdout io.Writer,
+) int {
+	repository := controller.NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repository.Close() }()
+
+	options := controller.DefaultReconcilerOptions()
+	options.Timeout = timeout
+
+	exitCode := ExitUnsealed
+	w := tabwriter.NewWriter(stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CONFIG\tINSTANCE\tSEALED\tERROR")
+	for _, vaultConfig := range configs {
+		configLogger := logger.WithValues("config", vaultConfig.Name)
+		statuses, allReady := controller.UnsealOnce(ctx, configLogger, repository, vaultConfig, options)
+		if !allReady {
+			exitCode = ExitSealed
+		}
+		for _, status := range statuses {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", vaultConfig.Name, status.Name, status.Sealed, status.Error)
+		}
+	}
+	_ = w.Flush()
+
+	return exitCode
+}
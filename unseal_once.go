@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// UnsealOnceCommand unseals a single Vault, e.g. the local pod, and exits.
+	// It runs as a Job or a sidecar next to Vault, without a cluster connection.
+	UnsealOnceCommand = "unseal-once"
+
+	// DefaultUnsealKeysDir is where unseal-once reads keys without --keys-dir
+	// or $VAULT_UNSEAL_KEYS_DIR, e.g. the mount of the unseal key Secret.
+	DefaultUnsealKeysDir = "/etc/vault-unseal-keys"
+)
+
+// UnsealOnceConfig holds the configuration of the unseal-once command.
+type UnsealOnceConfig struct {
+	Endpoint      string
+	KeysDir       string
+	Name          string
+	Threshold     int
+	TLSSkipVerify bool
+	Timeout       time.Duration
+	Wait          time.Duration
+	RetryInterval time.Duration
+	Development   bool
+}
+
+// NewUnsealOnceConfig returns the defaults of unseal-once, read from the
+// environment where Vault tooling and the downward API set them.
+func NewUnsealOnceConfig() *UnsealOnceConfig {
+	config := &UnsealOnceConfig{
+		Endpoint:      os.Getenv("VAULT_ADDR"),
+		KeysDir:       os.Getenv("VAULT_UNSEAL_KEYS_DIR"),
+		Name:          os.Getenv("POD_NAME"),
+		Timeout:       controller.DefaultTimeoutSeconds * time.Second,
+		Wait:          5 * time.Minute,
+		RetryInterval: 5 * time.Second,
+		Development:   true,
+	}
+	if config.KeysDir == "" {
+		config.KeysDir = DefaultUnsealKeysDir
+	}
+	if config.Name == "" {
+		config.Name, _ = os.Hostname()
+	}
+	config.TLSSkipVerify, _ = strconv.ParseBool(os.Getenv("VAULT_SKIP_VERIFY"))
+	return config
+}
+
+// runUnsealOnce unseals one Vault with keys from mounted files, retrying
+// while it starts, and returns an exit code.
+func runUnsealOnce(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	config := NewUnsealOnceConfig()
+
+	fs := flag.NewFlagSet(UnsealOnceCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&config.Endpoint, "endpoint", config.Endpoint, "URL of the Vault to unseal (defaults to $VAULT_ADDR).")
+	fs.StringVar(&config.KeysDir, "keys-dir", config.KeysDir,
+		"Directory whose files hold the unseal keys, e.g. a mounted Secret (defaults to $VAULT_UNSEAL_KEYS_DIR).")
+	fs.StringVar(&config.Name, "name", config.Name, "Instance name in logs and output (defaults to $POD_NAME or the hostname).")
+	fs.IntVar(&config.Threshold, "threshold", config.Threshold,
+		"Number of keys to submit; 0 reads the threshold from the seal status.")
+	fs.BoolVar(&config.TLSSkipVerify, "tls-skip-verify", config.TLSSkipVerify,
+		"Disable TLS certificate verification (defaults to $VAULT_SKIP_VERIFY).")
+	fs.DurationVar(&config.Timeout, "timeout", config.Timeout, "Timeout for each unseal attempt.")
+	fs.DurationVar(&config.Wait, "wait", config.Wait, "How long to retry while Vault is unreachable or sealed.")
+	fs.DurationVar(&config.RetryInterval, "retry-interval", config.RetryInterval, "Delay between unseal attempts.")
+	fs.BoolVar(&config.Development, "development", config.Development, "Enable development mode for logging.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s %s [flags]\n\n", os.Args[0], UnsealOnceCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitUnsealed
+		}
+		return ExitUsage
+	}
+	if config.Endpoint == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	logger := zap.New(zap.UseDevMode(config.Development), zap.WriteTo(stderr))
+
+	keys, err := controller.ReadKeyFiles(config.KeysDir)
+	if err != nil {
+		logger.Error(err, "unable to read unseal keys", "dir", config.KeysDir)
+		return ExitUsage
+	}
+
+	instance := vaultv1.VaultInstance{
+		Name:          config.Name,
+		Endpoint:      config.Endpoint,
+		UnsealKeys:    keys,
+		TLSSkipVerify: &config.TLSSkipVerify,
+	}
+	if config.Threshold > 0 {
+		instance.Threshold = &config.Threshold
+	}
+	vaultConfig := &vaultv1.VaultUnsealConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: UnsealOnceCommand},
+		Spec:       vaultv1.VaultUnsealConfigSpec{VaultInstances: []vaultv1.VaultInstance{instance}},
+	}
+
+	repository := controller.NewDefaultVaultClientRepository(nil)
+	defer func() { _ = repository.Close() }()
+	options := controller.DefaultReconcilerOptions()
+	options.Timeout = config.Timeout
+
+	ctx, cancel := context.WithTimeout(ctx, config.Wait)
+	defer cancel()
+	statuses, allReady := controller.UnsealUntilReady(ctx, logger, repository, vaultConfig, options,
+		config.RetryInterval)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "INSTANCE\tSEALED\tERROR")
+	for _, status := range statuses {
+		_, _ = fmt.Fprintf(w, "%s\t%t\t%s\n", status.Name, status.Sealed, status.Error)
+	}
+	_ = w.Flush()
+
+	if !allReady {
+		return ExitSealed
+	}
+	return ExitUnsealed
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	vaultv1 "github.com/panteparak/vault-autounseal-operator/pkg/api/v1"
+	"github.com/panteparak/vault-autounseal-operator/pkg/webhook"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// ValidateCommand runs the operator binary as an offline VaultUnsealConfig linter.
+	ValidateCommand = "validate"
+
+	// ExitValid is returned when every VaultUnsealConfig passes validation.
+	ExitValid = 0
+	// ExitInvalid is returned when at least one VaultUnsealConfig is rejected.
+	ExitInvalid = 1
+)
+
+// runValidate runs the checks of the validating webhook on the
+// VaultUnsealConfigs of local files and returns an exit code.
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	var files []string
+	fs := flag.NewFlagSet(ValidateCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Func("f", "VaultUnsealConfig YAML or bare spec file to validate, '-' for stdin; may be repeated.",
+		func(file string) error {
+			files = append(files, file)
+			return nil
+		})
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s %s -f <file> [-f <file>...]\n\n", os.Args[0], ValidateCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitValid
+		}
+		return ExitUsage
+	}
+	if len(files) == 0 || fs.NArg() > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	exitCode := ExitValid
+	for _, file := range files {
+		configs, err := loadUnsealConfigs(file)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			return ExitUsage
+		}
+		for _, vaultConfig := range configs {
+			if !validateUnsealConfig(file, vaultConfig, stdout) {
+				exitCode = ExitInvalid
+			}
+		}
+	}
+	return exitCode
+}
+
+// validateUnsealConfig prints the result of validating one config, one line
+// per problem, and reports whether it is valid.
+func validateUnsealConfig(file string, vaultConfig *vaultv1.VaultUnsealConfig, stdout io.Writer) bool {
+	name := "VaultUnsealConfig/" + vaultConfig.Name
+	err := webhook.ValidateVaultUnsealConfig(vaultConfig)
+	if err == nil {
+		_, _ = fmt.Fprintf(stdout, "%s: %s: valid\n", file, name)
+		return true
+	}
+
+	var statusErr *apierrors.StatusError
+	if errors.As(err, &statusErr) && statusErr.ErrStatus.Details != nil {
+		for _, cause := range statusErr.ErrStatus.Details.Causes {
+			_, _ = fmt.Fprintf(stdout, "%s: %s: %s: %s\n", file, name, cause.Field, strings.TrimSpace(cause.Message))
+		}
+		return false
+	}
+	_, _ = fmt.Fprintf(stdout, "%s: %s: %v\n", file, name, err)
+	return false
+}